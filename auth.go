@@ -2,9 +2,14 @@ package opensubtitles
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
 )
 
-// Methods related to authentication (Login, Logout, GetUserInfo)
+// Methods related to authentication (Login, Logout, ValidateToken, GetUserInfo)
 
 // Login authenticates the user with username and password, retrieving an API token.
 // The token and the appropriate base URL (e.g., vip-api.opensubtitles.com) are stored
@@ -25,37 +30,92 @@ func (c *Client) Login(ctx context.Context, params LoginRequest) (*LoginResponse
 		// Should ideally not happen if response.BaseURL is valid
 		return nil, err
 	}
+	c.setVIP(response.User.VIP)
+
+	if store := c.config.TokenStore; store != nil {
+		stored := StoredToken{Token: response.Token, BaseURL: response.BaseURL, ExpiresAt: tokenExpiry(response.Token, time.Now())}
+		if err := store.Set(ctx, stored); err != nil {
+			c.logger.Warn("opensubtitles: failed to store token", "error", err)
+		}
+	}
 
 	return &response, nil
 }
 
+// reauthenticate logs in again with Config.Credentials after a request sent with
+// staleToken was rejected. Concurrent callers share a single login: if the token has
+// changed since the failed request, it was already refreshed and is reused.
+func (c *Client) reauthenticate(ctx context.Context, staleToken string) error {
+	c.reauthMu.Lock()
+	defer c.reauthMu.Unlock()
+	if current := c.GetCurrentToken(); current != nil && *current != "" && *current != staleToken {
+		return nil
+	}
+	_, err := c.Login(ctx, *c.config.Credentials)
+	return err
+}
+
 // Logout invalidates the current API token.
-// It clears the token stored internally in the client.
+// It clears the token stored internally in the client, and in Config.TokenStore, and
+// switches back from the host assigned at login to the configured base URL. This
+// happens even if the API call fails, so that a token the caller gave up on is never
+// sent again; the error is still returned. A 401 response to the token means it is
+// already invalid and counts as a successful logout, with Status 401.
 func (c *Client) Logout(ctx context.Context) (*LogoutResponse, error) {
-	// Check if authenticated before attempting logout?
-	// The API might return an error anyway if no valid token is provided.
-	// Let the httpClient handle adding the token header if it exists.
-	// if !c.isAuthenticated() {
-	// 	return nil, errors.New("not logged in")
-	// }
-
 	var response LogoutResponse
 	err := c.httpClient.Delete(ctx, "/logout", &response)
+	var apiErr *APIError
+	if errors.Is(err, ErrTokenExpired) && errors.As(err, &apiErr) {
+		response = LogoutResponse{Message: apiErr.Message, Status: apiErr.StatusCode}
+		err = nil
+	}
+
+	c.clearToken(ctx)
 	if err != nil {
-		// Don't clear the token if the API call failed,
-		// as the token might still be valid.
 		return nil, err
 	}
+	return &response, nil
+}
 
-	// Clear the internal token on successful logout
-	_ = c.SetAuthToken("", "") // Reset token, keep base URL
+// clearToken forgets the token locally and in the TokenStore and switches back to
+// the configured base URL.
+func (c *Client) clearToken(ctx context.Context) {
+	_ = c.SetAuthToken("", "")
+	c.resetBaseURL()
+	if store := c.config.TokenStore; store != nil {
+		if err := store.Clear(ctx); err != nil {
+			c.logger.Warn("opensubtitles: failed to clear stored token", "error", err)
+		}
+	}
+}
 
-	return &response, nil
+// ValidateToken checks that the API still accepts the current token by calling the
+// cheap /infos/user endpoint, without logging in again through Config.Credentials.
+// Apps can call it in the background, e.g. on start or resume, to detect a stale
+// session before a user-facing call fails. It returns nil for a valid token, an
+// error matching ErrTokenExpired for a rejected one, ErrLoginRequired without a
+// token, and other errors, e.g. network failures, as they occur. The token is kept
+// either way; call Logout or Login to replace it. Like GetUserInfo, a successful
+// check updates LastDownloadQuota.
+func (c *Client) ValidateToken(ctx context.Context) error {
+	if !c.isAuthenticated() {
+		return fmt.Errorf("%w: ValidateToken needs a token", ErrLoginRequired)
+	}
+	var response GetUserInfoResponse
+	if err := c.httpClient.Get(httpclient.WithoutReauthentication(ctx), "/infos/user", nil, &response); err != nil {
+		return err
+	}
+	c.reportQuota(ctx, response.Data.RemainingDownloads, time.Time{})
+	c.setVIP(response.Data.VIP)
+	return nil
 }
 
 // GetUserInfo retrieves information about the currently authenticated user.
 // Requires authentication (a valid token must be set in the client).
 func (c *Client) GetUserInfo(ctx context.Context) (*GetUserInfoResponse, error) {
+	if err := c.requireLogin("GetUserInfo"); err != nil {
+		return nil, err
+	}
 	// No client-side check for auth needed here. If token is missing/invalid,
 	// the httpclient will make the request without Authorization header (or with invalid one),
 	// and the API will return a 401, which httpclient transforms into an error.
@@ -64,6 +124,8 @@ func (c *Client) GetUserInfo(ctx context.Context) (*GetUserInfoResponse, error)
 	if err != nil {
 		return nil, err
 	}
+	c.reportQuota(ctx, response.Data.RemainingDownloads, time.Time{})
+	c.setVIP(response.Data.VIP)
 
 	return &response, nil
 }
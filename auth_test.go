@@ -5,14 +5,21 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"sync"
+	"sync/atomic"
 	"testing"
-
-	// "time"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// testRateLimits keeps client-side throttling and 429 backoff negligible in tests.
+var testRateLimits = RateLimitConfig{
+	Default: 1000, Login: 1000, Download: 1000, Burst: 100,
+	MaxRetries: 3, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond,
+}
+
 // Helper to create a mock server and client for tests
 func setupTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server, *Client) {
 	server := httptest.NewServer(handler)
@@ -22,6 +29,7 @@ func setupTestServer(t *testing.T, handler http.HandlerFunc) (*httptest.Server,
 		ApiKey:    "test-api-key",
 		UserAgent: "GoTestClient/1.0",
 		BaseURL:   server.URL + "/api/v1", // Point client to mock server, assuming base path
+		RateLimit: &testRateLimits,
 	}
 	client, err := NewClient(config)
 	require.NoError(t, err, "Failed to create client for test")
@@ -189,6 +197,87 @@ func TestLogout(t *testing.T) {
 	})
 }
 
+func TestLogoutClearsTokenOnFailure(t *testing.T) {
+	t.Run("RejectedTokenCountsAsLoggedOut", func(t *testing.T) {
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"message":"invalid token"}`))
+		})
+		require.NoError(t, client.SetAuthToken("stale", ""))
+
+		resp, err := client.Logout(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &LogoutResponse{Message: "invalid token", Status: http.StatusUnauthorized}, resp)
+		assert.False(t, client.isAuthenticated())
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		})
+		require.NoError(t, client.SetAuthToken("tok", ""))
+
+		_, err := client.Logout(context.Background())
+		assert.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.False(t, client.isAuthenticated(), "the token is cleared even though the call failed")
+	})
+}
+
+func TestValidateToken(t *testing.T) {
+	var logins int
+	valid := "valid"
+	server, _ := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			logins++
+			_, _ = w.Write([]byte(`{"token":"fresh","status":200}`))
+		case "/api/v1/infos/user":
+			if r.Header.Get("Authorization") != "Bearer "+valid {
+				w.WriteHeader(http.StatusUnauthorized)
+				_, _ = w.Write([]byte(`{"message":"invalid token"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"remaining_downloads":17}}`))
+		}
+	})
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits,
+		Credentials: &LoginRequest{Username: "user", Password: "pass"}})
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, client.ValidateToken(context.Background()), ErrLoginRequired)
+
+	require.NoError(t, client.SetAuthToken(valid, ""))
+	require.NoError(t, client.ValidateToken(context.Background()))
+	quota, ok := client.LastDownloadQuota()
+	require.True(t, ok)
+	assert.Equal(t, 17, quota.Remaining)
+
+	valid = "rotated"
+	assert.ErrorIs(t, client.ValidateToken(context.Background()), ErrTokenExpired)
+	assert.Zero(t, logins, "a stale token is reported, not replaced by logging in again")
+	assert.Equal(t, "valid", *client.GetCurrentToken())
+}
+
+func TestLogoutRestoresConfiguredBaseURL(t *testing.T) {
+	vip := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/logout", r.URL.Path)
+		_, _ = w.Write([]byte(`{"message":"token successfully destroyed","status":200}`))
+	}))
+	t.Cleanup(vip.Close)
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("logout must go to the host assigned at login")
+	})
+	configured := client.GetCurrentBaseURL()
+
+	// The host assigned at login, as SetAuthToken receives it from Login.
+	require.NoError(t, client.SetAuthToken("tok", vip.URL))
+	assert.Equal(t, vip.URL+"/api/v1", client.GetCurrentBaseURL())
+
+	_, err := client.Logout(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, configured, client.GetCurrentBaseURL())
+}
+
 func TestGetUserInfoSuccess(t *testing.T) {
 	token := "valid-user-token"
 	expectedRemaining := 99
@@ -245,3 +334,68 @@ func TestGetUserInfoRequiresAuth(t *testing.T) {
 	assert.Nil(t, userInfo)
 	assert.Contains(t, err.Error(), "status 401") // Expect API 401
 }
+
+func TestReauthenticateOn401(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc, credentials *LoginRequest) *Client {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", Credentials: credentials, RateLimit: &testRateLimits})
+		require.NoError(t, err)
+		require.NoError(t, client.SetAuthToken("expired", ""))
+		return client
+	}
+
+	t.Run("RetriesOnceWithFreshToken", func(t *testing.T) {
+		var logins int32
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v1/login":
+				atomic.AddInt32(&logins, 1)
+				_, _ = w.Write([]byte(`{"token":"fresh","status":200}`))
+			case "/api/v1/infos/user":
+				if r.Header.Get("Authorization") != "Bearer fresh" {
+					http.Error(w, `{"message":"token expired"}`, http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":{"user_id":7}}`))
+			}
+		}
+		client := newClient(t, handler, &LoginRequest{Username: "user", Password: "pass"})
+
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				info, err := client.GetUserInfo(context.Background())
+				if assert.NoError(t, err) {
+					assert.Equal(t, 7, info.Data.UserID)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), atomic.LoadInt32(&logins), "concurrent 401s share one re-login")
+		assert.Equal(t, "fresh", *client.GetCurrentToken())
+	})
+
+	t.Run("WithoutCredentials", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.NotEqual(t, "/api/v1/login", r.URL.Path, "must not log in without credentials")
+			http.Error(w, `{"message":"token expired"}`, http.StatusUnauthorized)
+		}, nil)
+		_, err := client.GetUserInfo(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+	})
+
+	t.Run("ReloginFails", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			http.Error(w, `{"message":"invalid"}`, http.StatusUnauthorized)
+		}, &LoginRequest{Username: "user", Password: "wrong"})
+		_, err := client.GetUserInfo(context.Background())
+		require.ErrorIs(t, err, ErrUnauthorized)
+		assert.Contains(t, err.Error(), "re-authentication failed")
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "original request and one login, no retry")
+	})
+}
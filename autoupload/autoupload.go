@@ -0,0 +1,411 @@
+// Package autoupload uploads the subtitles that appear in a "completed" downloads
+// folder. A Pipeline polls the folder, pairs every subtitle with the video it came
+// with, consolidates the metadata of the pair, queues the upload in a batch.Runner
+// and, once uploaded, moves the pair to an archive folder:
+//
+//	pipeline, err := autoupload.New(uploader, autoupload.Options{
+//		Dir:        "/downloads/completed",
+//		ArchiveDir: "/downloads/uploaded",
+//		Resolvers:  registry.Chain(config),
+//		Confirm:    func(intent upload.UserUploadIntent) bool { return ask(intent) },
+//	})
+//	err = pipeline.Run(ctx)
+package autoupload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/batch"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/langprofile"
+	"github.com/angelospk/opensubtitles-go/metadata"
+	"github.com/angelospk/opensubtitles-go/scanner"
+	"github.com/angelospk/opensubtitles-go/upload"
+)
+
+// Defaults of Options.
+const (
+	DefaultPollInterval = time.Minute
+	DefaultSettleTime   = 30 * time.Second
+)
+
+// ErrNoDir is returned by New when Options.Dir is empty.
+var ErrNoDir = errors.New("autoupload: no directory to watch")
+
+// Uploader is the part of upload.Uploader used by the Pipeline. It must be logged in.
+type Uploader interface {
+	Upload(intent upload.UserUploadIntent) (*upload.UploadResult, error)
+}
+
+// Ensure the XML-RPC uploader can be used by the Pipeline.
+var _ Uploader = (upload.Uploader)(nil)
+
+// Options configures a Pipeline.
+type Options struct {
+	// Dir is the folder of completed downloads, watched recursively. Directories
+	// named "sample" or starting with "." are skipped.
+	Dir string
+	// ArchiveDir receives the uploaded pairs, at the same path relative to Dir.
+	// Empty leaves them in place; they are not uploaded twice while the checkpoint
+	// is kept.
+	ArchiveDir string
+	// Language is the upload language of subtitles whose file name has none, such as
+	// "Heat.srt" (see langprofile.LanguageFromFilename). Empty skips such subtitles.
+	Language string
+	// Resolvers identify the videos, e.g. from a metadata.Registry; see
+	// metadata.ConsolidateBatch.
+	Resolvers metadata.Chain
+	// Consolidate configures the metadata consolidation, e.g. its Prober.
+	Consolidate metadata.ConcurrencyOpts
+	// DetectFlags sets the hearing impaired and forced flags from the cues of the
+	// subtitles; see upload.DetectFlags.
+	DetectFlags bool
+	// Confirm, if set, is called with every consolidated intent before it is
+	// uploaded, e.g. to show a preview. Returning false skips the pair and leaves
+	// it in place; it is not asked for again while the checkpoint is kept.
+	Confirm func(intent upload.UserUploadIntent) bool
+	// DryRun only consolidates the pairs and reports them to OnResult; nothing is
+	// uploaded, queued or moved.
+	DryRun bool
+	// CheckpointPath is the JSON file of the upload queue; see batch.Options.
+	// Empty keeps the queue in memory only.
+	CheckpointPath string
+	// MaxAttempts marks an upload failed after this many errors (default:
+	// batch.DefaultMaxAttempts).
+	MaxAttempts int
+	// PollInterval is how often Dir is scanned (default: DefaultPollInterval).
+	PollInterval time.Duration
+	// SettleTime is how long a video and its subtitle must have been unmodified
+	// before they are processed, so that files still being moved in are not read
+	// (default: DefaultSettleTime).
+	SettleTime time.Duration
+	// OnResult, if set, is called with the outcome of every pair.
+	OnResult func(Result)
+	// Logger receives a debug record per pair and a warning per failure. Nil
+	// disables logging.
+	Logger *slog.Logger
+}
+
+// Result is the outcome of processing one pair.
+type Result struct {
+	Pair   metadata.VideoSubtitlePair
+	Intent *upload.UserUploadIntent // The consolidated intent; nil if none was built
+	// Upload is the uploaded subtitle; nil on a dry run, a declined or duplicate
+	// upload, or an error.
+	Upload   *upload.UploadResult
+	Declined bool // Options.Confirm returned false
+	// Duplicate is set if the subtitle was already in the database. The pair is
+	// archived like an uploaded one.
+	Duplicate bool
+	Archived  bool
+	Err       error
+}
+
+// Pipeline uploads the subtitles of the completed downloads folder.
+type Pipeline struct {
+	uploader Uploader
+	opts     Options
+	queue    *batch.Runner
+	reported map[string]bool // Pairs reported by a dry run
+
+	now func() time.Time
+}
+
+// New creates a Pipeline, restoring the upload queue from Options.CheckpointPath.
+func New(uploader Uploader, opts Options) (*Pipeline, error) {
+	if opts.Dir == "" {
+		return nil, ErrNoDir
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.SettleTime <= 0 {
+		opts.SettleTime = DefaultSettleTime
+	}
+	opts.Logger = logging.OrDiscard(opts.Logger)
+	queue, err := batch.New(batch.Options{
+		CheckpointPath: opts.CheckpointPath,
+		MaxAttempts:    opts.MaxAttempts,
+		Logger:         opts.Logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("autoupload: %w", err)
+	}
+	return &Pipeline{
+		uploader: uploader,
+		opts:     opts,
+		queue:    queue,
+		reported: make(map[string]bool),
+		now:      time.Now,
+	}, nil
+}
+
+// Run processes the folder every Options.PollInterval until ctx ends and returns
+// ctx.Err(), or a checkpoint file error. Failures of single pairs are reported to
+// Options.OnResult and logged, not returned.
+func (p *Pipeline) Run(ctx context.Context) error {
+	ticker := time.NewTicker(p.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		if err := p.RunOnce(ctx); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce scans the folder, consolidates and queues the settled pairs that are new,
+// and uploads everything queued.
+func (p *Pipeline) RunOnce(ctx context.Context) error {
+	pairs, err := p.newPairs()
+	if err != nil {
+		return err
+	}
+	if len(pairs) > 0 {
+		if err := p.enqueue(ctx, pairs); err != nil {
+			return err
+		}
+	}
+	if p.opts.DryRun {
+		return nil
+	}
+	return p.queue.Run(ctx, p.upload)
+}
+
+// Checkpoint returns the upload queue.
+func (p *Pipeline) Checkpoint() batch.Checkpoint {
+	return p.queue.Checkpoint()
+}
+
+// enqueue consolidates pairs and queues their uploads, keyed by subtitle path.
+func (p *Pipeline) enqueue(ctx context.Context, pairs []metadata.VideoSubtitlePair) error {
+	results, err := metadata.ConsolidateBatch(ctx, pairs, p.opts.Resolvers, p.opts.Consolidate)
+	if err != nil {
+		return err
+	}
+	var items []batch.Item
+	for _, consolidated := range results {
+		result := Result{Pair: consolidated.Pair, Intent: consolidated.Intent, Err: consolidated.Err}
+		if result.Err == nil {
+			result.Err = p.complete(result.Intent)
+		}
+		if result.Err != nil {
+			// Not queued, so the pair is tried again by the next poll.
+			p.opts.Logger.Warn("autoupload: failed to consolidate pair", "subtitle", result.Pair.SubtitlePath, "error", result.Err)
+			p.report(result)
+			continue
+		}
+		if p.opts.DryRun {
+			p.reported[result.Pair.SubtitlePath] = true
+			p.report(result)
+			continue
+		}
+		item, err := batch.NewItem(result.Pair.SubtitlePath, result.Intent)
+		if err != nil {
+			return fmt.Errorf("autoupload: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := p.queue.Add(items...); err != nil {
+		return fmt.Errorf("autoupload: %w", err)
+	}
+	return nil
+}
+
+// complete sets the language and the flags of a consolidated intent.
+func (p *Pipeline) complete(intent *upload.UserUploadIntent) error {
+	intent.LanguageID = p.opts.Language
+	if lang, ok := langprofile.LanguageFromFilename(intent.SubtitleFilePath); ok {
+		intent.LanguageID = lang
+	}
+	if intent.LanguageID == "" {
+		return fmt.Errorf("no language in subtitle file name '%s'", intent.SubtitleFileName)
+	}
+	if p.opts.DetectFlags {
+		if _, err := upload.DetectFlags(intent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upload is the batch.Task of the queue: it confirms, uploads and archives one pair.
+func (p *Pipeline) upload(ctx context.Context, item batch.Item) error {
+	var intent upload.UserUploadIntent
+	if err := item.Decode(&intent); err != nil {
+		return err
+	}
+	result := Result{
+		Pair:   metadata.VideoSubtitlePair{VideoPath: intent.VideoFilePath, SubtitlePath: intent.SubtitleFilePath},
+		Intent: &intent,
+	}
+	if p.opts.Confirm != nil && !p.opts.Confirm(intent) {
+		result.Declined = true
+		p.opts.Logger.Debug("autoupload: upload declined", "subtitle", intent.SubtitleFilePath)
+		p.report(result)
+		return nil
+	}
+	result.Upload, result.Err = p.uploader.Upload(intent)
+	if errors.Is(result.Err, upload.ErrUploadDuplicate) {
+		result.Duplicate, result.Err = true, nil
+	}
+	if result.Err == nil {
+		result.Err = p.archive(item.Key, intent)
+		result.Archived = result.Err == nil && p.opts.ArchiveDir != ""
+	}
+	if result.Err != nil {
+		p.opts.Logger.Warn("autoupload: failed to upload pair", "subtitle", intent.SubtitleFilePath, "error", result.Err)
+	} else {
+		p.opts.Logger.Debug("autoupload: uploaded pair", "subtitle", intent.SubtitleFilePath, "duplicate", result.Duplicate)
+	}
+	p.report(result)
+	return result.Err
+}
+
+// archive moves the subtitle of an uploaded pair to Options.ArchiveDir, and the video
+// too once no other queued subtitle of it is left.
+func (p *Pipeline) archive(key string, intent upload.UserUploadIntent) error {
+	if p.opts.ArchiveDir == "" {
+		return nil
+	}
+	if err := p.move(intent.SubtitleFilePath); err != nil {
+		return err
+	}
+	if intent.VideoFilePath == "" {
+		return nil
+	}
+	for _, item := range p.queue.Checkpoint().Items {
+		if item.Key == key || item.Status != batch.StatusPending {
+			continue
+		}
+		var other upload.UserUploadIntent
+		if item.Decode(&other) == nil && other.VideoFilePath == intent.VideoFilePath {
+			return nil
+		}
+	}
+	if _, err := os.Stat(intent.VideoFilePath); errors.Is(err, fs.ErrNotExist) {
+		return nil // Archived with an earlier subtitle
+	}
+	return p.move(intent.VideoFilePath)
+}
+
+// move moves path from Options.Dir to the same relative path in Options.ArchiveDir.
+func (p *Pipeline) move(path string) error {
+	rel, err := filepath.Rel(p.opts.Dir, path)
+	if err != nil {
+		return fmt.Errorf("failed to archive '%s': %w", path, err)
+	}
+	dest := filepath.Join(p.opts.ArchiveDir, rel)
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("failed to archive '%s': %w", path, err)
+	}
+	if err := os.Rename(path, dest); err != nil {
+		return fmt.Errorf("failed to archive '%s': %w", path, err)
+	}
+	return nil
+}
+
+// newPairs returns the settled pairs of the folder that are neither queued nor
+// reported by a dry run.
+func (p *Pipeline) newPairs() ([]metadata.VideoSubtitlePair, error) {
+	queued := make(map[string]bool)
+	for _, item := range p.queue.Checkpoint().Items {
+		queued[item.Key] = true
+	}
+	all, err := p.pairs()
+	if err != nil {
+		return nil, err
+	}
+	settled := p.now().Add(-p.opts.SettleTime)
+	var pairs []metadata.VideoSubtitlePair
+	for _, pair := range all {
+		if queued[pair.SubtitlePath] || p.reported[pair.SubtitlePath] {
+			continue
+		}
+		if modifiedAfter(pair.SubtitlePath, settled) || (pair.VideoPath != "" && modifiedAfter(pair.VideoPath, settled)) {
+			continue
+		}
+		pairs = append(pairs, pair)
+	}
+	return pairs, nil
+}
+
+// pairs walks the folder and pairs every subtitle with a video of its directory: the
+// video whose name without extension is the longest prefix of the subtitle's name,
+// such as "Heat.1995.mkv" for "Heat.1995.en.srt", or else the only video of the
+// directory, since subtitles of a release are often named differently. Subtitles
+// without a video are paired with none; metadata then resolves the subtitle's name.
+func (p *Pipeline) pairs() ([]metadata.VideoSubtitlePair, error) {
+	videos := make(map[string][]string) // Directory -> video paths
+	var subtitles []string
+	err := filepath.WalkDir(p.opts.Dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != p.opts.Dir && (strings.HasPrefix(name, ".") || strings.EqualFold(name, "sample")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		switch {
+		case !d.Type().IsRegular():
+		case langprofile.IsSubtitleFile(d.Name()):
+			subtitles = append(subtitles, path)
+		case scanner.IsVideoFile(d.Name()):
+			videos[filepath.Dir(path)] = append(videos[filepath.Dir(path)], path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("autoupload: failed to walk '%s': %w", p.opts.Dir, err)
+	}
+	sort.Strings(subtitles)
+	pairs := make([]metadata.VideoSubtitlePair, 0, len(subtitles))
+	for _, sub := range subtitles {
+		pairs = append(pairs, metadata.VideoSubtitlePair{VideoPath: videoOf(sub, videos[filepath.Dir(sub)]), SubtitlePath: sub})
+	}
+	return pairs, nil
+}
+
+// videoOf returns the video of the subtitle sub among the videos of its directory.
+func videoOf(sub string, videos []string) string {
+	name := strings.ToLower(filepath.Base(sub))
+	best := ""
+	for _, video := range videos {
+		stem := strings.ToLower(strings.TrimSuffix(filepath.Base(video), filepath.Ext(video)))
+		if strings.HasPrefix(name, stem+".") && len(video) > len(best) {
+			best = video
+		}
+	}
+	if best == "" && len(videos) == 1 {
+		best = videos[0]
+	}
+	return best
+}
+
+// modifiedAfter reports whether the file at path was modified after t, or cannot be read.
+func modifiedAfter(path string, t time.Time) bool {
+	info, err := os.Stat(path)
+	return err != nil || info.ModTime().After(t)
+}
+
+func (p *Pipeline) report(result Result) {
+	if p.opts.OnResult != nil {
+		p.opts.OnResult(result)
+	}
+}
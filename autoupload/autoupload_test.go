@@ -0,0 +1,151 @@
+package autoupload
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/batch"
+	"github.com/angelospk/opensubtitles-go/upload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeUploader records the uploads and rejects the subtitles in duplicates.
+type fakeUploader struct {
+	intents    []upload.UserUploadIntent
+	duplicates map[string]bool
+}
+
+func (u *fakeUploader) Upload(intent upload.UserUploadIntent) (*upload.UploadResult, error) {
+	u.intents = append(u.intents, intent)
+	if u.duplicates[intent.SubtitleFileName] {
+		return nil, upload.ErrUploadDuplicate
+	}
+	return &upload.UploadResult{SubtitleID: len(u.intents)}, nil
+}
+
+// writeFiles creates the files under dir, last modified at modTime.
+func writeFiles(t *testing.T, dir string, modTime time.Time, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:02,000\nHi\n"), 0o644))
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	dir, archive := t.TempDir(), t.TempDir()
+	now := time.Now()
+	old := now.Add(-time.Hour)
+	writeFiles(t, dir, old,
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.mkv",
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.en.srt",
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.el.srt",
+		"Ronin/Ronin.1998.mkv",
+		"Ronin/subs.srt", // The only video of the directory
+		"Sample/Ronin.sample.en.srt",
+	)
+	writeFiles(t, dir, now, "Alien/Alien.1979.mkv", "Alien/Alien.1979.en.srt") // Still being copied
+
+	uploader := &fakeUploader{duplicates: map[string]bool{"Heat.1995.1080p.BluRay.x264-GROUP.el.srt": true}}
+	var results []Result
+	p, err := New(uploader, Options{
+		Dir:        dir,
+		ArchiveDir: archive,
+		Language:   "fr",
+		OnResult:   func(r Result) { results = append(results, r) },
+	})
+	require.NoError(t, err)
+	p.now = func() time.Time { return now }
+
+	require.NoError(t, p.RunOnce(context.Background()))
+	require.Len(t, uploader.intents, 3)
+	heatEL, heatEN, ronin := uploader.intents[0], uploader.intents[1], uploader.intents[2]
+	assert.Equal(t, filepath.Join(dir, "Heat (1995)", "Heat.1995.1080p.BluRay.x264-GROUP.mkv"), heatEN.VideoFilePath)
+	assert.Equal(t, heatEN.VideoFilePath, heatEL.VideoFilePath)
+	assert.Equal(t, "en", heatEN.LanguageID)
+	assert.Equal(t, "Heat.1995.1080p.BluRay.x264-GROUP", heatEN.ReleaseName, "metadata is consolidated")
+	assert.Equal(t, filepath.Join(dir, "Ronin", "Ronin.1998.mkv"), ronin.VideoFilePath)
+	assert.Equal(t, "fr", ronin.LanguageID, "Options.Language applies without a language in the name")
+
+	require.Len(t, results, 3)
+	assert.True(t, results[0].Duplicate, "duplicates are archived like uploads")
+	for _, r := range results {
+		assert.NoError(t, r.Err)
+		assert.True(t, r.Archived)
+	}
+	for _, name := range []string{
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.mkv",
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.en.srt",
+		"Heat (1995)/Heat.1995.1080p.BluRay.x264-GROUP.el.srt",
+		"Ronin/Ronin.1998.mkv",
+		"Ronin/subs.srt",
+	} {
+		assert.FileExists(t, filepath.Join(archive, name))
+		assert.NoFileExists(t, filepath.Join(dir, name))
+	}
+	assert.FileExists(t, filepath.Join(dir, "Alien", "Alien.1979.en.srt"), "unsettled pairs wait")
+	assert.FileExists(t, filepath.Join(dir, "Sample", "Ronin.sample.en.srt"), "samples are skipped")
+
+	now = now.Add(time.Minute)
+	require.NoError(t, p.RunOnce(context.Background()))
+	require.Len(t, uploader.intents, 4)
+	assert.Equal(t, "Alien.1979.en.srt", uploader.intents[3].SubtitleFileName)
+	assert.Equal(t, 4, p.Checkpoint().Count(batch.StatusDone))
+}
+
+func TestPipelineConfirmAndDryRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, time.Now().Add(-time.Hour), "Heat.1995.mkv", "Heat.1995.en.srt", "Heat.1995.srt")
+
+	t.Run("DryRun", func(t *testing.T) {
+		uploader := &fakeUploader{}
+		var results []Result
+		p, err := New(uploader, Options{Dir: dir, DryRun: true, OnResult: func(r Result) { results = append(results, r) }})
+		require.NoError(t, err)
+
+		require.NoError(t, p.RunOnce(context.Background()))
+		assert.Empty(t, uploader.intents)
+		require.Len(t, results, 2)
+		assert.Equal(t, "en", results[0].Intent.LanguageID)
+		assert.ErrorContains(t, results[1].Err, "no language in subtitle file name 'Heat.1995.srt'")
+		assert.Empty(t, p.Checkpoint().Items, "nothing is queued")
+
+		require.NoError(t, p.RunOnce(context.Background()))
+		assert.Len(t, results, 3, "reported pairs are not reported again, failed ones are")
+	})
+
+	t.Run("Declined", func(t *testing.T) {
+		uploader := &fakeUploader{}
+		var asked []string
+		var results []Result
+		p, err := New(uploader, Options{
+			Dir:      dir,
+			Language: "el",
+			Confirm: func(intent upload.UserUploadIntent) bool {
+				asked = append(asked, intent.SubtitleFileName)
+				return intent.LanguageID == "en"
+			},
+			OnResult: func(r Result) { results = append(results, r) },
+		})
+		require.NoError(t, err)
+
+		require.NoError(t, p.RunOnce(context.Background()))
+		assert.Equal(t, []string{"Heat.1995.en.srt", "Heat.1995.srt"}, asked)
+		require.Len(t, uploader.intents, 1)
+		require.Len(t, results, 2)
+		assert.True(t, results[1].Declined)
+		assert.FileExists(t, filepath.Join(dir, "Heat.1995.srt"), "declined pairs stay in place")
+
+		require.NoError(t, p.RunOnce(context.Background()))
+		assert.Len(t, asked, 2, "declined pairs are not asked for again")
+	})
+
+	_, err := New(&fakeUploader{}, Options{})
+	assert.ErrorIs(t, err, ErrNoDir)
+}
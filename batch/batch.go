@@ -0,0 +1,322 @@
+// Package batch runs long jobs over many items, such as downloading subtitles for the
+// thousands of videos found by a library scan, and checkpoints their progress to a JSON
+// file. An interrupted run resumes where it stopped: finished items are skipped, and
+// an exhausted download quota is waited out before any new request is made.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/internal/jobstate"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// DefaultMaxAttempts is how often a failing item is tried when Options.MaxAttempts is not set.
+const DefaultMaxAttempts = jobstate.DefaultMaxAttempts
+
+// DefaultCheckpointEvery is after how many finished items the checkpoint is saved when
+// Options.CheckpointEvery is not set.
+const DefaultCheckpointEvery = 10
+
+// DefaultCheckpointInterval is how long finished items may stay unsaved when
+// Options.CheckpointInterval is not set.
+const DefaultCheckpointInterval = 30 * time.Second
+
+// DefaultQuotaWait is how long the Runner pauses when the quota is exhausted and the
+// API did not report when it resets.
+const DefaultQuotaWait = jobstate.DefaultQuotaWait
+
+// Status is the state of an Item.
+type Status = jobstate.Status
+
+const (
+	StatusPending = jobstate.StatusPending
+	StatusDone    = jobstate.StatusDone
+	StatusFailed  = jobstate.StatusFailed // Gave up after Options.MaxAttempts
+)
+
+// Item is a unit of work with its progress. Data holds the caller's payload, e.g. an
+// encoded scanner.MissingSubtitle, so a resumed run needs nothing but the checkpoint.
+type Item struct {
+	Key      string          `json:"key"` // Unique within a Runner
+	Data     json.RawMessage `json:"data,omitempty"`
+	Status   Status          `json:"status"`
+	Attempts int             `json:"attempts"`
+	Error    string          `json:"error,omitempty"` // Last failure
+}
+
+// NewItem returns a pending Item for key with data encoded as JSON.
+func NewItem(key string, data interface{}) (Item, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return Item{}, fmt.Errorf("batch: failed to encode data of item '%s': %w", key, err)
+	}
+	return Item{Key: key, Data: raw, Status: StatusPending}, nil
+}
+
+// Decode decodes the item's Data into v.
+func (it Item) Decode(v interface{}) error {
+	if err := json.Unmarshal(it.Data, v); err != nil {
+		return fmt.Errorf("batch: failed to decode data of item '%s': %w", it.Key, err)
+	}
+	return nil
+}
+
+// Checkpoint is the persisted progress of a batch and the last known download quota.
+type Checkpoint struct {
+	Items []Item `json:"items"`
+	// Remaining is the download quota left after the last item, or -1 if unknown.
+	Remaining int       `json:"remaining"`
+	ResetTime time.Time `json:"reset_time"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+// Count returns the number of items with status.
+func (c Checkpoint) Count(status Status) int {
+	n := 0
+	for _, item := range c.Items {
+		if item.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Task processes a single item. Returning an error wrapping
+// opensubtitles.ErrQuotaExceeded pauses the Runner until the quota resets and retries
+// the item without counting an attempt. Tasks should be idempotent, since items that
+// finished after the last checkpoint are run again after a crash.
+type Task func(ctx context.Context, item Item) error
+
+// Options configures a Runner.
+type Options struct {
+	// CheckpointPath is the JSON file progress is saved to and loaded from by New.
+	// Empty keeps the progress in memory only.
+	CheckpointPath string
+	// FS holds the checkpoint file. Defaults to vfs.OS.
+	FS vfs.FS
+	// CheckpointEvery saves the checkpoint after this many finished items
+	// (default: DefaultCheckpointEvery).
+	CheckpointEvery int
+	// CheckpointInterval saves the checkpoint once this long has passed since the
+	// last save, whatever the number of finished items (default: DefaultCheckpointInterval).
+	CheckpointInterval time.Duration
+	// MaxAttempts marks an item failed after this many errors (default: DefaultMaxAttempts).
+	// Quota errors do not count as attempts.
+	MaxAttempts int
+	// Quota, if set, reports the download quota after every item; pass the client's
+	// LastDownloadQuota. The Runner then pauses as soon as the quota is used up instead
+	// of waiting for a task to fail.
+	Quota func() (opensubtitles.DownloadQuota, bool)
+	// OnItem, if set, is called after every item is run, with its new progress.
+	OnItem func(item Item)
+	// OnPause, if set, is called before the Runner waits for the quota to reset.
+	OnPause func(until time.Time)
+	// Logger receives a debug record per item and an info record per pause.
+	// Nil disables logging.
+	Logger *slog.Logger
+}
+
+// Runner runs a task over its items in order and checkpoints the progress.
+// It is safe for concurrent use, but only one Run may be active at a time.
+type Runner struct {
+	opts Options
+
+	mu         sync.Mutex // Protects checkpoint, keys, unsaved and lastSave
+	checkpoint Checkpoint
+	keys       map[string]bool
+	unsaved    int // Items finished since the last save
+	lastSave   time.Time
+	file       jobstate.File
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a Runner, restoring the progress from opts.CheckpointPath if the file exists.
+func New(opts Options) (*Runner, error) {
+	if opts.FS == nil {
+		opts.FS = vfs.OS
+	}
+	if opts.CheckpointEvery <= 0 {
+		opts.CheckpointEvery = DefaultCheckpointEvery
+	}
+	if opts.CheckpointInterval <= 0 {
+		opts.CheckpointInterval = DefaultCheckpointInterval
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	opts.Logger = logging.OrDiscard(opts.Logger)
+	r := &Runner{
+		opts:       opts,
+		checkpoint: Checkpoint{Remaining: -1},
+		keys:       make(map[string]bool),
+		file:       jobstate.File{FS: opts.FS, Path: opts.CheckpointPath, Kind: "checkpoint"},
+		now:        time.Now,
+		sleep:      jobstate.Sleep,
+	}
+	if err := r.file.Load(&r.checkpoint); err != nil {
+		return nil, fmt.Errorf("batch: %w", err)
+	}
+	for _, item := range r.checkpoint.Items {
+		r.keys[item.Key] = true
+	}
+	r.lastSave = r.now()
+	return r, nil
+}
+
+// Add appends items to the batch and saves the checkpoint. Items whose key is already
+// in the batch are ignored, so a resumed program may add the same items again, e.g.
+// from a new scan of the same library.
+func (r *Runner) Add(items ...Item) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, item := range items {
+		if item.Key == "" {
+			return errors.New("batch: item key is required")
+		}
+		if r.keys[item.Key] {
+			continue
+		}
+		r.keys[item.Key] = true
+		if item.Status == "" {
+			item.Status = StatusPending
+		}
+		r.checkpoint.Items = append(r.checkpoint.Items, item)
+	}
+	return r.saveLocked()
+}
+
+// Checkpoint returns a copy of the current progress.
+func (r *Runner) Checkpoint() Checkpoint {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checkpoint := r.checkpoint
+	checkpoint.Items = append([]Item(nil), r.checkpoint.Items...)
+	return checkpoint
+}
+
+// Run runs task over all pending items in order and returns when none are left.
+// When the quota is exhausted it saves the checkpoint and waits until the reset time,
+// so Run may take days; cancel ctx to stop it, which also saves the checkpoint.
+// Per-item failures are recorded in the items rather than returned. The returned
+// error is ctx.Err() or a checkpoint file error.
+func (r *Runner) Run(ctx context.Context, task Task) (err error) {
+	defer func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if saveErr := r.saveLocked(); err == nil {
+			err = saveErr
+		}
+	}()
+	for {
+		i, ok := r.nextPending()
+		if !ok {
+			return nil
+		}
+		if until, exhausted := r.quotaExhausted(); exhausted {
+			if err := r.pause(ctx, until); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		r.mu.Lock()
+		item := r.checkpoint.Items[i]
+		r.mu.Unlock()
+		taskErr := task(ctx, item)
+
+		r.mu.Lock()
+		item = r.checkpoint.Items[i]
+		switch {
+		case taskErr == nil:
+			item.Status, item.Error = StatusDone, ""
+			r.opts.Logger.Debug("batch: item done", "key", item.Key)
+		case errors.Is(taskErr, opensubtitles.ErrQuotaExceeded):
+			r.checkpoint.Remaining, r.checkpoint.ResetTime = 0, jobstate.QuotaResetTime(taskErr)
+			r.opts.Logger.Debug("batch: download quota exceeded", "key", item.Key)
+		case ctx.Err() != nil:
+			r.mu.Unlock()
+			return ctx.Err()
+		default:
+			item.Attempts++
+			item.Error = taskErr.Error()
+			if item.Attempts >= r.opts.MaxAttempts {
+				item.Status = StatusFailed
+			}
+			r.opts.Logger.Debug("batch: item failed", "key", item.Key, "attempts", item.Attempts, "status", item.Status, "error", taskErr)
+		}
+		if r.opts.Quota != nil && !errors.Is(taskErr, opensubtitles.ErrQuotaExceeded) {
+			if quota, ok := r.opts.Quota(); ok {
+				r.checkpoint.Remaining, r.checkpoint.ResetTime = quota.Remaining, quota.ResetTime
+			}
+		}
+		r.checkpoint.Items[i] = item
+		r.unsaved++
+		var saveErr error
+		if r.unsaved >= r.opts.CheckpointEvery || r.now().Sub(r.lastSave) >= r.opts.CheckpointInterval {
+			saveErr = r.saveLocked()
+		}
+		r.mu.Unlock()
+		if r.opts.OnItem != nil {
+			r.opts.OnItem(item)
+		}
+		if saveErr != nil {
+			return saveErr
+		}
+	}
+}
+
+// nextPending returns the index of the first pending item.
+func (r *Runner) nextPending() (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return jobstate.NextPending(r.checkpoint.Items, func(item Item) Status { return item.Status })
+}
+
+// quotaExhausted reports whether no downloads are left and, if so, until when.
+func (r *Runner) quotaExhausted() (time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return jobstate.QuotaExhausted(&r.checkpoint.Remaining, &r.checkpoint.ResetTime, r.now())
+}
+
+// pause saves the checkpoint and waits until the quota resets or ctx ends.
+func (r *Runner) pause(ctx context.Context, until time.Time) error {
+	r.mu.Lock()
+	err := r.saveLocked()
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if r.opts.OnPause != nil {
+		r.opts.OnPause(until)
+	}
+	r.opts.Logger.Info("batch: download quota exhausted, pausing", "until", until)
+	return r.sleep(ctx, until.Sub(r.now()))
+}
+
+// saveLocked writes the checkpoint file atomically. r.mu must be held.
+func (r *Runner) saveLocked() error {
+	r.unsaved, r.lastSave = 0, r.now()
+	if r.opts.CheckpointPath == "" {
+		return nil
+	}
+	r.checkpoint.SavedAt = r.lastSave
+	if err := r.file.Save(r.checkpoint); err != nil {
+		return fmt.Errorf("batch: %w", err)
+	}
+	return nil
+}
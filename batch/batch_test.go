@@ -0,0 +1,251 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeQuota is a daily download quota used up by the test task.
+type fakeQuota struct {
+	remaining int
+	reset     time.Time
+	fail      map[string]error
+	calls     []string
+}
+
+func (q *fakeQuota) Task(ctx context.Context, item Item) error {
+	q.calls = append(q.calls, item.Key)
+	if err := q.fail[item.Key]; err != nil {
+		return err
+	}
+	if q.remaining == 0 {
+		return fmt.Errorf("download: %w", opensubtitles.ErrQuotaExceeded)
+	}
+	q.remaining--
+	return nil
+}
+
+func (q *fakeQuota) Last() (opensubtitles.DownloadQuota, bool) {
+	return opensubtitles.DownloadQuota{Remaining: q.remaining, ResetTime: q.reset}, true
+}
+
+// fakeClock advances when the Runner sleeps.
+type fakeClock struct {
+	now    time.Time
+	slept  []time.Duration
+	onWait func()
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	if c.onWait != nil {
+		c.onWait()
+	}
+	return ctx.Err()
+}
+
+func newTestRunner(t *testing.T, clock *fakeClock, opts Options) *Runner {
+	t.Helper()
+	r, err := New(opts)
+	require.NoError(t, err)
+	r.now, r.sleep = clock.Now, clock.Sleep
+	return r
+}
+
+func items(keys ...string) []Item {
+	var out []Item
+	for _, key := range keys {
+		out = append(out, Item{Key: key})
+	}
+	return out
+}
+
+func readCheckpoint(t *testing.T, fsys vfs.FS, path string) Checkpoint {
+	t.Helper()
+	data, err := fs.ReadFile(fsys, path)
+	require.NoError(t, err)
+	var checkpoint Checkpoint
+	require.NoError(t, json.Unmarshal(data, &checkpoint))
+	return checkpoint
+}
+
+func TestItemData(t *testing.T) {
+	type work struct {
+		VideoPath string
+		Language  string
+	}
+	item, err := NewItem("Heat.el.srt", work{"Heat.mkv", "el"})
+	require.NoError(t, err)
+	assert.Equal(t, StatusPending, item.Status)
+
+	var got work
+	require.NoError(t, item.Decode(&got))
+	assert.Equal(t, work{"Heat.mkv", "el"}, got)
+
+	_, err = NewItem("bad", func() {})
+	assert.Error(t, err)
+}
+
+func TestRunnerPausesUntilQuotaReset(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	reset := start.Add(4 * time.Hour)
+	clock := &fakeClock{now: start}
+	q := &fakeQuota{remaining: 2, reset: reset}
+	clock.onWait = func() { q.remaining = 2 }
+	var pausedUntil []time.Time
+	r := newTestRunner(t, clock, Options{Quota: q.Last, OnPause: func(until time.Time) { pausedUntil = append(pausedUntil, until) }})
+
+	require.NoError(t, r.Add(items("a", "b", "c", "a")...))
+	require.NoError(t, r.Run(context.Background(), q.Task))
+
+	assert.Equal(t, []string{"a", "b", "c"}, q.calls, "no task runs while the reported quota is exhausted")
+	assert.Equal(t, []time.Time{reset}, pausedUntil)
+	assert.Equal(t, []time.Duration{4 * time.Hour}, clock.slept)
+	checkpoint := r.Checkpoint()
+	assert.Equal(t, 3, checkpoint.Count(StatusDone))
+	assert.Equal(t, 1, checkpoint.Remaining)
+}
+
+func TestRunnerQuotaErrorWithoutResetTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	q := &fakeQuota{remaining: 0}
+	clock.onWait = func() { q.remaining = 10 }
+	r := newTestRunner(t, clock, Options{})
+
+	require.NoError(t, r.Add(items("x")...))
+	require.NoError(t, r.Run(context.Background(), q.Task))
+
+	assert.Equal(t, []string{"x", "x"}, q.calls)
+	assert.Equal(t, []time.Duration{DefaultQuotaWait}, clock.slept)
+	item := r.Checkpoint().Items[0]
+	assert.Equal(t, StatusDone, item.Status)
+	assert.Zero(t, item.Attempts, "quota errors are not counted as attempts")
+}
+
+func TestRunnerGivesUpAfterMaxAttempts(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	q := &fakeQuota{remaining: 10, fail: map[string]error{"a": errors.New("boom")}}
+	var seen []Item
+	r := newTestRunner(t, clock, Options{MaxAttempts: 2, OnItem: func(item Item) { seen = append(seen, item) }})
+
+	require.NoError(t, r.Add(items("a", "b")...))
+	require.NoError(t, r.Run(context.Background(), q.Task))
+
+	assert.Equal(t, []string{"a", "a", "b"}, q.calls)
+	got := r.Checkpoint().Items
+	assert.Equal(t, StatusFailed, got[0].Status)
+	assert.Equal(t, 2, got[0].Attempts)
+	assert.Equal(t, "boom", got[0].Error)
+	assert.Equal(t, StatusDone, got[1].Status)
+	require.Len(t, seen, 3)
+	assert.Equal(t, StatusPending, seen[0].Status, "retried")
+	assert.Equal(t, StatusFailed, seen[1].Status)
+}
+
+func TestRunnerCheckpointsPeriodically(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	opts := Options{CheckpointPath: "jobs/library.json", FS: fsys, CheckpointEvery: 2, CheckpointInterval: time.Hour}
+	r := newTestRunner(t, clock, opts)
+	require.NoError(t, r.Add(items("a", "b", "c", "d", "e")...))
+
+	var done []int
+	task := func(ctx context.Context, item Item) error {
+		done = append(done, readCheckpoint(t, fsys, opts.CheckpointPath).Count(StatusDone))
+		if item.Key == "d" {
+			clock.now = clock.now.Add(2 * time.Hour)
+		}
+		return nil
+	}
+	require.NoError(t, r.Run(context.Background(), task))
+	assert.Equal(t, []int{0, 0, 2, 2, 4}, done, "saved after every second item")
+	assert.Equal(t, 5, readCheckpoint(t, fsys, opts.CheckpointPath).Count(StatusDone), "saved when Run returns")
+
+	require.NoError(t, r.Add(items("f", "g", "h")...))
+	done = nil
+	clock.now = clock.now.Add(time.Minute)
+	require.NoError(t, r.Run(context.Background(), func(ctx context.Context, item Item) error {
+		done = append(done, readCheckpoint(t, fsys, opts.CheckpointPath).Count(StatusDone))
+		if item.Key == "f" {
+			clock.now = clock.now.Add(2 * time.Hour)
+		}
+		return nil
+	}))
+	assert.Equal(t, []int{5, 6, 6}, done, "saved after CheckpointInterval even before CheckpointEvery items")
+}
+
+func TestRunnerResumesFromCheckpoint(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	q := &fakeQuota{remaining: 1, reset: start.Add(time.Hour)}
+	opts := Options{CheckpointPath: "state/batch.json", FS: fsys, Quota: q.Last}
+	r := newTestRunner(t, clock, opts)
+	require.NoError(t, r.Add(items("a", "b", "c")...))
+
+	// Stop the run while it waits for the quota reset, like a process being shut down.
+	ctx, cancel := context.WithCancel(context.Background())
+	clock.onWait = cancel
+	require.ErrorIs(t, r.Run(ctx, q.Task), context.Canceled)
+
+	restored := newTestRunner(t, clock, opts)
+	checkpoint := restored.Checkpoint()
+	require.Len(t, checkpoint.Items, 3)
+	assert.Equal(t, []Status{StatusDone, StatusPending, StatusPending}, []Status{checkpoint.Items[0].Status, checkpoint.Items[1].Status, checkpoint.Items[2].Status})
+	assert.Equal(t, 0, checkpoint.Remaining)
+	assert.True(t, checkpoint.ResetTime.Equal(start.Add(time.Hour)))
+
+	// A new scan of the library adds the same items again.
+	require.NoError(t, restored.Add(items("a", "b", "c")...))
+	q.remaining = 5
+	clock.onWait = nil
+	clock.now = start.Add(30 * time.Minute)
+	require.NoError(t, restored.Run(context.Background(), q.Task))
+	assert.Equal(t, []string{"a", "b", "c"}, q.calls, "the finished item is not run again")
+	assert.Equal(t, []time.Duration{time.Hour, 30 * time.Minute}, clock.slept, "the restored quota state is waited out first")
+	assert.Equal(t, 3, restored.Checkpoint().Count(StatusDone))
+}
+
+func TestRunnerStopsOnCancel(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	clock := &fakeClock{now: time.Now()}
+	opts := Options{CheckpointPath: "batch.json", FS: fsys, CheckpointEvery: 100}
+	r := newTestRunner(t, clock, opts)
+	require.NoError(t, r.Add(items("a", "b", "c")...))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := r.Run(ctx, func(ctx context.Context, item Item) error {
+		if item.Key == "b" {
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	})
+	require.ErrorIs(t, err, context.Canceled)
+	checkpoint := readCheckpoint(t, fsys, opts.CheckpointPath)
+	assert.Equal(t, StatusDone, checkpoint.Items[0].Status, "saved on cancellation")
+	assert.Equal(t, StatusPending, checkpoint.Items[1].Status)
+	assert.Zero(t, checkpoint.Items[1].Attempts, "an interrupted item is not counted as an attempt")
+
+	assert.Error(t, r.Add(Item{}))
+}
+
+func TestNewRejectsCorruptCheckpoint(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	require.NoError(t, vfs.WriteFile(fsys, "batch.json", []byte("{")))
+	_, err := New(Options{CheckpointPath: "batch.json", FS: fsys})
+	assert.ErrorContains(t, err, "failed to decode checkpoint file")
+}
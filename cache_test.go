@@ -0,0 +1,132 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc, cache CacheConfig) *Client {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, RateLimit: &testRateLimits, Cache: &cache})
+		require.NoError(t, err)
+		return client
+	}
+	query := func(s string) SearchSubtitlesParams { return SearchSubtitlesParams{Query: &s} }
+
+	t.Run("ServesFreshResponses", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			_, _ = w.Write([]byte(`{"total_count":1,"data":[{"id":"` + r.URL.Query().Get("query") + `"}]}`))
+		}, CacheConfig{})
+
+		for i := 0; i < 3; i++ {
+			resp, err := client.SearchSubtitles(context.Background(), query("cheers"))
+			require.NoError(t, err)
+			assert.Equal(t, "cheers", resp.Data[0].ID)
+		}
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+		resp, err := client.SearchSubtitles(context.Background(), query("frasier"))
+		require.NoError(t, err)
+		assert.Equal(t, "frasier", resp.Data[0].ID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "different queries are cached separately")
+	})
+
+	t.Run("RevalidatesWithETag", func(t *testing.T) {
+		var calls, notModified int32
+		store := NewMemoryCache(10)
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&notModified, 1)
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"data":[{"id":"1"}]}`))
+		}, CacheConfig{Store: store})
+
+		_, err := client.SearchSubtitles(context.Background(), query("cheers"))
+		require.NoError(t, err)
+		entry, ok := store.Get(context.Background(), "/subtitles?query=cheers")
+		require.True(t, ok)
+		assert.Equal(t, `"v1"`, entry.ETag)
+		entry.Expires = time.Now().Add(-time.Second) // Let it go stale
+
+		resp, err := client.SearchSubtitles(context.Background(), query("cheers"))
+		require.NoError(t, err)
+		assert.Equal(t, "1", resp.Data[0].ID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&notModified))
+
+		_, err = client.SearchSubtitles(context.Background(), query("cheers"))
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "304 refreshes the TTL")
+	})
+
+	t.Run("SkipsUncachedPathsErrorsAndNoStore", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			switch r.URL.Path {
+			case "/features":
+				w.Header().Set("Cache-Control", "no-store")
+			case "/subtitles":
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		}, CacheConfig{TTL: time.Hour})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.DiscoverPopular(context.Background(), DiscoverParams{})
+			require.NoError(t, err)
+			_, err = client.SearchFeatures(context.Background(), SearchFeaturesParams{})
+			require.NoError(t, err)
+			_, err = client.SearchSubtitles(context.Background(), query("x"))
+			require.ErrorIs(t, err, ErrServiceUnavailable)
+		}
+		assert.Equal(t, int32(6), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("CustomPaths", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		}, CacheConfig{Paths: []string{"/discover/popular"}})
+
+		for i := 0; i < 2; i++ {
+			_, err := client.DiscoverPopular(context.Background(), DiscoverParams{})
+			require.NoError(t, err)
+			_, err = client.SearchSubtitles(context.Background(), query("x"))
+			require.NoError(t, err)
+		}
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestMemoryCacheEvicts(t *testing.T) {
+	ctx := context.Background()
+	cache := NewMemoryCache(2)
+	cache.Set(ctx, "a", &CachedResponse{Body: []byte("a")})
+	cache.Set(ctx, "b", &CachedResponse{Body: []byte("b")})
+	_, _ = cache.Get(ctx, "a") // a is now most recently used
+	cache.Set(ctx, "c", &CachedResponse{Body: []byte("c")})
+
+	assert.Equal(t, 2, cache.Len())
+	_, ok := cache.Get(ctx, "b")
+	assert.False(t, ok)
+	_, ok = cache.Get(ctx, "a")
+	assert.True(t, ok)
+}
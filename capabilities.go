@@ -0,0 +1,88 @@
+package opensubtitles
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrLoginRequired is returned by Download, GetUserInfo and UploadSubtitle of a client
+// in anonymous mode (Config.Anonymous) that is not logged in. No request is sent.
+var ErrLoginRequired = errors.New("opensubtitles: login required")
+
+// AccessLevel is the kind of credentials a client holds.
+type AccessLevel int
+
+const (
+	// AccessAPIKey means only the API key is available: searching, features, discover
+	// and utilities work, but downloads, uploads and user info need a login.
+	AccessAPIKey AccessLevel = iota
+	// AccessUser means the client is logged in, or can log in with Config.Credentials.
+	AccessUser
+	// AccessVIP means the client is logged in with a VIP account.
+	AccessVIP
+)
+
+// String returns "api_key", "user" or "vip".
+func (l AccessLevel) String() string {
+	switch l {
+	case AccessAPIKey:
+		return "api_key"
+	case AccessUser:
+		return "user"
+	case AccessVIP:
+		return "vip"
+	}
+	return fmt.Sprintf("AccessLevel(%d)", int(l))
+}
+
+// Capabilities reports what a client can do with its current credentials, e.g. to
+// disable download buttons for anonymous users.
+type Capabilities struct {
+	Level    AccessLevel
+	LoggedIn bool // A token is set; false if the client only can log in with Config.Credentials
+	Search   bool // Subtitle and feature search, discover and utilities
+	Download bool
+	Upload   bool
+	UserInfo bool
+}
+
+// Capabilities reports what the client can do with its current credentials. VIP
+// status is known after Login or GetUserInfo; for a token restored from a TokenStore
+// it is derived from the VIP host the token is bound to.
+func (c *Client) Capabilities() Capabilities {
+	c.mu.RLock()
+	loggedIn := c.authToken != nil && *c.authToken != ""
+	vip := c.currentBaseUrl == VIPBaseURL
+	if c.vip != nil {
+		vip = *c.vip
+	}
+	c.mu.RUnlock()
+
+	caps := Capabilities{Level: AccessAPIKey, LoggedIn: loggedIn, Search: true}
+	switch {
+	case loggedIn && vip:
+		caps.Level = AccessVIP
+	case loggedIn || c.config.Credentials != nil:
+		caps.Level = AccessUser
+	}
+	if caps.Level != AccessAPIKey {
+		caps.Download, caps.Upload, caps.UserInfo = true, true, true
+	}
+	return caps
+}
+
+// requireLogin returns ErrLoginRequired if the client is in anonymous mode and not
+// logged in.
+func (c *Client) requireLogin(method string) error {
+	if c.config.Anonymous && !c.isAuthenticated() {
+		return fmt.Errorf("%w: %s needs a logged-in user", ErrLoginRequired, method)
+	}
+	return nil
+}
+
+// setVIP records the VIP status reported by the API for the current token.
+func (c *Client) setVIP(vip bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.vip = &vip
+}
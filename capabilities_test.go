@@ -0,0 +1,100 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymousModeRequiresLogin(t *testing.T) {
+	var paths []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/v1/login":
+			_, _ = w.Write([]byte(`{"token":"tok","user":{"vip":false},"status":200}`))
+		case "/api/v1/download":
+			_, _ = w.Write([]byte(`{"link":"https://example.com/1.srt","remaining":5}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		}
+	}
+	server, _ := setupTestServer(t, handler)
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, Anonymous: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.Download(ctx, DownloadRequest{FileID: 1})
+	assert.ErrorIs(t, err, ErrLoginRequired)
+	_, err = client.GetUserInfo(ctx)
+	assert.ErrorIs(t, err, ErrLoginRequired)
+	_, err = client.UploadSubtitle(ctx, UploadParams{FileName: "a.srt", Language: "en"}, strings.NewReader("1"))
+	assert.ErrorIs(t, err, ErrLoginRequired)
+	assert.Empty(t, paths, "no request is sent")
+
+	// Searching works with the API key alone.
+	_, err = client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat")})
+	require.NoError(t, err)
+
+	_, err = client.Login(ctx, LoginRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+	resp, err := client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 5, resp.Remaining)
+	assert.Equal(t, []string{"/api/v1/subtitles", "/api/v1/login", "/api/v1/download"}, paths)
+}
+
+func TestAnonymousModeRejectsCredentials(t *testing.T) {
+	_, err := NewClient(Config{ApiKey: "key", Anonymous: true, Credentials: &LoginRequest{Username: "u", Password: "p"}})
+	assert.Error(t, err)
+}
+
+func TestCapabilities(t *testing.T) {
+	vip := false
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/login":
+			if vip {
+				_, _ = w.Write([]byte(`{"token":"tok","user":{"vip":true},"status":200}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"tok","user":{"vip":false},"status":200}`))
+		case "/api/v1/logout":
+			_, _ = w.Write([]byte(`{"message":"ok","status":200}`))
+		}
+	}
+	_, client := setupTestServer(t, handler)
+	ctx := context.Background()
+
+	assert.Equal(t, Capabilities{Level: AccessAPIKey, Search: true}, client.Capabilities())
+
+	_, err := client.Login(ctx, LoginRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, Capabilities{Level: AccessUser, LoggedIn: true, Search: true, Download: true, Upload: true, UserInfo: true}, client.Capabilities())
+
+	vip = true
+	_, err = client.Login(ctx, LoginRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+	assert.Equal(t, AccessVIP, client.Capabilities().Level)
+	assert.Equal(t, "vip", client.Capabilities().Level.String())
+
+	_, err = client.Logout(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, AccessAPIKey, client.Capabilities().Level)
+
+	// A client with credentials can log in on demand.
+	withCredentials, err := NewClient(Config{ApiKey: "key", Credentials: &LoginRequest{Username: "u", Password: "p"}})
+	require.NoError(t, err)
+	caps := withCredentials.Capabilities()
+	assert.Equal(t, AccessUser, caps.Level)
+	assert.False(t, caps.LoggedIn)
+	assert.True(t, caps.Download)
+
+	// VIP status of a restored token follows the host it is bound to.
+	require.NoError(t, client.SetAuthToken("stored", VIPBaseURL))
+	assert.Equal(t, AccessVIP, client.Capabilities().Level)
+}
@@ -0,0 +1,189 @@
+// Package charset detects the character encoding of subtitle files and converts
+// them to UTF-8.
+//
+// Many subtitles on OpenSubtitles are still stored in legacy code pages, notably
+// Windows-1253 (Greek) and Windows-1251 (Cyrillic). Detection recognizes byte order
+// marks and valid UTF-8 and otherwise picks the single-byte code page whose decoding
+// looks most like natural text in its language.
+package charset
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding names a supported character encoding.
+type Encoding string
+
+const (
+	UTF8        Encoding = "utf-8"
+	UTF16LE     Encoding = "utf-16le"
+	UTF16BE     Encoding = "utf-16be"
+	Windows1251 Encoding = "windows-1251" // Cyrillic
+	Windows1252 Encoding = "windows-1252" // Western European, superset of ISO-8859-1
+	Windows1253 Encoding = "windows-1253" // Greek
+)
+
+// ErrUnsupportedEncoding is returned for encoding names Lookup does not know.
+var ErrUnsupportedEncoding = errors.New("charset: unsupported encoding")
+
+var aliases = map[string]Encoding{
+	"utf-8": UTF8, "utf8": UTF8,
+	"utf-16le": UTF16LE, "utf-16be": UTF16BE,
+	"windows-1251": Windows1251, "cp1251": Windows1251,
+	"windows-1252": Windows1252, "cp1252": Windows1252, "iso-8859-1": Windows1252, "latin1": Windows1252,
+	"windows-1253": Windows1253, "cp1253": Windows1253,
+}
+
+// Lookup returns the encoding for a name such as "cp1253" or "Windows-1253".
+func Lookup(name string) (Encoding, error) {
+	if enc, ok := aliases[strings.ToLower(strings.TrimSpace(name))]; ok {
+		return enc, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnsupportedEncoding, name)
+}
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Detect guesses the encoding of data.
+func Detect(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE
+	case utf8.Valid(data):
+		return UTF8
+	}
+
+	// Western European text is mostly ASCII with a few accented letters, while Greek
+	// and Cyrillic text is mostly made of high bytes.
+	high, ascii := 0, 0
+	for _, b := range data {
+		switch {
+		case b >= 0xC0:
+			high++
+		case b < 0x80 && (b|0x20) >= 'a' && (b|0x20) <= 'z':
+			ascii++
+		}
+	}
+	if high*4 < high+ascii {
+		return Windows1252
+	}
+	if score(data, &cp1253, greekCommon) >= score(data, &cp1251, cyrillicCommon) {
+		return Windows1253
+	}
+	return Windows1251
+}
+
+// Frequent lower-case letters of Greek and Russian text, covering about 85% of letters.
+const (
+	greekCommon    = "αεοιτνσςηυρκπμλάέίόήύώ"
+	cyrillicCommon = "оеаинтсрвлкмдпуя"
+)
+
+// score returns the share of high bytes that decode to one of the common letters,
+// with undefined bytes counting against the code page.
+func score(data []byte, table *[128]rune, common string) float64 {
+	hits, total := 0, 0
+	for _, b := range data {
+		if b < 0x80 {
+			continue
+		}
+		r := table[b-0x80]
+		switch {
+		case r == utf8.RuneError:
+			hits -= 5
+		case unicode.IsLetter(r):
+			if strings.ContainsRune(common, unicode.ToLower(r)) {
+				hits++
+			}
+		default:
+			continue
+		}
+		total++
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(hits) / float64(total)
+}
+
+// Decode converts data from enc to UTF-8, dropping a byte order mark. Bytes that are
+// undefined in a code page become U+FFFD.
+func Decode(data []byte, enc Encoding) ([]byte, error) {
+	switch enc {
+	case UTF8:
+		return bytes.TrimPrefix(data, bomUTF8), nil
+	case UTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), false), nil
+	case UTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), true), nil
+	case Windows1251:
+		return decodeSingleByte(data, &cp1251), nil
+	case Windows1252:
+		return decodeSingleByte(data, &cp1252), nil
+	case Windows1253:
+		return decodeSingleByte(data, &cp1253), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedEncoding, enc)
+}
+
+// ToUTF8 detects the encoding of data and converts it to UTF-8.
+func ToUTF8(data []byte) ([]byte, Encoding, error) {
+	enc := Detect(data)
+	out, err := Decode(data, enc)
+	return out, enc, err
+}
+
+// NormalizeLineEndings replaces CRLF and lone CR line endings with eol ("\n" if empty).
+func NormalizeLineEndings(data []byte, eol string) []byte {
+	if eol == "" {
+		eol = "\n"
+	}
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+	data = bytes.ReplaceAll(data, []byte("\r"), []byte("\n"))
+	if eol != "\n" {
+		data = bytes.ReplaceAll(data, []byte("\n"), []byte(eol))
+	}
+	return data
+}
+
+func decodeSingleByte(data []byte, table *[128]rune) []byte {
+	out := make([]byte, 0, len(data)+len(data)/2)
+	for _, b := range data {
+		if b < 0x80 {
+			out = append(out, b)
+			continue
+		}
+		out = utf8.AppendRune(out, table[b-0x80])
+	}
+	return out
+}
+
+func decodeUTF16(data []byte, bigEndian bool) []byte {
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		if bigEndian {
+			units[i] = uint16(data[2*i])<<8 | uint16(data[2*i+1])
+		} else {
+			units[i] = uint16(data[2*i+1])<<8 | uint16(data[2*i])
+		}
+	}
+	out := make([]byte, 0, len(data))
+	for _, r := range utf16.Decode(units) {
+		out = utf8.AppendRune(out, r)
+	}
+	return out
+}
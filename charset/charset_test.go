@@ -0,0 +1,98 @@
+package charset
+
+import (
+	"testing"
+	"unicode/utf16"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	greekText   = "1\n00:00:01,000 --> 00:00:03,000\nΚαλημέρα, τι κάνεις σήμερα;\nΕίμαι καλά, ευχαριστώ πολύ.\n\n2\n00:00:04,000 --> 00:00:06,000\nΠού είναι ο Γιάννης; Θα έρθει αύριο το πρωί.\n"
+	russianText = "1\n00:00:01,000 --> 00:00:03,000\nДоброе утро, как твои дела сегодня?\nСпасибо, всё хорошо. Где Иван?\n\n2\n00:00:04,000 --> 00:00:06,000\nОн придёт завтра утром, не переживай.\n"
+	frenchText  = "1\n00:00:01,000 --> 00:00:03,000\nJ'espère que tu vas bien, mon frère.\nÀ bientôt, ça va être génial !\n"
+)
+
+// encode converts UTF-8 text to a code page using the inverse of its table.
+func encode(t *testing.T, text string, table *[128]rune) []byte {
+	t.Helper()
+	inverse := make(map[rune]byte)
+	for i, r := range table {
+		inverse[r] = byte(0x80 + i)
+	}
+	var out []byte
+	for _, r := range text {
+		if r < 0x80 {
+			out = append(out, byte(r))
+			continue
+		}
+		b, ok := inverse[r]
+		require.True(t, ok, "rune %q not in code page", r)
+		out = append(out, b)
+	}
+	return out
+}
+
+func TestDetectAndDecode(t *testing.T) {
+	for name, tc := range map[string]struct {
+		text  string
+		table *[128]rune
+		want  Encoding
+	}{
+		"Greek":   {greekText, &cp1253, Windows1253},
+		"Russian": {russianText, &cp1251, Windows1251},
+		"French":  {frenchText, &cp1252, Windows1252},
+	} {
+		t.Run(name, func(t *testing.T) {
+			data := encode(t, tc.text, tc.table)
+			assert.Equal(t, tc.want, Detect(data))
+
+			out, enc, err := ToUTF8(data)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, enc)
+			assert.Equal(t, tc.text, string(out))
+		})
+	}
+}
+
+func TestDetectUnicode(t *testing.T) {
+	assert.Equal(t, UTF8, Detect([]byte(greekText)))
+
+	out, enc, err := ToUTF8(append([]byte{0xEF, 0xBB, 0xBF}, russianText...))
+	require.NoError(t, err)
+	assert.Equal(t, UTF8, enc)
+	assert.Equal(t, russianText, string(out), "BOM is dropped")
+
+	units := utf16.Encode([]rune(greekText))
+	le, be := []byte{0xFF, 0xFE}, []byte{0xFE, 0xFF}
+	for _, u := range units {
+		le = append(le, byte(u), byte(u>>8))
+		be = append(be, byte(u>>8), byte(u))
+	}
+	out, enc, err = ToUTF8(le)
+	require.NoError(t, err)
+	assert.Equal(t, UTF16LE, enc)
+	assert.Equal(t, greekText, string(out))
+	out, enc, err = ToUTF8(be)
+	require.NoError(t, err)
+	assert.Equal(t, UTF16BE, enc)
+	assert.Equal(t, greekText, string(out))
+}
+
+func TestLookup(t *testing.T) {
+	enc, err := Lookup("CP1253")
+	require.NoError(t, err)
+	assert.Equal(t, Windows1253, enc)
+
+	_, err = Lookup("ebcdic")
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+	_, err = Decode(nil, "ebcdic")
+	assert.ErrorIs(t, err, ErrUnsupportedEncoding)
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+	in := []byte("a\r\nb\rc\nd")
+	assert.Equal(t, "a\nb\nc\nd", string(NormalizeLineEndings(in, "")))
+	assert.Equal(t, "a\r\nb\r\nc\r\nd", string(NormalizeLineEndings(in, "\r\n")))
+}
@@ -0,0 +1,342 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/hash"
+)
+
+func runSearch(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("search", "[flags] [QUERY]")
+	imdb := flags.String("imdb", "", "IMDb ID, e.g. tt0113277")
+	langs := flags.String("lang", "", "comma-separated language codes, e.g. el,en")
+	video := flags.String("file", "", "video file to search by OSDb hash")
+	kind := flags.String("type", "", "movie, episode or all")
+	season := flags.Int("season", 0, "season number")
+	episode := flags.Int("episode", 0, "episode number")
+	year := flags.Int("year", 0, "release year")
+	page := flags.Int("page", 0, "results page")
+	all := flags.Bool("all", false, "fetch every page from -page on; -output ndjson prints each result as it arrives")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	var params opensubtitles.SearchSubtitlesParams
+	if flags.NArg() > 0 {
+		query := flags.Arg(0)
+		params.Query = &query
+	}
+	if *imdb != "" {
+		id, err := opensubtitles.ParseIMDbID(*imdb)
+		if err != nil {
+			return err
+		}
+		params.IMDbID = &id
+	}
+	if *video != "" {
+		h, _, err := hash.ComputeOSDbHash(*video)
+		if err != nil {
+			return err
+		}
+		params.Moviehash = &h
+	}
+	params.Languages = optionalString(*langs)
+	params.Type = optionalString(*kind)
+	params.SeasonNumber = optionalInt(*season)
+	params.EpisodeNumber = optionalInt(*episode)
+	params.Year = optionalInt(*year)
+	params.Page = optionalInt(*page)
+
+	if err := a.connect(); err != nil {
+		return err
+	}
+	out := a.results("ID", "FILE_ID", "LANG", "DOWNLOADS", "RELEASE")
+	if *all {
+		it := a.client.SearchSubtitlesIter(ctx, params, opensubtitles.SearchIterOptions{})
+		for it.Next() {
+			if err := out.add(it.Subtitle(), subtitleRow(it.Subtitle())); err != nil {
+				return err
+			}
+		}
+		if err := it.Err(); err != nil {
+			return err
+		}
+		return out.flush(nil)
+	}
+	resp, err := a.client.SearchSubtitles(ctx, params)
+	if err != nil {
+		return err
+	}
+	for _, sub := range resp.Data {
+		if err := out.add(sub, subtitleRow(sub)); err != nil {
+			return err
+		}
+	}
+	return out.flush(resp)
+}
+
+// subtitleRow is the table row of a search result.
+func subtitleRow(sub opensubtitles.Subtitle) []string {
+	fileID := ""
+	if len(sub.Attributes.Files) > 0 {
+		fileID = strconv.Itoa(sub.Attributes.Files[0].FileID)
+	}
+	return []string{sub.ID, fileID, string(sub.Attributes.Language), strconv.Itoa(sub.Attributes.DownloadCount), sub.Attributes.Release}
+}
+
+// downloadResult is printed by the download command.
+type downloadResult struct {
+	FileID    int    `json:"file_id"`
+	Path      string `json:"path"`
+	Encoding  string `json:"encoding"`
+	Remaining int    `json:"remaining"`
+}
+
+func runDownload(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("download", "[flags] FILE_ID...")
+	dir := flags.String("dir", ".", "directory the files are written to, as FILE_ID.FORMAT")
+	format := flags.String("format", "", "subtitle format to convert to on the server, e.g. webvtt")
+	encoding := flags.String("encoding", "", "source encoding, instead of detecting it")
+	keep := flags.Bool("keep-encoding", false, "write the files as downloaded, without conversion to UTF-8")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	fileIDs := make([]int, flags.NArg())
+	for i, arg := range flags.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid file ID %q", arg)
+		}
+		fileIDs[i] = id
+	}
+	if err := a.login(ctx); err != nil {
+		return err
+	}
+
+	ext := "srt"
+	if *format != "" {
+		ext = *format
+	}
+	opts := opensubtitles.DownloadToFileOptions{Encoding: *encoding, KeepEncoding: *keep}
+	out := a.results("FILE_ID", "PATH", "ENCODING", "REMAINING")
+	for _, id := range fileIDs {
+		req := opensubtitles.DownloadRequest{FileID: id, SubFormat: optionalString(*format)}
+		res, err := a.client.DownloadToFile(ctx, req, filepath.Join(*dir, fmt.Sprintf("%d.%s", id, ext)), opts)
+		if err != nil {
+			return err
+		}
+		result := downloadResult{FileID: id, Path: res.Path, Encoding: string(res.Encoding), Remaining: res.Remaining}
+		if err := out.add(result, []string{strconv.Itoa(id), res.Path, string(res.Encoding), strconv.Itoa(res.Remaining)}); err != nil {
+			return err
+		}
+	}
+	return out.flush(nil)
+}
+
+func runUpload(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("upload", "-lang LANG [flags] SUBTITLE_FILE")
+	lang := flags.String("lang", "", "subtitle language, e.g. en (required)")
+	imdb := flags.String("imdb", "", "IMDb ID of the movie or episode")
+	video := flags.String("file", "", "video file the subtitle is synced to, for its hash, size and name")
+	release := flags.String("release", "", "release name")
+	comment := flags.String("comment", "", "author comment")
+	fps := flags.Float64("fps", 0, "video frame rate")
+	hi := flags.Bool("hi", false, "hearing impaired")
+	hd := flags.Bool("hd", false, "high definition")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 || *lang == "" {
+		flags.Usage()
+		return errUsage
+	}
+
+	path := flags.Arg(0)
+	params := opensubtitles.UploadParams{
+		FileName: filepath.Base(path),
+		Language: opensubtitles.LanguageCode(*lang),
+		FPS:      *fps,
+	}
+	params.ReleaseName = *release
+	params.Comment = *comment
+	params.HearingImpaired = *hi
+	params.HighDefinition = *hd
+	if *imdb != "" {
+		id, err := opensubtitles.ParseIMDbID(*imdb)
+		if err != nil {
+			return err
+		}
+		params.IMDbID = id
+	}
+	if *video != "" {
+		h, size, err := hash.ComputeOSDbHash(*video)
+		if err != nil {
+			return err
+		}
+		params.MovieHash, params.MovieByteSize, params.MovieFileName = h, size, filepath.Base(*video)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := a.login(ctx); err != nil {
+		return err
+	}
+	resp, err := a.client.UploadSubtitle(ctx, params, f)
+	if err != nil {
+		return err
+	}
+	return a.print(resp, []string{"SUBTITLE_ID", "URL"}, [][]string{{resp.SubtitleID, resp.URL}})
+}
+
+func runGuessit(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("guessit", "[flags] FILENAME...")
+	offline := flags.Bool("offline", false, "parse locally without calling the API")
+	workers := flags.Int("workers", opensubtitles.DefaultGuessitWorkers, "concurrent API requests")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	results := make(map[string]opensubtitles.GuessitResult, flags.NArg())
+	if *offline {
+		// Local parsing needs no API key.
+		for _, name := range flags.Args() {
+			results[name] = opensubtitles.GuessitResult{Guess: opensubtitles.ParseReleaseName(name), Local: true}
+		}
+	} else {
+		if err := a.connect(); err != nil {
+			return err
+		}
+		var err error
+		if results, err = a.client.GuessitBatch(ctx, flags.Args(), opensubtitles.GuessitBatchOptions{Workers: *workers}); err != nil {
+			return err
+		}
+	}
+	type guess struct {
+		Filename string                         `json:"filename"`
+		Guess    *opensubtitles.GuessitResponse `json:"guess,omitempty"`
+		Local    bool                           `json:"local"`
+		Error    string                         `json:"error,omitempty"`
+	}
+	out := a.results("FILENAME", "TITLE", "YEAR", "SEASON", "EPISODE", "GROUP")
+	var failed error
+	for _, name := range sortedKeys(results) {
+		res := results[name]
+		g := guess{Filename: name, Guess: res.Guess, Local: res.Local}
+		if res.Err != nil {
+			g.Error = res.Err.Error()
+			failed = errors.Join(failed, fmt.Errorf("%s: %w", name, res.Err))
+		}
+		var row []string
+		if r := res.Guess; r != nil {
+			row = []string{name, deref(r.Title), derefInt(r.Year), derefInt(r.Season), derefInt(r.Episode), deref(r.ReleaseGroup)}
+		}
+		if err := out.add(g, row); err != nil {
+			return err
+		}
+	}
+	if err := out.flush(nil); err != nil {
+		return err
+	}
+	return failed
+}
+
+// hashResult is printed by the hash command.
+type hashResult struct {
+	Path string `json:"path"`
+	Hash string `json:"hash"`
+	Size int64  `json:"size"`
+}
+
+func runHash(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("hash", "VIDEO_FILE_OR_URL...")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+	out := a.results("HASH", "SIZE", "PATH")
+	for _, path := range flags.Args() {
+		h, size, err := computeHash(ctx, path)
+		if err != nil {
+			return err
+		}
+		if err := out.add(hashResult{Path: path, Hash: h, Size: size}, []string{h, strconv.FormatInt(size, 10), path}); err != nil {
+			return err
+		}
+	}
+	return out.flush(nil)
+}
+
+// computeHash hashes a local file, or an http(s) URL with Range requests.
+func computeHash(ctx context.Context, path string) (string, int64, error) {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return hash.ComputeOSDbHashURL(ctx, path)
+	}
+	return hash.ComputeOSDbHash(path)
+}
+
+func runWhoami(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("whoami", "")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if err := a.login(ctx); err != nil {
+		return err
+	}
+	resp, err := a.client.GetUserInfo(ctx)
+	if err != nil {
+		return err
+	}
+	info := resp.Data
+	return a.print(info, []string{"USER_ID", "LEVEL", "VIP", "DOWNLOADS", "REMAINING"}, [][]string{{
+		strconv.Itoa(info.UserID), info.Level, strconv.FormatBool(info.VIP), strconv.Itoa(info.DownloadsCount), strconv.Itoa(info.RemainingDownloads),
+	}})
+}
+
+func optionalString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func optionalInt(n int) *int {
+	if n == 0 {
+		return nil
+	}
+	return &n
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt(n *int) string {
+	if n == nil {
+		return ""
+	}
+	return strconv.Itoa(*n)
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/opensubtitlestest"
+	"github.com/angelospk/opensubtitles-go/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const srt = "1\r\n00:00:01,000 --> 00:00:02,000\r\nHello\r\n"
+
+// testApp returns an app configured for a fake server through environment variables.
+func testApp(t *testing.T, srv *opensubtitlestest.Server) (*app, *bytes.Buffer) {
+	env := map[string]string{
+		"OPENSUBTITLES_API_KEY":  opensubtitlestest.APIKey,
+		"OPENSUBTITLES_USERNAME": opensubtitlestest.Username,
+		"OPENSUBTITLES_PASSWORD": opensubtitlestest.Password,
+		"OPENSUBTITLES_BASE_URL": srv.BaseURL(),
+	}
+	var stdout bytes.Buffer
+	return &app{
+		stdout:    &stdout,
+		stderr:    &bytes.Buffer{},
+		lookupEnv: func(key string) (string, bool) { v, ok := env[key]; return v, ok },
+	}, &stdout
+}
+
+func TestSearchTable(t *testing.T) {
+	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{})
+	t.Cleanup(srv.Close)
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "11", Title: "Heat", Year: 1995, IMDbID: 113277, Language: "el"}), []byte(srt))
+	a, stdout := testApp(t, srv)
+
+	err := a.run(context.Background(), []string{"-config", filepath.Join(t.TempDir(), "config.json"), "search", "-imdb", "tt0113277", "-lang", "EL"})
+	require.NoError(t, err)
+	assert.Equal(t, "ID  FILE_ID  LANG  DOWNLOADS  RELEASE\n11  12       el    0          Heat.1995.1080p.WEB-DL.x264\n", stdout.String())
+}
+
+func TestDownloadAndWhoamiJSON(t *testing.T) {
+	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{})
+	t.Cleanup(srv.Close)
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "11"}), []byte(srt))
+	a, stdout := testApp(t, srv)
+	dir := t.TempDir()
+	config := filepath.Join(dir, "config.json")
+
+	err := a.run(context.Background(), []string{"-config", config, "-json", "download", "-dir", dir, "12"})
+	require.NoError(t, err)
+	var results []downloadResult
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, opensubtitlestest.DefaultDownloadQuota-1, results[0].Remaining)
+	data, err := os.ReadFile(filepath.Join(dir, "12.srt"))
+	require.NoError(t, err)
+	assert.Equal(t, "1\n00:00:01,000 --> 00:00:02,000\nHello\n", string(data))
+
+	// The token cached by the download is reused instead of logging in again.
+	assert.FileExists(t, filepath.Join(dir, "token.json"))
+	stdout.Reset()
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "-json", "whoami"}))
+	var info struct {
+		RemainingDownloads int `json:"remaining_downloads"`
+	}
+	require.NoError(t, json.Unmarshal(stdout.Bytes(), &info))
+	assert.Equal(t, opensubtitlestest.DefaultDownloadQuota-1, info.RemainingDownloads)
+	logins := 0
+	for _, req := range srv.Requests() {
+		if req.Path == "/login" {
+			logins++
+		}
+	}
+	assert.Equal(t, 1, logins)
+}
+
+func TestUpload(t *testing.T) {
+	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{})
+	t.Cleanup(srv.Close)
+	a, _ := testApp(t, srv)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Heat.1995.en.srt")
+	require.NoError(t, os.WriteFile(path, []byte(srt), 0o644))
+
+	err := a.run(context.Background(), []string{"-config", filepath.Join(dir, "config.json"), "upload", "-lang", "en", "-imdb", "tt0113277", "-file", "../../testdata/video.mkv", path})
+	require.NoError(t, err)
+	uploads := srv.Uploads()
+	require.Len(t, uploads, 1)
+	assert.Equal(t, "Heat.1995.en.srt", uploads[0].FileName)
+	assert.Equal(t, "113277", uploads[0].Fields["idmovieimdb"])
+	assert.Equal(t, "a2b51e055b718161", uploads[0].Fields["moviehash"])
+	assert.Equal(t, "video.mkv", uploads[0].Fields["moviefilename"])
+}
+
+func TestHashAndOfflineGuessitNeedNoAPIKey(t *testing.T) {
+	var stdout bytes.Buffer
+	a := &app{stdout: &stdout, stderr: &bytes.Buffer{}, lookupEnv: func(string) (string, bool) { return "", false }}
+	config := filepath.Join(t.TempDir(), "config.json")
+
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "hash", "../../testdata/video.mkv"}))
+	assert.Equal(t, "HASH              SIZE    PATH\na2b51e055b718161  345108  ../../testdata/video.mkv\n", stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "guessit", "-offline", "Heat.1995.1080p.BluRay.x264-GROUP.mkv"}))
+	assert.Contains(t, stdout.String(), "Heat   1995")
+
+	err := a.run(context.Background(), []string{"-config", config, "whoami"})
+	assert.ErrorContains(t, err, "no API key")
+}
+
+func TestNDJSONOutput(t *testing.T) {
+	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{})
+	t.Cleanup(srv.Close)
+	for _, id := range []string{"11", "21", "31"} {
+		srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: id, Title: "Heat", Year: 1995, IMDbID: 113277, Language: "el"}), []byte(srt))
+	}
+	a, stdout := testApp(t, srv)
+	config := filepath.Join(t.TempDir(), "config.json")
+
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "-output", "ndjson", "search", "-all", "-imdb", "tt0113277"}))
+	lines := strings.Split(strings.TrimSuffix(stdout.String(), "\n"), "\n")
+	require.Len(t, lines, 3, "one line per subtitle")
+	var sub struct {
+		ID string `json:"id"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &sub))
+	assert.Equal(t, "11", sub.ID)
+
+	stdout.Reset()
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "-output", "ndjson", "hash", "../../testdata/video.mkv", "../../testdata/video.mkv"}))
+	assert.Equal(t, strings.Repeat(`{"path":"../../testdata/video.mkv","hash":"a2b51e055b718161","size":345108}`+"\n", 2), stdout.String())
+
+	stdout.Reset()
+	require.NoError(t, a.run(context.Background(), []string{"-config", config, "-output", "ndjson", "guessit", "-offline", "Heat.1995.mkv"}))
+	assert.Contains(t, stdout.String(), `{"filename":"Heat.1995.mkv","guess":{`)
+	assert.Equal(t, 1, strings.Count(stdout.String(), "\n"))
+
+	assert.ErrorIs(t, a.run(context.Background(), []string{"-output", "yaml", "hash", "x"}), errUsage)
+}
+
+func TestExitCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{errUsage, exitUsage},
+		{fmt.Errorf("%w: set api_key", errNoAPIKey), exitAuth},
+		{fmt.Errorf("login: %w", opensubtitles.ErrUnauthorized), exitAuth},
+		{opensubtitles.ErrQuotaExceeded, exitQuota},
+		{opensubtitles.ErrRateLimited, exitQuota},
+		{opensubtitles.ErrNotFound, exitNotFound},
+		{opensubtitles.ErrServiceUnavailable, exitNetwork},
+		{&url.Error{Op: "Get", URL: "https://api.example", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, exitNetwork},
+		{errors.New("disk full"), exitError},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, exitCode(tt.err), tt.err.Error())
+	}
+}
+
+func TestCompletion(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		t.Run(shell, func(t *testing.T) {
+			var stdout bytes.Buffer
+			a := &app{stdout: &stdout, stderr: &bytes.Buffer{}, lookupEnv: func(string) (string, bool) { return "", false }}
+			require.NoError(t, a.run(context.Background(), []string{"completion", shell}))
+			out := stdout.String()
+			for _, want := range []string{"search", "download", "whoami", "completion", "imdb", "keep-encoding", "ndjson"} {
+				assert.Contains(t, out, want)
+			}
+		})
+	}
+	a := &app{stdout: &bytes.Buffer{}, stderr: &bytes.Buffer{}, lookupEnv: func(string) (string, bool) { return "", false }}
+	assert.ErrorIs(t, a.run(context.Background(), []string{"completion", "tcsh"}), errUsage)
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The completion command reads commands, so it is added here to avoid an
+// initialization cycle.
+func init() {
+	commands = append(commands, command{"completion", "Print the bash, zsh or fish completion script", runCompletion})
+}
+
+// completionShells are the shells runCompletion writes scripts for.
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// completionFlag is a flag offered by the completion scripts.
+type completionFlag struct {
+	name   string
+	usage  string
+	values []string // Completed after the flag; nil completes files
+	isBool bool
+}
+
+// completionCommand is a command and its flags.
+type completionCommand struct {
+	name    string
+	summary string
+	flags   []completionFlag
+}
+
+func runCompletion(ctx context.Context, a *app, args []string) error {
+	flags := a.newFlagSet("completion", "bash|zsh|fish")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if flags.NArg() != 1 {
+		flags.Usage()
+		return errUsage
+	}
+	global := completionFlags(a.globalFlags)
+	var cmds []completionCommand
+	for _, cmd := range commands {
+		cmds = append(cmds, completionCommand{name: cmd.name, summary: cmd.summary, flags: commandFlags(ctx, cmd)})
+	}
+	switch flags.Arg(0) {
+	case "bash":
+		writeBashCompletion(a.stdout, global, cmds)
+	case "zsh":
+		writeZshCompletion(a.stdout, global, cmds)
+	case "fish":
+		writeFishCompletion(a.stdout, global, cmds)
+	default:
+		fmt.Fprintf(a.stderr, "ossub: unsupported shell %q\n", flags.Arg(0))
+		flags.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// commandFlags returns the flags of cmd by running it with -h, which makes every
+// command stop after defining its flags, before any other work.
+func commandFlags(ctx context.Context, cmd command) []completionFlag {
+	if cmd.name == "completion" {
+		return nil
+	}
+	probe := &app{stdout: io.Discard, stderr: io.Discard, lookupEnv: func(string) (string, bool) { return "", false }}
+	_ = cmd.run(ctx, probe, []string{"-h"})
+	return completionFlags(probe.commandFlags)
+}
+
+// completionFlags lists the flags of fs in order of name.
+func completionFlags(fs *flag.FlagSet) []completionFlag {
+	var flags []completionFlag
+	if fs == nil {
+		return nil
+	}
+	fs.VisitAll(func(f *flag.Flag) {
+		cf := completionFlag{name: f.Name, usage: f.Usage}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			cf.isBool = true
+		}
+		if f.Name == "output" {
+			cf.values = []string{outputTable, outputJSON, outputNDJSON}
+		}
+		flags = append(flags, cf)
+	})
+	return flags
+}
+
+// flagNames returns "-name" for every flag.
+func flagNames(flags []completionFlag) string {
+	names := make([]string, len(flags))
+	for i, f := range flags {
+		names[i] = "-" + f.name
+	}
+	return strings.Join(names, " ")
+}
+
+func writeBashCompletion(w io.Writer, global []completionFlag, cmds []completionCommand) {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.name
+	}
+	var valueFlags []string
+	for _, f := range global {
+		if !f.isBool {
+			valueFlags = append(valueFlags, "-"+f.name)
+		}
+	}
+	fmt.Fprintf(w, `# bash completion for ossub; load with: source <(ossub completion bash)
+_ossub() {
+    local cur=${COMP_WORDS[COMP_CWORD]} prev=${COMP_WORDS[COMP_CWORD-1]} cmd="" i
+    for ((i = 1; i < COMP_CWORD; i++)); do
+        case ${COMP_WORDS[i]} in
+            %s) ((i++)) ;;
+            -*) ;;
+            *) cmd=${COMP_WORDS[i]}; break ;;
+        esac
+    done
+    case $prev in
+        -output) COMPREPLY=($(compgen -W "%s %s %s" -- "$cur")); return ;;
+    esac
+    case $cmd in
+        "")
+            if [[ $cur == -* ]]; then
+                COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            else
+                COMPREPLY=($(compgen -W "%s" -- "$cur"))
+            fi ;;
+        completion) COMPREPLY=($(compgen -W "%s" -- "$cur")) ;;
+`, strings.Join(valueFlags, "|"), outputTable, outputJSON, outputNDJSON, flagNames(global), strings.Join(names, " "), strings.Join(completionShells, " "))
+	for _, cmd := range cmds {
+		if len(cmd.flags) > 0 {
+			fmt.Fprintf(w, "        %s) [[ $cur == -* ]] && COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")) ;;\n", cmd.name, flagNames(cmd.flags))
+		}
+	}
+	fmt.Fprint(w, `    esac
+}
+complete -o default -F _ossub ossub
+`)
+}
+
+func writeZshCompletion(w io.Writer, global []completionFlag, cmds []completionCommand) {
+	fmt.Fprint(w, `#compdef ossub
+# zsh completion for ossub; load with: source <(ossub completion zsh)
+_ossub() {
+    local -a commands flags
+    local cmd i
+    for ((i = 2; i < CURRENT; i++)); do
+        case ${words[i]} in
+`)
+	var valueFlags []string
+	for _, f := range global {
+		if !f.isBool {
+			valueFlags = append(valueFlags, "-"+f.name)
+		}
+	}
+	fmt.Fprintf(w, `            %s) ((i++)) ;;
+            -*) ;;
+            *) cmd=${words[i]}; break ;;
+        esac
+    done
+    if [[ ${words[CURRENT-1]} == -output ]]; then
+        compadd -- %s %s %s
+        return
+    fi
+    case $cmd in
+        "")
+            commands=(
+`, strings.Join(valueFlags, "|"), outputTable, outputJSON, outputNDJSON)
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "                %s\n", zshQuote(cmd.name+":"+zshEscape(cmd.summary)))
+	}
+	fmt.Fprint(w, "            )\n            flags=(\n")
+	for _, f := range global {
+		fmt.Fprintf(w, "                %s\n", zshQuote("-"+f.name+":"+zshEscape(f.usage)))
+	}
+	fmt.Fprint(w, `            )
+            if [[ $PREFIX == -* ]]; then
+                _describe 'flag' flags
+            else
+                _describe 'command' commands
+            fi
+            return ;;
+        completion) compadd -- `+strings.Join(completionShells, " ")+`; return ;;
+`)
+	for _, cmd := range cmds {
+		if len(cmd.flags) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "        %s) flags=(", cmd.name)
+		for i, f := range cmd.flags {
+			if i > 0 {
+				fmt.Fprint(w, " ")
+			}
+			fmt.Fprint(w, zshQuote("-"+f.name+":"+zshEscape(f.usage)))
+		}
+		fmt.Fprint(w, ") ;;\n")
+	}
+	fmt.Fprint(w, `    esac
+    if [[ $PREFIX == -* ]]; then
+        _describe 'flag' flags
+    else
+        _files
+    fi
+}
+compdef _ossub ossub
+`)
+}
+
+func writeFishCompletion(w io.Writer, global []completionFlag, cmds []completionCommand) {
+	fmt.Fprintln(w, "# fish completion for ossub; load with: ossub completion fish | source")
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.name
+	}
+	for _, f := range global {
+		fmt.Fprintf(w, "complete -c ossub -n '__fish_use_subcommand' %s\n", fishFlag(f))
+	}
+	for _, cmd := range cmds {
+		fmt.Fprintf(w, "complete -c ossub -n '__fish_use_subcommand' -f -a %s -d %s\n", cmd.name, fishQuote(cmd.summary))
+	}
+	fmt.Fprintf(w, "complete -c ossub -n '__fish_seen_subcommand_from completion' -f -a %s\n", fishQuote(strings.Join(completionShells, " ")))
+	for _, cmd := range cmds {
+		for _, f := range cmd.flags {
+			fmt.Fprintf(w, "complete -c ossub -n '__fish_seen_subcommand_from %s' %s\n", cmd.name, fishFlag(f))
+		}
+	}
+}
+
+// fishFlag returns the options of a fish complete command for f.
+func fishFlag(f completionFlag) string {
+	opts := "-o " + f.name
+	switch {
+	case f.values != nil:
+		opts += " -x -a " + fishQuote(strings.Join(f.values, " "))
+	case !f.isBool:
+		opts += " -r"
+	}
+	return opts + " -d " + fishQuote(f.usage)
+}
+
+// fishQuote quotes s for fish.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+// zshEscape escapes the colons of a _describe description.
+func zshEscape(s string) string {
+	return strings.ReplaceAll(s, ":", `\:`)
+}
+
+// zshQuote quotes s for zsh.
+func zshQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/envconfig"
+)
+
+// defaultUserAgent identifies the tool when no user agent is configured.
+const defaultUserAgent = "ossub v1.0"
+
+// fileConfig is the JSON config file. Environment variables override its values.
+type fileConfig struct {
+	ApiKey    string `json:"api_key"`
+	UserAgent string `json:"user_agent"`
+	BaseURL   string `json:"base_url"`
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	// TokenFile caches the login token between runs. Defaults to token.json next to
+	// the config file; "-" disables caching.
+	TokenFile string `json:"token_file"`
+}
+
+// defaultConfigPath returns $XDG_CONFIG_HOME/ossub/config.json or its platform equivalent.
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "ossub.json"
+	}
+	return filepath.Join(dir, "ossub", "config.json")
+}
+
+// loadConfig reads the config file at path, if it exists, and applies the
+// OPENSUBTITLES_* variables read with lookup on top of it.
+func loadConfig(path string, lookup func(string) (string, bool)) (fileConfig, error) {
+	var cfg fileConfig
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return cfg, fmt.Errorf("failed to read config file '%s': %w", path, err)
+	default:
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf("failed to decode config file '%s': %w", path, err)
+		}
+	}
+
+	l := envconfig.NewWithLookup("", lookup)
+	cfg.ApiKey = l.String("API_KEY", cfg.ApiKey)
+	cfg.UserAgent = l.String("USER_AGENT", cfg.UserAgent)
+	cfg.BaseURL = l.String("BASE_URL", cfg.BaseURL)
+	cfg.Username = l.String("USERNAME", cfg.Username)
+	cfg.Password = l.String("PASSWORD", cfg.Password)
+	cfg.TokenFile = l.String("TOKEN_FILE", cfg.TokenFile)
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = defaultUserAgent
+	}
+	if cfg.TokenFile == "" {
+		cfg.TokenFile = filepath.Join(filepath.Dir(path), "token.json")
+	}
+	return cfg, l.Err()
+}
+
+// clientConfig returns the library configuration for cfg.
+func (cfg fileConfig) clientConfig() opensubtitles.Config {
+	config := opensubtitles.Config{
+		ApiKey:    cfg.ApiKey,
+		UserAgent: cfg.UserAgent,
+		BaseURL:   cfg.BaseURL,
+	}
+	if cfg.Username != "" && cfg.Password != "" {
+		config.Credentials = &opensubtitles.LoginRequest{Username: cfg.Username, Password: cfg.Password}
+	}
+	if cfg.TokenFile != "-" {
+		config.TokenStore = &opensubtitles.FileTokenStore{Path: cfg.TokenFile}
+	}
+	return config
+}
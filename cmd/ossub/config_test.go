@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"api_key": "file-key", "username": "alice", "password": "secret"}`), 0o600))
+	env := map[string]string{"OPENSUBTITLES_API_KEY": "env-key"}
+
+	cfg, err := loadConfig(path, func(key string) (string, bool) { v, ok := env[key]; return v, ok })
+	require.NoError(t, err)
+	assert.Equal(t, "env-key", cfg.ApiKey) // Environment overrides the file
+	assert.Equal(t, "alice", cfg.Username)
+	assert.Equal(t, defaultUserAgent, cfg.UserAgent)
+	assert.Equal(t, filepath.Join(dir, "token.json"), cfg.TokenFile)
+
+	config := cfg.clientConfig()
+	require.NotNil(t, config.Credentials)
+	assert.Equal(t, "secret", config.Credentials.Password)
+	assert.NotNil(t, config.TokenStore)
+}
+
+func TestLoadConfigMissingFileAndNoTokenCache(t *testing.T) {
+	env := map[string]string{"OPENSUBTITLES_TOKEN_FILE": "-"}
+	cfg, err := loadConfig(filepath.Join(t.TempDir(), "missing.json"), func(key string) (string, bool) { v, ok := env[key]; return v, ok })
+	require.NoError(t, err)
+	assert.Empty(t, cfg.ApiKey)
+	config := cfg.clientConfig()
+	assert.Nil(t, config.TokenStore)
+	assert.Nil(t, config.Credentials)
+}
+
+func TestLoadConfigInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{`), 0o600))
+	_, err := loadConfig(path, func(string) (string, bool) { return "", false })
+	assert.ErrorContains(t, err, "failed to decode config file")
+}
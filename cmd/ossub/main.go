@@ -0,0 +1,310 @@
+// Command ossub searches, downloads and uploads subtitles on OpenSubtitles from the
+// command line. It is built on the opensubtitles library and meant for scripts:
+// results are printed as a table, as JSON (-output json, or -json) or as one JSON
+// object per line as they arrive (-output ndjson), for pipelines with jq.
+//
+// Usage:
+//
+//	ossub [-config FILE] [-output table|json|ndjson] [-v] COMMAND [ARGS]
+//
+// Commands:
+//
+//	search      Search subtitles by query, IMDb ID or video file hash
+//	download    Download subtitle files by file ID, converted to UTF-8
+//	upload      Upload a subtitle file
+//	guessit     Parse release names into title, year, season and episode
+//	hash        Print the OSDb hash of video files or http(s) URLs
+//	whoami      Print the logged-in user and remaining downloads
+//	completion  Print the bash, zsh or fish completion script
+//
+// Credentials are read from the JSON config file (default: ossub/config.json in the
+// user config directory) with the keys api_key, user_agent, base_url, username,
+// password and token_file, and from the variables OPENSUBTITLES_API_KEY,
+// OPENSUBTITLES_USER_AGENT, OPENSUBTITLES_BASE_URL, OPENSUBTITLES_USERNAME,
+// OPENSUBTITLES_PASSWORD and OPENSUBTITLES_TOKEN_FILE, which take precedence.
+// The login token is cached in token_file so repeated runs do not log in again.
+//
+// Exit codes are stable, so scripts can branch on the kind of failure:
+//
+//	0  Success
+//	1  Any other error
+//	2  Invalid command line
+//	3  Authentication: missing or rejected API key or credentials, expired token
+//	4  Quota: download quota exhausted, or still rate limited after retries
+//	5  Not found: the subtitle, file or feature does not exist
+//	6  Network: connection failure, timeout, or the API is unavailable (5xx)
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+)
+
+// errUsage is returned for invalid command lines; the usage has already been printed.
+var errUsage = errors.New("invalid usage")
+
+// Errors for missing credentials; they exit with exitAuth.
+var (
+	errNoAPIKey      = errors.New("no API key")
+	errLoginRequired = errors.New("this command requires login")
+)
+
+// Exit codes; see the package documentation.
+const (
+	exitError    = 1
+	exitUsage    = 2
+	exitAuth     = 3
+	exitQuota    = 4
+	exitNotFound = 5
+	exitNetwork  = 6
+)
+
+// command is a subcommand of ossub.
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, app *app, args []string) error
+}
+
+var commands = []command{
+	{"search", "Search subtitles by query, IMDb ID or video file hash", runSearch},
+	{"download", "Download subtitle files by file ID, converted to UTF-8", runDownload},
+	{"upload", "Upload a subtitle file", runUpload},
+	{"guessit", "Parse release names into title, year, season and episode", runGuessit},
+	{"hash", "Print the OSDb hash of video files or http(s) URLs", runHash},
+	{"whoami", "Print the logged-in user and remaining downloads", runWhoami},
+	// completion is added by init in completion.go.
+}
+
+// Output formats of the -output flag.
+const (
+	outputTable  = "table"
+	outputJSON   = "json"
+	outputNDJSON = "ndjson"
+)
+
+// app holds the state shared by all commands.
+type app struct {
+	stdout, stderr io.Writer
+	lookupEnv      func(string) (string, bool)
+	configPath     string
+	output         string
+	verbose        bool
+	config         fileConfig
+	client         *opensubtitles.Client
+	// globalFlags and commandFlags are the flag sets of ossub and of the running
+	// command, read by the completion command.
+	globalFlags, commandFlags *flag.FlagSet
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	a := &app{stdout: os.Stdout, stderr: os.Stderr, lookupEnv: os.LookupEnv}
+	if err := a.run(ctx, os.Args[1:]); err != nil {
+		if !errors.Is(err, errUsage) {
+			fmt.Fprintln(os.Stderr, "ossub:", err)
+		}
+		os.Exit(exitCode(err))
+	}
+}
+
+// exitCode returns the exit code of the failure class of err.
+func exitCode(err error) int {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, errUsage):
+		return exitUsage
+	case errors.Is(err, errNoAPIKey), errors.Is(err, errLoginRequired), errors.Is(err, opensubtitles.ErrUnauthorized),
+		errors.Is(err, opensubtitles.ErrForbidden), errors.Is(err, opensubtitles.ErrInvalidApiKey),
+		errors.Is(err, opensubtitles.ErrTokenExpired):
+		return exitAuth
+	case errors.Is(err, opensubtitles.ErrQuotaExceeded), errors.Is(err, opensubtitles.ErrRateLimited):
+		return exitQuota
+	case errors.Is(err, opensubtitles.ErrNotFound), errors.Is(err, opensubtitles.ErrNoSubtitleFound):
+		return exitNotFound
+	case errors.As(err, &netErr), errors.Is(err, opensubtitles.ErrServiceUnavailable),
+		errors.Is(err, context.DeadlineExceeded):
+		return exitNetwork
+	}
+	return exitError
+}
+
+// run parses the global flags and runs the selected command.
+func (a *app) run(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("ossub", flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	a.globalFlags = flags
+	flags.StringVar(&a.configPath, "config", defaultConfigPath(), "path of the JSON config file")
+	flags.StringVar(&a.output, "output", outputTable, "result format: table, json, or ndjson for one JSON object per line")
+	jsonOutput := flags.Bool("json", false, "print results as JSON, like -output json")
+	flags.BoolVar(&a.verbose, "v", false, "log API requests to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(a.stderr, "Usage: ossub [-config FILE] [-output table|json|ndjson] [-v] COMMAND [ARGS]\n\nCommands:")
+		for _, cmd := range commands {
+			fmt.Fprintf(a.stderr, "  %-11s %s\n", cmd.name, cmd.summary)
+		}
+		fmt.Fprintln(a.stderr, "\nGlobal flags:")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if *jsonOutput {
+		a.output = outputJSON
+	}
+	switch a.output {
+	case outputTable, outputJSON, outputNDJSON:
+	default:
+		fmt.Fprintf(a.stderr, "ossub: invalid output format %q\n", a.output)
+		flags.Usage()
+		return errUsage
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errUsage
+	}
+
+	name := flags.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		return cmd.run(ctx, a, flags.Args()[1:])
+	}
+	fmt.Fprintf(a.stderr, "ossub: unknown command %q\n", name)
+	flags.Usage()
+	return errUsage
+}
+
+// connect loads the configuration and creates the API client.
+func (a *app) connect() error {
+	cfg, err := loadConfig(a.configPath, a.lookupEnv)
+	if err != nil {
+		return err
+	}
+	if cfg.ApiKey == "" {
+		return fmt.Errorf("%w: set api_key in '%s' or OPENSUBTITLES_API_KEY", errNoAPIKey, a.configPath)
+	}
+	config := cfg.clientConfig()
+	if a.verbose {
+		config.Logger = slog.New(slog.NewTextHandler(a.stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	client, err := opensubtitles.NewClient(config)
+	if err != nil {
+		return err
+	}
+	a.config, a.client = cfg, client
+	return nil
+}
+
+// login connects and logs in with the configured credentials unless a cached token
+// was loaded.
+func (a *app) login(ctx context.Context) error {
+	if err := a.connect(); err != nil {
+		return err
+	}
+	if token := a.client.GetCurrentToken(); token != nil && *token != "" {
+		return nil
+	}
+	if a.config.Username == "" || a.config.Password == "" {
+		return fmt.Errorf("%w: set username and password in the config file or OPENSUBTITLES_USERNAME and OPENSUBTITLES_PASSWORD", errLoginRequired)
+	}
+	_, err := a.client.Login(ctx, opensubtitles.LoginRequest{Username: a.config.Username, Password: a.config.Password})
+	return err
+}
+
+// newFlagSet returns the flag set of a command, printing errors and usage to stderr.
+func (a *app) newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet("ossub "+name, flag.ContinueOnError)
+	flags.SetOutput(a.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(a.stderr, "Usage: ossub %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+	a.commandFlags = flags
+	return flags
+}
+
+// print writes the single result v as indented JSON with -output json, as one line
+// of JSON with -output ndjson, or else rows as a table under header.
+func (a *app) print(v interface{}, header []string, rows [][]string) error {
+	switch a.output {
+	case outputJSON:
+		enc := json.NewEncoder(a.stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(v)
+	case outputNDJSON:
+		return json.NewEncoder(a.stdout).Encode(v)
+	}
+	w := tabwriter.NewWriter(a.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, strings.Join(header, "\t"))
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
+
+// resultWriter prints the results of a command that produces many. With -output
+// ndjson every result is written as a line of JSON as soon as it is added, so a
+// pipeline can process it before the command finishes; the other formats are
+// written by flush.
+type resultWriter struct {
+	app    *app
+	header []string
+	values []interface{}
+	rows   [][]string
+}
+
+// results returns a resultWriter for a table with the columns of header.
+func (a *app) results(header ...string) *resultWriter {
+	return &resultWriter{app: a, header: header}
+}
+
+// add writes or collects the result v and its table row. A nil row leaves the
+// result out of the table.
+func (w *resultWriter) add(v interface{}, row []string) error {
+	if w.app.output == outputNDJSON {
+		return json.NewEncoder(w.app.stdout).Encode(v)
+	}
+	w.values = append(w.values, v)
+	if row != nil {
+		w.rows = append(w.rows, row)
+	}
+	return nil
+}
+
+// flush prints the collected results. With -output json it prints whole instead if
+// it is not nil, e.g. a response with its pagination, or else the results as an array.
+func (w *resultWriter) flush(whole interface{}) error {
+	if w.app.output == outputNDJSON {
+		return nil
+	}
+	if whole == nil {
+		whole = w.values
+	}
+	return w.app.print(whole, w.header, w.rows)
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
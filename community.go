@@ -0,0 +1,80 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Methods related to community feedback (Reports, Subtitle Requests)
+
+var (
+	// ErrInvalidReport is returned when a ReportRequest fails client-side validation.
+	ErrInvalidReport = errors.New("opensubtitles: invalid subtitle report")
+	// ErrInvalidAddRequest is returned when AddRequestParams fail client-side validation.
+	ErrInvalidAddRequest = errors.New("opensubtitles: invalid subtitle request")
+)
+
+// ReportSubtitle flags a bad subtitle, e.g. one that is out of sync or belongs to
+// another movie. ReportOther requires a comment explaining the problem.
+// Requires authentication.
+func (c *Client) ReportSubtitle(ctx context.Context, params ReportRequest) (*ReportResponse, error) {
+	params.SubtitleID = strings.TrimSpace(params.SubtitleID)
+	params.Comment = strings.TrimSpace(params.Comment)
+	if params.SubtitleID == "" {
+		return nil, fmt.Errorf("%w: subtitle ID is required", ErrInvalidReport)
+	}
+	switch params.Reason {
+	case ReportWrongSync, ReportWrongMovie, ReportWrongLanguage, ReportBadTranslation, ReportMachineTranslated:
+	case ReportOther:
+		if params.Comment == "" {
+			return nil, fmt.Errorf("%w: reason %q requires a comment", ErrInvalidReport, params.Reason)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown reason %q", ErrInvalidReport, params.Reason)
+	}
+
+	var response ReportResponse
+	if err := c.httpClient.Post(ctx, "/report", params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// AddRequest asks the community for subtitles of a feature in the given languages,
+// for features that have none yet. Language codes are lower-cased and de-duplicated.
+// Requires authentication.
+func (c *Client) AddRequest(ctx context.Context, params AddRequestParams) (*AddRequestResponse, error) {
+	switch {
+	case params.FeatureID < 0 || params.IMDbID < 0:
+		return nil, fmt.Errorf("%w: feature_id and imdb_id must be positive", ErrInvalidAddRequest)
+	case params.FeatureID == 0 && params.IMDbID == 0:
+		return nil, fmt.Errorf("%w: feature_id or imdb_id is required", ErrInvalidAddRequest)
+	case params.FeatureID != 0 && params.IMDbID != 0:
+		return nil, fmt.Errorf("%w: feature_id and imdb_id are mutually exclusive", ErrInvalidAddRequest)
+	}
+	seen := make(map[LanguageCode]bool)
+	var languages []LanguageCode
+	for _, lang := range params.Languages {
+		lang = LanguageCode(strings.ToLower(strings.TrimSpace(string(lang))))
+		if lang == "" || seen[lang] {
+			continue
+		}
+		if !languagePattern.MatchString(string(lang)) {
+			return nil, fmt.Errorf("%w: %q is not a language code", ErrInvalidAddRequest, lang)
+		}
+		seen[lang] = true
+		languages = append(languages, lang)
+	}
+	if len(languages) == 0 {
+		return nil, fmt.Errorf("%w: at least one language is required", ErrInvalidAddRequest)
+	}
+	params.Languages = languages
+
+	var response AddRequestResponse
+	if err := c.httpClient.Post(ctx, "/requests", params, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
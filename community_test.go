@@ -0,0 +1,81 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReportSubtitle(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/report", r.URL.Path)
+		var body ReportRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, ReportRequest{SubtitleID: "123", Reason: ReportOther, Comment: "ads in line 1"}, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(ReportResponse{Status: 200, Message: "Report received"})
+	}
+	_, client := setupTestServer(t, handler)
+
+	resp, err := client.ReportSubtitle(context.Background(), ReportRequest{SubtitleID: " 123 ", Reason: ReportOther, Comment: "ads in line 1"})
+	require.NoError(t, err)
+	assert.Equal(t, "Report received", resp.Message)
+}
+
+func TestReportSubtitleValidation(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	tests := []ReportRequest{
+		{Reason: ReportWrongSync},
+		{SubtitleID: "1", Reason: "boring"},
+		{SubtitleID: "1", Reason: ReportOther, Comment: "  "},
+	}
+	for _, params := range tests {
+		_, err := client.ReportSubtitle(context.Background(), params)
+		assert.ErrorIs(t, err, ErrInvalidReport, "%+v", params)
+	}
+}
+
+func TestAddRequest(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/requests", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, map[string]interface{}{"imdb_id": float64(113277), "languages": []interface{}{"el", "pt-br"}}, body)
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"status":200,"message":"Request added","data":{"request_id":"77","feature_id":6547,"languages":["el","pt-br"],"release":"","created_at":"2024-05-01T10:00:00Z"}}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	resp, err := client.AddRequest(context.Background(), AddRequestParams{IMDbID: 113277, Languages: []LanguageCode{"EL", "pt-br", "el"}})
+	require.NoError(t, err)
+	assert.Equal(t, "77", resp.Data.RequestID)
+	assert.Equal(t, 6547, resp.Data.FeatureID)
+	assert.Equal(t, []LanguageCode{"el", "pt-br"}, resp.Data.Languages)
+}
+
+func TestAddRequestValidation(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request to %s", r.URL.Path)
+	})
+	tests := []AddRequestParams{
+		{Languages: []LanguageCode{"en"}},
+		{FeatureID: 1, IMDbID: 2, Languages: []LanguageCode{"en"}},
+		{FeatureID: -1, Languages: []LanguageCode{"en"}},
+		{FeatureID: 1},
+		{FeatureID: 1, Languages: []LanguageCode{"english"}},
+	}
+	for _, params := range tests {
+		_, err := client.AddRequest(context.Background(), params)
+		assert.ErrorIs(t, err, ErrInvalidAddRequest, "%+v", params)
+	}
+}
@@ -0,0 +1,82 @@
+package opensubtitles
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// LanguageCoverage is the number of subtitles per language of a feature, as
+// reported in its SubtitlesCounts.
+type LanguageCoverage struct {
+	FeatureID   string
+	FeatureType FeatureType
+	Title       string
+	Total       int // All subtitles of the feature
+	Counts      SubtitleCounts
+}
+
+// GetLanguageCoverage returns the per-language subtitle counts of the feature with
+// ID featureID, e.g. for translators deciding what to translate next. It returns
+// ErrFeatureNotFound if the API does not know the feature.
+func (c *Client) GetLanguageCoverage(ctx context.Context, featureID int) (*LanguageCoverage, error) {
+	feature, err := c.getFeatureByID(ctx, FeatureIDRef{FeatureID: featureID})
+	if err != nil {
+		return nil, err
+	}
+	coverage := &LanguageCoverage{Counts: SubtitleCounts{}}
+	if base := featureBase(*feature); base != nil {
+		coverage.FeatureID = base.FeatureID
+		coverage.FeatureType = base.FeatureType
+		coverage.Title = base.Title
+		coverage.Total = base.SubtitlesCount
+		for lang, n := range base.SubtitlesCounts {
+			coverage.Counts[lang] = n
+		}
+	}
+	return coverage, nil
+}
+
+// Count returns the number of subtitles in lang, matching language codes
+// case-insensitively.
+func (lc LanguageCoverage) Count(lang LanguageCode) int {
+	if n, ok := lc.Counts[lang]; ok {
+		return n
+	}
+	for code, n := range lc.Counts {
+		if strings.EqualFold(string(code), string(lang)) {
+			return n
+		}
+	}
+	return 0
+}
+
+// MissingLanguages returns the languages of wanted without any subtitle, in the
+// order given.
+func (lc LanguageCoverage) MissingLanguages(wanted []LanguageCode) []LanguageCode {
+	var missing []LanguageCode
+	for _, lang := range wanted {
+		if lc.Count(lang) == 0 {
+			missing = append(missing, lang)
+		}
+	}
+	return missing
+}
+
+// Languages returns the languages with subtitles, the best covered first; languages
+// with the same count are sorted by code.
+func (lc LanguageCoverage) Languages() []LanguageCode {
+	langs := make([]LanguageCode, 0, len(lc.Counts))
+	for lang, n := range lc.Counts {
+		if n > 0 {
+			langs = append(langs, lang)
+		}
+	}
+	sort.Slice(langs, func(i, j int) bool {
+		if ni, nj := lc.Counts[langs[i]], lc.Counts[langs[j]]; ni != nj {
+			return ni > nj
+		}
+		return langs[i] < langs[j]
+	})
+	return langs
+}
@@ -0,0 +1,35 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetLanguageCoverage(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/features", r.URL.Path)
+		if r.URL.Query().Get("feature_id") != "646135" {
+			_, _ = w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"id":"646135","type":"feature","attributes":{
+			"feature_id":"646135","feature_type":"Movie","title":"Heat","year":"1995",
+			"subtitles_count":19,"subtitles_counts":{"en":10,"el":3,"pt-BR":6,"fr":0}}}]}`))
+	})
+
+	coverage, err := client.GetLanguageCoverage(context.Background(), 646135)
+	require.NoError(t, err)
+	assert.Equal(t, "Heat", coverage.Title)
+	assert.Equal(t, FeatureMovie, coverage.FeatureType)
+	assert.Equal(t, 19, coverage.Total)
+	assert.Equal(t, 6, coverage.Count("pt-br"), "codes match case-insensitively")
+	assert.Equal(t, []LanguageCode{"en", "pt-BR", "el"}, coverage.Languages())
+	assert.Equal(t, []LanguageCode{"de", "fr"}, coverage.MissingLanguages([]LanguageCode{"EN", "de", "el", "fr"}))
+
+	_, err = client.GetLanguageCoverage(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrFeatureNotFound)
+}
@@ -0,0 +1,84 @@
+package opensubtitles
+
+import (
+	"context"
+	"sync"
+)
+
+// flightGroup collapses concurrent calls with the same key into one, in the manner
+// of golang.org/x/sync/singleflight. Unlike singleflight, a caller whose context
+// ends stops waiting on its own, and the shared call is only canceled once every
+// caller has stopped waiting.
+type flightGroup struct {
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is a call in progress.
+type flight struct {
+	done    chan struct{} // Closed when value and err are set
+	value   any
+	err     error
+	waiters int                // Callers still waiting; protected by flightGroup.mu
+	cancel  context.CancelFunc // Cancels the shared call
+}
+
+// do calls fn once for all concurrent callers with the same key and returns its
+// result to each. fn runs with the values of the first caller's context but not its
+// deadline or cancelation, so that the other callers are not cut short with it.
+func (g *flightGroup) do(ctx context.Context, key string, fn func(ctx context.Context) (any, error)) (any, error) {
+	g.mu.Lock()
+	if g.flights == nil {
+		g.flights = make(map[string]*flight)
+	}
+	f, ok := g.flights[key]
+	if !ok {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &flight{done: make(chan struct{}), cancel: cancel}
+		g.flights[key] = f
+		go func() {
+			f.value, f.err = fn(callCtx)
+			g.mu.Lock()
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+			g.mu.Unlock()
+			cancel()
+			close(f.done)
+		}()
+	}
+	f.waiters++
+	g.mu.Unlock()
+
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		g.mu.Lock()
+		f.waiters--
+		if f.waiters == 0 {
+			// Nobody wants the result any more; later callers start a new call.
+			if g.flights[key] == f {
+				delete(g.flights, key)
+			}
+			f.cancel()
+		}
+		g.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// dedupe runs fn through the client's search flightGroup under key when
+// Config.EnableSearchDedup is set. Each caller gets its own copy of the result made by
+// clone. Calls whose context carries request headers or a ResponseMeta are sent on
+// their own, since a shared request would only use those of the first caller.
+func dedupe[T any](ctx context.Context, c *Client, key string, fn func(ctx context.Context) (*T, error), clone func(*T) *T) (*T, error) {
+	if !c.config.EnableSearchDedup || RequestHeaders(ctx) != nil || responseMetaFrom(ctx) != nil {
+		return fn(ctx)
+	}
+	value, err := c.searches.do(ctx, key, func(ctx context.Context) (any, error) { return fn(ctx) })
+	if err != nil {
+		return nil, err
+	}
+	return clone(value.(*T)), nil
+}
@@ -0,0 +1,157 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingSearchServer answers searches once release is closed and counts them.
+func blockingSearchServer(t *testing.T, config Config) (*Client, *atomic.Int32, chan struct{}) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server, _ := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte(`{"total_count":1,"data":[{"id":"` + r.URL.Query().Get("query") + `"}]}`))
+	})
+	config.ApiKey, config.BaseURL, config.RateLimit = "test-api-key", server.URL+"/api/v1", &testRateLimits
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	return client, &calls, release
+}
+
+// waitForWaiters waits until n callers wait for the search with key.
+func waitForWaiters(t *testing.T, client *Client, key string, n int) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		client.searches.mu.Lock()
+		defer client.searches.mu.Unlock()
+		f := client.searches.flights[key]
+		return f != nil && f.waiters == n
+	}, time.Second, time.Millisecond)
+}
+
+func TestSearchDedup(t *testing.T) {
+	t.Run("ConcurrentIdenticalSearchesShareARequest", func(t *testing.T) {
+		client, calls, release := blockingSearchServer(t, Config{EnableSearchDedup: true})
+		params := SearchSubtitlesParams{Query: String("heat")}
+		responses := make([]*SearchSubtitlesResponse, 5)
+		var wg sync.WaitGroup
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				resp, err := client.SearchSubtitles(context.Background(), params)
+				assert.NoError(t, err)
+				responses[i] = resp
+			}(i)
+		}
+		waitForWaiters(t, client, "/subtitles?query=heat", 5)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), calls.Load())
+		for _, resp := range responses[1:] {
+			assert.NotSame(t, responses[0], resp, "each caller gets its own copy")
+			assert.Equal(t, responses[0], resp)
+		}
+		responses[0].Data[0] = Subtitle{}
+		assert.Equal(t, "heat", responses[1].Data[0].ID, "modifying Data does not affect the other callers")
+
+		// The finished search is not cached.
+		_, err := client.SearchSubtitles(context.Background(), params)
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("DifferentSearchesAreNotShared", func(t *testing.T) {
+		client, calls, release := blockingSearchServer(t, Config{EnableSearchDedup: true})
+		var wg sync.WaitGroup
+		for _, q := range []string{"heat", "ronin"} {
+			wg.Add(1)
+			go func(q string) {
+				defer wg.Done()
+				resp, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Query: String(q)})
+				assert.NoError(t, err)
+				assert.Equal(t, q, resp.Data[0].ID)
+			}(q)
+		}
+		waitForWaiters(t, client, "/subtitles?query=heat", 1)
+		waitForWaiters(t, client, "/subtitles?query=ronin", 1)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(2), calls.Load())
+	})
+
+	t.Run("CanceledCallerDoesNotCancelOthers", func(t *testing.T) {
+		client, calls, release := blockingSearchServer(t, Config{EnableSearchDedup: true})
+		params := SearchFeaturesParams{Query: String("heat")}
+		ctx, cancel := context.WithCancel(context.Background())
+		canceled := make(chan error)
+		go func() {
+			_, err := client.SearchFeatures(ctx, params)
+			canceled <- err
+		}()
+		waitForWaiters(t, client, "/features?query=heat", 1)
+
+		var resp *SearchFeaturesResponse
+		done := make(chan error)
+		go func() {
+			var err error
+			resp, err = client.SearchFeatures(context.Background(), params)
+			done <- err
+		}()
+		waitForWaiters(t, client, "/features?query=heat", 2)
+		cancel()
+		assert.True(t, errors.Is(<-canceled, context.Canceled))
+
+		close(release)
+		require.NoError(t, <-done)
+		assert.Len(t, resp.Data, 1)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("PerCallHeadersAreNotShared", func(t *testing.T) {
+		client, calls, release := blockingSearchServer(t, Config{EnableSearchDedup: true})
+		var wg sync.WaitGroup
+		for _, ctx := range []context.Context{
+			WithRequestID(context.Background(), "a"),
+			WithRequestID(context.Background(), "b"),
+			WithResponseMeta(context.Background(), &ResponseMeta{}),
+		} {
+			wg.Add(1)
+			go func(ctx context.Context) {
+				defer wg.Done()
+				_, err := client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat")})
+				assert.NoError(t, err)
+			}(ctx)
+		}
+		require.Eventually(t, func() bool { return calls.Load() == 3 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+
+	t.Run("DisabledByDefault", func(t *testing.T) {
+		client, calls, release := blockingSearchServer(t, Config{})
+		var wg sync.WaitGroup
+		for i := 0; i < 3; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Query: String("heat")})
+				assert.NoError(t, err)
+			}()
+		}
+		require.Eventually(t, func() bool { return calls.Load() == 3 }, time.Second, time.Millisecond)
+		close(release)
+		wg.Wait()
+	})
+}
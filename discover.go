@@ -1,6 +1,13 @@
 package opensubtitles
 
-import "context"
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/go-querystring/query"
+)
 
 // Methods related to discovery endpoints (Popular, Latest, MostDownloaded)
 
@@ -33,3 +40,87 @@ func (c *Client) DiscoverMostDownloaded(ctx context.Context, params DiscoverPara
 	}
 	return &response, nil
 }
+
+// DiscoverCursor marks the newest subtitles a DiscoverLatestSince caller has seen.
+// It can be stored, e.g. as JSON, to continue after a restart.
+type DiscoverCursor struct {
+	UploadDate time.Time // Upload date of the newest subtitle seen
+	IDs        []string  // IDs of the subtitles seen with that upload date
+}
+
+// IsZero reports whether c marks nothing as seen.
+func (c DiscoverCursor) IsZero() bool {
+	return c.UploadDate.IsZero() && len(c.IDs) == 0
+}
+
+// DiscoverLatestSinceResult holds the subtitles DiscoverLatestSince found new.
+type DiscoverLatestSinceResult struct {
+	New    []Subtitle     // Newest first
+	Cursor DiscoverCursor // Pass to the next call
+	// Gap reports that every subtitle of the latest page is new, so more may have been
+	// added than the page holds and some were missed; poll more often.
+	Gap bool
+}
+
+// DiscoverLatestSince calls DiscoverLatest and returns only the subtitles uploaded
+// after lastSeen, e.g. for a poller of new subtitles in one language. With a zero
+// lastSeen, it continues from the previous call with the same params on this client,
+// whose cursor the client keeps; the first call then returns the whole page. The API
+// lists only the latest 60 subtitles, so callers must poll often enough; see
+// DiscoverLatestSinceResult.Gap. Failed requests are retried like every GET request;
+// the cursor only advances on success.
+func (c *Client) DiscoverLatestSince(ctx context.Context, params DiscoverParams, lastSeen DiscoverCursor) (*DiscoverLatestSinceResult, error) {
+	values, err := query.Values(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query parameters: %w", err)
+	}
+	key := values.Encode()
+	if lastSeen.IsZero() {
+		c.discoverMu.Lock()
+		lastSeen = c.latestCursors[key]
+		c.discoverMu.Unlock()
+	}
+
+	resp, err := c.DiscoverLatest(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result := lastSeen.Since(resp.Data)
+
+	c.discoverMu.Lock()
+	if c.latestCursors == nil {
+		c.latestCursors = make(map[string]DiscoverCursor)
+	}
+	c.latestCursors[key] = result.Cursor
+	c.discoverMu.Unlock()
+	return result, nil
+}
+
+// Since returns the subtitles of page that c does not mark as seen and the cursor
+// covering them. It applies to any list of subtitles, e.g. a SearchSubtitles page
+// ordered by OrderByUploadDate.
+func (c DiscoverCursor) Since(page []Subtitle) *DiscoverLatestSinceResult {
+	seen := make(map[string]bool, len(c.IDs))
+	for _, id := range c.IDs {
+		seen[id] = true
+	}
+	result := &DiscoverLatestSinceResult{Cursor: c}
+	for _, sub := range page {
+		date := sub.Attributes.UploadDate
+		if date.Before(c.UploadDate) || (date.Equal(c.UploadDate) && seen[sub.ID]) {
+			continue
+		}
+		result.New = append(result.New, sub)
+		switch {
+		case date.After(result.Cursor.UploadDate):
+			result.Cursor = DiscoverCursor{UploadDate: date, IDs: []string{sub.ID}}
+		case date.Equal(result.Cursor.UploadDate):
+			result.Cursor.IDs = append(append([]string(nil), result.Cursor.IDs...), sub.ID)
+		}
+	}
+	sort.SliceStable(result.New, func(i, j int) bool {
+		return result.New[i].Attributes.UploadDate.After(result.New[j].Attributes.UploadDate)
+	})
+	result.Gap = !c.IsZero() && len(page) > 0 && len(result.New) == len(page)
+	return result
+}
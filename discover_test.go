@@ -7,6 +7,7 @@ import (
 
 	// "net/url"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,7 +60,7 @@ func TestDiscoverPopularSuccess(t *testing.T) {
 	var movieAttrs FeatureMovieAttributes
 	err = json.Unmarshal(rawMovie, &movieAttrs)
 	require.NoError(t, err)
-	assert.Equal(t, "Movie", movieAttrs.FeatureType)
+	assert.Equal(t, FeatureMovie, movieAttrs.FeatureType)
 	assert.Equal(t, "514811", movieAttrs.FeatureID)
 
 	// Check second feature (TVShow)
@@ -67,7 +68,7 @@ func TestDiscoverPopularSuccess(t *testing.T) {
 	var tvAttrs FeatureTvshowAttributes
 	err = json.Unmarshal(rawTV, &tvAttrs)
 	require.NoError(t, err)
-	assert.Equal(t, "Tvshow", tvAttrs.FeatureType)
+	assert.Equal(t, FeatureTVShow, tvAttrs.FeatureType)
 	assert.Equal(t, "644054", tvAttrs.FeatureID)
 	assert.Equal(t, 11, tvAttrs.SeasonsCount)
 }
@@ -223,3 +224,55 @@ func TestDiscoverMostDownloadedError(t *testing.T) {
 	assert.Nil(t, resp)
 	assert.Contains(t, err.Error(), "status 503")
 }
+
+func TestDiscoverLatestSince(t *testing.T) {
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	sub := func(id string, minutes int) Subtitle {
+		return Subtitle{ApiDataWrapper: ApiDataWrapper{ID: id, Type: "subtitle"}, Attributes: SubtitleAttributes{UploadDate: base.Add(time.Duration(minutes) * time.Minute)}}
+	}
+	var page []Subtitle
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/discover/latest", r.URL.Path)
+		require.NoError(t, json.NewEncoder(w).Encode(DiscoverLatestResponse{Data: page}))
+	})
+	ids := func(subs []Subtitle) []string {
+		var out []string
+		for _, s := range subs {
+			out = append(out, s.ID)
+		}
+		return out
+	}
+	ctx := context.Background()
+	en := LanguageCode("en")
+	params := DiscoverParams{Language: &en}
+
+	page = []Subtitle{sub("b", 1), sub("a", 0)}
+	first, err := client.DiscoverLatestSince(ctx, params, DiscoverCursor{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "a"}, ids(first.New), "the first call returns the whole page")
+	assert.Equal(t, DiscoverCursor{UploadDate: base.Add(time.Minute), IDs: []string{"b"}}, first.Cursor)
+	assert.False(t, first.Gap)
+
+	// "c" shares the upload date of "b"; only unseen subtitles are returned.
+	page = []Subtitle{sub("d", 2), sub("c", 1), sub("b", 1), sub("a", 0)}
+	second, err := client.DiscoverLatestSince(ctx, params, DiscoverCursor{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, ids(second.New), "the client continues from its previous call")
+	assert.Equal(t, DiscoverCursor{UploadDate: base.Add(2 * time.Minute), IDs: []string{"d"}}, second.Cursor)
+
+	// Other params have their own cursor.
+	all, err := client.DiscoverLatestSince(ctx, DiscoverParams{}, DiscoverCursor{})
+	require.NoError(t, err)
+	assert.Len(t, all.New, 4)
+
+	// An explicit cursor, e.g. loaded after a restart, takes precedence.
+	resumed, err := client.DiscoverLatestSince(ctx, params, first.Cursor)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"d", "c"}, ids(resumed.New))
+
+	page = []Subtitle{sub("f", 4), sub("e", 3)}
+	gap, err := client.DiscoverLatestSince(ctx, params, DiscoverCursor{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"f", "e"}, ids(gap.New))
+	assert.True(t, gap.Gap, "everything on the page is new, so subtitles may have been missed")
+}
@@ -0,0 +1,324 @@
+package opensubtitles
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/charset"
+	"github.com/angelospk/opensubtitles-go/envconfig"
+	"github.com/angelospk/opensubtitles-go/naming"
+	"github.com/angelospk/opensubtitles-go/storage"
+)
+
+// DefaultDownloadWorkers is the number of concurrent downloads used by DownloadFiles
+// when DownloadFilesOptions.Workers is not set.
+const DefaultDownloadWorkers = 4
+
+// DownloadFilesOptions configures DownloadFiles.
+type DownloadFilesOptions struct {
+	// Dir is the directory files are written to when Sink is nil (default: current directory).
+	Dir string
+	// Sink receives the files instead of Dir, e.g. a storage.S3Sink.
+	Sink storage.Sink
+	// Workers is the number of files downloaded concurrently (default: DefaultDownloadWorkers).
+	Workers int
+}
+
+// DownloadFilesOptionsFromEnv reads the DOWNLOAD_DIR and DOWNLOAD_WORKERS variables of l.
+func DownloadFilesOptionsFromEnv(l *envconfig.Loader) DownloadFilesOptions {
+	return DownloadFilesOptions{
+		Dir:     l.String("DOWNLOAD_DIR", ""),
+		Workers: l.Int("DOWNLOAD_WORKERS", 0),
+	}
+}
+
+// DownloadFileResult is the outcome of downloading a single file.
+type DownloadFileResult struct {
+	Request DownloadRequest
+	// Path is where the file was written: joined with Dir for the default sink,
+	// otherwise the name passed to Sink.Put.
+	Path string
+	// Remaining and ResetTime report the download quota after this file's link was requested.
+	Remaining int
+	ResetTime time.Time
+	Err       error
+}
+
+// DownloadFiles requests a download link for every request and fetches the files
+// concurrently, writing them under the file name returned by the API. When several
+// files of the batch have the same name, e.g. "Movie.en.srt", all but the first get
+// their file ID appended, as in "Movie.en.1234.srt".
+// Results are returned in the order of requests; per-file failures are reported in
+// DownloadFileResult.Err. The returned error is only set if ctx ends early.
+// Requires authentication.
+func (c *Client) DownloadFiles(ctx context.Context, requests []DownloadRequest, opts DownloadFilesOptions) ([]DownloadFileResult, error) {
+	sink := opts.Sink
+	if sink == nil {
+		sink = &storage.LocalSink{Root: opts.Dir}
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultDownloadWorkers
+	}
+	if workers > len(requests) {
+		workers = len(requests)
+	}
+
+	results := make([]DownloadFileResult, len(requests))
+	names := &batchNames{taken: make(map[string]bool)}
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = c.downloadFile(ctx, requests[i], sink, names, opts)
+			}
+		}()
+	}
+
+feed:
+	for i := range requests {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(requests); j++ {
+				results[j] = DownloadFileResult{Request: requests[j], Err: ctx.Err()}
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// batchNames hands out the file names of a DownloadFiles batch.
+type batchNames struct {
+	mu    sync.Mutex
+	taken map[string]bool
+}
+
+// claim returns name, or name with fileID before its extension if another file of
+// the batch already has name.
+func (n *batchNames) claim(name string, fileID int) string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.taken[name] {
+		ext := filepath.Ext(name)
+		name = strings.TrimSuffix(name, ext) + "." + strconv.Itoa(fileID) + ext
+	}
+	n.taken[name] = true
+	return name
+}
+
+// downloadFile requests the link for a single file and stores its content in sink.
+func (c *Client) downloadFile(ctx context.Context, req DownloadRequest, sink storage.Sink, names *batchNames, opts DownloadFilesOptions) DownloadFileResult {
+	result := DownloadFileResult{Request: req}
+	link, err := c.Download(ctx, req)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to request download link for file %d: %w", req.FileID, err)
+		return result
+	}
+	result.Remaining = link.Remaining
+	result.ResetTime = link.ResetTimeUTC
+
+	name := filepath.Base(link.FileName)
+	if name == "" || name == "." || name == string(filepath.Separator) {
+		name = strconv.Itoa(req.FileID) + ".srt"
+	}
+	name = names.claim(name, req.FileID)
+	body, err := c.httpClient.Fetch(ctx, link.Link)
+	if err != nil {
+		result.Err = fmt.Errorf("failed to fetch file %d: %w", req.FileID, err)
+		return result
+	}
+	defer body.Close()
+	if err := sink.Put(ctx, name, body); err != nil {
+		result.Err = fmt.Errorf("failed to store file %d: %w", req.FileID, err)
+		return result
+	}
+
+	result.Path = name
+	if opts.Sink == nil {
+		result.Path = filepath.Join(opts.Dir, name)
+	}
+	return result
+}
+
+// DownloadToFileOptions configures DownloadToFile.
+type DownloadToFileOptions struct {
+	// Encoding forces the source encoding, e.g. "windows-1253", instead of detecting it.
+	Encoding string
+	// LineEnding replaces every line ending in the file (default "\n").
+	LineEnding string
+	// KeepEncoding writes the file as downloaded, without conversion to UTF-8.
+	KeepEncoding bool
+	// Collisions, if set, decides what happens when destPath already exists, e.g.
+	// writing "Heat.en.1.srt" under naming.PolicyKeepBoth. It is applied before the
+	// download link is requested, so a skipped file costs no download quota. Nil
+	// overwrites the existing file. See naming.Template for building destPath.
+	Collisions *naming.CollisionResolver
+}
+
+// DownloadToFileResult describes a file written by DownloadToFile.
+type DownloadToFileResult struct {
+	Path string // Where the file was written, or the existing file if Skipped
+	// Skipped is set if Collisions kept an existing file; nothing was downloaded.
+	Skipped bool
+	// Encoding is the detected (or forced) encoding of the downloaded content.
+	Encoding charset.Encoding
+	// Remaining and ResetTime report the download quota after the link was requested.
+	Remaining int
+	ResetTime time.Time
+}
+
+// DownloadToFile requests a download link for req, fetches the file, converts it to
+// UTF-8 with normalized line endings and writes it to destPath. The source encoding
+// is detected with the charset package unless opts.Encoding is set. The file is
+// written to a temporary name first and renamed into place.
+// Requires authentication.
+func (c *Client) DownloadToFile(ctx context.Context, req DownloadRequest, destPath string, opts DownloadToFileOptions) (*DownloadToFileResult, error) {
+	var forced charset.Encoding
+	if opts.Encoding != "" {
+		var err error
+		if forced, err = charset.Lookup(opts.Encoding); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Collisions != nil {
+		resolution, err := opts.Collisions.Resolve(naming.Incoming{Path: destPath})
+		if err != nil {
+			return nil, err
+		}
+		if resolution.Action == naming.ActionSkip {
+			return &DownloadToFileResult{Path: resolution.Path, Skipped: true}, nil
+		}
+		destPath = resolution.Path
+	}
+
+	link, err := c.Download(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request download link for file %d: %w", req.FileID, err)
+	}
+	body, err := c.httpClient.Fetch(ctx, link.Link)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch file %d: %w", req.FileID, err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file %d: %w", req.FileID, err)
+	}
+
+	result := &DownloadToFileResult{Path: destPath, Remaining: link.Remaining, ResetTime: link.ResetTimeUTC}
+	result.Encoding = forced
+	if result.Encoding == "" {
+		result.Encoding = charset.Detect(data)
+	}
+	if !opts.KeepEncoding {
+		if data, err = charset.Decode(data, result.Encoding); err != nil {
+			return nil, err
+		}
+		data = charset.NormalizeLineEndings(data, opts.LineEnding)
+	}
+
+	sink := &storage.LocalSink{Root: filepath.Dir(destPath)}
+	if err := sink.Put(ctx, filepath.Base(destPath), bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("failed to write file %d: %w", req.FileID, err)
+	}
+	return result, nil
+}
+
+// ErrDownloadCorrupt is returned while reading a stream opened by OpenDownload when the
+// file is shorter or longer than its Content-Length, or does not match its Content-MD5.
+var ErrDownloadCorrupt = errors.New("opensubtitles: downloaded file is truncated or corrupt")
+
+// DownloadMeta describes a file opened by OpenDownload.
+type DownloadMeta struct {
+	FileName string
+	// ContentLength is the size announced by the server, or -1 if unknown.
+	ContentLength int64
+	ContentType   string
+	// MD5 is the hex-encoded Content-MD5 announced by the server, or empty.
+	MD5 string
+	// Remaining and ResetTime report the download quota after the link was requested.
+	Remaining int
+	ResetTime time.Time
+}
+
+// OpenDownload requests a download link for req and opens the file as a stream, so that
+// it can be copied elsewhere without buffering it in memory. The stream checks the
+// file's length and, if the server sends a Content-MD5 header, its checksum; a
+// mismatch is returned as ErrDownloadCorrupt by the Read that reaches the end of
+// the file. The caller must close the stream.
+// Requires authentication.
+func (c *Client) OpenDownload(ctx context.Context, req DownloadRequest) (io.ReadCloser, *DownloadMeta, error) {
+	link, err := c.Download(ctx, req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to request download link for file %d: %w", req.FileID, err)
+	}
+	file, err := c.httpClient.FetchFile(ctx, link.Link)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch file %d: %w", req.FileID, err)
+	}
+	meta := &DownloadMeta{
+		FileName:      link.FileName,
+		ContentLength: file.ContentLength,
+		ContentType:   file.Header.Get("Content-Type"),
+		Remaining:     link.Remaining,
+		ResetTime:     link.ResetTimeUTC,
+	}
+	stream := &verifyingReader{body: file.Body, length: file.ContentLength, fileID: req.FileID}
+	if sum, err := base64.StdEncoding.DecodeString(file.Header.Get("Content-MD5")); err == nil && len(sum) == md5.Size {
+		meta.MD5 = hex.EncodeToString(sum)
+		stream.wantMD5, stream.hash = sum, md5.New()
+	}
+	return stream, meta, nil
+}
+
+// verifyingReader checks the length and MD5 of a download once it is read to the end.
+type verifyingReader struct {
+	body    io.ReadCloser
+	fileID  int
+	length  int64 // -1 if unknown
+	read    int64
+	hash    hash.Hash // Nil without a Content-MD5
+	wantMD5 []byte
+}
+
+func (r *verifyingReader) Read(p []byte) (int, error) {
+	n, err := r.body.Read(p)
+	r.read += int64(n)
+	if r.hash != nil {
+		r.hash.Write(p[:n])
+	}
+	if r.length >= 0 && r.read > r.length {
+		return n, fmt.Errorf("%w: file %d is longer than %d bytes", ErrDownloadCorrupt, r.fileID, r.length)
+	}
+	if err == io.ErrUnexpectedEOF || (err == io.EOF && r.length >= 0 && r.read < r.length) {
+		return n, fmt.Errorf("%w: file %d ended after %d of %d bytes", ErrDownloadCorrupt, r.fileID, r.read, r.length)
+	}
+	if err == io.EOF && r.hash != nil && !bytes.Equal(r.hash.Sum(nil), r.wantMD5) {
+		return n, fmt.Errorf("%w: file %d does not match its MD5 checksum %x", ErrDownloadCorrupt, r.fileID, r.wantMD5)
+	}
+	return n, err
+}
+
+func (r *verifyingReader) Close() error {
+	return r.body.Close()
+}
@@ -0,0 +1,249 @@
+package opensubtitles
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/charset"
+	"github.com/angelospk/opensubtitles-go/naming"
+	"github.com/angelospk/opensubtitles-go/storage"
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadFiles(t *testing.T) {
+	var serverURL string
+	var remaining int32 = 10
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/download":
+			var req DownloadRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			if req.FileID == 404 {
+				http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(DownloadResponse{
+				Link:      fmt.Sprintf("%s/files/%d", serverURL, req.FileID),
+				FileName:  fmt.Sprintf("../sub-%d.srt", req.FileID), // Must not escape the sink
+				Remaining: int(atomic.AddInt32(&remaining, -1)),
+			})
+		case "/files/1", "/files/2":
+			assert.Empty(t, r.Header.Get("Api-Key"), "API key must not leak to download links")
+			_, _ = w.Write([]byte("1\n00:00:01,000 --> 00:00:02,000\nHello " + r.URL.Path + "\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}
+	server, client := setupTestServer(t, handler)
+	serverURL = server.URL
+
+	mem := vfs.NewMemFS()
+	requests := []DownloadRequest{{FileID: 1}, {FileID: 404}, {FileID: 2}}
+	results, err := client.DownloadFiles(context.Background(), requests, DownloadFilesOptions{
+		Sink:    &storage.LocalSink{Root: "subs", FS: mem},
+		Workers: 2,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, "sub-1.srt", results[0].Path)
+	assert.Error(t, results[1].Err)
+	assert.Equal(t, 404, results[1].Request.FileID)
+	assert.NoError(t, results[2].Err)
+	assert.Less(t, results[2].Remaining, 10)
+
+	data, err := fs.ReadFile(mem, filepath.Join("subs", "sub-2.srt"))
+	require.NoError(t, err)
+	assert.Contains(t, string(data), "Hello /files/2")
+}
+
+func TestDownloadFilesSameName(t *testing.T) {
+	var serverURL string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/download":
+			var req DownloadRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+			_ = json.NewEncoder(w).Encode(DownloadResponse{Link: fmt.Sprintf("%s/files/%d", serverURL, req.FileID), FileName: "Movie.en.srt"})
+		default:
+			_, _ = w.Write([]byte("content of " + r.URL.Path))
+		}
+	}
+	server, client := setupTestServer(t, handler)
+	serverURL = server.URL
+
+	dir := t.TempDir()
+	requests := []DownloadRequest{{FileID: 1}, {FileID: 2}, {FileID: 3}}
+	results, err := client.DownloadFiles(context.Background(), requests, DownloadFilesOptions{Dir: dir, Workers: 3})
+	require.NoError(t, err)
+
+	paths := map[string]bool{}
+	for _, result := range results {
+		require.NoError(t, result.Err)
+		paths[result.Path] = true
+		data, err := os.ReadFile(result.Path)
+		require.NoError(t, err)
+		assert.Equal(t, fmt.Sprintf("content of /files/%d", result.Request.FileID), string(data), "each result points at its own file")
+	}
+	assert.Len(t, paths, 3)
+	assert.True(t, paths[filepath.Join(dir, "Movie.en.srt")])
+}
+
+func TestDownloadFilesCancelled(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected after cancellation")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := client.DownloadFiles(ctx, []DownloadRequest{{FileID: 1}, {FileID: 2}}, DownloadFilesOptions{Dir: t.TempDir()})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 2)
+	for _, result := range results {
+		assert.Error(t, result.Err)
+	}
+}
+
+func TestDownloadToFile(t *testing.T) {
+	// "Καλημέρα" in Windows-1253 with CRLF line endings.
+	greek := []byte("1\r\n00:00:01,000 --> 00:00:02,000\r\n\xca\xe1\xeb\xe7\xec\xdd\xf1\xe1\r\n")
+	var serverURL string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/download":
+			_ = json.NewEncoder(w).Encode(DownloadResponse{Link: serverURL + "/files/1", FileName: "sub.srt", Remaining: 9})
+		case "/files/1":
+			_, _ = w.Write(greek)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+	server, client := setupTestServer(t, handler)
+	serverURL = server.URL
+	dir := t.TempDir()
+
+	t.Run("ConvertsToUTF8", func(t *testing.T) {
+		dest := filepath.Join(dir, "nested", "movie.el.srt")
+		result, err := client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, dest, DownloadToFileOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, charset.Windows1253, result.Encoding)
+		assert.Equal(t, 9, result.Remaining)
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "1\n00:00:01,000 --> 00:00:02,000\nΚαλημέρα\n", string(data))
+	})
+
+	t.Run("ForcedEncodingAndLineEnding", func(t *testing.T) {
+		dest := filepath.Join(dir, "forced.srt")
+		result, err := client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, dest, DownloadToFileOptions{Encoding: "cp1251", LineEnding: "\r\n"})
+		require.NoError(t, err)
+		assert.Equal(t, charset.Windows1251, result.Encoding)
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, "1\r\n00:00:01,000 --> 00:00:02,000\r\nКблзмЭсб\r\n", string(data))
+	})
+
+	t.Run("KeepEncoding", func(t *testing.T) {
+		dest := filepath.Join(dir, "raw.srt")
+		_, err := client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, dest, DownloadToFileOptions{KeepEncoding: true})
+		require.NoError(t, err)
+		data, err := os.ReadFile(dest)
+		require.NoError(t, err)
+		assert.Equal(t, greek, data)
+	})
+
+	t.Run("UnknownEncoding", func(t *testing.T) {
+		_, err := client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, filepath.Join(dir, "x.srt"), DownloadToFileOptions{Encoding: "ebcdic"})
+		assert.ErrorIs(t, err, charset.ErrUnsupportedEncoding)
+	})
+
+	t.Run("Collisions", func(t *testing.T) {
+		dest := filepath.Join(dir, "raw.srt") // Written by KeepEncoding
+		result, err := client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, dest, DownloadToFileOptions{Collisions: &naming.CollisionResolver{Policy: naming.PolicyKeepBoth}})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "raw.1.srt"), result.Path)
+		assert.FileExists(t, result.Path)
+
+		skip := &naming.CollisionResolver{Policy: naming.PolicyAsk, Ask: func(string, naming.Incoming) (naming.Resolution, error) {
+			return naming.Resolution{Action: naming.ActionSkip, Path: dest}, nil
+		}}
+		result, err = client.DownloadToFile(context.Background(), DownloadRequest{FileID: 1}, dest, DownloadToFileOptions{Collisions: skip})
+		require.NoError(t, err)
+		assert.True(t, result.Skipped)
+		assert.Equal(t, dest, result.Path)
+		assert.Zero(t, result.Remaining, "no download link is requested")
+	})
+}
+
+func TestOpenDownload(t *testing.T) {
+	content := []byte("1\n00:00:01,000 --> 00:00:02,000\nHello\n")
+	sum := md5.Sum(content)
+	var serverURL string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/download":
+			var req DownloadRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			_ = json.NewEncoder(w).Encode(DownloadResponse{Link: fmt.Sprintf("%s/files/%d", serverURL, req.FileID), FileName: "sub.srt", Remaining: 7})
+		case "/files/1":
+			w.Header().Set("Content-Type", "application/x-subrip")
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(sum[:]))
+			_, _ = w.Write(content)
+		case "/files/2":
+			w.Header().Set("Content-MD5", base64.StdEncoding.EncodeToString(make([]byte, md5.Size)))
+			_, _ = w.Write(content)
+		case "/files/3":
+			w.Header().Set("Content-Length", "1000")
+			_, _ = w.Write(content)
+		default:
+			http.NotFound(w, r)
+		}
+	}
+	server, client := setupTestServer(t, handler)
+	serverURL = server.URL
+	ctx := context.Background()
+
+	stream, meta, err := client.OpenDownload(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+	assert.Equal(t, content, data)
+	assert.Equal(t, &DownloadMeta{
+		FileName:      "sub.srt",
+		ContentLength: int64(len(content)),
+		ContentType:   "application/x-subrip",
+		MD5:           fmt.Sprintf("%x", sum),
+		Remaining:     7,
+	}, meta)
+
+	stream, _, err = client.OpenDownload(ctx, DownloadRequest{FileID: 2})
+	require.NoError(t, err)
+	_, err = io.ReadAll(stream)
+	assert.ErrorIs(t, err, ErrDownloadCorrupt, "checksum mismatch")
+	stream.Close()
+
+	stream, meta, err = client.OpenDownload(ctx, DownloadRequest{FileID: 3})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1000), meta.ContentLength)
+	assert.Empty(t, meta.MD5)
+	_, err = io.ReadAll(stream)
+	assert.ErrorIs(t, err, ErrDownloadCorrupt, "truncated file")
+	stream.Close()
+
+	_, _, err = client.OpenDownload(ctx, DownloadRequest{FileID: 4})
+	assert.Error(t, err)
+}
@@ -0,0 +1,302 @@
+// Package downloadmanager downloads a queue of subtitle files over as many days as the
+// account's download quota requires. The Manager tracks the remaining quota reported by
+// the API, pauses until the quota resets when it runs out, and persists its queue so an
+// interrupted batch resumes where it stopped.
+package downloadmanager
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/internal/jobstate"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// DefaultMaxAttempts is how often a failing file is tried when Options.MaxAttempts is not set.
+const DefaultMaxAttempts = jobstate.DefaultMaxAttempts
+
+// DefaultQuotaWait is how long the Manager pauses when the quota is exhausted and the
+// API did not report when it resets.
+const DefaultQuotaWait = jobstate.DefaultQuotaWait
+
+// Downloader is the part of *opensubtitles.Client used by the Manager.
+type Downloader interface {
+	DownloadToFile(ctx context.Context, req opensubtitles.DownloadRequest, destPath string, opts opensubtitles.DownloadToFileOptions) (*opensubtitles.DownloadToFileResult, error)
+}
+
+// Ensure the client can be used as a Downloader.
+var _ Downloader = (*opensubtitles.Client)(nil)
+
+// Status is the state of a queued Item.
+type Status = jobstate.Status
+
+const (
+	StatusPending = jobstate.StatusPending
+	StatusDone    = jobstate.StatusDone
+	StatusFailed  = jobstate.StatusFailed // Gave up after Options.MaxAttempts
+)
+
+// Candidate is a subtitle file to download to DestPath.
+type Candidate struct {
+	FileID   int    `json:"file_id"`
+	DestPath string `json:"dest_path"`
+}
+
+// Item is a queued Candidate with its progress.
+type Item struct {
+	Candidate
+	Status   Status `json:"status"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"` // Last failure
+}
+
+// State is the persisted queue and the last known download quota.
+type State struct {
+	Items []Item `json:"items"`
+	// Remaining is the quota left after the last download, or -1 if unknown.
+	Remaining int       `json:"remaining"`
+	ResetTime time.Time `json:"reset_time"`
+}
+
+// Options configures a Manager.
+type Options struct {
+	// StatePath is the JSON file the state is saved to after every change and loaded
+	// from by New. Empty keeps the state in memory only.
+	StatePath string
+	// FS holds the state file. Defaults to vfs.OS.
+	FS vfs.FS
+	// Download is passed to Downloader.DownloadToFile for every file.
+	Download opensubtitles.DownloadToFileOptions
+	// MaxAttempts marks a file failed after this many errors (default: DefaultMaxAttempts).
+	// Quota errors do not count as attempts.
+	MaxAttempts int
+	// OnPause, if set, is called before the Manager waits for the quota to reset.
+	OnPause func(until time.Time)
+	// BatchWindow enables micro-batching: Run waits until no candidates were added for
+	// this long before it starts downloading, so a burst of Add calls, e.g. from a
+	// multi-select in a UI, is collected first. The downloads of a batch are then
+	// spaced by the download rate limit instead of being sent as one burst. Zero
+	// downloads as soon as Run finds pending items.
+	BatchWindow time.Duration
+	// Stats reports the client's learned rate limits, e.g. client.Stats. It is only
+	// used with BatchWindow; nil spaces downloads by DefaultRateLimitConfig.Download.
+	Stats func() opensubtitles.Stats
+	// Logger receives a debug record per download and an info record per pause.
+	// Nil disables logging.
+	Logger *slog.Logger
+}
+
+// Manager downloads queued candidates, pausing while the download quota is exhausted.
+// It is safe for concurrent use, but only one Run may be active at a time.
+type Manager struct {
+	downloader Downloader
+	opts       Options
+
+	mu      sync.Mutex // Protects state and lastAdd
+	state   State
+	lastAdd time.Time
+	file    jobstate.File
+
+	now   func() time.Time
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// New creates a Manager, restoring the queue from opts.StatePath if the file exists.
+func New(downloader Downloader, opts Options) (*Manager, error) {
+	if opts.FS == nil {
+		opts.FS = vfs.OS
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+	opts.Logger = logging.OrDiscard(opts.Logger)
+	m := &Manager{
+		downloader: downloader,
+		opts:       opts,
+		state:      State{Remaining: -1},
+		file:       jobstate.File{FS: opts.FS, Path: opts.StatePath, Kind: "state"},
+		now:        time.Now,
+		sleep:      jobstate.Sleep,
+	}
+	if err := m.file.Load(&m.state); err != nil {
+		return nil, fmt.Errorf("downloadmanager: %w", err)
+	}
+	return m, nil
+}
+
+// Add queues candidates and saves the state. Candidates already queued with the same
+// file ID and destination are ignored.
+func (m *Manager) Add(candidates ...Candidate) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	queued := make(map[Candidate]bool, len(m.state.Items))
+	for _, item := range m.state.Items {
+		queued[item.Candidate] = true
+	}
+	m.lastAdd = m.now()
+	for _, c := range candidates {
+		if !queued[c] {
+			queued[c] = true
+			m.state.Items = append(m.state.Items, Item{Candidate: c, Status: StatusPending})
+		}
+	}
+	return m.saveLocked()
+}
+
+// State returns a copy of the current state.
+func (m *Manager) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	state := m.state
+	state.Items = append([]Item(nil), m.state.Items...)
+	return state
+}
+
+// Run downloads all pending items in queue order and returns when none are left.
+// When the quota is exhausted it saves the state and waits until the reset time,
+// so Run may take days; cancel ctx to stop it. Per-file failures are recorded in the
+// items rather than returned. The returned error is ctx.Err() or a state file error.
+func (m *Manager) Run(ctx context.Context) error {
+	var lastRequest time.Time // Of the last download that reached the API, in batch mode
+	for {
+		i, ok := m.nextPending()
+		if !ok {
+			return nil
+		}
+		if wait := m.batchWait(lastRequest); wait > 0 {
+			if err := m.sleep(ctx, wait); err != nil {
+				return err
+			}
+			continue
+		}
+		if until, exhausted := m.quotaExhausted(); exhausted {
+			if err := m.pause(ctx, until); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		m.mu.Lock()
+		item := m.state.Items[i]
+		m.mu.Unlock()
+		result, err := m.downloader.DownloadToFile(ctx, opensubtitles.DownloadRequest{FileID: item.FileID}, item.DestPath, m.opts.Download)
+		if m.opts.BatchWindow > 0 && (err != nil || !result.Skipped) {
+			lastRequest = m.now()
+		}
+
+		m.mu.Lock()
+		item = m.state.Items[i]
+		switch {
+		case err == nil && result.Skipped:
+			item.Status, item.Error = StatusDone, ""
+			m.opts.Logger.Debug("downloadmanager: kept existing file", "file_id", item.FileID, "path", result.Path)
+		case err == nil:
+			item.Status, item.Error = StatusDone, ""
+			m.state.Remaining, m.state.ResetTime = result.Remaining, result.ResetTime
+			m.opts.Logger.Debug("downloadmanager: downloaded file", "file_id", item.FileID, "path", item.DestPath, "remaining", result.Remaining)
+		case errors.Is(err, opensubtitles.ErrQuotaExceeded):
+			m.state.Remaining, m.state.ResetTime = 0, jobstate.QuotaResetTime(err)
+			m.opts.Logger.Debug("downloadmanager: download quota exceeded", "file_id", item.FileID)
+		case ctx.Err() != nil:
+			m.mu.Unlock()
+			return ctx.Err()
+		default:
+			item.Attempts++
+			item.Error = err.Error()
+			if item.Attempts >= m.opts.MaxAttempts {
+				item.Status = StatusFailed
+			}
+			m.opts.Logger.Debug("downloadmanager: download failed", "file_id", item.FileID, "attempts", item.Attempts, "status", item.Status, "error", err)
+		}
+		m.state.Items[i] = item
+		saveErr := m.saveLocked()
+		m.mu.Unlock()
+		if saveErr != nil {
+			return saveErr
+		}
+	}
+}
+
+// batchWait returns how long Run waits before the next download in batch mode: until
+// BatchWindow has passed since the last Add, then until the download after lastRequest
+// is due.
+func (m *Manager) batchWait(lastRequest time.Time) time.Duration {
+	if m.opts.BatchWindow <= 0 {
+		return 0
+	}
+	now := m.now()
+	m.mu.Lock()
+	wait := m.lastAdd.Add(m.opts.BatchWindow).Sub(now)
+	m.mu.Unlock()
+	if wait > 0 || lastRequest.IsZero() {
+		return wait
+	}
+	return lastRequest.Add(m.downloadInterval()).Sub(now)
+}
+
+// downloadInterval is the time between two downloads allowed by the download rate
+// limit, plus the time until the next token if the server reported none left.
+func (m *Manager) downloadInterval() time.Duration {
+	rate, available := opensubtitles.DefaultRateLimitConfig.Download, 0.0
+	if m.opts.Stats != nil {
+		limits, ok := m.opts.Stats().RateLimits["download"]
+		if !ok {
+			return 0 // Downloads are not rate limited
+		}
+		rate, available = limits.Rate, limits.Available
+	}
+	if rate <= 0 {
+		return 0
+	}
+	interval := time.Duration(float64(time.Second) / rate)
+	if available < 0 {
+		interval += time.Duration(-available / rate * float64(time.Second))
+	}
+	return interval
+}
+
+// nextPending returns the index of the first pending item.
+func (m *Manager) nextPending() (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return jobstate.NextPending(m.state.Items, func(item Item) Status { return item.Status })
+}
+
+// quotaExhausted reports whether no downloads are left and, if so, until when.
+func (m *Manager) quotaExhausted() (time.Time, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return jobstate.QuotaExhausted(&m.state.Remaining, &m.state.ResetTime, m.now())
+}
+
+// pause saves the state and waits until the quota resets or ctx ends.
+func (m *Manager) pause(ctx context.Context, until time.Time) error {
+	m.mu.Lock()
+	err := m.saveLocked()
+	m.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if m.opts.OnPause != nil {
+		m.opts.OnPause(until)
+	}
+	m.opts.Logger.Info("downloadmanager: download quota exhausted, pausing", "until", until)
+	return m.sleep(ctx, until.Sub(m.now()))
+}
+
+// saveLocked writes the state file atomically. m.mu must be held.
+func (m *Manager) saveLocked() error {
+	if err := m.file.Save(m.state); err != nil {
+		return fmt.Errorf("downloadmanager: %w", err)
+	}
+	return nil
+}
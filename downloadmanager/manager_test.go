@@ -0,0 +1,224 @@
+package downloadmanager
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeDownloader serves files from a fixed daily quota.
+type fakeDownloader struct {
+	remaining int
+	reset     time.Time
+	fail      map[int]error
+	calls     []int
+}
+
+func (f *fakeDownloader) DownloadToFile(ctx context.Context, req opensubtitles.DownloadRequest, destPath string, opts opensubtitles.DownloadToFileOptions) (*opensubtitles.DownloadToFileResult, error) {
+	f.calls = append(f.calls, req.FileID)
+	if err := f.fail[req.FileID]; err != nil {
+		return nil, err
+	}
+	if f.remaining == 0 {
+		return nil, fmt.Errorf("download: %w", opensubtitles.ErrQuotaExceeded)
+	}
+	f.remaining--
+	return &opensubtitles.DownloadToFileResult{Path: destPath, Remaining: f.remaining, ResetTime: f.reset}, nil
+}
+
+// fakeClock advances when the Manager sleeps.
+type fakeClock struct {
+	now    time.Time
+	slept  []time.Duration
+	onWait func()
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	c.slept = append(c.slept, d)
+	c.now = c.now.Add(d)
+	if c.onWait != nil {
+		c.onWait()
+	}
+	return ctx.Err()
+}
+
+func newTestManager(t *testing.T, d Downloader, clock *fakeClock, opts Options) *Manager {
+	t.Helper()
+	m, err := New(d, opts)
+	require.NoError(t, err)
+	m.now, m.sleep = clock.Now, clock.Sleep
+	return m
+}
+
+func TestManagerPausesUntilQuotaReset(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	reset := start.Add(4 * time.Hour)
+	clock := &fakeClock{now: start}
+	d := &fakeDownloader{remaining: 2, reset: reset}
+	clock.onWait = func() { d.remaining = 2 }
+	var pausedUntil []time.Time
+	m := newTestManager(t, d, clock, Options{OnPause: func(until time.Time) { pausedUntil = append(pausedUntil, until) }})
+
+	require.NoError(t, m.Add(Candidate{1, "a.srt"}, Candidate{2, "b.srt"}, Candidate{3, "c.srt"}, Candidate{1, "a.srt"}))
+	require.NoError(t, m.Run(context.Background()))
+
+	assert.Equal(t, []int{1, 2, 3}, d.calls, "no request is made while the quota is exhausted")
+	assert.Equal(t, []time.Time{reset}, pausedUntil)
+	assert.Equal(t, []time.Duration{4 * time.Hour}, clock.slept)
+	state := m.State()
+	require.Len(t, state.Items, 3)
+	for _, item := range state.Items {
+		assert.Equal(t, StatusDone, item.Status)
+	}
+	assert.Equal(t, 1, state.Remaining)
+}
+
+func TestManagerQuotaErrorWithoutResetTime(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	d := &fakeDownloader{remaining: 0}
+	clock.onWait = func() { d.remaining = 10 }
+	m := newTestManager(t, d, clock, Options{})
+
+	require.NoError(t, m.Add(Candidate{FileID: 7, DestPath: "x.srt"}))
+	require.NoError(t, m.Run(context.Background()))
+
+	assert.Equal(t, []int{7, 7}, d.calls)
+	assert.Equal(t, []time.Duration{DefaultQuotaWait}, clock.slept)
+	item := m.State().Items[0]
+	assert.Equal(t, StatusDone, item.Status)
+	assert.Zero(t, item.Attempts, "quota errors are not counted as attempts")
+}
+
+func TestManagerGivesUpAfterMaxAttempts(t *testing.T) {
+	clock := &fakeClock{now: time.Now()}
+	d := &fakeDownloader{remaining: 10, fail: map[int]error{1: errors.New("boom")}}
+	m := newTestManager(t, d, clock, Options{MaxAttempts: 2})
+
+	require.NoError(t, m.Add(Candidate{1, "a.srt"}, Candidate{2, "b.srt"}))
+	require.NoError(t, m.Run(context.Background()))
+
+	assert.Equal(t, []int{1, 1, 2}, d.calls)
+	items := m.State().Items
+	assert.Equal(t, StatusFailed, items[0].Status)
+	assert.Equal(t, 2, items[0].Attempts)
+	assert.Equal(t, "boom", items[0].Error)
+	assert.Equal(t, StatusDone, items[1].Status)
+}
+
+func TestManagerResumesFromStateFile(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	d := &fakeDownloader{remaining: 1, reset: start.Add(time.Hour)}
+	opts := Options{StatePath: "state/queue.json", FS: fsys}
+	m := newTestManager(t, d, clock, opts)
+	require.NoError(t, m.Add(Candidate{1, "a.srt"}, Candidate{2, "b.srt"}))
+
+	// Stop the run while it waits for the quota reset, like a process being shut down.
+	ctx, cancel := context.WithCancel(context.Background())
+	clock.onWait = cancel
+	require.ErrorIs(t, m.Run(ctx), context.Canceled)
+
+	restored := newTestManager(t, d, clock, opts)
+	state := restored.State()
+	require.Len(t, state.Items, 2)
+	assert.Equal(t, StatusDone, state.Items[0].Status)
+	assert.Equal(t, StatusPending, state.Items[1].Status)
+	assert.Equal(t, 0, state.Remaining)
+	assert.True(t, state.ResetTime.Equal(start.Add(time.Hour)))
+
+	d.remaining = 5
+	clock.onWait = nil
+	require.NoError(t, restored.Run(context.Background()))
+	assert.Equal(t, []int{1, 2}, d.calls, "the finished file is not downloaded again")
+	assert.Equal(t, StatusDone, restored.State().Items[1].Status)
+}
+
+func TestManagerReadsResetTimeFromAPIError(t *testing.T) {
+	reset := time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotAcceptable)
+		_, _ = fmt.Fprintf(w, `{"message":"You have downloaded your allowed 20 subtitles for 24h","remaining":0,"reset_time_utc":%q}`, reset.Format(time.RFC3339))
+	}))
+	defer server.Close()
+	client, err := opensubtitles.NewClient(opensubtitles.Config{ApiKey: "key", BaseURL: server.URL, RateLimit: &opensubtitles.RateLimitConfig{}})
+	require.NoError(t, err)
+
+	clock := &fakeClock{now: reset.Add(-2 * time.Hour)}
+	ctx, cancel := context.WithCancel(context.Background())
+	clock.onWait = cancel
+	m := newTestManager(t, client, clock, Options{})
+	require.NoError(t, m.Add(Candidate{FileID: 1, DestPath: filepath.Join(t.TempDir(), "a.srt")}))
+
+	require.ErrorIs(t, m.Run(ctx), context.Canceled)
+	assert.Equal(t, []time.Duration{2 * time.Hour}, clock.slept)
+}
+
+func TestManagerBatchWindow(t *testing.T) {
+	start := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+	clock := &fakeClock{now: start}
+	d := &fakeDownloader{remaining: 10}
+	stats := opensubtitles.Stats{RateLimits: map[string]opensubtitles.RateLimitStats{"download": {Rate: 4}}}
+	m := newTestManager(t, d, clock, Options{BatchWindow: 2 * time.Second, Stats: func() opensubtitles.Stats { return stats }})
+
+	require.NoError(t, m.Add(Candidate{1, "a.srt"}))
+	clock.now = start.Add(500 * time.Millisecond)
+	require.NoError(t, m.Add(Candidate{2, "b.srt"}))
+	clock.now = start.Add(time.Second)
+	clock.onWait = func() {
+		if len(clock.slept) == 1 {
+			require.NoError(t, m.Add(Candidate{3, "c.srt"})) // Still within the window
+		}
+	}
+	require.NoError(t, m.Run(context.Background()))
+
+	assert.Equal(t, []int{1, 2, 3}, d.calls)
+	assert.Equal(t, []time.Duration{
+		1500 * time.Millisecond, // Until 2s after the second Add
+		2 * time.Second,         // The third Add restarts the window
+		250 * time.Millisecond,  // Downloads are spaced by the 4/s download limit
+		250 * time.Millisecond,
+	}, clock.slept)
+
+	t.Run("WaitsForExhaustedRateLimit", func(t *testing.T) {
+		stats.RateLimits["download"] = opensubtitles.RateLimitStats{Rate: 2, Available: -1}
+		m.opts.Stats = func() opensubtitles.Stats { return stats }
+		assert.Equal(t, time.Second, m.downloadInterval())
+		delete(stats.RateLimits, "download")
+		assert.Zero(t, m.downloadInterval(), "downloads are not limited")
+		m.opts.Stats = nil
+		assert.Equal(t, 200*time.Millisecond, m.downloadInterval(), "the default download limit is 5/s")
+	})
+}
+
+func TestManagerLogs(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)}
+	d := &fakeDownloader{remaining: 0}
+	clock.onWait = func() { d.remaining = 10 }
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	m := newTestManager(t, d, clock, Options{Logger: logger})
+
+	require.NoError(t, m.Add(Candidate{FileID: 7, DestPath: "x.srt"}))
+	require.NoError(t, m.Run(context.Background()))
+
+	logs := buf.String()
+	assert.Contains(t, logs, `level=DEBUG msg="downloadmanager: download quota exceeded" file_id=7`)
+	assert.Contains(t, logs, `level=INFO msg="downloadmanager: download quota exhausted, pausing" until=2024-05-01T01:00:00.000Z`)
+	assert.Contains(t, logs, `level=DEBUG msg="downloadmanager: downloaded file" file_id=7 path=x.srt remaining=9`)
+}
@@ -0,0 +1,169 @@
+// Package envconfig provides typed helpers for reading configuration from environment
+// variables, so applications built on this library can run without a config file
+// (e.g. in containers).
+//
+// All variables share a prefix, DefaultPrefix unless overridden:
+//
+//	l := envconfig.New("")
+//	apiKey := l.String("API_KEY", "")           // OPENSUBTITLES_API_KEY
+//	workers := l.Int("WORKERS", 4)              // OPENSUBTITLES_WORKERS
+//	langs := l.List("LANGUAGES", []string{"en"}) // OPENSUBTITLES_LANGUAGES=el,en
+//	if err := l.Err(); err != nil { ... }
+//
+// Parse errors do not abort loading; they are collected and returned by Err, so a
+// single run reports every malformed variable.
+package envconfig
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix is the prefix used by New when none is given.
+const DefaultPrefix = "OPENSUBTITLES_"
+
+// Loader reads prefixed environment variables and records parse errors.
+type Loader struct {
+	prefix string
+	lookup func(string) (string, bool)
+	errs   []error
+}
+
+// New creates a Loader reading from the process environment. An empty prefix
+// selects DefaultPrefix.
+func New(prefix string) *Loader {
+	return NewWithLookup(prefix, os.LookupEnv)
+}
+
+// NewWithLookup creates a Loader using lookup instead of os.LookupEnv, for tests.
+func NewWithLookup(prefix string, lookup func(string) (string, bool)) *Loader {
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Loader{prefix: prefix, lookup: lookup}
+}
+
+// Key returns the full variable name for name, e.g. "OPENSUBTITLES_API_KEY".
+func (l *Loader) Key(name string) string {
+	return l.prefix + name
+}
+
+// value returns the trimmed value of the variable and whether it is set and non-empty.
+func (l *Loader) value(name string) (string, bool) {
+	v, ok := l.lookup(l.Key(name))
+	v = strings.TrimSpace(v)
+	return v, ok && v != ""
+}
+
+func (l *Loader) fail(name, value, kind string, err error) {
+	l.errs = append(l.errs, fmt.Errorf("envconfig: %s=%q is not a valid %s: %w", l.Key(name), value, kind, err))
+}
+
+// String returns the variable value, or def if unset or empty.
+func (l *Loader) String(name, def string) string {
+	if v, ok := l.value(name); ok {
+		return v
+	}
+	return def
+}
+
+// Int parses the variable as an integer.
+func (l *Loader) Int(name string, def int) int {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.fail(name, v, "integer", err)
+		return def
+	}
+	return n
+}
+
+// Float parses the variable as a floating point number.
+func (l *Loader) Float(name string, def float64) float64 {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.fail(name, v, "number", err)
+		return def
+	}
+	return f
+}
+
+// Bool parses the variable with strconv.ParseBool, also accepting "yes"/"no" and "on"/"off".
+func (l *Loader) Bool(name string, def bool) bool {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	switch strings.ToLower(v) {
+	case "yes", "on":
+		return true
+	case "no", "off":
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.fail(name, v, "boolean", err)
+		return def
+	}
+	return b
+}
+
+// Duration parses the variable with time.ParseDuration ("1500ms", "2m").
+func (l *Loader) Duration(name string, def time.Duration) time.Duration {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.fail(name, v, "duration", err)
+		return def
+	}
+	return d
+}
+
+// List splits the variable on commas, trimming blanks and dropping empty items.
+func (l *Loader) List(name string, def []string) []string {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// OneOf returns the variable value if it is one of allowed, recording an error otherwise.
+func (l *Loader) OneOf(name, def string, allowed ...string) string {
+	v, ok := l.value(name)
+	if !ok {
+		return def
+	}
+	for _, a := range allowed {
+		if v == a {
+			return v
+		}
+	}
+	l.fail(name, v, "value", fmt.Errorf("must be one of %s", strings.Join(allowed, ", ")))
+	return def
+}
+
+// Err returns all parse errors recorded so far, or nil.
+func (l *Loader) Err() error {
+	return errors.Join(l.errs...)
+}
@@ -0,0 +1,62 @@
+package envconfig
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mapLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+}
+
+func TestLoader(t *testing.T) {
+	l := NewWithLookup("", mapLookup(map[string]string{
+		"OPENSUBTITLES_API_KEY":   " abc ",
+		"OPENSUBTITLES_WORKERS":   "8",
+		"OPENSUBTITLES_SCORE":     "0.75",
+		"OPENSUBTITLES_DRY_RUN":   "yes",
+		"OPENSUBTITLES_INTERVAL":  "90s",
+		"OPENSUBTITLES_LANGUAGES": "el, en,,",
+		"OPENSUBTITLES_POLICY":    "keep-both",
+		"OPENSUBTITLES_EMPTY":     "  ",
+	}))
+
+	assert.Equal(t, "OPENSUBTITLES_API_KEY", l.Key("API_KEY"))
+	assert.Equal(t, "abc", l.String("API_KEY", ""))
+	assert.Equal(t, "fallback", l.String("EMPTY", "fallback"))
+	assert.Equal(t, 8, l.Int("WORKERS", 1))
+	assert.Equal(t, 3, l.Int("MISSING", 3))
+	assert.Equal(t, 0.75, l.Float("SCORE", 0))
+	assert.True(t, l.Bool("DRY_RUN", false))
+	assert.Equal(t, 90*time.Second, l.Duration("INTERVAL", time.Minute))
+	assert.Equal(t, []string{"el", "en"}, l.List("LANGUAGES", nil))
+	assert.Equal(t, "keep-both", l.OneOf("POLICY", "overwrite", "overwrite", "keep-both"))
+	assert.NoError(t, l.Err())
+}
+
+func TestLoaderCollectsErrors(t *testing.T) {
+	l := NewWithLookup("APP_", mapLookup(map[string]string{
+		"APP_WORKERS":  "many",
+		"APP_DRY_RUN":  "maybe",
+		"APP_INTERVAL": "soon",
+		"APP_POLICY":   "random",
+	}))
+
+	assert.Equal(t, 2, l.Int("WORKERS", 2))
+	assert.False(t, l.Bool("DRY_RUN", false))
+	assert.Equal(t, time.Second, l.Duration("INTERVAL", time.Second))
+	assert.Equal(t, "a", l.OneOf("POLICY", "a", "a", "b"))
+
+	err := l.Err()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `APP_WORKERS="many" is not a valid integer`)
+	assert.Contains(t, err.Error(), "APP_DRY_RUN")
+	assert.Contains(t, err.Error(), "APP_INTERVAL")
+	assert.Contains(t, err.Error(), "must be one of a, b")
+}
@@ -0,0 +1,135 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		token   string
+		want    []error
+		notWant []error
+	}{
+		{"NotFound", http.StatusNotFound, `{"message":"Not found"}`, "", []error{ErrNotFound}, []error{ErrUnauthorized}},
+		{"TokenExpired", http.StatusUnauthorized, `{"message":"invalid token"}`, "old-token", []error{ErrUnauthorized, ErrTokenExpired}, []error{ErrInvalidApiKey}},
+		{"UnauthorizedWithoutToken", http.StatusUnauthorized, `{"message":"Authentication required"}`, "", []error{ErrUnauthorized}, []error{ErrTokenExpired}},
+		{"InvalidApiKey", http.StatusForbidden, `{"message":"You cannot consume this service"}`, "", []error{ErrForbidden, ErrInvalidApiKey}, []error{ErrQuotaExceeded}},
+		{"QuotaExceeded403", http.StatusForbidden, `{"message":"Download quota exceeded","status":403}`, "tok", []error{ErrForbidden, ErrQuotaExceeded}, nil},
+		{"QuotaExceeded406", http.StatusNotAcceptable, `{"message":"You have downloaded your allowed 20 subtitles for 24h"}`, "tok", []error{ErrQuotaExceeded}, nil},
+		{"Validation", http.StatusUnprocessableEntity, `{"errors":{"file_id":["is invalid"]},"status":422}`, "", []error{ErrValidation}, nil},
+		{"ServerError", http.StatusBadGateway, `<html>bad gateway</html>`, "", []error{ErrServiceUnavailable}, []error{ErrValidation}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tc.status)
+				_, _ = w.Write([]byte(tc.body))
+			})
+			if tc.token != "" {
+				require.NoError(t, client.SetAuthToken(tc.token, ""))
+			}
+			_, err := client.Download(context.Background(), DownloadRequest{FileID: 1})
+			require.Error(t, err)
+			for _, want := range tc.want {
+				assert.ErrorIs(t, err, want)
+			}
+			for _, notWant := range tc.notWant {
+				assert.NotErrorIs(t, err, notWant)
+			}
+
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tc.status, apiErr.StatusCode)
+			assert.Equal(t, tc.body, apiErr.Body)
+		})
+	}
+}
+
+func TestAPIErrorDetails(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"message":"Validation failed","errors":{"languages":["is not a valid language"],"query":"too short"}}`))
+	})
+	_, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{})
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Validation failed", apiErr.Message)
+	assert.Equal(t, map[string][]string{"languages": {"is not a valid language"}, "query": {"too short"}}, apiErr.Fields)
+	assert.Equal(t, "api request failed: status 422: Validation failed; languages: is not a valid language; query: too short", err.Error())
+
+	_, client = setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":["Invalid moviehash","Invalid page"],"status":400}`))
+	})
+	_, err = client.SearchSubtitles(context.Background(), SearchSubtitlesParams{})
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Invalid moviehash; Invalid page", apiErr.Message)
+	assert.ErrorIs(t, err, ErrValidation)
+}
+
+func TestRateLimitedAPIError(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		_, _ = w.Write([]byte(`{"message":"Throttle limit reached"}`))
+	})
+	_, err := client.GetUserInfo(context.Background())
+	assert.ErrorIs(t, err, ErrRateLimited)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, "Throttle limit reached", apiErr.Message)
+}
+
+func TestHTMLErrorPages(t *testing.T) {
+	page := "<!DOCTYPE html><html><head><title>\n  502 Bad Gateway | api.opensubtitles.com\n</title></head><body>" +
+		strings.Repeat("<p>Cloudflare</p>", 100) + "</body></html>"
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=UTF-8")
+		w.Header().Set("Retry-After", "120")
+		if r.URL.Path == "/api/v1/infos/user" {
+			// Maintenance pages are sometimes served with a success status.
+			_, _ = w.Write([]byte("<html><head><title>Maintenance</title></head></html>"))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte(page))
+	})
+
+	_, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Query: String("heat")})
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, 2*time.Minute, apiErr.RetryAfter)
+	assert.Equal(t, "unexpected non-JSON response (text/html; charset=UTF-8): 502 Bad Gateway | api.opensubtitles.com", apiErr.Message)
+	assert.Equal(t, page[:512], apiErr.Body, "only the start of the page is kept")
+
+	_, err = client.GetUserInfo(context.Background())
+	assert.ErrorIs(t, err, ErrServiceUnavailable)
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusOK, apiErr.StatusCode)
+	assert.Contains(t, err.Error(), "Maintenance")
+	assert.NotContains(t, err.Error(), "unmarshal")
+}
+
+func TestHTMLNotFoundPage(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("<html><body>Not Found</body></html>"))
+	})
+	_, err := client.Download(context.Background(), DownloadRequest{FileID: 1})
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NotErrorIs(t, err, ErrServiceUnavailable, "client errors keep their status-based kind")
+	assert.Contains(t, err.Error(), "unexpected non-JSON response")
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -70,43 +69,30 @@ func exampleDiscoverPopular(client *opensubtitles.Client, lang opensubtitles.Lan
 			break
 		}
 		fmt.Printf("--- Popular Feature %d (%s) ---\n", i+1, fType)
-		// Attributes handling is similar to SearchFeatures
-		attrBytes, _ := json.Marshal(feat.Attributes)
-		var genericAttrMap map[string]interface{}
-		_ = json.Unmarshal(attrBytes, &genericAttrMap)
-		actualFeatureType, _ := genericAttrMap["feature_type"].(string)
-
-		switch actualFeatureType {
-		case "Movie", "movie":
-			var movieAttrs opensubtitles.FeatureMovieAttributes
-			if json.Unmarshal(attrBytes, &movieAttrs) == nil {
-				fmt.Printf("  Title: %s (Year: %s)\n", movieAttrs.Title, movieAttrs.Year)
-				if movieAttrs.IMDbID != nil {
-					fmt.Printf("  IMDb ID: %d", *movieAttrs.IMDbID)
-				}
-				if movieAttrs.TMDBID != nil {
-					fmt.Printf(", TMDB ID: %d\n", *movieAttrs.TMDBID)
-				} else {
-					fmt.Println()
-				}
-				fmt.Printf("  URL: %s\n", movieAttrs.URL)
+		if movieAttrs, ok := feat.AsMovie(); ok {
+			fmt.Printf("  Title: %s (Year: %s)\n", movieAttrs.Title, movieAttrs.Year)
+			if movieAttrs.IMDbID != nil {
+				fmt.Printf("  IMDb ID: %d", *movieAttrs.IMDbID)
 			}
-		case "Tvshow", "tvshow":
-			var tvshowAttrs opensubtitles.FeatureTvshowAttributes
-			if json.Unmarshal(attrBytes, &tvshowAttrs) == nil {
-				fmt.Printf("  Title: %s (Year: %s)\n", tvshowAttrs.Title, tvshowAttrs.Year)
-				if tvshowAttrs.IMDbID != nil {
-					fmt.Printf("  IMDb ID: %d", *tvshowAttrs.IMDbID)
-				}
-				if tvshowAttrs.TMDBID != nil {
-					fmt.Printf(", TMDB ID: %d\n", *tvshowAttrs.TMDBID)
-				} else {
-					fmt.Println()
-				}
-				fmt.Printf("  URL: %s\n", tvshowAttrs.URL)
+			if movieAttrs.TMDBID != nil {
+				fmt.Printf(", TMDB ID: %d\n", *movieAttrs.TMDBID)
+			} else {
+				fmt.Println()
 			}
-		default:
-			fmt.Printf("  ID: %s, Type: %s (Attributes: %s)\n", feat.ID, feat.Type, string(attrBytes))
+			fmt.Printf("  URL: %s\n", movieAttrs.URL)
+		} else if tvshowAttrs, ok := feat.AsTvshow(); ok {
+			fmt.Printf("  Title: %s (Year: %s)\n", tvshowAttrs.Title, tvshowAttrs.Year)
+			if tvshowAttrs.IMDbID != nil {
+				fmt.Printf("  IMDb ID: %d", *tvshowAttrs.IMDbID)
+			}
+			if tvshowAttrs.TMDBID != nil {
+				fmt.Printf(", TMDB ID: %d\n", *tvshowAttrs.TMDBID)
+			} else {
+				fmt.Println()
+			}
+			fmt.Printf("  URL: %s\n", tvshowAttrs.URL)
+		} else {
+			fmt.Printf("  ID: %s, Type: %s (Attributes: %v)\n", feat.ID, feat.Type, feat.Attributes)
 		}
 	}
 }
@@ -2,7 +2,6 @@ package main
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
 	"time"
@@ -66,79 +65,46 @@ func exampleSearchFeatures(client *opensubtitles.Client, query string, featureTy
 		fmt.Printf("--- Feature %d ---\n", i+1)
 		fmt.Printf("  ID: %s, Type: %s\n", feat.ID, feat.Type)
 
-		// The Attributes field is an interface{}. We need to unmarshal it based on FeatureType.
-		// First, let's marshal it back to JSON bytes, then unmarshal into a map to inspect feature_type.
-		attrBytes, err := json.Marshal(feat.Attributes)
-		if err != nil {
-			log.Printf("  Error marshalling attributes: %v\n", err)
-			continue
-		}
-
-		var genericAttrMap map[string]interface{}
-		if err := json.Unmarshal(attrBytes, &genericAttrMap); err != nil {
-			log.Printf("  Error unmarshalling attributes to generic map: %v\n", err)
-			continue
-		}
-		actualFeatureType := feat.Type
-
-		log.Printf("  Attempting to unmarshal attributes for feature_type: %s\n", actualFeatureType)
-
-		switch actualFeatureType {
-		case "Movie", "movie": // API can return "Movie" or "movie"
-			var movieAttrs opensubtitles.FeatureMovieAttributes
-			if err := json.Unmarshal(attrBytes, &movieAttrs); err == nil {
-				fmt.Printf("  Title: %s (Year: %s)\n", movieAttrs.Title, movieAttrs.Year)
-				if movieAttrs.IMDbID != nil {
-					fmt.Printf("  IMDb ID: %d", *movieAttrs.IMDbID)
-				}
-				if movieAttrs.TMDBID != nil {
-					fmt.Printf(", TMDB ID: %d\n", *movieAttrs.TMDBID)
-				} else {
-					fmt.Println()
-				}
-				fmt.Printf("  URL: %s\n", movieAttrs.URL)
+		// Attributes are decoded into the struct matching the feature type.
+		if movieAttrs, ok := feat.AsMovie(); ok {
+			fmt.Printf("  Title: %s (Year: %s)\n", movieAttrs.Title, movieAttrs.Year)
+			if movieAttrs.IMDbID != nil {
+				fmt.Printf("  IMDb ID: %d", *movieAttrs.IMDbID)
+			}
+			if movieAttrs.TMDBID != nil {
+				fmt.Printf(", TMDB ID: %d\n", *movieAttrs.TMDBID)
 			} else {
-				log.Printf("  Error unmarshalling into FeatureMovieAttributes: %v\n", err)
+				fmt.Println()
 			}
-		case "Tvshow", "tvshow": // API can return "Tvshow" or "tvshow"
-			var tvshowAttrs opensubtitles.FeatureTvshowAttributes
-			if err := json.Unmarshal(attrBytes, &tvshowAttrs); err == nil {
-				fmt.Printf("  Title: %s (Year: %s)\n", tvshowAttrs.Title, tvshowAttrs.Year)
-				if tvshowAttrs.IMDbID != nil {
-					fmt.Printf("  IMDb ID: %d", *tvshowAttrs.IMDbID)
-				}
-				if tvshowAttrs.TMDBID != nil {
-					fmt.Printf(", TMDB ID: %d\n", *tvshowAttrs.TMDBID)
-				} else {
-					fmt.Println()
-				}
-				fmt.Printf("  Seasons Count: %d\n", tvshowAttrs.SeasonsCount)
-				fmt.Printf("  URL: %s\n", tvshowAttrs.URL)
+			fmt.Printf("  URL: %s\n", movieAttrs.URL)
+		} else if tvshowAttrs, ok := feat.AsTvshow(); ok {
+			fmt.Printf("  Title: %s (Year: %s)\n", tvshowAttrs.Title, tvshowAttrs.Year)
+			if tvshowAttrs.IMDbID != nil {
+				fmt.Printf("  IMDb ID: %d", *tvshowAttrs.IMDbID)
+			}
+			if tvshowAttrs.TMDBID != nil {
+				fmt.Printf(", TMDB ID: %d\n", *tvshowAttrs.TMDBID)
 			} else {
-				log.Printf("  Error unmarshalling into FeatureTvshowAttributes: %v\n", err)
+				fmt.Println()
+			}
+			fmt.Printf("  Seasons Count: %d\n", tvshowAttrs.SeasonsCount)
+			fmt.Printf("  URL: %s\n", tvshowAttrs.URL)
+		} else if episodeAttrs, ok := feat.AsEpisode(); ok {
+			fmt.Printf("  Title: %s (Season: %d, Episode: %d, Year: %s)\n", episodeAttrs.Title, episodeAttrs.SeasonNumber, episodeAttrs.EpisodeNumber, episodeAttrs.Year)
+			if episodeAttrs.ParentTitle != nil {
+				fmt.Printf("  Parent Title: %s\n", *episodeAttrs.ParentTitle)
+			}
+			if episodeAttrs.IMDbID != nil {
+				fmt.Printf("  IMDb ID: %d", *episodeAttrs.IMDbID)
 			}
-		case "Episode", "episode":
-			var episodeAttrs opensubtitles.FeatureEpisodeAttributes
-			if err := json.Unmarshal(attrBytes, &episodeAttrs); err == nil {
-				fmt.Printf("  Title: %s (Season: %d, Episode: %d, Year: %s)\n", episodeAttrs.Title, episodeAttrs.SeasonNumber, episodeAttrs.EpisodeNumber, episodeAttrs.Year)
-				if episodeAttrs.ParentTitle != nil {
-					fmt.Printf("  Parent Title: %s\n", *episodeAttrs.ParentTitle)
-				}
-				if episodeAttrs.IMDbID != nil {
-					fmt.Printf("  IMDb ID: %d", *episodeAttrs.IMDbID)
-				}
-				if episodeAttrs.TMDBID != nil {
-					fmt.Printf(", TMDB ID: %d\n", *episodeAttrs.TMDBID)
-				} else {
-					fmt.Println()
-				}
-				fmt.Printf("  URL: %s\n", episodeAttrs.URL)
+			if episodeAttrs.TMDBID != nil {
+				fmt.Printf(", TMDB ID: %d\n", *episodeAttrs.TMDBID)
 			} else {
-				log.Printf("  Error unmarshalling into FeatureEpisodeAttributes: %v\n", err)
+				fmt.Println()
 			}
-		default:
-			log.Printf("  Unknown or unhandled feature_type: %s\n", actualFeatureType)
-			fmt.Printf("  Raw Attributes: %s\n", string(attrBytes))
+			fmt.Printf("  URL: %s\n", episodeAttrs.URL)
+		} else {
+			log.Printf("  Unknown or unhandled feature attributes: %v\n", feat.Attributes)
 		}
 	}
 }
@@ -106,7 +106,14 @@ func main() {
 		fmt.Printf("  Subtitle ID: %s\n", sub.ID)
 		fmt.Printf("  Language:    %s\n", sub.Attributes.Language)
 		fmt.Printf("  Feature:     %s (IMDb: %v)\n", sub.Attributes.FeatureDetails.Title, *sub.Attributes.FeatureDetails.IMDbID)
-		fmt.Printf("  Uploader:    %s (Rank: %s)\n", sub.Attributes.Uploader.Name, sub.Attributes.Uploader.Rank)
+		uploaderName, uploaderRank := "unknown", "unknown"
+		if sub.Attributes.Uploader.Name != nil {
+			uploaderName = *sub.Attributes.Uploader.Name
+		}
+		if sub.Attributes.Uploader.Rank != nil {
+			uploaderRank = *sub.Attributes.Uploader.Rank
+		}
+		fmt.Printf("  Uploader:    %s (Rank: %s)\n", uploaderName, uploaderRank)
 		fmt.Printf("  Downloads:   %d\n", sub.Attributes.DownloadCount)
 		fmt.Printf("  Votes:       %d (Score: %.2f)\n", sub.Attributes.Votes, sub.Attributes.Ratings)
 		fmt.Printf("  Is Hearing Impaired: %t\n", sub.Attributes.HearingImpaired)
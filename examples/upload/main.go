@@ -111,7 +111,7 @@ func main() {
 
 	// --- Perform Upload ---
 	fmt.Println("\nUploading subtitle...")
-	subtitleURL, err := uploader.Upload(intent)
+	result, err := uploader.Upload(intent)
 
 	if err != nil {
 		if errors.Is(err, upload.ErrUploadDuplicate) {
@@ -125,7 +125,8 @@ func main() {
 
 	// --- Display Upload Result ---
 	fmt.Printf("\nUpload successful!\n")
-	fmt.Printf("  Subtitle URL: %s\n", subtitleURL)
+	fmt.Printf("  Subtitle URL: %s\n", result.URL)
+	fmt.Printf("  Subtitle ID: %d\n", result.SubtitleID)
 
 	// --- Logout Uploader ---
 	fmt.Println("\nLogging out XML-RPC Uploader...")
@@ -1,22 +1,273 @@
 package opensubtitles
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/google/go-querystring/query"
+)
 
 // Methods related to features (Movies, TV Shows, Episodes)
 
+// ErrFeatureNotFound is returned by GetEpisodesForSeason and ResolveEpisodeFeature when
+// the TV show, season or episode is not listed by the API.
+var ErrFeatureNotFound = errors.New("opensubtitles: feature not found")
+
 // SearchFeatures searches for features (movies, tvshows, episodes) based on criteria.
-// Note: The response contains a slice of Feature structs where the Attributes field
-// is an interface{}. Users will need to inspect the FeatureType within the attributes
-// (after potentially unmarshalling the interface{} into a map[string]interface{})
-// and then perform a type assertion or unmarshal the attributes into the specific
-// type (FeatureMovieAttributes, FeatureTvshowAttributes, FeatureEpisodeAttributes)
-// to access type-specific fields.
+// Each Feature's Attributes are decoded into FeatureMovieAttributes, FeatureTvshowAttributes
+// or FeatureEpisodeAttributes; use Feature.AsMovie, AsTvshow or AsEpisode to access them.
 func (c *Client) SearchFeatures(ctx context.Context, params SearchFeaturesParams) (*SearchFeaturesResponse, error) {
-	var response SearchFeaturesResponse
-	// Params struct has `url` tags for query string encoding
-	err := c.httpClient.Get(ctx, "/features", params, &response)
+	key, err := query.Values(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode query parameters: %w", err)
+	}
+	return dedupe(ctx, c, "/features?"+key.Encode(), func(ctx context.Context) (*SearchFeaturesResponse, error) {
+		var response SearchFeaturesResponse
+		// Params struct has `url` tags for query string encoding
+		if err := c.httpClient.Get(ctx, "/features", params, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}, func(response *SearchFeaturesResponse) *SearchFeaturesResponse {
+		return &SearchFeaturesResponse{Data: slices.Clone(response.Data)}
+	})
+}
+
+// EpisodeFeature identifies an episode resolved by ResolveEpisodeFeature.
+type EpisodeFeature struct {
+	ShowFeatureID int
+	ShowTitle     string
+	FeatureID     int
+	IMDbID        int // 0 if the API has no IMDb ID for the episode
+	Title         string
+	SeasonNumber  int
+	EpisodeNumber int
+}
+
+// GetEpisodesForSeason returns the episodes of a season of the TV show with feature ID
+// parentFeatureID, as listed in the show's FeatureTvshowAttributes. It returns
+// ErrFeatureNotFound if the show or the season is not found.
+func (c *Client) GetEpisodesForSeason(ctx context.Context, parentFeatureID, season int) ([]TvshowEpisodeStub, error) {
+	resp, err := c.SearchFeatures(ctx, SearchFeaturesParams{FeatureID: &parentFeatureID})
+	if err != nil {
+		return nil, err
+	}
+	for _, feature := range resp.Data {
+		if show, ok := feature.AsTvshow(); ok && show.FeatureID == strconv.Itoa(parentFeatureID) {
+			return seasonEpisodes(show, season)
+		}
+	}
+	return nil, fmt.Errorf("%w: no TV show with feature ID %d", ErrFeatureNotFound, parentFeatureID)
+}
+
+// ResolveEpisodeFeature searches the TV show matching showQuery and returns the feature
+// of the given episode, saving the lookups through the show's seasons and episodes. The
+// first TV show in the search results is used. It returns ErrFeatureNotFound if no
+// show matches or the show has no such episode.
+func (c *Client) ResolveEpisodeFeature(ctx context.Context, showQuery string, season, episode int) (*EpisodeFeature, error) {
+	showType := string(FeatureTVShow)
+	resp, err := c.SearchFeatures(ctx, SearchFeaturesParams{Query: &showQuery, Type: &showType})
+	if err != nil {
+		return nil, err
+	}
+	var show *FeatureTvshowAttributes
+	for _, feature := range resp.Data {
+		if s, ok := feature.AsTvshow(); ok {
+			show = s
+			break
+		}
+	}
+	if show == nil {
+		return nil, fmt.Errorf("%w: no TV show matches %q", ErrFeatureNotFound, showQuery)
+	}
+	showID, err := strconv.Atoi(show.FeatureID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid feature ID %q of TV show %q: %w", show.FeatureID, show.Title, err)
+	}
+
+	// Search results may omit the seasons; the feature lookup lists them.
+	var episodes []TvshowEpisodeStub
+	if len(show.Seasons) > 0 {
+		episodes, err = seasonEpisodes(show, season)
+	} else {
+		episodes, err = c.GetEpisodesForSeason(ctx, showID, season)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, stub := range episodes {
+		if stub.EpisodeNumber != episode {
+			continue
+		}
+		id, err := strconv.Atoi(stub.FeatureID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature ID %q of %s S%02dE%02d: %w", stub.FeatureID, show.Title, season, episode, err)
+		}
+		resolved := &EpisodeFeature{
+			ShowFeatureID: showID,
+			ShowTitle:     show.Title,
+			FeatureID:     id,
+			Title:         stub.Title,
+			SeasonNumber:  season,
+			EpisodeNumber: episode,
+		}
+		// The stubs carry no IMDb ID; look up the episode feature for it.
+		details, err := c.SearchFeatures(ctx, SearchFeaturesParams{FeatureID: &id})
+		if err != nil {
+			return nil, err
+		}
+		for _, feature := range details.Data {
+			if ep, ok := feature.AsEpisode(); ok && ep.IMDbID != nil {
+				resolved.IMDbID = *ep.IMDbID
+				break
+			}
+		}
+		return resolved, nil
+	}
+	return nil, fmt.Errorf("%w: %s has no episode S%02dE%02d", ErrFeatureNotFound, show.Title, season, episode)
+}
+
+// seasonEpisodes returns the episodes of season of show.
+func seasonEpisodes(show *FeatureTvshowAttributes, season int) ([]TvshowEpisodeStub, error) {
+	for _, s := range show.Seasons {
+		if s.SeasonNumber == season {
+			return s.Episodes, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s has no season %d", ErrFeatureNotFound, show.Title, season)
+}
+
+// DefaultFeatureLookupWorkers is the number of concurrent /features calls made by
+// GetFeaturesByIDs.
+const DefaultFeatureLookupWorkers = 4
+
+// FeatureIDRef identifies a feature by exactly one of its OpenSubtitles feature ID,
+// IMDb ID or TMDB ID.
+type FeatureIDRef struct {
+	FeatureID int
+	IMDbID    int
+	TMDBID    int
+}
+
+// FeatureLookupResult is the outcome of looking up a single FeatureIDRef.
+type FeatureLookupResult struct {
+	Feature *Feature
+	Err     error
+}
+
+// GetFeaturesByIDs looks up every ref with bounded concurrency and returns the
+// results keyed by ref; duplicate refs are looked up once. A ref the API does not know
+// gets ErrFeatureNotFound, and a ref without exactly one ID set ErrInvalidSearchParams.
+// The returned error is only set if ctx ends early.
+func (c *Client) GetFeaturesByIDs(ctx context.Context, ids []FeatureIDRef) (map[FeatureIDRef]FeatureLookupResult, error) {
+	results := make(map[FeatureIDRef]FeatureLookupResult, len(ids))
+	unique := make([]FeatureIDRef, 0, len(ids))
+	for _, ref := range ids {
+		if _, seen := results[ref]; !seen {
+			results[ref] = FeatureLookupResult{}
+			unique = append(unique, ref)
+		}
+	}
+
+	workers := DefaultFeatureLookupWorkers
+	if workers > len(unique) {
+		workers = len(unique)
+	}
+
+	var mu sync.Mutex
+	jobs := make(chan FeatureIDRef)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ref := range jobs {
+				feature, err := c.getFeatureByID(ctx, ref)
+				mu.Lock()
+				results[ref] = FeatureLookupResult{Feature: feature, Err: err}
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i, ref := range unique {
+		select {
+		case jobs <- ref:
+		case <-ctx.Done():
+			mu.Lock()
+			for _, rest := range unique[i:] {
+				results[rest] = FeatureLookupResult{Err: ctx.Err()}
+			}
+			mu.Unlock()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// getFeatureByID looks up the feature identified by ref. An IMDb or TMDB lookup may
+// also return related features, so the one carrying the requested ID is preferred.
+func (c *Client) getFeatureByID(ctx context.Context, ref FeatureIDRef) (*Feature, error) {
+	var params SearchFeaturesParams
+	set := 0
+	if ref.FeatureID != 0 {
+		params.FeatureID = &ref.FeatureID
+		set++
+	}
+	if ref.IMDbID != 0 {
+		imdb := strconv.Itoa(ref.IMDbID)
+		params.IMDbID = &imdb
+		set++
+	}
+	if ref.TMDBID != 0 {
+		tmdb := strconv.Itoa(ref.TMDBID)
+		params.TMDBID = &tmdb
+		set++
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("%w: feature reference %+v must set exactly one ID", ErrInvalidSearchParams, ref)
+	}
+
+	resp, err := c.SearchFeatures(ctx, params)
 	if err != nil {
 		return nil, err
 	}
-	return &response, nil
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("%w: no feature for %+v", ErrFeatureNotFound, ref)
+	}
+	for i := range resp.Data {
+		base := featureBase(resp.Data[i])
+		if base == nil {
+			continue
+		}
+		switch {
+		case ref.FeatureID != 0 && base.FeatureID == strconv.Itoa(ref.FeatureID),
+			ref.IMDbID != 0 && base.IMDbID != nil && *base.IMDbID == ref.IMDbID,
+			ref.TMDBID != 0 && base.TMDBID != nil && *base.TMDBID == ref.TMDBID:
+			return &resp.Data[i], nil
+		}
+	}
+	return &resp.Data[0], nil
+}
+
+// featureBase returns the attributes common to all feature types, or nil for an
+// unknown type.
+func featureBase(f Feature) *FeatureBaseAttributes {
+	if movie, ok := f.AsMovie(); ok {
+		return &movie.FeatureBaseAttributes
+	}
+	if show, ok := f.AsTvshow(); ok {
+		return &show.FeatureBaseAttributes
+	}
+	if episode, ok := f.AsEpisode(); ok {
+		return &episode.FeatureBaseAttributes
+	}
+	return nil
 }
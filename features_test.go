@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync/atomic"
 
 	// "net/url"
 	"testing"
@@ -39,7 +40,7 @@ func TestSearchFeaturesSuccessByID(t *testing.T) {
 					Attributes: FeatureEpisodeAttributes{ // Use specific struct for marshaling test data
 						FeatureBaseAttributes: FeatureBaseAttributes{
 							FeatureID:   expectedFeatureID,
-							FeatureType: expectedFeatureType,
+							FeatureType: FeatureType(expectedFeatureType),
 							Title:       "the tortelli tort",
 							Year:        "1982",
 							IMDbID:      pint(539911),
@@ -151,7 +152,7 @@ func TestSearchFeaturesSuccessByQuery(t *testing.T) {
 	var movieAttrs FeatureMovieAttributes
 	err = json.Unmarshal(rawAttrs, &movieAttrs)
 	require.NoError(t, err)
-	assert.Equal(t, "Movie", movieAttrs.FeatureType)
+	assert.Equal(t, FeatureMovie, movieAttrs.FeatureType)
 	assert.Equal(t, "514811", movieAttrs.FeatureID)
 }
 
@@ -171,6 +172,87 @@ func TestSearchFeaturesError(t *testing.T) {
 	assert.Contains(t, err.Error(), "status 400")
 }
 
+// breakingBadFeatures serves a TV show whose search results omit the seasons, the show
+// with its seasons by feature ID, and the episode by feature ID.
+func breakingBadFeatures(t *testing.T) http.HandlerFunc {
+	show := FeatureBaseAttributes{FeatureID: "1001", FeatureType: "Tvshow", Title: "Breaking Bad", Year: "2008"}
+	return func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/features", r.URL.Path)
+		query := r.URL.Query()
+		var data []Feature
+		switch {
+		case query.Get("query") != "":
+			assert.Equal(t, "breaking bad", query.Get("query"))
+			assert.Equal(t, "tvshow", query.Get("type"))
+			data = []Feature{{Attributes: FeatureTvshowAttributes{FeatureBaseAttributes: show, SeasonsCount: 5}}}
+		case query.Get("feature_id") == "1001":
+			data = []Feature{{Attributes: FeatureTvshowAttributes{
+				FeatureBaseAttributes: show,
+				SeasonsCount:          5,
+				Seasons: []TvshowSeason{
+					{SeasonNumber: 1, Episodes: []TvshowEpisodeStub{{EpisodeNumber: 1, Title: "Pilot", FeatureID: "2001"}}},
+					{SeasonNumber: 2, Episodes: []TvshowEpisodeStub{
+						{EpisodeNumber: 1, Title: "Seven Thirty-Seven", FeatureID: "2101"},
+						{EpisodeNumber: 2, Title: "Grilled", FeatureID: "2102"},
+					}},
+				},
+			}}}
+		case query.Get("feature_id") == "2102":
+			data = []Feature{{Attributes: FeatureEpisodeAttributes{
+				FeatureBaseAttributes: FeatureBaseAttributes{FeatureID: "2102", FeatureType: "Episode", Title: "Grilled", IMDbID: pint(1232249)},
+				ParentFeatureID:       pstr("1001"),
+				SeasonNumber:          2,
+				EpisodeNumber:         2,
+			}}}
+		default:
+			data = []Feature{}
+		}
+		require.NoError(t, json.NewEncoder(w).Encode(SearchFeaturesResponse{Data: data}))
+	}
+}
+
+func TestGetEpisodesForSeason(t *testing.T) {
+	_, client := setupTestServer(t, breakingBadFeatures(t))
+
+	episodes, err := client.GetEpisodesForSeason(context.Background(), 1001, 2)
+	require.NoError(t, err)
+	require.Len(t, episodes, 2)
+	assert.Equal(t, TvshowEpisodeStub{EpisodeNumber: 2, Title: "Grilled", FeatureID: "2102"}, episodes[1])
+
+	_, err = client.GetEpisodesForSeason(context.Background(), 1001, 9)
+	assert.ErrorIs(t, err, ErrFeatureNotFound)
+	_, err = client.GetEpisodesForSeason(context.Background(), 42, 1)
+	assert.ErrorIs(t, err, ErrFeatureNotFound)
+}
+
+func TestResolveEpisodeFeature(t *testing.T) {
+	_, client := setupTestServer(t, breakingBadFeatures(t))
+
+	episode, err := client.ResolveEpisodeFeature(context.Background(), "breaking bad", 2, 2)
+	require.NoError(t, err)
+	assert.Equal(t, &EpisodeFeature{
+		ShowFeatureID: 1001,
+		ShowTitle:     "Breaking Bad",
+		FeatureID:     2102,
+		IMDbID:        1232249,
+		Title:         "Grilled",
+		SeasonNumber:  2,
+		EpisodeNumber: 2,
+	}, episode)
+
+	_, err = client.ResolveEpisodeFeature(context.Background(), "breaking bad", 2, 14)
+	assert.ErrorIs(t, err, ErrFeatureNotFound)
+}
+
+func TestResolveEpisodeFeatureNoShow(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+
+	_, err := client.ResolveEpisodeFeature(context.Background(), "nothing", 1, 1)
+	assert.ErrorIs(t, err, ErrFeatureNotFound)
+}
+
 // Helper functions to create pointers easily in tests
 func pint(i int) *int       { return &i }
 func pstr(s string) *string { return &s }
@@ -180,3 +262,64 @@ func pstr(s string) *string { return &s }
 
 // Helper needed for tests in this file
 // func String(s string) *string { return &s }
+
+func TestGetFeaturesByIDs(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, "/api/v1/features", r.URL.Path)
+		query := r.URL.Query()
+		switch {
+		case query.Get("imdb_id") == "133093":
+			// Related features come first; the one with the requested IMDb ID wins.
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"2","type":"feature","attributes":{"feature_id":"2","feature_type":"Movie","title":"The Matrix Reloaded","imdb_id":234215}},
+				{"id":"1","type":"feature","attributes":{"feature_id":"1","feature_type":"Movie","title":"The Matrix","imdb_id":133093}}]}`))
+		case query.Get("tmdb_id") == "1396":
+			_, _ = w.Write([]byte(`{"data":[{"id":"3","type":"feature","attributes":{"feature_id":"3","feature_type":"Tvshow","title":"Breaking Bad","tmdb_id":1396}}]}`))
+		case query.Get("feature_id") == "4":
+			_, _ = w.Write([]byte(`{"data":[{"id":"4","type":"feature","attributes":{"feature_id":"4","feature_type":"Episode","title":"Pilot"}}]}`))
+		default:
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		}
+	}
+	_, client := setupTestServer(t, handler)
+
+	matrix := FeatureIDRef{IMDbID: 133093}
+	breakingBad := FeatureIDRef{TMDBID: 1396}
+	pilot := FeatureIDRef{FeatureID: 4}
+	missing := FeatureIDRef{IMDbID: 1}
+	ambiguous := FeatureIDRef{IMDbID: 1, TMDBID: 2}
+	results, err := client.GetFeaturesByIDs(context.Background(), []FeatureIDRef{matrix, breakingBad, matrix, pilot, missing, ambiguous})
+	require.NoError(t, err)
+	require.Len(t, results, 5)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "duplicates and invalid refs are not looked up")
+
+	movie, ok := results[matrix].Feature.AsMovie()
+	require.True(t, ok)
+	assert.Equal(t, "The Matrix", movie.Title)
+	show, ok := results[breakingBad].Feature.AsTvshow()
+	require.True(t, ok)
+	assert.Equal(t, "Breaking Bad", show.Title)
+	episode, ok := results[pilot].Feature.AsEpisode()
+	require.True(t, ok)
+	assert.Equal(t, "Pilot", episode.Title)
+
+	assert.ErrorIs(t, results[missing].Err, ErrFeatureNotFound)
+	assert.Nil(t, results[missing].Feature)
+	assert.ErrorIs(t, results[ambiguous].Err, ErrInvalidSearchParams)
+}
+
+func TestGetFeaturesByIDsCanceled(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected after cancellation")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := client.GetFeaturesByIDs(ctx, []FeatureIDRef{{IMDbID: 1}, {IMDbID: 2}})
+	require.ErrorIs(t, err, context.Canceled)
+	for _, result := range results {
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,349 @@
+// Package feed renders subtitle listings, such as the output of Client.DiscoverLatest,
+// as RSS 2.0 or Atom feeds so new subtitle availability can be followed with any
+// feed reader. Feeds can be written to disk or served over HTTP.
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// Options describes the feed channel and which subtitles it includes.
+type Options struct {
+	Title       string // Defaults to "OpenSubtitles: latest subtitles"
+	Link        string // Defaults to "https://www.opensubtitles.com"
+	Description string
+	// Languages restricts items to these language codes. Empty means all languages.
+	Languages []opensubtitles.LanguageCode
+	// Titles restricts items to features or shows whose title contains one of these
+	// strings (case-insensitive). Empty means all titles.
+	Titles []string
+	// MaxItems caps the number of items, newest first. Zero means no limit.
+	MaxItems int
+}
+
+// Item is a single feed entry.
+type Item struct {
+	ID        string
+	Title     string
+	Link      string
+	Summary   string
+	Language  opensubtitles.LanguageCode
+	Published time.Time
+}
+
+// Feed is a renderable list of items.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Updated     time.Time
+	Items       []Item
+}
+
+// FromSubtitles builds a Feed from subtitles, applying the filters in opts.
+// Items are ordered newest first.
+func FromSubtitles(subs []opensubtitles.Subtitle, opts Options) *Feed {
+	f := &Feed{
+		Title:       opts.Title,
+		Link:        opts.Link,
+		Description: opts.Description,
+	}
+	if f.Title == "" {
+		f.Title = "OpenSubtitles: latest subtitles"
+	}
+	if f.Link == "" {
+		f.Link = "https://www.opensubtitles.com"
+	}
+	if f.Description == "" {
+		f.Description = f.Title
+	}
+
+	for _, sub := range subs {
+		if !matches(sub, opts) {
+			continue
+		}
+		f.Items = append(f.Items, itemFromSubtitle(sub))
+	}
+	sort.SliceStable(f.Items, func(i, j int) bool { return f.Items[i].Published.After(f.Items[j].Published) })
+	if opts.MaxItems > 0 && len(f.Items) > opts.MaxItems {
+		f.Items = f.Items[:opts.MaxItems]
+	}
+	if len(f.Items) > 0 {
+		f.Updated = f.Items[0].Published
+	}
+	return f
+}
+
+func matches(sub opensubtitles.Subtitle, opts Options) bool {
+	if len(opts.Languages) > 0 {
+		found := false
+		for _, lang := range opts.Languages {
+			if strings.EqualFold(string(lang), string(sub.Attributes.Language)) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if len(opts.Titles) > 0 {
+		details := sub.Attributes.FeatureDetails
+		candidates := []string{details.Title, details.MovieName}
+		if details.ParentTitle != nil {
+			candidates = append(candidates, *details.ParentTitle)
+		}
+		for _, want := range opts.Titles {
+			for _, have := range candidates {
+				if have != "" && strings.Contains(strings.ToLower(have), strings.ToLower(want)) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func itemFromSubtitle(sub opensubtitles.Subtitle) Item {
+	attrs := sub.Attributes
+	name := attrs.FeatureDetails.MovieName
+	if name == "" {
+		name = attrs.FeatureDetails.Title
+	}
+	if name == "" {
+		name = attrs.Release
+	}
+
+	var summary []string
+	if attrs.Release != "" {
+		summary = append(summary, "Release: "+attrs.Release)
+	}
+	if attrs.Uploader.Name != nil && *attrs.Uploader.Name != "" {
+		summary = append(summary, "Uploader: "+*attrs.Uploader.Name)
+	}
+	if attrs.HearingImpaired {
+		summary = append(summary, "Hearing impaired")
+	}
+
+	id := sub.ID
+	if id == "" {
+		id = attrs.SubtitleID
+	}
+	return Item{
+		ID:        id,
+		Title:     fmt.Sprintf("%s [%s]", name, attrs.Language),
+		Link:      attrs.URL,
+		Summary:   strings.Join(summary, " | "),
+		Language:  attrs.Language,
+		Published: attrs.UploadDate,
+	}
+}
+
+// --- RSS 2.0 ---
+
+type rssDoc struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description,omitempty"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate,omitempty"`
+	Category    string  `xml:"category,omitempty"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes the feed as RSS 2.0.
+func (f *Feed) WriteRSS(w io.Writer) error {
+	doc := rssDoc{Version: "2.0", Channel: rssChannel{
+		Title:       f.Title,
+		Link:        f.Link,
+		Description: f.Description,
+	}}
+	if !f.Updated.IsZero() {
+		doc.Channel.LastBuildDate = f.Updated.UTC().Format(time.RFC1123Z)
+	}
+	for _, item := range f.Items {
+		ri := rssItem{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Summary,
+			GUID:        rssGUID{Value: "opensubtitles:subtitle:" + item.ID},
+			Category:    string(item.Language),
+		}
+		if !item.Published.IsZero() {
+			ri.PubDate = item.Published.UTC().Format(time.RFC1123Z)
+		}
+		doc.Channel.Items = append(doc.Channel.Items, ri)
+	}
+	return writeXML(w, doc)
+}
+
+// --- Atom ---
+
+type atomDoc struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Link    atomLink    `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	ID       string        `xml:"id"`
+	Title    string        `xml:"title"`
+	Updated  string        `xml:"updated"`
+	Link     *atomLink     `xml:"link,omitempty"`
+	Summary  string        `xml:"summary,omitempty"`
+	Category *atomCategory `xml:"category,omitempty"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+// WriteAtom writes the feed as Atom 1.0.
+func (f *Feed) WriteAtom(w io.Writer) error {
+	updated := f.Updated
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	doc := atomDoc{
+		ID:      f.Link,
+		Title:   f.Title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Link:    atomLink{Href: f.Link},
+	}
+	for _, item := range f.Items {
+		entry := atomEntry{
+			ID:      "urn:opensubtitles:subtitle:" + item.ID,
+			Title:   item.Title,
+			Updated: item.Published.UTC().Format(time.RFC3339),
+			Summary: item.Summary,
+		}
+		if item.Link != "" {
+			entry.Link = &atomLink{Href: item.Link}
+		}
+		if item.Language != "" {
+			entry.Category = &atomCategory{Term: string(item.Language)}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, doc interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("feed: failed to encode: %w", err)
+	}
+	return enc.Flush()
+}
+
+// Format selects the feed flavour written by WriteFile and served by Handler.
+type Format string
+
+const (
+	FormatRSS  Format = "rss"
+	FormatAtom Format = "atom"
+)
+
+// Write writes the feed in the given format.
+func (f *Feed) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatAtom:
+		return f.WriteAtom(w)
+	case FormatRSS, "":
+		return f.WriteRSS(w)
+	default:
+		return fmt.Errorf("feed: unknown format %q", format)
+	}
+}
+
+// WriteFile renders the feed and writes it to name on fsys (vfs.OS if nil),
+// replacing the file atomically.
+func (f *Feed) WriteFile(fsys vfs.FS, name string, format Format) error {
+	if fsys == nil {
+		fsys = vfs.OS
+	}
+	var buf bytes.Buffer
+	if err := f.Write(&buf, format); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := vfs.WriteFile(fsys, tmp, buf.Bytes()); err != nil {
+		return fmt.Errorf("feed: failed to write '%s': %w", tmp, err)
+	}
+	if err := fsys.Rename(tmp, name); err != nil {
+		_ = fsys.Remove(tmp)
+		return fmt.Errorf("feed: failed to move '%s' into place: %w", name, err)
+	}
+	return nil
+}
+
+// Source produces the subtitles rendered by Handler, e.g. by calling DiscoverLatest.
+type Source func(ctx context.Context) ([]opensubtitles.Subtitle, error)
+
+// Handler serves the feed built from source on every request. The format is taken
+// from the "format" query parameter ("rss" or "atom"), defaulting to defaultFormat.
+func Handler(source Source, opts Options, defaultFormat Format) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subs, err := source(r.Context())
+		if err != nil {
+			http.Error(w, "failed to load subtitles", http.StatusBadGateway)
+			return
+		}
+		format := Format(r.URL.Query().Get("format"))
+		if format == "" {
+			format = defaultFormat
+		}
+		var buf bytes.Buffer
+		if err := FromSubtitles(subs, opts).Write(&buf, format); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if format == FormatAtom {
+			w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		} else {
+			w.Header().Set("Content-Type", "application/rss+xml; charset=utf-8")
+		}
+		_, _ = w.Write(buf.Bytes())
+	})
+}
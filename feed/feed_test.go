@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subtitle(id, lang, movieName string, uploaded time.Time) opensubtitles.Subtitle {
+	return opensubtitles.Subtitle{
+		ApiDataWrapper: opensubtitles.ApiDataWrapper{ID: id, Type: "subtitle"},
+		Attributes: opensubtitles.SubtitleAttributes{
+			SubtitleID: id,
+			Language:   opensubtitles.LanguageCode(lang),
+			Release:    movieName + ".1080p.WEB",
+			UploadDate: uploaded,
+			URL:        "https://www.opensubtitles.com/en/subtitles/" + id,
+			FeatureDetails: opensubtitles.SubtitleFeatureDetails{
+				Title:     movieName,
+				MovieName: movieName,
+			},
+		},
+	}
+}
+
+func sampleSubtitles() []opensubtitles.Subtitle {
+	base := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return []opensubtitles.Subtitle{
+		subtitle("1", "en", "Dune Part Two", base),
+		subtitle("2", "el", "Dune Part Two", base.Add(time.Hour)),
+		subtitle("3", "el", "Oppenheimer", base.Add(2*time.Hour)),
+	}
+}
+
+func TestFromSubtitlesFilters(t *testing.T) {
+	f := FromSubtitles(sampleSubtitles(), Options{})
+	require.Len(t, f.Items, 3)
+	assert.Equal(t, "3", f.Items[0].ID, "newest first")
+	assert.Equal(t, f.Items[0].Published, f.Updated)
+
+	f = FromSubtitles(sampleSubtitles(), Options{Languages: []opensubtitles.LanguageCode{"EL"}})
+	require.Len(t, f.Items, 2)
+
+	f = FromSubtitles(sampleSubtitles(), Options{Languages: []opensubtitles.LanguageCode{"el"}, Titles: []string{"dune"}})
+	require.Len(t, f.Items, 1)
+	assert.Equal(t, "Dune Part Two [el]", f.Items[0].Title)
+
+	f = FromSubtitles(sampleSubtitles(), Options{MaxItems: 1})
+	require.Len(t, f.Items, 1)
+}
+
+func TestWriteRSS(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, FromSubtitles(sampleSubtitles(), Options{Title: "Greek subs"}).WriteRSS(&buf))
+
+	var doc rssDoc
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "2.0", doc.Version)
+	assert.Equal(t, "Greek subs", doc.Channel.Title)
+	require.Len(t, doc.Channel.Items, 3)
+	assert.Equal(t, "opensubtitles:subtitle:3", doc.Channel.Items[0].GUID.Value)
+	assert.Equal(t, "Fri, 01 Mar 2024 14:00:00 +0000", doc.Channel.Items[0].PubDate)
+	assert.Equal(t, "https://www.opensubtitles.com/en/subtitles/3", doc.Channel.Items[0].Link)
+}
+
+func TestWriteAtom(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, FromSubtitles(sampleSubtitles(), Options{}).WriteAtom(&buf))
+
+	var doc atomDoc
+	require.NoError(t, xml.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, "2024-03-01T14:00:00Z", doc.Updated)
+	require.Len(t, doc.Entries, 3)
+	assert.Equal(t, "urn:opensubtitles:subtitle:3", doc.Entries[0].ID)
+	require.NotNil(t, doc.Entries[0].Category)
+	assert.Equal(t, "el", doc.Entries[0].Category.Term)
+}
+
+func TestWriteFile(t *testing.T) {
+	m := vfs.NewMemFS()
+	require.NoError(t, FromSubtitles(sampleSubtitles(), Options{}).WriteFile(m, "latest.xml", FormatAtom))
+
+	data, err := fs.ReadFile(m, "latest.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+}
+
+func TestHandler(t *testing.T) {
+	source := func(ctx context.Context) ([]opensubtitles.Subtitle, error) { return sampleSubtitles(), nil }
+	h := Handler(source, Options{}, FormatRSS)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/rss+xml; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), "<rss version=\"2.0\">")
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed?format=atom", nil))
+	assert.Equal(t, "application/atom+xml; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/feed?format=json", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
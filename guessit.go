@@ -0,0 +1,264 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultGuessitWorkers is the number of concurrent Guessit calls made by GuessitBatch
+// when GuessitBatchOptions.Workers is not set.
+const DefaultGuessitWorkers = 4
+
+// GuessitBatchOptions configures GuessitBatch.
+type GuessitBatchOptions struct {
+	// Workers is the number of concurrent API calls (default: DefaultGuessitWorkers).
+	Workers int
+	// Offline skips the API and parses every filename with ParseReleaseName.
+	Offline bool
+	// NoFallback reports API failures in GuessitResult.Err instead of falling back
+	// to ParseReleaseName.
+	NoFallback bool
+}
+
+// GuessitResult is the outcome of guessing a single filename.
+type GuessitResult struct {
+	Guess *GuessitResponse
+	// Local reports whether Guess came from ParseReleaseName instead of the API.
+	Local bool
+	Err   error
+}
+
+// GuessitBatch calls Guessit for every filename with bounded concurrency and returns
+// the results keyed by filename; duplicate filenames are guessed once. When the API is
+// unavailable (network errors, 5xx or exhausted rate limit retries) the filename is
+// parsed locally with ParseReleaseName instead. The returned error is only set if ctx
+// ends early.
+func (c *Client) GuessitBatch(ctx context.Context, filenames []string, opts GuessitBatchOptions) (map[string]GuessitResult, error) {
+	results := make(map[string]GuessitResult, len(filenames))
+	unique := make([]string, 0, len(filenames))
+	for _, name := range filenames {
+		if _, seen := results[name]; !seen {
+			results[name] = GuessitResult{}
+			unique = append(unique, name)
+		}
+	}
+	if opts.Offline {
+		for _, name := range unique {
+			results[name] = GuessitResult{Guess: ParseReleaseName(name), Local: true}
+		}
+		return results, nil
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultGuessitWorkers
+	}
+	if workers > len(unique) {
+		workers = len(unique)
+	}
+
+	var mu sync.Mutex
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range jobs {
+				result := c.guessFilename(ctx, name, opts)
+				mu.Lock()
+				results[name] = result
+				mu.Unlock()
+			}
+		}()
+	}
+
+feed:
+	for i, name := range unique {
+		select {
+		case jobs <- name:
+		case <-ctx.Done():
+			mu.Lock()
+			for _, rest := range unique[i:] {
+				results[rest] = GuessitResult{Err: ctx.Err()}
+			}
+			mu.Unlock()
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// guessFilename calls Guessit for name, falling back to ParseReleaseName if the
+// API is unavailable.
+func (c *Client) guessFilename(ctx context.Context, name string, opts GuessitBatchOptions) GuessitResult {
+	guess, err := c.Guessit(ctx, GuessitParams{Filename: name})
+	if err == nil {
+		return GuessitResult{Guess: guess}
+	}
+	if opts.NoFallback || ctx.Err() != nil || !apiUnavailable(err) {
+		return GuessitResult{Err: err}
+	}
+	return GuessitResult{Guess: ParseReleaseName(name), Local: true}
+}
+
+// apiUnavailable reports whether err means the API could not answer, as opposed to
+// rejecting the request.
+func apiUnavailable(err error) bool {
+	if errors.Is(err, ErrServiceUnavailable) || errors.Is(err, ErrRateLimited) {
+		return true
+	}
+	var apiErr *APIError
+	return !errors.As(err, &apiErr)
+}
+
+var (
+	episodePattern = regexp.MustCompile(`(?i)^s(\d{1,2})e(\d{1,3})$|^(\d{1,2})x(\d{2,3})$`)
+	yearPattern    = regexp.MustCompile(`^(19|20)\d{2}$`)
+	screenPattern  = regexp.MustCompile(`(?i)^(\d{3,4}[pi]|4k)$`)
+)
+
+// releaseSources maps lower-case release tokens to guessit source names.
+var releaseSources = map[string]string{
+	"bluray": "Blu-ray", "bdrip": "Blu-ray", "brrip": "Blu-ray", "bdremux": "Blu-ray",
+	"webrip": "WEBRip", "webdl": "Web", "web": "Web",
+	"hdtv": "HDTV", "dvdrip": "DVD", "dvd": "DVD", "hdrip": "HDRip",
+}
+
+// releaseVideoCodecs maps lower-case release tokens to guessit video codec names.
+var releaseVideoCodecs = map[string]string{
+	"x264": "H.264", "h264": "H.264", "avc": "H.264",
+	"x265": "H.265", "h265": "H.265", "hevc": "H.265",
+	"xvid": "Xvid", "divx": "DivX", "av1": "AV1",
+}
+
+// releaseAudioCodecs maps lower-case release tokens to guessit audio codec names.
+var releaseAudioCodecs = map[string]string{
+	"aac": "AAC", "ac3": "Dolby Digital", "dd": "Dolby Digital", "dd5": "Dolby Digital",
+	"ddp": "Dolby Digital Plus", "ddp5": "Dolby Digital Plus", "eac3": "Dolby Digital Plus",
+	"dts": "DTS", "truehd": "Dolby TrueHD", "flac": "FLAC", "mp3": "MP3",
+}
+
+// releaseStreamingServices maps lower-case release tokens to streaming service names.
+var releaseStreamingServices = map[string]string{
+	"nf": "Netflix", "amzn": "Amazon Prime", "dsnp": "Disney+", "hmax": "HBO Max",
+	"atvp": "Apple TV+", "hulu": "Hulu",
+}
+
+// releaseOtherTokens are technical tokens that end the title but carry no field.
+var releaseOtherTokens = map[string]bool{
+	"proper": true, "repack": true, "internal": true, "remux": true, "hdr": true,
+	"10bit": true, "multi": true, "extended": true, "uncut": true, "atmos": true,
+}
+
+// ParseReleaseName extracts title, year, season, episode and technical details from a
+// scene-style release or file name without calling the API. It is the offline
+// fallback of GuessitBatch and understands common naming only, e.g.
+// "Show.Name.S01E02.Episode.Title.1080p.WEB-DL.DDP5.1.x264-GROUP.mkv".
+func ParseReleaseName(filename string) *GuessitResponse {
+	name := filename
+	if i := strings.LastIndexAny(name, `/\`); i >= 0 {
+		name = name[i+1:]
+	}
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && len(name)-i <= 5 {
+		switch strings.ToLower(name[i+1:]) {
+		case "mkv", "mp4", "avi", "m4v", "mov", "wmv", "ts", "srt", "sub", "ass", "vtt":
+			name = name[:i]
+		}
+	}
+
+	guess := &GuessitResponse{}
+	// The release group follows the last dash, unless the dash is part of WEB-DL.
+	if i := strings.LastIndexByte(name, '-'); i > 0 && i < len(name)-1 {
+		group := name[i+1:]
+		if !strings.ContainsAny(group, ". ") && !strings.EqualFold(group, "DL") {
+			guess.ReleaseGroup = &group
+			name = name[:i]
+		}
+	}
+
+	tokens := strings.FieldsFunc(strings.ReplaceAll(name, "WEB-DL", "WEBDL"), func(r rune) bool {
+		return r == '.' || r == ' ' || r == '_' || r == '-' || r == '[' || r == ']' || r == '(' || r == ')'
+	})
+	// Only the last year-like token is the year, so "Blade Runner 2049 2017" keeps
+	// 2049 in the title.
+	yearIndex := -1
+	for i := len(tokens) - 1; i > 0; i-- {
+		if yearPattern.MatchString(tokens[i]) {
+			yearIndex = i
+			break
+		}
+	}
+	var title, episodeTitle []string
+	titleDone, afterEpisode := false, false
+	for i, token := range tokens {
+		lower := strings.ToLower(token)
+		technical := true
+		switch {
+		case episodePattern.MatchString(token):
+			m := episodePattern.FindStringSubmatch(token)
+			season, episode := m[1], m[2]
+			if season == "" {
+				season, episode = m[3], m[4]
+			}
+			s, _ := strconv.Atoi(season)
+			e, _ := strconv.Atoi(episode)
+			guess.Season, guess.Episode = &s, &e
+			titleDone, afterEpisode = true, true
+			continue
+		case i == yearIndex:
+			year, _ := strconv.Atoi(token)
+			guess.Year = &year
+		case screenPattern.MatchString(token):
+			size := lower
+			if size == "4k" {
+				size = "2160p"
+			}
+			guess.ScreenSize = &size
+		case releaseSources[lower] != "":
+			source := releaseSources[lower]
+			guess.Source = &source
+		case releaseVideoCodecs[lower] != "":
+			codec := releaseVideoCodecs[lower]
+			guess.VideoCodec = &codec
+		case releaseAudioCodecs[lower] != "":
+			codec := releaseAudioCodecs[lower]
+			guess.AudioCodec = &codec
+		case releaseStreamingServices[lower] != "":
+			service := releaseStreamingServices[lower]
+			guess.StreamingService = &service
+		case releaseOtherTokens[lower]:
+		default:
+			technical = false
+		}
+		switch {
+		case technical:
+			titleDone, afterEpisode = true, false
+		case !titleDone:
+			title = append(title, token)
+		case afterEpisode:
+			episodeTitle = append(episodeTitle, token)
+		}
+	}
+
+	if len(title) > 0 {
+		t := strings.Join(title, " ")
+		guess.Title = &t
+	}
+	if len(episodeTitle) > 0 {
+		t := strings.Join(episodeTitle, " ")
+		guess.EpisodeTitle = &t
+	}
+	kind := "movie"
+	if guess.Episode != nil {
+		kind = "episode"
+	}
+	guess.Type = &kind
+	return guess
+}
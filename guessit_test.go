@@ -0,0 +1,114 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGuessitBatch(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		filename := r.URL.Query().Get("filename")
+		switch {
+		case strings.HasPrefix(filename, "down"):
+			w.WriteHeader(http.StatusInternalServerError)
+		case strings.HasPrefix(filename, "bad"):
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"message":"invalid filename"}`))
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"title":"` + strings.TrimSuffix(filename, ".mkv") + `","type":"movie"}`))
+		}
+	}
+	_, client := setupTestServer(t, handler)
+
+	files := []string{"Alpha.mkv", "Beta.mkv", "Alpha.mkv", "down.Gamma.2019.720p.mkv", "bad.mkv"}
+	results, err := client.GuessitBatch(context.Background(), files, GuessitBatchOptions{Workers: 2})
+	require.NoError(t, err)
+	require.Len(t, results, 4)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&calls), "duplicate filenames are guessed once")
+
+	assert.Equal(t, "Alpha", *results["Alpha.mkv"].Guess.Title)
+	assert.False(t, results["Alpha.mkv"].Local)
+	assert.Equal(t, "Beta", *results["Beta.mkv"].Guess.Title)
+
+	fallback := results["down.Gamma.2019.720p.mkv"]
+	require.NoError(t, fallback.Err)
+	assert.True(t, fallback.Local)
+	assert.Equal(t, "down Gamma", *fallback.Guess.Title)
+	assert.Equal(t, 2019, *fallback.Guess.Year)
+
+	assert.ErrorIs(t, results["bad.mkv"].Err, ErrValidation, "rejected requests do not fall back")
+	assert.Nil(t, results["bad.mkv"].Guess)
+}
+
+func TestGuessitBatchNoFallbackAndOffline(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	_, client := setupTestServer(t, handler)
+
+	results, err := client.GuessitBatch(context.Background(), []string{"Movie.2020.mkv"}, GuessitBatchOptions{NoFallback: true})
+	require.NoError(t, err)
+	assert.ErrorIs(t, results["Movie.2020.mkv"].Err, ErrServiceUnavailable)
+
+	results, err = client.GuessitBatch(context.Background(), []string{"Movie.2020.mkv"}, GuessitBatchOptions{Offline: true})
+	require.NoError(t, err)
+	assert.True(t, results["Movie.2020.mkv"].Local)
+	assert.Equal(t, "Movie", *results["Movie.2020.mkv"].Guess.Title)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "Offline does not call the API")
+}
+
+func TestGuessitBatchCanceled(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected after cancellation")
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := client.GuessitBatch(ctx, []string{"a.mkv", "b.mkv"}, GuessitBatchOptions{Workers: 1})
+	require.ErrorIs(t, err, context.Canceled)
+	for _, result := range results {
+		assert.Error(t, result.Err)
+	}
+}
+
+func TestParseReleaseName(t *testing.T) {
+	episode := ParseReleaseName("/tv/Stranger.Things.S04E01.Chapter.One.1080p.NF.WEB-DL.DDP5.1.x264-GalaxyTV.mkv")
+	assert.Equal(t, "Stranger Things", *episode.Title)
+	assert.Equal(t, 4, *episode.Season)
+	assert.Equal(t, 1, *episode.Episode)
+	assert.Equal(t, "Chapter One", *episode.EpisodeTitle)
+	assert.Equal(t, "1080p", *episode.ScreenSize)
+	assert.Equal(t, "Netflix", *episode.StreamingService)
+	assert.Equal(t, "Web", *episode.Source)
+	assert.Equal(t, "Dolby Digital Plus", *episode.AudioCodec)
+	assert.Equal(t, "H.264", *episode.VideoCodec)
+	assert.Equal(t, "GalaxyTV", *episode.ReleaseGroup)
+	assert.Equal(t, "episode", *episode.Type)
+	assert.Nil(t, episode.Year)
+
+	movie := ParseReleaseName("Blade Runner 2049 (2017) [2160p] BluRay x265.mkv")
+	assert.Equal(t, "Blade Runner 2049", *movie.Title)
+	assert.Equal(t, 2017, *movie.Year)
+	assert.Equal(t, "2160p", *movie.ScreenSize)
+	assert.Equal(t, "Blu-ray", *movie.Source)
+	assert.Equal(t, "H.265", *movie.VideoCodec)
+	assert.Nil(t, movie.ReleaseGroup)
+	assert.Equal(t, "movie", *movie.Type)
+
+	alt := ParseReleaseName("show_name_2x05.hdtv-lol.avi")
+	assert.Equal(t, "show name", *alt.Title)
+	assert.Equal(t, 2, *alt.Season)
+	assert.Equal(t, 5, *alt.Episode)
+	assert.Equal(t, "lol", *alt.ReleaseGroup)
+}
@@ -0,0 +1,87 @@
+// Package hash computes the OpenSubtitles movie hash ("moviehash"), the 64-bit checksum
+// used by SearchSubtitlesParams.Moviehash to match subtitles to an exact video file.
+//
+// The hash is the file size plus the sums of the 64-bit little-endian words in the
+// first and last 64 KiB of the file, formatted as 16 hex digits. See
+// https://trac.opensubtitles.org/projects/opensubtitles/wiki/HashSourceCodes
+package hash
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// ChunkSize is the number of bytes hashed from the start and the end of the file.
+const ChunkSize = 64 * 1024
+
+// ErrFileTooSmall is returned for files smaller than two chunks, which the
+// OpenSubtitles hash is not defined for.
+var ErrFileTooSmall = errors.New("hash: file is too small for OSDb hashing")
+
+// ComputeOSDbHash computes the OpenSubtitles hash of the file at path and returns it
+// together with the file size.
+func ComputeOSDbHash(path string) (hash string, size int64, err error) {
+	return ComputeOSDbHashFS(vfs.OS, path)
+}
+
+// ComputeOSDbHashFS computes the OpenSubtitles hash of a file read from fsys.
+// The file returned by fsys must implement io.ReaderAt.
+func ComputeOSDbHashFS(fsys fs.FS, path string) (hash string, size int64, err error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open file for OSDb hashing '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to stat file '%s': %w", path, err)
+	}
+	size = stat.Size()
+
+	readerAt, ok := file.(io.ReaderAt)
+	if !ok {
+		return "", size, fmt.Errorf("file '%s' does not support random access for OSDb hashing", path)
+	}
+	hash, err = ComputeOSDbHashReaderAt(readerAt, size)
+	if err != nil {
+		return "", size, fmt.Errorf("'%s': %w", path, err)
+	}
+	return hash, size, nil
+}
+
+// ComputeOSDbHashReaderAt computes the OpenSubtitles hash of size bytes readable from r,
+// e.g. an open file, a byte slice reader or a ranged HTTP reader.
+func ComputeOSDbHashReaderAt(r io.ReaderAt, size int64) (string, error) {
+	if size < ChunkSize*2 {
+		return "", fmt.Errorf("%w (size: %d)", ErrFileTooSmall, size)
+	}
+
+	buf := make([]byte, ChunkSize)
+	sum := uint64(size)
+	for _, offset := range []int64{0, size - ChunkSize} {
+		// ReadAt may return io.EOF alongside a full read of the final chunk.
+		if n, err := r.ReadAt(buf, offset); n < len(buf) {
+			if err == nil {
+				err = io.ErrUnexpectedEOF
+			}
+			return "", fmt.Errorf("failed to read chunk at offset %d: %w", offset, err)
+		}
+		sum += checksum(buf)
+	}
+	// uint64 overflow is part of the algorithm.
+	return fmt.Sprintf("%016x", sum), nil
+}
+
+// checksum sums the 64-bit little-endian words in buf.
+func checksum(buf []byte) (sum uint64) {
+	for i := 0; i+8 <= len(buf); i += 8 {
+		sum += binary.LittleEndian.Uint64(buf[i : i+8])
+	}
+	return sum
+}
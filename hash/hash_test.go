@@ -0,0 +1,47 @@
+package hash
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeOSDbHash(t *testing.T) {
+	hash, size, err := ComputeOSDbHash("../testdata/video.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "a2b51e055b718161", hash)
+	assert.Equal(t, int64(345108), size)
+}
+
+func TestComputeOSDbHashReaderAt(t *testing.T) {
+	// An all-zero file hashes to its size.
+	zeros := make([]byte, 2*ChunkSize)
+	hash, err := ComputeOSDbHashReaderAt(bytes.NewReader(zeros), int64(len(zeros)))
+	require.NoError(t, err)
+	assert.Equal(t, "0000000000020000", hash)
+
+	// Words overflow uint64 as part of the algorithm.
+	ones := bytes.Repeat([]byte{0xff}, 2*ChunkSize)
+	hash, err = ComputeOSDbHashReaderAt(bytes.NewReader(ones), int64(len(ones)))
+	require.NoError(t, err)
+	assert.Equal(t, "000000000001c000", hash) // 0x20000 - 2*8192
+
+	_, err = ComputeOSDbHashReaderAt(bytes.NewReader(zeros[:100]), 100)
+	assert.ErrorIs(t, err, ErrFileTooSmall)
+
+	_, err = ComputeOSDbHashReaderAt(bytes.NewReader(zeros), int64(len(zeros))+10)
+	assert.Error(t, err, "size larger than the content must fail")
+}
+
+func TestComputeOSDbHashFS(t *testing.T) {
+	mem := vfs.NewMemFS()
+	require.NoError(t, mem.MkdirAll("movies", 0o755))
+	require.NoError(t, vfs.WriteFile(mem, "movies/a.mkv", make([]byte, 3*ChunkSize)))
+	hash, size, err := ComputeOSDbHashFS(mem, "movies/a.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3*ChunkSize), size)
+	assert.Equal(t, "0000000000030000", hash)
+}
@@ -0,0 +1,169 @@
+package hash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrRangeNotSupported is returned when a server answers a Range request with the
+// whole file; hashing would then download all of it.
+var ErrRangeNotSupported = errors.New("hash: server does not support range requests")
+
+// HTTPSource reads remote files over HTTP(S) with Range requests, e.g. from WebDAV
+// shares or S3 presigned URLs. The zero value uses http.DefaultClient.
+type HTTPSource struct {
+	Client *http.Client // Default: http.DefaultClient
+	Header http.Header  // Sent with every request, e.g. Authorization for WebDAV
+}
+
+// ComputeOSDbHashURL computes the OpenSubtitles hash of the file at url, fetching only
+// its first and last ChunkSize bytes, and returns it together with the file size.
+func ComputeOSDbHashURL(ctx context.Context, url string) (hash string, size int64, err error) {
+	return HTTPSource{}.ComputeOSDbHash(ctx, url)
+}
+
+// ComputeOSDbHash computes the OpenSubtitles hash of the file at url with two Range
+// requests and returns it together with the file size.
+func (s HTTPSource) ComputeOSDbHash(ctx context.Context, url string) (hash string, size int64, err error) {
+	file, err := s.Open(ctx, url)
+	if err != nil {
+		return "", 0, err
+	}
+	hash, err = ComputeOSDbHashReaderAt(file, file.Size())
+	if err != nil {
+		return "", file.Size(), fmt.Errorf("'%s': %w", url, err)
+	}
+	return hash, file.Size(), nil
+}
+
+// Open requests the first ChunkSize bytes of the file at url to learn its size and
+// returns an io.ReaderAt for it. ctx applies to all reads of the returned file.
+func (s HTTPSource) Open(ctx context.Context, url string) (*HTTPFile, error) {
+	f := &HTTPFile{ctx: ctx, source: s, url: url}
+	prefix, size, err := f.get(0, ChunkSize)
+	if err != nil {
+		return nil, err
+	}
+	f.prefix, f.size = prefix, size
+	return f, nil
+}
+
+// HTTPFile is a remote file read with HTTP Range requests. Its first ChunkSize bytes
+// are kept from Open, so hashing costs a single further request.
+type HTTPFile struct {
+	ctx    context.Context
+	source HTTPSource
+	url    string
+	size   int64
+	prefix []byte
+}
+
+// Size returns the size of the file reported by the server.
+func (f *HTTPFile) Size() int64 {
+	return f.size
+}
+
+// ReadAt implements io.ReaderAt with a Range request for the bytes not read by Open.
+func (f *HTTPFile) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("hash: negative offset %d", off)
+	}
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	want := int64(len(p))
+	if off+want > f.size {
+		want = f.size - off
+	}
+	var data []byte
+	if off+want <= int64(len(f.prefix)) {
+		data = f.prefix[off : off+want]
+	} else {
+		var err error
+		if data, _, err = f.get(off, want); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, data)
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// get fetches length bytes at off and returns them with the file size from the
+// Content-Range header.
+func (f *HTTPFile) get(off, length int64) ([]byte, int64, error) {
+	req, err := http.NewRequestWithContext(f.ctx, http.MethodGet, f.url, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create request for '%s': %w", f.url, err)
+	}
+	for key, values := range f.source.Header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", off, off+length-1))
+	client := f.source.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to fetch '%s': %w", f.url, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+	case http.StatusRequestedRangeNotSatisfiable:
+		// An empty file: "Content-Range: bytes */0".
+		size, ok := contentRangeSize(resp.Header.Get("Content-Range"))
+		if ok && size <= off {
+			return nil, size, nil
+		}
+		return nil, 0, fmt.Errorf("range %d-%d of '%s' is not satisfiable", off, off+length-1, f.url)
+	case http.StatusOK:
+		// Servers may answer with the whole file if it is no larger than the range.
+		if resp.ContentLength < 0 || resp.ContentLength > off+length {
+			return nil, 0, fmt.Errorf("%w: '%s'", ErrRangeNotSupported, f.url)
+		}
+		data := make([]byte, resp.ContentLength)
+		if _, err := io.ReadFull(resp.Body, data); err != nil {
+			return nil, 0, fmt.Errorf("failed to read '%s': %w", f.url, err)
+		}
+		if off > resp.ContentLength {
+			off = resp.ContentLength
+		}
+		return data[off:], resp.ContentLength, nil
+	default:
+		return nil, 0, fmt.Errorf("failed to fetch '%s': unexpected status %s", f.url, resp.Status)
+	}
+
+	size, ok := contentRangeSize(resp.Header.Get("Content-Range"))
+	if !ok {
+		return nil, 0, fmt.Errorf("missing or invalid Content-Range %q from '%s'", resp.Header.Get("Content-Range"), f.url)
+	}
+	if end := size - off; end < length {
+		length = end
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		return nil, 0, fmt.Errorf("failed to read range of '%s': %w", f.url, err)
+	}
+	return data, size, nil
+}
+
+// contentRangeSize returns the complete length from a "bytes 0-65535/345108" or
+// "bytes */0" Content-Range header.
+func contentRangeSize(header string) (int64, bool) {
+	_, total, ok := strings.Cut(header, "/")
+	if !ok || !strings.HasPrefix(header, "bytes ") {
+		return 0, false
+	}
+	size, err := strconv.ParseInt(total, 10, 64)
+	return size, err == nil && size >= 0
+}
@@ -0,0 +1,87 @@
+package hash
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeOSDbHashURL(t *testing.T) {
+	video, err := os.ReadFile("../testdata/video.mkv")
+	require.NoError(t, err)
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		assert.NotEmpty(t, r.Header.Get("Range"), "only ranges are requested")
+		if r.URL.Path == "/dav/video.mkv" {
+			assert.Equal(t, "Basic secret", r.Header.Get("Authorization"))
+		}
+		http.ServeContent(w, r, "video.mkv", time.Time{}, bytes.NewReader(video))
+	}))
+	defer server.Close()
+
+	hash, size, err := ComputeOSDbHashURL(context.Background(), server.URL+"/video.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "a2b51e055b718161", hash)
+	assert.Equal(t, int64(345108), size)
+	assert.Equal(t, int32(2), requests.Load(), "first and last chunk")
+
+	source := HTTPSource{Client: server.Client(), Header: http.Header{"Authorization": {"Basic secret"}}}
+	hash, _, err = source.ComputeOSDbHash(context.Background(), server.URL+"/dav/video.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, "a2b51e055b718161", hash)
+}
+
+func TestHTTPFileReadAt(t *testing.T) {
+	content := []byte("0123456789")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "small.bin", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	file, err := HTTPSource{}.Open(context.Background(), server.URL)
+	require.NoError(t, err)
+	assert.Equal(t, int64(10), file.Size())
+	buf := make([]byte, 4)
+	n, err := file.ReadAt(buf, 8)
+	assert.Equal(t, 2, n)
+	assert.ErrorIs(t, err, io.EOF)
+	assert.Equal(t, "89", string(buf[:n]))
+
+	_, _, err = ComputeOSDbHashURL(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrFileTooSmall)
+}
+
+func TestComputeOSDbHashURLErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-ranges":
+			_, _ = w.Write(make([]byte, 3*ChunkSize))
+		case "/empty":
+			http.ServeContent(w, r, "empty", time.Time{}, bytes.NewReader(nil))
+		default:
+			http.Error(w, "forbidden", http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	_, _, err := ComputeOSDbHashURL(ctx, server.URL+"/no-ranges")
+	assert.ErrorIs(t, err, ErrRangeNotSupported)
+
+	_, size, err := ComputeOSDbHashURL(ctx, server.URL+"/empty")
+	assert.ErrorIs(t, err, ErrFileTooSmall)
+	assert.Zero(t, size)
+
+	_, _, err = ComputeOSDbHashURL(ctx, server.URL+"/private")
+	assert.ErrorContains(t, err, "403 Forbidden")
+}
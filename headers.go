@@ -0,0 +1,33 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+)
+
+// RequestIDHeader is the header WithRequestID sets, "X-Request-ID".
+const RequestIDHeader = httpclient.RequestIDHeader
+
+// WithRequestHeaders returns a context whose API requests and file downloads carry
+// header, e.g. tracing headers such as "traceparent", in addition to Config.Headers
+// and the headers already added to ctx; values of the same names are replaced. The
+// headers the client sets itself (Api-Key, Authorization, User-Agent, Content-Type)
+// are never replaced. XML-RPC calls take no context and only send Config.Headers.
+func WithRequestHeaders(ctx context.Context, header http.Header) context.Context {
+	return httpclient.WithHeaders(ctx, header)
+}
+
+// WithRequestID returns a context whose requests carry id in the X-Request-ID
+// header, so that calls can be correlated across services. Debug logs of the
+// requests include it as "request_id".
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return WithRequestHeaders(ctx, http.Header{RequestIDHeader: {id}})
+}
+
+// RequestHeaders returns the headers added to ctx by WithRequestHeaders and
+// WithRequestID, or nil. The result must not be modified.
+func RequestHeaders(ctx context.Context) http.Header {
+	return httpclient.HeadersFrom(ctx)
+}
@@ -0,0 +1,43 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestHeaders(t *testing.T) {
+	var received []http.Header
+	server, _ := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		received = append(received, r.Header.Clone())
+		_, _ = w.Write([]byte(`{"total_count":0,"data":[]}`))
+	})
+	client, err := NewClient(Config{
+		ApiKey:    "test-api-key",
+		BaseURL:   server.URL + "/api/v1",
+		RateLimit: &testRateLimits,
+		Headers:   http.Header{"X-App": {"catalog"}, "Api-Key": {"other"}},
+	})
+	require.NoError(t, err)
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	ctx = WithRequestHeaders(ctx, http.Header{"x-app": {"catalog-sync"}, "Authorization": {"Bearer forged"}})
+	assert.Equal(t, http.Header{"X-Request-Id": {"req-1"}, "X-App": {"catalog-sync"}, "Authorization": {"Bearer forged"}}, RequestHeaders(ctx))
+	_, err = client.SearchFeatures(ctx, SearchFeaturesParams{Query: String("heat")})
+	require.NoError(t, err)
+	_, err = client.SearchFeatures(context.Background(), SearchFeaturesParams{Query: String("heat")})
+	require.NoError(t, err)
+
+	require.Len(t, received, 2)
+	assert.Equal(t, "req-1", received[0].Get(RequestIDHeader))
+	assert.Equal(t, "catalog-sync", received[0].Get("X-App"), "per-call headers replace Config.Headers")
+	assert.Equal(t, "test-api-key", received[0].Get("Api-Key"), "reserved headers are never replaced")
+	assert.Empty(t, received[0].Get("Authorization"))
+
+	assert.Empty(t, received[1].Get(RequestIDHeader))
+	assert.Equal(t, "catalog", received[1].Get("X-App"))
+	assert.Nil(t, RequestHeaders(context.Background()))
+}
@@ -0,0 +1,199 @@
+package opensubtitles
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DownloadHistoryPageSize is the number of entries per page of GetDownloadHistory.
+const DownloadHistoryPageSize = 50
+
+// ErrNoDownloadHistory is returned by GetDownloadHistory and HasDownloaded when
+// Config.DownloadHistory is not set. The REST API does not list past downloads; it
+// only reports their count through GetUserInfo.
+var ErrNoDownloadHistory = errors.New("opensubtitles: download history is not configured")
+
+// DownloadHistoryEntry records a download link issued by the API to Download.
+type DownloadHistoryEntry struct {
+	FileID       int       `json:"file_id"`
+	FileName     string    `json:"file_name,omitempty"`
+	SubFormat    string    `json:"sub_format,omitempty"` // Requested conversion, empty for the original format
+	DownloadedAt time.Time `json:"downloaded_at"`
+	Remaining    int       `json:"remaining"` // Quota left after the download
+}
+
+// DownloadHistory records the downloads of a Client. Download adds an entry for every
+// link it requests from the API; links reused within Config.DownloadLinkTTL are not
+// added again.
+type DownloadHistory interface {
+	Add(ctx context.Context, entry DownloadHistoryEntry) error
+	// List returns all entries, oldest first.
+	List(ctx context.Context) ([]DownloadHistoryEntry, error)
+}
+
+// DownloadHistoryResponse is a page of the download history, newest entries first.
+type DownloadHistoryResponse struct {
+	PaginatedResponse
+	Data []DownloadHistoryEntry
+}
+
+// GetDownloadHistory returns a page (starting at 1) of the downloads recorded in
+// Config.DownloadHistory, newest first, DownloadHistoryPageSize entries per page.
+// No request is sent to the API.
+func (c *Client) GetDownloadHistory(ctx context.Context, page int) (*DownloadHistoryResponse, error) {
+	if c.config.DownloadHistory == nil {
+		return nil, ErrNoDownloadHistory
+	}
+	if page < 1 {
+		page = 1
+	}
+	entries, err := c.config.DownloadHistory.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download history: %w", err)
+	}
+
+	resp := &DownloadHistoryResponse{PaginatedResponse: PaginatedResponse{
+		TotalPages: (len(entries) + DownloadHistoryPageSize - 1) / DownloadHistoryPageSize,
+		TotalCount: len(entries),
+		PerPage:    DownloadHistoryPageSize,
+		Page:       page,
+	}}
+	// Entries are stored oldest first; page 1 holds the newest.
+	end := len(entries) - (page-1)*DownloadHistoryPageSize
+	start := end - DownloadHistoryPageSize
+	if start < 0 {
+		start = 0
+	}
+	for i := end - 1; i >= start; i-- {
+		resp.Data = append(resp.Data, entries[i])
+	}
+	return resp, nil
+}
+
+// HasDownloaded reports whether Config.DownloadHistory holds a download of fileID,
+// e.g. to skip files the user already has.
+func (c *Client) HasDownloaded(ctx context.Context, fileID int) (bool, error) {
+	if c.config.DownloadHistory == nil {
+		return false, ErrNoDownloadHistory
+	}
+	entries, err := c.config.DownloadHistory.List(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to read download history: %w", err)
+	}
+	for _, entry := range entries {
+		if entry.FileID == fileID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// recordDownload adds a download to Config.DownloadHistory. Failures are logged; the
+// download itself succeeded.
+func (c *Client) recordDownload(ctx context.Context, req DownloadRequest, resp *DownloadResponse) {
+	if c.config.DownloadHistory == nil {
+		return
+	}
+	entry := DownloadHistoryEntry{
+		FileID:       req.FileID,
+		FileName:     resp.FileName,
+		DownloadedAt: time.Now().UTC(),
+		Remaining:    resp.Remaining,
+	}
+	if req.SubFormat != nil {
+		entry.SubFormat = *req.SubFormat
+	}
+	if err := c.config.DownloadHistory.Add(ctx, entry); err != nil {
+		c.logger.Warn("opensubtitles: failed to record download", "file_id", req.FileID, "error", err)
+	}
+}
+
+// MemoryDownloadHistory keeps the download history in memory. The zero value is ready
+// to use.
+type MemoryDownloadHistory struct {
+	mu      sync.Mutex
+	entries []DownloadHistoryEntry
+}
+
+// Ensure MemoryDownloadHistory implements DownloadHistory.
+var _ DownloadHistory = (*MemoryDownloadHistory)(nil)
+
+// Add appends entry.
+func (h *MemoryDownloadHistory) Add(ctx context.Context, entry DownloadHistoryEntry) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.entries = append(h.entries, entry)
+	return nil
+}
+
+// List returns a copy of the entries.
+func (h *MemoryDownloadHistory) List(ctx context.Context) ([]DownloadHistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return append([]DownloadHistoryEntry(nil), h.entries...), nil
+}
+
+// FileDownloadHistory stores the download history as JSON lines in a file readable
+// only by the owner (0600), one entry per line.
+type FileDownloadHistory struct {
+	Path string
+
+	mu sync.Mutex
+}
+
+// Ensure FileDownloadHistory implements DownloadHistory.
+var _ DownloadHistory = (*FileDownloadHistory)(nil)
+
+// Add appends entry to the file, creating it if needed.
+func (h *FileDownloadHistory) Add(ctx context.Context, entry DownloadHistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode download history entry: %w", err)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(h.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for download history '%s': %w", h.Path, err)
+	}
+	f, err := os.OpenFile(h.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open download history '%s': %w", h.Path, err)
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write download history '%s': %w", h.Path, err)
+	}
+	return f.Close()
+}
+
+// List reads the file. A missing file is an empty history; lines that cannot be
+// decoded, e.g. one cut short by a crash, are skipped.
+func (h *FileDownloadHistory) List(ctx context.Context) ([]DownloadHistoryEntry, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	data, err := os.ReadFile(h.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read download history '%s': %w", h.Path, err)
+	}
+	var entries []DownloadHistoryEntry
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var entry DownloadHistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
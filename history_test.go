@@ -0,0 +1,113 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadRecordsHistory(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/download", r.URL.Path)
+		_, _ = w.Write([]byte(`{"link":"https://example.com/1.srt","file_name":"movie.srt","remaining":9}`))
+	}
+	server, _ := setupTestServer(t, handler)
+	history := &MemoryDownloadHistory{}
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, DownloadHistory: history})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	downloaded, err := client.HasDownloaded(ctx, 1)
+	require.NoError(t, err)
+	assert.False(t, downloaded)
+
+	_, err = client.Download(ctx, DownloadRequest{FileID: 1, SubFormat: String("webvtt")})
+	require.NoError(t, err)
+
+	downloaded, err = client.HasDownloaded(ctx, 1)
+	require.NoError(t, err)
+	assert.True(t, downloaded)
+
+	page, err := client.GetDownloadHistory(ctx, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, page.TotalCount)
+	require.Len(t, page.Data, 1)
+	entry := page.Data[0]
+	assert.Equal(t, 1, entry.FileID)
+	assert.Equal(t, "movie.srt", entry.FileName)
+	assert.Equal(t, "webvtt", entry.SubFormat)
+	assert.Equal(t, 9, entry.Remaining)
+	assert.False(t, entry.DownloadedAt.IsZero())
+}
+
+func TestGetDownloadHistoryPages(t *testing.T) {
+	history := &MemoryDownloadHistory{}
+	ctx := context.Background()
+	for id := 1; id <= DownloadHistoryPageSize+5; id++ {
+		require.NoError(t, history.Add(ctx, DownloadHistoryEntry{FileID: id}))
+	}
+	client, err := NewClient(Config{ApiKey: "key", DownloadHistory: history})
+	require.NoError(t, err)
+
+	first, err := client.GetDownloadHistory(ctx, 0)
+	require.NoError(t, err)
+	assert.Equal(t, PaginatedResponse{TotalPages: 2, TotalCount: 55, PerPage: DownloadHistoryPageSize, Page: 1}, first.PaginatedResponse)
+	require.Len(t, first.Data, DownloadHistoryPageSize)
+	assert.Equal(t, 55, first.Data[0].FileID, "newest first")
+	assert.Equal(t, 6, first.Data[DownloadHistoryPageSize-1].FileID)
+
+	second, err := client.GetDownloadHistory(ctx, 2)
+	require.NoError(t, err)
+	require.Len(t, second.Data, 5)
+	assert.Equal(t, 5, second.Data[0].FileID)
+	assert.Equal(t, 1, second.Data[4].FileID)
+
+	beyond, err := client.GetDownloadHistory(ctx, 3)
+	require.NoError(t, err)
+	assert.Empty(t, beyond.Data)
+}
+
+func TestDownloadHistoryNotConfigured(t *testing.T) {
+	client, err := NewClient(Config{ApiKey: "key"})
+	require.NoError(t, err)
+
+	_, err = client.GetDownloadHistory(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrNoDownloadHistory)
+	_, err = client.HasDownloaded(context.Background(), 1)
+	assert.ErrorIs(t, err, ErrNoDownloadHistory)
+}
+
+func TestFileDownloadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "downloads.jsonl")
+	history := &FileDownloadHistory{Path: path}
+	ctx := context.Background()
+
+	entries, err := history.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "a missing file is an empty history")
+
+	require.NoError(t, history.Add(ctx, DownloadHistoryEntry{FileID: 1, FileName: "a.srt"}))
+	require.NoError(t, history.Add(ctx, DownloadHistoryEntry{FileID: 2, FileName: "b.srt"}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// A truncated last line is skipped.
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"file_id":3,"file_na`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	entries, err = (&FileDownloadHistory{Path: path}).List(ctx)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "a.srt", entries[0].FileName)
+	assert.Equal(t, 2, entries[1].FileID)
+}
@@ -1,6 +1,15 @@
 package errors
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
 
 // Standard API-related errors
 var (
@@ -9,10 +18,162 @@ var (
 	ErrNotFound           = errors.New("opensubtitles: resource not found")
 	ErrRateLimited        = errors.New("opensubtitles: rate limit exceeded")
 	ErrServiceUnavailable = errors.New("opensubtitles: service unavailable or internal server error")
+	ErrQuotaExceeded      = errors.New("opensubtitles: download quota exceeded")
+	ErrInvalidApiKey      = errors.New("opensubtitles: invalid API key")
+	ErrTokenExpired       = errors.New("opensubtitles: token expired or invalid")
+	ErrValidation         = errors.New("opensubtitles: request validation failed")
 
 	// Application/Flow specific errors
 	ErrNotLoggedIn     = errors.New("client: not logged in")
 	ErrUploadDuplicate = errors.New("upload: subtitle is already present in the database (duplicate)")
 )
 
-// TODO: Add more specific errors as needed, potentially wrapping underlying errors.
+// APIError is returned for API responses with a non-2xx status. It matches the
+// sentinel errors above with errors.Is, e.g. ErrNotFound for a 404, and exposes the
+// status, the message and, for validation failures, per-field details via errors.As.
+type APIError struct {
+	StatusCode int
+	Message    string              // "message" or joined "errors" from the response body
+	Fields     map[string][]string // Field errors of validation failures, keyed by field name
+	Body       string              // Raw response body; the first MaxDiagnosticBody bytes of non-JSON bodies
+	RetryAfter time.Duration       // Server's Retry-After or RateLimit-Reset hint; zero if none
+	kinds      []error
+}
+
+// MaxDiagnosticBody bounds the part of a non-JSON response body kept in APIError.Body.
+const MaxDiagnosticBody = 512
+
+var htmlTitlePattern = regexp.MustCompile(`(?is)<title[^>]*>(.*?)</title>`)
+
+// NewNonJSONError builds the error for a response that is not JSON, such as an HTML
+// maintenance page or a gateway error page from a proxy. Responses with a success or
+// 5xx status also match ErrServiceUnavailable. The message names the content type and
+// the page title, if any.
+func NewNonJSONError(statusCode int, contentType string, body []byte, tokenSent bool) *APIError {
+	diagnostic := body
+	if len(diagnostic) > MaxDiagnosticBody {
+		diagnostic = diagnostic[:MaxDiagnosticBody]
+	}
+	e := NewAPIError(statusCode, diagnostic, tokenSent)
+	if contentType == "" {
+		contentType = "unknown content type"
+	}
+	e.Message = "unexpected non-JSON response (" + contentType + ")"
+	if m := htmlTitlePattern.FindSubmatch(body); m != nil {
+		if title := strings.Join(strings.Fields(string(m[1])), " "); title != "" {
+			e.Message += ": " + title
+		}
+	}
+	if (statusCode < 400 || statusCode >= 500) && !e.Is(ErrServiceUnavailable) {
+		e.kinds = append(e.kinds, ErrServiceUnavailable)
+	}
+	return e
+}
+
+// NewAPIError builds the error for a failed response. tokenSent reports whether the
+// request carried a user token, which turns a 401 into ErrTokenExpired.
+func NewAPIError(statusCode int, body []byte, tokenSent bool) *APIError {
+	e := &APIError{StatusCode: statusCode, Body: string(body)}
+	e.parseBody(body)
+
+	msg := strings.ToLower(e.Message)
+	apiKeyProblem := strings.Contains(msg, "api key") || strings.Contains(msg, "apikey") || strings.Contains(msg, "cannot consume this service")
+	quotaProblem := strings.Contains(msg, "quota") || strings.Contains(msg, "downloaded your allowed")
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		e.kinds = append(e.kinds, ErrUnauthorized)
+		if apiKeyProblem {
+			e.kinds = append(e.kinds, ErrInvalidApiKey)
+		} else if tokenSent {
+			e.kinds = append(e.kinds, ErrTokenExpired)
+		}
+	case statusCode == http.StatusForbidden:
+		e.kinds = append(e.kinds, ErrForbidden)
+		if apiKeyProblem {
+			e.kinds = append(e.kinds, ErrInvalidApiKey)
+		}
+	case statusCode == http.StatusNotFound:
+		e.kinds = append(e.kinds, ErrNotFound)
+	case statusCode == http.StatusTooManyRequests:
+		e.kinds = append(e.kinds, ErrRateLimited)
+	case statusCode == http.StatusBadRequest || statusCode == http.StatusUnprocessableEntity:
+		e.kinds = append(e.kinds, ErrValidation)
+	case statusCode >= 500:
+		e.kinds = append(e.kinds, ErrServiceUnavailable)
+	}
+	// The API reports an exhausted download quota as 403 or 406.
+	if quotaProblem || (statusCode == http.StatusNotAcceptable && strings.Contains(msg, "download")) {
+		e.kinds = append(e.kinds, ErrQuotaExceeded)
+	}
+	return e
+}
+
+// parseBody extracts the message and field errors. The API uses
+// {"message": "..."}, {"errors": ["..."]} and {"errors": {"field": ["..."]}}.
+func (e *APIError) parseBody(body []byte) {
+	var payload struct {
+		Message string          `json:"message"`
+		Errors  json.RawMessage `json:"errors"`
+	}
+	if json.Unmarshal(body, &payload) != nil {
+		return
+	}
+	messages := []string{}
+	if payload.Message != "" {
+		messages = append(messages, payload.Message)
+	}
+	var list []string
+	var fields map[string]json.RawMessage
+	switch {
+	case json.Unmarshal(payload.Errors, &list) == nil:
+		messages = append(messages, list...)
+	case json.Unmarshal(payload.Errors, &fields) == nil:
+		e.Fields = make(map[string][]string, len(fields))
+		for field, raw := range fields {
+			var one string
+			var many []string
+			if json.Unmarshal(raw, &many) != nil {
+				if json.Unmarshal(raw, &one) != nil {
+					continue
+				}
+				many = []string{one}
+			}
+			e.Fields[field] = many
+		}
+	}
+	e.Message = strings.Join(messages, "; ")
+}
+
+// Error keeps the "status N" wording of earlier releases for log compatibility.
+func (e *APIError) Error() string {
+	detail := e.Message
+	if len(e.Fields) > 0 {
+		names := make([]string, 0, len(e.Fields))
+		for name := range e.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		parts := make([]string, 0, len(names))
+		for _, name := range names {
+			parts = append(parts, name+": "+strings.Join(e.Fields[name], ", "))
+		}
+		if detail != "" {
+			detail += "; "
+		}
+		detail += strings.Join(parts, "; ")
+	}
+	if detail == "" {
+		detail = e.Body
+	}
+	return fmt.Sprintf("api request failed: status %d: %s", e.StatusCode, detail)
+}
+
+// Is reports whether target is one of the sentinel errors this response maps to.
+func (e *APIError) Is(target error) bool {
+	for _, kind := range e.kinds {
+		if kind == target {
+			return true
+		}
+	}
+	return false
+}
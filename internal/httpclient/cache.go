@@ -0,0 +1,176 @@
+package httpclient
+
+import (
+	"container/list"
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CachedResponse is a cached API response body together with its validators.
+type CachedResponse struct {
+	Body         []byte
+	ETag         string
+	LastModified string
+	Expires      time.Time // Served without contacting the API until then
+}
+
+// ResponseCache stores responses of GET requests under a normalized path and query.
+// Implementations must be safe for concurrent use and should keep expired entries
+// for a while, so they can be revalidated with a conditional request.
+type ResponseCache interface {
+	Get(ctx context.Context, key string) (*CachedResponse, bool)
+	Set(ctx context.Context, key string, response *CachedResponse)
+}
+
+// CacheConfig enables response caching for selected GET endpoints.
+type CacheConfig struct {
+	// Store holds the responses. Nil uses a MemoryCache of DefaultMemoryCacheSize entries.
+	Store ResponseCache
+	// TTL is how long a response is served without revalidation (default DefaultCacheTTL).
+	TTL time.Duration
+	// Paths lists the cached endpoints (default DefaultCachePaths).
+	Paths []string
+}
+
+const (
+	// DefaultCacheTTL is used when CacheConfig.TTL is not set.
+	DefaultCacheTTL = 5 * time.Minute
+	// DefaultMemoryCacheSize is the capacity of the default in-memory store.
+	DefaultMemoryCacheSize = 256
+)
+
+// DefaultCachePaths are the lookup endpoints whose responses rarely change.
+var DefaultCachePaths = []string{"/features", "/subtitles", "/infos/languages", "/infos/formats"}
+
+// responseCache is the resolved CacheConfig.
+type responseCache struct {
+	store ResponseCache
+	ttl   time.Duration
+	paths map[string]bool
+}
+
+func newResponseCache(cfg CacheConfig) *responseCache {
+	rc := &responseCache{store: cfg.Store, ttl: cfg.TTL, paths: make(map[string]bool)}
+	if rc.store == nil {
+		rc.store = NewMemoryCache(DefaultMemoryCacheSize)
+	}
+	if rc.ttl <= 0 {
+		rc.ttl = DefaultCacheTTL
+	}
+	paths := cfg.Paths
+	if len(paths) == 0 {
+		paths = DefaultCachePaths
+	}
+	for _, p := range paths {
+		rc.paths[p] = true
+	}
+	return rc
+}
+
+// cacheLookup carries the cache key of a request and the entry found for it, if any.
+type cacheLookup struct {
+	cache *responseCache
+	key   string
+	entry *CachedResponse
+}
+
+// fresh reports whether the entry can be served without contacting the API.
+func (l *cacheLookup) fresh(now time.Time) bool {
+	return l != nil && l.entry != nil && now.Before(l.entry.Expires)
+}
+
+// setConditionalHeaders asks the API to answer 304 if the cached entry is still current.
+func (l *cacheLookup) setConditionalHeaders(req *http.Request) {
+	if l == nil || l.entry == nil {
+		return
+	}
+	if l.entry.ETag != "" {
+		req.Header.Set("If-None-Match", l.entry.ETag)
+	}
+	if l.entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", l.entry.LastModified)
+	}
+}
+
+// revalidated extends the cached entry after a 304 response and returns its body.
+func (l *cacheLookup) revalidated(ctx context.Context) []byte {
+	entry := *l.entry
+	entry.Expires = time.Now().Add(l.cache.ttl)
+	l.cache.store.Set(ctx, l.key, &entry)
+	return entry.Body
+}
+
+// store caches a successful response unless the API forbids it.
+func (l *cacheLookup) store(ctx context.Context, header http.Header, body []byte) {
+	if l == nil || strings.Contains(strings.ToLower(header.Get("Cache-Control")), "no-store") {
+		return
+	}
+	l.cache.store.Set(ctx, l.key, &CachedResponse{
+		Body:         body,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Expires:      time.Now().Add(l.cache.ttl),
+	})
+}
+
+// MemoryCache is an in-memory LRU ResponseCache.
+type MemoryCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List // Front is most recently used
+	items    map[string]*list.Element
+}
+
+type memoryCacheEntry struct {
+	key      string
+	response *CachedResponse
+}
+
+// Ensure MemoryCache implements ResponseCache.
+var _ ResponseCache = (*MemoryCache)(nil)
+
+// NewMemoryCache creates a cache holding at most capacity responses.
+func NewMemoryCache(capacity int) *MemoryCache {
+	if capacity <= 0 {
+		capacity = DefaultMemoryCacheSize
+	}
+	return &MemoryCache{capacity: capacity, ll: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Get returns the cached response for key, fresh or not.
+func (m *MemoryCache) Get(ctx context.Context, key string) (*CachedResponse, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	elem, ok := m.items[key]
+	if !ok {
+		return nil, false
+	}
+	m.ll.MoveToFront(elem)
+	return elem.Value.(*memoryCacheEntry).response, true
+}
+
+// Set stores response under key, evicting the least recently used entry if full.
+func (m *MemoryCache) Set(ctx context.Context, key string, response *CachedResponse) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.items[key]; ok {
+		elem.Value.(*memoryCacheEntry).response = response
+		m.ll.MoveToFront(elem)
+		return
+	}
+	m.items[key] = m.ll.PushFront(&memoryCacheEntry{key: key, response: response})
+	for m.ll.Len() > m.capacity {
+		entry := m.ll.Remove(m.ll.Back()).(*memoryCacheEntry)
+		delete(m.items, entry.key)
+	}
+}
+
+// Len returns the number of cached responses.
+func (m *MemoryCache) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.ll.Len()
+}
@@ -4,12 +4,20 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"mime"
+	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
+	"time"
 
+	apierrors "github.com/angelospk/opensubtitles-go/internal/errors"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
 	"github.com/google/go-querystring/query"
 )
 
@@ -19,18 +27,311 @@ type Client struct {
 	apiKey     string
 	userAgent  string
 	httpClient *http.Client
+	fileClient *http.Client // Fetches download links; sends no API credentials
 	mu         sync.RWMutex // Protects token
 	authToken  *string
+
+	hostChangeHandler   func(fromHost, toHost string)
+	unauthorizedHandler func(ctx context.Context, staleToken string) error
+	maxResponseBytes    int64
+	limiter             *rateLimiter
+	cache               *responseCache
+	logger              *slog.Logger
+	observer            Observer
+	responseHook        ResponseHook
+	idempotency         map[string]IdempotencyPolicy
+	timeouts            Timeouts
+	headers             http.Header // Sent with every request; see SetHeaders
+}
+
+// Timeouts bound API requests. A zero duration means no timeout.
+type Timeouts struct {
+	Default   time.Duration            // Each API request attempt
+	Endpoints map[string]time.Duration // By path, e.g. "/upload"; overrides Default
+	Download  time.Duration            // Fetching a download link, including the body
+}
+
+// forPath returns the timeout of a request to path.
+func (t Timeouts) forPath(path string) time.Duration {
+	if d, ok := t.Endpoints[path]; ok {
+		return d
+	}
+	return t.Default
 }
 
+// Observer is called after every API request with the endpoint path (e.g.
+// "/subtitles"), the response status (0 if no response was received) and the latency.
+type Observer func(method, endpoint string, status int, latency time.Duration)
+
+// ResponseHook is called with the status and headers of every API response,
+// including error responses, after redirects were followed. ctx is the request's.
+type ResponseHook func(ctx context.Context, method, endpoint string, status int, header http.Header)
+
+// maxRedirects bounds redirect chains, matching net/http's default.
+const maxRedirects = 10
+
+// DefaultMaxResponseBytes is the default limit on the size of a (decompressed) response body.
+const DefaultMaxResponseBytes = 16 << 20 // 16 MiB
+
+// ErrResponseTooLarge is returned when a response body exceeds the configured limit.
+var ErrResponseTooLarge = errors.New("opensubtitles: response body exceeds size limit")
+
 // New creates a new internal HTTP client.
 func New(baseURL, apiKey, userAgent string) *Client {
-	return &Client{
-		baseURL:    baseURL,
-		apiKey:     apiKey,
-		userAgent:  userAgent,
-		httpClient: &http.Client{}, // Use default client, customize if needed (timeout, transport)
+	c := &Client{
+		baseURL:          baseURL,
+		apiKey:           apiKey,
+		userAgent:        userAgent,
+		maxResponseBytes: DefaultMaxResponseBytes,
+		limiter:          newRateLimiter(DefaultRateLimits),
+		logger:           logging.Discard(),
+	}
+	c.httpClient = &http.Client{CheckRedirect: c.checkRedirect} // Customize further if needed (timeout, transport)
+	c.fileClient = &http.Client{}
+	return c
+}
+
+// SetHTTPClient replaces the underlying HTTP client, e.g. to use a custom transport.
+// Its CheckRedirect is replaced for API requests. It must be called before
+// SetMiddlewares and before the client is used.
+func (c *Client) SetHTTPClient(base *http.Client) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	api, files := *base, *base
+	api.CheckRedirect = c.checkRedirect
+	c.httpClient, c.fileClient = &api, &files
+}
+
+// SetTimeouts sets the timeouts of API requests and file downloads.
+func (c *Client) SetTimeouts(timeouts Timeouts) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeouts = timeouts
+	if timeouts.Download > 0 {
+		c.fileClient.Timeout = timeouts.Download
+	}
+}
+
+// SetMaxResponseBytes sets the maximum accepted response body size.
+// Zero restores DefaultMaxResponseBytes; a negative value disables the limit.
+func (c *Client) SetMaxResponseBytes(limit int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if limit == 0 {
+		limit = DefaultMaxResponseBytes
+	}
+	c.maxResponseBytes = limit
+}
+
+// SetRateLimits replaces the client-side rate limits and 429 retry policy.
+func (c *Client) SetRateLimits(limits RateLimits) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.limiter = newRateLimiter(limits)
+}
+
+// RateLimitStats returns the state of the rate limit bucket of each endpoint class,
+// including the rates learned from the response headers.
+func (c *Client) RateLimitStats() map[string]RateLimitStats {
+	c.mu.RLock()
+	limiter := c.limiter
+	c.mu.RUnlock()
+	return limiter.stats(time.Now())
+}
+
+// SetResponseCache enables caching of GET responses for the configured paths.
+func (c *Client) SetResponseCache(cfg CacheConfig) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cache = newResponseCache(cfg)
+}
+
+// SetMiddlewares installs a middleware chain (see Chain) in front of the transport used
+// for API requests and file downloads. It must be called before the client is used.
+func (c *Client) SetMiddlewares(middlewares ...RoundTripperFunc) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	transport := Chain(c.httpClient.Transport, middlewares...)
+	c.httpClient.Transport = transport
+	c.fileClient.Transport = transport
+}
+
+// SetLogger sets the logger for request logs (debug level) and warnings.
+// Nil disables logging.
+func (c *Client) SetLogger(logger *slog.Logger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logger = logging.OrDiscard(logger)
+}
+
+// SetObserver sets the function called after every API request. Nil disables it.
+func (c *Client) SetObserver(observer Observer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.observer = observer
+}
+
+// SetResponseHook sets the function called with every API response. Nil disables it.
+func (c *Client) SetResponseHook(hook ResponseHook) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.responseHook = hook
+}
+
+// SetHostChangeHandler sets the callback invoked when the API redirects to another host.
+// A nil handler logs a warning to the logger instead.
+func (c *Client) SetHostChangeHandler(handler func(fromHost, toHost string)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hostChangeHandler = handler
+}
+
+// SetUnauthorizedHandler sets the callback invoked when a request fails with 401.
+// The handler receives the token the request was sent with and should obtain a new
+// one; if it returns nil the request is retried once. Requests to /login and /logout
+// are never retried.
+func (c *Client) SetUnauthorizedHandler(handler func(ctx context.Context, staleToken string) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.unauthorizedHandler = handler
+}
+
+// checkRedirect follows API redirects. net/http drops the Authorization header when a
+// redirect leaves the original domain, so it is re-applied (together with the API key)
+// for hosts in the same registrable domain, e.g. api. -> vip-api.opensubtitles.com.
+// Redirects that would turn a POST/DELETE into a GET are handed back to doRequest,
+// which re-issues them with the original method and body.
+func (c *Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	original := via[0]
+	if req.Method != original.Method {
+		return http.ErrUseLastResponse
+	}
+	if req.URL.Host != original.URL.Host {
+		if !sameSite(original.URL, req.URL) {
+			return fmt.Errorf("refusing redirect from %s to untrusted host %s", original.URL.Host, req.URL.Host)
+		}
+		for _, header := range []string{"Api-Key", "Authorization"} {
+			if v := original.Header.Get(header); v != "" {
+				req.Header.Set(header, v)
+			}
+		}
+		c.notifyHostChange(original.URL.Host, req.URL.Host)
+	}
+	return nil
+}
+
+// LimitReader returns a reader that fails with ErrResponseTooLarge once more than limit
+// bytes have been read from r. A negative limit returns r unchanged.
+func LimitReader(r io.Reader, limit int64) io.Reader {
+	if limit < 0 {
+		return r
 	}
+	return &limitedReader{r: r, remaining: limit}
+}
+
+type limitedReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.remaining < 0 {
+		return 0, ErrResponseTooLarge
+	}
+	// Read one byte past the limit so an exactly-sized body is not rejected.
+	if int64(len(p)) > l.remaining+1 {
+		p = p[:l.remaining+1]
+	}
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, ErrResponseTooLarge
+	}
+	return n, err
+}
+
+// RawBody is a pre-encoded request body, e.g. multipart form data, passed to Post
+// in place of a value to be JSON-encoded.
+type RawBody struct {
+	ContentType string
+	Data        []byte
+}
+
+// newRequest builds a request with the standard API headers.
+func (c *Client) newRequest(ctx context.Context, method, requestURL string, payload []byte, contentType string, token *string) (*http.Request, error) {
+	var reqBody io.Reader
+	if payload != nil {
+		reqBody = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	// Set headers
+	req.Header.Set("Api-Key", c.apiKey)
+	req.Header.Set("User-Agent", c.userAgent)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	// Add Authorization header if token exists
+	if token != nil && *token != "" {
+		req.Header.Set("Authorization", "Bearer "+*token)
+	}
+	c.applyHeaders(req)
+	return req, nil
+}
+
+// redirectLocation returns the Location of a redirect response that net/http handed
+// back unfollowed (see checkRedirect).
+func redirectLocation(resp *http.Response) (string, bool) {
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		return "", false
+	}
+	location := resp.Header.Get("Location")
+	return location, location != ""
+}
+
+// notifyHostChange reports that the API answered from a different host.
+func (c *Client) notifyHostChange(fromHost, toHost string) {
+	c.mu.RLock()
+	handler := c.hostChangeHandler
+	logger := c.logger
+	c.mu.RUnlock()
+	if handler != nil {
+		handler(fromHost, toHost)
+		return
+	}
+	logger.Warn("opensubtitles: API redirected to another host; consider updating the base URL", "from", fromHost, "to", toHost)
+}
+
+// sameSite reports whether both URLs use the same scheme security and registrable domain
+// (approximated by the last two labels of the hostname; IP addresses must match exactly).
+func sameSite(from, to *url.URL) bool {
+	if from.Scheme == "https" && to.Scheme != "https" {
+		return false // Never downgrade credentials to plain HTTP
+	}
+	fromHost, toHost := from.Hostname(), to.Hostname()
+	if net.ParseIP(fromHost) != nil || net.ParseIP(toHost) != nil {
+		return fromHost == toHost
+	}
+	return registrableDomain(fromHost) == registrableDomain(toHost)
+}
+
+func registrableDomain(host string) string {
+	labels := strings.Split(strings.TrimSuffix(strings.ToLower(host), "."), ".")
+	if len(labels) <= 2 {
+		return strings.Join(labels, ".")
+	}
+	return strings.Join(labels[len(labels)-2:], ".")
 }
 
 // SetBaseURL updates the base URL used for requests.
@@ -62,93 +363,384 @@ func (c *Client) Delete(ctx context.Context, path string, target interface{}) er
 	return c.doRequest(ctx, http.MethodDelete, path, nil, nil, target)
 }
 
-// doRequest performs the actual HTTP request.
+// Fetch GETs an absolute URL, such as a download link returned by the API, and returns
+// its body limited to the configured maximum response size. API credentials are not
+// sent, since download links point at a CDN. The caller must close the body.
+func (c *Client) Fetch(ctx context.Context, rawURL string) (io.ReadCloser, error) {
+	file, err := c.FetchFile(ctx, rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return file.Body, nil
+}
+
+// FetchedFile is a file opened by FetchFile.
+type FetchedFile struct {
+	Body          io.ReadCloser // Limited to the configured maximum response size
+	Header        http.Header
+	ContentLength int64 // -1 if unknown, e.g. for compressed transfers
+}
+
+// FetchFile is like Fetch but also returns the response headers and length.
+func (c *Client) FetchFile(ctx context.Context, rawURL string) (*FetchedFile, error) {
+	c.mu.RLock()
+	maxResponseBytes := c.maxResponseBytes
+	logger := c.logger
+	c.mu.RUnlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", withoutQuery(err))
+	}
+	req.Header.Set("User-Agent", c.userAgent)
+	c.applyHeaders(req)
+	start := time.Now()
+	resp, err := c.fileClient.Do(req)
+	err = withoutQuery(err)
+	logRequest(ctx, logger, req, resp, err, time.Since(start))
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("file request failed: status %d, body: %s", resp.StatusCode, string(msg))
+	}
+	return &FetchedFile{
+		Body:          limitedBody{Reader: LimitReader(resp.Body, maxResponseBytes), Closer: resp.Body},
+		Header:        resp.Header,
+		ContentLength: resp.ContentLength,
+	}, nil
+}
+
+// logRequest logs a finished HTTP exchange at debug level with its rate limit headers.
+// Query strings are left out as download links carry credentials in them.
+func logRequest(ctx context.Context, logger *slog.Logger, req *http.Request, resp *http.Response, err error, duration time.Duration) {
+	if !logger.Enabled(ctx, slog.LevelDebug) {
+		return
+	}
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("host", req.URL.Host),
+		slog.String("path", req.URL.Path),
+		slog.Duration("duration", duration),
+	}
+	if id := req.Header.Get(RequestIDHeader); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		logger.LogAttrs(ctx, slog.LevelDebug, "opensubtitles: request failed", attrs...)
+		return
+	}
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	for _, header := range []string{"RateLimit-Remaining", "RateLimit-Reset", "X-RateLimit-Remaining-Second", "Retry-After"} {
+		if v := resp.Header.Get(header); v != "" {
+			attrs = append(attrs, slog.String(strings.ToLower(header), v))
+		}
+	}
+	logger.LogAttrs(ctx, slog.LevelDebug, "opensubtitles: request", attrs...)
+}
+
+// withoutQuery removes the query from the URL of a *url.Error, such as the ones
+// returned by http.Client.Do, so that the credentials of download links do not end
+// up in errors and logs.
+func withoutQuery(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL, _, _ = strings.Cut(urlErr.URL, "?")
+	}
+	return err
+}
+
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// QueryEncoder is implemented by parameter types that encode their own query string,
+// bypassing reflection-based encoding on hot paths. EncodeQuery must return the same
+// output as go-querystring followed by url.Values.Encode.
+type QueryEncoder interface {
+	EncodeQuery() string
+}
+
+type noReauthKey struct{}
+
+// WithoutReauthentication returns a context whose requests report a rejected token
+// instead of re-authenticating through the unauthorized handler.
+func WithoutReauthentication(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noReauthKey{}, true)
+}
+
+// doRequest performs the HTTP request, re-authenticating through the unauthorized
+// handler and retrying once if the API rejects the token.
 func (c *Client) doRequest(ctx context.Context, method, path string, params interface{}, body interface{}, target interface{}) error {
+	c.mu.RLock()
+	handler := c.unauthorizedHandler
+	c.mu.RUnlock()
+	if ctx.Value(noReauthKey{}) != nil {
+		handler = nil
+	}
+
+	token, err := c.doWithRetry(ctx, method, path, params, body, target)
+	if handler == nil || path == "/login" || path == "/logout" || !errors.Is(err, apierrors.ErrUnauthorized) {
+		return err
+	}
+	if authErr := handler(ctx, token); authErr != nil {
+		return fmt.Errorf("%w (re-authentication failed: %v)", err, authErr)
+	}
+	_, err = c.doWithRetry(ctx, method, path, params, body, target)
+	return err
+}
+
+// rateLimitedError is returned for 429 responses and carries the headers with the reset hint.
+type rateLimitedError struct {
+	err    error
+	header http.Header
+}
+
+func (e *rateLimitedError) Error() string { return e.err.Error() }
+func (e *rateLimitedError) Unwrap() error { return e.err }
+
+// doWithRetry waits for the endpoint's rate limit before each attempt and retries
+// 429 responses, and 502-504 responses the endpoint's IdempotencyPolicy allows, with
+// exponential backoff, honouring the server's reset hints.
+func (c *Client) doWithRetry(ctx context.Context, method, path string, params interface{}, body interface{}, target interface{}) (string, error) {
+	c.mu.RLock()
+	limiter := c.limiter
+	c.mu.RUnlock()
+
+	// Fresh cached responses are served without spending rate limit tokens.
+	lookup, err := c.lookupCache(ctx, method, path, params)
+	if err != nil {
+		return "", err
+	}
+	if lookup.fresh(time.Now()) {
+		return "", decodeResponse(lookup.entry.Body, target)
+	}
+
+	retried := new(bool) // Set by the Retry middleware
+	ctx = context.WithValue(ctx, retriedKey{}, retried)
+	ctx = context.WithValue(ctx, replayCheckKey{}, replayCheck(func(ctx context.Context) bool {
+		return c.mayRetry(ctx, method, path)
+	}))
+	for attempt := 0; ; attempt++ {
+		if err := limiter.wait(ctx, path); err != nil {
+			return "", err
+		}
+		token, err := c.doRequestOnce(ctx, method, path, params, body, target, lookup)
+		var limited *rateLimitedError
+		var unavailable *unavailableError
+		var header http.Header
+		switch {
+		case attempt >= limiter.limits.MaxRetries:
+			return token, err
+		case errors.As(err, &limited):
+			header = limited.header
+		case errors.As(err, &unavailable) && !*retried && c.mayRetry(ctx, method, path):
+			header = unavailable.header
+		default:
+			return token, err
+		}
+		if err := sleepCtx(ctx, limiter.backoff(attempt, header)); err != nil {
+			return token, err
+		}
+	}
+}
+
+// lookupCache returns the cache key and entry for a cacheable GET request, or nil.
+func (c *Client) lookupCache(ctx context.Context, method, path string, params interface{}) (*cacheLookup, error) {
+	c.mu.RLock()
+	cache := c.cache
+	c.mu.RUnlock()
+	if cache == nil || method != http.MethodGet || !cache.paths[path] {
+		return nil, nil
+	}
+	rawQuery, err := encodeQuery(params)
+	if err != nil {
+		return nil, err
+	}
+	lookup := &cacheLookup{cache: cache, key: path + "?" + rawQuery}
+	lookup.entry, _ = cache.store.Get(ctx, lookup.key)
+	return lookup, nil
+}
+
+// encodeQuery encodes request parameters, preferring the type's own QueryEncoder.
+func encodeQuery(params interface{}) (string, error) {
+	if enc, ok := params.(QueryEncoder); ok {
+		return enc.EncodeQuery(), nil
+	}
+	if params == nil {
+		return "", nil
+	}
+	v, err := query.Values(params)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode query parameters: %w", err)
+	}
+	// TODO: Add logic to sort query parameters alphabetically and lowercase keys?
+	// This is tricky with go-querystring directly. May need custom encoding or reflection.
+	// For now, encode as is.
+	return v.Encode(), nil
+}
+
+func decodeResponse(data []byte, target interface{}) error {
+	if target == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("failed to unmarshal response body: %w", err)
+	}
+	return nil
+}
+
+// doRequestOnce performs a single HTTP request and returns the token it was sent with.
+// lookup, if set, makes the request conditional on the cached entry and caches the result.
+func (c *Client) doRequestOnce(ctx context.Context, method, path string, params interface{}, body interface{}, target interface{}, lookup *cacheLookup) (string, error) {
 	c.mu.RLock()
 	currentBaseURL := c.baseURL
 	currentToken := c.authToken
+	maxResponseBytes := c.maxResponseBytes
+	logger := c.logger
+	observer := c.observer
+	responseHook := c.responseHook
+	limiter := c.limiter
+	timeout := c.timeouts.forPath(path)
 	c.mu.RUnlock()
+	sentToken := ""
+	if currentToken != nil {
+		sentToken = *currentToken
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
 
 	fullURL, err := url.Parse(currentBaseURL)
 	if err != nil {
-		return fmt.Errorf("invalid base URL: %w", err)
+		return sentToken, fmt.Errorf("invalid base URL: %w", err)
 	}
 	fullURL.Path += path // Assumes baseURL doesn't end with / and path starts with /
 
 	// Encode query parameters if provided
-	if params != nil {
-		v, err := query.Values(params)
-		if err != nil {
-			return fmt.Errorf("failed to encode query parameters: %w", err)
-		}
-		// TODO: Add logic to sort query parameters alphabetically and lowercase keys?
-		// This is tricky with go-querystring directly. May need custom encoding or reflection.
-		// For now, encode as is.
-		fullURL.RawQuery = v.Encode()
+	if fullURL.RawQuery, err = encodeQuery(params); err != nil {
+		return sentToken, err
 	}
 
 	// Encode request body if provided
-	var reqBody io.Reader
-	var contentType string
-	if body != nil {
-		jsonData, err := json.Marshal(body)
+	var payload []byte
+	contentType := "application/json"
+	if raw, ok := body.(RawBody); ok {
+		payload, contentType = raw.Data, raw.ContentType
+	} else if body != nil {
+		payload, err = json.Marshal(body)
 		if err != nil {
-			return fmt.Errorf("failed to marshal request body: %w", err)
+			return sentToken, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonData)
-		contentType = "application/json"
-	}
-
-	req, err := http.NewRequestWithContext(ctx, method, fullURL.String(), reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// Set headers
-	req.Header.Set("Api-Key", c.apiKey)
-	req.Header.Set("User-Agent", c.userAgent)
-	req.Header.Set("Accept", "application/json")
-	if contentType != "" {
-		req.Header.Set("Content-Type", contentType)
+	// Make the request, re-issuing method-changing redirects ourselves (see checkRedirect)
+	requestURL := fullURL.String()
+	var resp *http.Response
+	for hop := 0; ; hop++ {
+		req, err := c.newRequest(ctx, method, requestURL, payload, contentType, currentToken)
+		if err != nil {
+			return sentToken, err
+		}
+		lookup.setConditionalHeaders(req)
+		start := time.Now()
+		resp, err = c.httpClient.Do(req)
+		err = withoutQuery(err)
+		latency := time.Since(start)
+		logRequest(ctx, logger, req, resp, err, latency)
+		if observer != nil {
+			status := 0
+			if resp != nil {
+				status = resp.StatusCode
+			}
+			observer(method, path, status, latency)
+		}
+		if err != nil {
+			return sentToken, fmt.Errorf("failed to execute request: %w", err)
+		}
+		location, isRedirect := redirectLocation(resp)
+		if !isRedirect {
+			break
+		}
+		resp.Body.Close()
+		if hop+1 >= maxRedirects {
+			return sentToken, fmt.Errorf("failed to execute request: stopped after %d redirects", maxRedirects)
+		}
+		next, err := req.URL.Parse(location)
+		if err != nil {
+			return sentToken, fmt.Errorf("invalid redirect location '%s': %w", location, err)
+		}
+		if next.Host != req.URL.Host {
+			if !sameSite(req.URL, next) {
+				return sentToken, fmt.Errorf("failed to execute request: refusing redirect from %s to untrusted host %s", req.URL.Host, next.Host)
+			}
+			c.notifyHostChange(req.URL.Host, next.Host)
+		}
+		requestURL = next.String()
 	}
-
-	// Add Authorization header if token exists
-	if currentToken != nil && *currentToken != "" {
-		req.Header.Set("Authorization", "Bearer "+*currentToken)
+	defer resp.Body.Close()
+	limiter.learn(path, resp.Header, time.Now())
+	if responseHook != nil {
+		responseHook(ctx, method, path, resp.StatusCode, resp.Header)
 	}
 
-	// Make the request
-	resp, err := c.httpClient.Do(req)
+	// Read response body. net/http transparently decompresses gzip responses, so the
+	// limit applies to the decompressed size and also guards against decompression bombs.
+	respBodyBytes, err := io.ReadAll(LimitReader(resp.Body, maxResponseBytes))
 	if err != nil {
-		return fmt.Errorf("failed to execute request: %w", err)
+		return sentToken, fmt.Errorf("failed to read response body: %w", err)
 	}
-	defer resp.Body.Close()
 
-	// Read response body
-	respBodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+	if resp.StatusCode == http.StatusNotModified && lookup != nil && lookup.entry != nil {
+		return sentToken, decodeResponse(lookup.revalidated(ctx), target)
 	}
 
-	// Check status code
-	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
-		// Attempt to decode error response? Or just return status + body
-		// Define custom error types? e.g., APIError
-		return fmt.Errorf("api request failed: status %d, body: %s", resp.StatusCode, string(respBodyBytes))
-		// Consider creating structured APIError type here
-		// var apiErr APIError
-		// if json.Unmarshal(respBodyBytes, &apiErr) == nil {
-		//    apiErr.StatusCode = resp.StatusCode
-		//    return apiErr
-		// } else { ... fallback ...}
+	// Check status code. HTML pages from proxies or maintenance windows are reported
+	// as errors instead of failing to decode.
+	respType := resp.Header.Get("Content-Type")
+	isJSON := looksLikeJSON(respType, respBodyBytes)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 || (!isJSON && target != nil) {
+		var apiErr *apierrors.APIError
+		if isJSON {
+			apiErr = apierrors.NewAPIError(resp.StatusCode, respBodyBytes, sentToken != "")
+		} else {
+			apiErr = apierrors.NewNonJSONError(resp.StatusCode, respType, respBodyBytes, sentToken != "")
+		}
+		apiErr.RetryAfter, _ = retryAfter(resp.Header, time.Now())
+		if resp.StatusCode == http.StatusTooManyRequests {
+			return sentToken, &rateLimitedError{err: apiErr, header: resp.Header}
+		}
+		if retryableStatus(resp.StatusCode) {
+			return sentToken, &unavailableError{err: apiErr, header: resp.Header}
+		}
+		return sentToken, apiErr
 	}
 
 	// Decode successful response if target is provided
-	if target != nil {
-		if err := json.Unmarshal(respBodyBytes, target); err != nil {
-			return fmt.Errorf("failed to unmarshal response body: %w", err)
-		}
+	if err := decodeResponse(respBodyBytes, target); err != nil {
+		return sentToken, err
 	}
+	lookup.store(ctx, resp.Header, respBodyBytes)
+	return sentToken, nil
+}
 
-	return nil
+// looksLikeJSON reports whether a response body can be decoded as JSON, judging by
+// its content type and, for generic types, its first character. Empty bodies count
+// as JSON so that their handling does not change.
+func looksLikeJSON(contentType string, body []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return true
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return false
+	}
+	trimmed := bytes.TrimLeft(body, " \t\r\n")
+	return len(trimmed) == 0 || trimmed[0] != '<'
 }
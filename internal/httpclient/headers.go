@@ -0,0 +1,72 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// RequestIDHeader is the header conventionally carrying a request ID for tracing.
+const RequestIDHeader = "X-Request-ID"
+
+// reservedHeaders are set by the client and never replaced by custom headers.
+var reservedHeaders = map[string]bool{
+	"Api-Key":        true,
+	"Authorization":  true,
+	"Content-Type":   true,
+	"Content-Length": true,
+	"Host":           true,
+	"User-Agent":     true,
+}
+
+type headersKey struct{}
+
+// WithHeaders returns a context whose requests carry header in addition to the
+// headers of ctx, replacing values of the same names.
+func WithHeaders(ctx context.Context, header http.Header) context.Context {
+	merged := HeadersFrom(ctx).Clone()
+	if merged == nil {
+		merged = make(http.Header, len(header))
+	}
+	for name, values := range header {
+		merged[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+	return context.WithValue(ctx, headersKey{}, merged)
+}
+
+// HeadersFrom returns the headers added to ctx by WithHeaders, or nil. The result
+// must not be modified.
+func HeadersFrom(ctx context.Context) http.Header {
+	header, _ := ctx.Value(headersKey{}).(http.Header)
+	return header
+}
+
+// SetHeaders sets headers sent with every request, API calls and file downloads;
+// headers of the request context take precedence.
+func (c *Client) SetHeaders(header http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers = header.Clone()
+}
+
+// applyHeaders adds the client's headers and those of the request context to req,
+// leaving reservedHeaders alone.
+func (c *Client) applyHeaders(req *http.Request) {
+	c.mu.RLock()
+	defaults := c.headers
+	c.mu.RUnlock()
+	AddHeaders(req.Header, defaults)
+	AddHeaders(req.Header, HeadersFrom(req.Context()))
+}
+
+// AddHeaders copies the values of src into dst, replacing values of the same names
+// and skipping headers the client sets itself, such as Api-Key, Authorization and
+// User-Agent.
+func AddHeaders(dst, src http.Header) {
+	for name, values := range src {
+		name = http.CanonicalHeaderKey(name)
+		if reservedHeaders[name] {
+			continue
+		}
+		dst[name] = append([]string(nil), values...)
+	}
+}
@@ -0,0 +1,79 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+)
+
+// IdempotencyPolicy decides whether requests to an endpoint are retried after a 502,
+// 503 or 504 response. Retries share RateLimits.MaxRetries and its backoff with 429
+// retries. Endpoints without a policy are retried for GET requests only.
+type IdempotencyPolicy struct {
+	Retry bool // Retry the endpoint's requests; false never retries them
+	// Check, if set, is called before each retry and must confirm that the failed
+	// attempt had no effect, e.g. did not count against the download quota. The
+	// request is not retried if it returns false or an error.
+	Check func(ctx context.Context) (bool, error)
+}
+
+// unavailableError is returned for 502, 503 and 504 responses and carries the
+// headers with the Retry-After hint.
+type unavailableError struct {
+	err    error
+	header http.Header
+}
+
+func (e *unavailableError) Error() string { return e.err.Error() }
+func (e *unavailableError) Unwrap() error { return e.err }
+
+// retriedKey holds a *bool in the request context that the Retry middleware sets
+// when it retried the request, so that the client does not retry it again.
+type retriedKey struct{}
+
+// markRetried records in ctx that a middleware retried the request.
+func markRetried(ctx context.Context) {
+	if retried, ok := ctx.Value(retriedKey{}).(*bool); ok {
+		*retried = true
+	}
+}
+
+// retryableStatus reports whether status is a gateway or availability error the
+// request may succeed after.
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// SetIdempotencyPolicies sets the retry policies for 502, 503 and 504 responses by
+// endpoint path, e.g. "/download". Nil restores the default of retrying GET requests.
+func (c *Client) SetIdempotencyPolicies(policies map[string]IdempotencyPolicy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.idempotency = policies
+}
+
+// mayRetry reports whether a request that failed with 502, 503 or 504 may be sent again.
+func (c *Client) mayRetry(ctx context.Context, method, path string) bool {
+	c.mu.RLock()
+	policy, ok := c.idempotency[path]
+	logger := c.logger
+	c.mu.RUnlock()
+	if !ok {
+		return method == http.MethodGet
+	}
+	if !policy.Retry {
+		return false
+	}
+	if policy.Check == nil {
+		return true
+	}
+	confirmed, err := policy.Check(ctx)
+	if err != nil {
+		logger.Warn("opensubtitles: idempotency check failed; not retrying", "method", method, "path", path, "error", err)
+		return false
+	}
+	return confirmed
+}
@@ -0,0 +1,144 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// RoundTripperFunc is a request middleware. It receives each outgoing request together
+// with the next stage of the chain and returns the response, usually by calling
+// next.RoundTrip. Like an http.RoundTripper it must not modify req; use req.Clone
+// to send a changed copy.
+type RoundTripperFunc func(req *http.Request, next http.RoundTripper) (*http.Response, error)
+
+// roundTripper adapts a plain function to http.RoundTripper.
+type roundTripper func(*http.Request) (*http.Response, error)
+
+func (f roundTripper) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// Chain wraps base in the middlewares. The first middleware is the outermost and
+// sees each request first. A nil base uses http.DefaultTransport.
+func Chain(base http.RoundTripper, middlewares ...RoundTripperFunc) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		mw, next := middlewares[i], base
+		if mw == nil {
+			continue
+		}
+		base = roundTripper(func(req *http.Request) (*http.Response, error) {
+			return mw(req, next)
+		})
+	}
+	return base
+}
+
+// RetryPolicy configures the Retry middleware.
+type RetryPolicy struct {
+	MaxRetries  int           // Retries after the first attempt
+	BaseBackoff time.Duration // Wait before the first retry, doubled for each further one
+	MaxBackoff  time.Duration // Upper bound for any single wait
+	// Retryable decides whether an attempt should be retried. Nil uses RetryableResponse.
+	Retryable func(resp *http.Response, err error) bool
+}
+
+// DefaultRetryPolicy retries up to 3 times, backing off from 500ms up to 10s.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:  3,
+	BaseBackoff: 500 * time.Millisecond,
+	MaxBackoff:  10 * time.Second,
+}
+
+// RetryableResponse reports whether an attempt failed with a network error or a
+// 5xx status other than 501 Not Implemented. 429 responses are left to the rate limiter.
+func RetryableResponse(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented
+}
+
+// Retry returns a middleware that retries network errors and 5xx responses with
+// capped exponential backoff, honouring Retry-After on the response. Waits end early
+// when the request's context is cancelled, and attempts failing because of it are not
+// retried. Requests whose body cannot be replayed (no GetBody) are sent only once.
+//
+// Only requests the server may safely see twice are retried: GET and HEAD requests,
+// and requests that failed before they were sent. API requests of the Client follow
+// the endpoint's IdempotencyPolicy instead, so POST /download and POST /upload are not
+// replayed unless a policy allows it.
+func Retry(policy RetryPolicy) RoundTripperFunc {
+	retryable := policy.Retryable
+	if retryable == nil {
+		retryable = RetryableResponse
+	}
+	return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+		ctx := req.Context()
+		for attempt := 0; ; attempt++ {
+			attemptReq := req
+			if attempt > 0 {
+				attemptReq = req.Clone(ctx)
+				if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+					body, err := req.GetBody()
+					if err != nil {
+						return nil, err
+					}
+					attemptReq.Body = body
+				}
+			}
+			var sent atomic.Bool
+			attemptReq = attemptReq.WithContext(httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+				WroteRequest: func(httptrace.WroteRequestInfo) { sent.Store(true) },
+			}))
+			resp, err := next.RoundTrip(attemptReq)
+			if attempt >= policy.MaxRetries || ctx.Err() != nil || !replayable(req) || !retryable(resp, err) ||
+				!idempotent(req, err != nil && !sent.Load()) {
+				return resp, err
+			}
+			markRetried(ctx)
+
+			wait, ok := time.Duration(0), false
+			if resp != nil {
+				wait, ok = retryAfter(resp.Header, time.Now())
+				// Drain a little so the connection can be reused, then discard the response.
+				_, _ = io.CopyN(io.Discard, resp.Body, 4096)
+				resp.Body.Close()
+			}
+			if !ok {
+				wait = exponentialBackoff(policy.BaseBackoff, DefaultRetryPolicy.BaseBackoff, attempt)
+			}
+			if err := sleepCtx(ctx, jitterBackoff(wait, policy.MaxBackoff, DefaultRetryPolicy.MaxBackoff)); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// replayCheckKey holds a replayCheck in the request context of API requests.
+type replayCheckKey struct{}
+
+// replayCheck reports whether the request of the context may be sent again after the
+// server may have processed it, following the endpoint's IdempotencyPolicy.
+type replayCheck func(ctx context.Context) bool
+
+// idempotent reports whether req may be sent again. Requests that never reached the
+// server always may; others only if their endpoint's policy or method allows it.
+func idempotent(req *http.Request, unsent bool) bool {
+	if unsent {
+		return true
+	}
+	if check, ok := req.Context().Value(replayCheckKey{}).(replayCheck); ok {
+		return check(req.Context())
+	}
+	return req.Method == http.MethodGet || req.Method == http.MethodHead
+}
+
+// replayable reports whether req can be sent again.
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
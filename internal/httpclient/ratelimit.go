@@ -0,0 +1,265 @@
+package httpclient
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimits configures client-side throttling and the handling of 429 responses.
+// Rates are in requests per second; a non-positive rate disables that bucket.
+type RateLimits struct {
+	Default  float64 // All endpoints without a dedicated class
+	Login    float64 // POST /login
+	Download float64 // POST /download
+	Burst    int     // Bucket capacity, at least 1
+
+	MaxRetries  int           // Retries after a 429 response, or a 502-504 one (see IdempotencyPolicy)
+	BaseBackoff time.Duration // First backoff when the server gives no reset hint
+	MaxBackoff  time.Duration // Upper bound for any single wait
+}
+
+// DefaultRateLimits follow the documented OpenSubtitles limits
+// (5 requests per second, 1 login per second).
+var DefaultRateLimits = RateLimits{
+	Default:     5,
+	Login:       1,
+	Download:    5,
+	Burst:       5,
+	MaxRetries:  3,
+	BaseBackoff: time.Second,
+	MaxBackoff:  30 * time.Second,
+}
+
+// endpointClass maps a request path to its rate limit bucket.
+func endpointClass(path string) string {
+	switch path {
+	case "/login":
+		return "login"
+	case "/download":
+		return "download"
+	}
+	return "default"
+}
+
+// rateLimiter holds one token bucket per endpoint class.
+type rateLimiter struct {
+	limits  RateLimits
+	buckets map[string]*tokenBucket
+}
+
+func newRateLimiter(limits RateLimits) *rateLimiter {
+	burst := float64(limits.Burst)
+	if burst < 1 {
+		burst = 1
+	}
+	rl := &rateLimiter{limits: limits, buckets: make(map[string]*tokenBucket)}
+	for class, rate := range map[string]float64{"default": limits.Default, "login": limits.Login, "download": limits.Download} {
+		if rate > 0 {
+			b := burst
+			if class == "login" {
+				b = 1 // Logins are never bursted
+			}
+			rl.buckets[class] = &tokenBucket{configured: rate, rate: rate, capacity: b, burst: b, tokens: b}
+		}
+	}
+	return rl
+}
+
+// wait blocks until a request to path may be sent or ctx ends.
+func (rl *rateLimiter) wait(ctx context.Context, path string) error {
+	if rl == nil {
+		return nil
+	}
+	b, ok := rl.buckets[endpointClass(path)]
+	if !ok {
+		return nil
+	}
+	return sleepCtx(ctx, b.reserve(time.Now()))
+}
+
+// learn adjusts the bucket of path to the rate limit headers of a response: the
+// bucket takes the rate of X-RateLimit-Limit-Second, is emptied when
+// X-RateLimit-Remaining-Second is 0 and, when RateLimit-Remaining is 0, holds
+// requests until RateLimit-Reset. Classes without a bucket are not limited.
+func (rl *rateLimiter) learn(path string, header http.Header, now time.Time) {
+	if rl == nil {
+		return
+	}
+	b, ok := rl.buckets[endpointClass(path)]
+	if !ok {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	if limit, ok := headerCount(header, "X-RateLimit-Limit-Second"); ok && limit > 0 {
+		b.rate = float64(limit)
+		b.burst = math.Min(b.capacity, b.rate)
+		b.tokens = math.Min(b.tokens, b.burst)
+		b.learned = true
+	}
+	if remaining, ok := headerCount(header, "X-RateLimit-Remaining-Second"); ok && remaining == 0 {
+		b.tokens = math.Min(b.tokens, 0)
+	}
+	if remaining, ok := headerCount(header, "RateLimit-Remaining"); ok && remaining == 0 {
+		if reset, ok := headerCount(header, "RateLimit-Reset"); ok {
+			// Negative tokens make reserve wait until the window resets.
+			b.tokens = math.Min(b.tokens, -float64(reset)*b.rate)
+		}
+	}
+}
+
+// RateLimitStats is the state of the bucket of one endpoint class.
+type RateLimitStats struct {
+	Configured float64 // Requests per second set by RateLimits
+	Rate       float64 // Requests per second in use, learned from the responses if Learned
+	Learned    bool
+	Burst      int
+	Available  float64 // Tokens left; negative while requests have to wait
+}
+
+// stats returns the state of each bucket by endpoint class ("default", "login" and
+// "download"). Disabled classes are missing.
+func (rl *rateLimiter) stats(now time.Time) map[string]RateLimitStats {
+	stats := make(map[string]RateLimitStats)
+	if rl == nil {
+		return stats
+	}
+	for class, b := range rl.buckets {
+		b.mu.Lock()
+		b.refill(now)
+		stats[class] = RateLimitStats{
+			Configured: b.configured,
+			Rate:       b.rate,
+			Learned:    b.learned,
+			Burst:      int(b.burst),
+			Available:  b.tokens,
+		}
+		b.mu.Unlock()
+	}
+	return stats
+}
+
+// headerCount parses a non-negative integer header.
+func headerCount(header http.Header, name string) (int, bool) {
+	n, err := strconv.Atoi(header.Get(name))
+	return n, err == nil && n >= 0
+}
+
+// backoff returns how long to wait before retry number attempt (starting at 0) after
+// a 429, 502, 503 or 504 response, preferring the server's Retry-After or RateLimit-Reset hint.
+func (rl *rateLimiter) backoff(attempt int, header http.Header) time.Duration {
+	wait, ok := retryAfter(header, time.Now())
+	if !ok {
+		wait = exponentialBackoff(rl.limits.BaseBackoff, DefaultRateLimits.BaseBackoff, attempt)
+	}
+	return jitterBackoff(wait, rl.limits.MaxBackoff, DefaultRateLimits.MaxBackoff)
+}
+
+// exponentialBackoff returns base doubled attempt times; a non-positive base uses fallback.
+// The result saturates at the largest Duration instead of overflowing.
+func exponentialBackoff(base, fallback time.Duration, attempt int) time.Duration {
+	if base <= 0 {
+		base = fallback
+	}
+	wait := float64(base) * math.Pow(2, float64(attempt))
+	if wait >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(wait)
+}
+
+// jitterBackoff adds up to 20% jitter to wait, so concurrent clients do not retry in
+// lockstep, and caps the result at max (or fallbackMax if max is not positive).
+func jitterBackoff(wait, max, fallbackMax time.Duration) time.Duration {
+	if max <= 0 {
+		max = fallbackMax
+	}
+	// Cap before adding the jitter, which would overflow a huge wait.
+	if wait > max {
+		wait = max
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	wait += time.Duration(rand.Int63n(int64(wait)/5 + 1))
+	if wait > max {
+		wait = max
+	}
+	return wait
+}
+
+// retryAfter parses the Retry-After (seconds or HTTP date) or RateLimit-Reset (seconds) header.
+func retryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if v := header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			if d := t.Sub(now); d > 0 {
+				return d, true
+			}
+			return 0, true
+		}
+	}
+	if v := header.Get("RateLimit-Reset"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+			return time.Duration(secs) * time.Second, true
+		}
+	}
+	return 0, false
+}
+
+// tokenBucket is a minimal token bucket; reserve takes a token and reports how long
+// the caller must wait for it.
+type tokenBucket struct {
+	mu         sync.Mutex
+	configured float64 // Rate set by RateLimits
+	rate       float64 // Tokens per second
+	learned    bool    // rate comes from the response headers
+	capacity   float64 // Burst set by RateLimits
+	burst      float64
+	tokens     float64
+	last       time.Time
+}
+
+func (b *tokenBucket) reserve(now time.Time) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refill(now)
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// refill adds the tokens accrued since the last call; b.mu must be held.
+func (b *tokenBucket) refill(now time.Time) {
+	if !b.last.IsZero() {
+		if !now.After(b.last) {
+			return
+		}
+		b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	}
+	b.last = now
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
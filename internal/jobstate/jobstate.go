@@ -0,0 +1,143 @@
+// Package jobstate provides what the batch and downloadmanager packages share: item
+// statuses, waiting out an exhausted download quota and saving progress to a JSON file
+// that an interrupted run resumes from.
+package jobstate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// DefaultMaxAttempts is how often a failing item is tried.
+const DefaultMaxAttempts = 3
+
+// DefaultQuotaWait is how long to pause when the quota is exhausted and the API did
+// not report when it resets.
+const DefaultQuotaWait = time.Hour
+
+// Status is the state of an item.
+type Status string
+
+const (
+	StatusPending Status = "pending"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed" // Gave up after the maximum number of attempts
+)
+
+// NextPending returns the index of the first item whose status is StatusPending.
+func NextPending[T any](items []T, status func(T) Status) (int, bool) {
+	for i, item := range items {
+		if status(item) == StatusPending {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// QuotaExhausted reports whether no downloads are left and, if so, until when.
+// remaining is -1 if unknown. An unknown reset time is set to DefaultQuotaWait from
+// now; once the reset time has passed, remaining becomes unknown again, as the next
+// download reports the new quota.
+func QuotaExhausted(remaining *int, resetTime *time.Time, now time.Time) (time.Time, bool) {
+	if *remaining != 0 {
+		return time.Time{}, false
+	}
+	if resetTime.IsZero() {
+		*resetTime = now.Add(DefaultQuotaWait)
+	}
+	if !now.Before(*resetTime) {
+		*remaining = -1
+		return time.Time{}, false
+	}
+	return *resetTime, true
+}
+
+// QuotaResetTime reads reset_time_utc from the body of a quota error, if present.
+func QuotaResetTime(err error) time.Time {
+	var apiErr *opensubtitles.APIError
+	if !errors.As(err, &apiErr) {
+		return time.Time{}
+	}
+	var body struct {
+		ResetTimeUTC time.Time `json:"reset_time_utc"`
+	}
+	if json.Unmarshal([]byte(apiErr.Body), &body) != nil {
+		return time.Time{}
+	}
+	return body.ResetTimeUTC
+}
+
+// Sleep waits for d or until ctx ends.
+func Sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// File is a JSON file holding the progress of a run. Kind names it in errors, e.g.
+// "state".
+type File struct {
+	FS   vfs.FS
+	Path string // Empty keeps the progress in memory only
+	Kind string
+}
+
+// Load decodes the file into v. A missing file leaves v unchanged.
+func (f File) Load(v any) error {
+	if f.Path == "" {
+		return nil
+	}
+	data, err := fs.ReadFile(f.FS, f.Path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed to read %s file '%s': %w", f.Kind, f.Path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to decode %s file '%s': %w", f.Kind, f.Path, err)
+	}
+	return nil
+}
+
+// Save writes v to the file atomically: through a temporary file renamed into place,
+// so a crash never leaves a truncated file behind.
+func (f File) Save(v any) error {
+	if f.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", f.Kind, err)
+	}
+	if dir := filepath.Dir(f.Path); dir != "." {
+		if err := f.FS.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create directory for %s file '%s': %w", f.Kind, f.Path, err)
+		}
+	}
+	tmp := f.Path + ".tmp"
+	if err := vfs.WriteFile(f.FS, tmp, data); err != nil {
+		return fmt.Errorf("failed to write %s file '%s': %w", f.Kind, tmp, err)
+	}
+	if err := f.FS.Rename(tmp, f.Path); err != nil {
+		_ = f.FS.Remove(tmp)
+		return fmt.Errorf("failed to move %s file '%s' into place: %w", f.Kind, f.Path, err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package jobstate
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaExhausted(t *testing.T) {
+	now := time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC)
+
+	remaining, reset := 3, time.Time{}
+	_, exhausted := QuotaExhausted(&remaining, &reset, now)
+	assert.False(t, exhausted)
+
+	remaining = 0
+	until, exhausted := QuotaExhausted(&remaining, &reset, now)
+	assert.True(t, exhausted)
+	assert.Equal(t, now.Add(DefaultQuotaWait), until, "an unknown reset time waits DefaultQuotaWait")
+
+	_, exhausted = QuotaExhausted(&remaining, &reset, until)
+	assert.False(t, exhausted)
+	assert.Equal(t, -1, remaining, "the quota is unknown after the reset")
+}
+
+func TestQuotaResetTime(t *testing.T) {
+	err := fmt.Errorf("download: %w", &opensubtitles.APIError{StatusCode: 406, Body: `{"reset_time_utc":"2024-05-02T00:00:00Z"}`})
+	assert.Equal(t, time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC), QuotaResetTime(err))
+	assert.True(t, QuotaResetTime(opensubtitles.ErrQuotaExceeded).IsZero())
+}
+
+func TestSleep(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	assert.NoError(t, Sleep(ctx, 0))
+	cancel()
+	assert.ErrorIs(t, Sleep(ctx, time.Hour), context.Canceled)
+}
+
+func TestFile(t *testing.T) {
+	fsys := vfs.NewMemFS()
+	file := File{FS: fsys, Path: "state/queue.json", Kind: "state"}
+	type state struct{ Items []string }
+
+	got := state{Items: []string{"unchanged"}}
+	require.NoError(t, file.Load(&got), "a missing file is not an error")
+	assert.Equal(t, []string{"unchanged"}, got.Items)
+
+	require.NoError(t, file.Save(state{Items: []string{"a", "b"}}))
+	require.NoError(t, file.Load(&got))
+	assert.Equal(t, []string{"a", "b"}, got.Items)
+	_, err := fs.Stat(fsys, "state/queue.json.tmp")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "the temporary file is renamed into place")
+
+	require.NoError(t, vfs.WriteFile(fsys, file.Path, []byte("{")))
+	assert.ErrorContains(t, file.Load(&got), "failed to decode state file 'state/queue.json'")
+
+	assert.NoError(t, File{Kind: "state"}.Save(got), "no path keeps the state in memory")
+}
@@ -0,0 +1,20 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrDiscard(t *testing.T) {
+	assert.Same(t, Discard(), OrDiscard(nil))
+	assert.False(t, OrDiscard(nil).Enabled(context.Background(), slog.LevelError))
+	OrDiscard(nil).With("k", "v").WithGroup("g").Error("dropped")
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	assert.Same(t, logger, OrDiscard(logger))
+}
@@ -0,0 +1,135 @@
+package opensubtitles
+
+import (
+	"context"
+	"time"
+)
+
+// SearchIterOptions configures SearchSubtitlesIter.
+type SearchIterOptions struct {
+	// MaxPages stops the iteration after this many pages. Zero means all pages.
+	MaxPages int
+	// PageDelay is waited before fetching each page after the first.
+	PageDelay time.Duration
+	// Cache, if set, serves and stores pages like SearchSubtitlesCached.
+	Cache *SearchPageCache
+}
+
+// SubtitleIterator walks every subtitle of a paginated search. Use it like bufio.Scanner:
+//
+//	it := client.SearchSubtitlesIter(ctx, params, opensubtitles.SearchIterOptions{})
+//	for it.Next() {
+//		sub := it.Subtitle()
+//		// ...
+//	}
+//	if err := it.Err(); err != nil {
+//		// ...
+//	}
+//
+// A SubtitleIterator is not safe for concurrent use.
+type SubtitleIterator struct {
+	client *Client
+	ctx    context.Context
+	params SearchSubtitlesParams
+	opts   SearchIterOptions
+
+	page       int // Page currently held in items
+	fetched    int // Number of pages fetched so far
+	totalPages int
+	totalCount int
+	items      []Subtitle
+	index      int
+	current    Subtitle
+	done       bool
+	err        error
+}
+
+// SearchSubtitlesIter returns an iterator over all pages of the search described by
+// params, starting at params.Page (default 1). Pages are fetched lazily as Next advances.
+func (c *Client) SearchSubtitlesIter(ctx context.Context, params SearchSubtitlesParams, opts SearchIterOptions) *SubtitleIterator {
+	start := 1
+	if params.Page != nil && *params.Page > 0 {
+		start = *params.Page
+	}
+	return &SubtitleIterator{
+		client: c,
+		ctx:    ctx,
+		params: params,
+		opts:   opts,
+		page:   start - 1,
+	}
+}
+
+// Next advances to the next subtitle, fetching the next page when needed.
+// It returns false when the results are exhausted or an error occurred.
+func (it *SubtitleIterator) Next() bool {
+	for it.index >= len(it.items) {
+		if it.done || !it.fetchNext() {
+			return false
+		}
+	}
+	it.current = it.items[it.index]
+	it.index++
+	return true
+}
+
+// fetchNext loads the following page and reports whether iteration can continue.
+func (it *SubtitleIterator) fetchNext() bool {
+	if it.opts.MaxPages > 0 && it.fetched >= it.opts.MaxPages {
+		it.done = true
+		return false
+	}
+	if it.fetched > 0 && it.page >= it.totalPages {
+		it.done = true
+		return false
+	}
+	if it.fetched > 0 && it.opts.PageDelay > 0 {
+		timer := time.NewTimer(it.opts.PageDelay)
+		select {
+		case <-timer.C:
+		case <-it.ctx.Done():
+			timer.Stop()
+			it.err = it.ctx.Err()
+			return false
+		}
+	}
+
+	page := it.page + 1
+	params := it.params
+	params.Page = &page
+	resp, err := it.client.SearchSubtitlesCached(it.ctx, it.opts.Cache, params)
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.page = page
+	it.fetched++
+	it.totalPages = resp.TotalPages
+	it.totalCount = resp.TotalCount
+	it.items = resp.Data
+	it.index = 0
+	if len(resp.Data) == 0 {
+		it.done = true
+	}
+	return true
+}
+
+// Subtitle returns the subtitle the last call to Next advanced to.
+func (it *SubtitleIterator) Subtitle() Subtitle {
+	return it.current
+}
+
+// Page returns the page number of the current subtitle.
+func (it *SubtitleIterator) Page() int {
+	return it.page
+}
+
+// TotalCount returns the total number of results reported by the API, once the first page is fetched.
+func (it *SubtitleIterator) TotalCount() int {
+	return it.totalCount
+}
+
+// Err returns the first error encountered while fetching pages.
+func (it *SubtitleIterator) Err() error {
+	return it.err
+}
@@ -0,0 +1,102 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedSearchHandler serves totalPages pages of perPage subtitles with IDs "<page>-<n>".
+func pagedSearchHandler(t *testing.T, totalPages, perPage int, requests *int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		assert.Equal(t, "/api/v1/subtitles", r.URL.Path)
+		assert.Equal(t, "en", r.URL.Query().Get("languages"))
+		page, err := strconv.Atoi(r.URL.Query().Get("page"))
+		require.NoError(t, err)
+
+		resp := SearchSubtitlesResponse{PaginatedResponse: PaginatedResponse{
+			TotalPages: totalPages, TotalCount: totalPages * perPage, PerPage: perPage, Page: page,
+		}}
+		if page <= totalPages {
+			for n := 0; n < perPage; n++ {
+				resp.Data = append(resp.Data, Subtitle{ApiDataWrapper: ApiDataWrapper{ID: strconv.Itoa(page) + "-" + strconv.Itoa(n)}})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
+
+func collectIDs(it *SubtitleIterator) []string {
+	var ids []string
+	for it.Next() {
+		ids = append(ids, it.Subtitle().ID)
+	}
+	return ids
+}
+
+func TestSearchSubtitlesIter(t *testing.T) {
+	params := SearchSubtitlesParams{Languages: String("en")}
+
+	t.Run("WalksAllPages", func(t *testing.T) {
+		var requests int32
+		_, client := setupTestServer(t, pagedSearchHandler(t, 3, 2, &requests))
+		it := client.SearchSubtitlesIter(context.Background(), params, SearchIterOptions{})
+		assert.Equal(t, []string{"1-0", "1-1", "2-0", "2-1", "3-0", "3-1"}, collectIDs(it))
+		require.NoError(t, it.Err())
+		assert.Equal(t, int32(3), atomic.LoadInt32(&requests), "no request past the last page")
+		assert.Equal(t, 6, it.TotalCount())
+		assert.Equal(t, 3, it.Page())
+	})
+
+	t.Run("StartPageAndMaxPages", func(t *testing.T) {
+		var requests int32
+		_, client := setupTestServer(t, pagedSearchHandler(t, 5, 1, &requests))
+		start := params
+		page := 2
+		start.Page = &page
+		it := client.SearchSubtitlesIter(context.Background(), start, SearchIterOptions{MaxPages: 2})
+		assert.Equal(t, []string{"2-0", "3-0"}, collectIDs(it))
+		require.NoError(t, it.Err())
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("PageDelayAndCancellation", func(t *testing.T) {
+		var requests int32
+		_, client := setupTestServer(t, pagedSearchHandler(t, 3, 1, &requests))
+		ctx, cancel := context.WithCancel(context.Background())
+		it := client.SearchSubtitlesIter(ctx, params, SearchIterOptions{PageDelay: time.Hour})
+		require.True(t, it.Next())
+		cancel()
+		assert.False(t, it.Next())
+		assert.ErrorIs(t, it.Err(), context.Canceled)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+	})
+
+	t.Run("ServerError", func(t *testing.T) {
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		})
+		it := client.SearchSubtitlesIter(context.Background(), params, SearchIterOptions{})
+		assert.False(t, it.Next())
+		assert.Error(t, it.Err())
+	})
+
+	t.Run("UsesCache", func(t *testing.T) {
+		var requests int32
+		_, client := setupTestServer(t, pagedSearchHandler(t, 2, 1, &requests))
+		cache := NewSearchPageCache(0)
+		for i := 0; i < 2; i++ {
+			it := client.SearchSubtitlesIter(context.Background(), params, SearchIterOptions{Cache: cache})
+			assert.Len(t, collectIDs(it), 2)
+		}
+		assert.Equal(t, int32(2), atomic.LoadInt32(&requests))
+	})
+}
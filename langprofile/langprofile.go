@@ -0,0 +1,164 @@
+// Package langprofile infers which subtitle languages a user actually keeps, by looking
+// at the sidecar subtitle files already present in their library, and proposes a default
+// language preference profile from it.
+package langprofile
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultMinShare is the share a language must reach to be proposed in a Profile.
+const DefaultMinShare = 0.05
+
+// subtitleExtensions lists the sidecar extensions considered by InferFromFS.
+var subtitleExtensions = map[string]bool{
+	".srt": true, ".sub": true, ".ssa": true, ".ass": true, ".vtt": true, ".smi": true,
+}
+
+// variantTokens are filename tokens that may follow the language code, e.g. "Movie.en.forced.srt".
+var variantTokens = map[string]bool{
+	"forced": true, "hi": true, "sdh": true, "cc": true, "default": true,
+}
+
+// releaseTokens are short release-name tokens that would otherwise look like language codes.
+var releaseTokens = map[string]bool{
+	"dvd": true, "web": true, "hdr": true, "avc": true, "aac": true, "dts": true,
+	"rip": true, "cam": true, "bd": true, "uhd": true, "sd": true, "hd": true,
+}
+
+// Share is the number and fraction of subtitle files in one language.
+type Share struct {
+	Language string
+	Count    int
+	Fraction float64
+}
+
+// Profile is an inferred language preference profile.
+type Profile struct {
+	Shares    []Share  // All observed languages, most common first
+	Preferred []string // Languages at or above the minimum share, most common first
+	Total     int      // Number of observations with a recognised language
+}
+
+// IsSubtitleFile reports whether name has a known subtitle file extension.
+func IsSubtitleFile(name string) bool {
+	return subtitleExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// LanguageFromFilename extracts the language code from a sidecar subtitle name such as
+// "Movie (2010).el.srt" or "Show.S01E01.pt-BR.forced.srt". The code is returned lowercased,
+// with any region kept ("pt-br"). ok is false if no language token is found.
+func LanguageFromFilename(name string) (lang string, ok bool) {
+	base := path.Base(strings.ReplaceAll(name, "\\", "/"))
+	tokens := strings.Split(strings.TrimSuffix(base, path.Ext(base)), ".")
+	// Walk backwards past variant tokens; the first token before them must look like a code.
+	for i := len(tokens) - 1; i > 0; i-- {
+		token := strings.ToLower(tokens[i])
+		if variantTokens[token] {
+			continue
+		}
+		if isLanguageToken(token) {
+			return token, true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// isLanguageToken accepts ISO 639-1/639-2 style codes with an optional region: "en", "ell", "pt-br".
+func isLanguageToken(token string) bool {
+	if releaseTokens[token] {
+		return false
+	}
+	code, region, hasRegion := strings.Cut(token, "-")
+	if len(code) < 2 || len(code) > 3 || !isLetters(code) {
+		return false
+	}
+	if hasRegion && (len(region) < 2 || len(region) > 4 || !isLetters(region)) {
+		return false
+	}
+	return true
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Infer builds a Profile from language observations (one entry per subtitle file).
+// Empty entries are ignored. minShare <= 0 uses DefaultMinShare.
+func Infer(languages []string, minShare float64) Profile {
+	if minShare <= 0 {
+		minShare = DefaultMinShare
+	}
+	counts := make(map[string]int)
+	total := 0
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" {
+			continue
+		}
+		counts[lang]++
+		total++
+	}
+
+	profile := Profile{Total: total}
+	for lang, count := range counts {
+		profile.Shares = append(profile.Shares, Share{
+			Language: lang,
+			Count:    count,
+			Fraction: float64(count) / float64(total),
+		})
+	}
+	sort.Slice(profile.Shares, func(i, j int) bool {
+		if profile.Shares[i].Count != profile.Shares[j].Count {
+			return profile.Shares[i].Count > profile.Shares[j].Count
+		}
+		return profile.Shares[i].Language < profile.Shares[j].Language
+	})
+	for _, share := range profile.Shares {
+		if share.Fraction >= minShare {
+			profile.Preferred = append(profile.Preferred, share.Language)
+		}
+	}
+	return profile
+}
+
+// InferFromFS walks root in fsys, collects the language of every sidecar subtitle file
+// and returns the inferred Profile. Files without a recognisable language are skipped.
+func InferFromFS(fsys fs.FS, root string, minShare float64) (Profile, error) {
+	var languages []string
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !IsSubtitleFile(p) {
+			return nil
+		}
+		if lang, ok := LanguageFromFilename(p); ok {
+			languages = append(languages, lang)
+		}
+		return nil
+	})
+	if err != nil {
+		return Profile{}, fmt.Errorf("langprofile: failed to walk '%s': %w", root, err)
+	}
+	return Infer(languages, minShare), nil
+}
+
+// String renders the profile as e.g. "el 90%, en 10%".
+func (p Profile) String() string {
+	parts := make([]string, len(p.Shares))
+	for i, share := range p.Shares {
+		parts[i] = fmt.Sprintf("%s %.0f%%", share.Language, share.Fraction*100)
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,69 @@
+package langprofile
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLanguageFromFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		lang string
+		ok   bool
+	}{
+		{"Movie (2010).el.srt", "el", true},
+		{"Movie.2010.1080p.ENG.srt", "eng", true},
+		{"Show.S01E01.pt-BR.forced.srt", "pt-br", true},
+		{"Show.S01E01.en.sdh.srt", "en", true},
+		{`C:\media\Movie.fr.srt`, "fr", true},
+		{"Movie.srt", "", false},
+		{"Movie.1080p.srt", "", false},
+		{"Movie.x264.srt", "", false},
+		{"Movie.2010.DVD.srt", "", false},
+	}
+	for _, tt := range tests {
+		lang, ok := LanguageFromFilename(tt.name)
+		assert.Equal(t, tt.ok, ok, tt.name)
+		assert.Equal(t, tt.lang, lang, tt.name)
+	}
+}
+
+func TestInfer(t *testing.T) {
+	var langs []string
+	for i := 0; i < 18; i++ {
+		langs = append(langs, "el")
+	}
+	langs = append(langs, "EN", "en", "")
+
+	p := Infer(langs, 0)
+	assert.Equal(t, 20, p.Total)
+	require.Len(t, p.Shares, 2)
+	assert.Equal(t, Share{Language: "el", Count: 18, Fraction: 0.9}, p.Shares[0])
+	assert.Equal(t, []string{"el", "en"}, p.Preferred)
+	assert.Equal(t, "el 90%, en 10%", p.String())
+
+	p = Infer(langs, 0.2)
+	assert.Equal(t, []string{"el"}, p.Preferred)
+}
+
+func TestInferFromFS(t *testing.T) {
+	m := vfs.NewMemFS()
+	require.NoError(t, m.MkdirAll("library/Show/Season 1", 0o755))
+	for ep := 1; ep <= 4; ep++ {
+		base := fmt.Sprintf("library/Show/Season 1/Show.S01E%02d", ep)
+		require.NoError(t, vfs.WriteFile(m, base+".mkv", nil))
+		require.NoError(t, vfs.WriteFile(m, base+".el.srt", nil))
+	}
+	require.NoError(t, vfs.WriteFile(m, "library/Show/Season 1/Show.S01E01.en.forced.srt", nil))
+	require.NoError(t, vfs.WriteFile(m, "library/Show/notes.txt", nil))
+
+	p, err := InferFromFS(m, "library", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 5, p.Total)
+	assert.Equal(t, []string{"el", "en"}, p.Preferred)
+	assert.InDelta(t, 0.8, p.Shares[0].Fraction, 1e-9)
+}
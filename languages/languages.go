@@ -0,0 +1,229 @@
+// Package languages maps between the language codes used by OpenSubtitles: the REST
+// API codes ("en", "pt-BR"), ISO 639-1 codes ("en") and the ISO 639-2/B based IDs of
+// the XML-RPC API ("eng", "pob"). Parse accepts any of them, common ISO 639-2/T
+// variants ("deu", "fra"), locale tags ("en_US") and English names:
+//
+//	lang, err := languages.Parse("Portuguese (Brazilian)")
+//	// lang.Code == "pt-BR", lang.ISO3 == "pob"
+//	code, err := languages.ToISO3("el") // "ell"
+package languages
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrUnknownLanguage is returned for codes and names that are not in the catalog.
+var ErrUnknownLanguage = errors.New("languages: unknown language")
+
+// Language is an entry of the catalog.
+type Language struct {
+	Code string // REST API code, e.g. "en" or "pt-BR"
+	ISO1 string // ISO 639-1 code; empty for languages without one, e.g. Asturian
+	ISO3 string // XML-RPC sublanguageid, ISO 639-2/B where one exists, e.g. "ger"
+	Name string // English name as listed by OpenSubtitles
+}
+
+// catalog lists the languages of OpenSubtitles. Regional variants follow their main
+// language, so an ISO 639-1 code resolves to the main language.
+var catalog = []Language{
+	{"af", "af", "afr", "Afrikaans"},
+	{"sq", "sq", "alb", "Albanian"},
+	{"am", "am", "amh", "Amharic"},
+	{"ar", "ar", "ara", "Arabic"},
+	{"an", "an", "arg", "Aragonese"},
+	{"hy", "hy", "arm", "Armenian"},
+	{"as", "as", "asm", "Assamese"},
+	{"at", "", "ast", "Asturian"},
+	{"az", "az", "aze", "Azerbaijani"},
+	{"eu", "eu", "baq", "Basque"},
+	{"be", "be", "bel", "Belarusian"},
+	{"bn", "bn", "ben", "Bengali"},
+	{"bs", "bs", "bos", "Bosnian"},
+	{"br", "br", "bre", "Breton"},
+	{"bg", "bg", "bul", "Bulgarian"},
+	{"my", "my", "bur", "Burmese"},
+	{"ca", "ca", "cat", "Catalan"},
+	{"zh-CN", "zh", "chi", "Chinese (simplified)"},
+	{"zh-TW", "zh", "zht", "Chinese (traditional)"},
+	{"ze", "zh", "zhe", "Chinese bilingual"},
+	{"hr", "hr", "hrv", "Croatian"},
+	{"cs", "cs", "cze", "Czech"},
+	{"da", "da", "dan", "Danish"},
+	{"pr", "", "prs", "Dari"},
+	{"nl", "nl", "dut", "Dutch"},
+	{"en", "en", "eng", "English"},
+	{"eo", "eo", "epo", "Esperanto"},
+	{"et", "et", "est", "Estonian"},
+	{"ex", "", "ext", "Extremaduran"},
+	{"fi", "fi", "fin", "Finnish"},
+	{"fr", "fr", "fre", "French"},
+	{"gd", "gd", "gla", "Gaelic"},
+	{"gl", "gl", "glg", "Galician"},
+	{"ka", "ka", "geo", "Georgian"},
+	{"de", "de", "ger", "German"},
+	{"el", "el", "ell", "Greek"},
+	{"he", "he", "heb", "Hebrew"},
+	{"hi", "hi", "hin", "Hindi"},
+	{"hu", "hu", "hun", "Hungarian"},
+	{"is", "is", "ice", "Icelandic"},
+	{"ig", "ig", "ibo", "Igbo"},
+	{"id", "id", "ind", "Indonesian"},
+	{"ia", "ia", "ina", "Interlingua"},
+	{"ga", "ga", "gle", "Irish"},
+	{"it", "it", "ita", "Italian"},
+	{"ja", "ja", "jpn", "Japanese"},
+	{"kn", "kn", "kan", "Kannada"},
+	{"kk", "kk", "kaz", "Kazakh"},
+	{"km", "km", "khm", "Khmer"},
+	{"ko", "ko", "kor", "Korean"},
+	{"ku", "ku", "kur", "Kurdish"},
+	{"lv", "lv", "lav", "Latvian"},
+	{"lt", "lt", "lit", "Lithuanian"},
+	{"lb", "lb", "ltz", "Luxembourgish"},
+	{"mk", "mk", "mac", "Macedonian"},
+	{"ms", "ms", "may", "Malay"},
+	{"ml", "ml", "mal", "Malayalam"},
+	{"ma", "", "mni", "Manipuri"},
+	{"mr", "mr", "mar", "Marathi"},
+	{"mn", "mn", "mon", "Mongolian"},
+	{"me", "", "mne", "Montenegrin"},
+	{"nv", "nv", "nav", "Navajo"},
+	{"ne", "ne", "nep", "Nepali"},
+	{"se", "se", "sme", "Northern Sami"},
+	{"no", "no", "nor", "Norwegian"},
+	{"oc", "oc", "oci", "Occitan"},
+	{"or", "or", "ori", "Odia"},
+	{"fa", "fa", "per", "Persian"},
+	{"pl", "pl", "pol", "Polish"},
+	{"pt-PT", "pt", "por", "Portuguese"},
+	{"pt-BR", "pt", "pob", "Portuguese (Brazilian)"},
+	{"pm", "pt", "pom", "Portuguese (Mozambique)"},
+	{"ps", "ps", "pus", "Pashto"},
+	{"ro", "ro", "rum", "Romanian"},
+	{"ru", "ru", "rus", "Russian"},
+	{"sx", "", "sat", "Santali"},
+	{"sr", "sr", "scc", "Serbian"},
+	{"sd", "sd", "snd", "Sindhi"},
+	{"si", "si", "sin", "Sinhalese"},
+	{"sk", "sk", "slo", "Slovak"},
+	{"sl", "sl", "slv", "Slovenian"},
+	{"so", "so", "som", "Somali"},
+	{"es", "es", "spa", "Spanish"},
+	{"sp", "es", "spn", "Spanish (Europe)"},
+	{"ea", "es", "spl", "Spanish (Latin America)"},
+	{"sw", "sw", "swa", "Swahili"},
+	{"sv", "sv", "swe", "Swedish"},
+	{"sy", "", "syr", "Syriac"},
+	{"tl", "tl", "tgl", "Tagalog"},
+	{"ta", "ta", "tam", "Tamil"},
+	{"tt", "tt", "tat", "Tatar"},
+	{"te", "te", "tel", "Telugu"},
+	{"th", "th", "tha", "Thai"},
+	{"tp", "", "tok", "Toki Pona"},
+	{"tr", "tr", "tur", "Turkish"},
+	{"tk", "tk", "tuk", "Turkmen"},
+	{"uk", "uk", "ukr", "Ukrainian"},
+	{"ur", "ur", "urd", "Urdu"},
+	{"uz", "uz", "uzb", "Uzbek"},
+	{"vi", "vi", "vie", "Vietnamese"},
+	{"cy", "cy", "wel", "Welsh"},
+}
+
+// iso3Aliases maps ISO 639-2/T and other alternative codes to the catalog's ISO3.
+var iso3Aliases = map[string]string{
+	"sqi": "alb", "hye": "arm", "eus": "baq", "mya": "bur", "zho": "chi", "ces": "cze",
+	"nld": "dut", "fra": "fre", "kat": "geo", "deu": "ger", "gre": "ell", "isl": "ice",
+	"mkd": "mac", "msa": "may", "fas": "per", "ron": "rum", "srp": "scc", "slk": "slo",
+	"cym": "wel", "scr": "hrv", "nob": "nor", "nno": "nor",
+}
+
+// Lookup indexes, keyed by lower-case code or name.
+var byCode, byISO1, byISO3, byName = index()
+
+func index() (code, iso1, iso3, name map[string]int) {
+	code, iso1, iso3, name = make(map[string]int), make(map[string]int), make(map[string]int), make(map[string]int)
+	for i, l := range catalog {
+		code[strings.ToLower(l.Code)] = i
+		if _, ok := iso1[l.ISO1]; !ok && l.ISO1 != "" {
+			iso1[l.ISO1] = i
+		}
+		iso3[l.ISO3] = i
+		name[strings.ToLower(l.Name)] = i
+	}
+	return code, iso1, iso3, name
+}
+
+// All returns a copy of the catalog. Regional variants follow their main language.
+func All() []Language {
+	return append([]Language(nil), catalog...)
+}
+
+// Parse resolves a REST API code, an ISO 639-1 or 639-2 code, a locale tag such as
+// "pt_BR" or "en-US", or an English name, case-insensitively. A region that is not a
+// variant known to OpenSubtitles is ignored, so "en-US" is English.
+func Parse(s string) (Language, error) {
+	key := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(s), "_", "-"))
+	if l, ok := lookup(key); ok {
+		return l, nil
+	}
+	if base, _, ok := strings.Cut(key, "-"); ok {
+		if l, ok := lookup(base); ok {
+			return l, nil
+		}
+	}
+	return Language{}, fmt.Errorf("%w: %q", ErrUnknownLanguage, s)
+}
+
+func lookup(key string) (Language, bool) {
+	if alias, ok := iso3Aliases[key]; ok {
+		key = alias
+	}
+	for _, m := range []map[string]int{byCode, byISO1, byISO3, byName} {
+		if i, ok := m[key]; ok {
+			return catalog[i], true
+		}
+	}
+	return Language{}, false
+}
+
+// ToOSCode returns the REST API code of s, e.g. "pt-BR" for "pob".
+func ToOSCode(s string) (string, error) {
+	l, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return l.Code, nil
+}
+
+// ToISO2 returns the ISO 639-1 code of s, e.g. "de" for "ger". Languages without
+// one, such as Asturian, return ErrUnknownLanguage.
+func ToISO2(s string) (string, error) {
+	l, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	if l.ISO1 == "" {
+		return "", fmt.Errorf("%w: %s has no ISO 639-1 code", ErrUnknownLanguage, l.Name)
+	}
+	return l.ISO1, nil
+}
+
+// ToISO3 returns the XML-RPC sublanguageid of s, e.g. "ger" for "de".
+func ToISO3(s string) (string, error) {
+	l, err := Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return l.ISO3, nil
+}
+
+// Name returns the English name of s, or an empty string if s is unknown.
+func Name(s string) string {
+	l, err := Parse(s)
+	if err != nil {
+		return ""
+	}
+	return l.Name
+}
@@ -0,0 +1,82 @@
+package languages
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]string{
+		"en":                     "en",
+		"EN":                     "en",
+		"eng":                    "en",
+		"English":                "en",
+		"en-US":                  "en",
+		"en_GB":                  "en",
+		"pt-br":                  "pt-BR",
+		"pt_BR":                  "pt-BR",
+		"pob":                    "pt-BR",
+		"Portuguese (Brazilian)": "pt-BR",
+		"pt":                     "pt-PT",
+		"zh":                     "zh-CN",
+		"zht":                    "zh-TW",
+		"deu":                    "de",
+		"ger":                    "de",
+		"gre":                    "el",
+		"ell":                    "el",
+		" tok ":                  "tp",
+		"Asturian":               "at",
+	}
+	for input, want := range tests {
+		lang, err := Parse(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, lang.Code, input)
+	}
+
+	for _, input := range []string{"", "xx", "klingon", "xx-YY"} {
+		_, err := Parse(input)
+		assert.ErrorIs(t, err, ErrUnknownLanguage, input)
+	}
+}
+
+func TestConversions(t *testing.T) {
+	code, err := ToOSCode("spl")
+	require.NoError(t, err)
+	assert.Equal(t, "ea", code)
+
+	iso2, err := ToISO2("ger")
+	require.NoError(t, err)
+	assert.Equal(t, "de", iso2)
+	_, err = ToISO2("ast")
+	assert.ErrorIs(t, err, ErrUnknownLanguage, "Asturian has no ISO 639-1 code")
+
+	iso3, err := ToISO3("el")
+	require.NoError(t, err)
+	assert.Equal(t, "ell", iso3)
+	_, err = ToISO3("xx")
+	assert.ErrorIs(t, err, ErrUnknownLanguage)
+
+	assert.Equal(t, "Greek", Name("el"))
+	assert.Equal(t, "", Name("xx"))
+}
+
+func TestCatalogIsConsistent(t *testing.T) {
+	codes, iso3s := make(map[string]bool), make(map[string]bool)
+	for _, l := range All() {
+		assert.False(t, codes[l.Code], "duplicate code %s", l.Code)
+		assert.False(t, iso3s[l.ISO3], "duplicate ISO3 %s", l.ISO3)
+		codes[l.Code], iso3s[l.ISO3] = true, true
+
+		parsed, err := Parse(l.Code)
+		require.NoError(t, err)
+		assert.Equal(t, l, parsed, "code %s resolves to its own entry", l.Code)
+		parsed, err = Parse(l.ISO3)
+		require.NoError(t, err)
+		assert.Equal(t, l, parsed, "ISO3 %s resolves to its own entry", l.ISO3)
+	}
+	for alias, iso3 := range iso3Aliases {
+		assert.True(t, iso3s[iso3], "alias %s points to unknown %s", alias, iso3)
+	}
+}
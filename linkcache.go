@@ -0,0 +1,88 @@
+package opensubtitles
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// linkCache keeps download links for Config.DownloadLinkTTL so that requesting the
+// same file again does not use up download quota. A nil *linkCache caches nothing.
+type linkCache struct {
+	ttl time.Duration
+	now func() time.Time // Replaced in tests
+
+	mu    sync.Mutex
+	links map[string]cachedLink
+}
+
+type cachedLink struct {
+	response DownloadResponse
+	expires  time.Time
+}
+
+func newLinkCache(ttl time.Duration) *linkCache {
+	return &linkCache{ttl: ttl, now: time.Now, links: make(map[string]cachedLink)}
+}
+
+// linkKey identifies a download by file and conversion options, so a file requested
+// in another format or frame rate gets its own link. Forced downloads are not cached.
+func linkKey(req DownloadRequest) (string, bool) {
+	if req.ForceDownload != nil && *req.ForceDownload {
+		return "", false
+	}
+	key, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	return string(key), true
+}
+
+// get returns a copy of the unexpired link for req, or nil.
+func (lc *linkCache) get(req DownloadRequest) *DownloadResponse {
+	if lc == nil {
+		return nil
+	}
+	key, ok := linkKey(req)
+	if !ok {
+		return nil
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	cached, ok := lc.links[key]
+	if !ok || !lc.now().Before(cached.expires) {
+		return nil
+	}
+	response := cached.response
+	return &response
+}
+
+// put stores the link returned for req and drops expired links.
+func (lc *linkCache) put(req DownloadRequest, response DownloadResponse) {
+	if lc == nil {
+		return
+	}
+	key, ok := linkKey(req)
+	if !ok {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	now := lc.now()
+	for k, cached := range lc.links {
+		if !now.Before(cached.expires) {
+			delete(lc.links, k)
+		}
+	}
+	lc.links[key] = cachedLink{response: response, expires: now.Add(lc.ttl)}
+}
+
+// clear drops all links, e.g. when another user logs in.
+func (lc *linkCache) clear() {
+	if lc == nil {
+		return
+	}
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+	lc.links = make(map[string]cachedLink)
+}
@@ -0,0 +1,69 @@
+package opensubtitles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadLinkCache(t *testing.T) {
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		fmt.Fprintf(w, `{"link":"https://example.com/%d.srt","remaining":%d}`, calls, 100-calls)
+	}
+	server, _ := setupTestServer(t, handler)
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, DownloadLinkTTL: time.Hour})
+	require.NoError(t, err)
+	now := time.Now()
+	client.links.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	first, err := client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	again, err := client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, first, again)
+	assert.Equal(t, 1, calls, "the cached link is reused")
+
+	// Other files and formats get their own links.
+	_, err = client.Download(ctx, DownloadRequest{FileID: 2})
+	require.NoError(t, err)
+	_, err = client.Download(ctx, DownloadRequest{FileID: 1, SubFormat: String("webvtt")})
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+
+	force := true
+	forced, err := client.Download(ctx, DownloadRequest{FileID: 1, ForceDownload: &force})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/4.srt", forced.Link)
+
+	now = now.Add(time.Hour)
+	expired, err := client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/5.srt", expired.Link)
+
+	require.NoError(t, client.SetAuthToken("other-user", ""))
+	_, err = client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	assert.Equal(t, 6, calls, "a new token clears the cache")
+}
+
+func TestDownloadLinkCacheDisabledByDefault(t *testing.T) {
+	calls := 0
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		_, _ = w.Write([]byte(`{"link":"https://example.com/1.srt","remaining":9}`))
+	})
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Download(context.Background(), DownloadRequest{FileID: 1})
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,293 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/angelospk/opensubtitles-go/subfmt"
+)
+
+// ErrNoSubtitleFound is returned by FindBestSubtitle when no search strategy yields a
+// downloadable subtitle.
+var ErrNoSubtitleFound = errors.New("opensubtitles: no matching subtitle found")
+
+// VideoQuery describes a local video to find subtitles for. FindBestSubtitle searches
+// by MovieHash, then IMDbID, then Query, using the first strategy that returns results.
+type VideoQuery struct {
+	MovieHash     string // OSDb hash, see the hash package
+	IMDbID        int
+	Query         string // Title or file name for the text search
+	ReleaseName   string // Compared with subtitle release names; defaults to Query
+	SeasonNumber  int    // Episodes only
+	EpisodeNumber int    // Episodes only
+	Year          int
+	// Duration is the runtime of the video, e.g. from a mediainfo.Prober. It is used
+	// by SubtitleMatch.CheckCut; 0 if unknown.
+	Duration time.Duration
+}
+
+// MatchPreferences tunes the ranking done by FindBestSubtitle and RankSubtitles.
+type MatchPreferences struct {
+	// Languages restricts the search, most preferred first. Empty means all languages.
+	Languages []LanguageCode
+	// HearingImpaired and ForeignPartsOnly (forced) prefer matching subtitles when true
+	// and avoid them when false. Nil means no preference.
+	HearingImpaired  *bool
+	ForeignPartsOnly *bool
+	// ExcludeAITranslated and ExcludeMachineTranslated drop AI- or machine-translated
+	// subtitles when true and allow them when false. Nil leaves FindBestSubtitle to
+	// Config.ExcludeAITranslated and Config.ExcludeMachineTranslated; RankSubtitles
+	// then keeps them.
+	ExcludeAITranslated      *bool
+	ExcludeMachineTranslated *bool
+}
+
+// SubtitleMatch is a ranked search result: the chosen file of a subtitle and its score.
+type SubtitleMatch struct {
+	SubtitleFile
+	Subtitle Subtitle
+	Score    float64
+	// DurationMismatch is set by CheckCut if the subtitle seems to be made for another
+	// cut of the video.
+	DurationMismatch *DurationMismatch
+}
+
+// Thresholds of SubtitleMatch.CheckCut.
+const (
+	// CutOverrunTolerance is how far the last cue may end after the end of the video.
+	CutOverrunTolerance = time.Minute
+	// CutMaxCredits is how long before the end of the video the last cue may end.
+	// Closing credits rarely take longer; extended cuts usually add more.
+	CutMaxCredits = 15 * time.Minute
+)
+
+// DurationMismatch warns that the last cue of a subtitle ends too far from the end of
+// the video, as for a subtitle made for the theatrical cut of an extended video, or
+// the other way round.
+type DurationMismatch struct {
+	Video   time.Duration // Runtime of the video
+	LastCue time.Duration // End of the last cue
+	Delta   time.Duration // LastCue - Video: positive if the subtitle runs longer
+}
+
+func (m DurationMismatch) String() string {
+	return fmt.Sprintf("subtitle ends at %s, %s from the end of the %s video", m.LastCue, m.Delta, m.Video)
+}
+
+// Score weights used by RankSubtitles.
+const (
+	scoreMoviehashMatch  = 100.0
+	scoreReleaseMatch    = 40.0 // Scaled by release name similarity
+	scoreLanguage        = 20.0 // Scaled by position in MatchPreferences.Languages
+	scoreTrusted         = 20.0
+	scorePreference      = 15.0 // Added or subtracted per HI/forced preference
+	scoreDownloadsPerLog = 5.0  // Per order of magnitude of downloads
+	scoreCutMismatch     = 50.0 // Subtracted by CheckCut
+)
+
+// FindBestSubtitle searches for subtitles of video, trying the moviehash, the IMDb ID
+// and the query text in turn, and returns the best ranked result of the first search
+// that finds any. It returns ErrNoSubtitleFound if none does.
+func (c *Client) FindBestSubtitle(ctx context.Context, video VideoQuery, prefs MatchPreferences) (*SubtitleMatch, error) {
+	base := SearchSubtitlesParams{}
+	if langs := languagesParam(prefs.Languages); langs != "" {
+		base.Languages = &langs
+	}
+	if video.SeasonNumber > 0 {
+		base.SeasonNumber = &video.SeasonNumber
+	}
+	if video.EpisodeNumber > 0 {
+		base.EpisodeNumber = &video.EpisodeNumber
+	}
+	base.AITranslated = translationFilter(prefs.ExcludeAITranslated)
+	base.MachineTranslated = translationFilter(prefs.ExcludeMachineTranslated)
+
+	var strategies []SearchSubtitlesParams
+	if video.MovieHash != "" {
+		p := base
+		p.Moviehash = &video.MovieHash
+		strategies = append(strategies, p)
+	}
+	if video.IMDbID > 0 {
+		p := base
+		p.IMDbID = &video.IMDbID
+		strategies = append(strategies, p)
+	}
+	if video.Query != "" {
+		p := base
+		p.Query = &video.Query
+		if video.Year > 0 {
+			p.Year = &video.Year
+		}
+		strategies = append(strategies, p)
+	}
+	if len(strategies) == 0 {
+		return nil, errors.New("video query needs a moviehash, IMDb ID or query")
+	}
+
+	for _, params := range strategies {
+		resp, err := c.SearchSubtitles(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if ranked := RankSubtitles(video, prefs, resp.Data); len(ranked) > 0 {
+			return &ranked[0], nil
+		}
+	}
+	return nil, ErrNoSubtitleFound
+}
+
+// RankSubtitles scores subtitles for video, best first. Subtitles without files, and
+// AI- or machine-translated ones excluded by prefs, are dropped. The score adds a
+// moviehash match, release name similarity, language preference, a trusted uploader,
+// the download count and the HI/forced preferences; ties keep the input order.
+func RankSubtitles(video VideoQuery, prefs MatchPreferences, subtitles []Subtitle) []SubtitleMatch {
+	release := video.ReleaseName
+	if release == "" {
+		release = video.Query
+	}
+	wantTokens := releaseTokens(release)
+
+	matches := make([]SubtitleMatch, 0, len(subtitles))
+	for _, sub := range subtitles {
+		attrs := sub.Attributes
+		if len(attrs.Files) == 0 || excluded(prefs.ExcludeAITranslated, attrs.AITranslated) ||
+			excluded(prefs.ExcludeMachineTranslated, attrs.MachineTranslated) {
+			continue
+		}
+		score := 0.0
+		if attrs.MoviehashMatch != nil && *attrs.MoviehashMatch {
+			score += scoreMoviehashMatch
+		}
+		score += scoreReleaseMatch * similarity(wantTokens, releaseTokens(attrs.Release))
+		if n := len(prefs.Languages); n > 0 {
+			for i, lang := range prefs.Languages {
+				if strings.EqualFold(string(lang), string(attrs.Language)) {
+					score += scoreLanguage * float64(n-i) / float64(n)
+					break
+				}
+			}
+		}
+		if attrs.FromTrusted || (attrs.Uploader.Rank != nil && strings.EqualFold(*attrs.Uploader.Rank, "trusted")) {
+			score += scoreTrusted
+		}
+		if attrs.DownloadCount > 0 {
+			score += scoreDownloadsPerLog * math.Log10(float64(attrs.DownloadCount)+1)
+		}
+		score += preferenceScore(prefs.HearingImpaired, attrs.HearingImpaired)
+		score += preferenceScore(prefs.ForeignPartsOnly, attrs.ForeignPartsOnly)
+
+		matches = append(matches, SubtitleMatch{SubtitleFile: attrs.Files[0], Subtitle: sub, Score: score})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches
+}
+
+// CheckCut compares the end of the last cue of the downloaded subtitle with
+// video.Duration to detect a subtitle made for another cut of the video. On a
+// mismatch it sets m.DurationMismatch and lowers m.Score, so that matches re-sorted
+// by score prefer subtitles that fit the video. Forced subtitles may end long before
+// the video and are only checked for running longer. CheckCut does nothing if
+// video.Duration is unknown; it fails if the subtitle cannot be parsed.
+func (m *SubtitleMatch) CheckCut(video VideoQuery, subtitle []byte) error {
+	if video.Duration <= 0 {
+		return nil
+	}
+	doc, err := subfmt.Parse(subtitle)
+	if err != nil {
+		return err
+	}
+	var lastCue time.Duration
+	for _, cue := range doc.Cues {
+		lastCue = max(lastCue, cue.End)
+	}
+	if lastCue == 0 {
+		return nil
+	}
+	delta := lastCue - video.Duration
+	if delta > CutOverrunTolerance || (delta < -CutMaxCredits && !m.Subtitle.Attributes.ForeignPartsOnly) {
+		if m.DurationMismatch == nil {
+			m.Score -= scoreCutMismatch
+		}
+		m.DurationMismatch = &DurationMismatch{Video: video.Duration, LastCue: lastCue, Delta: delta}
+	}
+	return nil
+}
+
+// translationFilter converts an exclusion preference to the search filter; nil keeps
+// the Config default.
+func translationFilter(exclude *bool) *FilterInclusion {
+	if exclude == nil {
+		return nil
+	}
+	filter := Include
+	if *exclude {
+		filter = Exclude
+	}
+	return &filter
+}
+
+// excluded reports whether a subtitle with the flag set is excluded by the preference.
+func excluded(exclude *bool, flagged bool) bool {
+	return flagged && exclude != nil && *exclude
+}
+
+func preferenceScore(want *bool, have bool) float64 {
+	switch {
+	case want == nil:
+		return 0
+	case *want == have:
+		return scorePreference
+	default:
+		return -scorePreference
+	}
+}
+
+// languagesParam formats codes as the sorted, comma-separated list the API expects.
+func languagesParam(codes []LanguageCode) string {
+	langs := make([]string, 0, len(codes))
+	for _, code := range codes {
+		if code != "" {
+			langs = append(langs, strings.ToLower(string(code)))
+		}
+	}
+	sort.Strings(langs)
+	return strings.Join(langs, ",")
+}
+
+// releaseTokens splits a release or file name into lower-case words, dropping a
+// trailing file extension.
+func releaseTokens(name string) map[string]bool {
+	name = strings.ToLower(name)
+	if i := strings.LastIndexByte(name, '.'); i >= 0 && len(name)-i <= 5 {
+		switch name[i+1:] {
+		case "mkv", "mp4", "avi", "m4v", "mov", "wmv", "ts", "srt", "sub", "ass", "vtt":
+			name = name[:i]
+		}
+	}
+	tokens := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) {
+		tokens[word] = true
+	}
+	return tokens
+}
+
+// similarity is the Jaccard index of two token sets.
+func similarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for token := range a {
+		if b[token] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
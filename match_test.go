@@ -0,0 +1,216 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matchSubtitle(id string, fileID int, lang LanguageCode, release string, downloads int) Subtitle {
+	return Subtitle{
+		ApiDataWrapper: ApiDataWrapper{ID: id, Type: "subtitle"},
+		Attributes: SubtitleAttributes{
+			SubtitleID:    id,
+			Language:      lang,
+			Release:       release,
+			DownloadCount: downloads,
+			Files:         []SubtitleFile{{FileID: fileID, CDNumber: 1, FileName: release}},
+		},
+	}
+}
+
+func TestRankSubtitles(t *testing.T) {
+	video := VideoQuery{ReleaseName: "Inception.2010.1080p.BluRay.x264-SPARKS.mkv"}
+
+	t.Run("MoviehashMatchWins", func(t *testing.T) {
+		popular := matchSubtitle("1", 10, "en", "Inception.2010.1080p.BluRay.x264-SPARKS", 500000)
+		hashed := matchSubtitle("2", 20, "en", "Inception.DVDRip", 10)
+		matched := true
+		hashed.Attributes.MoviehashMatch = &matched
+
+		ranked := RankSubtitles(video, MatchPreferences{}, []Subtitle{popular, hashed})
+		require.Len(t, ranked, 2)
+		assert.Equal(t, 20, ranked[0].FileID)
+		assert.Greater(t, ranked[0].Score, ranked[1].Score)
+	})
+
+	t.Run("ReleaseSimilarityAndTrust", func(t *testing.T) {
+		other := matchSubtitle("1", 10, "en", "Inception.2010.720p.WEB-DL", 1000)
+		exact := matchSubtitle("2", 20, "en", "Inception.2010.1080p.BluRay.x264-SPARKS", 1000)
+		ranked := RankSubtitles(video, MatchPreferences{}, []Subtitle{other, exact})
+		assert.Equal(t, 20, ranked[0].FileID)
+
+		trusted := other
+		trusted.Attributes.FromTrusted = true
+		trusted.Attributes.Release = exact.Attributes.Release
+		ranked = RankSubtitles(video, MatchPreferences{}, []Subtitle{exact, trusted})
+		assert.Equal(t, 10, ranked[0].FileID)
+	})
+
+	t.Run("LanguageOrderAndPreferences", func(t *testing.T) {
+		en := matchSubtitle("1", 10, "en", "x", 100)
+		el := matchSubtitle("2", 20, "el", "x", 100)
+		ranked := RankSubtitles(video, MatchPreferences{Languages: []LanguageCode{"el", "en"}}, []Subtitle{en, el})
+		assert.Equal(t, 20, ranked[0].FileID)
+
+		hi := matchSubtitle("3", 30, "en", "x", 100)
+		hi.Attributes.HearingImpaired = true
+		noHI := false
+		ranked = RankSubtitles(video, MatchPreferences{HearingImpaired: &noHI}, []Subtitle{hi, en})
+		assert.Equal(t, 10, ranked[0].FileID)
+
+		forced := matchSubtitle("4", 40, "en", "x", 100)
+		forced.Attributes.ForeignPartsOnly = true
+		wantForced := true
+		ranked = RankSubtitles(video, MatchPreferences{ForeignPartsOnly: &wantForced}, []Subtitle{en, forced})
+		assert.Equal(t, 40, ranked[0].FileID)
+	})
+
+	t.Run("ExcludesTranslatedSubtitles", func(t *testing.T) {
+		human := matchSubtitle("1", 10, "en", "x", 1)
+		ai := matchSubtitle("2", 20, "en", "Inception.2010.1080p.BluRay.x264-SPARKS", 1000)
+		ai.Attributes.AITranslated = true
+		machine := matchSubtitle("3", 30, "en", "Inception.2010.1080p.BluRay.x264-SPARKS", 1000)
+		machine.Attributes.MachineTranslated = true
+		subs := []Subtitle{human, ai, machine}
+
+		assert.Len(t, RankSubtitles(video, MatchPreferences{}, subs), 3, "kept without preference")
+		exclude, allow := true, false
+		ranked := RankSubtitles(video, MatchPreferences{ExcludeAITranslated: &exclude, ExcludeMachineTranslated: &allow}, subs)
+		require.Len(t, ranked, 2)
+		assert.Equal(t, QualityMachine, ranked[0].Subtitle.Attributes.Quality())
+		assert.Equal(t, QualityHuman, ranked[1].Subtitle.Attributes.Quality())
+		ranked = RankSubtitles(video, MatchPreferences{ExcludeAITranslated: &exclude, ExcludeMachineTranslated: &exclude}, subs)
+		require.Len(t, ranked, 1)
+		assert.Equal(t, 10, ranked[0].FileID)
+	})
+
+	t.Run("SkipsSubtitlesWithoutFiles", func(t *testing.T) {
+		empty := matchSubtitle("1", 10, "en", "x", 100)
+		empty.Attributes.Files = nil
+		assert.Empty(t, RankSubtitles(video, MatchPreferences{}, []Subtitle{empty}))
+	})
+}
+
+func TestCheckCut(t *testing.T) {
+	srt := func(end string) []byte {
+		return []byte("1\n00:00:05,000 --> 00:00:07,000\nHello\n\n2\n" + end + "\nBye\n")
+	}
+	video := VideoQuery{Duration: 2*time.Hour + 11*time.Minute}
+
+	t.Run("SameCut", func(t *testing.T) {
+		m := SubtitleMatch{Score: 80}
+		require.NoError(t, m.CheckCut(video, srt("02:05:00,000 --> 02:05:03,000")))
+		assert.Nil(t, m.DurationMismatch, "the credits take six minutes")
+		assert.Equal(t, 80.0, m.Score)
+	})
+
+	t.Run("ExtendedSubtitleForTheatricalVideo", func(t *testing.T) {
+		m := SubtitleMatch{Score: 80}
+		require.NoError(t, m.CheckCut(video, srt("02:51:00,000 --> 02:51:02,500")))
+		require.NotNil(t, m.DurationMismatch)
+		assert.Equal(t, 2*time.Hour+51*time.Minute+2500*time.Millisecond, m.DurationMismatch.LastCue)
+		assert.Equal(t, 40*time.Minute+2500*time.Millisecond, m.DurationMismatch.Delta)
+		assert.Equal(t, "subtitle ends at 2h51m2.5s, 40m2.5s from the end of the 2h11m0s video", m.DurationMismatch.String())
+		assert.Equal(t, 30.0, m.Score)
+
+		require.NoError(t, m.CheckCut(video, srt("02:51:00,000 --> 02:51:02,500")))
+		assert.Equal(t, 30.0, m.Score, "the penalty is applied once")
+	})
+
+	t.Run("TheatricalSubtitleForExtendedVideo", func(t *testing.T) {
+		m := SubtitleMatch{}
+		require.NoError(t, m.CheckCut(video, srt("01:40:00,000 --> 01:40:02,000")))
+		require.NotNil(t, m.DurationMismatch)
+		assert.Equal(t, -(30*time.Minute + 58*time.Second), m.DurationMismatch.Delta)
+
+		forced := SubtitleMatch{Subtitle: Subtitle{Attributes: SubtitleAttributes{ForeignPartsOnly: true}}}
+		require.NoError(t, forced.CheckCut(video, srt("01:40:00,000 --> 01:40:02,000")))
+		assert.Nil(t, forced.DurationMismatch, "forced subtitles may end early")
+	})
+
+	t.Run("UnknownDuration", func(t *testing.T) {
+		m := SubtitleMatch{}
+		require.NoError(t, m.CheckCut(VideoQuery{}, []byte("not a subtitle")))
+		assert.Error(t, m.CheckCut(video, []byte("not a subtitle")))
+	})
+}
+
+func TestFindBestSubtitle(t *testing.T) {
+	t.Run("FallsBackFromHashToIMDbToQuery", func(t *testing.T) {
+		var searches []string
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			assert.Equal(t, "el,en", q.Get("languages"))
+			resp := SearchSubtitlesResponse{}
+			switch {
+			case q.Get("moviehash") != "":
+				searches = append(searches, "hash")
+			case q.Get("imdb_id") != "":
+				searches = append(searches, "imdb")
+			case q.Get("query") != "":
+				searches = append(searches, "query")
+				assert.Equal(t, "2010", q.Get("year"))
+				resp.Data = []Subtitle{
+					matchSubtitle("1", 10, "en", "Inception.2010.720p", 50),
+					matchSubtitle("2", 20, "el", "Inception.2010.720p", 50),
+				}
+			}
+			w.Header().Set("Content-Type", "application/json")
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		})
+
+		match, err := client.FindBestSubtitle(context.Background(),
+			VideoQuery{MovieHash: "8e245d9679d31e12", IMDbID: 1375666, Query: "Inception", Year: 2010},
+			MatchPreferences{Languages: []LanguageCode{"el", "en"}})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"hash", "imdb", "query"}, searches)
+		assert.Equal(t, 20, match.FileID)
+		assert.Equal(t, "2", match.Subtitle.ID)
+	})
+
+	t.Run("StopsAtFirstStrategyWithResults", func(t *testing.T) {
+		calls := 0
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			assert.Equal(t, "8e245d9679d31e12", r.URL.Query().Get("moviehash"))
+			resp := SearchSubtitlesResponse{Data: []Subtitle{matchSubtitle("1", 10, "en", "x", 1)}}
+			require.NoError(t, json.NewEncoder(w).Encode(resp))
+		})
+		match, err := client.FindBestSubtitle(context.Background(), VideoQuery{MovieHash: "8e245d9679d31e12", Query: "x"}, MatchPreferences{})
+		require.NoError(t, err)
+		assert.Equal(t, 10, match.FileID)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("TranslationPreferencesOverrideConfig", func(t *testing.T) {
+		server, _ := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+			assert.Equal(t, "include", q.Get("ai_translated"), "preference wins")
+			assert.Equal(t, "exclude", q.Get("machine_translated"), "config default")
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		})
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits,
+			ExcludeAITranslated: true, ExcludeMachineTranslated: true})
+		require.NoError(t, err)
+		allow := false
+		_, err = client.FindBestSubtitle(context.Background(), VideoQuery{Query: "x"}, MatchPreferences{ExcludeAITranslated: &allow})
+		assert.ErrorIs(t, err, ErrNoSubtitleFound)
+	})
+
+	t.Run("NoResults", func(t *testing.T) {
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		})
+		_, err := client.FindBestSubtitle(context.Background(), VideoQuery{Query: "nothing"}, MatchPreferences{})
+		assert.ErrorIs(t, err, ErrNoSubtitleFound)
+
+		_, err = client.FindBestSubtitle(context.Background(), VideoQuery{}, MatchPreferences{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,148 @@
+// Package mediainfo reads the technical details of video files that subtitle uploads
+// need, such as the frame rate and duration. Prober is the extension point; FFprobe
+// implements it with the ffprobe command of FFmpeg:
+//
+//	info, err := mediainfo.FFprobe{}.Probe(ctx, "Heat.1995.1080p.BluRay.mkv")
+//	// info.FPS == 23.976, info.Resolution() == "1920x1080"
+package mediainfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNoVideoStream is returned for files without a video stream.
+var ErrNoVideoStream = errors.New("mediainfo: no video stream found")
+
+// Info describes the first video stream of a file. Zero values mean unknown.
+type Info struct {
+	Duration time.Duration
+	FPS      float64
+	Frames   int64 // Read from the container, or estimated from Duration and FPS
+	Width    int
+	Height   int
+	Codec    string // e.g. "h264", "hevc"
+}
+
+// Resolution returns "WIDTHxHEIGHT", or an empty string if unknown.
+func (i Info) Resolution() string {
+	if i.Width == 0 || i.Height == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%dx%d", i.Width, i.Height)
+}
+
+// Prober reads the Info of a video file.
+type Prober interface {
+	Probe(ctx context.Context, path string) (*Info, error)
+}
+
+// FFprobe probes files by running ffprobe.
+type FFprobe struct {
+	// Path is the ffprobe executable (default: "ffprobe" from PATH).
+	Path string
+}
+
+// Ensure FFprobe implements Prober.
+var _ Prober = FFprobe{}
+
+// Probe runs ffprobe on path. If ffprobe is not installed, the error wraps
+// exec.ErrNotFound.
+func (f FFprobe) Probe(ctx context.Context, path string) (*Info, error) {
+	bin := f.Path
+	if bin == "" {
+		bin = "ffprobe"
+	}
+	cmd := exec.CommandContext(ctx, bin, "-v", "error", "-print_format", "json",
+		"-show_format", "-show_streams", "-select_streams", "v:0", path)
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("mediainfo: ffprobe failed for '%s': %s", path, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("mediainfo: failed to run ffprobe for '%s': %w", path, err)
+	}
+	info, err := parseFFprobe(out)
+	if err != nil {
+		return nil, fmt.Errorf("%w in '%s'", err, path)
+	}
+	return info, nil
+}
+
+// ffprobeOutput is the part of ffprobe's JSON output that Info is built from.
+type ffprobeOutput struct {
+	Streams []struct {
+		CodecType    string `json:"codec_type"`
+		CodecName    string `json:"codec_name"`
+		Width        int    `json:"width"`
+		Height       int    `json:"height"`
+		AvgFrameRate string `json:"avg_frame_rate"`
+		RFrameRate   string `json:"r_frame_rate"`
+		NbFrames     string `json:"nb_frames"`
+		Duration     string `json:"duration"`
+	} `json:"streams"`
+	Format struct {
+		Duration string `json:"duration"`
+	} `json:"format"`
+}
+
+func parseFFprobe(data []byte) (*Info, error) {
+	var out ffprobeOutput
+	if err := json.Unmarshal(data, &out); err != nil {
+		return nil, fmt.Errorf("mediainfo: failed to decode ffprobe output: %w", err)
+	}
+	for _, stream := range out.Streams {
+		if stream.CodecType != "video" {
+			continue
+		}
+		info := &Info{Codec: stream.CodecName, Width: stream.Width, Height: stream.Height}
+		info.FPS = frameRate(stream.AvgFrameRate)
+		if info.FPS == 0 {
+			info.FPS = frameRate(stream.RFrameRate)
+		}
+		info.Duration = seconds(stream.Duration)
+		if info.Duration == 0 {
+			info.Duration = seconds(out.Format.Duration)
+		}
+		info.Frames, _ = strconv.ParseInt(stream.NbFrames, 10, 64)
+		if info.Frames <= 0 && info.FPS > 0 {
+			info.Frames = int64(math.Round(info.Duration.Seconds() * info.FPS))
+		}
+		return info, nil
+	}
+	return nil, ErrNoVideoStream
+}
+
+// frameRate parses ffprobe's "24000/1001" notation, rounded to 3 decimals.
+func frameRate(s string) float64 {
+	num, den, ok := strings.Cut(s, "/")
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || n <= 0 {
+		return 0
+	}
+	if ok {
+		d, err := strconv.ParseFloat(den, 64)
+		if err != nil || d <= 0 {
+			return 0
+		}
+		n /= d
+	}
+	return math.Round(n*1000) / 1000
+}
+
+// seconds parses a duration in (fractional) seconds.
+func seconds(s string) time.Duration {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f <= 0 || math.IsInf(f, 0) {
+		return 0
+	}
+	return time.Duration(f * float64(time.Second))
+}
@@ -0,0 +1,66 @@
+package mediainfo
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFFprobe(t *testing.T) {
+	data, err := os.ReadFile("testdata/ffprobe.json")
+	require.NoError(t, err)
+
+	info, err := parseFFprobe(data)
+	require.NoError(t, err)
+	assert.Equal(t, &Info{
+		Duration: 10223432 * time.Millisecond,
+		FPS:      23.976,
+		Frames:   245117, // Estimated: the container does not count frames
+		Width:    1920,
+		Height:   1080,
+		Codec:    "h264",
+	}, info)
+	assert.Equal(t, "1920x1080", info.Resolution())
+
+	info, err = parseFFprobe([]byte(`{"streams":[{"codec_type":"video","avg_frame_rate":"0/0","r_frame_rate":"25/1","nb_frames":"1500","duration":"60.0"}]}`))
+	require.NoError(t, err)
+	assert.Equal(t, 25.0, info.FPS)
+	assert.Equal(t, int64(1500), info.Frames)
+	assert.Equal(t, time.Minute, info.Duration)
+	assert.Empty(t, info.Resolution())
+
+	_, err = parseFFprobe([]byte(`{"streams":[{"codec_type":"audio"}]}`))
+	assert.ErrorIs(t, err, ErrNoVideoStream)
+	_, err = parseFFprobe([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestFFprobe(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script as fake ffprobe")
+	}
+	fake := filepath.Join(t.TempDir(), "ffprobe")
+	script := "#!/bin/sh\n" +
+		"for last; do :; done\n" +
+		"if [ \"$last\" = missing.mkv ]; then echo 'missing.mkv: No such file or directory' >&2; exit 1; fi\n" +
+		"cat testdata/ffprobe.json\n"
+	require.NoError(t, os.WriteFile(fake, []byte(script), 0o755))
+
+	info, err := FFprobe{Path: fake}.Probe(context.Background(), "Heat.mkv")
+	require.NoError(t, err)
+	assert.Equal(t, 23.976, info.FPS)
+
+	_, err = FFprobe{Path: fake}.Probe(context.Background(), "missing.mkv")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "No such file or directory")
+
+	_, err = FFprobe{Path: "opensubtitles-go-no-such-ffprobe"}.Probe(context.Background(), "Heat.mkv")
+	assert.ErrorIs(t, err, exec.ErrNotFound)
+}
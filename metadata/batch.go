@@ -0,0 +1,150 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/mediainfo"
+	"github.com/angelospk/opensubtitles-go/upload"
+)
+
+// DefaultBatchWorkers is the number of pairs consolidated concurrently by
+// ConsolidateBatch when ConcurrencyOpts.Workers is not set.
+const DefaultBatchWorkers = 8
+
+// VideoSubtitlePair is a subtitle file and the video it belongs to.
+type VideoSubtitlePair struct {
+	VideoPath    string // May be empty; the subtitle's name is then resolved instead
+	SubtitlePath string
+}
+
+// ConcurrencyOpts configures ConsolidateBatch.
+type ConcurrencyOpts struct {
+	// Workers is the number of pairs consolidated concurrently (default: DefaultBatchWorkers).
+	Workers int
+	// RateLimits caps the calls per second to resolvers by name, shared by all
+	// workers, e.g. {"tmdb": 40, "trakt": 3}. Resolvers not listed are not limited;
+	// the OpenSubtitles client applies its own Config.RateLimit.
+	RateLimits map[string]float64
+	// Prober, if set, reads the FPS, duration and frame count of the videos; see
+	// upload.ConsolidateMetadata.
+	Prober mediainfo.Prober
+}
+
+// ConsolidateResult is the outcome of consolidating a single pair. Info and Intent
+// hold what was found even if Err is set, e.g. when one resolver failed.
+type ConsolidateResult struct {
+	Pair   VideoSubtitlePair
+	Info   *VideoInfo
+	Intent *upload.UserUploadIntent // Ready for an XML-RPC upload once LanguageID is set
+	Err    error
+}
+
+// ConsolidateBatch identifies the videos of pairs with resolvers and builds an upload
+// intent for each, concurrently. The IMDb ID of the intent is only set for movies;
+// the IDs of a VideoInfo identify the show of an episode, not the episode.
+// Results are returned in the order of pairs; per-pair failures are reported in
+// ConsolidateResult.Err. The returned error is only set if ctx ends early.
+func ConsolidateBatch(ctx context.Context, pairs []VideoSubtitlePair, resolvers Chain, opts ConcurrencyOpts) ([]ConsolidateResult, error) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	if workers > len(pairs) {
+		workers = len(pairs)
+	}
+	chain := make(Chain, len(resolvers))
+	for i, resolver := range resolvers {
+		chain[i] = resolver
+		if rate := opts.RateLimits[resolver.Name()]; rate > 0 {
+			chain[i] = &rateLimitedResolver{Resolver: resolver, interval: time.Duration(float64(time.Second) / rate)}
+		}
+	}
+
+	results := make([]ConsolidateResult, len(pairs))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = consolidate(ctx, pairs[i], chain, opts.Prober)
+			}
+		}()
+	}
+
+feed:
+	for i := range pairs {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for j := i; j < len(pairs); j++ {
+				results[j] = ConsolidateResult{Pair: pairs[j], Err: ctx.Err()}
+			}
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results, ctx.Err()
+}
+
+// consolidate resolves and probes a single pair.
+func consolidate(ctx context.Context, pair VideoSubtitlePair, chain Chain, prober mediainfo.Prober) ConsolidateResult {
+	result := ConsolidateResult{Pair: pair, Info: &VideoInfo{Path: pair.VideoPath}}
+	if result.Info.Path == "" {
+		result.Info.Path = pair.SubtitlePath
+	}
+	var errs []error
+	if err := chain.Resolve(ctx, result.Info); err != nil {
+		errs = append(errs, fmt.Errorf("failed to resolve '%s': %w", result.Info.Path, err))
+	}
+
+	result.Intent = &upload.UserUploadIntent{
+		VideoFilePath:    pair.VideoPath,
+		SubtitleFilePath: pair.SubtitlePath,
+	}
+	if result.Info.Kind == Movie && result.Info.IMDbID != 0 {
+		result.Intent.IMDBID = fmt.Sprintf("tt%07d", result.Info.IMDbID)
+	}
+	if err := upload.ConsolidateMetadata(ctx, result.Intent, prober); err != nil {
+		errs = append(errs, fmt.Errorf("failed to probe '%s': %w", pair.VideoPath, err))
+	}
+	result.Err = errors.Join(errs...)
+	return result
+}
+
+// rateLimitedResolver spaces the calls to a Resolver by interval.
+type rateLimitedResolver struct {
+	Resolver
+	interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest start of the next call
+}
+
+func (r *rateLimitedResolver) Resolve(ctx context.Context, info *VideoInfo) error {
+	r.mu.Lock()
+	now := time.Now()
+	start := r.next
+	if start.Before(now) {
+		start = now
+	}
+	r.next = start.Add(r.interval)
+	r.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return r.Resolver.Resolve(ctx, info)
+}
@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/mediainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pathResolver resolves every path it has an entry for and records call times.
+type pathResolver struct {
+	name  string
+	infos map[string]VideoInfo
+
+	mu    sync.Mutex
+	calls []time.Time
+}
+
+func (p *pathResolver) Name() string { return p.name }
+
+func (p *pathResolver) Resolve(ctx context.Context, info *VideoInfo) error {
+	p.mu.Lock()
+	p.calls = append(p.calls, time.Now())
+	p.mu.Unlock()
+	found, ok := p.infos[info.Path]
+	if !ok {
+		return fmt.Errorf("no entry for %s", info.Path)
+	}
+	info.Merge(found)
+	return nil
+}
+
+type fixedProber struct{ info mediainfo.Info }
+
+func (f fixedProber) Probe(ctx context.Context, path string) (*mediainfo.Info, error) {
+	if path == "broken.mkv" {
+		return nil, errors.New("unreadable")
+	}
+	return &f.info, nil
+}
+
+func TestConsolidateBatch(t *testing.T) {
+	resolver := &pathResolver{name: "tmdb", infos: map[string]VideoInfo{
+		"/movies/Heat.mkv":  {Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949},
+		"/tv/BB.S01E02.mkv": {Kind: Episode, Title: "Breaking Bad", Season: 1, Episode: 2, IMDbID: 903747},
+		"/subs/only.srt":    {Kind: Movie, Title: "Only", IMDbID: 1},
+		"broken.mkv":        {Kind: Movie, Title: "Broken"},
+	}}
+	pairs := []VideoSubtitlePair{
+		{VideoPath: "/movies/Heat.mkv", SubtitlePath: "/movies/Heat.en.srt"},
+		{VideoPath: "/tv/BB.S01E02.mkv", SubtitlePath: "/tv/BB.S01E02.en.srt"},
+		{SubtitlePath: "/subs/only.srt"},
+		{VideoPath: "/unknown.mkv", SubtitlePath: "/unknown.srt"},
+		{VideoPath: "broken.mkv", SubtitlePath: "broken.srt"},
+	}
+	opts := ConcurrencyOpts{Workers: 3, RateLimits: map[string]float64{"tmdb": 50}, Prober: fixedProber{mediainfo.Info{FPS: 25, Duration: time.Minute}}}
+
+	start := time.Now()
+	results, err := ConsolidateBatch(context.Background(), pairs, Chain{resolver}, opts)
+	require.NoError(t, err)
+	require.Len(t, results, len(pairs))
+	assert.GreaterOrEqual(t, time.Since(start), 75*time.Millisecond, "5 calls at 50 per second")
+
+	heat := results[0]
+	require.NoError(t, heat.Err)
+	assert.Equal(t, pairs[0], heat.Pair)
+	assert.Equal(t, "Heat", heat.Info.Title)
+	assert.Equal(t, "tt0113277", heat.Intent.IMDBID)
+	assert.Equal(t, "Heat.mkv", heat.Intent.VideoFileName)
+	assert.Equal(t, "Heat.en.srt", heat.Intent.SubtitleFileName)
+	assert.Equal(t, 25.0, heat.Intent.FPS)
+	assert.Equal(t, int64(60000), heat.Intent.TimeMS)
+	assert.Equal(t, int64(1500), heat.Intent.Frames)
+
+	require.NoError(t, results[1].Err)
+	assert.Empty(t, results[1].Intent.IMDBID, "the IDs of an episode belong to its show")
+
+	require.NoError(t, results[2].Err)
+	assert.Equal(t, "/subs/only.srt", results[2].Info.Path, "the subtitle is resolved without a video")
+	assert.Equal(t, "tt0000001", results[2].Intent.IMDBID)
+
+	assert.ErrorContains(t, results[3].Err, "no entry for /unknown.mkv")
+	assert.Equal(t, "unknown.mkv", results[3].Intent.VideoFileName, "partial results are kept")
+
+	assert.ErrorContains(t, results[4].Err, "unreadable")
+	assert.Equal(t, "Broken", results[4].Info.Title)
+}
+
+func TestConsolidateBatchCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	pairs := []VideoSubtitlePair{{SubtitlePath: "a.srt"}, {SubtitlePath: "b.srt"}}
+	results, err := ConsolidateBatch(ctx, pairs, nil, ConcurrencyOpts{})
+	assert.ErrorIs(t, err, context.Canceled)
+	require.Len(t, results, 2)
+	for i, result := range results {
+		assert.Equal(t, pairs[i], result.Pair)
+		assert.ErrorIs(t, result.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,284 @@
+// Package metadata identifies videos: it fills a VideoInfo with the title, year,
+// season, episode and IMDb/TMDB/TheTVDB IDs by asking a chain of Resolvers, from local
+// NFO files and the file name to the OpenSubtitles, TMDB and Trakt APIs.
+//
+// Resolvers are registered in a Registry with a priority. Config, e.g. parsed from an
+// environment variable, disables or reorders them without code changes:
+//
+//	registry := metadata.NewRegistry()
+//	registry.Register(metadata.NFO{}, 100)
+//	registry.Register(metadata.Filename{}, 90)
+//	registry.Register(&metadata.OpenSubtitles{Client: client}, 50)
+//	registry.Register(&metadata.TMDB{APIKey: tmdbKey}, 40)
+//	registry.Register(&metadata.Trakt{ClientID: traktID}, 30)
+//
+//	config, err := metadata.ParseConfig("-trakt, tmdb=60") // skip Trakt, ask TMDB first
+//	info := &metadata.VideoInfo{Path: "/media/Heat.1995.1080p.BluRay.mkv"}
+//	err = registry.Chain(config).Resolve(ctx, info)
+//	resp, err := client.SearchSubtitles(ctx, info.SearchParams())
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/angelospk/opensubtitles-go"
+)
+
+// ErrNotFound is returned by a Resolver that has nothing to add for a video. Chain
+// treats it as success and moves on to the next resolver.
+var ErrNotFound = errors.New("metadata: video not found")
+
+// ErrInvalidConfig is returned by ParseConfig for malformed specifications.
+var ErrInvalidConfig = errors.New("metadata: invalid resolver configuration")
+
+// Kind is the type of video a VideoInfo describes.
+type Kind string
+
+const (
+	Movie   Kind = "movie"
+	Episode Kind = "episode"
+)
+
+// VideoInfo is what is known about a video. Zero values mean unknown; resolvers only
+// fill fields that are still zero.
+type VideoInfo struct {
+	Path    string // Video file, read by local resolvers such as NFO and Filename
+	Kind    Kind
+	Title   string // Movie title, or the show title of an episode
+	Year    int    // Release year, or the year an episode's show first aired
+	Season  int
+	Episode int
+	// The IDs identify the movie or, for episodes, the show.
+	IMDbID int // Without the "tt" prefix, e.g. 113277
+	TMDBID int
+	TVDBID int
+}
+
+// Complete reports whether the kind, title, year, IMDb and TMDB IDs and, for
+// episodes, the season and episode numbers are known. Chain stops resolving once
+// a VideoInfo is complete.
+func (v *VideoInfo) Complete() bool {
+	if v.Kind == "" || v.Title == "" || v.Year == 0 || v.IMDbID == 0 || v.TMDBID == 0 {
+		return false
+	}
+	return v.Kind == Movie || (v.Season > 0 && v.Episode > 0)
+}
+
+// Merge copies the fields of other into the fields of v that are still zero.
+func (v *VideoInfo) Merge(other VideoInfo) {
+	if v.Path == "" {
+		v.Path = other.Path
+	}
+	if v.Kind == "" {
+		v.Kind = other.Kind
+	}
+	if v.Title == "" {
+		v.Title = other.Title
+	}
+	for _, f := range []struct{ dst, src *int }{
+		{&v.Year, &other.Year}, {&v.Season, &other.Season}, {&v.Episode, &other.Episode},
+		{&v.IMDbID, &other.IMDbID}, {&v.TMDBID, &other.TMDBID}, {&v.TVDBID, &other.TVDBID},
+	} {
+		if *f.dst == 0 {
+			*f.dst = *f.src
+		}
+	}
+}
+
+// SearchParams returns subtitle search parameters for the video: its IMDb or TMDB ID
+// (of the show for episodes) when known, otherwise its title and year.
+func (v *VideoInfo) SearchParams() opensubtitles.SearchSubtitlesParams {
+	var params opensubtitles.SearchSubtitlesParams
+	imdb, tmdb := v.IMDbID, v.TMDBID
+	switch v.Kind {
+	case Episode:
+		kind := string(Episode)
+		params.Type = &kind
+		if v.Season > 0 {
+			params.SeasonNumber = &v.Season
+		}
+		if v.Episode > 0 {
+			params.EpisodeNumber = &v.Episode
+		}
+		switch {
+		case imdb != 0:
+			params.ParentIMDbID = &imdb
+		case tmdb != 0:
+			params.ParentTMDBID = &tmdb
+		}
+	case Movie:
+		kind := string(Movie)
+		params.Type = &kind
+		fallthrough
+	default:
+		switch {
+		case imdb != 0:
+			params.IMDbID = &imdb
+		case tmdb != 0:
+			params.TMDBID = &tmdb
+		}
+	}
+	if params.IMDbID == nil && params.TMDBID == nil && params.ParentIMDbID == nil && params.ParentTMDBID == nil {
+		if v.Title != "" {
+			params.Query = &v.Title
+		}
+		if v.Year > 0 && v.Kind != Episode {
+			params.Year = &v.Year
+		}
+	}
+	return params
+}
+
+// Resolver adds what it knows about a video to info, leaving fields that are already
+// set alone (see VideoInfo.Merge). It returns ErrNotFound if it knows nothing.
+type Resolver interface {
+	// Name identifies the resolver in a Registry and in Config, e.g. "tmdb".
+	Name() string
+	Resolve(ctx context.Context, info *VideoInfo) error
+}
+
+// Chain runs resolvers in order.
+type Chain []Resolver
+
+// Resolve runs the resolvers in order until info is complete. A failing resolver does
+// not stop the chain: the next one may still fill the missing fields. The errors of
+// all failed resolvers, except ErrNotFound, are joined and returned, each prefixed by
+// the resolver name. Resolve returns at once if ctx ends.
+func (c Chain) Resolve(ctx context.Context, info *VideoInfo) error {
+	var errs []error
+	for _, resolver := range c {
+		if info.Complete() {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		err := resolver.Resolve(ctx, info)
+		switch {
+		case err == nil, errors.Is(err, ErrNotFound):
+		case ctx.Err() != nil:
+			return ctx.Err()
+		default:
+			errs = append(errs, fmt.Errorf("%s: %w", resolver.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Config selects and orders the resolvers of a Registry.
+type Config struct {
+	// Disabled lists the names of resolvers to skip.
+	Disabled []string
+	// Priority overrides the registered priority of resolvers by name.
+	Priority map[string]int
+}
+
+// ParseConfig parses a comma-separated specification such as "-trakt, tmdb=60":
+// "-name" disables a resolver and "name=N" sets its priority. A bare name is
+// accepted and changes nothing, so lists of enabled resolvers read naturally.
+func ParseConfig(spec string) (Config, error) {
+	var config Config
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if name, ok := strings.CutPrefix(item, "-"); ok {
+			name = strings.TrimSpace(name)
+			if name == "" {
+				return Config{}, fmt.Errorf("%w: missing name in %q", ErrInvalidConfig, item)
+			}
+			config.Disabled = append(config.Disabled, name)
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return Config{}, fmt.Errorf("%w: missing name in %q", ErrInvalidConfig, item)
+		}
+		if !ok {
+			continue
+		}
+		priority, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return Config{}, fmt.Errorf("%w: priority of %q is not a number: %q", ErrInvalidConfig, name, value)
+		}
+		if config.Priority == nil {
+			config.Priority = make(map[string]int)
+		}
+		config.Priority[name] = priority
+	}
+	return config, nil
+}
+
+// registration is a resolver with its registered priority.
+type registration struct {
+	resolver Resolver
+	priority int
+}
+
+// Registry holds the available resolvers by name. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	entries map[string]registration
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{entries: make(map[string]registration)}
+}
+
+// Register adds resolver, replacing any resolver of the same name. Resolvers with a
+// higher priority run first; local, cheap sources should come before API calls.
+func (r *Registry) Register(resolver Resolver, priority int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[resolver.Name()] = registration{resolver: resolver, priority: priority}
+}
+
+// Unregister removes the resolver called name, if any.
+func (r *Registry) Unregister(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, name)
+}
+
+// Chain returns the resolvers enabled by config, highest priority first; ties are
+// ordered by name.
+func (r *Registry) Chain(config Config) Chain {
+	disabled := make(map[string]bool, len(config.Disabled))
+	for _, name := range config.Disabled {
+		disabled[name] = true
+	}
+
+	r.mu.RLock()
+	entries := make([]registration, 0, len(r.entries))
+	for name, entry := range r.entries {
+		if disabled[name] {
+			continue
+		}
+		if priority, ok := config.Priority[name]; ok {
+			entry.priority = priority
+		}
+		entries = append(entries, entry)
+	}
+	r.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].priority != entries[j].priority {
+			return entries[i].priority > entries[j].priority
+		}
+		return entries[i].resolver.Name() < entries[j].resolver.Name()
+	})
+	chain := make(Chain, len(entries))
+	for i, entry := range entries {
+		chain[i] = entry.resolver
+	}
+	return chain
+}
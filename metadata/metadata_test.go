@@ -0,0 +1,129 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver merges fixed fields and records its calls.
+type stubResolver struct {
+	name  string
+	info  VideoInfo
+	err   error
+	calls *[]string
+}
+
+func (s stubResolver) Name() string { return s.name }
+
+func (s stubResolver) Resolve(ctx context.Context, info *VideoInfo) error {
+	*s.calls = append(*s.calls, s.name)
+	if s.err != nil {
+		return s.err
+	}
+	info.Merge(s.info)
+	return nil
+}
+
+func TestChainResolve(t *testing.T) {
+	var calls []string
+	failure := errors.New("boom")
+	chain := Chain{
+		stubResolver{name: "nfo", err: ErrNotFound, calls: &calls},
+		stubResolver{name: "filename", info: VideoInfo{Kind: Movie, Title: "Heat", Year: 1995}, calls: &calls},
+		stubResolver{name: "broken", err: failure, calls: &calls},
+		stubResolver{name: "tmdb", info: VideoInfo{Title: "Other", IMDbID: 113277, TMDBID: 949}, calls: &calls},
+		stubResolver{name: "trakt", info: VideoInfo{TVDBID: 1}, calls: &calls},
+	}
+
+	info := &VideoInfo{Path: "/media/Heat.1995.mkv"}
+	err := chain.Resolve(context.Background(), info)
+	assert.ErrorIs(t, err, failure)
+	assert.EqualError(t, err, "broken: boom")
+	assert.Equal(t, []string{"nfo", "filename", "broken", "tmdb"}, calls, "stops once complete")
+	assert.Equal(t, &VideoInfo{Path: "/media/Heat.1995.mkv", Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	calls = nil
+	assert.ErrorIs(t, chain.Resolve(ctx, &VideoInfo{}), context.Canceled)
+	assert.Empty(t, calls)
+}
+
+func TestVideoInfoComplete(t *testing.T) {
+	movie := VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}
+	assert.True(t, movie.Complete())
+	movie.TMDBID = 0
+	assert.False(t, movie.Complete())
+
+	episode := VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, IMDbID: 903747, TMDBID: 1396, Season: 1}
+	assert.False(t, episode.Complete())
+	episode.Episode = 2
+	assert.True(t, episode.Complete())
+}
+
+func TestVideoInfoSearchParams(t *testing.T) {
+	movie := VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, TMDBID: 949}
+	params := movie.SearchParams()
+	require.NotNil(t, params.TMDBID)
+	assert.Equal(t, 949, *params.TMDBID)
+	assert.Equal(t, "movie", *params.Type)
+	assert.Nil(t, params.Query)
+
+	episode := VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2, IMDbID: 903747}
+	params = episode.SearchParams()
+	require.NotNil(t, params.ParentIMDbID)
+	assert.Equal(t, 903747, *params.ParentIMDbID)
+	assert.Equal(t, 1, *params.SeasonNumber)
+	assert.Equal(t, 2, *params.EpisodeNumber)
+	assert.Nil(t, params.IMDbID)
+
+	unknown := VideoInfo{Title: "Heat", Year: 1995}
+	params = unknown.SearchParams()
+	assert.Equal(t, "Heat", *params.Query)
+	assert.Equal(t, 1995, *params.Year)
+	assert.Nil(t, params.Type)
+}
+
+func TestParseConfig(t *testing.T) {
+	config, err := ParseConfig(" nfo, -trakt, tmdb=60 ,,")
+	require.NoError(t, err)
+	assert.Equal(t, Config{Disabled: []string{"trakt"}, Priority: map[string]int{"tmdb": 60}}, config)
+
+	config, err = ParseConfig("")
+	require.NoError(t, err)
+	assert.Equal(t, Config{}, config)
+
+	for _, spec := range []string{"-", "=5", "tmdb=high"} {
+		_, err := ParseConfig(spec)
+		assert.ErrorIs(t, err, ErrInvalidConfig, spec)
+	}
+}
+
+func TestRegistryChain(t *testing.T) {
+	var calls []string
+	registry := NewRegistry()
+	for name, priority := range map[string]int{"nfo": 100, "filename": 90, "opensubtitles": 50, "tmdb": 40, "trakt": 40} {
+		registry.Register(stubResolver{name: name, calls: &calls}, priority)
+	}
+	names := func(chain Chain) []string {
+		var out []string
+		for _, r := range chain {
+			out = append(out, r.Name())
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"nfo", "filename", "opensubtitles", "tmdb", "trakt"}, names(registry.Chain(Config{})))
+
+	config, err := ParseConfig("-trakt, tmdb=95, -unknown")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nfo", "tmdb", "filename", "opensubtitles"}, names(registry.Chain(config)))
+
+	registry.Unregister("nfo")
+	registry.Register(stubResolver{name: "filename", calls: &calls}, 10)
+	assert.Equal(t, []string{"opensubtitles", "tmdb", "trakt", "filename"}, names(registry.Chain(Config{})))
+}
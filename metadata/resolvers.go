@@ -0,0 +1,274 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	neturl "net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/nfo"
+)
+
+// NFO reads the .nfo files media managers such as Kodi place next to videos:
+// "<video>.nfo" or "movie.nfo" in the video's directory and, for episodes,
+// "tvshow.nfo" in that directory or its parent (the season folder's show folder).
+// Episode NFOs carry the IDs of the episode, not the show, so only their titles and
+// numbers are used.
+type NFO struct{}
+
+// Name returns "nfo".
+func (NFO) Name() string { return "nfo" }
+
+// Resolve fills info from the NFO files of info.Path.
+func (NFO) Resolve(ctx context.Context, info *VideoInfo) error {
+	if info.Path == "" {
+		return ErrNotFound
+	}
+	dir := filepath.Dir(info.Path)
+	base := strings.TrimSuffix(filepath.Base(info.Path), filepath.Ext(info.Path))
+
+	found := false
+	for _, path := range []string{filepath.Join(dir, base+".nfo"), filepath.Join(dir, "movie.nfo")} {
+		parsed, err := readNFO(path)
+		if err != nil {
+			return err
+		}
+		if parsed == nil {
+			continue
+		}
+		found = true
+		if parsed.Kind == nfo.Episode {
+			info.Merge(VideoInfo{Kind: Episode, Title: parsed.ShowTitle, Season: parsed.Season, Episode: parsed.Episode})
+		} else {
+			info.Merge(fromNFO(parsed))
+		}
+		break
+	}
+	if info.Kind == Episode || info.Kind == "" {
+		for _, path := range []string{filepath.Join(dir, "tvshow.nfo"), filepath.Join(filepath.Dir(dir), "tvshow.nfo")} {
+			parsed, err := readNFO(path)
+			if err != nil {
+				return err
+			}
+			if parsed != nil {
+				found = true
+				show := fromNFO(parsed)
+				show.Kind = Episode
+				info.Merge(show)
+				break
+			}
+		}
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// readNFO parses the NFO at path, returning nil if it does not exist or holds nothing.
+func readNFO(path string) (*nfo.Info, error) {
+	parsed, err := nfo.ParseFile(path)
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, nfo.ErrNoMetadata) {
+		return nil, nil
+	}
+	return parsed, err
+}
+
+// fromNFO converts the metadata of a movie or show NFO.
+func fromNFO(parsed *nfo.Info) VideoInfo {
+	v := VideoInfo{
+		Title:   parsed.Title,
+		Year:    parsed.Year,
+		Season:  parsed.Season,
+		Episode: parsed.Episode,
+		IMDbID:  parsed.IMDbID,
+		TMDBID:  parsed.TMDBID,
+		TVDBID:  parsed.TVDBID,
+	}
+	switch parsed.Kind {
+	case nfo.Movie:
+		v.Kind = Movie
+	case nfo.TVShow, nfo.Episode:
+		v.Kind = Episode
+	}
+	return v
+}
+
+// Filename guesses the title, year, season and episode from the name of info.Path
+// with opensubtitles.ParseReleaseName, without calling any API.
+type Filename struct{}
+
+// Name returns "filename".
+func (Filename) Name() string { return "filename" }
+
+// Resolve fills info from the file name.
+func (Filename) Resolve(ctx context.Context, info *VideoInfo) error {
+	if info.Path == "" {
+		return ErrNotFound
+	}
+	guess := opensubtitles.ParseReleaseName(filepath.Base(info.Path))
+	var v VideoInfo
+	if guess.Title != nil {
+		v.Title = *guess.Title
+	}
+	if guess.Year != nil {
+		v.Year = *guess.Year
+	}
+	if guess.Season != nil {
+		v.Season = *guess.Season
+	}
+	if guess.Episode != nil {
+		v.Episode = *guess.Episode
+	}
+	if guess.Type != nil {
+		switch *guess.Type {
+		case "episode":
+			v.Kind = Episode
+		case "movie":
+			v.Kind = Movie
+		}
+	}
+	if v.Title == "" {
+		return ErrNotFound
+	}
+	info.Merge(v)
+	return nil
+}
+
+// OpenSubtitles looks videos up in the OpenSubtitles feature database: by IMDb or
+// TMDB ID when one is known, otherwise by title and year.
+type OpenSubtitles struct {
+	Client *opensubtitles.Client
+}
+
+// Name returns "opensubtitles".
+func (*OpenSubtitles) Name() string { return "opensubtitles" }
+
+// Resolve fills info from the first matching feature.
+func (o *OpenSubtitles) Resolve(ctx context.Context, info *VideoInfo) error {
+	var params opensubtitles.SearchFeaturesParams
+	switch {
+	case info.IMDbID != 0:
+		imdb := strconv.Itoa(info.IMDbID)
+		params.IMDbID = &imdb
+	case info.TMDBID != 0:
+		tmdb := strconv.Itoa(info.TMDBID)
+		params.TMDBID = &tmdb
+	case info.Title != "":
+		params.Query = &info.Title
+		if info.Year > 0 {
+			params.Year = &info.Year
+		}
+	default:
+		return ErrNotFound
+	}
+	featureType := "movie"
+	if info.Kind == Episode {
+		featureType = "tvshow"
+	}
+	if info.Kind != "" {
+		params.Type = &featureType
+	}
+
+	resp, err := o.Client.SearchFeatures(ctx, params)
+	if err != nil {
+		return err
+	}
+	for _, feature := range resp.Data {
+		var base opensubtitles.FeatureBaseAttributes
+		kind := Movie
+		if movie, ok := feature.AsMovie(); ok {
+			base = movie.FeatureBaseAttributes
+		} else if show, ok := feature.AsTvshow(); ok {
+			base, kind = show.FeatureBaseAttributes, Episode
+		} else {
+			continue
+		}
+		if info.Kind != "" && info.Kind != kind {
+			continue
+		}
+		v := VideoInfo{Kind: kind, Title: base.Title}
+		v.Year, _ = strconv.Atoi(base.Year)
+		if base.IMDbID != nil {
+			v.IMDbID = *base.IMDbID
+		}
+		if base.TMDBID != nil {
+			v.TMDBID = *base.TMDBID
+		}
+		if (info.IMDbID != 0 && v.IMDbID != 0 && v.IMDbID != info.IMDbID) ||
+			(info.TMDBID != 0 && v.TMDBID != 0 && v.TMDBID != info.TMDBID) {
+			continue
+		}
+		info.Merge(v)
+		return nil
+	}
+	return ErrNotFound
+}
+
+// maxResponseBytes caps the responses read from metadata APIs.
+const maxResponseBytes = 4 << 20
+
+// getJSON sends a GET request with header and decodes the JSON response into target.
+// 404 responses return ErrNotFound. Errors leave out the query of url, which may hold
+// an API key.
+func getJSON(ctx context.Context, client *http.Client, url string, header http.Header, target any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	req.Header.Set("Accept", "application/json")
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	body := io.LimitReader(resp.Body, maxResponseBytes)
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		msg, _ := io.ReadAll(io.LimitReader(body, 256))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if err := json.NewDecoder(body).Decode(target); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// parseYear returns the year of a "2006-01-02" date, or 0.
+func parseYear(date string) int {
+	year, _, _ := strings.Cut(date, "-")
+	n, err := strconv.Atoi(year)
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// parseIMDbID parses "tt0113277", returning 0 for anything else.
+func parseIMDbID(s string) int {
+	id, err := opensubtitles.ParseIMDbID(s)
+	if err != nil {
+		return 0
+	}
+	return id
+}
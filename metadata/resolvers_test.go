@@ -0,0 +1,123 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestNFOResolver(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	moviePath := filepath.Join(dir, "Movies", "Heat (1995)", "Heat.1995.mkv")
+	writeFile(t, filepath.Join(dir, "Movies", "Heat (1995)", "movie.nfo"),
+		`<movie><title>Heat</title><year>1995</year><uniqueid type="imdb">tt0113277</uniqueid><uniqueid type="tmdb">949</uniqueid></movie>`)
+	info := &VideoInfo{Path: moviePath}
+	require.NoError(t, NFO{}.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Path: moviePath, Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	// The episode NFO gives the numbers; its IDs belong to the episode and are ignored.
+	episodePath := filepath.Join(dir, "TV", "Breaking Bad", "Season 1", "Breaking.Bad.S01E02.mkv")
+	writeFile(t, filepath.Join(dir, "TV", "Breaking Bad", "Season 1", "Breaking.Bad.S01E02.nfo"),
+		`<episodedetails><title>Cat's in the Bag...</title><showtitle>Breaking Bad</showtitle><season>1</season><episode>2</episode><uniqueid type="tmdb">62086</uniqueid></episodedetails>`)
+	writeFile(t, filepath.Join(dir, "TV", "Breaking Bad", "tvshow.nfo"),
+		`<tvshow><title>Breaking Bad</title><year>2008</year><uniqueid type="tmdb">1396</uniqueid><uniqueid type="tvdb">81189</uniqueid><uniqueid type="imdb">tt0903747</uniqueid></tvshow>`)
+	info = &VideoInfo{Path: episodePath}
+	require.NoError(t, NFO{}.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Path: episodePath, Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2,
+		IMDbID: 903747, TMDBID: 1396, TVDBID: 81189}, info)
+
+	assert.ErrorIs(t, NFO{}.Resolve(ctx, &VideoInfo{Path: filepath.Join(dir, "none", "video.mkv")}), ErrNotFound)
+	assert.ErrorIs(t, NFO{}.Resolve(ctx, &VideoInfo{}), ErrNotFound)
+}
+
+func TestFilenameResolver(t *testing.T) {
+	ctx := context.Background()
+	info := &VideoInfo{Path: "/tv/Stranger.Things.S04E01.Chapter.One.1080p.NF.WEB-DL.DDP5.1.x264-GalaxyTV.mkv"}
+	require.NoError(t, Filename{}.Resolve(ctx, info))
+	assert.Equal(t, Episode, info.Kind)
+	assert.Equal(t, "Stranger Things", info.Title)
+	assert.Equal(t, 4, info.Season)
+	assert.Equal(t, 1, info.Episode)
+
+	info = &VideoInfo{Path: "Blade Runner 2049 (2017) [2160p] BluRay x265.mkv", Title: "Blade Runner 2049: The Final Cut"}
+	require.NoError(t, Filename{}.Resolve(ctx, info))
+	assert.Equal(t, "Blade Runner 2049: The Final Cut", info.Title, "set fields are kept")
+	assert.Equal(t, 2017, info.Year)
+	assert.Equal(t, Movie, info.Kind)
+
+	assert.ErrorIs(t, Filename{}.Resolve(ctx, &VideoInfo{}), ErrNotFound)
+}
+
+func TestOpenSubtitlesResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/features", r.URL.Path)
+		switch r.URL.RawQuery {
+		case "query=Heat&type=movie&year=1995":
+			_, _ = w.Write([]byte(`{"data":[
+				{"id":"1","type":"feature","attributes":{"feature_type":"Tvshow","title":"Heat","year":"1995","imdb_id":1,"tmdb_id":2}},
+				{"id":"2","type":"feature","attributes":{"feature_type":"Movie","title":"Heat","year":"1995","imdb_id":113277,"tmdb_id":949}}]}`))
+		case "imdb_id=1":
+			_, _ = w.Write([]byte(`{"data":[{"id":"3","type":"feature","attributes":{"feature_type":"Movie","title":"Wrong","year":"2000","imdb_id":2}}]}`))
+		default:
+			t.Errorf("unexpected query %q", r.URL.RawQuery)
+		}
+	}))
+	defer server.Close()
+	client, err := opensubtitles.NewClient(opensubtitles.Config{ApiKey: "key", BaseURL: server.URL + "/api/v1", RateLimit: &opensubtitles.RateLimitConfig{}})
+	require.NoError(t, err)
+	resolver := &OpenSubtitles{Client: client}
+	ctx := context.Background()
+
+	info := &VideoInfo{Kind: Movie, Title: "Heat", Year: 1995}
+	require.NoError(t, resolver.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	assert.ErrorIs(t, resolver.Resolve(ctx, &VideoInfo{IMDbID: 1}), ErrNotFound, "features with other IDs are ignored")
+	assert.ErrorIs(t, resolver.Resolve(ctx, &VideoInfo{}), ErrNotFound)
+}
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/missing":
+			http.NotFound(w, r)
+		case "/denied":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"status_message":"Invalid API key"}`))
+		default:
+			assert.Equal(t, "value", r.Header.Get("X-Test"))
+			_, _ = w.Write([]byte(`{"id":1}`))
+		}
+	}))
+	defer server.Close()
+	ctx := context.Background()
+
+	var target struct{ ID int }
+	require.NoError(t, getJSON(ctx, server.Client(), server.URL+"/ok", http.Header{"X-Test": {"value"}}, &target))
+	assert.Equal(t, 1, target.ID)
+	assert.ErrorIs(t, getJSON(ctx, server.Client(), server.URL+"/missing", nil, &target), ErrNotFound)
+	err := getJSON(ctx, server.Client(), server.URL+"/denied", nil, &target)
+	assert.ErrorContains(t, err, "401 Unauthorized")
+	assert.ErrorContains(t, err, "Invalid API key")
+
+	server.Close()
+	err = getJSON(ctx, server.Client(), server.URL+"/search?api_key=secret-key&query=Heat", nil, &target)
+	require.Error(t, err)
+	assert.ErrorContains(t, err, server.URL+"/search")
+	assert.NotContains(t, err.Error(), "secret-key", "the API key is not leaked")
+}
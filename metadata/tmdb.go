@@ -0,0 +1,156 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultTMDBBaseURL is the TMDB API used when TMDB.BaseURL is not set.
+const DefaultTMDBBaseURL = "https://api.themoviedb.org/3"
+
+// TMDB resolves videos with The Movie Database API
+// (https://developer.themoviedb.org). It finds the TMDB entry by IMDb or TheTVDB ID,
+// or searches the title and year, then reads the entry's IMDb and TheTVDB IDs.
+// A TMDBID without a Kind is looked up as a movie, since movie and TV IDs overlap.
+type TMDB struct {
+	// APIKey is a v3 API key, sent as the api_key parameter. Prefer AccessToken,
+	// which keeps the credential out of URLs, e.g. in proxy logs.
+	APIKey string
+	// AccessToken is a v4 read access token, sent as a bearer token. It is used
+	// instead of APIKey if set.
+	AccessToken string
+	BaseURL     string       // Default: DefaultTMDBBaseURL
+	HTTPClient  *http.Client // Default: http.DefaultClient
+}
+
+// Name returns "tmdb".
+func (*TMDB) Name() string { return "tmdb" }
+
+// tmdbEntry is a movie or TV show in TMDB's search, find and details responses.
+type tmdbEntry struct {
+	ID           int    `json:"id"`
+	Title        string `json:"title"` // Movies
+	ReleaseDate  string `json:"release_date"`
+	Name         string `json:"name"` // TV shows
+	FirstAirDate string `json:"first_air_date"`
+	IMDbID       string `json:"imdb_id"` // Movie details
+	ExternalIDs  struct {
+		IMDbID string `json:"imdb_id"`
+		TVDBID int    `json:"tvdb_id"`
+	} `json:"external_ids"` // TV details with append_to_response=external_ids
+}
+
+// videoInfo converts e, a movie or a show depending on kind.
+func (e tmdbEntry) videoInfo(kind Kind) VideoInfo {
+	if kind == Episode {
+		return VideoInfo{Kind: kind, Title: e.Name, Year: parseYear(e.FirstAirDate), TMDBID: e.ID,
+			IMDbID: parseIMDbID(e.ExternalIDs.IMDbID), TVDBID: e.ExternalIDs.TVDBID}
+	}
+	return VideoInfo{Kind: kind, Title: e.Title, Year: parseYear(e.ReleaseDate), TMDBID: e.ID, IMDbID: parseIMDbID(e.IMDbID)}
+}
+
+// Resolve fills info from TMDB.
+func (t *TMDB) Resolve(ctx context.Context, info *VideoInfo) error {
+	if t.APIKey == "" && t.AccessToken == "" {
+		return errors.New("API key or access token is required")
+	}
+	if info.TMDBID == 0 {
+		entry, kind, err := t.find(ctx, info)
+		if err != nil {
+			return err
+		}
+		info.Merge(entry.videoInfo(kind))
+	}
+
+	if info.IMDbID != 0 && info.Title != "" && info.Year != 0 && (info.Kind != Episode || info.TVDBID != 0) {
+		return nil
+	}
+	kind := info.Kind
+	if kind == "" {
+		kind = Movie
+	}
+	var details tmdbEntry
+	path := "/movie/" + strconv.Itoa(info.TMDBID)
+	params := url.Values{}
+	if kind == Episode {
+		path = "/tv/" + strconv.Itoa(info.TMDBID)
+		params.Set("append_to_response", "external_ids")
+	}
+	if err := t.get(ctx, path, params, &details); err != nil {
+		return err
+	}
+	info.Merge(details.videoInfo(kind))
+	return nil
+}
+
+// find looks up the TMDB entry of info by external ID or title.
+func (t *TMDB) find(ctx context.Context, info *VideoInfo) (*tmdbEntry, Kind, error) {
+	var external, source string
+	switch {
+	case info.IMDbID != 0:
+		external, source = fmt.Sprintf("tt%07d", info.IMDbID), "imdb_id"
+	case info.TVDBID != 0:
+		external, source = strconv.Itoa(info.TVDBID), "tvdb_id"
+	}
+	if external != "" {
+		var found struct {
+			MovieResults []tmdbEntry `json:"movie_results"`
+			TVResults    []tmdbEntry `json:"tv_results"`
+		}
+		if err := t.get(ctx, "/find/"+external, url.Values{"external_source": {source}}, &found); err != nil {
+			return nil, "", err
+		}
+		if len(found.MovieResults) > 0 && info.Kind != Episode {
+			return &found.MovieResults[0], Movie, nil
+		}
+		if len(found.TVResults) > 0 && info.Kind != Movie {
+			return &found.TVResults[0], Episode, nil
+		}
+		return nil, "", ErrNotFound
+	}
+
+	if info.Title == "" {
+		return nil, "", ErrNotFound
+	}
+	kind, path, params := Movie, "/search/movie", url.Values{"query": {info.Title}}
+	if info.Kind == Episode {
+		kind, path = Episode, "/search/tv"
+	}
+	if info.Year > 0 {
+		if kind == Episode {
+			params.Set("first_air_date_year", strconv.Itoa(info.Year))
+		} else {
+			params.Set("year", strconv.Itoa(info.Year))
+		}
+	}
+	var search struct {
+		Results []tmdbEntry `json:"results"`
+	}
+	if err := t.get(ctx, path, params, &search); err != nil {
+		return nil, "", err
+	}
+	if len(search.Results) == 0 {
+		return nil, "", ErrNotFound
+	}
+	return &search.Results[0], kind, nil
+}
+
+// get sends an authenticated GET request to the TMDB API.
+func (t *TMDB) get(ctx context.Context, path string, params url.Values, target any) error {
+	base := t.BaseURL
+	if base == "" {
+		base = DefaultTMDBBaseURL
+	}
+	header := http.Header{}
+	if t.AccessToken != "" {
+		header.Set("Authorization", "Bearer "+t.AccessToken)
+	} else {
+		params.Set("api_key", t.APIKey)
+	}
+	return getJSON(ctx, t.HTTPClient, strings.TrimSuffix(base, "/")+path+"?"+params.Encode(), header, target)
+}
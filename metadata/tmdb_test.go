@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTMDBServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			assert.Equal(t, "key", r.URL.Query().Get("api_key"))
+		}
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/find/tt0113277":
+			assert.Equal(t, "imdb_id", query.Get("external_source"))
+			_, _ = w.Write([]byte(`{"movie_results":[{"id":949,"title":"Heat","release_date":"1995-12-15"}],"tv_results":[]}`))
+		case "/search/tv":
+			assert.Equal(t, "Breaking Bad", query.Get("query"))
+			assert.Equal(t, "2008", query.Get("first_air_date_year"))
+			_, _ = w.Write([]byte(`{"results":[{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20"}]}`))
+		case "/tv/1396":
+			assert.Equal(t, "external_ids", query.Get("append_to_response"))
+			_, _ = w.Write([]byte(`{"id":1396,"name":"Breaking Bad","first_air_date":"2008-01-20","external_ids":{"imdb_id":"tt0903747","tvdb_id":81189}}`))
+		case "/movie/949":
+			_, _ = w.Write([]byte(`{"id":949,"title":"Heat","release_date":"1995-12-15","imdb_id":"tt0113277"}`))
+		case "/search/movie":
+			_, _ = w.Write([]byte(`{"results":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestTMDBResolver(t *testing.T) {
+	server := newTMDBServer(t)
+	defer server.Close()
+	tmdb := &TMDB{APIKey: "key", BaseURL: server.URL}
+	ctx := context.Background()
+
+	// By IMDb ID: /find already gives everything a movie needs.
+	info := &VideoInfo{IMDbID: 113277}
+	require.NoError(t, tmdb.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	// By title: the details add the external IDs.
+	info = &VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2}
+	require.NoError(t, tmdb.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2, IMDbID: 903747, TMDBID: 1396, TVDBID: 81189}, info)
+
+	// By TMDB ID, authenticated with an access token.
+	info = &VideoInfo{TMDBID: 949}
+	require.NoError(t, (&TMDB{AccessToken: "token", BaseURL: server.URL}).Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	assert.ErrorIs(t, tmdb.Resolve(ctx, &VideoInfo{Title: "Nothing"}), ErrNotFound)
+	assert.ErrorIs(t, tmdb.Resolve(ctx, &VideoInfo{Kind: Episode, IMDbID: 113277}), ErrNotFound, "only a movie matches")
+	assert.ErrorIs(t, tmdb.Resolve(ctx, &VideoInfo{}), ErrNotFound)
+	assert.Error(t, (&TMDB{BaseURL: server.URL}).Resolve(ctx, &VideoInfo{Title: "Heat"}), "credentials are required")
+}
@@ -0,0 +1,102 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// DefaultTraktBaseURL is the Trakt API used when Trakt.BaseURL is not set.
+const DefaultTraktBaseURL = "https://api.trakt.tv"
+
+// Trakt resolves videos with the Trakt API (https://trakt.docs.apiary.io). A single
+// search by IMDb, TMDB or TheTVDB ID, or by title and year, returns all IDs.
+type Trakt struct {
+	// ClientID is the client ID of a Trakt API app, sent as the trakt-api-key header.
+	ClientID   string
+	BaseURL    string       // Default: DefaultTraktBaseURL
+	HTTPClient *http.Client // Default: http.DefaultClient
+}
+
+// Name returns "trakt".
+func (*Trakt) Name() string { return "trakt" }
+
+// traktItem is a movie or show in Trakt search results.
+type traktItem struct {
+	Title string `json:"title"`
+	Year  int    `json:"year"`
+	IDs   struct {
+		IMDb string `json:"imdb"`
+		TMDB int    `json:"tmdb"`
+		TVDB int    `json:"tvdb"`
+	} `json:"ids"`
+}
+
+// Resolve fills info from the first Trakt search result.
+func (t *Trakt) Resolve(ctx context.Context, info *VideoInfo) error {
+	if t.ClientID == "" {
+		return errors.New("client ID is required")
+	}
+	types := "movie,show"
+	switch info.Kind {
+	case Movie:
+		types = "movie"
+	case Episode:
+		types = "show"
+	}
+
+	var path string
+	params := url.Values{}
+	switch {
+	case info.IMDbID != 0:
+		path = fmt.Sprintf("/search/imdb/tt%07d", info.IMDbID)
+		params.Set("type", types)
+	case info.TMDBID != 0:
+		path = "/search/tmdb/" + strconv.Itoa(info.TMDBID)
+		params.Set("type", types)
+	case info.TVDBID != 0:
+		path = "/search/tvdb/" + strconv.Itoa(info.TVDBID)
+		params.Set("type", types)
+	case info.Title != "":
+		path = "/search/" + types
+		params.Set("query", info.Title)
+		if info.Year > 0 {
+			params.Set("years", strconv.Itoa(info.Year))
+		}
+	default:
+		return ErrNotFound
+	}
+
+	base := t.BaseURL
+	if base == "" {
+		base = DefaultTraktBaseURL
+	}
+	header := http.Header{}
+	header.Set("trakt-api-version", "2")
+	header.Set("trakt-api-key", t.ClientID)
+	var results []struct {
+		Type  string     `json:"type"`
+		Movie *traktItem `json:"movie"`
+		Show  *traktItem `json:"show"`
+	}
+	if err := getJSON(ctx, t.HTTPClient, strings.TrimSuffix(base, "/")+path+"?"+params.Encode(), header, &results); err != nil {
+		return err
+	}
+	for _, result := range results {
+		item, kind := result.Movie, Movie
+		if result.Type == "show" {
+			item, kind = result.Show, Episode
+		}
+		if item == nil {
+			continue
+		}
+		info.Merge(VideoInfo{Kind: kind, Title: item.Title, Year: item.Year,
+			IMDbID: parseIMDbID(item.IDs.IMDb), TMDBID: item.IDs.TMDB, TVDBID: item.IDs.TVDB})
+		return nil
+	}
+	return ErrNotFound
+}
@@ -0,0 +1,45 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraktResolver(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "2", r.Header.Get("trakt-api-version"))
+		assert.Equal(t, "client-id", r.Header.Get("trakt-api-key"))
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/search/imdb/tt0113277":
+			assert.Equal(t, "movie,show", query.Get("type"))
+			_, _ = w.Write([]byte(`[{"type":"movie","score":1000,"movie":{"title":"Heat","year":1995,"ids":{"trakt":614,"slug":"heat-1995","imdb":"tt0113277","tmdb":949}}}]`))
+		case "/search/show":
+			assert.Equal(t, "Breaking Bad", query.Get("query"))
+			assert.Equal(t, "2008", query.Get("years"))
+			_, _ = w.Write([]byte(`[{"type":"show","score":1000,"show":{"title":"Breaking Bad","year":2008,"ids":{"trakt":1388,"imdb":"tt0903747","tmdb":1396,"tvdb":81189}}}]`))
+		default:
+			_, _ = w.Write([]byte(`[]`))
+		}
+	}))
+	defer server.Close()
+	trakt := &Trakt{ClientID: "client-id", BaseURL: server.URL}
+	ctx := context.Background()
+
+	info := &VideoInfo{IMDbID: 113277}
+	require.NoError(t, trakt.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+
+	info = &VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2}
+	require.NoError(t, trakt.Resolve(ctx, info))
+	assert.Equal(t, &VideoInfo{Kind: Episode, Title: "Breaking Bad", Year: 2008, Season: 1, Episode: 2, IMDbID: 903747, TMDBID: 1396, TVDBID: 81189}, info)
+
+	assert.ErrorIs(t, trakt.Resolve(ctx, &VideoInfo{TMDBID: 1}), ErrNotFound)
+	assert.ErrorIs(t, trakt.Resolve(ctx, &VideoInfo{}), ErrNotFound)
+	assert.Error(t, (&Trakt{BaseURL: server.URL}).Resolve(ctx, &VideoInfo{IMDbID: 1}), "client ID is required")
+}
@@ -0,0 +1,41 @@
+package opensubtitles
+
+import (
+	"strings"
+	"time"
+)
+
+// Metrics receives measurements of the client's API usage, e.g. to export them to
+// Prometheus with the metrics/prometheus package. Implementations must be safe for
+// concurrent use and should return quickly, as they are called on the request path.
+type Metrics interface {
+	// IncRequests counts a finished API request. endpoint is the API path without
+	// parameters, e.g. "/subtitles" or "/subtitles/{id}"; statusCode is 0 if no response was received.
+	IncRequests(endpoint, method string, statusCode int)
+	// ObserveLatency records how long an API request took.
+	ObserveLatency(endpoint, method string, latency time.Duration)
+	// SetRemainingDownloads reports the download quota left, as returned by
+	// Download and GetUserInfo.
+	SetRemainingDownloads(remaining int)
+}
+
+// observeRequest forwards an API request measured by the HTTP client to m.
+func observeRequest(m Metrics) func(method, endpoint string, status int, latency time.Duration) {
+	return func(method, endpoint string, status int, latency time.Duration) {
+		endpoint = metricEndpoint(endpoint)
+		m.IncRequests(endpoint, method, status)
+		m.ObserveLatency(endpoint, method, latency)
+	}
+}
+
+// metricEndpoint replaces IDs in endpoint paths, e.g. "/subtitles/123" becomes
+// "/subtitles/{id}", so that metrics have one series per endpoint.
+func metricEndpoint(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if segment != "" && strings.Trim(segment, "0123456789") == "" {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
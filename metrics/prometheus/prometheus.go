@@ -0,0 +1,221 @@
+// Package prometheus exports the client's opensubtitles.Metrics in the Prometheus
+// text exposition format, without depending on the Prometheus client library:
+//
+//	collector := prometheus.New(prometheus.Options{})
+//	client, err := opensubtitles.NewClient(opensubtitles.Config{ApiKey: key, Metrics: collector})
+//	http.Handle("/metrics", collector)
+//
+// It exports these metrics, prefixed with Options.Namespace:
+//
+//	opensubtitles_requests_total{endpoint,method,code}          counter
+//	opensubtitles_request_duration_seconds{endpoint,method}     histogram
+//	opensubtitles_remaining_downloads                           gauge
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+)
+
+// DefaultNamespace prefixes the metric names when Options.Namespace is empty.
+const DefaultNamespace = "opensubtitles"
+
+// DefaultBuckets are the latency histogram bounds in seconds.
+var DefaultBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Options configures a Collector.
+type Options struct {
+	Namespace string    // Defaults to DefaultNamespace
+	Buckets   []float64 // Sorted upper bounds in seconds; defaults to DefaultBuckets
+}
+
+// Collector records opensubtitles.Metrics and serves them over HTTP. It is safe for
+// concurrent use.
+type Collector struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex // Protects the fields below
+	requests  map[requestKey]uint64
+	latencies map[latencyKey]*histogram
+	remaining *int // Nil until a quota was reported
+}
+
+// Ensure Collector implements opensubtitles.Metrics.
+var _ opensubtitles.Metrics = (*Collector)(nil)
+
+type requestKey struct {
+	endpoint, method string
+	code             int
+}
+
+type latencyKey struct {
+	endpoint, method string
+}
+
+type histogram struct {
+	counts []uint64 // Per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// New creates an empty Collector.
+func New(opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultNamespace
+	}
+	if len(opts.Buckets) == 0 {
+		opts.Buckets = DefaultBuckets
+	}
+	buckets := append([]float64(nil), opts.Buckets...)
+	sort.Float64s(buckets)
+	return &Collector{
+		namespace: opts.Namespace,
+		buckets:   buckets,
+		requests:  make(map[requestKey]uint64),
+		latencies: make(map[latencyKey]*histogram),
+	}
+}
+
+// IncRequests implements opensubtitles.Metrics.
+func (c *Collector) IncRequests(endpoint, method string, statusCode int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.requests[requestKey{endpoint, method, statusCode}]++
+}
+
+// ObserveLatency implements opensubtitles.Metrics.
+func (c *Collector) ObserveLatency(endpoint, method string, latency time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := latencyKey{endpoint, method}
+	h := c.latencies[key]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(c.buckets))}
+		c.latencies[key] = h
+	}
+	seconds := latency.Seconds()
+	if i := sort.SearchFloat64s(c.buckets, seconds); i < len(c.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += seconds
+}
+
+// SetRemainingDownloads implements opensubtitles.Metrics.
+func (c *Collector) SetRemainingDownloads(remaining int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.remaining = &remaining
+}
+
+// ServeHTTP writes the metrics in the Prometheus text exposition format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format, sorted by
+// label values so the output is stable.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+
+	name := c.namespace + "_requests_total"
+	fmt.Fprintf(cw, "# HELP %s API requests by endpoint, method and status code (0: no response).\n# TYPE %s counter\n", name, name)
+	requestKeys := make([]requestKey, 0, len(c.requests))
+	for key := range c.requests {
+		requestKeys = append(requestKeys, key)
+	}
+	sort.Slice(requestKeys, func(i, j int) bool {
+		a, b := requestKeys[i], requestKeys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		if a.method != b.method {
+			return a.method < b.method
+		}
+		return a.code < b.code
+	})
+	for _, key := range requestKeys {
+		fmt.Fprintf(cw, "%s{%s,code=\"%d\"} %d\n", name, labels(key.endpoint, key.method), key.code, c.requests[key])
+	}
+
+	name = c.namespace + "_request_duration_seconds"
+	fmt.Fprintf(cw, "# HELP %s API request latency.\n# TYPE %s histogram\n", name, name)
+	latencyKeys := make([]latencyKey, 0, len(c.latencies))
+	for key := range c.latencies {
+		latencyKeys = append(latencyKeys, key)
+	}
+	sort.Slice(latencyKeys, func(i, j int) bool {
+		a, b := latencyKeys[i], latencyKeys[j]
+		if a.endpoint != b.endpoint {
+			return a.endpoint < b.endpoint
+		}
+		return a.method < b.method
+	})
+	for _, key := range latencyKeys {
+		h, l := c.latencies[key], labels(key.endpoint, key.method)
+		var cumulative uint64
+		for i, bound := range c.buckets {
+			cumulative += h.counts[i]
+			fmt.Fprintf(cw, "%s_bucket{%s,le=\"%s\"} %d\n", name, l, formatFloat(bound), cumulative)
+		}
+		fmt.Fprintf(cw, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, l, h.count)
+		fmt.Fprintf(cw, "%s_sum{%s} %s\n", name, l, formatFloat(h.sum))
+		fmt.Fprintf(cw, "%s_count{%s} %d\n", name, l, h.count)
+	}
+
+	if c.remaining != nil {
+		name = c.namespace + "_remaining_downloads"
+		fmt.Fprintf(cw, "# HELP %s Download quota left, as last reported by the API.\n# TYPE %s gauge\n%s %d\n", name, name, name, *c.remaining)
+	}
+
+	if err := cw.w.Flush(); err != nil && cw.err == nil {
+		cw.err = err
+	}
+	return cw.n, cw.err
+}
+
+// labels formats the endpoint and method labels.
+func labels(endpoint, method string) string {
+	return fmt.Sprintf("endpoint=%s,method=%s", quote(endpoint), quote(method))
+}
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func quote(s string) string {
+	return `"` + labelEscaper.Replace(s) + `"`
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written and keeps the first error.
+type countingWriter struct {
+	w   *bufio.Writer
+	n   int64
+	err error
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	if cw.err != nil {
+		return 0, cw.err
+	}
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	cw.err = err
+	return n, err
+}
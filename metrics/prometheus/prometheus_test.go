@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/opensubtitlestest"
+	"github.com/angelospk/opensubtitles-go/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteTo(t *testing.T) {
+	c := New(Options{Namespace: "osub", Buckets: []float64{1, 0.1}})
+	c.IncRequests("/subtitles", "GET", 200)
+	c.IncRequests("/subtitles", "GET", 200)
+	c.IncRequests("/download", "POST", 406)
+	c.ObserveLatency("/subtitles", "GET", 50*time.Millisecond)
+	c.ObserveLatency("/subtitles", "GET", 500*time.Millisecond)
+	c.ObserveLatency("/subtitles", "GET", 3*time.Second)
+	c.SetRemainingDownloads(17)
+
+	var sb strings.Builder
+	n, err := c.WriteTo(&sb)
+	require.NoError(t, err)
+	assert.Equal(t, int64(sb.Len()), n)
+	assert.Equal(t, `# HELP osub_requests_total API requests by endpoint, method and status code (0: no response).
+# TYPE osub_requests_total counter
+osub_requests_total{endpoint="/download",method="POST",code="406"} 1
+osub_requests_total{endpoint="/subtitles",method="GET",code="200"} 2
+# HELP osub_request_duration_seconds API request latency.
+# TYPE osub_request_duration_seconds histogram
+osub_request_duration_seconds_bucket{endpoint="/subtitles",method="GET",le="0.1"} 1
+osub_request_duration_seconds_bucket{endpoint="/subtitles",method="GET",le="1"} 2
+osub_request_duration_seconds_bucket{endpoint="/subtitles",method="GET",le="+Inf"} 3
+osub_request_duration_seconds_sum{endpoint="/subtitles",method="GET"} 3.55
+osub_request_duration_seconds_count{endpoint="/subtitles",method="GET"} 3
+# HELP osub_remaining_downloads Download quota left, as last reported by the API.
+# TYPE osub_remaining_downloads gauge
+osub_remaining_downloads 17
+`, sb.String())
+}
+
+func TestCollectorWithClient(t *testing.T) {
+	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{DownloadQuota: 5})
+	t.Cleanup(srv.Close)
+	sub := testutil.NewSubtitle(testutil.SubtitleOptions{})
+	srv.AddSubtitle(sub, []byte("1\n00:00:01,000 --> 00:00:02,000\nHi\n"))
+	collector := New(Options{})
+	client, err := opensubtitles.NewClient(opensubtitles.Config{
+		ApiKey:    opensubtitlestest.APIKey,
+		BaseURL:   srv.BaseURL(),
+		RateLimit: &opensubtitles.RateLimitConfig{},
+		Metrics:   collector,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{})
+	require.NoError(t, err)
+	_, err = client.Download(ctx, opensubtitles.DownloadRequest{FileID: sub.Attributes.Files[0].FileID})
+	require.NoError(t, err)
+	_, err = client.Download(ctx, opensubtitles.DownloadRequest{FileID: 1})
+	require.Error(t, err)
+
+	rec := httptest.NewRecorder()
+	collector.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body := rec.Body.String()
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, body, `opensubtitles_requests_total{endpoint="/subtitles",method="GET",code="200"} 1`)
+	assert.Contains(t, body, `opensubtitles_requests_total{endpoint="/download",method="POST",code="200"} 1`)
+	assert.Contains(t, body, `opensubtitles_requests_total{endpoint="/download",method="POST",code="404"} 1`)
+	assert.Contains(t, body, `opensubtitles_request_duration_seconds_count{endpoint="/download",method="POST"} 2`)
+	assert.Contains(t, body, "opensubtitles_remaining_downloads 4\n")
+}
@@ -0,0 +1,90 @@
+package opensubtitles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingMetrics records the calls made to it.
+type recordingMetrics struct {
+	mu        sync.Mutex
+	requests  []string
+	latencies int
+	remaining []int
+}
+
+func (m *recordingMetrics) IncRequests(endpoint, method string, statusCode int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests = append(m.requests, fmt.Sprintf("%s %s %d", method, endpoint, statusCode))
+}
+
+func (m *recordingMetrics) ObserveLatency(endpoint, method string, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies++
+}
+
+func (m *recordingMetrics) SetRemainingDownloads(remaining int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.remaining = append(m.remaining, remaining)
+}
+
+func TestMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/download":
+			fmt.Fprint(w, `{"link":"https://example.com/sub.srt","remaining":9}`)
+		case "/api/v1/infos/user":
+			fmt.Fprint(w, `{"data":{"remaining_downloads":8}}`)
+		case "/api/v1/subtitles/123":
+			fmt.Fprint(w, `{"data":{"id":"123"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not found"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	metrics := &recordingMetrics{}
+	client, err := NewClient(Config{
+		ApiKey:    "test-api-key",
+		BaseURL:   server.URL + "/api/v1",
+		RateLimit: &testRateLimits,
+		Metrics:   metrics,
+	})
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	_, err = client.Download(ctx, DownloadRequest{FileID: 1})
+	require.NoError(t, err)
+	_, err = client.GetUserInfo(ctx)
+	require.NoError(t, err)
+	_, err = client.SearchFeatures(ctx, SearchFeaturesParams{Query: String("heat")})
+	require.ErrorIs(t, err, ErrNotFound)
+	_, err = client.GetSubtitleByID(ctx, "123")
+	require.NoError(t, err)
+
+	server.Close()
+	_, err = client.GetUserInfo(ctx)
+	require.Error(t, err)
+
+	assert.Equal(t, []string{
+		"POST /download 200",
+		"GET /infos/user 200",
+		"GET /features 404",
+		"GET /subtitles/{id} 200",
+		"GET /infos/user 0",
+	}, metrics.requests)
+	assert.Equal(t, 5, metrics.latencies)
+	assert.Equal(t, []int{9, 8}, metrics.remaining)
+}
@@ -0,0 +1,163 @@
+// Package naming contains helpers for choosing where downloaded subtitle files are written.
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/envconfig"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// Policy selects how a CollisionResolver handles a destination path that already exists.
+type Policy string
+
+const (
+	// PolicyOverwrite replaces the existing file (the behaviour of the examples).
+	PolicyOverwrite Policy = "overwrite"
+	// PolicyKeepBoth writes the new file next to the existing one with a numeric suffix.
+	PolicyKeepBoth Policy = "keep-both"
+	// PolicyPreferHigherScore replaces the existing file only if the incoming one scores higher.
+	// If the score of the existing file is unknown it behaves like PolicyKeepBoth.
+	PolicyPreferHigherScore Policy = "prefer-higher-score"
+	// PolicyAsk delegates the decision to CollisionResolver.Ask.
+	PolicyAsk Policy = "ask"
+)
+
+// PolicyFromEnv reads the COLLISION_POLICY variable of l, defaulting to PolicyOverwrite.
+func PolicyFromEnv(l *envconfig.Loader) Policy {
+	return Policy(l.OneOf("COLLISION_POLICY", string(PolicyOverwrite),
+		string(PolicyOverwrite), string(PolicyKeepBoth), string(PolicyPreferHigherScore), string(PolicyAsk)))
+}
+
+// Action is the outcome of resolving a destination path.
+type Action string
+
+const (
+	ActionWrite Action = "write" // Write the file to Resolution.Path
+	ActionSkip  Action = "skip"  // Keep the existing file and discard the incoming one
+)
+
+// Errors returned by CollisionResolver.
+var (
+	ErrUnknownPolicy = errors.New("naming: unknown collision policy")
+	ErrNoAskCallback = errors.New("naming: ask policy requires an Ask callback")
+	ErrTooManyCopies = errors.New("naming: no free suffixed filename available")
+)
+
+// maxSuffix bounds the numeric suffix search of PolicyKeepBoth.
+const maxSuffix = 999
+
+// Incoming describes a subtitle about to be saved.
+type Incoming struct {
+	Path     string  // Desired destination path
+	Language string  // Language code of the subtitle, e.g. "en"
+	Variant  string  // Optional variant such as "hi" or "forced"
+	Score    float64 // Match score of the subtitle, used by PolicyPreferHigherScore
+}
+
+// Resolution records how a collision was resolved, so callers can log or persist it.
+type Resolution struct {
+	Action    Action
+	Path      string // Final destination when Action is ActionWrite
+	Collision bool   // True if the desired destination already existed
+	Reason    string
+}
+
+// CollisionResolver applies a Policy when saving a subtitle would overwrite an existing file.
+type CollisionResolver struct {
+	Policy Policy
+	// Ask is consulted by PolicyAsk with the existing path and the incoming subtitle.
+	Ask func(existingPath string, incoming Incoming) (Resolution, error)
+	// ExistingScore returns the score recorded for an existing file, if known.
+	ExistingScore func(path string) (score float64, ok bool)
+	// FS is used to check for existing files. Defaults to vfs.OS.
+	FS vfs.FS
+}
+
+// Resolve decides where (and whether) the incoming subtitle should be written.
+func (r CollisionResolver) Resolve(in Incoming) (Resolution, error) {
+	fsys := r.FS
+	if fsys == nil {
+		fsys = vfs.OS
+	}
+	exists, err := fileExists(fsys, in.Path)
+	if err != nil {
+		return Resolution{}, err
+	}
+	if !exists {
+		return Resolution{Action: ActionWrite, Path: in.Path, Reason: "destination is free"}, nil
+	}
+
+	switch r.Policy {
+	case PolicyOverwrite, "":
+		return Resolution{Action: ActionWrite, Path: in.Path, Collision: true, Reason: "overwrite existing file"}, nil
+	case PolicyKeepBoth:
+		return keepBoth(fsys, in.Path, "keep both files")
+	case PolicyPreferHigherScore:
+		if r.ExistingScore == nil {
+			return keepBoth(fsys, in.Path, "existing score unknown, keeping both")
+		}
+		existingScore, ok := r.ExistingScore(in.Path)
+		if !ok {
+			return keepBoth(fsys, in.Path, "existing score unknown, keeping both")
+		}
+		if in.Score > existingScore {
+			return Resolution{
+				Action:    ActionWrite,
+				Path:      in.Path,
+				Collision: true,
+				Reason:    fmt.Sprintf("incoming score %.2f beats existing %.2f", in.Score, existingScore),
+			}, nil
+		}
+		return Resolution{
+			Action:    ActionSkip,
+			Path:      in.Path,
+			Collision: true,
+			Reason:    fmt.Sprintf("existing score %.2f is not lower than incoming %.2f", existingScore, in.Score),
+		}, nil
+	case PolicyAsk:
+		if r.Ask == nil {
+			return Resolution{}, ErrNoAskCallback
+		}
+		res, err := r.Ask(in.Path, in)
+		if err != nil {
+			return Resolution{}, fmt.Errorf("naming: ask callback failed: %w", err)
+		}
+		res.Collision = true
+		return res, nil
+	default:
+		return Resolution{}, fmt.Errorf("%w: %q", ErrUnknownPolicy, r.Policy)
+	}
+}
+
+// keepBoth finds the first free "name.N.ext" path next to path.
+func keepBoth(fsys vfs.FS, path, reason string) (Resolution, error) {
+	ext := filepath.Ext(path)
+	stem := strings.TrimSuffix(path, ext)
+	for i := 1; i <= maxSuffix; i++ {
+		candidate := fmt.Sprintf("%s.%d%s", stem, i, ext)
+		exists, err := fileExists(fsys, candidate)
+		if err != nil {
+			return Resolution{}, err
+		}
+		if !exists {
+			return Resolution{Action: ActionWrite, Path: candidate, Collision: true, Reason: reason}, nil
+		}
+	}
+	return Resolution{}, fmt.Errorf("%w for '%s'", ErrTooManyCopies, path)
+}
+
+func fileExists(fsys vfs.FS, path string) (bool, error) {
+	_, err := fsys.Stat(path)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	return false, fmt.Errorf("naming: failed to stat '%s': %w", path, err)
+}
@@ -0,0 +1,97 @@
+package naming
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func touch(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte("1\n00:00:01,000 --> 00:00:02,000\nHi\n"), 0o644))
+}
+
+func TestCollisionResolver(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "Movie.en.srt")
+
+	t.Run("FreeDestination", func(t *testing.T) {
+		res, err := CollisionResolver{Policy: PolicyKeepBoth}.Resolve(Incoming{Path: filepath.Join(dir, "Free.srt")})
+		require.NoError(t, err)
+		assert.Equal(t, ActionWrite, res.Action)
+		assert.False(t, res.Collision)
+	})
+
+	touch(t, target)
+
+	t.Run("Overwrite", func(t *testing.T) {
+		res, err := CollisionResolver{Policy: PolicyOverwrite}.Resolve(Incoming{Path: target})
+		require.NoError(t, err)
+		assert.Equal(t, ActionWrite, res.Action)
+		assert.Equal(t, target, res.Path)
+		assert.True(t, res.Collision)
+	})
+
+	t.Run("KeepBoth", func(t *testing.T) {
+		res, err := CollisionResolver{Policy: PolicyKeepBoth}.Resolve(Incoming{Path: target})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "Movie.en.1.srt"), res.Path)
+
+		touch(t, res.Path)
+		res, err = CollisionResolver{Policy: PolicyKeepBoth}.Resolve(Incoming{Path: target})
+		require.NoError(t, err)
+		assert.Equal(t, filepath.Join(dir, "Movie.en.2.srt"), res.Path)
+	})
+
+	t.Run("PreferHigherScore", func(t *testing.T) {
+		r := CollisionResolver{
+			Policy:        PolicyPreferHigherScore,
+			ExistingScore: func(string) (float64, bool) { return 5, true },
+		}
+		res, err := r.Resolve(Incoming{Path: target, Score: 7})
+		require.NoError(t, err)
+		assert.Equal(t, ActionWrite, res.Action)
+		assert.Equal(t, target, res.Path)
+
+		res, err = r.Resolve(Incoming{Path: target, Score: 3})
+		require.NoError(t, err)
+		assert.Equal(t, ActionSkip, res.Action)
+
+		r.ExistingScore = nil
+		res, err = r.Resolve(Incoming{Path: target, Score: 3})
+		require.NoError(t, err)
+		assert.Equal(t, ActionWrite, res.Action)
+		assert.NotEqual(t, target, res.Path, "unknown score should fall back to keep-both")
+	})
+
+	t.Run("Ask", func(t *testing.T) {
+		_, err := CollisionResolver{Policy: PolicyAsk}.Resolve(Incoming{Path: target})
+		assert.ErrorIs(t, err, ErrNoAskCallback)
+
+		r := CollisionResolver{
+			Policy: PolicyAsk,
+			Ask: func(existing string, in Incoming) (Resolution, error) {
+				assert.Equal(t, target, existing)
+				assert.Equal(t, "el", in.Language)
+				return Resolution{Action: ActionSkip, Reason: "user declined"}, nil
+			},
+		}
+		res, err := r.Resolve(Incoming{Path: target, Language: "el"})
+		require.NoError(t, err)
+		assert.Equal(t, ActionSkip, res.Action)
+		assert.True(t, res.Collision)
+
+		r.Ask = func(string, Incoming) (Resolution, error) { return Resolution{}, errors.New("boom") }
+		_, err = r.Resolve(Incoming{Path: target})
+		assert.Error(t, err)
+	})
+
+	t.Run("UnknownPolicy", func(t *testing.T) {
+		_, err := CollisionResolver{Policy: "bogus"}.Resolve(Incoming{Path: target})
+		assert.ErrorIs(t, err, ErrUnknownPolicy)
+	})
+}
@@ -0,0 +1,221 @@
+package naming
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// Template is a subtitle file name pattern with {placeholder} fields, expanded with
+// the values of Fields:
+//
+//	{basename}  video file name without extension
+//	{title}     title of the movie or show
+//	{year}      release year
+//	{season}    season number, two digits
+//	{episode}   episode number, two digits
+//	{lang}      language code
+//	{hi}        "hi" for hearing impaired subtitles
+//	{forced}    "forced" for subtitles of foreign parts only
+//	{ext}       file extension without the dot (default "srt")
+//
+// A placeholder without a value is dropped with the dots, spaces, dashes or
+// underscores before it, so "{basename}.{lang}.{forced}.{ext}" becomes
+// "Heat.en.srt" for a subtitle that is not forced.
+type Template string
+
+// DefaultTemplate names subtitles like "Heat.1995.1080p.BluRay.x264-GROUP.en.srt".
+const DefaultTemplate Template = "{basename}.{lang}.{hi}.{forced}.{ext}"
+
+// ErrInvalidTemplate is returned for templates with unknown or unclosed placeholders.
+var ErrInvalidTemplate = errors.New("naming: invalid template")
+
+// MaxNameLength is the length in bytes most file systems allow for a file name.
+const MaxNameLength = 255
+
+// Fields are the values of the placeholders of a Template.
+type Fields struct {
+	Basename string
+	Title    string
+	Year     int
+	Season   int
+	Episode  int
+	Lang     string
+	HI       bool
+	Forced   bool
+	Ext      string // Without the dot; defaults to "srt"
+}
+
+// values returns the placeholder values of f.
+func (f Fields) values() map[string]string {
+	number := func(n int, format string) string {
+		if n <= 0 {
+			return ""
+		}
+		return fmt.Sprintf(format, n)
+	}
+	flag := func(set bool, value string) string {
+		if set {
+			return value
+		}
+		return ""
+	}
+	ext := strings.TrimPrefix(f.Ext, ".")
+	if ext == "" {
+		ext = "srt"
+	}
+	return map[string]string{
+		"basename": f.Basename,
+		"title":    f.Title,
+		"year":     number(f.Year, "%d"),
+		"season":   number(f.Season, "%02d"),
+		"episode":  number(f.Episode, "%02d"),
+		"lang":     f.Lang,
+		"hi":       flag(f.HI, "hi"),
+		"forced":   flag(f.Forced, "forced"),
+		"ext":      ext,
+	}
+}
+
+// Validate checks that every placeholder of t is known and closed.
+func (t Template) Validate() error {
+	_, err := t.Expand(Fields{Basename: "x"})
+	return err
+}
+
+// Expand returns the file name t describes for f. The characters of every value are
+// sanitized as by Sanitize first, so a title such as "Mission: Impossible" cannot add directories or
+// characters Windows rejects; the whole name is sanitized and shortened to
+// MaxNameLength bytes, keeping the extension, last.
+func (t Template) Expand(f Fields) (string, error) {
+	values := f.values()
+	rest := string(t)
+	var b strings.Builder
+	for rest != "" {
+		open := strings.IndexByte(rest, '{')
+		if open < 0 {
+			b.WriteString(rest)
+			break
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("%w: unclosed placeholder in %q", ErrInvalidTemplate, t)
+		}
+		end += open
+		b.WriteString(rest[:open])
+		name := rest[open+1 : end]
+		value, ok := values[name]
+		if !ok {
+			return "", fmt.Errorf("%w: unknown placeholder {%s} in %q", ErrInvalidTemplate, name, t)
+		}
+		if value = sanitizeChars(value); value == "" {
+			trimmed := strings.TrimRight(b.String(), ". -_")
+			b.Reset()
+			b.WriteString(trimmed)
+		}
+		b.WriteString(value)
+		rest = rest[end+1:]
+	}
+	name := Sanitize(strings.TrimLeft(b.String(), ". -_"))
+	if name == "" {
+		return "", fmt.Errorf("%w: %q expands to an empty name", ErrInvalidTemplate, t)
+	}
+	return truncateName(name), nil
+}
+
+// Sidecar returns the path of the subtitle of the video at videoPath: t expanded in
+// the video's directory, with Fields.Basename defaulting to the video's file name
+// without extension.
+func (t Template) Sidecar(videoPath string, f Fields) (string, error) {
+	if f.Basename == "" {
+		base := filepath.Base(videoPath)
+		f.Basename = strings.TrimSuffix(base, filepath.Ext(base))
+	}
+	name, err := t.Expand(f)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(videoPath), name), nil
+}
+
+// Resolve expands t for the video at videoPath and resolves collisions of the result
+// with r, e.g. adding a numeric suffix under PolicyKeepBoth.
+func (t Template) Resolve(r CollisionResolver, videoPath string, f Fields, score float64) (Resolution, error) {
+	path, err := t.Sidecar(videoPath, f)
+	if err != nil {
+		return Resolution{}, err
+	}
+	in := Incoming{Path: path, Language: f.Lang, Score: score}
+	switch {
+	case f.Forced:
+		in.Variant = "forced"
+	case f.HI:
+		in.Variant = "hi"
+	}
+	return r.Resolve(in)
+}
+
+// windowsReserved are the device names Windows does not allow as file names, with or
+// without extension.
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// Sanitize makes name safe as a single file name on Linux, macOS and Windows: path
+// separators and colons become dashes, the other characters Windows rejects and
+// control characters are removed, runs of spaces are collapsed, trailing dots and
+// spaces are trimmed and Windows device names such as "CON" get a trailing underscore.
+func Sanitize(name string) string {
+	name = sanitizeChars(name)
+	stem, _, _ := strings.Cut(name, ".")
+	if windowsReserved[strings.ToUpper(stem)] {
+		name = stem + "_" + name[len(stem):]
+	}
+	return name
+}
+
+// sanitizeChars is Sanitize without the device name check, for placeholder values.
+func sanitizeChars(name string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r):
+			if space {
+				continue
+			}
+			r = ' '
+		case r == '/' || r == '\\' || r == ':':
+			r = '-'
+		case strings.ContainsRune(`<>"|?*`, r) || unicode.IsControl(r):
+			continue
+		}
+		space = r == ' '
+		b.WriteRune(r)
+	}
+	return strings.TrimRight(strings.TrimSpace(b.String()), ". ")
+}
+
+// truncateName shortens name to MaxNameLength bytes, cutting the part before the
+// extension at a character boundary.
+func truncateName(name string) string {
+	if len(name) <= MaxNameLength {
+		return name
+	}
+	ext := filepath.Ext(name)
+	stem := name[:len(name)-len(ext)]
+	limit := MaxNameLength - len(ext)
+	for limit > 0 && !utf8Start(stem[limit]) {
+		limit--
+	}
+	return strings.TrimRight(stem[:limit], ". ") + ext
+}
+
+// utf8Start reports whether b starts a UTF-8 encoded character.
+func utf8Start(b byte) bool {
+	return b&0xC0 != 0x80
+}
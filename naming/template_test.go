@@ -0,0 +1,92 @@
+package naming
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplateExpand(t *testing.T) {
+	tests := []struct {
+		template Template
+		fields   Fields
+		want     string
+	}{
+		{DefaultTemplate, Fields{Basename: "Heat.1995", Lang: "en"}, "Heat.1995.en.srt"},
+		{DefaultTemplate, Fields{Basename: "Heat.1995", Lang: "en", Forced: true}, "Heat.1995.en.forced.srt"},
+		{DefaultTemplate, Fields{Basename: "Heat.1995", Lang: "en", HI: true, Ext: ".ass"}, "Heat.1995.en.hi.ass"},
+		{"{title} ({year}).{lang}.{ext}", Fields{Title: "Heat", Year: 1995, Lang: "el"}, "Heat (1995).el.srt"},
+		{"{title} - S{season}E{episode}.{lang}.{ext}", Fields{Title: "Show", Season: 1, Episode: 2, Lang: "en"}, "Show - S01E02.en.srt"},
+		{"{title}.{year}.{lang}.{ext}", Fields{Title: "Heat", Lang: "en"}, "Heat.en.srt"},
+		{"{title}.{lang}.{ext}", Fields{Title: `Mission: Impossible / "Fallout"?`, Lang: "en"}, "Mission- Impossible - Fallout.en.srt"},
+		{"{title}.{lang}.{ext}", Fields{Title: "../../etc/passwd", Lang: "en"}, "etc-passwd.en.srt"},
+		{"{title}.{lang}.{ext}", Fields{Title: "Con", Lang: "en"}, "Con_.en.srt"},
+		{"{title} ({year}).{ext}", Fields{Title: "Con Air", Year: 1997}, "Con Air (1997).srt"},
+	}
+	for _, tt := range tests {
+		got, err := tt.template.Expand(tt.fields)
+		require.NoError(t, err, tt.template)
+		assert.Equal(t, tt.want, got, tt.template)
+	}
+
+	for _, template := range []Template{"{name}.srt", "{title", "{title}.{lang}"} {
+		_, err := template.Expand(Fields{})
+		assert.ErrorIs(t, err, ErrInvalidTemplate, template)
+	}
+	assert.NoError(t, DefaultTemplate.Validate())
+	assert.ErrorIs(t, Template("{basename}.{language}.srt").Validate(), ErrInvalidTemplate)
+
+	long, err := Template("{title}.{lang}.{ext}").Expand(Fields{Title: strings.Repeat("é", 200), Lang: "en"})
+	require.NoError(t, err)
+	assert.LessOrEqual(t, len(long), MaxNameLength)
+	assert.True(t, strings.HasSuffix(long, "é.srt"), "cut at a character boundary, extension kept")
+}
+
+func TestSanitize(t *testing.T) {
+	tests := map[string]string{
+		"Heat":                 "Heat",
+		"AC/DC: Live":          "AC-DC- Live",
+		"What?<>|*\"":          "What",
+		"Tabs\tand  \n spaces": "Tabs and spaces",
+		"Trailing dots...":     "Trailing dots",
+		"CON":                  "CON_",
+		"con.en.srt":           "con_.en.srt",
+		"Console.en.srt":       "Console.en.srt",
+		"bell\a":               "bell",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, Sanitize(in), in)
+	}
+}
+
+func TestTemplateSidecarAndResolve(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.mkv")
+
+	path, err := DefaultTemplate.Sidecar(video, Fields{Lang: "en"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.en.srt"), path)
+
+	touch(t, path)
+	var asked Incoming
+	r := CollisionResolver{Policy: PolicyAsk, Ask: func(existing string, in Incoming) (Resolution, error) {
+		asked = in
+		return Resolution{Action: ActionSkip, Path: existing}, nil
+	}}
+	res, err := DefaultTemplate.Resolve(r, video, Fields{Lang: "en"}, 7)
+	require.NoError(t, err)
+	assert.Equal(t, ActionSkip, res.Action)
+	assert.Equal(t, Incoming{Path: path, Language: "en", Score: 7}, asked)
+
+	res, err = DefaultTemplate.Resolve(CollisionResolver{Policy: PolicyKeepBoth}, video, Fields{Lang: "en"}, 0)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.en.1.srt"), res.Path)
+
+	res, err = DefaultTemplate.Resolve(CollisionResolver{}, video, Fields{Lang: "en", Forced: true}, 0)
+	require.NoError(t, err)
+	assert.False(t, res.Collision)
+	assert.Equal(t, filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.en.forced.srt"), res.Path)
+}
@@ -0,0 +1,225 @@
+// Package nfo reads the .nfo files media managers and release groups place next to
+// videos. It understands the XML files written by Kodi, Jellyfin and Emby (<movie>,
+// <tvshow> and <episodedetails>) and plain-text NFOs that link to IMDb, TMDB or TheTVDB:
+//
+//	info, err := nfo.ParseFile("Movies/Heat (1995)/movie.nfo")
+//	// info.IMDbID == 113277, info.Title == "Heat", info.Year == 1995
+package nfo
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrNoMetadata is returned when an NFO contains no IDs, titles, years or numbers.
+var ErrNoMetadata = errors.New("nfo: no metadata found")
+
+// MaxSize is the number of bytes ParseFile and ParseReader read; NFOs are small, so
+// anything larger is cut off.
+const MaxSize = 1 << 20
+
+// Kind is the type of video an NFO describes.
+type Kind string
+
+const (
+	Movie   Kind = "movie"
+	TVShow  Kind = "tvshow"
+	Episode Kind = "episode"
+)
+
+// Info is the metadata read from an NFO. Zero values mean the NFO does not say.
+type Info struct {
+	Kind      Kind   // Empty if the NFO gives no hint
+	Title     string // Movie, show or episode title; only read from XML NFOs
+	ShowTitle string // Show of an episode
+	Year      int
+	Season    int
+	Episode   int
+	IMDbID    int // Without the "tt" prefix, e.g. 113277
+	TMDBID    int
+	TVDBID    int
+}
+
+// ParseFile reads and parses the NFO at path.
+func ParseFile(path string) (*Info, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	info, err := ParseReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w in %s", err, path)
+	}
+	return info, nil
+}
+
+// ParseReader parses an NFO read from r, up to MaxSize bytes.
+func ParseReader(r io.Reader) (*Info, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize))
+	if err != nil {
+		return nil, fmt.Errorf("nfo: failed to read: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses an NFO. XML fields take precedence; IDs, the kind and season and
+// episode numbers missing from them are taken from links and patterns such as
+// "tt0113277" or "S01E02" anywhere in the text, so plain-text NFOs and Kodi's
+// "XML followed by a URL" files work too.
+func Parse(data []byte) (*Info, error) {
+	info := &Info{}
+	parseXML(data, info)
+	parseText(data, info)
+	if *info == (Info{Kind: info.Kind}) {
+		return nil, ErrNoMetadata
+	}
+	return info, nil
+}
+
+// kodiNFO covers the fields of Kodi's movie, tvshow and episodedetails NFOs.
+type kodiNFO struct {
+	XMLName   xml.Name
+	Title     string `xml:"title"`
+	ShowTitle string `xml:"showtitle"`
+	Year      string `xml:"year"`
+	Premiered string `xml:"premiered"`
+	Aired     string `xml:"aired"`
+	Season    string `xml:"season"`
+	Episode   string `xml:"episode"`
+	ID        string `xml:"id"`
+	IMDbID    string `xml:"imdbid"`
+	IMDb      string `xml:"imdb_id"`
+	TMDBID    string `xml:"tmdbid"`
+	TVDBID    string `xml:"tvdbid"`
+	UniqueIDs []struct {
+		Type  string `xml:"type,attr"`
+		Value string `xml:",chardata"`
+	} `xml:"uniqueid"`
+}
+
+// parseXML fills info from the first XML element of data, if it is a known NFO root.
+func parseXML(data []byte, info *Info) {
+	start := bytes.IndexByte(data, '<')
+	if start < 0 {
+		return
+	}
+	var doc kodiNFO
+	decoder := xml.NewDecoder(bytes.NewReader(data[start:]))
+	decoder.Strict = false
+	decoder.CharsetReader = func(_ string, r io.Reader) (io.Reader, error) { return r, nil }
+	if decoder.Decode(&doc) != nil {
+		return
+	}
+	switch doc.XMLName.Local {
+	case "movie":
+		info.Kind = Movie
+	case "tvshow":
+		info.Kind = TVShow
+	case "episodedetails":
+		info.Kind = Episode
+	default:
+		return
+	}
+
+	info.Title = clean(doc.Title)
+	info.ShowTitle = clean(doc.ShowTitle)
+	info.Year = number(doc.Year)
+	for _, date := range []string{doc.Premiered, doc.Aired} {
+		if year, _, ok := strings.Cut(strings.TrimSpace(date), "-"); ok && info.Year == 0 {
+			info.Year = number(year)
+		}
+	}
+	info.Season = number(doc.Season)
+	info.Episode = number(doc.Episode)
+
+	for _, id := range doc.UniqueIDs {
+		switch strings.ToLower(id.Type) {
+		case "imdb":
+			setOnce(&info.IMDbID, imdbNumber(id.Value))
+		case "tmdb":
+			setOnce(&info.TMDBID, number(id.Value))
+		case "tvdb":
+			setOnce(&info.TVDBID, number(id.Value))
+		}
+	}
+	setOnce(&info.IMDbID, imdbNumber(doc.IMDbID))
+	setOnce(&info.IMDbID, imdbNumber(doc.IMDb))
+	setOnce(&info.TMDBID, number(doc.TMDBID))
+	setOnce(&info.TVDBID, number(doc.TVDBID))
+	// <id> holds the IMDb ID of movies and, for older TV scrapers, the TheTVDB ID.
+	if id := strings.TrimSpace(doc.ID); strings.HasPrefix(strings.ToLower(id), "tt") {
+		setOnce(&info.IMDbID, imdbNumber(id))
+	} else if info.Kind != Movie {
+		setOnce(&info.TVDBID, number(id))
+	}
+}
+
+var (
+	imdbURLPattern = regexp.MustCompile(`(?i)imdb\.com/title/tt0*([1-9][0-9]{0,9})`)
+	imdbIDPattern  = regexp.MustCompile(`(?i)\btt0*([1-9][0-9]{0,9})\b`)
+	tmdbURLPattern = regexp.MustCompile(`(?i)themoviedb\.org/(movie|tv)/([0-9]{1,10})`)
+	tvdbURLPattern = regexp.MustCompile(`(?i)thetvdb\.com/(?:\S*?[?&](?:id|seriesid)=|dereferrer/series/|series/)([0-9]{1,10})\b`)
+	episodePattern = regexp.MustCompile(`(?i)\bs([0-9]{1,2})e([0-9]{1,3})\b`)
+)
+
+// parseText fills the fields info lacks from links and patterns in the raw text.
+func parseText(data []byte, info *Info) {
+	if m := imdbURLPattern.FindSubmatch(data); m != nil {
+		setOnce(&info.IMDbID, number(string(m[1])))
+	} else if m := imdbIDPattern.FindSubmatch(data); m != nil {
+		setOnce(&info.IMDbID, number(string(m[1])))
+	}
+	if m := tmdbURLPattern.FindSubmatch(data); m != nil {
+		setOnce(&info.TMDBID, number(string(m[2])))
+		if info.Kind == "" && strings.EqualFold(string(m[1]), "movie") {
+			info.Kind = Movie
+		}
+	}
+	if m := tvdbURLPattern.FindSubmatch(data); m != nil {
+		setOnce(&info.TVDBID, number(string(m[1])))
+	}
+	if m := episodePattern.FindSubmatch(data); m != nil && info.Season == 0 && info.Episode == 0 {
+		info.Season, info.Episode = number(string(m[1])), number(string(m[2]))
+		if info.Kind == "" {
+			info.Kind = Episode
+		}
+	}
+}
+
+// setOnce sets *dst to v unless it is already set.
+func setOnce(dst *int, v int) {
+	if *dst == 0 {
+		*dst = v
+	}
+}
+
+// number parses a non-negative integer, returning 0 for anything else.
+func number(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// imdbNumber parses "tt0113277" or "113277".
+func imdbNumber(s string) int {
+	s = strings.TrimSpace(s)
+	if len(s) > 2 && strings.EqualFold(s[:2], "tt") {
+		s = s[2:]
+	}
+	return number(s)
+}
+
+// clean collapses whitespace in a title.
+func clean(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,109 @@
+package nfo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKodiMovie(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8" standalone="yes" ?>
+<movie>
+    <title>Heat</title>
+    <year>1995</year>
+    <uniqueid type="imdb" default="true">tt0113277</uniqueid>
+    <uniqueid type="tmdb">949</uniqueid>
+</movie>`
+	info, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{Kind: Movie, Title: "Heat", Year: 1995, IMDbID: 113277, TMDBID: 949}, info)
+}
+
+func TestParseKodiEpisode(t *testing.T) {
+	data := `<episodedetails>
+  <title>Grilled</title>
+  <showtitle>Breaking Bad</showtitle>
+  <season>2</season>
+  <episode>2</episode>
+  <aired>2009-03-15</aired>
+  <uniqueid type="tvdb">438906</uniqueid>
+  <uniqueid type="imdb">tt1232248</uniqueid>
+</episodedetails>`
+	info, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{Kind: Episode, Title: "Grilled", ShowTitle: "Breaking Bad", Year: 2009, Season: 2, Episode: 2, IMDbID: 1232248, TVDBID: 438906}, info)
+}
+
+func TestParseLegacyIDs(t *testing.T) {
+	show, err := Parse([]byte("<tvshow><title>Breaking Bad</title><id>81189</id><imdbid>tt0903747</imdbid></tvshow>"))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{Kind: TVShow, Title: "Breaking Bad", IMDbID: 903747, TVDBID: 81189}, show)
+
+	movie, err := Parse([]byte("<movie><title>Heat</title><id>tt0113277</id></movie>"))
+	require.NoError(t, err)
+	assert.Equal(t, 113277, movie.IMDbID)
+}
+
+func TestParseXMLWithURL(t *testing.T) {
+	data := "<movie><title>Heat</title></movie>\nhttps://www.themoviedb.org/movie/949-heat\n"
+	info, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{Kind: Movie, Title: "Heat", TMDBID: 949}, info)
+}
+
+func TestParsePlainText(t *testing.T) {
+	data := `
+    ░▒▓ GROUP PRESENTS ▓▒░
+  Release: Breaking.Bad.S02E02.720p.HDTV.x264
+  IMDb...: https://www.imdb.com/title/tt0903747/
+  TVDB...: https://thetvdb.com/?tab=series&id=81189
+`
+	info, err := Parse([]byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{Kind: Episode, Season: 2, Episode: 2, IMDbID: 903747, TVDBID: 81189}, info)
+
+	info, err = Parse([]byte("Heat (1995) tt0113277"))
+	require.NoError(t, err)
+	assert.Equal(t, &Info{IMDbID: 113277}, info)
+}
+
+func TestParseNoMetadata(t *testing.T) {
+	for _, data := range []string{"", "just some text", "<movie></movie>", "<html><title>x</title></html>"} {
+		_, err := Parse([]byte(data))
+		assert.ErrorIs(t, err, ErrNoMetadata, data)
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movie.nfo")
+	require.NoError(t, os.WriteFile(path, []byte("<movie><title>Heat</title></movie>"), 0o644))
+	info, err := ParseFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "Heat", info.Title)
+
+	_, err = ParseFile(filepath.Join(t.TempDir(), "missing.nfo"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = ParseReader(strings.NewReader("nothing"))
+	assert.ErrorIs(t, err, ErrNoMetadata)
+}
+
+func FuzzParse(f *testing.F) {
+	f.Add([]byte("<movie><title>Heat</title><year>1995</year><uniqueid type=\"imdb\">tt0113277</uniqueid></movie>"))
+	f.Add([]byte("<episodedetails><season>1</season><episode>2</episode><id>123</id></episodedetails>"))
+	f.Add([]byte("https://www.imdb.com/title/tt0903747/ S02E02 https://www.themoviedb.org/tv/1396"))
+	f.Add([]byte("<tvshow><title>\x00</title><id>99999999999999999999</id>"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		info, err := Parse(data)
+		if err != nil {
+			return
+		}
+		if info.IMDbID < 0 || info.TMDBID < 0 || info.TVDBID < 0 || info.Season < 0 || info.Episode < 0 || info.Year < 0 {
+			t.Fatalf("negative number in %+v", info)
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// discordMaxContent is Discord's limit in characters for the content field of a
+// webhook message.
+const discordMaxContent = 2000
+
+// DiscordSender posts events to a Discord channel webhook.
+type DiscordSender struct {
+	WebhookURL string
+	Username   string // Optional display name override
+	Renderer   Renderer
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+}
+
+// Ensure DiscordSender implements Sender.
+var _ Sender = (*DiscordSender)(nil)
+
+type discordPayload struct {
+	Content  string `json:"content"`
+	Username string `json:"username,omitempty"`
+}
+
+// Send renders event and posts it to the webhook.
+func (d *DiscordSender) Send(ctx context.Context, event Event) error {
+	if d.WebhookURL == "" {
+		return errors.New("notifier: discord webhook URL is required")
+	}
+	text, err := d.Renderer.Render(event)
+	if err != nil {
+		return err
+	}
+	if runes := []rune(text); len(runes) > discordMaxContent {
+		text = string(runes[:discordMaxContent-3]) + "..." // Characters, not bytes
+	}
+	return postJSON(ctx, d.HTTPClient, d.WebhookURL, discordPayload{Content: text, Username: d.Username})
+}
@@ -0,0 +1,152 @@
+// Package notifier delivers notifications about subtitle activity (finished jobs,
+// newly available subtitles, download quota warnings) to pluggable senders such as
+// Discord webhooks and Telegram bots.
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+	"text/template"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+)
+
+// EventKind identifies the type of an Event.
+type EventKind string
+
+const (
+	EventJobCompleted EventKind = "job_completed"
+	EventNewSubtitle  EventKind = "new_subtitle"
+	EventQuotaWarning EventKind = "quota_warning"
+)
+
+// JobInfo summarises a finished batch or job.
+type JobInfo struct {
+	Name      string
+	Succeeded int
+	Failed    int
+	Duration  time.Duration
+}
+
+// QuotaInfo describes the remaining download quota.
+type QuotaInfo struct {
+	Remaining int
+	ResetTime time.Time
+}
+
+// Event is a notification to deliver. Only the field matching Kind needs to be set.
+type Event struct {
+	Kind     EventKind
+	Time     time.Time
+	Job      *JobInfo
+	Subtitle *opensubtitles.Subtitle
+	Quota    *QuotaInfo
+	// Extra carries integration-specific values available to templates as .Extra.
+	Extra map[string]string
+}
+
+// Sender delivers an Event.
+type Sender interface {
+	Send(ctx context.Context, event Event) error
+}
+
+// SenderFunc adapts a function to the Sender interface.
+type SenderFunc func(ctx context.Context, event Event) error
+
+// Send calls f(ctx, event).
+func (f SenderFunc) Send(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// DefaultTemplates are the text/template sources used when a sender has no override.
+var DefaultTemplates = map[EventKind]string{
+	EventJobCompleted: `Job "{{.Job.Name}}" finished: {{.Job.Succeeded}} succeeded, {{.Job.Failed}} failed{{if .Job.Duration}} in {{.Job.Duration}}{{end}}.`,
+	EventNewSubtitle: `New {{.Subtitle.Attributes.Language}} subtitle for {{with .Subtitle.Attributes.FeatureDetails}}{{if .MovieName}}{{.MovieName}}{{else}}{{.Title}}{{end}}{{end}}` +
+		`{{if .Subtitle.Attributes.Release}} ({{.Subtitle.Attributes.Release}}){{end}}{{if .Subtitle.Attributes.URL}}: {{.Subtitle.Attributes.URL}}{{end}}`,
+	EventQuotaWarning: `Download quota low: {{.Quota.Remaining}} downloads remaining{{if not .Quota.ResetTime.IsZero}}, resets at {{.Quota.ResetTime.UTC.Format "2006-01-02 15:04 MST"}}{{end}}.`,
+}
+
+// Renderer turns events into message text using per-kind templates.
+// The zero value uses DefaultTemplates.
+type Renderer struct {
+	// Templates overrides DefaultTemplates per event kind.
+	Templates map[EventKind]string
+}
+
+// Render formats event as text.
+func (r Renderer) Render(event Event) (string, error) {
+	src, ok := r.Templates[event.Kind]
+	if !ok {
+		src, ok = DefaultTemplates[event.Kind]
+	}
+	if !ok {
+		return "", fmt.Errorf("notifier: no template for event kind %q", event.Kind)
+	}
+	if err := checkEvent(event); err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(string(event.Kind)).Option("missingkey=zero").Parse(src)
+	if err != nil {
+		return "", fmt.Errorf("notifier: invalid template for %q: %w", event.Kind, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, event); err != nil {
+		return "", fmt.Errorf("notifier: failed to render %q: %w", event.Kind, err)
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+// checkEvent ensures the payload used by the default template of each kind is present.
+func checkEvent(event Event) error {
+	switch {
+	case event.Kind == EventJobCompleted && event.Job == nil,
+		event.Kind == EventNewSubtitle && event.Subtitle == nil,
+		event.Kind == EventQuotaWarning && event.Quota == nil:
+		return fmt.Errorf("notifier: %q event is missing its payload", event.Kind)
+	}
+	return nil
+}
+
+// withoutURL strips the URL from a *url.Error, since Discord webhook and Telegram bot
+// URLs carry their tokens and errors end up in logs.
+func withoutURL(err error) error {
+	var urlErr *neturl.Error
+	if errors.As(err, &urlErr) {
+		return fmt.Errorf("%s: %w", urlErr.Op, urlErr.Err)
+	}
+	return err
+}
+
+// postJSON sends payload as a JSON POST and fails on non-2xx responses.
+func postJSON(ctx context.Context, httpClient *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("notifier: failed to marshal payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("notifier: failed to create request: %w", withoutURL(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("notifier: request failed: %w", withoutURL(err))
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("notifier: request failed: status %d, body: %s", resp.StatusCode, string(msg))
+	}
+	return nil
+}
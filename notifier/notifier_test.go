@@ -0,0 +1,158 @@
+package notifier
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newSubtitleEvent() Event {
+	return Event{
+		Kind: EventNewSubtitle,
+		Subtitle: &opensubtitles.Subtitle{Attributes: opensubtitles.SubtitleAttributes{
+			Language: "el",
+			Release:  "Dune.Part.Two.2024.1080p.WEB",
+			URL:      "https://www.opensubtitles.com/en/subtitles/123",
+			FeatureDetails: opensubtitles.SubtitleFeatureDetails{
+				MovieName: "2024 - Dune: Part Two",
+			},
+		}},
+	}
+}
+
+func TestRenderer(t *testing.T) {
+	var r Renderer
+
+	text, err := r.Render(newSubtitleEvent())
+	require.NoError(t, err)
+	assert.Equal(t, "New el subtitle for 2024 - Dune: Part Two (Dune.Part.Two.2024.1080p.WEB): https://www.opensubtitles.com/en/subtitles/123", text)
+
+	text, err = r.Render(Event{Kind: EventJobCompleted, Job: &JobInfo{Name: "Season 1", Succeeded: 9, Failed: 1, Duration: 90 * time.Second}})
+	require.NoError(t, err)
+	assert.Equal(t, `Job "Season 1" finished: 9 succeeded, 1 failed in 1m30s.`, text)
+
+	text, err = r.Render(Event{Kind: EventQuotaWarning, Quota: &QuotaInfo{Remaining: 3, ResetTime: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}})
+	require.NoError(t, err)
+	assert.Equal(t, "Download quota low: 3 downloads remaining, resets at 2024-01-02 00:00 UTC.", text)
+
+	_, err = r.Render(Event{Kind: EventQuotaWarning})
+	assert.Error(t, err, "missing payload")
+	_, err = r.Render(Event{Kind: "unknown"})
+	assert.Error(t, err)
+
+	custom := Renderer{Templates: map[EventKind]string{EventQuotaWarning: "{{.Quota.Remaining}} left ({{.Extra.user}})"}}
+	text, err = custom.Render(Event{Kind: EventQuotaWarning, Quota: &QuotaInfo{Remaining: 2}, Extra: map[string]string{"user": "alice"}})
+	require.NoError(t, err)
+	assert.Equal(t, "2 left (alice)", text)
+}
+
+func TestDiscordSender(t *testing.T) {
+	var got discordPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	d := &DiscordSender{WebhookURL: server.URL, Username: "subs-bot"}
+	require.NoError(t, d.Send(context.Background(), newSubtitleEvent()))
+	assert.Equal(t, "subs-bot", got.Username)
+	assert.True(t, strings.HasPrefix(got.Content, "New el subtitle"))
+
+	assert.Error(t, (&DiscordSender{}).Send(context.Background(), newSubtitleEvent()))
+
+	t.Run("TruncatesOnRuneBoundary", func(t *testing.T) {
+		long := Renderer{Templates: map[EventKind]string{EventNewSubtitle: strings.Repeat("Ω", 2500)}}
+		d := &DiscordSender{WebhookURL: server.URL, Renderer: long}
+		require.NoError(t, d.Send(context.Background(), newSubtitleEvent()))
+		assert.True(t, utf8.ValidString(got.Content))
+		assert.Equal(t, discordMaxContent, utf8.RuneCountInString(got.Content))
+		assert.True(t, strings.HasSuffix(got.Content, "Ω..."))
+	})
+}
+
+func TestTelegramSender(t *testing.T) {
+	var gotPath string
+	var got telegramPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+
+	tg := &TelegramSender{BotToken: "123:abc", ChatID: "-10042", APIBaseURL: server.URL}
+	require.NoError(t, tg.Send(context.Background(), Event{Kind: EventJobCompleted, Job: &JobInfo{Name: "upload", Succeeded: 1}}))
+	assert.Equal(t, "/bot123:abc/sendMessage", gotPath)
+	assert.Equal(t, "-10042", got.ChatID)
+	assert.Equal(t, `Job "upload" finished: 1 succeeded, 0 failed.`, got.Text)
+
+	t.Run("ErrorHidesToken", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		tg := &TelegramSender{BotToken: "123:secret", ChatID: "-10042", APIBaseURL: closed.URL}
+		err := tg.Send(context.Background(), Event{Kind: EventJobCompleted, Job: &JobInfo{}})
+		require.Error(t, err)
+		assert.NotContains(t, err.Error(), "secret")
+		assert.Contains(t, err.Error(), "notifier: request failed: Post: ")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		assert.ErrorIs(t, tg.Send(ctx, Event{Kind: EventJobCompleted, Job: &JobInfo{}}), context.Canceled)
+	})
+
+	t.Run("ErrorStatus", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Unauthorized"}`))
+		}))
+		t.Cleanup(failing.Close)
+		tg.APIBaseURL = failing.URL
+		err := tg.Send(context.Background(), Event{Kind: EventJobCompleted, Job: &JobInfo{}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 401")
+	})
+}
+
+func TestWebhookSender(t *testing.T) {
+	var got WebhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(server.Close)
+
+	event := newSubtitleEvent()
+	event.Extra = map[string]string{"search": "dune"}
+	require.NoError(t, (&WebhookSender{URL: server.URL}).Send(context.Background(), event))
+	assert.Equal(t, EventNewSubtitle, got.Kind)
+	assert.True(t, strings.HasPrefix(got.Text, "New el subtitle"))
+	require.NotNil(t, got.Subtitle)
+	assert.Equal(t, "Dune.Part.Two.2024.1080p.WEB", got.Subtitle.Attributes.Release)
+	assert.Equal(t, map[string]string{"search": "dune"}, got.Extra)
+	assert.Nil(t, got.Job)
+
+	assert.Error(t, (&WebhookSender{}).Send(context.Background(), event))
+}
+
+func TestChannelSender(t *testing.T) {
+	ch := make(chan Event, 1)
+	require.NoError(t, ChannelSender(ch).Send(context.Background(), newSubtitleEvent()))
+	assert.Equal(t, EventNewSubtitle, (<-ch).Kind)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, ChannelSender(make(chan Event)).Send(ctx, newSubtitleEvent()), context.Canceled)
+}
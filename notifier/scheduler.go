@@ -0,0 +1,262 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+)
+
+// DefaultInterval is how often saved searches are polled when no interval is set.
+const DefaultInterval = 15 * time.Minute
+
+// Client is the part of *opensubtitles.Client used by the Scheduler.
+type Client interface {
+	DiscoverLatestSince(ctx context.Context, params opensubtitles.DiscoverParams, lastSeen opensubtitles.DiscoverCursor) (*opensubtitles.DiscoverLatestSinceResult, error)
+	SearchSubtitles(ctx context.Context, params opensubtitles.SearchSubtitlesParams) (*opensubtitles.SearchSubtitlesResponse, error)
+}
+
+// Ensure the client can be used by the Scheduler.
+var _ Client = (*opensubtitles.Client)(nil)
+
+// SavedSearch is a search the Scheduler polls for new subtitles. Without a feature
+// ID it follows all new subtitles through DiscoverLatest; otherwise it searches the
+// feature's subtitles, newest first.
+type SavedSearch struct {
+	Name            string                     // Identifies the search; required and unique
+	Language        opensubtitles.LanguageCode // Empty means all languages
+	FeatureID       int                        // A movie or episode
+	ParentFeatureID int                        // A TV show: subtitles of all its episodes
+	// Interval overrides SchedulerOptions.Interval for this search.
+	Interval time.Duration
+}
+
+// SchedulerOptions configures a Scheduler.
+type SchedulerOptions struct {
+	Searches []SavedSearch
+	// Interval is how often each search is polled (default: DefaultInterval).
+	Interval time.Duration
+	// Senders receive an EventNewSubtitle event per new subtitle, with the name of
+	// the search in Event.Extra["search"].
+	Senders []Sender
+	// NotifyExisting also notifies the subtitles found by the first poll of a search.
+	// By default the first poll only records them.
+	NotifyExisting bool
+	// OnError, if set, is called with failed polls and failed deliveries.
+	OnError func(search SavedSearch, err error)
+	// Logger receives a warning per failure. Nil disables logging.
+	Logger *slog.Logger
+}
+
+// ErrDuplicateSearch is returned for a SavedSearch whose name is already scheduled.
+var ErrDuplicateSearch = errors.New("notifier: duplicate saved search name")
+
+// Scheduler polls saved searches and notifies the senders of new subtitles.
+type Scheduler struct {
+	client Client
+	opts   SchedulerOptions
+
+	mu       sync.Mutex // Protects searches
+	searches map[string]*searchState
+	wake     chan struct{} // Signals Run that a search was added
+
+	now func() time.Time
+}
+
+// searchState is the progress of a saved search.
+type searchState struct {
+	search SavedSearch
+	cursor opensubtitles.DiscoverCursor
+	polled bool      // The first poll is done
+	due    time.Time // Next poll; zero polls at once
+}
+
+// NewScheduler creates a Scheduler for opts.Searches. It does not poll until Run or
+// Poll.
+func NewScheduler(client Client, opts SchedulerOptions) (*Scheduler, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	opts.Logger = logging.OrDiscard(opts.Logger)
+	s := &Scheduler{
+		client:   client,
+		opts:     opts,
+		searches: make(map[string]*searchState),
+		wake:     make(chan struct{}, 1),
+		now:      time.Now,
+	}
+	for _, search := range opts.Searches {
+		if err := s.Add(search); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Add schedules search; a running Scheduler polls it at once.
+func (s *Scheduler) Add(search SavedSearch) error {
+	switch {
+	case search.Name == "":
+		return errors.New("notifier: saved search name is required")
+	case search.FeatureID != 0 && search.ParentFeatureID != 0:
+		return fmt.Errorf("notifier: saved search %q sets both FeatureID and ParentFeatureID", search.Name)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.searches[search.Name]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateSearch, search.Name)
+	}
+	s.searches[search.Name] = &searchState{search: search}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// Remove unschedules the search with name.
+func (s *Scheduler) Remove(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.searches, name)
+}
+
+// Run polls the searches when they are due until ctx ends, and returns ctx.Err().
+// Failures are reported to SchedulerOptions.OnError and logged, not returned.
+func (s *Scheduler) Run(ctx context.Context) error {
+	for {
+		s.Poll(ctx)
+		timer := time.NewTimer(s.untilDue())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		case <-s.wake:
+			timer.Stop()
+		}
+	}
+}
+
+// Poll polls every search that is due and notifies the senders of new subtitles. It
+// must not be called while Run is running.
+func (s *Scheduler) Poll(ctx context.Context) {
+	now := s.now()
+	s.mu.Lock()
+	var due []*searchState
+	for _, state := range s.searches {
+		if !state.due.After(now) {
+			due = append(due, state)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, state := range due {
+		if ctx.Err() != nil {
+			return
+		}
+		s.poll(ctx, state)
+		interval := state.search.Interval
+		if interval <= 0 {
+			interval = s.opts.Interval
+		}
+		s.mu.Lock()
+		state.due = now.Add(interval)
+		s.mu.Unlock()
+	}
+}
+
+// untilDue returns the time until the next search is due.
+func (s *Scheduler) untilDue() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	wait := s.opts.Interval
+	now := s.now()
+	for _, state := range s.searches {
+		if d := state.due.Sub(now); d < wait {
+			wait = d
+		}
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// poll fetches the new subtitles of state's search and sends them.
+func (s *Scheduler) poll(ctx context.Context, state *searchState) {
+	search := state.search
+	result, err := s.fetch(ctx, search, state.cursor)
+	if err != nil {
+		s.report(search, fmt.Errorf("failed to poll: %w", err))
+		return
+	}
+	first := !state.polled
+	state.cursor, state.polled = result.Cursor, true
+	if result.Gap && !first {
+		s.opts.Logger.Warn("notifier: more new subtitles than one page, some were missed", "search", search.Name)
+	}
+	if first && !s.opts.NotifyExisting {
+		return
+	}
+	// Oldest first, in the order they were uploaded.
+	for i := len(result.New) - 1; i >= 0; i-- {
+		event := Event{
+			Kind:     EventNewSubtitle,
+			Time:     s.now(),
+			Subtitle: &result.New[i],
+			Extra:    map[string]string{"search": search.Name},
+		}
+		for _, sender := range s.opts.Senders {
+			if err := sender.Send(ctx, event); err != nil {
+				s.report(search, fmt.Errorf("failed to send subtitle %s: %w", result.New[i].ID, err))
+			}
+		}
+	}
+}
+
+// fetch returns the subtitles of search that cursor does not mark as seen.
+func (s *Scheduler) fetch(ctx context.Context, search SavedSearch, cursor opensubtitles.DiscoverCursor) (*opensubtitles.DiscoverLatestSinceResult, error) {
+	if search.FeatureID == 0 && search.ParentFeatureID == 0 {
+		var params opensubtitles.DiscoverParams
+		if search.Language != "" {
+			params.Language = &search.Language
+		}
+		if cursor.IsZero() {
+			// A zero cursor would continue from the client's own cursor for these params,
+			// which other searches or callers may share.
+			cursor = opensubtitles.DiscoverCursor{UploadDate: time.Unix(0, 0)}
+		}
+		return s.client.DiscoverLatestSince(ctx, params, cursor)
+	}
+
+	orderBy, direction := opensubtitles.OrderByUploadDate, opensubtitles.SortDesc
+	params := opensubtitles.SearchSubtitlesParams{OrderBy: &orderBy, OrderDirection: &direction}
+	if search.FeatureID != 0 {
+		params.ID = &search.FeatureID
+	} else {
+		params.ParentFeatureID = &search.ParentFeatureID
+	}
+	if search.Language != "" {
+		languages := string(search.Language)
+		params.Languages = &languages
+	}
+	resp, err := s.client.SearchSubtitles(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return cursor.Since(resp.Data), nil
+}
+
+// report logs err and passes it to OnError.
+func (s *Scheduler) report(search SavedSearch, err error) {
+	s.opts.Logger.Warn("notifier: saved search failed", "search", search.Name, "error", err)
+	if s.opts.OnError != nil {
+		s.opts.OnError(search, err)
+	}
+}
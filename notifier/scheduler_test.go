@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient serves the subtitles in pages, newest first, as the API lists them.
+type fakeClient struct {
+	mu       sync.Mutex
+	pages    [][]opensubtitles.Subtitle // One per call; the last repeats
+	err      error
+	discover []opensubtitles.DiscoverParams
+	searches []opensubtitles.SearchSubtitlesParams
+}
+
+func (f *fakeClient) page() []opensubtitles.Subtitle {
+	page := f.pages[0]
+	if len(f.pages) > 1 {
+		f.pages = f.pages[1:]
+	}
+	return page
+}
+
+func (f *fakeClient) DiscoverLatestSince(_ context.Context, params opensubtitles.DiscoverParams, lastSeen opensubtitles.DiscoverCursor) (*opensubtitles.DiscoverLatestSinceResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.discover = append(f.discover, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return lastSeen.Since(f.page()), nil
+}
+
+func (f *fakeClient) SearchSubtitles(_ context.Context, params opensubtitles.SearchSubtitlesParams) (*opensubtitles.SearchSubtitlesResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.searches = append(f.searches, params)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &opensubtitles.SearchSubtitlesResponse{Data: f.page()}, nil
+}
+
+func subtitle(id string, minute int) opensubtitles.Subtitle {
+	var sub opensubtitles.Subtitle
+	sub.ID = id
+	sub.Attributes.UploadDate = time.Date(2024, 5, 1, 12, minute, 0, 0, time.UTC)
+	return sub
+}
+
+// recorder collects the IDs of the subtitles sent.
+type recorder struct{ ids []string }
+
+func (r *recorder) Send(_ context.Context, event Event) error {
+	r.ids = append(r.ids, event.Extra["search"]+":"+event.Subtitle.ID)
+	return nil
+}
+
+func TestSchedulerPoll(t *testing.T) {
+	client := &fakeClient{pages: [][]opensubtitles.Subtitle{
+		{subtitle("2", 1), subtitle("1", 0)},
+		{subtitle("4", 3), subtitle("3", 2), subtitle("2", 1)},
+	}}
+	var sent recorder
+	s, err := NewScheduler(client, SchedulerOptions{
+		Searches: []SavedSearch{{Name: "greek", Language: "el"}},
+		Interval: time.Minute,
+		Senders:  []Sender{&sent},
+	})
+	require.NoError(t, err)
+	now := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	s.Poll(context.Background())
+	assert.Empty(t, sent.ids, "the first poll only records the existing subtitles")
+	require.Len(t, client.discover, 1)
+	assert.Equal(t, opensubtitles.LanguageCode("el"), *client.discover[0].Language)
+
+	s.Poll(context.Background())
+	assert.Len(t, client.discover, 1, "not due yet")
+
+	now = now.Add(time.Minute)
+	s.Poll(context.Background())
+	assert.Equal(t, []string{"greek:3", "greek:4"}, sent.ids, "oldest first")
+
+	now = now.Add(time.Minute)
+	s.Poll(context.Background())
+	assert.Len(t, sent.ids, 2, "nothing new")
+}
+
+func TestSchedulerFeatureSearch(t *testing.T) {
+	client := &fakeClient{pages: [][]opensubtitles.Subtitle{{subtitle("1", 0)}}}
+	var sent recorder
+	s, err := NewScheduler(client, SchedulerOptions{
+		Searches:       []SavedSearch{{Name: "show", Language: "en", ParentFeatureID: 42}},
+		Senders:        []Sender{&sent},
+		NotifyExisting: true,
+	})
+	require.NoError(t, err)
+
+	s.Poll(context.Background())
+	assert.Equal(t, []string{"show:1"}, sent.ids)
+	require.Len(t, client.searches, 1)
+	params := client.searches[0]
+	assert.Equal(t, 42, *params.ParentFeatureID)
+	assert.Nil(t, params.ID)
+	assert.Equal(t, "en", *params.Languages)
+	assert.Equal(t, opensubtitles.OrderByUploadDate, *params.OrderBy)
+	assert.Empty(t, client.discover)
+}
+
+func TestSchedulerErrors(t *testing.T) {
+	client := &fakeClient{err: errors.New("boom")}
+	var failed []string
+	s, err := NewScheduler(client, SchedulerOptions{
+		Searches: []SavedSearch{{Name: "all"}},
+		OnError:  func(search SavedSearch, err error) { failed = append(failed, search.Name+": "+err.Error()) },
+	})
+	require.NoError(t, err)
+
+	s.Poll(context.Background())
+	assert.Equal(t, []string{"all: failed to poll: boom"}, failed)
+
+	assert.ErrorIs(t, s.Add(SavedSearch{Name: "all"}), ErrDuplicateSearch)
+	assert.Error(t, s.Add(SavedSearch{}))
+	assert.Error(t, s.Add(SavedSearch{Name: "both", FeatureID: 1, ParentFeatureID: 2}))
+	_, err = NewScheduler(client, SchedulerOptions{Searches: []SavedSearch{{Name: "a"}, {Name: "a"}}})
+	assert.ErrorIs(t, err, ErrDuplicateSearch)
+}
+
+func TestSchedulerRun(t *testing.T) {
+	client := &fakeClient{pages: [][]opensubtitles.Subtitle{{subtitle("1", 0)}}}
+	events := make(chan Event)
+	s, err := NewScheduler(client, SchedulerOptions{Senders: []Sender{ChannelSender(events)}, NotifyExisting: true})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- s.Run(ctx) }()
+
+	require.NoError(t, s.Add(SavedSearch{Name: "added"}))
+	select {
+	case event := <-events:
+		assert.Equal(t, "1", event.Subtitle.ID)
+		assert.Equal(t, "added", event.Extra["search"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("the added search was not polled")
+	}
+	cancel()
+	assert.ErrorIs(t, <-done, context.Canceled)
+}
@@ -0,0 +1,46 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// defaultTelegramAPI is the Telegram Bot API base URL.
+const defaultTelegramAPI = "https://api.telegram.org"
+
+// TelegramSender sends events to a Telegram chat through a bot.
+type TelegramSender struct {
+	BotToken   string
+	ChatID     string // Numeric chat ID or "@channelname"
+	Renderer   Renderer
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+	APIBaseURL string       // Defaults to https://api.telegram.org
+}
+
+// Ensure TelegramSender implements Sender.
+var _ Sender = (*TelegramSender)(nil)
+
+type telegramPayload struct {
+	ChatID                string `json:"chat_id"`
+	Text                  string `json:"text"`
+	DisableWebPagePreview bool   `json:"disable_web_page_preview"`
+}
+
+// Send renders event and delivers it with the sendMessage method.
+func (t *TelegramSender) Send(ctx context.Context, event Event) error {
+	if t.BotToken == "" || t.ChatID == "" {
+		return errors.New("notifier: telegram bot token and chat ID are required")
+	}
+	text, err := t.Renderer.Render(event)
+	if err != nil {
+		return err
+	}
+	base := t.APIBaseURL
+	if base == "" {
+		base = defaultTelegramAPI
+	}
+	url := strings.TrimSuffix(base, "/") + "/bot" + t.BotToken + "/sendMessage"
+	return postJSON(ctx, t.HTTPClient, url, telegramPayload{ChatID: t.ChatID, Text: text, DisableWebPagePreview: true})
+}
@@ -0,0 +1,69 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+)
+
+// WebhookSender posts events as JSON to any URL, e.g. a home automation or chat-ops
+// endpoint. The body is a WebhookPayload.
+type WebhookSender struct {
+	URL        string
+	Renderer   Renderer     // Renders WebhookPayload.Text
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+}
+
+// Ensure WebhookSender implements Sender.
+var _ Sender = (*WebhookSender)(nil)
+
+// WebhookPayload is the JSON body WebhookSender posts.
+type WebhookPayload struct {
+	Kind     EventKind               `json:"kind"`
+	Time     time.Time               `json:"time"`
+	Text     string                  `json:"text"`
+	Job      *JobInfo                `json:"job,omitempty"`
+	Subtitle *opensubtitles.Subtitle `json:"subtitle,omitempty"`
+	Quota    *QuotaInfo              `json:"quota,omitempty"`
+	Extra    map[string]string       `json:"extra,omitempty"`
+}
+
+// Send renders event and posts it to the URL.
+func (s *WebhookSender) Send(ctx context.Context, event Event) error {
+	if s.URL == "" {
+		return errors.New("notifier: webhook URL is required")
+	}
+	text, err := s.Renderer.Render(event)
+	if err != nil {
+		return err
+	}
+	return postJSON(ctx, s.HTTPClient, s.URL, WebhookPayload{
+		Kind:     event.Kind,
+		Time:     event.Time,
+		Text:     text,
+		Job:      event.Job,
+		Subtitle: event.Subtitle,
+		Quota:    event.Quota,
+		Extra:    event.Extra,
+	})
+}
+
+// ChannelSender delivers events to a channel, e.g. for an application's own event
+// loop. Send blocks until the event is received or ctx ends.
+type ChannelSender chan<- Event
+
+// Ensure ChannelSender implements Sender.
+var _ Sender = ChannelSender(nil)
+
+// Send sends event on the channel.
+func (ch ChannelSender) Send(ctx context.Context, event Event) error {
+	select {
+	case ch <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -1,15 +1,23 @@
 package opensubtitles
 
 import (
-	// Added for future method signatures
+	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync" // For thread-safe access to token/baseUrl
+	"time"
 
+	"github.com/angelospk/opensubtitles-go/envconfig"
 	"github.com/angelospk/opensubtitles-go/internal/constants"
+	apierrors "github.com/angelospk/opensubtitles-go/internal/errors"
 	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/mediainfo"
 
 	// Import the upload package
 	"github.com/angelospk/opensubtitles-go/upload"
@@ -19,7 +27,256 @@ import (
 type Config struct {
 	ApiKey    string
 	UserAgent string
-	BaseURL   string // Optional: Override default base URL
+	// BaseURL overrides DefaultBaseURL, e.g. VIPBaseURL. A bare host such as
+	// "vip-api.opensubtitles.com" gets the https scheme and the /api/v1 path.
+	// The host returned by Login replaces it until Logout switches back to it.
+	BaseURL string
+	// OnHostMigration is called when the API redirects a request to a different host,
+	// e.g. after an infrastructure move. Defaults to logging a warning to Logger.
+	OnHostMigration func(fromHost, toHost string)
+	// MaxResponseBytes limits the size of API response bodies (after decompression).
+	// Zero uses the 16 MiB default; a negative value disables the limit.
+	MaxResponseBytes int64
+	// Credentials, when set, let the client log in again and retry a request once
+	// when the API rejects an expired or invalidated token with 401.
+	Credentials *LoginRequest
+	// RateLimit configures client-side throttling per endpoint class and retries of
+	// 429 responses. Nil uses DefaultRateLimitConfig; a zero value disables both.
+	RateLimit *RateLimitConfig
+	// IdempotencyPolicies decide by endpoint path, e.g. "/subtitles", whether requests
+	// are retried after 502, 503 and 504 responses, up to RateLimit.MaxRetries times.
+	// Paths without a policy are retried for GET requests only. Unless set here,
+	// "/download" is retried only when GetUserInfo confirms that the failed attempt
+	// did not count against the download quota, which must be known from an earlier
+	// Download or GetUserInfo.
+	IdempotencyPolicies map[string]IdempotencyPolicy
+	// TokenStore, when set, persists the login token across restarts: a stored,
+	// unexpired token is loaded by NewClient, Login saves and Logout clears it.
+	TokenStore TokenStore
+	// Headers are sent with every request: API calls, file downloads and XML-RPC
+	// calls. WithRequestHeaders adds headers per call. The headers the client sets
+	// itself, such as Api-Key and Authorization, are never replaced.
+	Headers http.Header
+	// Middlewares wrap every HTTP request, including file downloads, e.g. for logging,
+	// metrics or RetryMiddleware. The first middleware is the outermost.
+	Middlewares []RoundTripperFunc
+	// Cache, when set, caches GET responses of lookup endpoints (features, subtitle
+	// search, languages, formats) for CacheConfig.TTL and then revalidates them with
+	// ETag/Last-Modified conditional requests.
+	Cache *CacheConfig
+	// Logger receives structured logs: every HTTP request at debug level (method, path,
+	// status, duration and rate limit headers) and warnings, e.g. token store failures.
+	// It is also used by the XML-RPC uploader. Nil disables logging.
+	Logger *slog.Logger
+	// Metrics, when set, is called on every API request with its endpoint, status and
+	// latency, and with the remaining download quota.
+	Metrics Metrics
+	// DownloadLinkTTL, when positive, reuses the link returned by Download for the same
+	// request within this window instead of calling /download again, which would
+	// count against the download quota. Requests with ForceDownload set always call
+	// the API. The links expire on the server after a few hours.
+	DownloadLinkTTL time.Duration
+	// Anonymous declares that the client has no user account. Download, GetUserInfo
+	// and UploadSubtitle then return ErrLoginRequired without calling the API unless
+	// a token is set by Login, SetAuthToken or TokenStore. It cannot be combined
+	// with Credentials.
+	Anonymous bool
+	// DownloadHistory, when set, records every download link requested by Download,
+	// for GetDownloadHistory and HasDownloaded. The API itself does not list past
+	// downloads. See MemoryDownloadHistory and FileDownloadHistory.
+	DownloadHistory DownloadHistory
+	// HTTPClient, when set, sends all requests: API calls, file downloads and, through
+	// its Transport, XML-RPC uploads. Its CheckRedirect is replaced for API requests.
+	// It cannot be combined with ProxyURL or TLSConfig.
+	HTTPClient *http.Client
+	// ProxyURL sends all requests through an HTTP(S) or SOCKS5 proxy, e.g.
+	// "http://proxy.example.com:3128". Without it, HTTP(S)_PROXY from the environment
+	// applies.
+	ProxyURL string
+	// TLSConfig is used for all requests, e.g. with RootCAs holding a corporate CA bundle.
+	TLSConfig *tls.Config
+	// Timeouts bounds API requests, per endpoint if needed, file downloads and XML-RPC
+	// calls. Nil leaves API requests and downloads to the request context.
+	Timeouts *TimeoutConfig
+	// ExcludeAITranslated and ExcludeMachineTranslated make SearchSubtitles, and the
+	// helpers built on it such as FindBestSubtitle and SearchByVideoFile, exclude AI-
+	// or machine-translated subtitles unless the search parameters set AITranslated
+	// or MachineTranslated themselves.
+	ExcludeAITranslated      bool
+	ExcludeMachineTranslated bool
+	// MediaProber, when set, reads the FPS, duration and frame count of the video
+	// before each XML-RPC upload (see upload.ConsolidateMetadata), e.g.
+	// mediainfo.FFprobe{}. Values set in the UserUploadIntent are kept.
+	MediaProber mediainfo.Prober
+	// DetectSubtitleFlags makes XML-RPC uploads set HearingImpaired and
+	// ForeignPartsOnly when the subtitle's content shows them; see upload.DetectFlags.
+	DetectSubtitleFlags bool
+	// ValidateUploadLanguage makes XML-RPC uploads check the subtitle's language
+	// against the languages the server lists before uploading; see
+	// upload.Options.ValidateLanguage.
+	ValidateUploadLanguage bool
+	// EnableSearchDedup makes concurrent SearchSubtitles and SearchFeatures calls with
+	// identical parameters, e.g. from a search box firing on every keystroke, share a
+	// single request. Each caller gets its own copy of the response and its Data slice;
+	// the subtitles and features in it share their nested values. Calls whose context
+	// carries request headers (WithRequestHeaders, WithRequestID) or a ResponseMeta
+	// are always sent on their own.
+	EnableSearchDedup bool
+}
+
+// DefaultBaseURL is the REST API base URL used when Config.BaseURL is not set.
+const DefaultBaseURL = constants.DefaultBaseURL
+
+// VIPBaseURL is the base URL of the API host for VIP accounts. Login switches to it
+// automatically when the API assigns it to the user.
+const VIPBaseURL = "https://vip-api.opensubtitles.com" + constants.ApiPath
+
+// CacheConfig selects the store, TTL and endpoints of the response cache.
+type CacheConfig = httpclient.CacheConfig
+
+// ResponseCache is the pluggable store behind Config.Cache, e.g. backed by Redis.
+// Keys are the request path followed by the normalized query string.
+type ResponseCache = httpclient.ResponseCache
+
+// CachedResponse is a response body stored in a ResponseCache with its validators.
+type CachedResponse = httpclient.CachedResponse
+
+// MemoryCache is the default in-memory LRU ResponseCache.
+type MemoryCache = httpclient.MemoryCache
+
+// NewMemoryCache creates an in-memory ResponseCache holding at most capacity responses.
+func NewMemoryCache(capacity int) *MemoryCache {
+	return httpclient.NewMemoryCache(capacity)
+}
+
+// DefaultCacheTTL is how long cached responses are served when CacheConfig.TTL is not set.
+const DefaultCacheTTL = httpclient.DefaultCacheTTL
+
+// DefaultCachePaths are the endpoints cached when CacheConfig.Paths is not set.
+var DefaultCachePaths = httpclient.DefaultCachePaths
+
+// IdempotencyPolicy decides whether requests to an endpoint are retried after a 502,
+// 503 or 504 response; see Config.IdempotencyPolicies. Requests already retried by
+// RetryMiddleware are not retried again.
+type IdempotencyPolicy = httpclient.IdempotencyPolicy
+
+// RoundTripperFunc is a request middleware: it receives the request and the next
+// http.RoundTripper in the chain and returns the response, usually from next.RoundTrip.
+// It must not modify the request; send a req.Clone instead.
+type RoundTripperFunc = httpclient.RoundTripperFunc
+
+// RetryPolicy configures RetryMiddleware.
+type RetryPolicy = httpclient.RetryPolicy
+
+// DefaultRetryPolicy retries up to 3 times with backoff from 500ms up to 10s.
+var DefaultRetryPolicy = httpclient.DefaultRetryPolicy
+
+// RetryMiddleware returns a middleware that retries network errors and 5xx responses
+// (except 501) with capped exponential backoff, honouring Retry-After and the request
+// context. 429 responses are handled separately by Config.RateLimit. API requests are
+// only replayed if Config.IdempotencyPolicies allows it for their endpoint (by default
+// GET requests), or if they failed before reaching the server; file downloads are GET
+// requests and always retried.
+func RetryMiddleware(policy RetryPolicy) RoundTripperFunc {
+	return httpclient.Retry(policy)
+}
+
+// RateLimitConfig sets requests per second for the default, login and download
+// endpoint classes, the bucket burst, and the 429 retry/backoff policy.
+type RateLimitConfig = httpclient.RateLimits
+
+// DefaultRateLimitConfig follows the documented API limits: 5 requests per second,
+// 1 login per second, and up to 3 retries of 429 responses.
+var DefaultRateLimitConfig = httpclient.DefaultRateLimits
+
+var (
+	// ErrResponseTooLarge is returned when an API response exceeds Config.MaxResponseBytes.
+	ErrResponseTooLarge = httpclient.ErrResponseTooLarge
+	// ErrUnauthorized matches requests the API rejected with 401.
+	ErrUnauthorized = apierrors.ErrUnauthorized
+	// ErrRateLimited matches requests still rejected with 429 after retries.
+	ErrRateLimited = apierrors.ErrRateLimited
+	// ErrForbidden matches 403 responses.
+	ErrForbidden = apierrors.ErrForbidden
+	// ErrNotFound matches 404 responses.
+	ErrNotFound = apierrors.ErrNotFound
+	// ErrServiceUnavailable matches 5xx responses.
+	ErrServiceUnavailable = apierrors.ErrServiceUnavailable
+	// ErrQuotaExceeded matches responses reporting an exhausted download quota.
+	ErrQuotaExceeded = apierrors.ErrQuotaExceeded
+	// ErrInvalidApiKey matches 401/403 responses rejecting the API key.
+	ErrInvalidApiKey = apierrors.ErrInvalidApiKey
+	// ErrTokenExpired matches 401 responses to requests sent with a user token.
+	ErrTokenExpired = apierrors.ErrTokenExpired
+	// ErrValidation matches 400 and 422 responses; see APIError.Fields for details.
+	ErrValidation = apierrors.ErrValidation
+)
+
+// APIError is returned for non-2xx API responses. Use errors.Is with the sentinel
+// errors above to branch on the kind of failure, and errors.As to read the status,
+// message and validation field errors.
+type APIError = apierrors.APIError
+
+// ConfigFromEnv builds a Config from OPENSUBTITLES_* environment variables, so that
+// applications can run without a configuration file:
+//
+//   - API_KEY, USER_AGENT, BASE_URL and PROXY_URL set the strings of the same name.
+//   - USERNAME and PASSWORD, when both are set, become Credentials.
+//   - MAX_RESPONSE_BYTES and DOWNLOAD_LINK_TTL (a duration such as "2h").
+//   - RATE_LIMIT, RATE_LIMIT_LOGIN, RATE_LIMIT_DOWNLOAD (requests per second),
+//     RATE_LIMIT_BURST, MAX_RETRIES, RETRY_BASE_BACKOFF and RETRY_MAX_BACKOFF
+//     override DefaultRateLimitConfig.
+//   - TIMEOUT, DOWNLOAD_TIMEOUT and XMLRPC_TIMEOUT set Timeouts.
+//   - ANONYMOUS, EXCLUDE_AI_TRANSLATED, EXCLUDE_MACHINE_TRANSLATED,
+//     DETECT_SUBTITLE_FLAGS, VALIDATE_UPLOAD_LANGUAGE and ENABLE_SEARCH_DEDUP are
+//     booleans.
+//
+// The error lists every variable that is set but cannot be parsed.
+func ConfigFromEnv() (Config, error) {
+	return configFromLoader(envconfig.New(""))
+}
+
+func configFromLoader(l *envconfig.Loader) (Config, error) {
+	config := Config{
+		ApiKey:                   l.String("API_KEY", ""),
+		UserAgent:                l.String("USER_AGENT", ""),
+		BaseURL:                  l.String("BASE_URL", ""),
+		ProxyURL:                 l.String("PROXY_URL", ""),
+		MaxResponseBytes:         int64(l.Int("MAX_RESPONSE_BYTES", 0)),
+		DownloadLinkTTL:          l.Duration("DOWNLOAD_LINK_TTL", 0),
+		Anonymous:                l.Bool("ANONYMOUS", false),
+		ExcludeAITranslated:      l.Bool("EXCLUDE_AI_TRANSLATED", false),
+		ExcludeMachineTranslated: l.Bool("EXCLUDE_MACHINE_TRANSLATED", false),
+		DetectSubtitleFlags:      l.Bool("DETECT_SUBTITLE_FLAGS", false),
+		ValidateUploadLanguage:   l.Bool("VALIDATE_UPLOAD_LANGUAGE", false),
+		EnableSearchDedup:        l.Bool("ENABLE_SEARCH_DEDUP", false),
+	}
+	username, password := l.String("USERNAME", ""), l.String("PASSWORD", "")
+	if username != "" && password != "" {
+		config.Credentials = &LoginRequest{Username: username, Password: password}
+	}
+
+	limits := DefaultRateLimitConfig
+	limits.Default = l.Float("RATE_LIMIT", limits.Default)
+	limits.Login = l.Float("RATE_LIMIT_LOGIN", limits.Login)
+	limits.Download = l.Float("RATE_LIMIT_DOWNLOAD", limits.Download)
+	limits.Burst = l.Int("RATE_LIMIT_BURST", limits.Burst)
+	limits.MaxRetries = l.Int("MAX_RETRIES", limits.MaxRetries)
+	limits.BaseBackoff = l.Duration("RETRY_BASE_BACKOFF", limits.BaseBackoff)
+	limits.MaxBackoff = l.Duration("RETRY_MAX_BACKOFF", limits.MaxBackoff)
+	if limits != DefaultRateLimitConfig {
+		config.RateLimit = &limits
+	}
+
+	timeouts := TimeoutConfig{
+		Default:  l.Duration("TIMEOUT", 0),
+		Download: l.Duration("DOWNLOAD_TIMEOUT", 0),
+		XMLRPC:   l.Duration("XMLRPC_TIMEOUT", 0),
+	}
+	if timeouts.Default != 0 || timeouts.Download != 0 || timeouts.XMLRPC != 0 {
+		config.Timeouts = &timeouts
+	}
+	return config, l.Err()
 }
 
 // Client is the main OpenSubtitles API client.
@@ -29,8 +286,28 @@ type Client struct {
 	mu             sync.RWMutex       // Protects access to token and currentBaseUrl
 	authToken      *string
 	currentBaseUrl string
+	defaultBaseUrl string // Configured base URL, restored by Logout
 	// Add UploadClient
 	uploader upload.Uploader
+	reauthMu sync.Mutex // Serialises automatic re-login
+
+	languagesMu sync.Mutex // Protects languages
+	languages   *GetLanguagesResponse
+
+	vip *bool // VIP status of the current token, if reported by the API; protected by mu
+
+	links *linkCache // Nil unless Config.DownloadLinkTTL is set
+
+	statusMu      sync.Mutex // Protects lastRateLimit and lastQuota
+	lastRateLimit *RateLimitStatus
+	lastQuota     *DownloadQuota
+
+	searches flightGroup // Deduplicates concurrent identical searches
+
+	discoverMu    sync.Mutex                // Protects latestCursors
+	latestCursors map[string]DiscoverCursor // DiscoverLatestSince cursors by encoded params
+
+	logger *slog.Logger
 }
 
 // NewClient creates a new OpenSubtitles API client.
@@ -38,6 +315,13 @@ func NewClient(config Config) (*Client, error) {
 	if config.ApiKey == "" {
 		return nil, errors.New("API key is required")
 	}
+	if config.Anonymous && config.Credentials != nil {
+		return nil, errors.New("anonymous mode cannot be used with credentials")
+	}
+	base, err := baseHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
 	if config.UserAgent == "" {
 		// Use the default user agent if none is provided
 		config.UserAgent = constants.DefaultUserAgent
@@ -45,22 +329,70 @@ func NewClient(config Config) (*Client, error) {
 
 	baseUrl := constants.DefaultBaseURL
 	if config.BaseURL != "" {
-		// Validate user-provided base URL slightly
-		if _, err := url.ParseRequestURI(config.BaseURL); err != nil {
+		if baseUrl, err = normalizeBaseURL(config.BaseURL, false); err != nil {
 			return nil, fmt.Errorf("invalid BaseURL provided: %w", err)
 		}
-		baseUrl = config.BaseURL
 	}
 
 	c := &Client{
 		config:         config,
 		httpClient:     httpclient.New(baseUrl, config.ApiKey, config.UserAgent),
 		currentBaseUrl: baseUrl,
+		defaultBaseUrl: baseUrl,
+		logger:         logging.OrDiscard(config.Logger),
+	}
+	if config.DownloadLinkTTL > 0 {
+		c.links = newLinkCache(config.DownloadLinkTTL)
+	}
+	if base != nil {
+		c.httpClient.SetHTTPClient(base)
+	}
+	if config.Timeouts != nil {
+		c.httpClient.SetTimeouts(config.Timeouts.httpTimeouts())
+	}
+	c.httpClient.SetLogger(config.Logger)
+	c.httpClient.SetMaxResponseBytes(config.MaxResponseBytes)
+	if config.RateLimit != nil {
+		c.httpClient.SetRateLimits(*config.RateLimit)
+	}
+	if config.Cache != nil {
+		c.httpClient.SetResponseCache(*config.Cache)
+	}
+	if len(config.Headers) > 0 {
+		c.httpClient.SetHeaders(config.Headers)
+	}
+	if len(config.Middlewares) > 0 {
+		c.httpClient.SetMiddlewares(config.Middlewares...)
+	}
+	if config.OnHostMigration != nil {
+		c.httpClient.SetHostChangeHandler(config.OnHostMigration)
+	}
+	if config.Credentials != nil {
+		c.httpClient.SetUnauthorizedHandler(c.reauthenticate)
+	}
+	if config.Metrics != nil {
+		c.httpClient.SetObserver(observeRequest(config.Metrics))
+	}
+	c.httpClient.SetResponseHook(c.observeResponse)
+	policies := map[string]IdempotencyPolicy{"/download": {Retry: true, Check: c.downloadNotCounted}}
+	for path, policy := range config.IdempotencyPolicies {
+		policies[path] = policy
+	}
+	c.httpClient.SetIdempotencyPolicies(policies)
+
+	if config.TokenStore != nil {
+		c.loadStoredToken(context.Background())
 	}
 
-	// Initialize the uploader
-	var err error
-	c.uploader, err = upload.NewXmlRpcUploader() // Initialize the XML-RPC uploader
+	// Initialize the XML-RPC uploader with the same transport
+	uploadOpts := upload.Options{Logger: config.Logger, Prober: config.MediaProber, DetectFlags: config.DetectSubtitleFlags, ValidateLanguage: config.ValidateUploadLanguage, Header: config.Headers}
+	if base != nil {
+		uploadOpts.Transport = base.Transport
+	}
+	if config.Timeouts != nil {
+		uploadOpts.Timeout = config.Timeouts.XMLRPC
+	}
+	c.uploader, err = upload.NewXmlRpcUploaderWithOptions(uploadOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize uploader: %w", err)
 	}
@@ -72,38 +404,23 @@ func NewClient(config Config) (*Client, error) {
 func (c *Client) SetAuthToken(token string, baseUrl string) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	c.vip = nil     // Unknown until reported for the new token
+	c.links.clear() // Links belong to the previous user's quota
 
 	if token == "" {
 		c.authToken = nil
 		c.httpClient.SetAuthToken(nil) // Clear in http client too
-		// Optionally reset base URL? Keep it for now.
+		// Keep the base URL; Logout switches back to the configured one.
 		return nil
 	}
 
 	// Validate and update base URL if provided
 	if baseUrl != "" {
-		// Ensure scheme *before* parsing, as ParseRequestURI requires it.
-		if !strings.HasPrefix(baseUrl, "http://") && !strings.HasPrefix(baseUrl, "https://") {
-			baseUrl = "https://" + baseUrl // Assume https
-		}
-
-		parsedUrl, err := url.ParseRequestURI(baseUrl)
+		normalized, err := normalizeBaseURL(baseUrl, true)
 		if err != nil {
-			// This error should be less likely now, but keep the check
 			return fmt.Errorf("invalid base URL provided ('%s'): %w", baseUrl, err)
 		}
-
-		// Scheme check is now redundant here, but harmless.
-		// if parsedUrl.Scheme == "" { ... }
-
-		// Assuming httpclient expects the full base URL with potential /api/v1 path
-		// If the login response `base_url` is just `vip-api.opensubtitles.com`, append path.
-		if parsedUrl.Host != "" && parsedUrl.Path == "" {
-			c.currentBaseUrl = baseUrl + constants.ApiPath // Append standard path
-		} else {
-			c.currentBaseUrl = baseUrl // Assume full URL provided
-		}
-
+		c.currentBaseUrl = normalized
 		c.httpClient.SetBaseURL(c.currentBaseUrl)
 	}
 
@@ -128,11 +445,64 @@ func (c *Client) GetCurrentBaseURL() string {
 	return c.currentBaseUrl
 }
 
+// resetBaseURL switches back to the base URL the client was created with.
+func (c *Client) resetBaseURL() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.currentBaseUrl = c.defaultBaseUrl
+	c.httpClient.SetBaseURL(c.currentBaseUrl)
+}
+
+// normalizeBaseURL accepts a full base URL or a bare host such as the base_url
+// returned by Login ("vip-api.opensubtitles.com"). A missing scheme defaults to
+// https. The standard /api/v1 path is appended to a bare host, and to any URL
+// without a path if forcePath is set.
+func normalizeBaseURL(raw string, forcePath bool) (string, error) {
+	// Ensure scheme *before* parsing, as ParseRequestURI requires it.
+	if !strings.HasPrefix(raw, "http://") && !strings.HasPrefix(raw, "https://") {
+		raw = "https://" + raw // Assume https
+		forcePath = true
+	}
+	parsedUrl, err := url.ParseRequestURI(raw)
+	if err != nil {
+		return "", err
+	}
+	if parsedUrl.Host == "" {
+		return "", fmt.Errorf("missing host in '%s'", raw)
+	}
+	if forcePath && parsedUrl.Path == "" {
+		return raw + constants.ApiPath, nil // Append standard path
+	}
+	return raw, nil // Assume full URL provided
+}
+
 // Uploader returns the configured uploader instance for XML-RPC operations.
 func (c *Client) Uploader() upload.Uploader {
 	return c.uploader
 }
 
+// loadStoredToken restores the token from Config.TokenStore if it is still valid.
+// Store failures only disable persistence, so they are logged rather than returned.
+func (c *Client) loadStoredToken(ctx context.Context) {
+	stored, err := c.config.TokenStore.Get(ctx)
+	if err != nil {
+		c.logger.Warn("opensubtitles: failed to load stored token", "error", err)
+		return
+	}
+	if stored == nil || stored.Token == "" {
+		return
+	}
+	if stored.Expired(time.Now()) {
+		if err := c.config.TokenStore.Clear(ctx); err != nil {
+			c.logger.Warn("opensubtitles: failed to clear expired token", "error", err)
+		}
+		return
+	}
+	if err := c.SetAuthToken(stored.Token, stored.BaseURL); err != nil {
+		c.logger.Warn("opensubtitles: ignoring stored token", "error", err)
+	}
+}
+
 // Helper to check if authenticated
 func (c *Client) isAuthenticated() bool {
 	c.mu.RLock()
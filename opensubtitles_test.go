@@ -1,3 +1,612 @@
 package opensubtitles
 
 // TODO: Add tests for NewClient, config validation, etc.
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/envconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OPENSUBTITLES_API_KEY":    "env-key",
+		"OPENSUBTITLES_USER_AGENT": "EnvAgent/1.0",
+		"OPENSUBTITLES_BASE_URL":   "https://vip-api.opensubtitles.com/api/v1",
+		"OPENSUBTITLES_PROXY_URL":  "http://proxy.example.com:3128",
+	}
+	l := envconfig.NewWithLookup("", func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+
+	config, err := configFromLoader(l)
+	require.NoError(t, err)
+	assert.Equal(t, Config{
+		ApiKey:    "env-key",
+		UserAgent: "EnvAgent/1.0",
+		BaseURL:   "https://vip-api.opensubtitles.com/api/v1",
+		ProxyURL:  "http://proxy.example.com:3128",
+	}, config)
+
+	client, err := NewClient(config)
+	require.NoError(t, err)
+	assert.Equal(t, "https://vip-api.opensubtitles.com/api/v1", client.GetCurrentBaseURL())
+}
+
+func TestConfigFromEnvSettings(t *testing.T) {
+	env := map[string]string{
+		"OPENSUBTITLES_USERNAME":              "alice",
+		"OPENSUBTITLES_PASSWORD":              "secret",
+		"OPENSUBTITLES_MAX_RESPONSE_BYTES":    "1048576",
+		"OPENSUBTITLES_DOWNLOAD_LINK_TTL":     "2h",
+		"OPENSUBTITLES_RATE_LIMIT":            "2.5",
+		"OPENSUBTITLES_MAX_RETRIES":           "5",
+		"OPENSUBTITLES_RETRY_MAX_BACKOFF":     "1m",
+		"OPENSUBTITLES_DOWNLOAD_TIMEOUT":      "30s",
+		"OPENSUBTITLES_EXCLUDE_AI_TRANSLATED": "yes",
+		"OPENSUBTITLES_DOWNLOAD_DIR":          "/subs",
+		"OPENSUBTITLES_DOWNLOAD_WORKERS":      "8",
+	}
+	l := envconfig.NewWithLookup("", func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+
+	config, err := configFromLoader(l)
+	require.NoError(t, err)
+	assert.Equal(t, &LoginRequest{Username: "alice", Password: "secret"}, config.Credentials)
+	assert.Equal(t, int64(1<<20), config.MaxResponseBytes)
+	assert.Equal(t, 2*time.Hour, config.DownloadLinkTTL)
+	assert.True(t, config.ExcludeAITranslated)
+	limits := DefaultRateLimitConfig
+	limits.Default, limits.MaxRetries, limits.MaxBackoff = 2.5, 5, time.Minute
+	assert.Equal(t, &limits, config.RateLimit)
+	assert.Equal(t, &TimeoutConfig{Download: 30 * time.Second}, config.Timeouts)
+	assert.Equal(t, DownloadFilesOptions{Dir: "/subs", Workers: 8}, DownloadFilesOptionsFromEnv(l))
+
+	env["OPENSUBTITLES_RATE_LIMIT_BURST"] = "many"
+	env["OPENSUBTITLES_TIMEOUT"] = "soon"
+	_, err = configFromLoader(envconfig.NewWithLookup("", func(k string) (string, bool) { v, ok := env[k]; return v, ok }))
+	assert.ErrorContains(t, err, "OPENSUBTITLES_RATE_LIMIT_BURST")
+	assert.ErrorContains(t, err, "OPENSUBTITLES_TIMEOUT")
+}
+
+func TestNewClientBaseURL(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"", DefaultBaseURL},
+		{"vip-api.opensubtitles.com", VIPBaseURL},
+		{VIPBaseURL, VIPBaseURL},
+		{"http://localhost:8080", "http://localhost:8080"},
+		{"https://proxy.example.com/os/api/v1", "https://proxy.example.com/os/api/v1"},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: tt.in})
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, client.GetCurrentBaseURL(), tt.in)
+	}
+
+	_, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: "https://"})
+	assert.Error(t, err)
+}
+
+func TestRedirectHandling(t *testing.T) {
+	t.Run("PostFollowsHostMigrationWithHeadersAndBody", func(t *testing.T) {
+		newHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, http.MethodPost, r.Method, "method must survive a 301")
+			assert.Equal(t, "/api/v1/login", r.URL.Path)
+			assert.Equal(t, "test-api-key", r.Header.Get("Api-Key"))
+			var body LoginRequest
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, "user", body.Username)
+			_, _ = w.Write([]byte(`{"token":"tok","status":200}`))
+		}))
+		t.Cleanup(newHost.Close)
+
+		var migrations []string
+		oldHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, newHost.URL+r.URL.Path, http.StatusMovedPermanently)
+		}))
+		t.Cleanup(oldHost.Close)
+
+		client, err := NewClient(Config{
+			ApiKey:          "test-api-key",
+			BaseURL:         oldHost.URL + "/api/v1",
+			OnHostMigration: func(from, to string) { migrations = append(migrations, from+" -> "+to) },
+		})
+		require.NoError(t, err)
+
+		resp, err := client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.NoError(t, err)
+		assert.Equal(t, "tok", resp.Token)
+		require.Len(t, migrations, 1)
+		assert.Equal(t, strings.TrimPrefix(oldHost.URL, "http://")+" -> "+strings.TrimPrefix(newHost.URL, "http://"), migrations[0])
+	})
+
+	t.Run("GetReappliesAuthorizationAcrossHosts", func(t *testing.T) {
+		newHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "Bearer my-token", r.Header.Get("Authorization"))
+			assert.Equal(t, "test-api-key", r.Header.Get("Api-Key"))
+			_, _ = w.Write([]byte(`{"data":{"user_id":7}}`))
+		}))
+		t.Cleanup(newHost.Close)
+		oldHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, newHost.URL+r.URL.Path, http.StatusPermanentRedirect)
+		}))
+		t.Cleanup(oldHost.Close)
+
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: oldHost.URL + "/api/v1", OnHostMigration: func(string, string) {}})
+		require.NoError(t, err)
+		require.NoError(t, client.SetAuthToken("my-token", ""))
+
+		info, err := client.GetUserInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 7, info.Data.UserID)
+	})
+
+	t.Run("RefusesUntrustedHost", func(t *testing.T) {
+		other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Error("credentials must not be sent to an untrusted host")
+		}))
+		t.Cleanup(other.Close)
+		// "localhost" is a different site than the 127.0.0.1 test server.
+		target := strings.Replace(other.URL, "127.0.0.1", "localhost", 1)
+		handler := func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, target+r.URL.Path, http.StatusTemporaryRedirect)
+		}
+		_, client := setupTestServer(t, handler)
+
+		_, err := client.GetUserInfo(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "untrusted host")
+	})
+}
+
+func TestMaxResponseBytes(t *testing.T) {
+	padding := strings.Repeat(" ", 4096)
+	body := `{"token":"tok",` + padding + `"status":200}`
+
+	t.Run("RejectsOversizedBody", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, MaxResponseBytes: 1024})
+		require.NoError(t, err)
+		_, err = client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+
+		client, err = NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, MaxResponseBytes: int64(len(body))})
+		require.NoError(t, err)
+		resp, err := client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.NoError(t, err, "a body of exactly the limit is accepted")
+		assert.Equal(t, "tok", resp.Token)
+	})
+
+	t.Run("LimitsDecompressedSize", func(t *testing.T) {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		_, _ = zw.Write([]byte(`{"token":"` + strings.Repeat("a", 1<<20) + `"}`))
+		require.NoError(t, zw.Close())
+		require.Less(t, compressed.Len(), 4096)
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = w.Write(compressed.Bytes())
+		}))
+		t.Cleanup(server.Close)
+
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, MaxResponseBytes: 64 << 10})
+		require.NoError(t, err)
+		_, err = client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.ErrorIs(t, err, ErrResponseTooLarge)
+	})
+}
+
+func TestRateLimiting(t *testing.T) {
+	t.Run("Retries429HonouringRetryAfter", func(t *testing.T) {
+		var calls int32
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				http.Error(w, `{"message":"Throttle limit reached"}`, http.StatusTooManyRequests)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":{"user_id":7}}`))
+		})
+		info, err := client.GetUserInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 7, info.Data.UserID)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("GivesUpAfterMaxRetries", func(t *testing.T) {
+		var calls int32
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+		_, err := client.GetUserInfo(context.Background())
+		require.ErrorIs(t, err, ErrRateLimited)
+		assert.Equal(t, int32(testRateLimits.MaxRetries+1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("ThrottlesPerEndpointClass", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"token":"tok","data":{}}`))
+		}))
+		t.Cleanup(server.Close)
+		client, err := NewClient(Config{
+			ApiKey:    "test-api-key",
+			BaseURL:   server.URL,
+			RateLimit: &RateLimitConfig{Default: 1000, Login: 20, Burst: 10},
+		})
+		require.NoError(t, err)
+
+		start := time.Now()
+		for i := 0; i < 3; i++ {
+			_, err := client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+			require.NoError(t, err)
+		}
+		assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "logins are limited to 20/s without burst")
+
+		start = time.Now()
+		for i := 0; i < 5; i++ {
+			_, err := client.GetUserInfo(context.Background())
+			require.NoError(t, err)
+		}
+		assert.Less(t, time.Since(start), 90*time.Millisecond, "other endpoints use their own bucket")
+	})
+
+	t.Run("BackoffRespectsContext", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "3600")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		t.Cleanup(server.Close)
+		limits := DefaultRateLimitConfig
+		limits.MaxBackoff = time.Hour
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, RateLimit: &limits})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = client.GetUserInfo(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestServerErrorRetries(t *testing.T) {
+	// newServer answers /download with the given statuses, then 200, and /infos/user
+	// with the given remaining downloads, repeating the last.
+	newServer := func(t *testing.T, config Config, downloads []int, remaining []int) (*Client, *int32, *int32) {
+		var downloadCalls, userCalls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/download":
+				if n := int(atomic.AddInt32(&downloadCalls, 1)); n <= len(downloads) {
+					w.WriteHeader(downloads[n-1])
+					return
+				}
+				_, _ = w.Write([]byte(`{"link":"https://example.com/sub.srt","remaining":9}`))
+			case "/infos/user":
+				n := int(atomic.AddInt32(&userCalls, 1))
+				_, _ = fmt.Fprintf(w, `{"data":{"remaining_downloads":%d}}`, remaining[min(n, len(remaining))-1])
+			case "/login":
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		t.Cleanup(server.Close)
+		config.ApiKey, config.BaseURL, config.RateLimit = "test-api-key", server.URL, &testRateLimits
+		client, err := NewClient(config)
+		require.NoError(t, err)
+		return client, &downloadCalls, &userCalls
+	}
+	ctx := context.Background()
+
+	t.Run("RetriesGETs", func(t *testing.T) {
+		var calls int32
+		_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		})
+		_, err := client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat")})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("DoesNotRetryPOSTsWithoutPolicy", func(t *testing.T) {
+		client, _, _ := newServer(t, Config{}, nil, []int{10})
+		_, err := client.Login(ctx, LoginRequest{Username: "user", Password: "pass"})
+		require.ErrorIs(t, err, ErrServiceUnavailable)
+	})
+
+	t.Run("PolicyDisablesRetries", func(t *testing.T) {
+		var calls int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		t.Cleanup(server.Close)
+		client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL, RateLimit: &testRateLimits,
+			IdempotencyPolicies: map[string]IdempotencyPolicy{"/infos/user": {}}})
+		require.NoError(t, err)
+		_, err = client.GetUserInfo(ctx)
+		require.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("RetriesDownloadWhenQuotaIsUntouched", func(t *testing.T) {
+		client, downloadCalls, userCalls := newServer(t, Config{}, []int{http.StatusServiceUnavailable}, []int{10})
+		_, err := client.GetUserInfo(ctx)
+		require.NoError(t, err)
+		resp, err := client.Download(ctx, DownloadRequest{FileID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, 9, resp.Remaining)
+		assert.Equal(t, int32(2), atomic.LoadInt32(downloadCalls))
+		assert.Equal(t, int32(2), atomic.LoadInt32(userCalls), "the quota is checked before the retry")
+	})
+
+	t.Run("DoesNotRetryCountedDownload", func(t *testing.T) {
+		client, downloadCalls, _ := newServer(t, Config{}, []int{http.StatusGatewayTimeout}, []int{10, 9})
+		_, err := client.GetUserInfo(ctx)
+		require.NoError(t, err)
+		_, err = client.Download(ctx, DownloadRequest{FileID: 1})
+		require.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, int32(1), atomic.LoadInt32(downloadCalls))
+	})
+
+	t.Run("DoesNotRetryDownloadWithUnknownQuota", func(t *testing.T) {
+		client, downloadCalls, userCalls := newServer(t, Config{}, []int{http.StatusServiceUnavailable}, []int{10})
+		_, err := client.Download(ctx, DownloadRequest{FileID: 1})
+		require.ErrorIs(t, err, ErrServiceUnavailable)
+		assert.Equal(t, int32(1), atomic.LoadInt32(downloadCalls))
+		assert.Equal(t, int32(0), atomic.LoadInt32(userCalls))
+	})
+
+	t.Run("CustomDownloadPolicy", func(t *testing.T) {
+		config := Config{IdempotencyPolicies: map[string]IdempotencyPolicy{"/download": {Retry: true}}}
+		client, downloadCalls, _ := newServer(t, config, []int{http.StatusServiceUnavailable}, []int{10})
+		_, err := client.Download(ctx, DownloadRequest{FileID: 1})
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(downloadCalls))
+	})
+}
+
+func TestMiddlewares(t *testing.T) {
+	newClient := func(t *testing.T, handler http.HandlerFunc, middlewares ...RoundTripperFunc) *Client {
+		server := httptest.NewServer(handler)
+		t.Cleanup(server.Close)
+		client, err := NewClient(Config{
+			ApiKey:      "test-api-key",
+			BaseURL:     server.URL,
+			RateLimit:   &testRateLimits,
+			Middlewares: middlewares,
+		})
+		require.NoError(t, err)
+		return client
+	}
+	fastRetry := RetryPolicy{MaxRetries: 2, BaseBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	t.Run("RunInOrder", func(t *testing.T) {
+		var order []string
+		tag := func(name string) RoundTripperFunc {
+			return func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+				order = append(order, name+":before")
+				resp, err := next.RoundTrip(req)
+				order = append(order, name+":after")
+				return resp, err
+			}
+		}
+		addHeader := func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			req = req.Clone(req.Context())
+			req.Header.Set("X-Trace-Id", "abc")
+			return next.RoundTrip(req)
+		}
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "abc", r.Header.Get("X-Trace-Id"))
+			_, _ = w.Write([]byte(`{"data":{"user_id":7}}`))
+		}, tag("outer"), tag("inner"), addHeader)
+
+		_, err := client.GetUserInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, order)
+	})
+
+	t.Run("RetriesServerErrors", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			assert.JSONEq(t, `{"username":"user","password":"pass"}`, string(body), "body is replayed on retry")
+			if atomic.AddInt32(&calls, 1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"tok","base_url":"","status":200}`))
+		}, RetryMiddleware(fastRetry))
+		client.httpClient.SetIdempotencyPolicies(map[string]IdempotencyPolicy{"/login": {Retry: true}})
+
+		_, err := client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("BackoffDoesNotOverflow", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, RetryMiddleware(RetryPolicy{MaxRetries: 70, BaseBackoff: time.Nanosecond, MaxBackoff: time.Nanosecond}))
+
+		_, err := client.GetLanguages(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, int32(71), atomic.LoadInt32(&calls), "retries past the 63rd doubling of the backoff")
+	})
+
+	t.Run("DoesNotReplayDownloads", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, RetryMiddleware(fastRetry))
+
+		_, err := client.Download(context.Background(), DownloadRequest{FileID: 1})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "POST /download is not replayed while the quota is unknown")
+
+		calls = 0
+		_, err = client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "nor any other POST without a policy")
+	})
+
+	t.Run("GivesUpAfterMaxRetries", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, RetryMiddleware(fastRetry))
+
+		_, err := client.GetUserInfo(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "status 503")
+		assert.Equal(t, int32(fastRetry.MaxRetries+1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("DoesNotRetryClientErrors", func(t *testing.T) {
+		var calls int32
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&calls, 1)
+			w.WriteHeader(http.StatusNotFound)
+		}, RetryMiddleware(fastRetry))
+
+		_, err := client.GetUserInfo(context.Background())
+		require.Error(t, err)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("RetriesNetworkErrors", func(t *testing.T) {
+		var calls int32
+		flaky := func(req *http.Request, next http.RoundTripper) (*http.Response, error) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				return nil, errors.New("connection reset by peer")
+			}
+			return next.RoundTrip(req)
+		}
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(`{"data":{"user_id":7}}`))
+		}, RetryMiddleware(fastRetry), flaky)
+
+		_, err := client.GetUserInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("BackoffRespectsContext", func(t *testing.T) {
+		client := newClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}, RetryMiddleware(RetryPolicy{MaxRetries: 5, BaseBackoff: time.Hour, MaxBackoff: time.Hour}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err := client.GetUserInfo(ctx)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestLogger(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("RateLimit-Remaining", "4")
+		w.Header().Set("RateLimit-Reset", "1")
+		_, _ = w.Write([]byte(`{"token":"tok","status":200}`))
+	}))
+	t.Cleanup(server.Close)
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient(Config{
+		ApiKey:     "test-api-key",
+		BaseURL:    server.URL + "/api/v1",
+		RateLimit:  &testRateLimits,
+		Logger:     logger,
+		TokenStore: &FileTokenStore{Path: t.TempDir()}, // A directory: storing the token fails
+	})
+	require.NoError(t, err)
+
+	_, err = client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		records = append(records, record)
+	}
+	require.GreaterOrEqual(t, len(records), 2)
+	request := records[len(records)-2]
+	assert.Equal(t, "DEBUG", request["level"])
+	assert.Equal(t, "POST", request["method"])
+	assert.Equal(t, "/api/v1/login", request["path"])
+	assert.Equal(t, float64(200), request["status"])
+	assert.Equal(t, "4", request["ratelimit-remaining"])
+	assert.Contains(t, request, "duration")
+	warning := records[len(records)-1]
+	assert.Equal(t, "WARN", warning["level"])
+	assert.Equal(t, "opensubtitles: failed to store token", warning["msg"])
+}
+
+func TestLoggerHidesDownloadLinkQuery(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client, err := NewClient(Config{ApiKey: "test-api-key", RateLimit: &testRateLimits, Logger: logger})
+	require.NoError(t, err)
+
+	_, err = client.httpClient.FetchFile(context.Background(), server.URL+"/download/file.srt?token=secret-token")
+	require.Error(t, err)
+	assert.ErrorContains(t, err, server.URL+"/download/file.srt")
+	assert.NotContains(t, err.Error(), "secret-token")
+	assert.Contains(t, buf.String(), "request failed")
+	assert.NotContains(t, buf.String(), "secret-token")
+
+	_, err = client.httpClient.FetchFile(context.Background(), "http://[bad host/file.srt?token=secret-token")
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+}
+
+func TestNoLoggerIsSilent(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"token":"tok","status":200}`))
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, TokenStore: &FileTokenStore{Path: t.TempDir()}})
+	require.NoError(t, err)
+
+	_, err = client.Login(context.Background(), LoginRequest{Username: "user", Password: "pass"})
+	require.NoError(t, err)
+	assert.Empty(t, buf.String(), "the library must not write to the standard logger")
+}
@@ -0,0 +1,593 @@
+// Package opensubtitlestest provides a fake OpenSubtitles REST API server for
+// integration tests of applications built on this module. The Server implements
+// login, logout, user info, subtitle search and lookup by ID, download, upload, reports and subtitle
+// requests against an in-memory
+// catalog filled with AddSubtitle, so tests never reach the real API:
+//
+//	srv := opensubtitlestest.NewServer(opensubtitlestest.Options{})
+//	defer srv.Close()
+//	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{}), []byte("1\n00:00:01,000 --> 00:00:02,000\nHi\n"))
+//	client, err := srv.NewClient()
+package opensubtitlestest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/testutil"
+)
+
+const (
+	// APIKey is the Api-Key the Server accepts when Options.APIKey is not set.
+	APIKey = "test-api-key"
+	// Username and Password are the credentials accepted when Options.Users is not set.
+	Username = "user"
+	Password = "pass"
+	// DefaultDownloadQuota is the number of downloads per user (or anonymous caller)
+	// allowed when Options.DownloadQuota is not set.
+	DefaultDownloadQuota = 20
+	// PageSize is the number of subtitles per search results page, as on the real API.
+	PageSize = 60
+)
+
+// apiPath is the path prefix of the REST endpoints, as in opensubtitles.DefaultBaseURL.
+const apiPath = "/api/v1"
+
+// Options configures a Server.
+type Options struct {
+	// APIKey is the Api-Key header every API request must carry. Defaults to APIKey.
+	APIKey string
+	// Users maps the usernames accepted by /login to their passwords.
+	// Defaults to Username with Password.
+	Users map[string]string
+	// DownloadQuota is the number of downloads allowed per user before /download
+	// reports an exhausted quota. Defaults to DefaultDownloadQuota; negative is unlimited.
+	DownloadQuota int
+}
+
+// Request is an API request received by the Server.
+type Request struct {
+	Method string
+	Path   string // Without the /api/v1 prefix, e.g. "/subtitles"
+	Query  string // Raw query string
+	Token  string // Bearer token, empty for anonymous requests
+}
+
+// Upload is a subtitle received by the /upload endpoint.
+type Upload struct {
+	SubtitleID string
+	FileName   string
+	Content    []byte
+	Fields     map[string]string // Form fields, e.g. "sublanguageid" and "moviereleasename"
+}
+
+// Server is a fake OpenSubtitles API. The embedded httptest.Server must be closed
+// with Close. All methods are safe for concurrent use.
+type Server struct {
+	*httptest.Server
+	opts Options
+
+	mu        sync.Mutex // Protects the fields below
+	subtitles []opensubtitles.Subtitle
+	files     map[int][]byte    // File ID -> content
+	fileNames map[int]string    // File ID -> file name
+	tokens    map[string]string // Token -> username
+	downloads map[string]int    // Username ("" for anonymous) -> downloads used
+	uploads   []Upload
+	reports   []opensubtitles.ReportRequest
+	wanted    []opensubtitles.AddRequestParams
+	requests  []Request
+	failures  map[string]failure // "METHOD /path" -> injected failure
+	nextID    int
+}
+
+type failure struct {
+	status int
+	body   string
+}
+
+// NewServer starts a Server with an empty catalog.
+func NewServer(opts Options) *Server {
+	if opts.APIKey == "" {
+		opts.APIKey = APIKey
+	}
+	if opts.Users == nil {
+		opts.Users = map[string]string{Username: Password}
+	}
+	if opts.DownloadQuota == 0 {
+		opts.DownloadQuota = DefaultDownloadQuota
+	}
+	s := &Server{
+		opts:      opts,
+		files:     make(map[int][]byte),
+		fileNames: make(map[int]string),
+		tokens:    make(map[string]string),
+		downloads: make(map[string]int),
+		failures:  make(map[string]failure),
+		nextID:    9000001,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(apiPath+"/", s.serveAPI)
+	mux.HandleFunc("/files/", s.serveFile)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// BaseURL returns the value for opensubtitles.Config.BaseURL.
+func (s *Server) BaseURL() string {
+	return s.URL + apiPath
+}
+
+// NewClient returns a client for the Server with client-side rate limiting disabled.
+func (s *Server) NewClient() (*opensubtitles.Client, error) {
+	return opensubtitles.NewClient(opensubtitles.Config{
+		ApiKey:    s.opts.APIKey,
+		UserAgent: "opensubtitlestest/1.0",
+		BaseURL:   s.BaseURL(),
+		RateLimit: &opensubtitles.RateLimitConfig{},
+	})
+}
+
+// AddSubtitle adds sub to the catalog. Every file of sub is served with content;
+// testutil.NewSubtitle builds a fully populated sub.
+func (s *Server) AddSubtitle(sub opensubtitles.Subtitle, content []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subtitles = append(s.subtitles, sub)
+	for _, file := range sub.Attributes.Files {
+		s.files[file.FileID] = content
+		s.fileNames[file.FileID] = file.FileName
+	}
+}
+
+// Uploads returns the subtitles uploaded so far, in order.
+func (s *Server) Uploads() []Upload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Upload(nil), s.uploads...)
+}
+
+// Reports returns the subtitle reports received so far, in order.
+func (s *Server) Reports() []opensubtitles.ReportRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]opensubtitles.ReportRequest(nil), s.reports...)
+}
+
+// SubtitleRequests returns the requests for missing subtitles received so far, in order.
+func (s *Server) SubtitleRequests() []opensubtitles.AddRequestParams {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]opensubtitles.AddRequestParams(nil), s.wanted...)
+}
+
+// Requests returns the API requests received so far, in order.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// Fail makes every following request to the endpoint method and path (e.g. "GET",
+// "/subtitles") fail with status and body, until Fail is called for it again with
+// status 0. An empty body is replaced by a JSON message for the status.
+func (s *Server) Fail(method, path string, status int, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := method + " " + path
+	if status == 0 {
+		delete(s.failures, key)
+		return
+	}
+	if body == "" {
+		body = messageBody(http.StatusText(status))
+	}
+	s.failures[key] = failure{status: status, body: body}
+}
+
+// serveAPI authenticates and routes a REST API request.
+func (s *Server) serveAPI(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, apiPath)
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{Method: r.Method, Path: path, Query: r.URL.RawQuery, Token: token})
+	injected, failed := s.failures[r.Method+" "+path]
+	user, loggedIn := s.tokens[token]
+	s.mu.Unlock()
+
+	switch {
+	case r.Header.Get("Api-Key") != s.opts.APIKey:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "You cannot consume this service: invalid API key"})
+		return
+	case failed:
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(injected.status)
+		_, _ = io.WriteString(w, injected.body)
+		return
+	case token != "" && !loggedIn:
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "invalid token"})
+		return
+	}
+
+	switch r.Method + " " + path {
+	case "POST /login":
+		s.login(w, r)
+	case "DELETE /logout":
+		s.logout(w, token, loggedIn)
+	case "GET /infos/user":
+		s.userInfo(w, user, loggedIn)
+	case "GET /subtitles":
+		s.search(w, r)
+	case "POST /download":
+		s.download(w, r, user)
+	case "POST /upload":
+		s.upload(w, r, loggedIn)
+	case "POST /report":
+		s.report(w, r, loggedIn)
+	case "POST /requests":
+		s.addRequest(w, r, loggedIn)
+	default:
+		if id, ok := strings.CutPrefix(path, "/subtitles/"); ok && r.Method == http.MethodGet {
+			s.subtitle(w, id)
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "not found"})
+	}
+}
+
+func (s *Server) login(w http.ResponseWriter, r *http.Request) {
+	var req opensubtitles.LoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid JSON body"})
+		return
+	}
+	password, ok := s.opts.Users[req.Username]
+	if !ok || password != req.Password {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Error, invalid username/password"})
+		return
+	}
+
+	s.mu.Lock()
+	token := fmt.Sprintf("token-%d", len(s.tokens)+1)
+	s.tokens[token] = req.Username
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, opensubtitles.LoginResponse{
+		User:    opensubtitles.LoginUser{BaseUserInfo: s.baseUserInfo(req.Username)},
+		BaseURL: s.BaseURL(),
+		Token:   token,
+		Status:  http.StatusOK,
+	})
+}
+
+func (s *Server) logout(w http.ResponseWriter, token string, loggedIn bool) {
+	if !loggedIn {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "not logged in"})
+		return
+	}
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, opensubtitles.LogoutResponse{Message: "token successfully destroyed", Status: http.StatusOK})
+}
+
+func (s *Server) userInfo(w http.ResponseWriter, user string, loggedIn bool) {
+	if !loggedIn {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "not logged in"})
+		return
+	}
+	s.mu.Lock()
+	info := opensubtitles.UserInfo{
+		BaseUserInfo:       s.baseUserInfo(user),
+		DownloadsCount:     s.downloads[user],
+		RemainingDownloads: s.remainingLocked(user),
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, opensubtitles.GetUserInfoResponse{Data: info})
+}
+
+// baseUserInfo describes user; every user gets the configured download quota.
+func (s *Server) baseUserInfo(user string) opensubtitles.BaseUserInfo {
+	return opensubtitles.BaseUserInfo{
+		AllowedDownloads: s.opts.DownloadQuota,
+		Level:            "Sub leecher",
+		UserID:           len(user) + 1000,
+	}
+}
+
+// search filters the catalog by the parameters SearchSubtitlesParams sends most often.
+func (s *Server) subtitle(w http.ResponseWriter, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subtitles {
+		if sub.ID == id {
+			writeJSON(w, http.StatusOK, map[string]opensubtitles.Subtitle{"data": sub})
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "subtitle not found"})
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	s.mu.Lock()
+	var matches []opensubtitles.Subtitle
+	for _, sub := range s.subtitles {
+		if subtitleMatches(sub, q.Get) {
+			matches = append(matches, sub)
+		}
+	}
+	s.mu.Unlock()
+
+	page := 1
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 1 {
+		page = p
+	}
+	totalPages := (len(matches) + PageSize - 1) / PageSize
+	start := min((page-1)*PageSize, len(matches))
+	end := min(start+PageSize, len(matches))
+	writeJSON(w, http.StatusOK, opensubtitles.SearchSubtitlesResponse{
+		PaginatedResponse: opensubtitles.PaginatedResponse{
+			TotalPages: totalPages,
+			TotalCount: len(matches),
+			PerPage:    PageSize,
+			Page:       page,
+		},
+		Data: append([]opensubtitles.Subtitle{}, matches[start:end]...),
+	})
+}
+
+// subtitleMatches reports whether sub matches the search query parameters.
+func subtitleMatches(sub opensubtitles.Subtitle, param func(string) string) bool {
+	attrs := sub.Attributes
+	details := attrs.FeatureDetails
+	if langs := param("languages"); langs != "" && !containsFold(strings.Split(langs, ","), string(attrs.Language)) {
+		return false
+	}
+	if query := strings.ToLower(param("query")); query != "" &&
+		!strings.Contains(strings.ToLower(details.Title), query) &&
+		!strings.Contains(strings.ToLower(details.MovieName), query) &&
+		!strings.Contains(strings.ToLower(attrs.Release), query) {
+		return false
+	}
+	intFilters := []struct {
+		name  string
+		value *int
+	}{
+		{"id", &details.FeatureID},
+		{"imdb_id", details.IMDbID},
+		{"tmdb_id", details.TMDBID},
+		{"parent_imdb_id", details.ParentIMDbID},
+		{"season_number", details.SeasonNumber},
+		{"episode_number", details.EpisodeNumber},
+		{"year", &details.Year},
+	}
+	for _, f := range intFilters {
+		if raw := param(f.name); raw != "" {
+			want, err := strconv.Atoi(raw)
+			if err != nil || f.value == nil || *f.value != want {
+				return false
+			}
+		}
+	}
+	switch param("type") {
+	case "movie":
+		return details.FeatureType == opensubtitles.FeatureMovie
+	case "episode":
+		return details.FeatureType == opensubtitles.FeatureEpisode
+	}
+	return true
+}
+
+func (s *Server) download(w http.ResponseWriter, r *http.Request, user string) {
+	var req opensubtitles.DownloadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid JSON body"})
+		return
+	}
+	resetTime := nextReset(time.Now())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	name, ok := s.fileNames[req.FileID]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "file not found"})
+		return
+	}
+	if s.remainingLocked(user) == 0 {
+		writeJSON(w, http.StatusNotAcceptable, opensubtitles.DownloadResponse{
+			Requests:     s.downloads[user],
+			Message:      fmt.Sprintf("You have downloaded your allowed %d subtitles for 24h.", s.opts.DownloadQuota),
+			ResetTime:    time.Until(resetTime).Round(time.Minute).String(),
+			ResetTimeUTC: resetTime,
+		})
+		return
+	}
+	s.downloads[user]++
+	if req.FileName != nil && *req.FileName != "" {
+		name = *req.FileName
+	}
+	writeJSON(w, http.StatusOK, opensubtitles.DownloadResponse{
+		Link:         fmt.Sprintf("%s/files/%d/%s", s.URL, req.FileID, name),
+		FileName:     name,
+		Requests:     s.downloads[user],
+		Remaining:    s.remainingLocked(user),
+		Message:      "Your quota will be renewed in " + time.Until(resetTime).Round(time.Minute).String(),
+		ResetTime:    time.Until(resetTime).Round(time.Minute).String(),
+		ResetTimeUTC: resetTime,
+	})
+}
+
+// remainingLocked returns the downloads user has left, or -1 if unlimited. s.mu must be held.
+func (s *Server) remainingLocked(user string) int {
+	if s.opts.DownloadQuota < 0 {
+		return -1
+	}
+	return max(s.opts.DownloadQuota-s.downloads[user], 0)
+}
+
+// serveFile serves the content of a file from a download link: /files/{id}/{name}.
+func (s *Server) serveFile(w http.ResponseWriter, r *http.Request) {
+	idPart, _, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/files/"), "/")
+	id, err := strconv.Atoi(idPart)
+	s.mu.Lock()
+	content, ok := s.files[id]
+	s.mu.Unlock()
+	if err != nil || !ok {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "application/x-subrip")
+	_, _ = w.Write(content)
+}
+
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, loggedIn bool) {
+	if !loggedIn {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "not logged in"})
+		return
+	}
+	if err := r.ParseMultipartForm(32 << 20); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid multipart form"})
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string][]string{"errors": {"file is required"}})
+		return
+	}
+	defer file.Close()
+	content, err := io.ReadAll(file)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "failed to read file"})
+		return
+	}
+	fields := make(map[string]string, len(r.MultipartForm.Value))
+	for name, values := range r.MultipartForm.Value {
+		fields[name] = values[0]
+	}
+	if fields["sublanguageid"] == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]map[string][]string{"errors": {"sublanguageid": {"is required"}}})
+		return
+	}
+
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID += 2 // The file ID is id+1
+	imdbID, _ := strconv.Atoi(fields["idmovieimdb"])
+	sub := testutil.NewSubtitle(testutil.SubtitleOptions{
+		ID:              strconv.Itoa(id),
+		Language:        opensubtitles.LanguageCode(fields["sublanguageid"]),
+		IMDbID:          imdbID,
+		Release:         fields["moviereleasename"],
+		FileName:        header.Filename,
+		UploadDate:      time.Now().UTC().Truncate(time.Second),
+		HearingImpaired: fields["hearingimpaired"] == "1",
+	})
+	s.subtitles = append(s.subtitles, sub)
+	s.files[id+1] = content
+	s.fileNames[id+1] = header.Filename
+	s.uploads = append(s.uploads, Upload{SubtitleID: sub.ID, FileName: header.Filename, Content: content, Fields: fields})
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, opensubtitles.UploadResponse{
+		Status:     http.StatusOK,
+		Message:    "Subtitle uploaded",
+		SubtitleID: sub.ID,
+		URL:        sub.Attributes.URL,
+	})
+}
+
+func (s *Server) report(w http.ResponseWriter, r *http.Request, loggedIn bool) {
+	var req opensubtitles.ReportRequest
+	if !decodeAuthenticated(w, r, loggedIn, &req) {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.hasSubtitleLocked(req.SubtitleID) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "subtitle not found"})
+		return
+	}
+	s.reports = append(s.reports, req)
+	writeJSON(w, http.StatusOK, opensubtitles.ReportResponse{Status: http.StatusOK, Message: "Report received"})
+}
+
+func (s *Server) addRequest(w http.ResponseWriter, r *http.Request, loggedIn bool) {
+	var req opensubtitles.AddRequestParams
+	if !decodeAuthenticated(w, r, loggedIn, &req) {
+		return
+	}
+	s.mu.Lock()
+	s.wanted = append(s.wanted, req)
+	id := len(s.wanted)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, opensubtitles.AddRequestResponse{
+		Status:  http.StatusOK,
+		Message: "Request added",
+		Data: opensubtitles.SubtitleRequest{
+			RequestID: strconv.Itoa(id),
+			FeatureID: req.FeatureID,
+			Languages: req.Languages,
+			Release:   req.Release,
+			CreatedAt: time.Now().UTC().Truncate(time.Second),
+		},
+	})
+}
+
+// hasSubtitleLocked reports whether the catalog holds the subtitle id. s.mu must be held.
+func (s *Server) hasSubtitleLocked(id string) bool {
+	for _, sub := range s.subtitles {
+		if sub.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeAuthenticated decodes the JSON body of a request that requires login into v.
+// It writes the error response and returns false if that fails.
+func decodeAuthenticated(w http.ResponseWriter, r *http.Request, loggedIn bool, v interface{}) bool {
+	if !loggedIn {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "not logged in"})
+		return false
+	}
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid JSON body"})
+		return false
+	}
+	return true
+}
+
+// nextReset returns the next midnight UTC, when the real API renews download quotas.
+func nextReset(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func messageBody(message string) string {
+	data, _ := json.Marshal(map[string]string{"message": message})
+	return string(data)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
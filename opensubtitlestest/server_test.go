@@ -0,0 +1,195 @@
+package opensubtitlestest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const srt = "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+
+func newTestServer(t *testing.T, opts Options) (*Server, *opensubtitles.Client) {
+	srv := NewServer(opts)
+	t.Cleanup(srv.Close)
+	client, err := srv.NewClient()
+	require.NoError(t, err)
+	return srv, client
+}
+
+func TestLoginLogout(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	_, err := client.Login(ctx, opensubtitles.LoginRequest{Username: Username, Password: "wrong"})
+	assert.ErrorIs(t, err, opensubtitles.ErrUnauthorized)
+
+	resp, err := client.Login(ctx, opensubtitles.LoginRequest{Username: Username, Password: Password})
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	assert.Equal(t, srv.BaseURL(), resp.BaseURL)
+
+	info, err := client.GetUserInfo(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, DefaultDownloadQuota, info.Data.RemainingDownloads)
+
+	_, err = client.Logout(ctx)
+	require.NoError(t, err)
+	_, err = client.GetUserInfo(ctx)
+	assert.ErrorIs(t, err, opensubtitles.ErrUnauthorized)
+}
+
+func TestInvalidAPIKey(t *testing.T) {
+	srv := NewServer(Options{APIKey: "secret"})
+	t.Cleanup(srv.Close)
+	client, err := opensubtitles.NewClient(opensubtitles.Config{ApiKey: "other", BaseURL: srv.BaseURL(), RateLimit: &opensubtitles.RateLimitConfig{}})
+	require.NoError(t, err)
+
+	_, err = client.SearchSubtitles(context.Background(), opensubtitles.SearchSubtitlesParams{})
+	assert.ErrorIs(t, err, opensubtitles.ErrInvalidApiKey)
+}
+
+func TestSearchFilters(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "1", Title: "Heat", Year: 1995, IMDbID: 113277}), []byte(srt))
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "3", Title: "Heat", Year: 1995, IMDbID: 113277, Language: "el"}), []byte(srt))
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "5", Title: "Dune", Year: 2021, IMDbID: 1160419}), []byte(srt))
+	ctx := context.Background()
+
+	query, langs, imdbID := "heat", "el", 113277
+	resp, err := client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{Query: &query})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.TotalCount)
+
+	resp, err = client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{IMDbID: &imdbID, Languages: &langs})
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 1)
+	assert.Equal(t, "3", resp.Data[0].ID)
+
+	requests := srv.Requests()
+	require.Len(t, requests, 2)
+	assert.Equal(t, "/subtitles", requests[1].Path)
+	assert.Contains(t, requests[1].Query, "languages=el")
+}
+
+func TestGetSubtitleByID(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "7", Title: "Heat"}), []byte(srt))
+	ctx := context.Background()
+
+	sub, err := client.GetSubtitleByID(ctx, "7")
+	require.NoError(t, err)
+	assert.Equal(t, "7", sub.ID)
+	assert.Equal(t, "Heat", sub.Attributes.FeatureDetails.Title)
+
+	_, err = client.GetSubtitleByID(ctx, "8")
+	assert.ErrorIs(t, err, opensubtitles.ErrNotFound)
+}
+
+func TestSearchPagination(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	for i := 0; i < PageSize+5; i++ {
+		srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "1", FileID: i + 1}), nil)
+	}
+	page := 2
+	resp, err := client.SearchSubtitles(context.Background(), opensubtitles.SearchSubtitlesParams{Page: &page})
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.TotalPages)
+	assert.Equal(t, 2, resp.Page)
+	assert.Len(t, resp.Data, 5)
+}
+
+func TestDownloadQuota(t *testing.T) {
+	srv, client := newTestServer(t, Options{DownloadQuota: 1})
+	sub := testutil.NewSubtitle(testutil.SubtitleOptions{})
+	srv.AddSubtitle(sub, []byte(srt))
+	ctx := context.Background()
+	fileID := sub.Attributes.Files[0].FileID
+
+	link, err := client.Download(ctx, opensubtitles.DownloadRequest{FileID: fileID})
+	require.NoError(t, err)
+	assert.Equal(t, 0, link.Remaining)
+	assert.Equal(t, sub.Attributes.Files[0].FileName, link.FileName)
+
+	resp, err := http.Get(link.Link)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, srt, string(body))
+
+	_, err = client.Download(ctx, opensubtitles.DownloadRequest{FileID: fileID})
+	assert.ErrorIs(t, err, opensubtitles.ErrQuotaExceeded)
+
+	_, err = client.Download(ctx, opensubtitles.DownloadRequest{FileID: 42})
+	assert.ErrorIs(t, err, opensubtitles.ErrNotFound)
+}
+
+func TestUpload(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	ctx := context.Background()
+	params := opensubtitles.UploadParams{FileName: "Heat.1995.en.srt", Language: "en", IMDbID: 113277}
+
+	_, err := client.UploadSubtitle(ctx, params, strings.NewReader(srt))
+	assert.ErrorIs(t, err, opensubtitles.ErrUnauthorized)
+
+	_, err = client.Login(ctx, opensubtitles.LoginRequest{Username: Username, Password: Password})
+	require.NoError(t, err)
+	resp, err := client.UploadSubtitle(ctx, params, strings.NewReader(srt))
+	require.NoError(t, err)
+
+	uploads := srv.Uploads()
+	require.Len(t, uploads, 1)
+	assert.Equal(t, resp.SubtitleID, uploads[0].SubtitleID)
+	assert.Equal(t, srt, string(uploads[0].Content))
+	assert.Equal(t, "113277", uploads[0].Fields["idmovieimdb"])
+
+	// Uploaded subtitles become searchable.
+	imdbID := 113277
+	found, err := client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{IMDbID: &imdbID})
+	require.NoError(t, err)
+	require.Len(t, found.Data, 1)
+	assert.Equal(t, resp.SubtitleID, found.Data[0].ID)
+}
+
+func TestFail(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	ctx := context.Background()
+
+	srv.Fail(http.MethodGet, "/subtitles", http.StatusServiceUnavailable, "")
+	_, err := client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{})
+	var apiErr *opensubtitles.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.ErrorIs(t, err, opensubtitles.ErrServiceUnavailable)
+
+	srv.Fail(http.MethodGet, "/subtitles", 0, "")
+	_, err = client.SearchSubtitles(ctx, opensubtitles.SearchSubtitlesParams{})
+	assert.NoError(t, err)
+}
+
+func TestReportAndAddRequest(t *testing.T) {
+	srv, client := newTestServer(t, Options{})
+	srv.AddSubtitle(testutil.NewSubtitle(testutil.SubtitleOptions{ID: "7"}), []byte(srt))
+	ctx := context.Background()
+	_, err := client.Login(ctx, opensubtitles.LoginRequest{Username: Username, Password: Password})
+	require.NoError(t, err)
+
+	_, err = client.ReportSubtitle(ctx, opensubtitles.ReportRequest{SubtitleID: "8", Reason: opensubtitles.ReportWrongSync})
+	assert.ErrorIs(t, err, opensubtitles.ErrNotFound)
+	_, err = client.ReportSubtitle(ctx, opensubtitles.ReportRequest{SubtitleID: "7", Reason: opensubtitles.ReportWrongSync})
+	require.NoError(t, err)
+	assert.Equal(t, []opensubtitles.ReportRequest{{SubtitleID: "7", Reason: opensubtitles.ReportWrongSync}}, srv.Reports())
+
+	resp, err := client.AddRequest(ctx, opensubtitles.AddRequestParams{FeatureID: 100001, Languages: []opensubtitles.LanguageCode{"el"}})
+	require.NoError(t, err)
+	assert.Equal(t, "1", resp.Data.RequestID)
+	require.Len(t, srv.SubtitleRequests(), 1)
+}
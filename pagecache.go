@@ -0,0 +1,151 @@
+package opensubtitles
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// DefaultSearchPageCacheSize is the number of pages kept by a SearchPageCache
+// created with a non-positive capacity.
+const DefaultSearchPageCacheSize = 32
+
+// SearchPageCache is an LRU cache of SearchSubtitles result pages, keyed by the
+// search parameters and the page number. It lets pagination helpers and UI layers
+// move back and forth between pages without re-fetching them.
+// A SearchPageCache is safe for concurrent use.
+type SearchPageCache struct {
+	mu       sync.Mutex
+	capacity int
+	ll       *list.List               // Front is most recently used
+	items    map[string]*list.Element // Keyed by searchKey + page
+}
+
+// pageCacheEntry is the value stored in the LRU list.
+type pageCacheEntry struct {
+	searchKey string
+	page      int
+	response  *SearchSubtitlesResponse
+}
+
+// NewSearchPageCache creates a page cache holding at most capacity pages.
+func NewSearchPageCache(capacity int) *SearchPageCache {
+	if capacity <= 0 {
+		capacity = DefaultSearchPageCacheSize
+	}
+	return &SearchPageCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get returns the cached response for the page selected by params, if present.
+func (c *SearchPageCache) Get(params SearchSubtitlesParams) (*SearchSubtitlesResponse, bool) {
+	searchKey, page := pageCacheKey(params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.items[entryKey(searchKey, page)]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(elem)
+	return elem.Value.(*pageCacheEntry).response, true
+}
+
+// Put stores the response for the page selected by params, evicting the least
+// recently used page if the cache is full.
+func (c *SearchPageCache) Put(params SearchSubtitlesParams, response *SearchSubtitlesResponse) {
+	if response == nil {
+		return
+	}
+	searchKey, page := pageCacheKey(params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := entryKey(searchKey, page)
+	if elem, ok := c.items[key]; ok {
+		elem.Value.(*pageCacheEntry).response = response
+		c.ll.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.ll.PushFront(&pageCacheEntry{searchKey: searchKey, page: page, response: response})
+	for c.ll.Len() > c.capacity {
+		c.removeElement(c.ll.Back())
+	}
+}
+
+// Invalidate drops every cached page of the search described by params
+// (the Page field is ignored). Call it when the user changes languages or filters.
+func (c *SearchPageCache) Invalidate(params SearchSubtitlesParams) {
+	searchKey, _ := pageCacheKey(params)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for elem := c.ll.Front(); elem != nil; {
+		next := elem.Next()
+		if elem.Value.(*pageCacheEntry).searchKey == searchKey {
+			c.removeElement(elem)
+		}
+		elem = next
+	}
+}
+
+// Purge removes all cached pages.
+func (c *SearchPageCache) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ll.Init()
+	c.items = make(map[string]*list.Element)
+}
+
+// Len returns the number of cached pages.
+func (c *SearchPageCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.ll.Len()
+}
+
+// removeElement unlinks elem from the list and the index. Caller must hold c.mu.
+func (c *SearchPageCache) removeElement(elem *list.Element) {
+	entry := c.ll.Remove(elem).(*pageCacheEntry)
+	delete(c.items, entryKey(entry.searchKey, entry.page))
+}
+
+// pageCacheKey returns the normalized key of the search (without the page) and the page number.
+func pageCacheKey(params SearchSubtitlesParams) (string, int) {
+	page := 1
+	if params.Page != nil && *params.Page > 0 {
+		page = *params.Page
+	}
+	params.Page = nil                 // params is a copy
+	return params.EncodeQuery(), page // Keys are sorted, so equal searches share a key
+}
+
+func entryKey(searchKey string, page int) string {
+	return fmt.Sprintf("%s#%d", searchKey, page)
+}
+
+// SearchSubtitlesCached behaves like SearchSubtitles but serves pages from cache when
+// available and stores freshly fetched pages in it. A nil cache disables caching.
+func (c *Client) SearchSubtitlesCached(ctx context.Context, cache *SearchPageCache, params SearchSubtitlesParams) (*SearchSubtitlesResponse, error) {
+	params, err := params.Normalize() // Equivalent searches share cache entries
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		if response, ok := cache.Get(params); ok {
+			return response, nil
+		}
+	}
+	response, err := c.SearchSubtitles(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cache.Put(params, response)
+	}
+	return response, nil
+}
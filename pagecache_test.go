@@ -0,0 +1,103 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchPageCache(t *testing.T) {
+	t.Run("GetPutAndPageDefault", func(t *testing.T) {
+		cache := NewSearchPageCache(4)
+		params := SearchSubtitlesParams{Query: String("inception"), Languages: String("en")}
+		resp := &SearchSubtitlesResponse{PaginatedResponse: PaginatedResponse{Page: 1}}
+
+		_, ok := cache.Get(params)
+		assert.False(t, ok)
+
+		cache.Put(params, resp)
+		got, ok := cache.Get(params)
+		require.True(t, ok)
+		assert.Same(t, resp, got)
+
+		// An explicit page 1 is the same page as an omitted page.
+		params.Page = pint(1)
+		got, ok = cache.Get(params)
+		require.True(t, ok)
+		assert.Same(t, resp, got)
+
+		params.Page = pint(2)
+		_, ok = cache.Get(params)
+		assert.False(t, ok)
+	})
+
+	t.Run("EvictsLeastRecentlyUsed", func(t *testing.T) {
+		cache := NewSearchPageCache(2)
+		p1 := SearchSubtitlesParams{Query: String("q"), Page: pint(1)}
+		p2 := SearchSubtitlesParams{Query: String("q"), Page: pint(2)}
+		p3 := SearchSubtitlesParams{Query: String("q"), Page: pint(3)}
+
+		cache.Put(p1, &SearchSubtitlesResponse{})
+		cache.Put(p2, &SearchSubtitlesResponse{})
+		_, _ = cache.Get(p1) // p1 becomes most recently used
+		cache.Put(p3, &SearchSubtitlesResponse{})
+
+		assert.Equal(t, 2, cache.Len())
+		_, ok := cache.Get(p2)
+		assert.False(t, ok, "page 2 should have been evicted")
+		_, ok = cache.Get(p1)
+		assert.True(t, ok)
+		_, ok = cache.Get(p3)
+		assert.True(t, ok)
+	})
+
+	t.Run("InvalidateDropsAllPagesOfSearch", func(t *testing.T) {
+		cache := NewSearchPageCache(8)
+		en := SearchSubtitlesParams{Query: String("q"), Languages: String("en")}
+		el := SearchSubtitlesParams{Query: String("q"), Languages: String("el")}
+		for page := 1; page <= 3; page++ {
+			en.Page = pint(page)
+			cache.Put(en, &SearchSubtitlesResponse{})
+		}
+		cache.Put(el, &SearchSubtitlesResponse{})
+
+		en.Page = pint(2) // Page is ignored by Invalidate
+		cache.Invalidate(en)
+
+		assert.Equal(t, 1, cache.Len())
+		_, ok := cache.Get(el)
+		assert.True(t, ok)
+
+		cache.Purge()
+		assert.Equal(t, 0, cache.Len())
+	})
+}
+
+func TestSearchSubtitlesCached(t *testing.T) {
+	calls := 0
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"total_count": 0, "page": 1, "total_pages": 1, "data": []}`))
+	}
+
+	_, client := setupTestServer(t, handler)
+	cache := NewSearchPageCache(4)
+	params := SearchSubtitlesParams{Query: String("inception")}
+
+	first, err := client.SearchSubtitlesCached(context.Background(), cache, params)
+	require.NoError(t, err)
+	second, err := client.SearchSubtitlesCached(context.Background(), cache, params)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, calls, "second call should be served from cache")
+	assert.Same(t, first, second)
+
+	cache.Invalidate(params)
+	_, err = client.SearchSubtitlesCached(context.Background(), cache, params)
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
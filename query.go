@@ -0,0 +1,87 @@
+package opensubtitles
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+)
+
+// Ensure the hot-path parameter structs bypass reflection-based query encoding.
+var _ httpclient.QueryEncoder = SearchSubtitlesParams{}
+
+// EncodeQuery encodes the parameters as a URL query string without reflection.
+// The output is identical to encoding the struct's `url` tags with go-querystring
+// followed by url.Values.Encode: keys sorted, nil fields omitted.
+func (p SearchSubtitlesParams) EncodeQuery() string {
+	var b strings.Builder
+	b.Grow(128)
+	// Keys must stay in sorted order.
+	if p.AITranslated != nil {
+		appendString(&b, "ai_translated", string(*p.AITranslated))
+	}
+	appendInt(&b, "episode_number", p.EpisodeNumber)
+	if p.ForeignPartsOnly != nil {
+		appendString(&b, "foreign_parts_only", string(*p.ForeignPartsOnly))
+	}
+	if p.HearingImpaired != nil {
+		appendString(&b, "hearing_impaired", string(*p.HearingImpaired))
+	}
+	appendInt(&b, "id", p.ID)
+	appendInt(&b, "imdb_id", p.IMDbID)
+	appendStringPtr(&b, "languages", p.Languages)
+	if p.MachineTranslated != nil {
+		appendString(&b, "machine_translated", string(*p.MachineTranslated))
+	}
+	appendStringPtr(&b, "moviehash", p.Moviehash)
+	appendStringPtr(&b, "moviehash_match", p.MoviehashMatch)
+	if p.OrderBy != nil {
+		appendString(&b, "order_by", string(*p.OrderBy))
+	}
+	if p.OrderDirection != nil {
+		appendString(&b, "order_direction", string(*p.OrderDirection))
+	}
+	appendInt(&b, "page", p.Page)
+	appendInt(&b, "parent_feature_id", p.ParentFeatureID)
+	appendInt(&b, "parent_imdb_id", p.ParentIMDbID)
+	appendInt(&b, "parent_tmdb_id", p.ParentTMDBID)
+	appendStringPtr(&b, "query", p.Query)
+	appendInt(&b, "season_number", p.SeasonNumber)
+	appendInt(&b, "tmdb_id", p.TMDBID)
+	if p.TrustedSources != nil {
+		appendString(&b, "trusted_sources", string(*p.TrustedSources))
+	}
+	appendStringPtr(&b, "type", p.Type)
+	appendInt(&b, "uploader_id", p.UploaderID)
+	appendInt(&b, "year", p.Year)
+	return b.String()
+}
+
+func appendKey(b *strings.Builder, key string) {
+	if b.Len() > 0 {
+		b.WriteByte('&')
+	}
+	b.WriteString(key) // Keys are plain ASCII and need no escaping
+	b.WriteByte('=')
+}
+
+func appendString(b *strings.Builder, key, value string) {
+	appendKey(b, key)
+	b.WriteString(url.QueryEscape(value))
+}
+
+func appendStringPtr(b *strings.Builder, key string, value *string) {
+	if value != nil {
+		appendString(b, key, *value)
+	}
+}
+
+func appendInt(b *strings.Builder, key string, value *int) {
+	if value == nil {
+		return
+	}
+	appendKey(b, key)
+	var buf [20]byte
+	b.Write(strconv.AppendInt(buf[:0], int64(*value), 10))
+}
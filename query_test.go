@@ -0,0 +1,63 @@
+package opensubtitles
+
+import (
+	"testing"
+
+	"github.com/google/go-querystring/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fullSearchParams() SearchSubtitlesParams {
+	i := func(v int) *int { return &v }
+	s := func(v string) *string { return &v }
+	include, only := Include, Only
+	trusted := OnlyTrusted
+	desc, orderBy := SortDesc, OrderByDownloadCount
+	return SearchSubtitlesParams{
+		ID: i(1), IMDbID: i(2), TMDBID: i(3), ParentIMDbID: i(4), ParentTMDBID: i(5), ParentFeatureID: i(6),
+		Query:        s("the matrix & co/100%"),
+		SeasonNumber: i(1), EpisodeNumber: i(-2),
+		Moviehash: s("8e245d9679d31e12"), Languages: s("el,en"), Type: s("movie"), Year: i(1999),
+		AITranslated: &include, MachineTranslated: &include,
+		HearingImpaired: &only, ForeignPartsOnly: &only, TrustedSources: &trusted,
+		MoviehashMatch: s("only"), UploaderID: i(47), OrderBy: &orderBy, OrderDirection: &desc, Page: i(3),
+	}
+}
+
+func reflectEncode(t testing.TB, params SearchSubtitlesParams) string {
+	v, err := query.Values(params)
+	require.NoError(t, err)
+	return v.Encode()
+}
+
+func TestSearchSubtitlesParamsEncodeQuery(t *testing.T) {
+	empty := ""
+	cases := map[string]SearchSubtitlesParams{
+		"Empty":       {},
+		"Full":        fullSearchParams(),
+		"QueryOnly":   {Query: fullSearchParams().Query},
+		"EmptyString": {Languages: &empty},
+	}
+	for name, params := range cases {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, reflectEncode(t, params), params.EncodeQuery())
+		})
+	}
+}
+
+func BenchmarkSearchSubtitlesParamsEncode(b *testing.B) {
+	params := fullSearchParams()
+	b.Run("Reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = reflectEncode(b, params)
+		}
+	})
+	b.Run("EncodeQuery", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_ = params.EncodeQuery()
+		}
+	})
+}
@@ -0,0 +1,187 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+)
+
+// RateLimitStatus is the rate limit state reported in the headers of an API response.
+// Nil fields were not reported; a zero Remaining means no requests are left.
+type RateLimitStatus struct {
+	Limit              *int           // RateLimit-Limit: requests allowed in the current window
+	Remaining          *int           // RateLimit-Remaining: requests left in the current window
+	Reset              *time.Duration // RateLimit-Reset: time until the window resets
+	LimitPerSecond     *int           // X-RateLimit-Limit-Second
+	RemainingPerSecond *int           // X-RateLimit-Remaining-Second
+	RetryAfter         *time.Duration // Retry-After, sent with 429 and 503 responses
+	Endpoint           string         // API path of the response, e.g. "/subtitles"
+	ObservedAt         time.Time
+}
+
+// DownloadQuota is the download quota reported by Download and GetUserInfo.
+type DownloadQuota struct {
+	Remaining  int
+	ResetTime  time.Time // Zero if not reported, as by GetUserInfo
+	ObservedAt time.Time
+}
+
+// ResponseMeta receives the status and headers of the API responses of a call; see
+// WithResponseMeta.
+type ResponseMeta struct {
+	Method     string
+	Endpoint   string // API path, e.g. "/download"
+	StatusCode int
+	Header     http.Header
+	RateLimit  RateLimitStatus
+	// DownloadQuota is set by Download and GetUserInfo.
+	DownloadQuota *DownloadQuota
+}
+
+type responseMetaKey struct{}
+
+// WithResponseMeta returns a context that makes the client fill meta with the last API
+// response of calls made with it. A call that retries or re-authenticates overwrites
+// meta with each response; a call answered without a request, e.g. from the download
+// link cache, leaves it unchanged. Use one ResponseMeta per call: it is not safe for
+// concurrent use.
+func WithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, meta)
+}
+
+// responseMetaFrom returns the ResponseMeta of ctx, or nil.
+func responseMetaFrom(ctx context.Context) *ResponseMeta {
+	meta, _ := ctx.Value(responseMetaKey{}).(*ResponseMeta)
+	return meta
+}
+
+// LastRateLimit returns the rate limit state of the last API response that reported
+// one, so schedulers can pace their requests to the server's actual limits.
+// ok is false until such a response was received.
+func (c *Client) LastRateLimit() (status RateLimitStatus, ok bool) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.lastRateLimit == nil {
+		return RateLimitStatus{}, false
+	}
+	return *c.lastRateLimit, true
+}
+
+// LastDownloadQuota returns the download quota last reported by Download or
+// GetUserInfo. ok is false until one of them succeeded.
+func (c *Client) LastDownloadQuota() (quota DownloadQuota, ok bool) {
+	c.statusMu.Lock()
+	defer c.statusMu.Unlock()
+	if c.lastQuota == nil {
+		return DownloadQuota{}, false
+	}
+	return *c.lastQuota, true
+}
+
+// RateLimitStats is the state of the client-side rate limit of one endpoint class.
+// Rate starts at the configured rate and follows the X-RateLimit-Limit-Second header
+// of the responses; a bucket is emptied when the server reports no requests left.
+type RateLimitStats = httpclient.RateLimitStats
+
+// Stats is a snapshot of the client's rate limiting.
+type Stats struct {
+	// RateLimits holds the limiter of each endpoint class ("default", "login" and
+	// "download"); classes disabled in Config.RateLimit are missing.
+	RateLimits    map[string]RateLimitStats
+	LastRateLimit *RateLimitStatus // Nil until a response reported one
+	DownloadQuota *DownloadQuota   // Nil until Download or GetUserInfo succeeded
+}
+
+// Stats returns the learned and configured rate limits and the last reported limits
+// and quota.
+func (c *Client) Stats() Stats {
+	stats := Stats{RateLimits: c.httpClient.RateLimitStats()}
+	if status, ok := c.LastRateLimit(); ok {
+		stats.LastRateLimit = &status
+	}
+	if quota, ok := c.LastDownloadQuota(); ok {
+		stats.DownloadQuota = &quota
+	}
+	return stats
+}
+
+// observeResponse records the rate limit headers of an API response and fills the
+// ResponseMeta of ctx.
+func (c *Client) observeResponse(ctx context.Context, method, endpoint string, status int, header http.Header) {
+	now := time.Now()
+	limits, ok := parseRateLimitStatus(header, now)
+	limits.Endpoint = endpoint
+	if ok {
+		c.statusMu.Lock()
+		c.lastRateLimit = &limits
+		c.statusMu.Unlock()
+	}
+	if meta := responseMetaFrom(ctx); meta != nil {
+		*meta = ResponseMeta{
+			Method:     method,
+			Endpoint:   endpoint,
+			StatusCode: status,
+			Header:     header.Clone(),
+			RateLimit:  limits,
+		}
+	}
+}
+
+// reportQuota records the download quota returned by Download or GetUserInfo and
+// reports it to the metrics, if configured.
+func (c *Client) reportQuota(ctx context.Context, remaining int, reset time.Time) {
+	quota := DownloadQuota{Remaining: remaining, ResetTime: reset, ObservedAt: time.Now()}
+	c.statusMu.Lock()
+	c.lastQuota = &quota
+	c.statusMu.Unlock()
+	if meta := responseMetaFrom(ctx); meta != nil {
+		meta.DownloadQuota = &quota
+	}
+	if c.config.Metrics != nil {
+		c.config.Metrics.SetRemainingDownloads(remaining)
+	}
+}
+
+// parseRateLimitStatus reads the rate limit headers of a response. ok is false if
+// there are none.
+func parseRateLimitStatus(header http.Header, now time.Time) (status RateLimitStatus, ok bool) {
+	status = RateLimitStatus{
+		Limit:              headerInt(header, "RateLimit-Limit"),
+		Remaining:          headerInt(header, "RateLimit-Remaining"),
+		Reset:              headerSeconds(header, "RateLimit-Reset"),
+		LimitPerSecond:     headerInt(header, "X-RateLimit-Limit-Second"),
+		RemainingPerSecond: headerInt(header, "X-RateLimit-Remaining-Second"),
+		ObservedAt:         now,
+	}
+	if status.RetryAfter = headerSeconds(header, "Retry-After"); status.RetryAfter == nil {
+		if t, err := http.ParseTime(header.Get("Retry-After")); err == nil {
+			wait := max(t.Sub(now), 0)
+			status.RetryAfter = &wait
+		}
+	}
+	ok = status.Limit != nil || status.Remaining != nil || status.Reset != nil ||
+		status.LimitPerSecond != nil || status.RemainingPerSecond != nil || status.RetryAfter != nil
+	return status, ok
+}
+
+// headerInt parses a non-negative integer header, returning nil if it is missing or invalid.
+func headerInt(header http.Header, name string) *int {
+	n, err := strconv.Atoi(header.Get(name))
+	if err != nil || n < 0 {
+		return nil
+	}
+	return &n
+}
+
+// headerSeconds parses a header holding a number of seconds.
+func headerSeconds(header http.Header, name string) *time.Duration {
+	n := headerInt(header, name)
+	if n == nil {
+		return nil
+	}
+	d := time.Duration(*n) * time.Second
+	return &d
+}
@@ -0,0 +1,174 @@
+package opensubtitles
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitStatus(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/subtitles/123":
+			w.Header().Set("RateLimit-Limit", "40")
+			w.Header().Set("RateLimit-Remaining", "2")
+			w.Header().Set("RateLimit-Reset", "7")
+			w.Header().Set("X-RateLimit-Limit-Second", "5")
+			w.Header().Set("X-RateLimit-Remaining-Second", "4")
+			fmt.Fprint(w, `{"data":{"id":"123"}}`)
+		case "/api/v1/download":
+			fmt.Fprint(w, `{"link":"https://example.com/sub.srt","remaining":9,"reset_time_utc":"2026-10-17T00:00:00.000Z"}`)
+		case "/api/v1/infos/user":
+			fmt.Fprint(w, `{"data":{"remaining_downloads":8}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"message":"Not found"}`)
+		}
+	})
+	ctx := context.Background()
+
+	_, ok := client.LastRateLimit()
+	assert.False(t, ok)
+	_, ok = client.LastDownloadQuota()
+	assert.False(t, ok)
+
+	var meta ResponseMeta
+	_, err := client.GetSubtitleByID(WithResponseMeta(ctx, &meta), "123")
+	require.NoError(t, err)
+	assert.Equal(t, "GET", meta.Method)
+	assert.Equal(t, "/subtitles/123", meta.Endpoint)
+	assert.Equal(t, http.StatusOK, meta.StatusCode)
+	assert.Equal(t, "40", meta.Header.Get("RateLimit-Limit"))
+	assert.Nil(t, meta.DownloadQuota)
+	status, ok := client.LastRateLimit()
+	require.True(t, ok)
+	assert.Equal(t, meta.RateLimit, status)
+	require.NotNil(t, status.Remaining)
+	assert.Equal(t, 2, *status.Remaining)
+	assert.Equal(t, 40, *status.Limit)
+	assert.Equal(t, 7*time.Second, *status.Reset)
+	assert.Equal(t, 5, *status.LimitPerSecond)
+	assert.Equal(t, 4, *status.RemainingPerSecond)
+	assert.Nil(t, status.RetryAfter)
+	assert.Equal(t, "/subtitles/123", status.Endpoint)
+
+	t.Run("responses without headers keep the last status", func(t *testing.T) {
+		var meta ResponseMeta
+		_, err := client.SearchFeatures(WithResponseMeta(ctx, &meta), SearchFeaturesParams{Query: String("heat")})
+		require.ErrorIs(t, err, ErrNotFound)
+		assert.Equal(t, http.StatusNotFound, meta.StatusCode)
+		assert.Nil(t, meta.RateLimit.Remaining)
+		last, ok := client.LastRateLimit()
+		require.True(t, ok)
+		assert.Equal(t, "/subtitles/123", last.Endpoint)
+	})
+
+	t.Run("download quota", func(t *testing.T) {
+		var meta ResponseMeta
+		_, err := client.Download(WithResponseMeta(ctx, &meta), DownloadRequest{FileID: 1})
+		require.NoError(t, err)
+		require.NotNil(t, meta.DownloadQuota)
+		assert.Equal(t, 9, meta.DownloadQuota.Remaining)
+		assert.Equal(t, time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC), meta.DownloadQuota.ResetTime.UTC())
+		quota, ok := client.LastDownloadQuota()
+		require.True(t, ok)
+		assert.Equal(t, *meta.DownloadQuota, quota)
+
+		_, err = client.GetUserInfo(ctx)
+		require.NoError(t, err)
+		quota, ok = client.LastDownloadQuota()
+		require.True(t, ok)
+		assert.Equal(t, 8, quota.Remaining)
+		assert.True(t, quota.ResetTime.IsZero())
+	})
+}
+
+func TestRateLimitLearning(t *testing.T) {
+	var remaining atomic.Value
+	remaining.Store("")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit-Second", "20")
+		if v := remaining.Load().(string); v != "" {
+			w.Header().Set("RateLimit-Remaining", v)
+			w.Header().Set("RateLimit-Reset", "1")
+		}
+		fmt.Fprint(w, `{"data":{"remaining_downloads":8}}`)
+	}))
+	t.Cleanup(server.Close)
+	client, err := NewClient(Config{
+		ApiKey:    "test-api-key",
+		BaseURL:   server.URL,
+		RateLimit: &RateLimitConfig{Default: 1000, Burst: 10},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	stats := client.Stats()
+	assert.Equal(t, 1000.0, stats.RateLimits["default"].Rate)
+	assert.False(t, stats.RateLimits["default"].Learned)
+	assert.NotContains(t, stats.RateLimits, "login", "disabled classes are missing")
+	assert.Nil(t, stats.LastRateLimit)
+
+	_, err = client.GetUserInfo(ctx)
+	require.NoError(t, err)
+	stats = client.Stats()
+	learned := stats.RateLimits["default"]
+	assert.True(t, learned.Learned)
+	assert.Equal(t, 1000.0, learned.Configured)
+	assert.Equal(t, 20.0, learned.Rate)
+	assert.Equal(t, 10, learned.Burst)
+	require.NotNil(t, stats.LastRateLimit)
+	assert.Equal(t, 20, *stats.LastRateLimit.LimitPerSecond)
+	require.NotNil(t, stats.DownloadQuota)
+	assert.Equal(t, 8, stats.DownloadQuota.Remaining)
+
+	start := time.Now()
+	for i := 0; i < 12; i++ {
+		_, err = client.GetUserInfo(ctx)
+		require.NoError(t, err)
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond, "requests beyond the burst follow the learned 20/s")
+
+	remaining.Store("0")
+	_, err = client.GetUserInfo(ctx)
+	require.NoError(t, err)
+	assert.Less(t, client.Stats().RateLimits["default"].Available, -19.0, "an exhausted window empties the bucket")
+	remaining.Store("")
+	start = time.Now()
+	_, err = client.GetUserInfo(ctx)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 900*time.Millisecond, "requests wait for RateLimit-Reset")
+}
+
+func TestParseRateLimitStatus(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	_, ok := parseRateLimitStatus(http.Header{"Content-Type": {"application/json"}}, now)
+	assert.False(t, ok)
+
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+	status, ok := parseRateLimitStatus(header, now)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, *status.RetryAfter)
+	assert.Equal(t, now, status.ObservedAt)
+
+	header.Set("Retry-After", now.Add(time.Minute).Format(http.TimeFormat))
+	status, ok = parseRateLimitStatus(header, now)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, *status.RetryAfter)
+
+	header = http.Header{}
+	header.Set("RateLimit-Remaining", "-1")
+	header.Set("X-RateLimit-Remaining-Second", "soon")
+	_, ok = parseRateLimitStatus(header, now)
+	assert.False(t, ok)
+}
@@ -0,0 +1,185 @@
+// Package scanner walks a media library and lists the videos that lack a sidecar
+// subtitle in one of the wanted languages, as work items for a downloader.
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/langprofile"
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// ErrNoLanguages is returned by Scan when Options.Languages is empty and no language
+// could be inferred from the subtitles already in the library.
+var ErrNoLanguages = errors.New("scanner: no wanted languages given or inferred")
+
+// videoExtensions lists the extensions Scan treats as videos by default.
+var videoExtensions = map[string]bool{
+	".mkv": true, ".mp4": true, ".avi": true, ".m4v": true, ".mov": true,
+	".wmv": true, ".ts": true, ".m2ts": true, ".mpg": true, ".mpeg": true, ".webm": true,
+}
+
+// Options configures Scan.
+type Options struct {
+	// Languages are the wanted subtitle languages, e.g. "el", "pt-br". If empty, the
+	// preferred languages inferred by langprofile from the library are used.
+	Languages []string
+	// FS is the file system to scan. Defaults to vfs.OS.
+	FS fs.FS
+	// MinVideoSize skips smaller video files, e.g. samples and trailers.
+	MinVideoSize int64
+	// SkipDir, if set, is called for every directory; returning true skips it.
+	// Directories named "sample" or starting with "." are always skipped.
+	SkipDir func(dir string) bool
+}
+
+// Video is a video file found by Scan.
+type Video struct {
+	Path string
+	Size int64
+	// Languages are the languages of its existing sidecar subtitles, sorted.
+	Languages []string
+}
+
+// MissingSubtitle is a work item: Video lacks a subtitle in Language. SubtitlePath is
+// the sidecar path a downloaded subtitle should be written to, e.g. "Movies/Heat.el.srt".
+type MissingSubtitle struct {
+	Video        Video
+	Language     string
+	SubtitlePath string
+}
+
+// IsVideoFile reports whether name has a known video file extension.
+func IsVideoFile(name string) bool {
+	return videoExtensions[strings.ToLower(path.Ext(name))]
+}
+
+// Scan walks root and returns a MissingSubtitle for every video and wanted language
+// without a sidecar subtitle. A sidecar belongs to a video if it is in the same
+// directory and its name starts with the video name without extension, such as
+// "Heat (1995).en.srt" for "Heat (1995).mkv"; its language is read with
+// langprofile.LanguageFromFilename. Items are ordered by video path, then language.
+func Scan(root string, opts Options) ([]MissingSubtitle, error) {
+	fsys := opts.FS
+	if fsys == nil {
+		fsys = vfs.OS
+	}
+
+	var videos []Video
+	subtitles := make(map[string][]string) // Directory -> sidecar subtitle names
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != root && skipDir(p, opts) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		dir, name := path.Split(p)
+		switch {
+		case langprofile.IsSubtitleFile(name):
+			subtitles[dir] = append(subtitles[dir], name)
+		case IsVideoFile(name):
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			if info.Size() < opts.MinVideoSize {
+				return nil
+			}
+			videos = append(videos, Video{Path: p, Size: info.Size()})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanner: failed to walk '%s': %w", root, err)
+	}
+
+	wanted := normalizeLanguages(opts.Languages)
+	if len(wanted) == 0 {
+		var all []string
+		for _, names := range subtitles {
+			for _, name := range names {
+				if lang, ok := langprofile.LanguageFromFilename(name); ok {
+					all = append(all, lang)
+				}
+			}
+		}
+		wanted = langprofile.Infer(all, 0).Preferred
+		if len(wanted) == 0 {
+			return nil, ErrNoLanguages
+		}
+		sort.Strings(wanted)
+	}
+
+	sort.Slice(videos, func(i, j int) bool { return videos[i].Path < videos[j].Path })
+	var missing []MissingSubtitle
+	for _, video := range videos {
+		dir, name := path.Split(video.Path)
+		stem := strings.TrimSuffix(name, path.Ext(name))
+		have := make(map[string]bool)
+		for _, sub := range subtitles[dir] {
+			if lang, ok := sidecarLanguage(stem, sub); ok && !have[lang] {
+				have[lang] = true
+				video.Languages = append(video.Languages, lang)
+			}
+		}
+		sort.Strings(video.Languages)
+		for _, lang := range wanted {
+			if !have[lang] {
+				missing = append(missing, MissingSubtitle{
+					Video:        video,
+					Language:     lang,
+					SubtitlePath: dir + stem + "." + lang + ".srt",
+				})
+			}
+		}
+	}
+	return missing, nil
+}
+
+// sidecarLanguage returns the language of the subtitle sub if it is a sidecar of the
+// video named stem: the language must directly follow the stem, so "Heat.2.en.srt"
+// belongs to "Heat.2.mkv" but not to "Heat.mkv".
+func sidecarLanguage(stem, sub string) (string, bool) {
+	if !strings.HasPrefix(sub, stem+".") {
+		return "", false
+	}
+	lang, ok := langprofile.LanguageFromFilename(sub)
+	if !ok {
+		return "", false
+	}
+	next, _, _ := strings.Cut(sub[len(stem)+1:], ".")
+	return lang, strings.EqualFold(next, lang)
+}
+
+// skipDir reports whether the directory at p is excluded from the scan.
+func skipDir(p string, opts Options) bool {
+	name := path.Base(p)
+	if strings.HasPrefix(name, ".") || strings.EqualFold(name, "sample") {
+		return true
+	}
+	return opts.SkipDir != nil && opts.SkipDir(p)
+}
+
+// normalizeLanguages lower-cases, de-duplicates and sorts the wanted languages.
+func normalizeLanguages(languages []string) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, lang := range languages {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang != "" && !seen[lang] {
+			seen[lang] = true
+			out = append(out, lang)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,80 @@
+package scanner
+
+import (
+	"path"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/vfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLibrary(t *testing.T, files map[string]string) *vfs.MemFS {
+	t.Helper()
+	fsys := vfs.NewMemFS()
+	for name, content := range files {
+		require.NoError(t, fsys.MkdirAll(path.Dir(name), 0o755))
+		require.NoError(t, vfs.WriteFile(fsys, name, []byte(content)))
+	}
+	return fsys
+}
+
+func TestScan(t *testing.T) {
+	fsys := newLibrary(t, map[string]string{
+		"Movies/Heat (1995)/Heat (1995).mkv":           "video content",
+		"Movies/Heat (1995)/Heat (1995).en.srt":        "",
+		"Movies/Heat (1995)/Heat (1995).EL.forced.srt": "",
+		"Movies/Heat.mkv":                              "video content",
+		"Movies/Heat.2.mkv":                            "video content",
+		"Movies/Heat.2.en.srt":                         "",
+		"Movies/Heat.2.en.sample.mkv":                  "x",
+		"Movies/Sample/Heat.sample.mkv":                "video content",
+		"Movies/.trash/Old.mkv":                        "video content",
+		"Movies/notes.txt":                             "",
+	})
+
+	missing, err := Scan("Movies", Options{FS: fsys, Languages: []string{"EN", "el", "en"}, MinVideoSize: 2})
+	require.NoError(t, err)
+
+	type item struct{ video, lang, dest string }
+	var got []item
+	for _, m := range missing {
+		got = append(got, item{m.Video.Path, m.Language, m.SubtitlePath})
+	}
+	assert.Equal(t, []item{
+		{"Movies/Heat.2.mkv", "el", "Movies/Heat.2.el.srt"},
+		{"Movies/Heat.mkv", "el", "Movies/Heat.el.srt"},
+		{"Movies/Heat.mkv", "en", "Movies/Heat.en.srt"},
+	}, got)
+	assert.Equal(t, []string{"en"}, missing[0].Video.Languages)
+	assert.Equal(t, int64(len("video content")), missing[0].Video.Size)
+	assert.Empty(t, missing[1].Video.Languages, "Heat.2.en.srt is not a sidecar of Heat.mkv")
+}
+
+func TestScanInfersLanguages(t *testing.T) {
+	fsys := newLibrary(t, map[string]string{
+		"TV/Show.S01E01.mkv":    "v",
+		"TV/Show.S01E01.el.srt": "",
+		"TV/Show.S01E02.mkv":    "v",
+	})
+
+	missing, err := Scan("TV", Options{FS: fsys})
+	require.NoError(t, err)
+	require.Len(t, missing, 1)
+	assert.Equal(t, "TV/Show.S01E02.mkv", missing[0].Video.Path)
+	assert.Equal(t, "el", missing[0].Language)
+}
+
+func TestScanErrors(t *testing.T) {
+	fsys := newLibrary(t, map[string]string{"Movies/Heat.mkv": "v"})
+
+	_, err := Scan("Movies", Options{FS: fsys})
+	assert.ErrorIs(t, err, ErrNoLanguages)
+
+	_, err = Scan("Missing", Options{FS: fsys, Languages: []string{"en"}})
+	assert.Error(t, err)
+
+	missing, err := Scan("Movies", Options{FS: fsys, Languages: []string{"en"}, SkipDir: func(string) bool { return true }})
+	require.NoError(t, err)
+	assert.Len(t, missing, 1, "SkipDir is not applied to the root")
+}
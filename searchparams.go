@@ -0,0 +1,187 @@
+package opensubtitles
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/languages"
+)
+
+// ErrInvalidSearchParams is returned when SearchSubtitlesParams fail client-side
+// validation; the API would otherwise silently return no results.
+var ErrInvalidSearchParams = errors.New("opensubtitles: invalid search parameters")
+
+var (
+	moviehashPattern = regexp.MustCompile(`^[a-f0-9]{16}$`)
+	imdbIDPattern    = regexp.MustCompile(`^(?i:tt)?0*([1-9][0-9]*)$`)
+	languagePattern  = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]{2,4})?$`)
+)
+
+// ParseIMDbID parses an IMDb ID such as "tt0133093" or "133093" into the number used
+// by the IMDbID fields.
+func ParseIMDbID(s string) (int, error) {
+	m := imdbIDPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, fmt.Errorf("%w: %q is not an IMDb ID", ErrInvalidSearchParams, s)
+	}
+	id, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 0, fmt.Errorf("%w: IMDb ID %q is out of range", ErrInvalidSearchParams, s)
+	}
+	return id, nil
+}
+
+// Normalize returns a copy of p in the form the API expects and validates it:
+// Languages is checked against the languages catalog, lower-cased, de-duplicated and
+// sorted, with XML-RPC codes such as "eng" converted to REST codes, Moviehash is lower-cased and
+// must be 16 hex digits, a Query that is only an IMDb ID ("tt0133093") becomes IMDbID,
+// and conflicting or out-of-range parameters, unknown OrderBy fields and an
+// OrderDirection without OrderBy are rejected. Errors wrap
+// ErrInvalidSearchParams. SearchSubtitles normalizes its parameters automatically.
+func (p SearchSubtitlesParams) Normalize() (SearchSubtitlesParams, error) {
+	if p.Query != nil {
+		query := strings.TrimSpace(*p.Query)
+		id, err := ParseIMDbID(query)
+		switch {
+		case query == "":
+			p.Query = nil
+		case err == nil && strings.HasPrefix(strings.ToLower(query), "tt") && p.ID == nil && p.IMDbID == nil && p.TMDBID == nil:
+			p.IMDbID, p.Query = &id, nil
+		default:
+			p.Query = &query
+		}
+	}
+
+	if p.Languages != nil {
+		langs, err := normalizeLanguages(*p.Languages)
+		if err != nil {
+			return p, err
+		}
+		if langs == "" {
+			p.Languages = nil
+		} else {
+			p.Languages = &langs
+		}
+	}
+
+	if p.Moviehash != nil {
+		hash := strings.ToLower(strings.TrimSpace(*p.Moviehash))
+		if !moviehashPattern.MatchString(hash) {
+			return p, fmt.Errorf("%w: moviehash %q must be 16 hexadecimal digits", ErrInvalidSearchParams, *p.Moviehash)
+		}
+		p.Moviehash = &hash
+	}
+	if p.MoviehashMatch != nil {
+		if p.Moviehash == nil {
+			return p, fmt.Errorf("%w: moviehash_match requires moviehash", ErrInvalidSearchParams)
+		}
+		if *p.MoviehashMatch != "include" && *p.MoviehashMatch != "only" {
+			return p, fmt.Errorf("%w: moviehash_match must be \"include\" or \"only\", got %q", ErrInvalidSearchParams, *p.MoviehashMatch)
+		}
+	}
+
+	if p.Type != nil {
+		switch *p.Type {
+		case "movie", "episode", "all":
+		default:
+			return p, fmt.Errorf("%w: type must be \"movie\", \"episode\" or \"all\", got %q", ErrInvalidSearchParams, *p.Type)
+		}
+	}
+	if p.OrderBy != nil && !p.OrderBy.Valid() {
+		return p, fmt.Errorf("%w: order_by must be one of %v, got %q", ErrInvalidSearchParams, subtitleOrderFields, *p.OrderBy)
+	}
+	if p.OrderDirection != nil {
+		if *p.OrderDirection != SortAsc && *p.OrderDirection != SortDesc {
+			return p, fmt.Errorf("%w: order_direction must be %q or %q, got %q", ErrInvalidSearchParams, SortAsc, SortDesc, *p.OrderDirection)
+		}
+		if p.OrderBy == nil {
+			return p, fmt.Errorf("%w: order_direction requires order_by", ErrInvalidSearchParams)
+		}
+	}
+
+	ids := []intParam{
+		{"id", p.ID}, {"imdb_id", p.IMDbID}, {"tmdb_id", p.TMDBID},
+		{"parent_feature_id", p.ParentFeatureID}, {"parent_imdb_id", p.ParentIMDbID}, {"parent_tmdb_id", p.ParentTMDBID},
+		{"uploader_id", p.UploaderID},
+	}
+	for _, id := range ids {
+		if id.value != nil && *id.value <= 0 {
+			return p, fmt.Errorf("%w: %s must be positive, got %d", ErrInvalidSearchParams, id.name, *id.value)
+		}
+	}
+	if err := exclusive(ids[0:3]...); err != nil {
+		return p, err
+	}
+	if err := exclusive(ids[3:6]...); err != nil {
+		return p, err
+	}
+
+	counts := []struct {
+		name  string
+		value *int
+		min   int
+	}{
+		{"season_number", p.SeasonNumber, 0}, {"episode_number", p.EpisodeNumber, 0}, {"page", p.Page, 1}, {"year", p.Year, 1},
+	}
+	for _, c := range counts {
+		if c.value != nil && *c.value < c.min {
+			return p, fmt.Errorf("%w: %s must be at least %d, got %d", ErrInvalidSearchParams, c.name, c.min, *c.value)
+		}
+	}
+	return p, nil
+}
+
+// intParam is a named optional integer parameter, for validation messages.
+type intParam struct {
+	name  string
+	value *int
+}
+
+// exclusive returns an error if more than one of params is set.
+func exclusive(params ...intParam) error {
+	var set []string
+	for _, p := range params {
+		if p.value != nil {
+			set = append(set, p.name)
+		}
+	}
+	if len(set) > 1 {
+		return fmt.Errorf("%w: %s are mutually exclusive", ErrInvalidSearchParams, strings.Join(set, " and "))
+	}
+	return nil
+}
+
+// normalizeLanguages lower-cases, de-duplicates and sorts a comma-separated language
+// list. Codes must be in the languages catalog; XML-RPC style codes such as "eng" or
+// "pob" are converted to the REST API codes ("en", "pt-br").
+func normalizeLanguages(list string) (string, error) {
+	seen := make(map[string]bool)
+	var langs []string
+	for _, lang := range strings.Split(list, ",") {
+		lang = strings.ToLower(strings.TrimSpace(lang))
+		if lang == "" {
+			continue
+		}
+		if !languagePattern.MatchString(lang) {
+			return "", fmt.Errorf("%w: %q is not a language code", ErrInvalidSearchParams, lang)
+		}
+		known, err := languages.Parse(lang)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q is not a known language", ErrInvalidSearchParams, lang)
+		}
+		if lang != known.ISO1 {
+			lang = strings.ToLower(known.Code)
+		}
+		if seen[lang] {
+			continue
+		}
+		seen[lang] = true
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return strings.Join(langs, ","), nil
+}
@@ -0,0 +1,99 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSearchSubtitlesParamsNormalize(t *testing.T) {
+	params, err := SearchSubtitlesParams{
+		Query:     pstr("  tt0133093 "),
+		Languages: pstr("pt-BR, EN,en,,el"),
+		Moviehash: pstr("8E245D9679D31E12"),
+	}.Normalize()
+	require.NoError(t, err)
+	assert.Nil(t, params.Query)
+	require.NotNil(t, params.IMDbID)
+	assert.Equal(t, 133093, *params.IMDbID)
+	assert.Equal(t, "el,en,pt-br", *params.Languages)
+	assert.Equal(t, "8e245d9679d31e12", *params.Moviehash)
+
+	params, err = SearchSubtitlesParams{Languages: pstr("eng,pob,en,zh")}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "en,pt-br,zh", *params.Languages, "XML-RPC codes become REST codes")
+
+	params, err = SearchSubtitlesParams{Query: pstr(" The Matrix "), Languages: pstr(" , ")}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "The Matrix", *params.Query)
+	assert.Nil(t, params.Languages)
+
+	orderBy, desc := OrderByRatings, SortDesc
+	params, err = SearchSubtitlesParams{OrderBy: &orderBy, OrderDirection: &desc}.Normalize()
+	require.NoError(t, err)
+	assert.Equal(t, "order_by=ratings&order_direction=desc", params.EncodeQuery())
+}
+
+func TestSearchSubtitlesParamsNormalizeErrors(t *testing.T) {
+	only, direction, orderBy, badOrderBy := "only", SortDirection("up"), OrderByUploadDate, SubtitleOrderField("size")
+	desc := SortDesc
+	tests := []struct {
+		name   string
+		params SearchSubtitlesParams
+		msg    string
+	}{
+		{"short moviehash", SearchSubtitlesParams{Moviehash: pstr("abc")}, "16 hexadecimal digits"},
+		{"non-hex moviehash", SearchSubtitlesParams{Moviehash: pstr("zzzzzzzzzzzzzzzz")}, "16 hexadecimal digits"},
+		{"bad language", SearchSubtitlesParams{Languages: pstr("en,english")}, `"english" is not a language code`},
+		{"unknown language", SearchSubtitlesParams{Languages: pstr("en,xx")}, `"xx" is not a known language`},
+		{"match without hash", SearchSubtitlesParams{MoviehashMatch: &only}, "requires moviehash"},
+		{"bad type", SearchSubtitlesParams{Type: pstr("tvshow")}, "type must be"},
+		{"bad direction", SearchSubtitlesParams{OrderBy: &orderBy, OrderDirection: &direction}, "order_direction must be"},
+		{"bad order field", SearchSubtitlesParams{OrderBy: &badOrderBy}, `order_by must be one of [language download_count`},
+		{"direction without field", SearchSubtitlesParams{OrderDirection: &desc}, "order_direction requires order_by"},
+		{"id and imdb_id", SearchSubtitlesParams{ID: pint(1), IMDbID: pint(2)}, "id and imdb_id are mutually exclusive"},
+		{"parent ids", SearchSubtitlesParams{ParentIMDbID: pint(1), ParentTMDBID: pint(2)}, "parent_imdb_id and parent_tmdb_id are mutually exclusive"},
+		{"negative id", SearchSubtitlesParams{TMDBID: pint(-5)}, "tmdb_id must be positive"},
+		{"page zero", SearchSubtitlesParams{Page: pint(0)}, "page must be at least 1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.params.Normalize()
+			require.ErrorIs(t, err, ErrInvalidSearchParams)
+			assert.Contains(t, err.Error(), tt.msg)
+		})
+	}
+}
+
+func TestParseIMDbID(t *testing.T) {
+	for _, s := range []string{"tt0133093", "TT133093", "0133093", " 133093 "} {
+		id, err := ParseIMDbID(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, 133093, id, s)
+	}
+	for _, s := range []string{"", "tt", "tt0", "nm0000206", "tt12a"} {
+		_, err := ParseIMDbID(s)
+		assert.ErrorIs(t, err, ErrInvalidSearchParams, s)
+	}
+}
+
+func TestSearchSubtitlesRejectsInvalidParams(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("invalid parameters must not reach the API")
+	})
+	_, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Moviehash: pstr("not-a-hash")})
+	require.ErrorIs(t, err, ErrInvalidSearchParams)
+}
+
+func TestSearchSubtitlesSendsNormalizedParams(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "imdb_id=133093&languages=en%2Cpt-br", r.URL.RawQuery)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"total_pages":1,"total_count":0,"page":1,"data":[]}`))
+	})
+	_, err := client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Query: pstr("tt0133093"), Languages: pstr("pt-BR,en")})
+	require.NoError(t, err)
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/envconfig"
+)
+
+// S3Sink uploads files to an S3-compatible object store (AWS S3, MinIO, Backblaze B2, ...)
+// using AWS Signature Version 4. Content is buffered in memory before upload, which is
+// fine for subtitle-sized objects.
+type S3Sink struct {
+	Endpoint        string // e.g. "https://s3.eu-west-1.amazonaws.com" or "http://minio:9000"
+	Region          string // e.g. "eu-west-1"
+	Bucket          string
+	Prefix          string // Optional key prefix, e.g. "subtitles/"
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // Optional, for temporary credentials
+
+	HTTPClient *http.Client // Defaults to http.DefaultClient
+
+	now func() time.Time // Overridable in tests
+}
+
+// Ensure S3Sink implements Sink.
+var _ Sink = (*S3Sink)(nil)
+
+// S3SinkFromEnv configures an S3Sink from the S3_ENDPOINT, S3_REGION, S3_BUCKET, S3_PREFIX,
+// S3_ACCESS_KEY_ID, S3_SECRET_ACCESS_KEY and S3_SESSION_TOKEN variables of l.
+func S3SinkFromEnv(l *envconfig.Loader) (*S3Sink, error) {
+	s := &S3Sink{
+		Endpoint:        l.String("S3_ENDPOINT", ""),
+		Region:          l.String("S3_REGION", "us-east-1"),
+		Bucket:          l.String("S3_BUCKET", ""),
+		Prefix:          l.String("S3_PREFIX", ""),
+		AccessKeyID:     l.String("S3_ACCESS_KEY_ID", ""),
+		SecretAccessKey: l.String("S3_SECRET_ACCESS_KEY", ""),
+		SessionToken:    l.String("S3_SESSION_TOKEN", ""),
+	}
+	if err := l.Err(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Put uploads the content of r as Prefix+name using a path-style PUT request.
+func (s *S3Sink) Put(ctx context.Context, name string, r io.Reader) error {
+	if s.Endpoint == "" || s.Bucket == "" || s.Region == "" {
+		return errors.New("storage: S3 endpoint, region and bucket are required")
+	}
+	cleaned, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("storage: failed to read content for '%s': %w", name, err)
+	}
+
+	endpoint, err := url.Parse(strings.TrimSuffix(s.Endpoint, "/"))
+	if err != nil {
+		return fmt.Errorf("storage: invalid S3 endpoint: %w", err)
+	}
+	endpoint.Path += "/" + s.Bucket + "/" + s.Prefix + cleaned
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint.String(), bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("storage: failed to create S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-subrip")
+	if strings.HasSuffix(cleaned, ".vtt") {
+		req.Header.Set("Content-Type", "text/vtt")
+	}
+
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	s.sign(req, sha256Hex(body), now().UTC())
+
+	httpClient := s.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("storage: S3 upload of '%s' failed: %w", cleaned, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("storage: S3 upload of '%s' failed: status %d, body: %s", cleaned, resp.StatusCode, string(msg))
+	}
+	return nil
+}
+
+// sign adds the SigV4 headers to req. All headers already present on req are signed.
+// The path is sent exactly as it is signed, in the SigV4 URI encoding.
+func (s *S3Sink) sign(req *http.Request, payloadHash string, t time.Time) {
+	canonicalPath := uriEncodePath(req.URL.Path)
+	req.URL.RawPath = canonicalPath
+	amzDate := t.Format("20060102T150405Z")
+	shortDate := t.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+
+	// Canonical headers: lowercase names, sorted, trimmed values, including host.
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := shortDate + "/" + s.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), shortDate)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// uriEncodePath encodes each segment of p by the SigV4 UriEncode rules: every byte
+// except A-Z, a-z, 0-9, '-', '.', '_' and '~' is percent-encoded, and the slashes
+// between segments are kept. url.URL.EscapedPath differs for characters such as
+// '&', '+', ',' and '=', which S3 then rejects with SignatureDoesNotMatch.
+func uriEncodePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '.', c == '_', c == '~', c == '/':
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&0x0f])
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
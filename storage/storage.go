@@ -0,0 +1,82 @@
+// Package storage provides pluggable sinks that downloaded subtitle files are written to.
+//
+// LocalSink writes through a vfs.FS, S3Sink uploads to an S3-compatible object store.
+// Other targets (WebDAV, SFTP, ...) can be added by implementing Sink.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/vfs"
+)
+
+// Sink stores subtitle files under slash-separated names such as "Movies/Inception.en.srt".
+type Sink interface {
+	// Put stores the content read from r under name, replacing any existing object.
+	Put(ctx context.Context, name string, r io.Reader) error
+}
+
+// LocalSink writes files below Root on a vfs.FS. Files are written to a temporary
+// name first and renamed into place, so readers never observe partial content.
+type LocalSink struct {
+	Root string
+	FS   vfs.FS // Defaults to vfs.OS
+}
+
+// Ensure LocalSink implements Sink.
+var _ Sink = (*LocalSink)(nil)
+
+// Put writes the content of r to Root/name, creating parent directories as needed.
+func (s *LocalSink) Put(ctx context.Context, name string, r io.Reader) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	cleaned, err := cleanName(name)
+	if err != nil {
+		return err
+	}
+	fsys := s.FS
+	if fsys == nil {
+		fsys = vfs.OS
+	}
+
+	dest := filepath.Join(s.Root, filepath.FromSlash(cleaned))
+	if err := fsys.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return fmt.Errorf("storage: failed to create directory for '%s': %w", dest, err)
+	}
+
+	// A unique temporary name, so concurrent writers of the same name never mix content.
+	f, tmp, err := vfs.CreateTemp(fsys, filepath.Dir(dest), "."+filepath.Base(dest)+".*.part")
+	if err != nil {
+		return fmt.Errorf("storage: failed to create temporary file for '%s': %w", dest, err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		_ = fsys.Remove(tmp)
+		return fmt.Errorf("storage: failed to write '%s': %w", tmp, err)
+	}
+	if err := f.Close(); err != nil {
+		_ = fsys.Remove(tmp)
+		return fmt.Errorf("storage: failed to close '%s': %w", tmp, err)
+	}
+	if err := fsys.Rename(tmp, dest); err != nil {
+		_ = fsys.Remove(tmp)
+		return fmt.Errorf("storage: failed to move '%s' into place: %w", dest, err)
+	}
+	return nil
+}
+
+// cleanName validates an object name and rejects attempts to escape the sink root.
+func cleanName(name string) (string, error) {
+	cleaned := path.Clean("/" + strings.ReplaceAll(name, "\\", "/"))
+	cleaned = strings.TrimPrefix(cleaned, "/")
+	if cleaned == "" || cleaned == "." {
+		return "", fmt.Errorf("storage: invalid object name '%s'", name)
+	}
+	return cleaned, nil
+}
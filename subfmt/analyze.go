@@ -0,0 +1,87 @@
+package subfmt
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Thresholds of Analyze.
+const (
+	// HIMinShare is the share of cues with sound descriptions or speaker labels from
+	// which a document counts as hearing impaired.
+	HIMinShare = 0.05
+	// HIMinCues is the minimum number of such cues, so that a short document with a
+	// single "[music]" does not count as hearing impaired.
+	HIMinCues = 3
+	// ForcedMaxCuesPerMinute is the cue density below which a document counts as
+	// forced, i.e. only translating foreign parts or signs. Full dialogue subtitles
+	// usually have 5 to 15 cues per minute.
+	ForcedMaxCuesPerMinute = 1.0
+)
+
+var (
+	markupTag = regexp.MustCompile(`<[^>]*>`)
+	// soundDescription matches "[door slams]" anywhere and "(sighs)" at the start of a line.
+	soundDescription = regexp.MustCompile(`\[[^\]]+\]|^\([^)]+\)`)
+	// speakerLabel matches an ALL-CAPS name followed by a colon at the start of a line,
+	// e.g. "JOHN:" or "- DR. SMITH:".
+	speakerLabel = regexp.MustCompile(`^\p{Lu}[\p{Lu}\d .'-]*\p{Lu}:(\s|$)`)
+)
+
+// Analysis is what the cues of a document reveal about its kind.
+type Analysis struct {
+	Cues              int
+	SoundDescriptions int // Cues with a description such as "[door slams]" or "(sighs)"
+	SpeakerLabels     int // Cues with an ALL-CAPS speaker label such as "JOHN:"
+	// CuesPerMinute is the cue density over the runtime passed to Analyze, or up to
+	// the end of the last cue if none was passed.
+	CuesPerMinute float64
+	// HearingImpaired is set if at least HIMinCues cues, and HIMinShare of all cues,
+	// have sound descriptions or speaker labels.
+	HearingImpaired bool
+	// Forced is set if the cue density is below ForcedMaxCuesPerMinute. It needs the
+	// video's runtime: the cues of forced subtitles often end long before the video.
+	Forced bool
+}
+
+// Analyze scans the cues of d for the marks of hearing impaired and forced
+// subtitles. runtime is the duration of the video; 0 if unknown.
+func Analyze(d *Document, runtime time.Duration) Analysis {
+	a := Analysis{Cues: len(d.Cues)}
+	if a.Cues == 0 {
+		return a
+	}
+	hi := 0
+	var end time.Duration
+	for _, cue := range d.Cues {
+		if cue.End > end {
+			end = cue.End
+		}
+		sound, speaker := false, false
+		for _, line := range strings.Split(markupTag.ReplaceAllString(cue.Text, ""), "\n") {
+			line = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "-"))
+			sound = sound || soundDescription.MatchString(line)
+			speaker = speaker || speakerLabel.MatchString(line)
+		}
+		if sound {
+			a.SoundDescriptions++
+		}
+		if speaker {
+			a.SpeakerLabels++
+		}
+		if sound || speaker {
+			hi++
+		}
+	}
+	a.HearingImpaired = hi >= HIMinCues && float64(hi) >= HIMinShare*float64(a.Cues)
+
+	if runtime <= 0 {
+		runtime = end
+	}
+	if runtime > 0 {
+		a.CuesPerMinute = float64(a.Cues) / runtime.Minutes()
+		a.Forced = a.CuesPerMinute < ForcedMaxCuesPerMinute
+	}
+	return a
+}
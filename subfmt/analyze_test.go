@@ -0,0 +1,52 @@
+package subfmt
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// cues builds a document with one cue per text, every 4 seconds.
+func cues(texts ...string) *Document {
+	d := &Document{Format: SRT}
+	for i, text := range texts {
+		start := time.Duration(i) * 4 * time.Second
+		d.Cues = append(d.Cues, Cue{Start: start, End: start + 3*time.Second, Text: text})
+	}
+	return d
+}
+
+func TestAnalyzeHearingImpaired(t *testing.T) {
+	dialogue := make([]string, 0, 20)
+	for i := 0; i < 17; i++ {
+		dialogue = append(dialogue, fmt.Sprintf("Line %d (of many).", i))
+	}
+	hi := append(dialogue, "[door slams]", "<i>(sighs)</i> Fine.", "- JOHN: Wait!\n- DR. SMITH: No.")
+	a := Analyze(cues(hi...), 0)
+	assert.Equal(t, 20, a.Cues)
+	assert.Equal(t, 2, a.SoundDescriptions)
+	assert.Equal(t, 1, a.SpeakerLabels)
+	assert.True(t, a.HearingImpaired)
+	assert.False(t, a.Forced, "the cues are measured up to the last one")
+
+	a = Analyze(cues(append(dialogue, "[music]", "Meet at 10:30.", "OK.", "Said I: no")...), 0)
+	assert.Equal(t, 1, a.SoundDescriptions)
+	assert.Zero(t, a.SpeakerLabels)
+	assert.False(t, a.HearingImpaired, "a single description is not enough")
+
+	many := strings.Split(strings.Repeat("Hello.|", 99)+"[music]|[laughs]|[music]", "|")
+	assert.False(t, Analyze(cues(many...), 0).HearingImpaired, "3 of 102 cues is below HIMinShare")
+}
+
+func TestAnalyzeForced(t *testing.T) {
+	doc := cues("Sign: Hotel", "Où est la gare ?", "Merci.")
+	a := Analyze(doc, 2*time.Hour)
+	assert.InDelta(t, 0.025, a.CuesPerMinute, 0.0001)
+	assert.True(t, a.Forced)
+
+	assert.False(t, Analyze(doc, 0).Forced, "without a runtime the cues are dense")
+	assert.Equal(t, Analysis{}, Analyze(&Document{}, time.Hour))
+}
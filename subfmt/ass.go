@@ -0,0 +1,171 @@
+package subfmt
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// assEventFormat is the [Events] format line written by Encode.
+const assEventFormat = "Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text"
+
+// assDefaultHeader is used when encoding a document that was not parsed from ASS.
+const assDefaultHeader = `[Script Info]
+ScriptType: v4.00+
+WrapStyle: 0
+ScaledBorderAndShadow: yes
+PlayResX: 384
+PlayResY: 288
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,16,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,1,0,2,10,10,10,1
+`
+
+func parseASS(text string) (*Document, error) {
+	doc := &Document{Format: ASS}
+	fields := strings.Split(strings.ReplaceAll(assEventFormat, " ", ""), ",")
+	var header strings.Builder
+	section, seenEvents := "", false
+	for n, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.ToLower(trimmed)
+			seenEvents = seenEvents || section == "[events]"
+		}
+		if section != "[events]" {
+			if !seenEvents && section != "" {
+				header.WriteString(line + "\n")
+			}
+			continue
+		}
+		key, value, ok := strings.Cut(trimmed, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "format":
+			fields = strings.Split(strings.ReplaceAll(value, " ", ""), ",")
+		case "dialogue":
+			cue, err := parseASSDialogue(strings.TrimLeft(value, " "), fields)
+			if err != nil {
+				return nil, fmt.Errorf("subfmt: ass line %d: %w", n+1, err)
+			}
+			doc.Cues = append(doc.Cues, cue)
+		}
+	}
+	// Only a v4+ header matches the event format written by Encode.
+	if h := header.String(); strings.Contains(strings.ToLower(h), "[v4+ styles]") {
+		doc.assHeader = strings.TrimRight(h, "\n") + "\n"
+	}
+	return doc, nil
+}
+
+func parseASSDialogue(value string, fields []string) (Cue, error) {
+	values := strings.SplitN(value, ",", len(fields))
+	if len(values) != len(fields) {
+		return Cue{}, fmt.Errorf("expected %d fields, got %d", len(fields), len(values))
+	}
+	var cue Cue
+	for i, field := range fields {
+		v := values[i]
+		var err error
+		switch strings.ToLower(field) {
+		case "start":
+			cue.Start, err = parseTimestamp(v)
+		case "end":
+			cue.End, err = parseTimestamp(v)
+		case "style":
+			cue.Style = strings.TrimPrefix(strings.TrimSpace(v), "*")
+		case "text":
+			cue.Text = assToMarkup(v)
+		}
+		if err != nil {
+			return Cue{}, err
+		}
+	}
+	return cue, nil
+}
+
+// assToMarkup converts ASS text to cue markup: \N and \n become line breaks, \h a
+// space, and bold/italic/underline overrides become tags; other overrides are dropped.
+func assToMarkup(text string) string {
+	var b strings.Builder
+	open := map[string]bool{}
+	for len(text) > 0 {
+		switch {
+		case text[0] == '{':
+			end := strings.IndexByte(text, '}')
+			if end < 0 {
+				text = ""
+				continue
+			}
+			for _, override := range strings.Split(text[1:end], `\`) {
+				for _, tag := range []string{"b", "i", "u"} {
+					if len(override) < 2 || override[:1] != tag || strings.Trim(override[1:], "0123456789") != "" {
+						continue
+					}
+					on := override[1:] != "0"
+					if on != open[tag] {
+						open[tag] = on
+						if on {
+							b.WriteString("<" + tag + ">")
+						} else {
+							b.WriteString("</" + tag + ">")
+						}
+					}
+				}
+			}
+			text = text[end+1:]
+		case strings.HasPrefix(text, `\N`), strings.HasPrefix(text, `\n`):
+			b.WriteByte('\n')
+			text = text[2:]
+		case strings.HasPrefix(text, `\h`):
+			b.WriteByte(' ')
+			text = text[2:]
+		default:
+			b.WriteByte(text[0])
+			text = text[1:]
+		}
+	}
+	for _, tag := range []string{"u", "i", "b"} {
+		if open[tag] {
+			b.WriteString("</" + tag + ">")
+		}
+	}
+	return b.String()
+}
+
+var markupToASS = strings.NewReplacer(
+	"<b>", `{\b1}`, "</b>", `{\b0}`,
+	"<i>", `{\i1}`, "</i>", `{\i0}`,
+	"<u>", `{\u1}`, "</u>", `{\u0}`,
+	"\n", `\N`,
+)
+
+func encodeASS(d *Document) []byte {
+	var b strings.Builder
+	if d.assHeader != "" {
+		b.WriteString(d.assHeader)
+	} else {
+		b.WriteString(assDefaultHeader)
+	}
+	b.WriteString("\n[Events]\nFormat: " + assEventFormat + "\n")
+	for _, c := range d.Cues {
+		style := c.Style
+		if style == "" {
+			style = "Default"
+		}
+		fmt.Fprintf(&b, "Dialogue: 0,%s,%s,%s,,0,0,0,,%s\n", formatASSTimestamp(c.Start), formatASSTimestamp(c.End), style, markupToASS.Replace(basicTags(c.Text)))
+	}
+	return []byte(b.String())
+}
+
+// formatASSTimestamp formats d as h:mm:ss.cc.
+func formatASSTimestamp(d time.Duration) string {
+	if d < 0 {
+		d = 0
+	}
+	cs := d.Round(10*time.Millisecond).Milliseconds() / 10
+	return fmt.Sprintf("%d:%02d:%02d.%02d", cs/360000, cs/6000%60, cs/100%60, cs%100)
+}
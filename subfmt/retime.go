@@ -0,0 +1,225 @@
+package subfmt
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// retimeText rewrites the timestamps of text in format and copies every other byte
+// unchanged: styles, positioning, cue settings, comments and line endings stay as they
+// were, which a round trip through Document would lose. Cues ending before zero are
+// removed; SRT cues keep their original numbers.
+func retimeText(text string, format Format, scale float64, offset time.Duration) (string, error) {
+	retimer := timestampRetimer{scale: scale, offset: offset}
+	switch format {
+	case SRT, VTT:
+		return retimer.cues(text, format)
+	case ASS:
+		return retimer.events(text)
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// timestampRetimer maps the start and end of a cue as Document.transform does.
+type timestampRetimer struct {
+	scale  float64
+	offset time.Duration
+}
+
+// apply returns the new start and end, and false if the cue ends before zero.
+func (r timestampRetimer) apply(start, end time.Duration) (time.Duration, time.Duration, bool) {
+	start = time.Duration(math.Round(float64(start)*r.scale)) + r.offset
+	end = time.Duration(math.Round(float64(end)*r.scale)) + r.offset
+	if end < 0 {
+		return 0, 0, false
+	}
+	if start < 0 {
+		start = 0
+	}
+	return start, end, true
+}
+
+// cues rewrites the "start --> end" lines of SRT and WebVTT text. A cue that ends
+// before zero is removed with the blank lines that follow it.
+func (r timestampRetimer) cues(text string, format Format) (string, error) {
+	var b strings.Builder
+	lines := splitLinesKeepEnds(text)
+	for i := 0; i < len(lines); {
+		if isBlank(lines[i]) {
+			b.WriteString(lines[i])
+			i++
+			continue
+		}
+		end := i
+		for end < len(lines) && !isBlank(lines[end]) {
+			end++
+		}
+		block := lines[i:end]
+		keep, err := r.cueBlock(block, format, i == 0)
+		if err != nil {
+			return "", fmt.Errorf("subfmt: %s line %d: %w", format, i+1, err)
+		}
+		if keep {
+			for _, line := range block {
+				b.WriteString(line)
+			}
+		} else {
+			for end < len(lines) && isBlank(lines[end]) {
+				end++
+			}
+		}
+		i = end
+	}
+	return b.String(), nil
+}
+
+// cueBlock rewrites the timing line of a block of non-blank lines in place and reports
+// whether the block is kept.
+func (r timestampRetimer) cueBlock(block []string, format Format, first bool) (bool, error) {
+	if format == VTT {
+		head := strings.Fields(block[0])
+		if first && len(head) > 0 && strings.HasPrefix(head[0], "WEBVTT") {
+			return true, nil
+		}
+		if len(head) > 0 && (head[0] == "NOTE" || head[0] == "STYLE" || head[0] == "REGION") {
+			return true, nil
+		}
+	}
+	for j, line := range block {
+		if !strings.Contains(line, "-->") {
+			continue
+		}
+		retimed, keep, err := r.timingLine(line)
+		if err != nil {
+			return false, err
+		}
+		block[j] = retimed
+		return keep, nil
+	}
+	return true, nil // Stray text between cues
+}
+
+// timingLine rewrites the two timestamps of a "start --> end [settings]" line, in the
+// style of the originals: the same fraction separator and, for WebVTT, hours only if
+// the original had them or they are needed.
+func (r timestampRetimer) timingLine(line string) (string, bool, error) {
+	start, end, err := parseTiming(line)
+	if err != nil {
+		return "", false, err
+	}
+	start, end, keep := r.apply(start, end)
+	if !keep {
+		return "", false, nil
+	}
+	from, to, _ := strings.Cut(line, "-->")
+	return replaceField(from, func(ts string) string { return formatTimestampLike(ts, start) }) +
+		"-->" + replaceField(to, func(ts string) string { return formatTimestampLike(ts, end) }), true, nil
+}
+
+// events rewrites the Start and End fields of the Dialogue and Comment lines in the
+// [Events] section of ASS text. An event that ends before zero is removed.
+func (r timestampRetimer) events(text string) (string, error) {
+	var b strings.Builder
+	fields := strings.Split(strings.ReplaceAll(assEventFormat, " ", ""), ",")
+	section := ""
+	for n, line := range splitLinesKeepEnds(text) {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.ToLower(trimmed)
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if section != "[events]" || !ok {
+			b.WriteString(line)
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			fields = strings.Split(strings.ReplaceAll(strings.TrimSpace(value), " ", ""), ",")
+		case "dialogue", "comment":
+			retimed, keep, err := r.event(value, fields)
+			if err != nil {
+				return "", fmt.Errorf("subfmt: ass line %d: %w", n+1, err)
+			}
+			if !keep {
+				continue
+			}
+			line = key + ":" + retimed
+		}
+		b.WriteString(line)
+	}
+	return b.String(), nil
+}
+
+// event rewrites the Start and End fields of the value of an event line.
+func (r timestampRetimer) event(value string, fields []string) (string, bool, error) {
+	values := strings.SplitN(value, ",", len(fields))
+	if len(values) != len(fields) {
+		return "", false, fmt.Errorf("expected %d fields, got %d", len(fields), len(values))
+	}
+	startField, endField := -1, -1
+	for i, field := range fields {
+		switch strings.ToLower(field) {
+		case "start":
+			startField = i
+		case "end":
+			endField = i
+		}
+	}
+	if startField < 0 || endField < 0 {
+		return value, true, nil
+	}
+	start, err := parseTimestamp(values[startField])
+	if err != nil {
+		return "", false, err
+	}
+	end, err := parseTimestamp(values[endField])
+	if err != nil {
+		return "", false, err
+	}
+	start, end, keep := r.apply(start, end)
+	if !keep {
+		return "", false, nil
+	}
+	values[startField] = replaceField(values[startField], func(string) string { return formatASSTimestamp(start) })
+	values[endField] = replaceField(values[endField], func(string) string { return formatASSTimestamp(end) })
+	return strings.Join(values, ","), true, nil
+}
+
+// replaceField replaces the first whitespace-separated field of s with fn(field),
+// keeping the whitespace around it and everything after it.
+func replaceField(s string, fn func(field string) string) string {
+	start := len(s) - len(strings.TrimLeft(s, " \t"))
+	end := start
+	for end < len(s) && s[end] != ' ' && s[end] != '\t' && s[end] != '\r' && s[end] != '\n' {
+		end++
+	}
+	return s[:start] + fn(s[start:end]) + s[end:]
+}
+
+// formatTimestampLike formats d in the style of the timestamp orig.
+func formatTimestampLike(orig string, d time.Duration) string {
+	sep := ","
+	if strings.Contains(orig, ".") {
+		sep = "."
+	}
+	formatted := formatTimestamp(d, sep)
+	if strings.Count(orig, ":") == 1 && d < time.Hour {
+		formatted = strings.TrimPrefix(formatted, "00:") // WebVTT mm:ss.ttt
+	}
+	return formatted
+}
+
+// splitLinesKeepEnds splits text after every "\n", keeping the line endings.
+func splitLinesKeepEnds(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
@@ -0,0 +1,85 @@
+package subfmt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const styledASS = `[Script Info]
+Title: Styled
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Sign,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,1,0,8,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Comment: 1,0:00:10.00,0:00:11.00,Default,Note,0,0,0,,timing check
+Dialogue: 2,0:00:12.00,0:00:14.50,Sign,Bob,15,20,25,Scroll up;10;20,{\pos(192,40)\c&H00FFFF&}Sign text, with a comma
+Dialogue: 0,1:02:03.45,1:02:05.00,Default,Alice,0,0,0,,{\i1}Hello{\i0}\Nthere
+
+[Fonts]
+fontname: custom.ttf
+M0T7.2+D1L
+`
+
+const styledVTT = "WEBVTT - styled\n" +
+	"\n" +
+	"STYLE\n" +
+	"::cue(.yellow) { color: yellow; }\n" +
+	"\n" +
+	"NOTE times below --> are shifted\n" +
+	"\n" +
+	"intro\n" +
+	"00:10.000 --> 00:12.500 line:0 align:left position:10%\n" +
+	"<v Bob><c.yellow>Hello</c> &amp; bye</v>\n" +
+	"\n" +
+	"01:00:02.000 --> 01:00:04.000 region:fred\n" +
+	"<v.loud Alice>Later</v>\n"
+
+func TestShiftKeepsEverythingButTimes(t *testing.T) {
+	t.Run("ASS", func(t *testing.T) {
+		out, err := Shift(strings.NewReader(styledASS), 2*time.Second)
+		require.NoError(t, err)
+		want := strings.NewReplacer(
+			"0:00:10.00,0:00:11.00", "0:00:12.00,0:00:13.00",
+			"0:00:12.00,0:00:14.50", "0:00:14.00,0:00:16.50",
+			"1:02:03.45,1:02:05.00", "1:02:05.45,1:02:07.00",
+		).Replace(styledASS)
+		assert.Equal(t, want, string(out))
+	})
+
+	t.Run("VTT", func(t *testing.T) {
+		out, err := Shift(strings.NewReader(styledVTT), -1500*time.Millisecond)
+		require.NoError(t, err)
+		want := strings.NewReplacer(
+			"00:10.000 --> 00:12.500", "00:08.500 --> 00:11.000",
+			"01:00:02.000 --> 01:00:04.000", "01:00:00.500 --> 01:00:02.500",
+		).Replace(styledVTT)
+		assert.Equal(t, want, string(out))
+	})
+
+	t.Run("CRLF", func(t *testing.T) {
+		in := strings.ReplaceAll(styledASS, "\n", "\r\n")
+		out, err := Rescale(strings.NewReader(in), 25, 25)
+		require.NoError(t, err)
+		assert.Equal(t, in, string(out))
+	})
+}
+
+func TestShiftRemovesCuesBeforeZero(t *testing.T) {
+	out, err := Shift(strings.NewReader(styledASS), -11500*time.Millisecond)
+	require.NoError(t, err)
+	assert.NotContains(t, string(out), "timing check", "the comment ended before zero")
+	assert.Contains(t, string(out), "Dialogue: 2,0:00:00.50,0:00:03.00,Sign,Bob,15,20,25,Scroll up;10;20,{\\pos(192,40)")
+
+	srt := "1\n00:00:01,000 --> 00:00:02,000\nGone\n\n2\n00:00:05,000 --> 00:00:06,000\nKept\n"
+	out, err = Shift(strings.NewReader(srt), -3*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "2\n00:00:02,000 --> 00:00:03,000\nKept\n", string(out))
+}
@@ -0,0 +1,68 @@
+package subfmt
+
+import (
+	"fmt"
+	"strings"
+)
+
+func parseSRT(text string) (*Document, error) {
+	doc := &Document{Format: SRT}
+	for i, block := range splitBlocks(text) {
+		timing := -1
+		for j, line := range block {
+			if strings.Contains(line, "-->") {
+				timing = j
+				break
+			}
+		}
+		if timing < 0 {
+			continue // Stray text between cues
+		}
+		start, end, err := parseTiming(block[timing])
+		if err != nil {
+			return nil, fmt.Errorf("subfmt: srt cue %d: %w", i+1, err)
+		}
+		doc.Cues = append(doc.Cues, Cue{Start: start, End: end, Text: strings.Join(block[timing+1:], "\n")})
+	}
+	return doc, nil
+}
+
+func encodeSRT(d *Document) []byte {
+	var b strings.Builder
+	for i, c := range d.Cues {
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, formatTimestamp(c.Start, ","), formatTimestamp(c.End, ","), cueText(c.Text))
+	}
+	return []byte(b.String())
+}
+
+// splitBlocks splits text into groups of non-blank lines.
+func splitBlocks(text string) [][]string {
+	var blocks [][]string
+	var current []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if len(current) > 0 {
+				blocks = append(blocks, current)
+				current = nil
+			}
+			continue
+		}
+		current = append(current, strings.TrimRight(line, " \t"))
+	}
+	if len(current) > 0 {
+		blocks = append(blocks, current)
+	}
+	return blocks
+}
+
+// cueText removes blank lines, which would end the cue early in SRT and WebVTT.
+func cueText(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
@@ -0,0 +1,281 @@
+// Package subfmt parses subtitle files and converts them between SubRip (SRT),
+// WebVTT and Advanced SubStation Alpha (ASS/SSA), with helpers for timeshifts and
+// frame-rate conversion.
+//
+// Content downloaded through the API can be post-processed locally instead of asking
+// the API for another sub_format:
+//
+//	doc, err := subfmt.Parse(data)
+//	// ...
+//	doc.Shift(-1500 * time.Millisecond)
+//	err = doc.ConvertFPS(25, 23.976)
+//	// ...
+//	vtt, err := doc.Encode(subfmt.VTT)
+//
+// Shift and Rescale do the same for a file on disk in one step. They rewrite only its
+// timestamps, keeping the format, styles and markup byte for byte:
+//
+//	f, err := os.Open("movie.srt")
+//	// ...
+//	shifted, err := subfmt.Shift(f, 2*time.Second)
+//
+// Cue text uses SRT/WebVTT style markup: lines are separated by "\n" and <b>, <i> and
+// <u> tags are kept. ASS override tags are mapped to these tags where possible and
+// dropped otherwise. Input must be UTF-8; a leading byte order mark is ignored.
+package subfmt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// Format identifies a subtitle file format.
+type Format string
+
+const (
+	SRT Format = "srt"
+	VTT Format = "vtt"
+	ASS Format = "ass" // Also used for SSA input; output is always ASS (v4+)
+)
+
+// ErrUnknownFormat is returned when the format of the content cannot be detected
+// or is not supported.
+var ErrUnknownFormat = errors.New("subfmt: unknown subtitle format")
+
+// Cue is a single timed subtitle entry.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+	Style string // ASS style name; empty uses "Default"
+}
+
+// Document is a parsed subtitle file.
+type Document struct {
+	Format Format // Format the document was parsed from
+	Cues   []Cue
+
+	// assHeader holds the ASS sections preceding [Events] (script info and styles),
+	// reused when the document is encoded as ASS again.
+	assHeader string
+}
+
+// Detect reports the format of data.
+func Detect(data []byte) (Format, error) {
+	text := strings.TrimLeft(string(trimBOM(data)), " \t\r\n")
+	switch {
+	case strings.HasPrefix(text, "WEBVTT"):
+		return VTT, nil
+	case strings.HasPrefix(text, "[Script Info]") || strings.Contains(text, "\n[Events]"):
+		return ASS, nil
+	case strings.Contains(text, "-->"):
+		return SRT, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse detects the format of data and parses it.
+func Parse(data []byte) (*Document, error) {
+	format, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFormat(data, format)
+}
+
+// ParseFormat parses data as the given format.
+func ParseFormat(data []byte, format Format) (*Document, error) {
+	text := strings.ReplaceAll(string(trimBOM(data)), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	switch format {
+	case SRT:
+		return parseSRT(text)
+	case VTT:
+		return parseVTT(text)
+	case ASS:
+		return parseASS(text)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// Convert parses data in any supported format and encodes it as format.
+func Convert(data []byte, format Format) ([]byte, error) {
+	doc, err := Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return doc.Encode(format)
+}
+
+// Encode serializes the document in the given format.
+func (d *Document) Encode(format Format) ([]byte, error) {
+	switch format {
+	case SRT:
+		return encodeSRT(d), nil
+	case VTT:
+		return encodeVTT(d), nil
+	case ASS:
+		return encodeASS(d), nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// Shift moves every cue by offset, which may be negative. Cues ending before zero
+// are removed and cues starting before zero are clamped to start at zero.
+func (d *Document) Shift(offset time.Duration) {
+	d.transform(1, offset)
+}
+
+// ConvertFPS retimes a subtitle made for a video at fromFPS to play in sync with the
+// same video at toFPS, keeping every cue on the same frame numbers.
+func (d *Document) ConvertFPS(fromFPS, toFPS float64) error {
+	if fromFPS <= 0 || toFPS <= 0 {
+		return fmt.Errorf("subfmt: invalid frame rate conversion %v -> %v", fromFPS, toFPS)
+	}
+	d.transform(fromFPS/toFPS, 0)
+	return nil
+}
+
+// Shift reads a subtitle file in any supported format from r, moves every cue by
+// offset as Document.Shift does and returns it in the same format. Only the timestamps
+// change: styles, positioning, cue settings, comments and line endings are copied
+// unchanged. It is the offline counterpart of the timeshift download option.
+func Shift(r io.Reader, offset time.Duration) ([]byte, error) {
+	return retime(r, 1, offset)
+}
+
+// Rescale reads a subtitle file in any supported format from r, converts its timing
+// from fromFPS to toFPS as Document.ConvertFPS does and returns it in the same format,
+// changing only the timestamps like Shift. It is the offline counterpart of the
+// in_fps/out_fps download options.
+func Rescale(r io.Reader, fromFPS, toFPS float64) ([]byte, error) {
+	if fromFPS <= 0 || toFPS <= 0 {
+		return nil, fmt.Errorf("subfmt: invalid frame rate conversion %v -> %v", fromFPS, toFPS)
+	}
+	return retime(r, fromFPS/toFPS, 0)
+}
+
+// retime reads the content of r and rewrites its timestamps, keeping a leading byte
+// order mark.
+func retime(r io.Reader, scale float64, offset time.Duration) ([]byte, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("subfmt: failed to read subtitle: %w", err)
+	}
+	format, err := Detect(data)
+	if err != nil {
+		return nil, err
+	}
+	body := trimBOM(data)
+	text, err := retimeText(string(body), format, scale, offset)
+	if err != nil {
+		return nil, err
+	}
+	bom := data[:len(data)-len(body)]
+	return append(append([]byte(nil), bom...), text...), nil
+}
+
+func (d *Document) transform(scale float64, offset time.Duration) {
+	cues := d.Cues[:0]
+	for _, c := range d.Cues {
+		c.Start = time.Duration(math.Round(float64(c.Start)*scale)) + offset
+		c.End = time.Duration(math.Round(float64(c.End)*scale)) + offset
+		if c.End < 0 {
+			continue
+		}
+		if c.Start < 0 {
+			c.Start = 0
+		}
+		cues = append(cues, c)
+	}
+	d.Cues = cues
+}
+
+func trimBOM(data []byte) []byte {
+	return bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+}
+
+// parseTimestamp parses "[hh:]mm:ss[.,]fff" timestamps. The fraction may have any
+// number of digits, so ASS centiseconds ("0:00:01.50") are handled too.
+func parseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	var total time.Duration
+	for i, part := range parts[:len(parts)-1] {
+		n, err := parseDigits(part)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		unit := time.Minute
+		if len(parts) == 3 && i == 0 {
+			unit = time.Hour
+		}
+		total += time.Duration(n) * unit
+	}
+	secs, frac, _ := strings.Cut(strings.Replace(parts[len(parts)-1], ",", ".", 1), ".")
+	n, err := parseDigits(secs)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", s)
+	}
+	total += time.Duration(n) * time.Second
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		f, err := parseDigits(frac)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", s)
+		}
+		total += time.Duration(f) * time.Duration(math.Pow10(9-len(frac)))
+	}
+	return total, nil
+}
+
+func parseDigits(s string) (int, error) {
+	if s == "" {
+		return 0, errors.New("empty number")
+	}
+	n := 0
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("invalid number %q", s)
+		}
+		n = n*10 + int(r-'0')
+	}
+	return n, nil
+}
+
+// formatTimestamp formats d as hh:mm:ss followed by sep and milliseconds.
+func formatTimestamp(d time.Duration, sep string) string {
+	if d < 0 {
+		d = 0
+	}
+	ms := d.Round(time.Millisecond).Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
+
+// parseTiming parses a "start --> end [settings]" line.
+func parseTiming(line string) (start, end time.Duration, err error) {
+	from, to, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid timing line %q", line)
+	}
+	if fields := strings.Fields(to); len(fields) > 0 {
+		to = fields[0] // Drop WebVTT cue settings
+	}
+	if start, err = parseTimestamp(from); err != nil {
+		return 0, 0, err
+	}
+	if end, err = parseTimestamp(to); err != nil {
+		return 0, 0, err
+	}
+	return start, end, nil
+}
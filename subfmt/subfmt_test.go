@@ -0,0 +1,197 @@
+package subfmt
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sampleSRT = "\xef\xbb\xbf1\r\n00:00:01,000 --> 00:00:02,500\r\nHello <i>there</i>\r\nGeneral Kenobi\r\n\r\n2\r\n00:01:02,345 --> 00:01:04,000\r\n<font color=\"red\">Red</font> & bold <b>text</b>\r\n\r\n"
+
+const sampleVTT = `WEBVTT
+Kind: captions
+
+NOTE This is a comment
+
+intro
+00:01.000 --> 00:02.500 align:start position:10%
+<v Anna>Hello <i.loud>there</i>
+General Kenobi
+
+01:02.345 --> 01:04.000
+Tom &amp; Jerry
+`
+
+const sampleASS = `[Script Info]
+Title: Sample
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Sign,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,1,0,8,10,10,10,1
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Comment: 0,0:00:00.00,0:00:01.00,Default,,0,0,0,,ignored
+Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,Hello {\i1}there{\i0}\NGeneral Kenobi
+Dialogue: 0,0:01:02.35,0:01:04.00,Sign,,0,0,0,,{\pos(10,10)\bord2}Text, with a comma{\b1}
+`
+
+func TestDetect(t *testing.T) {
+	for name, tc := range map[string]struct {
+		data string
+		want Format
+	}{
+		"srt": {sampleSRT, SRT},
+		"vtt": {sampleVTT, VTT},
+		"ass": {sampleASS, ASS},
+	} {
+		t.Run(name, func(t *testing.T) {
+			got, err := Detect([]byte(tc.data))
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+	_, err := Detect([]byte("just some text"))
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestParse(t *testing.T) {
+	t.Run("SRT", func(t *testing.T) {
+		doc, err := Parse([]byte(sampleSRT))
+		require.NoError(t, err)
+		require.Len(t, doc.Cues, 2)
+		assert.Equal(t, Cue{Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello <i>there</i>\nGeneral Kenobi"}, doc.Cues[0])
+		assert.Equal(t, time.Minute+2345*time.Millisecond, doc.Cues[1].Start)
+	})
+
+	t.Run("VTT", func(t *testing.T) {
+		doc, err := Parse([]byte(sampleVTT))
+		require.NoError(t, err)
+		require.Len(t, doc.Cues, 2)
+		assert.Equal(t, Cue{Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello <i>there</i>\nGeneral Kenobi"}, doc.Cues[0])
+		assert.Equal(t, "Tom & Jerry", doc.Cues[1].Text)
+		assert.Equal(t, 4*time.Second+time.Minute, doc.Cues[1].End)
+	})
+
+	t.Run("ASS", func(t *testing.T) {
+		doc, err := Parse([]byte(sampleASS))
+		require.NoError(t, err)
+		require.Len(t, doc.Cues, 2)
+		assert.Equal(t, Cue{Start: time.Second, End: 2500 * time.Millisecond, Text: "Hello <i>there</i>\nGeneral Kenobi", Style: "Default"}, doc.Cues[0])
+		assert.Equal(t, Cue{Start: time.Minute + 2350*time.Millisecond, End: time.Minute + 4*time.Second, Text: "Text, with a comma<b></b>", Style: "Sign"}, doc.Cues[1])
+	})
+
+	t.Run("InvalidTiming", func(t *testing.T) {
+		_, err := ParseFormat([]byte("1\n00:00:xx,000 --> 00:00:02,000\nHi\n"), SRT)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncode(t *testing.T) {
+	doc, err := Parse([]byte(sampleSRT))
+	require.NoError(t, err)
+
+	srt, err := doc.Encode(SRT)
+	require.NoError(t, err)
+	assert.Equal(t, "1\n00:00:01,000 --> 00:00:02,500\nHello <i>there</i>\nGeneral Kenobi\n\n"+
+		"2\n00:01:02,345 --> 00:01:04,000\n<font color=\"red\">Red</font> & bold <b>text</b>\n\n", string(srt))
+
+	vtt, err := doc.Encode(VTT)
+	require.NoError(t, err)
+	assert.Equal(t, "WEBVTT\n\n00:00:01.000 --> 00:00:02.500\nHello <i>there</i>\nGeneral Kenobi\n\n"+
+		"00:01:02.345 --> 00:01:04.000\nRed &amp; bold <b>text</b>\n\n", string(vtt))
+
+	ass, err := doc.Encode(ASS)
+	require.NoError(t, err)
+	assert.Contains(t, string(ass), "[V4+ Styles]\n")
+	assert.Contains(t, string(ass), "Dialogue: 0,0:00:01.00,0:00:02.50,Default,,0,0,0,,Hello {\\i1}there{\\i0}\\NGeneral Kenobi\n")
+	assert.Contains(t, string(ass), "Dialogue: 0,0:01:02.35,0:01:04.00,Default,,0,0,0,,Red & bold {\\b1}text{\\b0}\n")
+
+	_, err = doc.Encode("sub")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, from := range []string{sampleSRT, sampleVTT, sampleASS} {
+		doc, err := Parse([]byte(from))
+		require.NoError(t, err)
+		for _, format := range []Format{SRT, VTT, ASS} {
+			data, err := doc.Encode(format)
+			require.NoError(t, err)
+			again, err := ParseFormat(data, format)
+			require.NoError(t, err, "format %s", format)
+			require.Len(t, again.Cues, len(doc.Cues))
+			for i := range doc.Cues {
+				assert.InDelta(t, float64(doc.Cues[i].Start), float64(again.Cues[i].Start), float64(5*time.Millisecond))
+				assert.Equal(t, basicTags(doc.Cues[i].Text), basicTags(again.Cues[i].Text), "%s -> %s", doc.Format, format)
+			}
+		}
+	}
+}
+
+func TestASSKeepsHeader(t *testing.T) {
+	doc, err := Parse([]byte(sampleASS))
+	require.NoError(t, err)
+	out, err := doc.Encode(ASS)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "[Script Info]\nTitle: Sample\n"))
+	assert.Contains(t, string(out), "Style: Sign,Arial,20")
+	assert.Equal(t, 1, strings.Count(string(out), "[Events]"))
+}
+
+func TestShift(t *testing.T) {
+	doc := &Document{Cues: []Cue{
+		{Start: time.Second, End: 2 * time.Second, Text: "gone"},
+		{Start: 3 * time.Second, End: 5 * time.Second, Text: "clamped"},
+		{Start: 10 * time.Second, End: 12 * time.Second, Text: "shifted"},
+	}}
+	doc.Shift(-4 * time.Second)
+	assert.Equal(t, []Cue{
+		{Start: 0, End: time.Second, Text: "clamped"},
+		{Start: 6 * time.Second, End: 8 * time.Second, Text: "shifted"},
+	}, doc.Cues)
+}
+
+func TestConvertFPS(t *testing.T) {
+	doc := &Document{Cues: []Cue{{Start: 25 * time.Second, End: 50 * time.Second}}}
+	require.NoError(t, doc.ConvertFPS(25, 23.976))
+	assert.InDelta(t, float64(26067*time.Millisecond), float64(doc.Cues[0].Start), float64(time.Millisecond))
+
+	assert.Error(t, doc.ConvertFPS(0, 25))
+}
+
+func TestConvert(t *testing.T) {
+	out, err := Convert([]byte(sampleVTT), SRT)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "1\n00:00:01,000 --> 00:00:02,500\nHello <i>there</i>\n"))
+}
+
+func TestShiftReader(t *testing.T) {
+	out, err := Shift(strings.NewReader(sampleSRT), 2*time.Second)
+	require.NoError(t, err)
+	want := strings.NewReplacer("00:00:01,000 --> 00:00:02,500", "00:00:03,000 --> 00:00:04,500", "00:01:02,345 --> 00:01:04,000", "00:01:04,345 --> 00:01:06,000").Replace(sampleSRT)
+	assert.Equal(t, want, string(out), "keeps the byte order mark and CRLF line endings")
+
+	out, err = Shift(strings.NewReader(sampleVTT), -time.Second)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "WEBVTT"), "keeps the input format")
+
+	_, err = Shift(strings.NewReader("not a subtitle"), time.Second)
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+}
+
+func TestRescaleReader(t *testing.T) {
+	out, err := Rescale(strings.NewReader(sampleASS), 25, 23.976)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(string(out), "[Script Info]"))
+	doc, err := Parse(out)
+	require.NoError(t, err)
+	assert.InDelta(t, float64(1043*time.Millisecond), float64(doc.Cues[0].Start), float64(10*time.Millisecond))
+
+	_, err = Rescale(strings.NewReader(sampleSRT), 25, 0)
+	assert.Error(t, err)
+}
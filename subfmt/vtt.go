@@ -0,0 +1,60 @@
+package subfmt
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var vttUnescaper = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&nbsp;", "\u00a0", "&lrm;", "\u200e", "&rlm;", "\u200f")
+
+func parseVTT(text string) (*Document, error) {
+	doc := &Document{Format: VTT}
+	for i, block := range splitBlocks(text) {
+		if i == 0 && strings.HasPrefix(block[0], "WEBVTT") {
+			continue
+		}
+		if first := strings.Fields(block[0]); len(first) > 0 && (first[0] == "NOTE" || first[0] == "STYLE" || first[0] == "REGION") {
+			continue
+		}
+		timing := 0
+		if !strings.Contains(block[0], "-->") {
+			timing = 1 // Cue identifier
+		}
+		if timing >= len(block) || !strings.Contains(block[timing], "-->") {
+			continue
+		}
+		start, end, err := parseTiming(block[timing])
+		if err != nil {
+			return nil, fmt.Errorf("subfmt: vtt cue %d: %w", i, err)
+		}
+		body := basicTags(strings.Join(block[timing+1:], "\n"))
+		doc.Cues = append(doc.Cues, Cue{Start: start, End: end, Text: vttUnescaper.Replace(body)})
+	}
+	return doc, nil
+}
+
+func encodeVTT(d *Document) []byte {
+	var b strings.Builder
+	b.WriteString("WEBVTT\n\n")
+	for _, c := range d.Cues {
+		text := strings.ReplaceAll(basicTags(cueText(c.Text)), "&", "&amp;")
+		fmt.Fprintf(&b, "%s --> %s\n%s\n\n", formatTimestamp(c.Start, "."), formatTimestamp(c.End, "."), text)
+	}
+	return []byte(b.String())
+}
+
+var tagPattern = regexp.MustCompile(`<(/?)([A-Za-z]+|[0-9])[^<>]*>`)
+
+// basicTags keeps <b>, <i> and <u> (dropping WebVTT classes such as <i.loud>) and
+// removes every other tag, e.g. <font>, <c> or <v Speaker>.
+func basicTags(text string) string {
+	return tagPattern.ReplaceAllStringFunc(text, func(tag string) string {
+		m := tagPattern.FindStringSubmatch(tag)
+		switch name := strings.ToLower(m[2]); name {
+		case "b", "i", "u":
+			return "<" + m[1] + name + ">"
+		}
+		return ""
+	})
+}
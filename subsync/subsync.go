@@ -0,0 +1,190 @@
+// Package subsync is an experimental automatic subtitle synchronizer.
+//
+// Align compares the cue timings of a subtitle with a correctly timed reference
+// (an embedded track or a synced subtitle in another language) and estimates the
+// global offset and linear scale (frame-rate mismatch) that best map one onto the
+// other. Many otherwise good subtitles only need a constant shift.
+package subsync
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Cue is a timed subtitle entry. Only the timing is used for alignment.
+type Cue struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// Result describes the transform mapping target timings onto the reference:
+// aligned = original*Scale + Offset.
+type Result struct {
+	Offset     time.Duration
+	Scale      float64
+	Confidence float64 // Fraction of target cues matching a reference cue after alignment, 0..1
+	Matched    int     // Number of target cues that matched
+}
+
+// Options tunes Align. The zero value uses the defaults.
+type Options struct {
+	MaxOffset  time.Duration // Largest shift considered. Default 2 minutes.
+	Resolution time.Duration // Histogram bucket size. Default 100ms.
+	Tolerance  time.Duration // Max distance for a cue to count as matched. Default 500ms.
+	Scales     []float64     // Candidate scales. Default: 1 plus the common FPS conversions.
+}
+
+// ErrNotEnoughCues is returned when either side has too few cues to align.
+var ErrNotEnoughCues = errors.New("subsync: not enough cues to align")
+
+// minCues is the minimum number of cues required on each side.
+const minCues = 3
+
+// DefaultScales covers no scaling and conversions between 23.976, 24 and 25 fps.
+var DefaultScales = []float64{
+	1,
+	25 / 23.976, 23.976 / 25,
+	25.0 / 24, 24.0 / 25,
+	24 / 23.976, 23.976 / 24,
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxOffset <= 0 {
+		o.MaxOffset = 2 * time.Minute
+	}
+	if o.Resolution <= 0 {
+		o.Resolution = 100 * time.Millisecond
+	}
+	if o.Tolerance <= 0 {
+		o.Tolerance = 500 * time.Millisecond
+	}
+	if len(o.Scales) == 0 {
+		o.Scales = DefaultScales
+	}
+	return o
+}
+
+// Align estimates the offset and scale that best synchronize target with reference.
+// For each candidate scale it builds a histogram of differences between reference and
+// target cue onsets; the tallest peak is the most likely offset. The scale whose best
+// offset matches the most cues wins.
+func Align(reference, target []Cue, opts Options) (Result, error) {
+	if len(reference) < minCues || len(target) < minCues {
+		return Result{}, ErrNotEnoughCues
+	}
+	opts = opts.withDefaults()
+
+	refStarts := starts(reference)
+	tgtStarts := starts(target)
+
+	best := Result{Scale: 1}
+	for _, scale := range opts.Scales {
+		offset := bestOffset(refStarts, tgtStarts, scale, opts)
+		matched := countMatches(refStarts, tgtStarts, scale, offset, opts.Tolerance)
+		if matched > best.Matched || (matched == best.Matched && scale == 1) {
+			best = Result{Offset: offset, Scale: scale, Matched: matched}
+		}
+	}
+	best.Confidence = float64(best.Matched) / float64(len(tgtStarts))
+	return best, nil
+}
+
+// Apply returns a copy of cues with the transform described by r applied.
+// Cues that would start before zero are clamped to zero.
+func Apply(cues []Cue, r Result) []Cue {
+	out := make([]Cue, len(cues))
+	for i, c := range cues {
+		out[i] = Cue{Start: transform(c.Start, r.Scale, r.Offset), End: transform(c.End, r.Scale, r.Offset)}
+		if out[i].Start < 0 {
+			out[i].Start = 0
+		}
+		if out[i].End < out[i].Start {
+			out[i].End = out[i].Start
+		}
+	}
+	return out
+}
+
+func transform(d time.Duration, scale float64, offset time.Duration) time.Duration {
+	return time.Duration(math.Round(float64(d)*scale)) + offset
+}
+
+func starts(cues []Cue) []time.Duration {
+	s := make([]time.Duration, len(cues))
+	for i, c := range cues {
+		s[i] = c.Start
+	}
+	sort.Slice(s, func(i, j int) bool { return s[i] < s[j] })
+	return s
+}
+
+// bestOffset returns the peak of the onset-difference histogram for the given scale,
+// refined to the mean difference of the pairs falling in the peak.
+func bestOffset(ref, tgt []time.Duration, scale float64, opts Options) time.Duration {
+	buckets := int(opts.MaxOffset/opts.Resolution) * 2
+	histogram := make([]int, buckets+1)
+	bucketOf := func(diff time.Duration) int {
+		return int((diff + opts.MaxOffset) / opts.Resolution)
+	}
+
+	lo := 0
+	for _, t := range tgt {
+		scaled := transform(t, scale, 0)
+		for lo < len(ref) && ref[lo] < scaled-opts.MaxOffset {
+			lo++
+		}
+		for i := lo; i < len(ref) && ref[i] <= scaled+opts.MaxOffset; i++ {
+			histogram[bucketOf(ref[i]-scaled)]++
+		}
+	}
+
+	// Smooth over neighbouring buckets so a peak split across a boundary still wins.
+	peak, peakScore := buckets/2, -1
+	for i := range histogram {
+		score := histogram[i]
+		if i > 0 {
+			score += histogram[i-1]
+		}
+		if i < buckets {
+			score += histogram[i+1]
+		}
+		if score > peakScore {
+			peak, peakScore = i, score
+		}
+	}
+
+	// Refine with the mean of the differences inside the peak window.
+	center := time.Duration(peak)*opts.Resolution - opts.MaxOffset
+	var sum time.Duration
+	var n int
+	lo = 0
+	for _, t := range tgt {
+		scaled := transform(t, scale, 0)
+		for lo < len(ref) && ref[lo] < scaled+center-opts.Resolution*2 {
+			lo++
+		}
+		for i := lo; i < len(ref) && ref[i] <= scaled+center+opts.Resolution*2; i++ {
+			sum += ref[i] - scaled
+			n++
+		}
+	}
+	if n == 0 {
+		return center
+	}
+	return (sum / time.Duration(n)).Round(time.Millisecond)
+}
+
+// countMatches counts target onsets that land within tolerance of a reference onset.
+func countMatches(ref, tgt []time.Duration, scale float64, offset, tolerance time.Duration) int {
+	matched := 0
+	for _, t := range tgt {
+		aligned := transform(t, scale, offset)
+		i := sort.Search(len(ref), func(i int) bool { return ref[i] >= aligned-tolerance })
+		if i < len(ref) && ref[i] <= aligned+tolerance {
+			matched++
+		}
+	}
+	return matched
+}
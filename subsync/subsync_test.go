@@ -0,0 +1,70 @@
+package subsync
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceCues builds an irregular, deterministic cue track similar to real dialogue.
+func referenceCues(n int) []Cue {
+	rng := rand.New(rand.NewSource(42))
+	cues := make([]Cue, n)
+	at := 5 * time.Second
+	for i := range cues {
+		length := time.Duration(1000+rng.Intn(3000)) * time.Millisecond
+		cues[i] = Cue{Start: at, End: at + length}
+		at += length + time.Duration(300+rng.Intn(6000))*time.Millisecond
+	}
+	return cues
+}
+
+func TestAlignConstantOffset(t *testing.T) {
+	ref := referenceCues(300)
+	shifted := Apply(ref, Result{Scale: 1, Offset: 3500 * time.Millisecond})
+
+	res, err := Align(ref, shifted, Options{})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, res.Scale)
+	assert.InDelta(t, float64(-3500*time.Millisecond), float64(res.Offset), float64(20*time.Millisecond))
+	assert.Greater(t, res.Confidence, 0.95)
+
+	fixed := Apply(shifted, res)
+	assert.InDelta(t, float64(ref[10].Start), float64(fixed[10].Start), float64(20*time.Millisecond))
+}
+
+func TestAlignFrameRateMismatch(t *testing.T) {
+	ref := referenceCues(400)
+	// A subtitle timed for 25fps played against a 23.976fps video.
+	target := Apply(ref, Result{Scale: 23.976 / 25, Offset: -time.Second})
+
+	res, err := Align(ref, target, Options{})
+	require.NoError(t, err)
+	assert.InDelta(t, 25/23.976, res.Scale, 1e-9)
+	assert.Greater(t, res.Confidence, 0.9)
+
+	fixed := Apply(target, res)
+	last := len(ref) - 1
+	assert.InDelta(t, float64(ref[last].Start), float64(fixed[last].Start), float64(100*time.Millisecond))
+}
+
+func TestAlignUnrelatedTracksHaveLowConfidence(t *testing.T) {
+	ref := referenceCues(200)
+	other := make([]Cue, 200)
+	for i := range other {
+		start := time.Duration(i) * 7919 * time.Millisecond
+		other[i] = Cue{Start: start, End: start + time.Second}
+	}
+
+	res, err := Align(ref, other, Options{})
+	require.NoError(t, err)
+	assert.Less(t, res.Confidence, 0.5)
+}
+
+func TestAlignNotEnoughCues(t *testing.T) {
+	_, err := Align([]Cue{{}}, referenceCues(10), Options{})
+	assert.ErrorIs(t, err, ErrNotEnoughCues)
+}
@@ -1,31 +1,223 @@
 package opensubtitles
 
-import "context"
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
 
-// Methods related to subtitles (Search, Download)
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+)
+
+// Methods related to subtitles (Search, Download, Upload)
 
 // SearchSubtitles searches for subtitles based on various criteria.
+// The parameters are normalized and validated first, see SearchSubtitlesParams.Normalize.
 func (c *Client) SearchSubtitles(ctx context.Context, params SearchSubtitlesParams) (*SearchSubtitlesResponse, error) {
-	var response SearchSubtitlesResponse
-	// Params struct already has `url` tags for query string encoding
-	err := c.httpClient.Get(ctx, "/subtitles", params, &response)
+	params, err := c.translationDefaults(params).Normalize()
 	if err != nil {
 		return nil, err
 	}
-	return &response, nil
+	return dedupe(ctx, c, "/subtitles?"+params.EncodeQuery(), func(ctx context.Context) (*SearchSubtitlesResponse, error) {
+		var response SearchSubtitlesResponse
+		// Params struct already has `url` tags for query string encoding
+		if err := c.httpClient.Get(ctx, "/subtitles", params, &response); err != nil {
+			return nil, err
+		}
+		return &response, nil
+	}, func(response *SearchSubtitlesResponse) *SearchSubtitlesResponse {
+		clone := *response
+		clone.Data = slices.Clone(response.Data)
+		return &clone
+	})
+}
+
+// translationDefaults applies Config.ExcludeAITranslated and
+// Config.ExcludeMachineTranslated to parameters that leave the filters unset.
+func (c *Client) translationDefaults(params SearchSubtitlesParams) SearchSubtitlesParams {
+	exclude := Exclude
+	if c.config.ExcludeAITranslated && params.AITranslated == nil {
+		params.AITranslated = &exclude
+	}
+	if c.config.ExcludeMachineTranslated && params.MachineTranslated == nil {
+		params.MachineTranslated = &exclude
+	}
+	return params
+}
+
+// GetSubtitleByID returns the subtitle with the given REST subtitle ID (Subtitle.ID),
+// e.g. one stored from an earlier search, without searching again. A subtitle that no
+// longer exists returns ErrNotFound. IDs of the old opensubtitles.org site
+// (SubtitleAttributes.LegacySubtitleID) are not accepted; see GetSubtitleByLegacyID.
+func (c *Client) GetSubtitleByID(ctx context.Context, id string) (*Subtitle, error) {
+	if id == "" {
+		return nil, errors.New("subtitle ID is required")
+	}
+	var response struct {
+		Data Subtitle `json:"data"`
+	}
+	err := c.httpClient.Get(ctx, "/subtitles/"+url.PathEscape(id), nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response.Data, nil
+}
+
+// GetSubtitleByLegacyID returns the subtitle whose ID on the old opensubtitles.org site
+// (SubtitleAttributes.LegacySubtitleID) is legacyID, e.g. one read from an NFO file or
+// an old database. The API has neither an endpoint nor a search filter for these IDs,
+// so the subtitle is looked for in the results of the search within, whose pages are
+// walked until it is found. within must name the movie or episode, by ID, IMDbID,
+// TMDBID, ParentFeatureID, ParentIMDbID or ParentTMDBID, which such records usually
+// store next to the legacy ID; setting Languages as well saves requests. A subtitle
+// that is not among the results returns an error matching ErrNotFound.
+func (c *Client) GetSubtitleByLegacyID(ctx context.Context, legacyID int, within SearchSubtitlesParams) (*Subtitle, error) {
+	if legacyID <= 0 {
+		return nil, fmt.Errorf("%w: legacy subtitle ID %d must be positive", ErrInvalidSearchParams, legacyID)
+	}
+	if within.ID == nil && within.IMDbID == nil && within.TMDBID == nil &&
+		within.ParentFeatureID == nil && within.ParentIMDbID == nil && within.ParentTMDBID == nil {
+		return nil, fmt.Errorf("%w: looking up legacy subtitle ID %d requires the feature's ID, IMDbID or TMDBID", ErrInvalidSearchParams, legacyID)
+	}
+	it := c.SearchSubtitlesIter(ctx, within, SearchIterOptions{})
+	for it.Next() {
+		sub := it.Subtitle()
+		if id := sub.Attributes.LegacySubtitleID; id != nil && *id == legacyID {
+			return &sub, nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+	return nil, fmt.Errorf("%w: no subtitle with legacy ID %d", ErrNotFound, legacyID)
 }
 
-// Download requests a download link for a specific subtitle file.
+// Download requests a download link for a specific subtitle file. With
+// Config.DownloadLinkTTL set, a link requested for the same parameters within the
+// window is returned again without calling the API, unless ForceDownload is set.
 // Requires authentication.
 func (c *Client) Download(ctx context.Context, params DownloadRequest) (*DownloadResponse, error) {
+	if err := c.requireLogin("Download"); err != nil {
+		return nil, err
+	}
+	if cached := c.links.get(params); cached != nil {
+		return cached, nil
+	}
+	if quota, ok := c.LastDownloadQuota(); ok {
+		ctx = context.WithValue(ctx, quotaBeforeKey{}, quota) // For downloadNotCounted
+	}
 	// Authentication token is added automatically by the httpClient if available.
 	var response DownloadResponse
 	err := c.httpClient.Post(ctx, "/download", params, &response)
 	if err != nil {
 		return nil, err
 	}
+	c.reportQuota(ctx, response.Remaining, response.ResetTimeUTC)
+	c.links.put(params, response)
+	c.recordDownload(ctx, params, &response)
+	return &response, nil
+}
+
+// quotaBeforeKey holds the DownloadQuota known before a Download request.
+type quotaBeforeKey struct{}
+
+// downloadNotCounted is the idempotency check of /download: it confirms with
+// GetUserInfo that a failed Download left the quota known before it untouched.
+// Without a known quota, or after its reset time, nothing can be confirmed.
+func (c *Client) downloadNotCounted(ctx context.Context) (bool, error) {
+	before, ok := ctx.Value(quotaBeforeKey{}).(DownloadQuota)
+	if !ok || (!before.ResetTime.IsZero() && !time.Now().Before(before.ResetTime)) {
+		return false, nil
+	}
+	info, err := c.GetUserInfo(ctx)
+	if err != nil {
+		return false, err
+	}
+	return info.Data.RemainingDownloads >= before.Remaining, nil
+}
+
+// UploadSubtitle uploads the subtitle content read from r through the REST /upload
+// endpoint as multipart form data. Form fields use the same names as the XML-RPC
+// upload, so metadata behaves identically with both transports.
+// Requires authentication.
+func (c *Client) UploadSubtitle(ctx context.Context, params UploadParams, r io.Reader) (*UploadResponse, error) {
+	if err := c.requireLogin("UploadSubtitle"); err != nil {
+		return nil, err
+	}
+	if params.FileName == "" {
+		return nil, errors.New("upload: subtitle file name is required")
+	}
+	if params.Language == "" {
+		return nil, errors.New("upload: subtitle language is required")
+	}
+	if err := params.Metadata.Validate(); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	form := multipart.NewWriter(&buf)
+	fields := [][2]string{
+		{"sublanguageid", string(params.Language)},
+		{"moviereleasename", params.ReleaseName},
+		{"movieaka", params.MovieAka()},
+		{"subauthorcomment", params.Comment},
+		{"subtranslator", params.Translator},
+		{"moviehash", params.MovieHash},
+		{"moviefilename", params.MovieFileName},
+		{"hearingimpaired", formBool(params.HearingImpaired)},
+		{"highdefinition", formBool(params.HighDefinition)},
+		{"automatictranslation", formBool(params.AutomaticTranslation)},
+		{"foreignpartsonly", formBool(params.ForeignPartsOnly)},
+	}
+	if params.IMDbID > 0 {
+		fields = append(fields, [2]string{"idmovieimdb", strconv.Itoa(params.IMDbID)})
+	}
+	if params.MovieByteSize > 0 {
+		fields = append(fields, [2]string{"moviebytesize", strconv.FormatInt(params.MovieByteSize, 10)})
+	}
+	if params.FPS > 0 {
+		fields = append(fields, [2]string{"moviefps", strconv.FormatFloat(params.FPS, 'f', 3, 64)})
+	}
+	for _, field := range fields {
+		if field[1] == "" {
+			continue
+		}
+		if err := form.WriteField(field[0], field[1]); err != nil {
+			return nil, fmt.Errorf("failed to encode upload form: %w", err)
+		}
+	}
+	part, err := form.CreateFormFile("file", params.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode upload form: %w", err)
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("failed to read subtitle content: %w", err)
+	}
+	if err := form.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode upload form: %w", err)
+	}
+
+	var response UploadResponse
+	body := httpclient.RawBody{ContentType: form.FormDataContentType(), Data: buf.Bytes()}
+	if err := c.httpClient.Post(ctx, "/upload", body, &response); err != nil {
+		return nil, err
+	}
 	return &response, nil
 }
 
+// formBool encodes a flag the way the upload endpoints expect ("1" or "0").
+func formBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
 // TODO: Implement DownloadSubtitle
 // func (c *Client) DownloadSubtitle(ctx context.Context, params DownloadRequest) (*DownloadResponse, error) { ... }
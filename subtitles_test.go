@@ -4,12 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
+	"strings"
 
-	// "net/url"
+	"net/url"
 	"testing"
 	"time"
 
+	"github.com/angelospk/opensubtitles-go/upload"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -122,6 +125,36 @@ func TestSearchSubtitlesWithParams(t *testing.T) {
 	// assert.True(t, true, "Test needs SearchSubtitles implementation")
 }
 
+func TestSearchSubtitlesTranslationDefaults(t *testing.T) {
+	var query url.Values
+	server, _ := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	client, err := NewClient(Config{ApiKey: "test-api-key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits,
+		ExcludeAITranslated: true, ExcludeMachineTranslated: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat")})
+	require.NoError(t, err)
+	assert.Equal(t, "exclude", query.Get("ai_translated"))
+	assert.Equal(t, "exclude", query.Get("machine_translated"))
+
+	include := Include
+	_, err = client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat"), MachineTranslated: &include})
+	require.NoError(t, err)
+	assert.Equal(t, "exclude", query.Get("ai_translated"))
+	assert.Equal(t, "include", query.Get("machine_translated"), "explicit parameters win")
+
+	_, client = setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.NotContains(t, r.URL.RawQuery, "translated", "no filter by default")
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	})
+	_, err = client.SearchSubtitles(ctx, SearchSubtitlesParams{Query: String("heat")})
+	require.NoError(t, err)
+}
+
 func TestSearchSubtitlesError(t *testing.T) {
 	handler := func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError) // Simulate server error
@@ -142,6 +175,63 @@ func TestSearchSubtitlesError(t *testing.T) {
 
 // --- Download Subtitle Tests ---
 
+func TestGetSubtitleByID(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/subtitles/6891574", r.URL.Path)
+		_, _ = w.Write([]byte(`{"data":{"id":"6891574","type":"subtitle","attributes":{"subtitle_id":"6891574","language":"en","legacy_subtitle_id":3936836,"files":[{"file_id":7075599}]}}}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	sub, err := client.GetSubtitleByID(context.Background(), "6891574")
+	require.NoError(t, err)
+	assert.Equal(t, "6891574", sub.ID)
+	assert.Equal(t, 3936836, *sub.Attributes.LegacySubtitleID)
+	require.Len(t, sub.Attributes.Files, 1)
+	assert.Equal(t, 7075599, sub.Attributes.Files[0].FileID)
+
+	_, err = client.GetSubtitleByID(context.Background(), "")
+	assert.Error(t, err)
+}
+
+func TestGetSubtitleByLegacyID(t *testing.T) {
+	var pages []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/subtitles", r.URL.Path)
+		assert.Equal(t, "113277", r.URL.Query().Get("imdb_id"))
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "" || page == "1" {
+			_, _ = w.Write([]byte(`{"total_pages":2,"total_count":3,"page":1,"data":[{"id":"1","attributes":{"legacy_subtitle_id":11}},{"id":"2","attributes":{"legacy_subtitle_id":null}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"total_pages":2,"total_count":3,"page":2,"data":[{"id":"6891574","attributes":{"legacy_subtitle_id":3936836}}]}`))
+	}
+	_, client := setupTestServer(t, handler)
+	imdbID := 113277
+	within := SearchSubtitlesParams{IMDbID: &imdbID}
+
+	sub, err := client.GetSubtitleByLegacyID(context.Background(), 3936836, within)
+	require.NoError(t, err)
+	assert.Equal(t, "6891574", sub.ID)
+	assert.Equal(t, []string{"1", "2"}, pages, "walks the pages until found")
+
+	pages = nil
+	sub, err = client.GetSubtitleByLegacyID(context.Background(), 11, within)
+	require.NoError(t, err)
+	assert.Equal(t, "1", sub.ID)
+	assert.Len(t, pages, 1, "stops at the first match")
+
+	_, err = client.GetSubtitleByLegacyID(context.Background(), 99, within)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	query := "heat"
+	_, err = client.GetSubtitleByLegacyID(context.Background(), 3936836, SearchSubtitlesParams{Query: &query})
+	assert.ErrorIs(t, err, ErrInvalidSearchParams, "a search that does not name the feature")
+	_, err = client.GetSubtitleByLegacyID(context.Background(), 0, within)
+	assert.ErrorIs(t, err, ErrInvalidSearchParams)
+}
+
 func TestDownloadSubtitleSuccess(t *testing.T) {
 	token := "valid-download-token"
 	expectedFileID := 11047023
@@ -283,3 +373,58 @@ func TestDownloadSubtitleErrorFileID(t *testing.T) {
 	// Dummy assertion - REMOVE
 	// assert.True(t, true, "Test needs DownloadSubtitle implementation")
 }
+
+func TestUploadSubtitle(t *testing.T) {
+	content := "1\n00:00:01,000 --> 00:00:02,000\nHello\n"
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "/api/v1/upload", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+
+		assert.Equal(t, "el", r.FormValue("sublanguageid"))
+		assert.Equal(t, "1375666", r.FormValue("idmovieimdb"))
+		assert.Equal(t, "Inception.2010.1080p", r.FormValue("moviereleasename"))
+		assert.Equal(t, "Origen; Inception", r.FormValue("movieaka"))
+		assert.Equal(t, "Synced to the BluRay", r.FormValue("subauthorcomment"))
+		assert.Equal(t, "1", r.FormValue("hearingimpaired"))
+		assert.Equal(t, "0", r.FormValue("automatictranslation"))
+		assert.Equal(t, "23.976", r.FormValue("moviefps"))
+		_, hasHash := r.MultipartForm.Value["moviehash"]
+		assert.False(t, hasHash, "empty fields are omitted")
+
+		file, header, err := r.FormFile("file")
+		require.NoError(t, err)
+		defer file.Close()
+		assert.Equal(t, "inception.el.srt", header.Filename)
+		data, _ := io.ReadAll(file)
+		assert.Equal(t, content, string(data))
+
+		_ = json.NewEncoder(w).Encode(UploadResponse{Status: 200, SubtitleID: "99", URL: "https://www.opensubtitles.com/el/subtitles/99"})
+	}
+	_, client := setupTestServer(t, handler)
+	require.NoError(t, client.SetAuthToken("token", ""))
+
+	params := UploadParams{
+		Metadata: upload.Metadata{
+			ReleaseName:     "Inception.2010.1080p",
+			AKATitles:       []string{"Origen", "Inception"},
+			Comment:         "Synced to the BluRay",
+			HearingImpaired: true,
+		},
+		FileName: "inception.el.srt",
+		Language: "el",
+		IMDbID:   1375666,
+		FPS:      23.976,
+	}
+	resp, err := client.UploadSubtitle(context.Background(), params, strings.NewReader(content))
+	require.NoError(t, err)
+	assert.Equal(t, "99", resp.SubtitleID)
+
+	params.Comment = strings.Repeat("x", upload.MaxCommentLength+1)
+	_, err = client.UploadSubtitle(context.Background(), params, strings.NewReader(content))
+	assert.ErrorIs(t, err, upload.ErrInvalidMetadata)
+
+	_, err = client.UploadSubtitle(context.Background(), UploadParams{Language: "el"}, strings.NewReader(content))
+	assert.Error(t, err)
+}
@@ -0,0 +1,197 @@
+// Package testutil provides builders for fully populated opensubtitles domain values
+// with sensible defaults, so downstream tests do not need to hand-write large struct
+// literals. Every option is optional; zero values are replaced by defaults.
+package testutil
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+)
+
+// DefaultUploadDate is the upload date given to subtitles built without one.
+var DefaultUploadDate = time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+// SubtitleOptions customises NewSubtitle.
+type SubtitleOptions struct {
+	ID              string                     // Defaults to "1000001"
+	Language        opensubtitles.LanguageCode // Defaults to "en"
+	Title           string                     // Defaults to "Example Movie"
+	Year            int                        // Defaults to 2020
+	FeatureID       int                        // Defaults to 100001
+	FeatureType     opensubtitles.FeatureType  // Defaults to FeatureMovie, or FeatureEpisode with episode numbers
+	IMDbID          int                        // Defaults to 1234567
+	Release         string                     // Derived from Title and Year when empty
+	FileID          int                        // Defaults to the numeric ID plus one
+	FileName        string                     // Derived from Release when empty
+	UploadDate      time.Time                  // Defaults to DefaultUploadDate
+	DownloadCount   int
+	Ratings         float64
+	FPS             float64 // Defaults to 23.976
+	HearingImpaired bool
+	FromTrusted     bool
+	UploaderName    string // Defaults to "uploader"
+	// SeasonNumber and EpisodeNumber make the subtitle an episode subtitle when set.
+	SeasonNumber  int
+	EpisodeNumber int
+}
+
+// NewSubtitle returns a Subtitle as the API would return it from a search.
+func NewSubtitle(opts SubtitleOptions) opensubtitles.Subtitle {
+	if opts.ID == "" {
+		opts.ID = "1000001"
+	}
+	if opts.Language == "" {
+		opts.Language = "en"
+	}
+	if opts.Title == "" {
+		opts.Title = "Example Movie"
+	}
+	if opts.Year == 0 {
+		opts.Year = 2020
+	}
+	if opts.FeatureID == 0 {
+		opts.FeatureID = 100001
+	}
+	if opts.FeatureType == "" {
+		opts.FeatureType = opensubtitles.FeatureMovie
+		if opts.SeasonNumber > 0 || opts.EpisodeNumber > 0 {
+			opts.FeatureType = opensubtitles.FeatureEpisode
+		}
+	}
+	if opts.IMDbID == 0 {
+		opts.IMDbID = 1234567
+	}
+	if opts.Release == "" {
+		opts.Release = fmt.Sprintf("%s.%d.1080p.WEB-DL.x264", strings.ReplaceAll(opts.Title, " ", "."), opts.Year)
+	}
+	if opts.FileID == 0 {
+		n, _ := strconv.Atoi(opts.ID)
+		opts.FileID = n + 1
+	}
+	if opts.FileName == "" {
+		opts.FileName = opts.Release + ".srt"
+	}
+	if opts.UploadDate.IsZero() {
+		opts.UploadDate = DefaultUploadDate
+	}
+	if opts.FPS == 0 {
+		opts.FPS = 23.976
+	}
+	if opts.UploaderName == "" {
+		opts.UploaderName = "uploader"
+	}
+
+	nbCD := 1
+	uploaderID := 42
+	rank := "Trusted member"
+	slug := strings.ToLower(strings.ReplaceAll(opts.Title, " ", "-"))
+	details := opensubtitles.SubtitleFeatureDetails{
+		FeatureID:   opts.FeatureID,
+		FeatureType: opts.FeatureType,
+		Year:        opts.Year,
+		Title:       opts.Title,
+		MovieName:   fmt.Sprintf("%d - %s", opts.Year, opts.Title),
+		IMDbID:      intPtr(opts.IMDbID),
+	}
+	if opts.SeasonNumber > 0 || opts.EpisodeNumber > 0 {
+		details.SeasonNumber = intPtr(opts.SeasonNumber)
+		details.EpisodeNumber = intPtr(opts.EpisodeNumber)
+		details.ParentTitle = strPtr(opts.Title)
+		details.MovieName = fmt.Sprintf("%s - S%02dE%02d", opts.Title, opts.SeasonNumber, opts.EpisodeNumber)
+	}
+
+	return opensubtitles.Subtitle{
+		ApiDataWrapper: opensubtitles.ApiDataWrapper{ID: opts.ID, Type: "subtitle"},
+		Attributes: opensubtitles.SubtitleAttributes{
+			SubtitleID:      opts.ID,
+			Language:        opts.Language,
+			DownloadCount:   opts.DownloadCount,
+			HearingImpaired: opts.HearingImpaired,
+			HD:              true,
+			FPS:             &opts.FPS,
+			Ratings:         opts.Ratings,
+			FromTrusted:     opts.FromTrusted,
+			UploadDate:      opts.UploadDate,
+			Release:         opts.Release,
+			NbCD:            &nbCD,
+			Slug:            &slug,
+			Uploader: opensubtitles.UploaderInfo{
+				UploaderID: &uploaderID,
+				Name:       strPtr(opts.UploaderName),
+				Rank:       &rank,
+			},
+			FeatureDetails: details,
+			URL:            fmt.Sprintf("https://www.opensubtitles.com/%s/subtitles/%s", opts.Language, slug),
+			Files: []opensubtitles.SubtitleFile{
+				{FileID: opts.FileID, CDNumber: 1, FileName: opts.FileName},
+			},
+		},
+	}
+}
+
+// FeatureMovieOptions customises NewFeatureMovie.
+type FeatureMovieOptions struct {
+	ID             string // Defaults to "100001"
+	Title          string // Defaults to "Example Movie"
+	OriginalTitle  string
+	Year           int // Defaults to 2020
+	IMDbID         int // Defaults to 1234567
+	TMDBID         int // Defaults to 7654321
+	SubtitlesCount map[opensubtitles.LanguageCode]int
+}
+
+// NewFeatureMovie returns a movie Feature whose Attributes hold a FeatureMovieAttributes value.
+func NewFeatureMovie(opts FeatureMovieOptions) opensubtitles.Feature {
+	if opts.ID == "" {
+		opts.ID = "100001"
+	}
+	if opts.Title == "" {
+		opts.Title = "Example Movie"
+	}
+	if opts.Year == 0 {
+		opts.Year = 2020
+	}
+	if opts.IMDbID == 0 {
+		opts.IMDbID = 1234567
+	}
+	if opts.TMDBID == 0 {
+		opts.TMDBID = 7654321
+	}
+	if opts.SubtitlesCount == nil {
+		opts.SubtitlesCount = map[opensubtitles.LanguageCode]int{"en": 10, "el": 3}
+	}
+	total := 0
+	for _, n := range opts.SubtitlesCount {
+		total += n
+	}
+
+	attrs := opensubtitles.FeatureMovieAttributes{
+		FeatureBaseAttributes: opensubtitles.FeatureBaseAttributes{
+			FeatureID:       opts.ID,
+			FeatureType:     opensubtitles.FeatureMovie,
+			Title:           opts.Title,
+			Year:            strconv.Itoa(opts.Year),
+			IMDbID:          intPtr(opts.IMDbID),
+			TMDBID:          intPtr(opts.TMDBID),
+			TitleAKA:        []string{opts.Title},
+			URL:             "https://www.opensubtitles.com/en/movies/" + strings.ToLower(strings.ReplaceAll(opts.Title, " ", "-")),
+			ImgURL:          strPtr("https://s9.osdb.link/features/" + opts.ID + ".jpg"),
+			SubtitlesCount:  total,
+			SubtitlesCounts: opensubtitles.SubtitleCounts(opts.SubtitlesCount),
+		},
+	}
+	if opts.OriginalTitle != "" {
+		attrs.OriginalTitle = strPtr(opts.OriginalTitle)
+	}
+	return opensubtitles.Feature{
+		ApiDataWrapper: opensubtitles.ApiDataWrapper{ID: opts.ID, Type: "feature"},
+		Attributes:     attrs,
+	}
+}
+
+func intPtr(i int) *int       { return &i }
+func strPtr(s string) *string { return &s }
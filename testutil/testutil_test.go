@@ -0,0 +1,50 @@
+package testutil
+
+import (
+	"encoding/json"
+	"testing"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSubtitleDefaults(t *testing.T) {
+	sub := NewSubtitle(SubtitleOptions{})
+	attrs := sub.Attributes
+	assert.Equal(t, "1000001", sub.ID)
+	assert.Equal(t, opensubtitles.LanguageCode("en"), attrs.Language)
+	assert.Equal(t, "Example.Movie.2020.1080p.WEB-DL.x264", attrs.Release)
+	require.Len(t, attrs.Files, 1)
+	assert.Equal(t, 1000002, attrs.Files[0].FileID)
+	require.NotNil(t, attrs.Uploader.Name)
+	assert.Equal(t, "uploader", *attrs.Uploader.Name)
+	assert.Nil(t, attrs.FeatureDetails.SeasonNumber)
+
+	// Built values must survive a JSON round trip like real API data.
+	data, err := json.Marshal(sub)
+	require.NoError(t, err)
+	var decoded opensubtitles.Subtitle
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, sub, decoded)
+}
+
+func TestNewSubtitleEpisode(t *testing.T) {
+	sub := NewSubtitle(SubtitleOptions{ID: "7", Language: "el", Title: "Some Show", SeasonNumber: 2, EpisodeNumber: 5})
+	details := sub.Attributes.FeatureDetails
+	assert.Equal(t, opensubtitles.FeatureEpisode, details.FeatureType)
+	require.NotNil(t, details.SeasonNumber)
+	assert.Equal(t, 2, *details.SeasonNumber)
+	assert.Equal(t, "Some Show - S02E05", details.MovieName)
+	assert.Equal(t, 8, sub.Attributes.Files[0].FileID)
+}
+
+func TestNewFeatureMovie(t *testing.T) {
+	feature := NewFeatureMovie(FeatureMovieOptions{Title: "Dune", Year: 2021, SubtitlesCount: map[opensubtitles.LanguageCode]int{"en": 4}})
+	attrs, ok := feature.Attributes.(opensubtitles.FeatureMovieAttributes)
+	require.True(t, ok)
+	assert.Equal(t, "2021", attrs.Year)
+	assert.Equal(t, opensubtitles.FeatureMovie, attrs.FeatureType)
+	assert.Equal(t, 4, attrs.SubtitlesCount)
+	assert.Nil(t, attrs.OriginalTitle)
+}
@@ -0,0 +1,106 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultTokenLifetime is assumed for tokens whose expiry cannot be read from the JWT.
+const DefaultTokenLifetime = 24 * time.Hour
+
+// StoredToken is an API token persisted by a TokenStore.
+type StoredToken struct {
+	Token     string    `json:"token"`
+	BaseURL   string    `json:"base_url,omitempty"` // Host assigned at login, e.g. vip-api.opensubtitles.com
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the token is expired at now. A zero ExpiresAt never expires.
+func (t StoredToken) Expired(now time.Time) bool {
+	return !t.ExpiresAt.IsZero() && !now.Before(t.ExpiresAt)
+}
+
+// TokenStore persists the API token across process restarts. The Client loads the
+// token in NewClient, saves it after Login and clears it after Logout.
+type TokenStore interface {
+	// Get returns the stored token, or nil if there is none.
+	Get(ctx context.Context) (*StoredToken, error)
+	Set(ctx context.Context, token StoredToken) error
+	Clear(ctx context.Context) error
+}
+
+// FileTokenStore stores the token as JSON in a file readable only by the owner (0600).
+type FileTokenStore struct {
+	Path string
+}
+
+// Ensure FileTokenStore implements TokenStore.
+var _ TokenStore = (*FileTokenStore)(nil)
+
+// Get reads the token file. A missing file is not an error.
+func (s *FileTokenStore) Get(ctx context.Context) (*StoredToken, error) {
+	data, err := os.ReadFile(s.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read token file '%s': %w", s.Path, err)
+	}
+	var token StoredToken
+	if err := json.Unmarshal(data, &token); err != nil {
+		return nil, fmt.Errorf("failed to decode token file '%s': %w", s.Path, err)
+	}
+	return &token, nil
+}
+
+// Set writes the token file atomically with 0600 permissions.
+func (s *FileTokenStore) Set(ctx context.Context, token StoredToken) error {
+	data, err := json.MarshalIndent(token, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode token: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.Path), 0o700); err != nil {
+		return fmt.Errorf("failed to create directory for token file '%s': %w", s.Path, err)
+	}
+	tmp := s.Path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write token file '%s': %w", tmp, err)
+	}
+	if err := os.Rename(tmp, s.Path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to move token file '%s' into place: %w", s.Path, err)
+	}
+	return nil
+}
+
+// Clear removes the token file. A missing file is not an error.
+func (s *FileTokenStore) Clear(ctx context.Context) error {
+	if err := os.Remove(s.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to remove token file '%s': %w", s.Path, err)
+	}
+	return nil
+}
+
+// tokenExpiry reads the exp claim of a JWT, falling back to DefaultTokenLifetime from now.
+func tokenExpiry(token string, now time.Time) time.Time {
+	parts := strings.Split(token, ".")
+	if len(parts) == 3 {
+		if payload, err := base64.RawURLEncoding.DecodeString(parts[1]); err == nil {
+			var claims struct {
+				Exp int64 `json:"exp"`
+			}
+			if json.Unmarshal(payload, &claims) == nil && claims.Exp > 0 {
+				return time.Unix(claims.Exp, 0).UTC()
+			}
+		}
+	}
+	return now.Add(DefaultTokenLifetime).UTC()
+}
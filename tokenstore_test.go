@@ -0,0 +1,141 @@
+package opensubtitles
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileTokenStore(t *testing.T) {
+	ctx := context.Background()
+	store := &FileTokenStore{Path: filepath.Join(t.TempDir(), "nested", "token.json")}
+
+	got, err := store.Get(ctx)
+	require.NoError(t, err, "missing file is not an error")
+	assert.Nil(t, got)
+	require.NoError(t, store.Clear(ctx), "clearing a missing file is not an error")
+
+	want := StoredToken{Token: "abc", BaseURL: "vip-api.opensubtitles.com", ExpiresAt: time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)}
+	require.NoError(t, store.Set(ctx, want))
+
+	info, err := os.Stat(store.Path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	got, err = store.Get(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, got)
+	assert.Equal(t, want.Token, got.Token)
+	assert.Equal(t, want.BaseURL, got.BaseURL)
+	assert.True(t, want.ExpiresAt.Equal(got.ExpiresAt))
+
+	require.NoError(t, store.Clear(ctx))
+	_, err = os.Stat(store.Path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestStoredTokenExpired(t *testing.T) {
+	now := time.Now()
+	assert.False(t, StoredToken{}.Expired(now), "zero expiry never expires")
+	assert.False(t, StoredToken{ExpiresAt: now.Add(time.Minute)}.Expired(now))
+	assert.True(t, StoredToken{ExpiresAt: now}.Expired(now))
+}
+
+func TestTokenExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"exp":1893456000}`))
+	jwt := "eyJhbGciOiJIUzI1NiJ9." + payload + ".sig"
+	assert.Equal(t, time.Unix(1893456000, 0).UTC(), tokenExpiry(jwt, now))
+	assert.Equal(t, now.Add(DefaultTokenLifetime), tokenExpiry("opaque-token", now))
+}
+
+func TestClientTokenStore(t *testing.T) {
+	newClient := func(t *testing.T, serverURL string, store TokenStore) *Client {
+		client, err := NewClient(Config{
+			ApiKey:     "test-api-key",
+			UserAgent:  "GoTestClient/1.0",
+			BaseURL:    serverURL + "/api/v1",
+			RateLimit:  &testRateLimits,
+			TokenStore: store,
+		})
+		require.NoError(t, err)
+		return client
+	}
+
+	t.Run("LoadsStoredToken", func(t *testing.T) {
+		var gotAuth string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotAuth = r.Header.Get("Authorization")
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"data":{"allowed_downloads":10}}`))
+		}))
+		t.Cleanup(server.Close)
+
+		store := &FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+		require.NoError(t, store.Set(context.Background(), StoredToken{
+			Token:     "stored-token",
+			BaseURL:   server.URL + "/api/v1",
+			ExpiresAt: time.Now().Add(time.Hour),
+		}))
+
+		client := newClient(t, server.URL, store)
+		require.True(t, client.isAuthenticated())
+		_, err := client.GetUserInfo(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, "Bearer stored-token", gotAuth)
+	})
+
+	t.Run("IgnoresExpiredToken", func(t *testing.T) {
+		store := &FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+		require.NoError(t, store.Set(context.Background(), StoredToken{Token: "old", ExpiresAt: time.Now().Add(-time.Minute)}))
+
+		client := newClient(t, "http://127.0.0.1:0", store)
+		assert.False(t, client.isAuthenticated())
+		got, err := store.Get(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, got, "expired token is removed from the store")
+	})
+
+	t.Run("LoginStoresLogoutClears", func(t *testing.T) {
+		var server *httptest.Server
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/api/v1/login":
+				_ = json.NewEncoder(w).Encode(LoginResponse{Token: "fresh-token", BaseURL: server.URL + "/api/v1", Status: http.StatusOK})
+			case "/api/v1/logout":
+				_ = json.NewEncoder(w).Encode(LogoutResponse{Message: "token successfully destroyed", Status: http.StatusOK})
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+		t.Cleanup(server.Close)
+
+		store := &FileTokenStore{Path: filepath.Join(t.TempDir(), "token.json")}
+		client := newClient(t, server.URL, store)
+
+		_, err := client.Login(context.Background(), LoginRequest{Username: "u", Password: "p"})
+		require.NoError(t, err)
+		got, err := store.Get(context.Background())
+		require.NoError(t, err)
+		require.NotNil(t, got)
+		assert.Equal(t, "fresh-token", got.Token)
+		assert.Equal(t, server.URL+"/api/v1", got.BaseURL)
+		assert.False(t, got.Expired(time.Now()))
+
+		_, err = client.Logout(context.Background())
+		require.NoError(t, err)
+		got, err = store.Get(context.Background())
+		require.NoError(t, err)
+		assert.Nil(t, got)
+	})
+}
@@ -0,0 +1,55 @@
+package opensubtitles
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+)
+
+// TimeoutConfig bounds requests made by the client. A zero duration means no timeout,
+// except for XMLRPC.
+type TimeoutConfig struct {
+	// Default bounds each attempt of an API request, including reading the response.
+	Default time.Duration
+	// Endpoints overrides Default by API path, e.g. {"/upload": 2 * time.Minute}.
+	Endpoints map[string]time.Duration
+	// Download bounds fetching a file from a download link.
+	Download time.Duration
+	// XMLRPC bounds each call of the XML-RPC uploader (default: upload.DefaultTimeout).
+	XMLRPC time.Duration
+}
+
+// baseHTTPClient returns the HTTP client configured by Config.HTTPClient, ProxyURL and
+// TLSConfig, or nil to keep the defaults.
+func baseHTTPClient(config Config) (*http.Client, error) {
+	if config.HTTPClient != nil {
+		if config.ProxyURL != "" || config.TLSConfig != nil {
+			return nil, errors.New("HTTPClient cannot be combined with ProxyURL or TLSConfig; configure its transport instead")
+		}
+		return config.HTTPClient, nil
+	}
+	if config.ProxyURL == "" && config.TLSConfig == nil {
+		return nil, nil
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if config.ProxyURL != "" {
+		proxy, err := url.Parse(config.ProxyURL)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid ProxyURL %q", config.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// httpTimeouts converts the REST part of t.
+func (t *TimeoutConfig) httpTimeouts() httpclient.Timeouts {
+	return httpclient.Timeouts{Default: t.Default, Endpoints: t.Endpoints, Download: t.Download}
+}
@@ -0,0 +1,88 @@
+package opensubtitles
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProxyURL(t *testing.T) {
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.URL.Host)
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer proxy.Close()
+
+	client, err := NewClient(Config{ApiKey: "key", BaseURL: "http://api.example.invalid/api/v1", RateLimit: &testRateLimits, ProxyURL: proxy.URL})
+	require.NoError(t, err)
+	_, err = client.GetLanguages(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api.example.invalid"}, hosts)
+
+	_, err = NewClient(Config{ApiKey: "key", ProxyURL: "proxy.example.com"})
+	assert.Error(t, err, "the proxy URL needs a scheme")
+}
+
+func TestTLSConfig(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	untrusted, err := NewClient(Config{ApiKey: "key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits})
+	require.NoError(t, err)
+	_, err = untrusted.GetLanguages(context.Background())
+	assert.Error(t, err, "the test server's certificate is not trusted by default")
+
+	roots := x509.NewCertPool()
+	roots.AddCert(server.Certificate())
+	trusted, err := NewClient(Config{ApiKey: "key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, TLSConfig: &tls.Config{RootCAs: roots}})
+	require.NoError(t, err)
+	_, err = trusted.GetLanguages(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(Config{ApiKey: "key", BaseURL: server.URL + "/api/v1", RateLimit: &testRateLimits, HTTPClient: server.Client()})
+	require.NoError(t, err)
+	_, err = client.GetLanguages(context.Background())
+	assert.NoError(t, err)
+
+	_, err = NewClient(Config{ApiKey: "key", HTTPClient: server.Client(), ProxyURL: "http://proxy.example.com"})
+	assert.Error(t, err)
+}
+
+func TestTimeouts(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/subtitles" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		_, _ = w.Write([]byte(`{"data":[]}`))
+	}
+	server, _ := setupTestServer(t, handler)
+	client, err := NewClient(Config{
+		ApiKey:    "key",
+		BaseURL:   server.URL + "/api/v1",
+		RateLimit: &testRateLimits,
+		Timeouts:  &TimeoutConfig{Default: time.Second, Endpoints: map[string]time.Duration{"/subtitles": 20 * time.Millisecond}},
+	})
+	require.NoError(t, err)
+
+	_, err = client.SearchSubtitles(context.Background(), SearchSubtitlesParams{Query: String("heat")})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, err = client.GetLanguages(context.Background())
+	assert.NoError(t, err, "other endpoints use the default timeout")
+}
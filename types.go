@@ -1,6 +1,14 @@
 package opensubtitles
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/upload"
+)
 
 // --- Common Types ---
 
@@ -15,6 +23,46 @@ const (
 	SortDesc SortDirection = "desc"
 )
 
+// SubtitleOrderField is a field SearchSubtitles can order results by.
+type SubtitleOrderField string
+
+const (
+	OrderByLanguage          SubtitleOrderField = "language"
+	OrderByDownloadCount     SubtitleOrderField = "download_count"
+	OrderByNewDownloadCount  SubtitleOrderField = "new_download_count"
+	OrderByHearingImpaired   SubtitleOrderField = "hearing_impaired"
+	OrderByHD                SubtitleOrderField = "hd"
+	OrderByFPS               SubtitleOrderField = "fps"
+	OrderByVotes             SubtitleOrderField = "votes"
+	OrderByPoints            SubtitleOrderField = "points"
+	OrderByRatings           SubtitleOrderField = "ratings"
+	OrderByFromTrusted       SubtitleOrderField = "from_trusted"
+	OrderByForeignPartsOnly  SubtitleOrderField = "foreign_parts_only"
+	OrderByAITranslated      SubtitleOrderField = "ai_translated"
+	OrderByMachineTranslated SubtitleOrderField = "machine_translated"
+	OrderByUploadDate        SubtitleOrderField = "upload_date"
+	OrderByRelease           SubtitleOrderField = "release"
+	OrderByComments          SubtitleOrderField = "comments"
+)
+
+// subtitleOrderFields lists the fields the API documents for order_by.
+var subtitleOrderFields = []SubtitleOrderField{
+	OrderByLanguage, OrderByDownloadCount, OrderByNewDownloadCount, OrderByHearingImpaired,
+	OrderByHD, OrderByFPS, OrderByVotes, OrderByPoints, OrderByRatings, OrderByFromTrusted,
+	OrderByForeignPartsOnly, OrderByAITranslated, OrderByMachineTranslated, OrderByUploadDate,
+	OrderByRelease, OrderByComments,
+}
+
+// Valid reports whether f is one of the documented order_by fields.
+func (f SubtitleOrderField) Valid() bool {
+	for _, field := range subtitleOrderFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
 // FilterInclusion defines include/exclude options.
 type FilterInclusion string
 
@@ -40,7 +88,9 @@ const (
 	OnlyTrusted    FilterTrustedSources = "only"
 )
 
-// FeatureType defines the type of a feature.
+// FeatureType defines the type of a feature. Responses spell it "Movie", "Tvshow"
+// and "Episode"; decoding normalizes it to the constants below, so it can be compared
+// with them directly.
 type FeatureType string
 
 const (
@@ -50,6 +100,47 @@ const (
 	FeatureAll     FeatureType = "all" // For searching
 )
 
+// ErrUnknownFeatureType is returned by ParseFeatureType for unknown feature types.
+var ErrUnknownFeatureType = errors.New("opensubtitles: unknown feature type")
+
+// ParseFeatureType returns the FeatureType spelled s in any case, e.g. "Tvshow",
+// "TV Show" or "tv_show" for FeatureTVShow.
+func ParseFeatureType(s string) (FeatureType, error) {
+	normalized := strings.NewReplacer(" ", "", "_", "", "-", "").Replace(strings.ToLower(strings.TrimSpace(s)))
+	switch t := FeatureType(normalized); t {
+	case FeatureMovie, FeatureTVShow, FeatureEpisode, FeatureAll:
+		return t, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownFeatureType, s)
+}
+
+// apiFeatureTypes are the spellings of the feature types in API responses.
+var apiFeatureTypes = map[FeatureType]string{
+	FeatureMovie: "Movie", FeatureTVShow: "Tvshow", FeatureEpisode: "Episode",
+}
+
+// UnmarshalJSON normalizes known feature types to the FeatureType constants and
+// keeps unknown ones as sent.
+func (t *FeatureType) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if parsed, err := ParseFeatureType(s); err == nil {
+		s = string(parsed)
+	}
+	*t = FeatureType(s)
+	return nil
+}
+
+// MarshalJSON encodes known feature types as the API spells them, e.g. "Movie".
+func (t FeatureType) MarshalJSON() ([]byte, error) {
+	if s, ok := apiFeatureTypes[t]; ok {
+		return json.Marshal(s)
+	}
+	return json.Marshal(string(t))
+}
+
 // PaginatedResponse defines the structure for paginated API responses.
 type PaginatedResponse struct {
 	TotalPages int `json:"total_pages"`
@@ -149,7 +240,7 @@ type SearchFeaturesParams struct {
 // FeatureBaseAttributes holds common fields for all feature types.
 type FeatureBaseAttributes struct {
 	FeatureID       string         `json:"feature_id"`
-	FeatureType     string         `json:"feature_type"` // "Movie", "Tvshow", "Episode"
+	FeatureType     FeatureType    `json:"feature_type"`
 	Title           string         `json:"title"`
 	OriginalTitle   *string        `json:"original_title"` // Pointer as can be null
 	Year            string         `json:"year"`           // String in API response
@@ -208,10 +299,99 @@ type FeatureEpisodeAttributes struct {
 }
 
 // Feature represents any feature type returned by the API.
-// Use type assertion on Attributes based on FeatureType.
+// When decoded from JSON, Attributes holds a FeatureMovieAttributes, FeatureTvshowAttributes
+// or FeatureEpisodeAttributes value selected by feature_type (a map[string]interface{} for
+// unknown types). Use AsMovie, AsTvshow or AsEpisode to access it.
 type Feature struct {
 	ApiDataWrapper
-	Attributes interface{} `json:"attributes"` // Use specific attribute struct after unmarshalling based on FeatureType
+	Attributes interface{} `json:"attributes"`
+}
+
+// UnmarshalJSON decodes the attributes into the struct matching their feature_type.
+func (f *Feature) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		ApiDataWrapper
+		Attributes json.RawMessage `json:"attributes"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	f.ApiDataWrapper = raw.ApiDataWrapper
+	f.Attributes = nil
+	if len(raw.Attributes) == 0 || string(raw.Attributes) == "null" {
+		return nil
+	}
+
+	var probe struct {
+		FeatureType FeatureType `json:"feature_type"`
+	}
+	if err := json.Unmarshal(raw.Attributes, &probe); err != nil {
+		return fmt.Errorf("failed to decode feature attributes: %w", err)
+	}
+
+	decodeErr := func(err error) error {
+		return fmt.Errorf("failed to decode %q feature attributes: %w", probe.FeatureType, err)
+	}
+	switch probe.FeatureType {
+	case FeatureMovie:
+		var movie FeatureMovieAttributes
+		if err := json.Unmarshal(raw.Attributes, &movie); err != nil {
+			return decodeErr(err)
+		}
+		f.Attributes = movie
+	case FeatureTVShow:
+		var show FeatureTvshowAttributes
+		if err := json.Unmarshal(raw.Attributes, &show); err != nil {
+			return decodeErr(err)
+		}
+		f.Attributes = show
+	case FeatureEpisode:
+		var episode FeatureEpisodeAttributes
+		if err := json.Unmarshal(raw.Attributes, &episode); err != nil {
+			return decodeErr(err)
+		}
+		f.Attributes = episode
+	default:
+		var generic map[string]interface{}
+		if err := json.Unmarshal(raw.Attributes, &generic); err != nil {
+			return decodeErr(err)
+		}
+		f.Attributes = generic
+	}
+	return nil
+}
+
+// AsMovie returns the attributes of a movie feature.
+func (f Feature) AsMovie() (*FeatureMovieAttributes, bool) {
+	switch a := f.Attributes.(type) {
+	case FeatureMovieAttributes:
+		return &a, true
+	case *FeatureMovieAttributes:
+		return a, a != nil
+	}
+	return nil, false
+}
+
+// AsTvshow returns the attributes of a TV show feature.
+func (f Feature) AsTvshow() (*FeatureTvshowAttributes, bool) {
+	switch a := f.Attributes.(type) {
+	case FeatureTvshowAttributes:
+		return &a, true
+	case *FeatureTvshowAttributes:
+		return a, a != nil
+	}
+	return nil, false
+}
+
+// AsEpisode returns the attributes of an episode feature.
+func (f Feature) AsEpisode() (*FeatureEpisodeAttributes, bool) {
+	switch a := f.Attributes.(type) {
+	case FeatureEpisodeAttributes:
+		return &a, true
+	case *FeatureEpisodeAttributes:
+		return a, a != nil
+	}
+	return nil, false
 }
 
 // SearchFeaturesResponse wraps the list of features.
@@ -223,19 +403,19 @@ type SearchFeaturesResponse struct {
 
 // SubtitleFeatureDetails represents the nested feature info within a subtitle.
 type SubtitleFeatureDetails struct {
-	FeatureID       int     `json:"feature_id"`
-	FeatureType     string  `json:"feature_type"` // "Movie", "Episode"
-	Year            int     `json:"year"`
-	Title           string  `json:"title"`
-	MovieName       string  `json:"movie_name"`
-	IMDbID          *int    `json:"imdb_id"`
-	TMDBID          *int    `json:"tmdb_id"`
-	SeasonNumber    *int    `json:"season_number"`
-	EpisodeNumber   *int    `json:"episode_number"`
-	ParentIMDbID    *int    `json:"parent_imdb_id"`
-	ParentTMDBID    *int    `json:"parent_tmdb_id"`
-	ParentTitle     *string `json:"parent_title"`
-	ParentFeatureID *int    `json:"parent_feature_id"` // Number in example
+	FeatureID       int         `json:"feature_id"`
+	FeatureType     FeatureType `json:"feature_type"`
+	Year            int         `json:"year"`
+	Title           string      `json:"title"`
+	MovieName       string      `json:"movie_name"`
+	IMDbID          *int        `json:"imdb_id"`
+	TMDBID          *int        `json:"tmdb_id"`
+	SeasonNumber    *int        `json:"season_number"`
+	EpisodeNumber   *int        `json:"episode_number"`
+	ParentIMDbID    *int        `json:"parent_imdb_id"`
+	ParentTMDBID    *int        `json:"parent_tmdb_id"`
+	ParentTitle     *string     `json:"parent_title"`
+	ParentFeatureID *int        `json:"parent_feature_id"` // Number in example
 }
 
 // SubtitleFile represents a single file within a subtitle entry.
@@ -275,6 +455,28 @@ type SubtitleAttributes struct {
 	Files             []SubtitleFile         `json:"files"`
 }
 
+// Quality tells who translated a subtitle, so user interfaces can show the same
+// badge for it everywhere.
+type Quality string
+
+const (
+	QualityHuman   Quality = "human"
+	QualityAI      Quality = "ai"      // Translated by an AI model
+	QualityMachine Quality = "machine" // Translated by a machine translation service
+)
+
+// Quality returns QualityMachine for machine-translated subtitles, QualityAI for
+// AI-translated ones and QualityHuman otherwise.
+func (a SubtitleAttributes) Quality() Quality {
+	switch {
+	case a.MachineTranslated:
+		return QualityMachine
+	case a.AITranslated:
+		return QualityAI
+	}
+	return QualityHuman
+}
+
 // Subtitle represents a full subtitle entry.
 type Subtitle struct {
 	ApiDataWrapper
@@ -303,8 +505,8 @@ type SearchSubtitlesParams struct {
 	TrustedSources    *FilterTrustedSources `url:"trusted_sources,omitempty"`
 	MoviehashMatch    *string               `url:"moviehash_match,omitempty"` // "include", "only"
 	UploaderID        *int                  `url:"uploader_id,omitempty"`
-	OrderBy           *string               `url:"order_by,omitempty"` // Field name from allowed list
-	OrderDirection    *SortDirection        `url:"order_direction,omitempty"`
+	OrderBy           *SubtitleOrderField   `url:"order_by,omitempty"`
+	OrderDirection    *SortDirection        `url:"order_direction,omitempty"` // Requires OrderBy
 	Page              *int                  `url:"page,omitempty"`
 }
 
@@ -336,6 +538,27 @@ type DownloadResponse struct {
 	ResetTimeUTC time.Time `json:"reset_time_utc"` // Use time.Time
 }
 
+// UploadParams describes a subtitle uploaded with Client.UploadSubtitle.
+// The descriptive fields come from the same upload.Metadata struct used by the XML-RPC uploader.
+type UploadParams struct {
+	upload.Metadata
+	FileName      string       // Subtitle file name, required
+	Language      LanguageCode // Subtitle language, required
+	IMDbID        int          // IMDb ID of the movie or episode (without "tt")
+	MovieHash     string       // OSDb hash of the video, see the hash package
+	MovieByteSize int64
+	MovieFileName string
+	FPS           float64
+}
+
+// UploadResponse is the response of the /upload endpoint.
+type UploadResponse struct {
+	Status     int    `json:"status"`
+	Message    string `json:"message"`
+	SubtitleID string `json:"subtitle_id"`
+	URL        string `json:"url"`
+}
+
 // --- Discover Types ---
 
 // DiscoverParams defines common query parameters for discover endpoints.
@@ -364,6 +587,59 @@ type DiscoverMostDownloadedResponse struct {
 	Data []Subtitle `json:"data"`
 }
 
+// --- Community Types ---
+
+// ReportReason is the reason a subtitle is reported as bad.
+type ReportReason string
+
+const (
+	ReportWrongSync         ReportReason = "wrong_sync"
+	ReportWrongMovie        ReportReason = "wrong_movie"
+	ReportWrongLanguage     ReportReason = "wrong_language"
+	ReportBadTranslation    ReportReason = "bad_translation"
+	ReportMachineTranslated ReportReason = "machine_translated"
+	ReportOther             ReportReason = "other" // Requires a comment
+)
+
+// ReportRequest is the request body for the /report endpoint.
+type ReportRequest struct {
+	SubtitleID string       `json:"subtitle_id"` // Required
+	Reason     ReportReason `json:"reason"`      // Required
+	Comment    string       `json:"comment,omitempty"`
+}
+
+// ReportResponse is the response from the /report endpoint.
+type ReportResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// AddRequestParams is the request body for the /requests endpoint, asking the
+// community for subtitles of a feature. Set either FeatureID or IMDbID.
+type AddRequestParams struct {
+	FeatureID int            `json:"feature_id,omitempty"`
+	IMDbID    int            `json:"imdb_id,omitempty"`
+	Languages []LanguageCode `json:"languages"`         // Required
+	Release   string         `json:"release,omitempty"` // Release the subtitles should be synced to
+	Comment   string         `json:"comment,omitempty"`
+}
+
+// SubtitleRequest is a request for missing subtitles.
+type SubtitleRequest struct {
+	RequestID string         `json:"request_id"`
+	FeatureID int            `json:"feature_id"`
+	Languages []LanguageCode `json:"languages"`
+	Release   string         `json:"release"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+// AddRequestResponse is the response from the /requests endpoint.
+type AddRequestResponse struct {
+	Status  int             `json:"status"`
+	Message string          `json:"message"`
+	Data    SubtitleRequest `json:"data"`
+}
+
 // --- Utilities Types ---
 
 // GuessitParams defines query parameters for the /utilities/guessit endpoint.
@@ -371,6 +647,27 @@ type GuessitParams struct {
 	Filename string `url:"filename"` // Required
 }
 
+// Language is a subtitle language supported by the API.
+type Language struct {
+	LanguageCode LanguageCode `json:"language_code"` // e.g. "en", "pt-BR"
+	LanguageName string       `json:"language_name"`
+}
+
+// GetLanguagesResponse is the response from the /infos/languages endpoint.
+type GetLanguagesResponse struct {
+	Data []Language `json:"data"`
+}
+
+// SubtitleFormats lists the formats subtitles can be downloaded in.
+type SubtitleFormats struct {
+	OutputFormats []string `json:"output_formats"` // e.g. "srt", "webvtt"
+}
+
+// GetSubtitleFormatsResponse is the response from the /infos/formats endpoint.
+type GetSubtitleFormatsResponse struct {
+	Data SubtitleFormats `json:"data"`
+}
+
 // GuessitResponse is the response from the /utilities/guessit endpoint.
 // All fields are pointers as they might be null if not detected.
 type GuessitResponse struct {
@@ -1,3 +1,97 @@
 package opensubtitles
 
-// TODO: Add tests for struct marshalling/unmarshalling if needed
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFeatureUnmarshalJSON(t *testing.T) {
+	data := []byte(`[
+		{"id":"1","type":"feature","attributes":{"feature_id":"1","feature_type":"Movie","title":"Inception","year":"2010","imdb_id":1375666}},
+		{"id":"2","type":"feature","attributes":{"feature_id":"2","feature_type":"Tvshow","title":"Cheers","seasons_count":11}},
+		{"id":"3","type":"feature","attributes":{"feature_id":"3","feature_type":"episode","title":"The Tortelli Tort","season_number":1,"episode_number":7}},
+		{"id":"4","type":"feature","attributes":{"feature_type":"Documentary","title":"Unknown"}},
+		{"id":"5","type":"feature","attributes":null}
+	]`)
+	var features []Feature
+	require.NoError(t, json.Unmarshal(data, &features))
+	require.Len(t, features, 5)
+
+	movie, ok := features[0].AsMovie()
+	require.True(t, ok)
+	assert.Equal(t, "1", features[0].ID)
+	assert.Equal(t, "Inception", movie.Title)
+	require.NotNil(t, movie.IMDbID)
+	assert.Equal(t, 1375666, *movie.IMDbID)
+	_, ok = features[0].AsTvshow()
+	assert.False(t, ok)
+
+	assert.Equal(t, FeatureMovie, movie.FeatureType, "feature types are normalized")
+
+	show, ok := features[1].AsTvshow()
+	require.True(t, ok)
+	assert.Equal(t, 11, show.SeasonsCount)
+	assert.Equal(t, FeatureTVShow, show.FeatureType)
+
+	episode, ok := features[2].AsEpisode()
+	require.True(t, ok, "feature_type matching is case-insensitive")
+	assert.Equal(t, 7, episode.EpisodeNumber)
+
+	generic, ok := features[3].Attributes.(map[string]interface{})
+	require.True(t, ok, "unknown feature types fall back to a map")
+	assert.Equal(t, "Unknown", generic["title"])
+
+	assert.Nil(t, features[4].Attributes)
+
+	// Re-encoding keeps the attributes intact.
+	encoded, err := json.Marshal(features[0])
+	require.NoError(t, err)
+	var again Feature
+	require.NoError(t, json.Unmarshal(encoded, &again))
+	assert.Equal(t, features[0], again)
+}
+
+func TestParseFeatureType(t *testing.T) {
+	tests := map[string]FeatureType{
+		"Movie": FeatureMovie, "movie": FeatureMovie, " MOVIE ": FeatureMovie,
+		"Tvshow": FeatureTVShow, "TV Show": FeatureTVShow, "tv_show": FeatureTVShow,
+		"Episode": FeatureEpisode, "all": FeatureAll,
+	}
+	for in, want := range tests {
+		got, err := ParseFeatureType(in)
+		require.NoError(t, err, in)
+		assert.Equal(t, want, got, in)
+	}
+	_, err := ParseFeatureType("Documentary")
+	assert.ErrorIs(t, err, ErrUnknownFeatureType)
+}
+
+func TestFeatureTypeJSON(t *testing.T) {
+	var details SubtitleFeatureDetails
+	require.NoError(t, json.Unmarshal([]byte(`{"feature_type":"Episode"}`), &details))
+	assert.Equal(t, FeatureEpisode, details.FeatureType)
+
+	encoded, err := json.Marshal(details)
+	require.NoError(t, err)
+	assert.Contains(t, string(encoded), `"feature_type":"Episode"`, "encoded as the API spells it")
+
+	require.NoError(t, json.Unmarshal([]byte(`{"feature_type":"Documentary"}`), &details))
+	assert.Equal(t, FeatureType("Documentary"), details.FeatureType, "unknown types are kept")
+}
+
+func TestFeatureAccessorsAcceptPointers(t *testing.T) {
+	f := Feature{Attributes: &FeatureEpisodeAttributes{SeasonNumber: 2}}
+	episode, ok := f.AsEpisode()
+	require.True(t, ok)
+	assert.Equal(t, 2, episode.SeasonNumber)
+}
+
+func TestSubtitleQuality(t *testing.T) {
+	assert.Equal(t, QualityHuman, SubtitleAttributes{}.Quality())
+	assert.Equal(t, QualityAI, SubtitleAttributes{AITranslated: true}.Quality())
+	assert.Equal(t, QualityMachine, SubtitleAttributes{MachineTranslated: true}.Quality())
+	assert.Equal(t, QualityMachine, SubtitleAttributes{AITranslated: true, MachineTranslated: true}.Quality())
+}
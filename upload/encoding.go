@@ -0,0 +1,94 @@
+package upload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ContentEncoding selects how UploadSubtitles sends the subtitle content.
+type ContentEncoding string
+
+const (
+	// EncodingAuto sends plain content and, if the server rejects it as invalid
+	// (ErrContentRejected), gzipped content. The accepted encoding is tried first by
+	// later uploads of the same uploader.
+	EncodingAuto  ContentEncoding = "auto"
+	EncodingPlain ContentEncoding = "plain" // Base64 of the file
+	EncodingGzip  ContentEncoding = "gzip"  // Base64 of the gzipped file, as the XML-RPC documentation describes
+)
+
+// ErrContentRejected is returned when UploadSubtitles rejects the subtitle content as
+// invalid (status 402 or 416), e.g. because it was sent in an encoding the server
+// does not expect.
+var ErrContentRejected = errors.New("upload failed: subtitle content rejected")
+
+// contentRejected reports whether an UploadSubtitles status rejects the content.
+func contentRejected(status string) bool {
+	return strings.HasPrefix(status, "402") || strings.HasPrefix(status, "416")
+}
+
+// validate checks that e is a known encoding; "" counts as EncodingAuto.
+func (e ContentEncoding) validate() error {
+	switch e {
+	case "", EncodingAuto, EncodingPlain, EncodingGzip:
+		return nil
+	}
+	return fmt.Errorf("unknown content encoding %q", e)
+}
+
+// encodeContent returns content as base64, gzipped first for EncodingGzip.
+func encodeContent(content []byte, encoding ContentEncoding) (string, error) {
+	if encoding == EncodingGzip {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(content); err != nil {
+			return "", fmt.Errorf("failed to gzip subtitle content: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return "", fmt.Errorf("failed to gzip subtitle content: %w", err)
+		}
+		content = buf.Bytes()
+	}
+	return base64.StdEncoding.EncodeToString(content), nil
+}
+
+// encodingsToTry returns the encodings to send an upload in, in order.
+func (c *xmlRpcClient) encodingsToTry() []ContentEncoding {
+	if c.encoding != "" && c.encoding != EncodingAuto {
+		return []ContentEncoding{c.encoding}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.accepted == EncodingGzip {
+		return []ContentEncoding{EncodingGzip, EncodingPlain}
+	}
+	return []ContentEncoding{EncodingPlain, EncodingGzip}
+}
+
+// uploadSubtitlesEncoded prepares and sends UploadSubtitles in the configured
+// encoding. In auto mode, content the server rejects is sent again in the other one.
+func (c *xmlRpcClient) uploadSubtitlesEncoded(tryParams XmlRpcTryUploadParams, subtitlePath string) (*xmlRpcUploadSubtitlesResponse, error) {
+	var err error
+	for _, encoding := range c.encodingsToTry() {
+		params, prepErr := prepareUploadSubtitlesParams(tryParams, subtitlePath, encoding)
+		if prepErr != nil {
+			return nil, fmt.Errorf("error preparing UploadSubtitles params: %w", prepErr)
+		}
+		var resp *xmlRpcUploadSubtitlesResponse
+		if resp, err = c.uploadSubtitles(params); err == nil {
+			c.mu.Lock()
+			c.accepted = encoding
+			c.mu.Unlock()
+			return resp, nil
+		}
+		if !errors.Is(err, ErrContentRejected) {
+			break
+		}
+		c.log().Debug("xmlrpc: UploadSubtitles rejected the content", "encoding", encoding, "error", err)
+	}
+	return nil, fmt.Errorf("UploadSubtitles failed: %w", err)
+}
@@ -0,0 +1,128 @@
+package upload
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	xmlrpc "github.com/kolo/xmlrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	methodNamePattern = regexp.MustCompile(`<methodName>([^<]+)</methodName>`)
+	subContentPattern = regexp.MustCompile(`<name>subcontent</name>\s*<value>(?:<string>)?([^<]*)`)
+)
+
+// fixtureServer replays the responses recorded in testdata/xmlrpc by method name.
+// UploadSubtitles succeeds only for content in the accepted encoding and answers
+// anything else with the recorded 402 response. It returns the encodings received.
+func fixtureServer(t *testing.T, accepted ContentEncoding) (*xmlRpcClient, *[]ContentEncoding) {
+	t.Helper()
+	var received []ContentEncoding
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		method := methodNamePattern.FindSubmatch(body)
+		require.NotNil(t, method)
+		fixture := string(method[1])
+		if fixture == "UploadSubtitles" {
+			content := subContentPattern.FindSubmatch(body)
+			require.NotNil(t, content)
+			decoded, err := base64.StdEncoding.DecodeString(string(content[1]))
+			require.NoError(t, err)
+			encoding := EncodingPlain
+			if bytes.HasPrefix(decoded, []byte{0x1f, 0x8b}) {
+				encoding = EncodingGzip
+			}
+			received = append(received, encoding)
+			if encoding != accepted {
+				fixture += "-402"
+			}
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", "xmlrpc", fixture+".xml"))
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(server.Close)
+	client, err := xmlrpc.NewClient(server.URL, nil)
+	require.NoError(t, err)
+	return &xmlRpcClient{client: client, token: "token", loggedIn: true}, &received
+}
+
+var fixtureIntent = UserUploadIntent{
+	SubtitleFilePath: "../testdata/dummy.srt",
+	SubtitleFileName: "dummy.srt",
+	IMDBID:           "tt0113277",
+	LanguageID:       "eng",
+}
+
+func TestContentEncoding(t *testing.T) {
+	t.Run("AutoFallsBackToGzipAndRemembersIt", func(t *testing.T) {
+		c, received := fixtureServer(t, EncodingGzip)
+		result, err := c.Upload(fixtureIntent)
+		require.NoError(t, err)
+		assert.Equal(t, 3580232, result.SubtitleID)
+		assert.Equal(t, []ContentEncoding{EncodingPlain, EncodingGzip}, *received)
+
+		_, err = c.Upload(fixtureIntent)
+		require.NoError(t, err)
+		assert.Equal(t, []ContentEncoding{EncodingPlain, EncodingGzip, EncodingGzip}, *received)
+	})
+
+	t.Run("AutoSendsPlainFirst", func(t *testing.T) {
+		c, received := fixtureServer(t, EncodingPlain)
+		_, err := c.Upload(fixtureIntent)
+		require.NoError(t, err)
+		assert.Equal(t, []ContentEncoding{EncodingPlain}, *received)
+	})
+
+	t.Run("FixedEncodingDoesNotFallBack", func(t *testing.T) {
+		c, received := fixtureServer(t, EncodingGzip)
+		c.encoding = EncodingPlain
+		_, err := c.Upload(fixtureIntent)
+		assert.ErrorIs(t, err, ErrContentRejected)
+		assert.ErrorContains(t, err, "402 Subtitles has invalid format")
+		assert.Equal(t, []ContentEncoding{EncodingPlain}, *received)
+
+		c, received = fixtureServer(t, EncodingGzip)
+		c.encoding = EncodingGzip
+		_, err = c.Upload(fixtureIntent)
+		require.NoError(t, err)
+		assert.Equal(t, []ContentEncoding{EncodingGzip}, *received)
+	})
+
+	t.Run("RejectedByBoth", func(t *testing.T) {
+		c, received := fixtureServer(t, "none")
+		_, err := c.Upload(fixtureIntent)
+		assert.ErrorIs(t, err, ErrContentRejected)
+		assert.Len(t, *received, 2)
+	})
+}
+
+func TestEncodeContent(t *testing.T) {
+	plain, err := encodeContent([]byte("Hi"), EncodingPlain)
+	require.NoError(t, err)
+	assert.Equal(t, "SGk=", plain)
+
+	gzipped, err := encodeContent([]byte("Hi"), EncodingGzip)
+	require.NoError(t, err)
+	raw, err := base64.StdEncoding.DecodeString(gzipped)
+	require.NoError(t, err)
+	zr, err := gzip.NewReader(bytes.NewReader(raw))
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "Hi", string(content))
+
+	_, err = NewXmlRpcUploaderWithOptions(Options{ContentEncoding: "zstd"})
+	assert.ErrorContains(t, err, `unknown content encoding "zstd"`)
+}
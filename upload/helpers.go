@@ -1,10 +1,11 @@
 package upload
 
 import (
-	"encoding/base64"
 	"fmt"
 	"os"
 	"strconv"
+
+	"github.com/angelospk/opensubtitles-go/languages"
 )
 
 // UserUploadIntent holds all the data provided by the user or derived
@@ -14,7 +15,7 @@ type UserUploadIntent struct {
 	VideoFilePath        string // Path to the video file
 	SubtitleFilePath     string // Path to the subtitle file
 	IMDBID               string // e.g., "tt1234567" or "1234567"
-	LanguageID           string // e.g., "eng"
+	LanguageID           string // e.g., "eng"; other codes of the languages package such as "en" are converted
 	VideoFileName        string // Basename of the video file
 	SubtitleFileName     string // Basename of the subtitle file
 	ReleaseName          string
@@ -44,6 +45,9 @@ func PrepareTryUploadParams(intent UserUploadIntent) (XmlRpcTryUploadParams, err
 	params := XmlRpcTryUploadParams{
 		CDs: make(map[string]XmlRpcTryUploadFileItem),
 	}
+	if err := intent.Metadata().Validate(); err != nil {
+		return params, err
+	}
 
 	// --- Populate global optional parameters for TryUpload ---
 	if intent.IMDBID != "" {
@@ -54,7 +58,12 @@ func PrepareTryUploadParams(intent UserUploadIntent) (XmlRpcTryUploadParams, err
 		params.IDMovieImdb = imdbid
 	}
 	if intent.LanguageID != "" {
-		params.SubLanguageID = intent.LanguageID
+		// Accept any code of the languages catalog, e.g. "en" for "eng".
+		languageID, err := languages.ToISO3(intent.LanguageID)
+		if err != nil {
+			return params, fmt.Errorf("invalid subtitle language: %w", err)
+		}
+		params.SubLanguageID = languageID
 	}
 	if intent.Comment != "" {
 		params.SubAuthorComment = intent.Comment
@@ -132,28 +141,22 @@ func PrepareTryUploadParams(intent UserUploadIntent) (XmlRpcTryUploadParams, err
 	return params, nil
 }
 
-// readAndEncodeSubtitle reads the subtitle file, GZips it, and returns its Base64 encoded content.
-// UPDATE: Removing Gzip step based on server developer feedback - trying only Base64.
+// ReadAndEncodeSubtitle reads the subtitle file and returns its Base64 encoded content
+// (EncodingPlain) and its MD5 hash.
 func ReadAndEncodeSubtitle(filePath string) (encodedContent string, subHash string, err error) {
+	return readAndEncodeSubtitle(filePath, EncodingPlain)
+}
+
+// readAndEncodeSubtitle reads the subtitle file and returns its content in encoding
+// and its MD5 hash. The hash is always of the raw file.
+func readAndEncodeSubtitle(filePath string, encoding ContentEncoding) (encodedContent string, subHash string, err error) {
 	contentBytes, err := os.ReadFile(filePath)
 	if err != nil {
 		return "", "", fmt.Errorf("failed to read subtitle file content '%s': %w", filePath, err)
 	}
-
-	// GZip the content - REMOVED
-	// var gzipBuffer bytes.Buffer
-	// gzipWriter := gzip.NewWriter(&gzipBuffer)
-	// _, err = gzipWriter.Write(contentBytes)
-	// if err != nil {
-	// 	return "", fmt.Errorf("failed to gzip subtitle content: %w", err)
-	// }
-	// err = gzipWriter.Close() // Close is important to finalize compression
-	// if err != nil {
-	// 	return "", fmt.Errorf("failed to close gzip writer: %w", err)
-	// }
-
-	// Base64 encode the *raw* content
-	encodedContent = base64.StdEncoding.EncodeToString(contentBytes)
+	if encodedContent, err = encodeContent(contentBytes, encoding); err != nil {
+		return "", "", err
+	}
 
 	// Calculate the MD5 hash of the content
 	subHash, err = CalculateMD5Hash(filePath)
@@ -170,10 +173,16 @@ func CalculateSubHash(filePath string) (string, error) {
 	return CalculateMD5Hash(filePath)
 }
 
-// PrepareUploadSubtitlesParams prepares the parameters for the final UploadSubtitles XML-RPC call.
+// PrepareUploadSubtitlesParams prepares the parameters for the final UploadSubtitles
+// XML-RPC call, with the subtitle content in EncodingPlain.
 func PrepareUploadSubtitlesParams(tryParams XmlRpcTryUploadParams, subtitlePath string) (XmlRpcUploadSubtitlesParams, error) {
+	return prepareUploadSubtitlesParams(tryParams, subtitlePath, EncodingPlain)
+}
 
-	base64Content, calculatedSubHash, err := ReadAndEncodeSubtitle(subtitlePath)
+// prepareUploadSubtitlesParams prepares the UploadSubtitles parameters with the
+// subtitle content in encoding.
+func prepareUploadSubtitlesParams(tryParams XmlRpcTryUploadParams, subtitlePath string, encoding ContentEncoding) (XmlRpcUploadSubtitlesParams, error) {
+	base64Content, calculatedSubHash, err := readAndEncodeSubtitle(subtitlePath, encoding)
 	if err != nil {
 		return XmlRpcUploadSubtitlesParams{}, fmt.Errorf("failed to read and encode subtitle for upload: %w", err)
 	}
@@ -226,16 +235,16 @@ func PrepareUploadSubtitlesParams(tryParams XmlRpcTryUploadParams, subtitlePath
 	// Build the final structure
 	params := XmlRpcUploadSubtitlesParams{
 		BaseInfo: XmlRpcUploadSubtitlesBaseInfo{
-			IDMovieImdb:      tryParams.IDMovieImdb, // Reuse global info from tryParams
-			SubLanguageID:    tryParams.SubLanguageID,
-			MovieReleaseName: tryParams.MovieReleaseName,
-			MovieAka:         tryParams.MovieAka,
-			SubAuthorComment: tryParams.SubAuthorComment,
-			SubTranslator:    tryParams.SubTranslator,
-			HearingImpaired:  tryParams.HearingImpaired,
-			HighDefinition:   tryParams.HighDefinition,
-			ForeignPartsOnly: tryParams.ForeignPartsOnly,
-			// SubTranslator, HearingImpaired, etc. are intentionally omitted as per UploadSubtitles baseinfo spec
+			IDMovieImdb:          tryParams.IDMovieImdb, // Reuse global info from tryParams
+			SubLanguageID:        tryParams.SubLanguageID,
+			MovieReleaseName:     tryParams.MovieReleaseName,
+			MovieAka:             tryParams.MovieAka,
+			SubAuthorComment:     tryParams.SubAuthorComment,
+			SubTranslator:        tryParams.SubTranslator,
+			HearingImpaired:      tryParams.HearingImpaired,
+			HighDefinition:       tryParams.HighDefinition,
+			AutomaticTranslation: tryParams.AutomaticTranslation,
+			ForeignPartsOnly:     tryParams.ForeignPartsOnly,
 		},
 		CDs: map[string]XmlRpcUploadSubtitlesCD{
 			"cd1": {
@@ -296,15 +305,16 @@ type XmlRpcUploadSubtitlesParams struct {
 
 // XmlRpcUploadSubtitlesBaseInfo holds the 'baseinfo' part for UploadSubtitles.
 type XmlRpcUploadSubtitlesBaseInfo struct {
-	IDMovieImdb      string `xmlrpc:"idmovieimdb,omitempty"`
-	SubLanguageID    string `xmlrpc:"sublanguageid,omitempty"`
-	MovieReleaseName string `xmlrpc:"moviereleasename,omitempty"`
-	MovieAka         string `xmlrpc:"movieaka,omitempty"`
-	SubAuthorComment string `xmlrpc:"subauthorcomment,omitempty"`
-	SubTranslator    string `xmlrpc:"subtranslator,omitempty"`
-	HearingImpaired  string `xmlrpc:"hearingimpaired,omitempty"`
-	HighDefinition   string `xmlrpc:"highdefinition,omitempty"`
-	ForeignPartsOnly string `xmlrpc:"foreignpartsonly,omitempty"`
+	IDMovieImdb          string `xmlrpc:"idmovieimdb,omitempty"`
+	SubLanguageID        string `xmlrpc:"sublanguageid,omitempty"`
+	MovieReleaseName     string `xmlrpc:"moviereleasename,omitempty"`
+	MovieAka             string `xmlrpc:"movieaka,omitempty"`
+	SubAuthorComment     string `xmlrpc:"subauthorcomment,omitempty"`
+	SubTranslator        string `xmlrpc:"subtranslator,omitempty"`
+	HearingImpaired      string `xmlrpc:"hearingimpaired,omitempty"`
+	HighDefinition       string `xmlrpc:"highdefinition,omitempty"`
+	AutomaticTranslation string `xmlrpc:"automatictranslation,omitempty"`
+	ForeignPartsOnly     string `xmlrpc:"foreignpartsonly,omitempty"`
 }
 
 // XmlRpcUploadSubtitlesCD holds the 'cdX' data for UploadSubtitles.
@@ -0,0 +1,25 @@
+package upload
+
+import (
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/languages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrepareTryUploadParamsLanguage(t *testing.T) {
+	intent := UserUploadIntent{
+		SubtitleFilePath: "../testdata/dummy.srt",
+		SubtitleFileName: "dummy.srt",
+		IMDBID:           "tt0113277",
+		LanguageID:       "pt-BR",
+	}
+	params, err := PrepareTryUploadParams(intent)
+	require.NoError(t, err)
+	assert.Equal(t, "pob", params.SubLanguageID)
+
+	intent.LanguageID = "klingon"
+	_, err = PrepareTryUploadParams(intent)
+	assert.ErrorIs(t, err, languages.ErrUnknownLanguage)
+}
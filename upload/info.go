@@ -0,0 +1,160 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Info defines the XML-RPC calls describing the server itself. Neither needs a
+// session, but the value returned by NewXmlRpcUploader implements it with the rest
+// of the Uploader.
+type Info interface {
+	// GetSubLanguages lists the subtitle languages the server accepts as
+	// SubLanguageID, with their names in the interface language given as an ISO
+	// 639-1 code (empty means "en").
+	GetSubLanguages(language string) ([]SubLanguage, error)
+	// ServerInfo returns the server's statistics and download limits.
+	ServerInfo() (*ServerInfo, error)
+}
+
+// Ensure xmlRpcClient implements Info.
+var _ Info = (*xmlRpcClient)(nil)
+
+// ErrUnsupportedLanguage is returned by Upload, with Options.ValidateLanguage set,
+// when the server does not list the subtitle's language.
+var ErrUnsupportedLanguage = errors.New("upload failed: subtitle language not supported by the server")
+
+// SubLanguage is a subtitle language of GetSubLanguages.
+type SubLanguage struct {
+	SubLanguageID string // ISO 639-2 code used as SubLanguageID, e.g. "eng"
+	LanguageName  string
+	ISO639        string // ISO 639-1 code, e.g. "en"; empty for some languages
+}
+
+// ServerInfo is the response of the XML-RPC ServerInfo method. The server sends
+// most counts as strings; they are converted.
+type ServerInfo struct {
+	XmlRpcVersion           string
+	XmlRpcURL               string
+	Application             string
+	Contact                 string
+	WebsiteURL              string
+	UsersOnlineTotal        int
+	UsersOnlineProgram      int
+	UsersLoggedIn           int
+	UsersMaxAllTime         int
+	UsersRegistered         int
+	SubsDownloads           int64
+	SubsSubtitleFiles       int64
+	MoviesTotal             int64
+	MoviesAka               int64
+	TotalSubtitlesLanguages int
+	LastUpdateStrings       map[string]string // Last update of each interface language
+	DownloadLimits          DownloadLimits
+}
+
+// DownloadLimits are the download limits the server applies to the caller.
+type DownloadLimits struct {
+	ClientIP               string
+	LimitCheckBy           string // "user_ip" or "user_id"
+	Client24hDownloadCount int
+	Client24hDownloadLimit int
+	Global24hDownloadLimit int
+}
+
+// GetSubLanguages calls the XML-RPC GetSubLanguages method.
+func (c *xmlRpcClient) GetSubLanguages(language string) ([]SubLanguage, error) {
+	if language == "" {
+		language = "en"
+	}
+	resp, err := c.call("GetSubLanguages", language)
+	if err != nil {
+		return nil, err
+	}
+	items, _ := resp["data"].([]interface{})
+	languages := make([]SubLanguage, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		languages = append(languages, SubLanguage{
+			SubLanguageID: xmlRpcString(m["SubLanguageID"]),
+			LanguageName:  xmlRpcString(m["LanguageName"]),
+			ISO639:        xmlRpcString(m["ISO639"]),
+		})
+	}
+	return languages, nil
+}
+
+// ServerInfo calls the XML-RPC ServerInfo method. Unlike the other methods, its
+// response has no status.
+func (c *xmlRpcClient) ServerInfo() (*ServerInfo, error) {
+	var raw interface{}
+	if err := c.client.Call("ServerInfo", nil, &raw); err != nil {
+		return nil, fmt.Errorf("xmlrpc ServerInfo call failed: %w", err)
+	}
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected ServerInfo response type: %T (%v)", raw, raw)
+	}
+	info := &ServerInfo{
+		XmlRpcVersion:           xmlRpcString(m["xmlrpc_version"]),
+		XmlRpcURL:               xmlRpcString(m["xmlrpc_url"]),
+		Application:             xmlRpcString(m["application"]),
+		Contact:                 xmlRpcString(m["contact"]),
+		WebsiteURL:              xmlRpcString(m["website_url"]),
+		UsersOnlineTotal:        xmlRpcInt(m["users_online_total"]),
+		UsersOnlineProgram:      xmlRpcInt(m["users_online_program"]),
+		UsersLoggedIn:           xmlRpcInt(m["users_loggedin"]),
+		UsersMaxAllTime:         xmlRpcInt(m["users_max_alltime"]),
+		UsersRegistered:         xmlRpcInt(m["users_registered"]),
+		SubsDownloads:           xmlRpcInt64(m["subs_downloads"]),
+		SubsSubtitleFiles:       xmlRpcInt64(m["subs_subtitle_files"]),
+		MoviesTotal:             xmlRpcInt64(m["movies_total"]),
+		MoviesAka:               xmlRpcInt64(m["movies_aka"]),
+		TotalSubtitlesLanguages: xmlRpcInt(m["total_subtitles_languages"]),
+	}
+	if updates, ok := m["last_update_strings"].(map[string]interface{}); ok {
+		info.LastUpdateStrings = make(map[string]string, len(updates))
+		for lang, date := range updates {
+			info.LastUpdateStrings[lang] = xmlRpcString(date)
+		}
+	}
+	if limits, ok := m["download_limits"].(map[string]interface{}); ok {
+		info.DownloadLimits = DownloadLimits{
+			ClientIP:               xmlRpcString(limits["client_ip"]),
+			LimitCheckBy:           xmlRpcString(limits["limit_check_by"]),
+			Client24hDownloadCount: xmlRpcInt(limits["client_24h_download_count"]),
+			Client24hDownloadLimit: xmlRpcInt(limits["client_24h_download_limit"]),
+			Global24hDownloadLimit: xmlRpcInt(limits["global_24h_download_limit"]),
+		}
+	}
+	return info, nil
+}
+
+// checkSubLanguage returns ErrUnsupportedLanguage if the server does not list id.
+// The list is fetched once per uploader; a failed fetch is retried by the next call.
+func (c *xmlRpcClient) checkSubLanguage(id string) error {
+	c.mu.Lock()
+	known := c.subLanguages
+	c.mu.Unlock()
+	if known == nil {
+		languages, err := c.GetSubLanguages("")
+		if err != nil {
+			return err
+		}
+		known = make(map[string]bool, len(languages))
+		for _, l := range languages {
+			known[strings.ToLower(l.SubLanguageID)] = true
+		}
+		c.mu.Lock()
+		c.subLanguages = known
+		c.mu.Unlock()
+	}
+	if !known[strings.ToLower(id)] {
+		return fmt.Errorf("%w: %q", ErrUnsupportedLanguage, id)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package upload
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func subLanguagesResponse() string {
+	language := func(id, name, iso string) string {
+		return "<value><struct>" + member("SubLanguageID", "<string>"+id+"</string>") +
+			member("LanguageName", "<string>"+name+"</string>") + member("ISO639", "<string>"+iso+"</string>") + "</struct></value>"
+	}
+	return "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<array><data>"+language("eng", "English", "en")+language("ell", "Greek", "el")+"</data></array>") + "</struct>"
+}
+
+func TestGetSubLanguages(t *testing.T) {
+	c := newTestClient(t, subLanguagesResponse(), func(body string) {
+		assert.Contains(t, body, "<methodName>GetSubLanguages</methodName>")
+		assert.Contains(t, body, "<string>en</string>", "the interface language defaults to English")
+	})
+
+	languages, err := c.GetSubLanguages("")
+	require.NoError(t, err)
+	assert.Equal(t, []SubLanguage{
+		{SubLanguageID: "eng", LanguageName: "English", ISO639: "en"},
+		{SubLanguageID: "ell", LanguageName: "Greek", ISO639: "el"},
+	}, languages)
+}
+
+func TestServerInfo(t *testing.T) {
+	response := "<struct>" + member("xmlrpc_version", "<string>0.1</string>") +
+		member("application", "<string>OpenSuber v0.2</string>") +
+		member("users_online_total", "<int>4132</int>") +
+		member("subs_downloads", "<string>2934818293</string>") +
+		member("total_subtitles_languages", "<string>79</string>") +
+		member("last_update_strings", "<struct>"+member("en", "<string>2013-01-08 13:37:00</string>")+"</struct>") +
+		member("download_limits", "<struct>"+member("client_ip", "<string>192.0.2.1</string>")+
+			member("limit_check_by", "<string>user_ip</string>")+
+			member("client_24h_download_count", "<int>12</int>")+
+			member("client_24h_download_limit", "<int>200</int>")+"</struct>") + "</struct>"
+	c := newTestClient(t, response, func(body string) {
+		assert.Contains(t, body, "<methodName>ServerInfo</methodName>")
+	})
+
+	info, err := c.ServerInfo()
+	require.NoError(t, err)
+	assert.Equal(t, "0.1", info.XmlRpcVersion)
+	assert.Equal(t, 4132, info.UsersOnlineTotal)
+	assert.Equal(t, int64(2934818293), info.SubsDownloads)
+	assert.Equal(t, 79, info.TotalSubtitlesLanguages)
+	assert.Equal(t, map[string]string{"en": "2013-01-08 13:37:00"}, info.LastUpdateStrings)
+	assert.Equal(t, DownloadLimits{
+		ClientIP:               "192.0.2.1",
+		LimitCheckBy:           "user_ip",
+		Client24hDownloadCount: 12,
+		Client24hDownloadLimit: 200,
+	}, info.DownloadLimits)
+}
+
+func TestUploadValidatesLanguage(t *testing.T) {
+	var methods []string
+	c := newTestClient(t, subLanguagesResponse(), func(body string) {
+		start := strings.Index(body, "<methodName>") + len("<methodName>")
+		methods = append(methods, body[start:strings.Index(body, "</methodName>")])
+	})
+	c.validate = true
+
+	// French is in the languages catalog but not listed by the server.
+	_, err := c.Upload(UserUploadIntent{
+		SubtitleFilePath: "../testdata/dummy.srt",
+		SubtitleFileName: "dummy.srt",
+		IMDBID:           "tt0113277",
+		LanguageID:       "fr",
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+	_, err = c.Upload(UserUploadIntent{
+		SubtitleFilePath: "../testdata/dummy.srt",
+		SubtitleFileName: "dummy.srt",
+		IMDBID:           "tt0113277",
+		LanguageID:       "fr",
+	})
+	assert.ErrorIs(t, err, ErrUnsupportedLanguage)
+	assert.Equal(t, []string{"GetSubLanguages"}, methods, "the list is fetched once and nothing is uploaded")
+}
@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/angelospk/opensubtitles-go/mediainfo"
+	"github.com/angelospk/opensubtitles-go/subfmt"
+)
+
+// Length limits (in characters) for free-text upload metadata. Longer values are
+// rejected up front instead of being silently truncated by the server.
+const (
+	MaxReleaseNameLength = 255
+	MaxMovieAkaLength    = 255
+	MaxTranslatorLength  = 100
+	MaxCommentLength     = 1000
+)
+
+// akaSeparator joins multiple AKA titles into the single movieaka field.
+const akaSeparator = "; "
+
+// ErrInvalidMetadata is returned when upload metadata fails validation.
+var ErrInvalidMetadata = errors.New("invalid upload metadata")
+
+// Metadata is the canonical set of descriptive fields attached to a subtitle upload.
+// It is transport independent: ApplyTo feeds it into a UserUploadIntent for the
+// XML-RPC uploader, and the same struct is meant to populate REST upload parameters.
+type Metadata struct {
+	ReleaseName          string
+	AKATitles            []string // Alternative titles of the movie, sent as one movieaka value
+	Comment              string   // Author comment shown on the subtitle page
+	Translator           string   // Translator credit
+	HearingImpaired      bool
+	HighDefinition       bool
+	AutomaticTranslation bool
+	ForeignPartsOnly     bool
+}
+
+// MovieAka returns the AKA titles joined into the single value the server expects.
+func (m Metadata) MovieAka() string {
+	var titles []string
+	for _, title := range m.AKATitles {
+		if title = strings.TrimSpace(title); title != "" {
+			titles = append(titles, title)
+		}
+	}
+	return strings.Join(titles, akaSeparator)
+}
+
+// Validate checks the free-text fields against the length limits.
+func (m Metadata) Validate() error {
+	checks := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"release name", m.ReleaseName, MaxReleaseNameLength},
+		{"AKA titles", m.MovieAka(), MaxMovieAkaLength},
+		{"translator", m.Translator, MaxTranslatorLength},
+		{"comment", m.Comment, MaxCommentLength},
+	}
+	for _, c := range checks {
+		if !utf8.ValidString(c.value) {
+			return fmt.Errorf("%w: %s is not valid UTF-8", ErrInvalidMetadata, c.name)
+		}
+		if n := utf8.RuneCountInString(c.value); n > c.max {
+			return fmt.Errorf("%w: %s is %d characters, maximum is %d", ErrInvalidMetadata, c.name, n, c.max)
+		}
+	}
+	return nil
+}
+
+// ApplyTo copies the metadata into intent, replacing its descriptive fields.
+func (m Metadata) ApplyTo(intent *UserUploadIntent) {
+	intent.ReleaseName = m.ReleaseName
+	intent.MovieAka = m.MovieAka()
+	intent.Comment = m.Comment
+	intent.Translator = m.Translator
+	intent.HearingImpaired = m.HearingImpaired
+	intent.HighDefinition = m.HighDefinition
+	intent.AutomaticTranslation = m.AutomaticTranslation
+	intent.ForeignPartsOnly = m.ForeignPartsOnly
+}
+
+// Metadata returns the descriptive fields of the intent as a Metadata value.
+func (intent UserUploadIntent) Metadata() Metadata {
+	m := Metadata{
+		ReleaseName:          intent.ReleaseName,
+		Comment:              intent.Comment,
+		Translator:           intent.Translator,
+		HearingImpaired:      intent.HearingImpaired,
+		HighDefinition:       intent.HighDefinition,
+		AutomaticTranslation: intent.AutomaticTranslation,
+		ForeignPartsOnly:     intent.ForeignPartsOnly,
+	}
+	if intent.MovieAka != "" {
+		m.AKATitles = strings.Split(intent.MovieAka, akaSeparator)
+	}
+	return m
+}
+
+// ConsolidateMetadata fills the fields of intent that can be derived from its files:
+// VideoFileName and SubtitleFileName from the paths, ReleaseName from the video or
+// else the subtitle file name (see ReleaseNameFromFile), and FPS, TimeMS and Frames
+// by probing VideoFilePath with prober (e.g. mediainfo.FFprobe{}). Fields already set
+// are kept. Without a video file or prober only the names are filled.
+func ConsolidateMetadata(ctx context.Context, intent *UserUploadIntent, prober mediainfo.Prober) error {
+	if intent.SubtitleFileName == "" && intent.SubtitleFilePath != "" {
+		intent.SubtitleFileName = filepath.Base(intent.SubtitleFilePath)
+	}
+	if intent.VideoFileName == "" && intent.VideoFilePath != "" {
+		intent.VideoFileName = filepath.Base(intent.VideoFilePath)
+	}
+	fillReleaseName(intent)
+	if intent.VideoFilePath == "" || prober == nil || (intent.FPS > 0 && intent.TimeMS > 0 && intent.Frames > 0) {
+		return nil
+	}
+
+	info, err := prober.Probe(ctx, intent.VideoFilePath)
+	if err != nil {
+		return fmt.Errorf("failed to probe video file: %w", err)
+	}
+	if intent.FPS <= 0 {
+		intent.FPS = info.FPS
+	}
+	if intent.TimeMS <= 0 {
+		intent.TimeMS = info.Duration.Milliseconds()
+	}
+	if intent.Frames <= 0 {
+		intent.Frames = info.Frames
+		if intent.Frames <= 0 && intent.FPS > 0 {
+			intent.Frames = int64(math.Round(float64(intent.TimeMS) / 1000 * intent.FPS))
+		}
+	}
+	return nil
+}
+
+// DetectFlags sets HearingImpaired and ForeignPartsOnly on intent if the cues of
+// SubtitleFilePath show them (see subfmt.Analyze) and returns the analysis. Flags are
+// set, never cleared. ForeignPartsOnly is only detected with the video's TimeMS, e.g.
+// from ConsolidateMetadata, since forced subtitles often end long before the video.
+func DetectFlags(intent *UserUploadIntent) (subfmt.Analysis, error) {
+	data, err := os.ReadFile(intent.SubtitleFilePath)
+	if err != nil {
+		return subfmt.Analysis{}, fmt.Errorf("failed to read subtitle file: %w", err)
+	}
+	doc, err := subfmt.Parse(data)
+	if err != nil {
+		return subfmt.Analysis{}, fmt.Errorf("failed to parse subtitle file: %w", err)
+	}
+	runtime := time.Duration(intent.TimeMS) * time.Millisecond
+	analysis := subfmt.Analyze(doc, runtime)
+	if analysis.HearingImpaired {
+		intent.HearingImpaired = true
+	}
+	if analysis.Forced && runtime > 0 {
+		intent.ForeignPartsOnly = true
+	}
+	return analysis, nil
+}
@@ -0,0 +1,90 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/angelospk/opensubtitles-go/mediainfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubProber returns fixed results and counts its calls.
+type stubProber struct {
+	info  *mediainfo.Info
+	err   error
+	calls int
+}
+
+func (p *stubProber) Probe(ctx context.Context, path string) (*mediainfo.Info, error) {
+	p.calls++
+	return p.info, p.err
+}
+
+func TestConsolidateMetadata(t *testing.T) {
+	ctx := context.Background()
+	prober := &stubProber{info: &mediainfo.Info{Duration: 90 * time.Minute, FPS: 23.976, Frames: 129470}}
+
+	intent := UserUploadIntent{VideoFilePath: "/videos/Heat.1995.mkv", SubtitleFilePath: "/subs/Heat.1995.srt", FPS: 25}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, prober))
+	assert.Equal(t, "Heat.1995.mkv", intent.VideoFileName)
+	assert.Equal(t, "Heat.1995.srt", intent.SubtitleFileName)
+	assert.Equal(t, 25.0, intent.FPS, "values set by the caller are kept")
+	assert.Equal(t, int64(5400000), intent.TimeMS)
+	assert.Equal(t, int64(129470), intent.Frames)
+
+	// Frames are estimated when the prober cannot count them.
+	prober.info = &mediainfo.Info{Duration: time.Minute, FPS: 25}
+	intent = UserUploadIntent{VideoFilePath: "movie.mkv", VideoFileName: "custom.mkv"}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, prober))
+	assert.Equal(t, "custom.mkv", intent.VideoFileName)
+	assert.Equal(t, int64(1500), intent.Frames)
+
+	// Nothing to probe: complete intents, no video file or no prober.
+	prober.calls = 0
+	require.NoError(t, ConsolidateMetadata(ctx, &UserUploadIntent{VideoFilePath: "movie.mkv", FPS: 25, TimeMS: 1, Frames: 1}, prober))
+	require.NoError(t, ConsolidateMetadata(ctx, &UserUploadIntent{SubtitleFilePath: "movie.srt"}, prober))
+	require.NoError(t, ConsolidateMetadata(ctx, &UserUploadIntent{VideoFilePath: "movie.mkv"}, nil))
+	assert.Zero(t, prober.calls)
+
+	prober.err = errors.New("ffprobe exploded")
+	intent = UserUploadIntent{VideoFilePath: "movie.mkv"}
+	err := ConsolidateMetadata(ctx, &intent, prober)
+	assert.ErrorContains(t, err, "ffprobe exploded")
+	assert.Equal(t, "movie.mkv", intent.VideoFileName, "file names are filled even if probing fails")
+}
+
+func TestDetectFlags(t *testing.T) {
+	dir := t.TempDir()
+	hiPath := filepath.Join(dir, "hi.srt")
+	srt := "1\n00:00:01,000 --> 00:00:02,000\n[door slams]\n\n" +
+		"2\n00:00:03,000 --> 00:00:04,000\nJOHN: Who's there?\n\n" +
+		"3\n00:00:05,000 --> 00:00:06,000\n(whispers) Quiet.\n\n"
+	require.NoError(t, os.WriteFile(hiPath, []byte(srt), 0o644))
+
+	intent := UserUploadIntent{SubtitleFilePath: hiPath}
+	analysis, err := DetectFlags(&intent)
+	require.NoError(t, err)
+	assert.Equal(t, 3, analysis.Cues)
+	assert.True(t, intent.HearingImpaired)
+	assert.False(t, intent.ForeignPartsOnly, "forced subtitles are only detected with a runtime")
+
+	intent = UserUploadIntent{SubtitleFilePath: hiPath, TimeMS: (90 * time.Minute).Milliseconds()}
+	_, err = DetectFlags(&intent)
+	require.NoError(t, err)
+	assert.True(t, intent.ForeignPartsOnly)
+
+	plainPath := filepath.Join(dir, "plain.srt")
+	require.NoError(t, os.WriteFile(plainPath, []byte("1\n00:00:01,000 --> 00:00:02,000\nHello.\n\n"), 0o644))
+	intent = UserUploadIntent{SubtitleFilePath: plainPath, HearingImpaired: true}
+	_, err = DetectFlags(&intent)
+	require.NoError(t, err)
+	assert.True(t, intent.HearingImpaired, "flags are never cleared")
+
+	_, err = DetectFlags(&UserUploadIntent{SubtitleFilePath: filepath.Join(dir, "missing.srt")})
+	assert.Error(t, err)
+}
@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	// releaseMarker is the year or season/episode that ends the title of a release name.
+	releaseMarker = regexp.MustCompile(`^(?i:(19|20)\d{2}|S\d{1,2}(E\d{1,3})*|\d{1,2}x\d{2,3})$`)
+	// releaseTitleToken is a word of the title; scene names use no spaces or brackets.
+	releaseTitleToken = regexp.MustCompile(`^[\p{L}\d'&+!,]+$`)
+	// releaseTechToken matches resolutions and codecs such as 1080p, x264 or H265.
+	releaseTechToken = regexp.MustCompile(`^(?i:\d{3,4}[pi]|4k|[xh]26[45]|hevc|avc|xvid|divx)$`)
+	// releaseTagToken is a tag after the marker, e.g. "DDP5" or "1" of "DDP5.1".
+	releaseTagToken = regexp.MustCompile(`^[\p{L}\d+]+$`)
+	// releaseGroup is the group after the last dash.
+	releaseGroup = regexp.MustCompile(`^[A-Za-z\d]+$`)
+)
+
+// releaseSources are the source tags of scene release names, in lower case.
+var releaseSources = map[string]bool{
+	"bluray": true, "bdrip": true, "brrip": true, "bdremux": true, "remux": true,
+	"web": true, "webdl": true, "webrip": true, "hdtv": true, "pdtv": true, "hdrip": true,
+	"dvdrip": true, "dvd": true, "dvdscr": true, "hdcam": true, "uhd": true,
+}
+
+// subtitleNameTags are the tokens a subtitle file name may add after the release
+// name, e.g. "Heat.1995.1080p.BluRay.x264-GROUP.eng.forced.srt".
+var subtitleNameTags = map[string]bool{"forced": true, "sdh": true, "hi": true, "cc": true}
+
+// IsSceneReleaseName reports whether name follows the scene naming grammar: title
+// words and a year or season/episode marker, then at least one source, resolution
+// or codec tag, all joined by dots, and optionally a dash and the release group, e.g.
+// "Heat.1995.1080p.BluRay.x264-GROUP" or "Show.Name.S01E02.720p.HDTV.x264-GROUP".
+func IsSceneReleaseName(name string) bool {
+	if name == "" || len(name) > MaxReleaseNameLength || strings.ContainsAny(name, " \t/\\") {
+		return false
+	}
+	// The group follows the last dash, unless the dash is part of WEB-DL.
+	if i := strings.LastIndexByte(name, '-'); i > 0 && !strings.EqualFold(name[i+1:], "DL") {
+		if !releaseGroup.MatchString(name[i+1:]) {
+			return false
+		}
+		name = name[:i]
+	}
+	tokens := strings.Split(strings.ReplaceAll(strings.ReplaceAll(name, "WEB-DL", "WEBDL"), "web-dl", "webdl"), ".")
+	marker := -1
+	for i, token := range tokens {
+		if i > 0 && releaseMarker.MatchString(token) {
+			marker = i
+			break
+		}
+		if !releaseTitleToken.MatchString(token) {
+			return false
+		}
+	}
+	if marker < 0 {
+		return false
+	}
+	tagged := false
+	for _, token := range tokens[marker+1:] {
+		if !releaseTagToken.MatchString(token) {
+			return false
+		}
+		tagged = tagged || releaseSources[strings.ToLower(token)] || releaseTechToken.MatchString(token)
+	}
+	return tagged
+}
+
+// ReleaseNameFromFile returns the release name in the name of a video or subtitle
+// file: the name without its directory, extension and, for subtitles, trailing
+// language and forced/SDH tags. ok is false if the rest is not a scene release name
+// (see IsSceneReleaseName), e.g. for "Heat (1995).mkv".
+func ReleaseNameFromFile(filename string) (name string, ok bool) {
+	name = filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if ext := filepath.Ext(name); ext != "" && len(ext) <= 5 {
+		name = strings.TrimSuffix(name, ext)
+	}
+	if IsSceneReleaseName(name) {
+		return name, true
+	}
+	// Strip up to two tags such as ".en", ".eng" or ".forced" from a subtitle name.
+	trimmed := name
+	for n := 0; n < 2; n++ {
+		i := strings.LastIndexByte(trimmed, '.')
+		if i < 0 {
+			break
+		}
+		tag := trimmed[i+1:]
+		if !subtitleNameTags[strings.ToLower(tag)] && !isLanguageTag(tag) {
+			break
+		}
+		trimmed = trimmed[:i]
+		if IsSceneReleaseName(trimmed) {
+			return trimmed, true
+		}
+	}
+	return "", false
+}
+
+// isLanguageTag reports whether tag looks like a lower-case ISO 639 code, e.g. "en",
+// "eng" or "pt-br".
+func isLanguageTag(tag string) bool {
+	code, region, _ := strings.Cut(tag, "-")
+	if len(code) < 2 || len(code) > 3 || (region != "" && len(region) != 2) {
+		return false
+	}
+	for _, r := range code + region {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// fillReleaseName sets ReleaseName from the video file name or, failing that, the
+// subtitle file name, if it is empty and one of them is a scene release name.
+func fillReleaseName(intent *UserUploadIntent) {
+	if intent.ReleaseName != "" {
+		return
+	}
+	for _, filename := range []string{intent.VideoFileName, intent.VideoFilePath, intent.SubtitleFileName, intent.SubtitleFilePath} {
+		if filename == "" {
+			continue
+		}
+		if name, ok := ReleaseNameFromFile(filename); ok {
+			intent.ReleaseName = name
+			return
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package upload
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReleaseNameFromFile(t *testing.T) {
+	tests := []struct {
+		filename string
+		want     string
+	}{
+		{"/videos/Heat.1995.1080p.BluRay.x264-GROUP.mkv", "Heat.1995.1080p.BluRay.x264-GROUP"},
+		{`C:\Videos\Show.Name.S01E02.720p.HDTV.x264-GROUP.mp4`, "Show.Name.S01E02.720p.HDTV.x264-GROUP"},
+		{"Show.Name.S01E02.Episode.Title.1080p.WEB-DL.DDP5.1.H.264-GROUP.mkv", "Show.Name.S01E02.Episode.Title.1080p.WEB-DL.DDP5.1.H.264-GROUP"},
+		{"Blade.Runner.2049.2017.2160p.UHD.BluRay.x265.mkv", "Blade.Runner.2049.2017.2160p.UHD.BluRay.x265"},
+		{"Heat.1995.1080p.BluRay.x264-GROUP.eng.srt", "Heat.1995.1080p.BluRay.x264-GROUP"},
+		{"Heat.1995.1080p.BluRay.x264-GROUP.pt-br.forced.srt", "Heat.1995.1080p.BluRay.x264-GROUP"},
+	}
+	for _, tt := range tests {
+		name, ok := ReleaseNameFromFile(tt.filename)
+		assert.True(t, ok, tt.filename)
+		assert.Equal(t, tt.want, name, tt.filename)
+	}
+
+	for _, filename := range []string{
+		"Heat (1995).mkv",                       // not dot separated
+		"Heat.1995.mkv",                         // no tags
+		"Heat.1080p.BluRay.x264-GROUP.mkv",      // no year or episode
+		"1995.1080p.BluRay.mkv",                 // no title
+		"Heat.1995.1080p.BluRay.x264-GR.UP.mkv", // invalid group
+		"heat-en.srt",
+		"",
+	} {
+		_, ok := ReleaseNameFromFile(filename)
+		assert.False(t, ok, filename)
+	}
+}
+
+func TestConsolidateMetadataReleaseName(t *testing.T) {
+	ctx := context.Background()
+
+	intent := UserUploadIntent{
+		VideoFilePath:    "/videos/Heat.1995.1080p.BluRay.x264-GROUP.mkv",
+		SubtitleFilePath: "/subs/Heat.1995.720p.BluRay.x264-OTHER.srt",
+	}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, nil))
+	assert.Equal(t, "Heat.1995.1080p.BluRay.x264-GROUP", intent.ReleaseName, "the video name wins")
+
+	intent = UserUploadIntent{VideoFilePath: "/videos/heat.mkv", SubtitleFilePath: "/subs/Heat.1995.720p.BluRay.x264-OTHER.en.srt"}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, nil))
+	assert.Equal(t, "Heat.1995.720p.BluRay.x264-OTHER", intent.ReleaseName, "falls back to the subtitle name")
+
+	intent = UserUploadIntent{SubtitleFilePath: "/subs/Heat.1995.720p.BluRay.x264-OTHER.srt", ReleaseName: "Custom"}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, nil))
+	assert.Equal(t, "Custom", intent.ReleaseName, "values set by the caller are kept")
+
+	intent = UserUploadIntent{VideoFilePath: "/videos/Heat (1995).mkv", SubtitleFilePath: "/subs/heat.srt"}
+	require.NoError(t, ConsolidateMetadata(ctx, &intent, nil))
+	assert.Empty(t, intent.ReleaseName)
+}
@@ -0,0 +1,52 @@
+package upload
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// UploadResult describes a subtitle uploaded with Upload.
+type UploadResult struct {
+	// SubtitleID is the ID of the subtitle on opensubtitles.org, parsed from URL. REST
+	// results carry it as SubtitleAttributes.LegacySubtitleID. It is 0 if the server
+	// returned no URL or one in an unknown form.
+	SubtitleID int
+	URL        string // Page of the uploaded subtitle
+	Language   string // XML-RPC language ID of the upload, e.g. "eng"
+	IMDbID     int    // IMDb ID the subtitle was uploaded for, without "tt"; 0 if unknown
+	// FeatureID is the REST feature ID of the movie or episode. Upload leaves it 0;
+	// opensubtitles.Client.ResolveUpload fills it.
+	FeatureID int
+}
+
+// ParseSubtitleURL returns the subtitle ID in the URL of an opensubtitles.org subtitle
+// page, e.g. 3580232 for "http://www.opensubtitles.org/en/subtitles/3580232/heat-en".
+func ParseSubtitleURL(raw string) (int, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return 0, fmt.Errorf("invalid subtitle URL '%s': %w", raw, err)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] != "subtitles" {
+			continue
+		}
+		if id, err := strconv.Atoi(segments[i+1]); err == nil && id > 0 {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("no subtitle ID in URL '%s'", raw)
+}
+
+// newUploadResult builds the result of an upload of params that returned subtitleURL.
+func newUploadResult(params XmlRpcTryUploadParams, subtitleURL string) (*UploadResult, error) {
+	result := &UploadResult{URL: subtitleURL, Language: params.SubLanguageID}
+	result.IMDbID, _ = strconv.Atoi(params.IDMovieImdb)
+	var err error
+	if subtitleURL != "" {
+		result.SubtitleID, err = ParseSubtitleURL(subtitleURL)
+	}
+	return result, err
+}
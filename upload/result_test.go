@@ -0,0 +1,68 @@
+package upload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	xmlrpc "github.com/kolo/xmlrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSubtitleURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want int
+	}{
+		{"http://www.opensubtitles.org/subtitles/3580232/heat-en", 3580232},
+		{"https://www.opensubtitles.org/en/subtitles/3580232/heat-en", 3580232},
+		{"http://www.opensubtitles.org/en/subtitles/3580232", 3580232},
+	}
+	for _, tt := range tests {
+		id, err := ParseSubtitleURL(tt.url)
+		require.NoError(t, err, tt.url)
+		assert.Equal(t, tt.want, id, tt.url)
+	}
+
+	for _, url := range []string{"", "http://www.opensubtitles.org/en/search", "http://www.opensubtitles.org/subtitles/heat", "%zz"} {
+		_, err := ParseSubtitleURL(url)
+		assert.Error(t, err, url)
+	}
+}
+
+func TestUploadResult(t *testing.T) {
+	// The server answers each call in order: CheckSubHash, TryUploadSubtitles, UploadSubtitles.
+	responses := []string{
+		"<struct>" + member("status", "<string>200 OK</string>") + member("data", "<array><data></data></array>") + "</struct>",
+		"<struct>" + member("status", "<string>200 OK</string>") + member("alreadyindb", "<int>0</int>") + member("data", "<array><data></data></array>") + "</struct>",
+		"<struct>" + member("status", "<string>200 OK</string>") + member("data", "<string>http://www.opensubtitles.org/subtitles/3580232/heat-en</string>") + "</struct>",
+	}
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value>`+responses[calls]+`</value></param></params></methodResponse>`)
+		calls++
+	}))
+	t.Cleanup(server.Close)
+	client, err := xmlrpc.NewClient(server.URL, nil)
+	require.NoError(t, err)
+	c := &xmlRpcClient{client: client, token: "token", loggedIn: true}
+
+	result, err := c.Upload(UserUploadIntent{
+		SubtitleFilePath: "../testdata/dummy.srt",
+		SubtitleFileName: "dummy.srt",
+		IMDBID:           "tt0113277",
+		LanguageID:       "en",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &UploadResult{
+		SubtitleID: 3580232,
+		URL:        "http://www.opensubtitles.org/subtitles/3580232/heat-en",
+		Language:   "eng",
+		IMDbID:     113277,
+	}, result)
+	assert.Equal(t, 3, calls)
+}
@@ -0,0 +1,330 @@
+package upload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Searcher defines the legacy XML-RPC lookups, for users who identify videos by
+// OSDb hash through the old API. The value returned by NewXmlRpcUploader implements
+// it and uses the session of Uploader.Login.
+type Searcher interface {
+	// SearchSubtitles runs every query and returns the combined results, at most limit
+	// per query (zero uses the server default of 500).
+	SearchSubtitles(queries []SearchQuery, limit int) ([]SearchResult, error)
+	// CheckMovieHash returns the best movie match for each known hash.
+	CheckMovieHash(hashes []string) (*CheckMovieHashResult, error)
+	// CheckMovieHash2 returns all movie matches for each known hash.
+	CheckMovieHash2(hashes []string) (*CheckMovieHash2Result, error)
+	// CheckSubHash maps each subtitle MD5 hash that is already in the database to its
+	// IDSubtitleFile. Unknown hashes are left out.
+	CheckSubHash(hashes []string) (map[string]string, error)
+}
+
+// Ensure xmlRpcClient implements Searcher.
+var _ Searcher = (*xmlRpcClient)(nil)
+
+// MaxCheckMovieHashes is the number of hashes the server accepts per CheckMovieHash call.
+const MaxCheckMovieHashes = 200
+
+// SearchQuery is one query of the XML-RPC SearchSubtitles method. Set MovieHash with
+// MovieByteSize, or IMDbID, or Query (optionally with Season and Episode), or Tag.
+type SearchQuery struct {
+	SubLanguageID string // Comma-separated ISO 639-2 codes, e.g. "eng,ell"; empty means all
+	MovieHash     string
+	MovieByteSize int64
+	IMDbID        string // With or without "tt"
+	Query         string
+	Season        int
+	Episode       int
+	Tag           string // Release or file name
+}
+
+// SearchResult is a subtitle file returned by SearchSubtitles. The server sends all
+// values as strings; numeric and flag fields are converted.
+type SearchResult struct {
+	IDSubtitleFile     string
+	IDSubtitle         string
+	SubFileName        string
+	SubHash            string
+	SubFormat          string
+	SubEncoding        string
+	SubLanguageID      string
+	ISO639             string
+	LanguageName       string
+	SubDownloadsCnt    int
+	SubRating          float64
+	SubHearingImpaired bool
+	SubAddDate         string
+	MovieHash          string
+	MovieByteSize      int64
+	MovieReleaseName   string
+	MovieName          string
+	MovieYear          int
+	MovieKind          string
+	IDMovieImdb        string
+	SeriesSeason       int
+	SeriesEpisode      int
+	UserNickName       string
+	MatchedBy          string // "moviehash", "imdbid", "tag" or "fulltext"
+	QueryNumber        int    // Index of the matching SearchQuery
+	SubDownloadLink    string // Gzipped subtitle file
+	ZipDownloadLink    string
+	SubtitlesLink      string
+}
+
+// MovieHashMatch is a movie identified by its OSDb hash.
+type MovieHashMatch struct {
+	MovieHash     string
+	MovieImdbID   string
+	MovieName     string
+	MovieYear     int
+	MovieKind     string // CheckMovieHash2 only: "movie" or "episode"
+	SeriesSeason  int    // CheckMovieHash2 only
+	SeriesEpisode int    // CheckMovieHash2 only
+	SeenCount     int    // CheckMovieHash2 only
+	SubCount      int    // CheckMovieHash2 only
+}
+
+// CheckMovieHashResult maps each known hash to its best match. Hashes the server
+// did not process (e.g. over the rate limit) are listed in NotProcessed.
+type CheckMovieHashResult struct {
+	Matches      map[string]MovieHashMatch
+	NotProcessed []string
+}
+
+// CheckMovieHash2Result maps each known hash to all its matches.
+type CheckMovieHash2Result struct {
+	Matches      map[string][]MovieHashMatch
+	NotProcessed []string
+}
+
+// SearchSubtitles calls the XML-RPC SearchSubtitles method.
+func (c *xmlRpcClient) SearchSubtitles(queries []SearchQuery, limit int) ([]SearchResult, error) {
+	if !c.loggedIn || c.token == "" {
+		return nil, ErrNotLoggedIn
+	}
+	args := make([]interface{}, 0, len(queries))
+	for _, q := range queries {
+		m := map[string]interface{}{"sublanguageid": q.SubLanguageID}
+		if q.MovieHash != "" {
+			m["moviehash"] = q.MovieHash
+			m["moviebytesize"] = strconv.FormatInt(q.MovieByteSize, 10)
+		}
+		if q.IMDbID != "" {
+			m["imdbid"] = strings.TrimPrefix(strings.ToLower(q.IMDbID), "tt")
+		}
+		if q.Query != "" {
+			m["query"] = q.Query
+		}
+		if q.Season > 0 {
+			m["season"] = strconv.Itoa(q.Season)
+		}
+		if q.Episode > 0 {
+			m["episode"] = strconv.Itoa(q.Episode)
+		}
+		if q.Tag != "" {
+			m["tag"] = q.Tag
+		}
+		args = append(args, m)
+	}
+	callArgs := []interface{}{c.token, args}
+	if limit > 0 {
+		callArgs = append(callArgs, map[string]interface{}{"limit": limit})
+	}
+
+	resp, err := c.call("SearchSubtitles", callArgs...)
+	if err != nil {
+		return nil, err
+	}
+	// "data" is false when nothing matches.
+	items, _ := resp["data"].([]interface{})
+	results := make([]SearchResult, 0, len(items))
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		results = append(results, SearchResult{
+			IDSubtitleFile:     xmlRpcString(m["IDSubtitleFile"]),
+			IDSubtitle:         xmlRpcString(m["IDSubtitle"]),
+			SubFileName:        xmlRpcString(m["SubFileName"]),
+			SubHash:            xmlRpcString(m["SubHash"]),
+			SubFormat:          xmlRpcString(m["SubFormat"]),
+			SubEncoding:        xmlRpcString(m["SubEncoding"]),
+			SubLanguageID:      xmlRpcString(m["SubLanguageID"]),
+			ISO639:             xmlRpcString(m["ISO639"]),
+			LanguageName:       xmlRpcString(m["LanguageName"]),
+			SubDownloadsCnt:    xmlRpcInt(m["SubDownloadsCnt"]),
+			SubRating:          xmlRpcFloat(m["SubRating"]),
+			SubHearingImpaired: xmlRpcInt(m["SubHearingImpaired"]) != 0,
+			SubAddDate:         xmlRpcString(m["SubAddDate"]),
+			MovieHash:          xmlRpcString(m["MovieHash"]),
+			MovieByteSize:      xmlRpcInt64(m["MovieByteSize"]),
+			MovieReleaseName:   xmlRpcString(m["MovieReleaseName"]),
+			MovieName:          xmlRpcString(m["MovieName"]),
+			MovieYear:          xmlRpcInt(m["MovieYear"]),
+			MovieKind:          xmlRpcString(m["MovieKind"]),
+			IDMovieImdb:        xmlRpcString(m["IDMovieImdb"]),
+			SeriesSeason:       xmlRpcInt(m["SeriesSeason"]),
+			SeriesEpisode:      xmlRpcInt(m["SeriesEpisode"]),
+			UserNickName:       xmlRpcString(m["UserNickName"]),
+			MatchedBy:          xmlRpcString(m["MatchedBy"]),
+			QueryNumber:        xmlRpcInt(m["QueryNumber"]),
+			SubDownloadLink:    xmlRpcString(m["SubDownloadLink"]),
+			ZipDownloadLink:    xmlRpcString(m["ZipDownloadLink"]),
+			SubtitlesLink:      xmlRpcString(m["SubtitlesLink"]),
+		})
+	}
+	return results, nil
+}
+
+// CheckMovieHash calls the XML-RPC CheckMovieHash method.
+func (c *xmlRpcClient) CheckMovieHash(hashes []string) (*CheckMovieHashResult, error) {
+	data, notProcessed, err := c.checkMovieHash("CheckMovieHash", hashes)
+	if err != nil {
+		return nil, err
+	}
+	result := &CheckMovieHashResult{Matches: make(map[string]MovieHashMatch), NotProcessed: notProcessed}
+	for hash, raw := range data {
+		// Unknown hashes map to an empty array instead of a struct.
+		if m, ok := raw.(map[string]interface{}); ok && xmlRpcString(m["MovieImdbID"]) != "" {
+			result.Matches[hash] = movieHashMatch(m)
+		}
+	}
+	return result, nil
+}
+
+// CheckMovieHash2 calls the XML-RPC CheckMovieHash2 method.
+func (c *xmlRpcClient) CheckMovieHash2(hashes []string) (*CheckMovieHash2Result, error) {
+	data, notProcessed, err := c.checkMovieHash("CheckMovieHash2", hashes)
+	if err != nil {
+		return nil, err
+	}
+	result := &CheckMovieHash2Result{Matches: make(map[string][]MovieHashMatch), NotProcessed: notProcessed}
+	for hash, raw := range data {
+		list, _ := raw.([]interface{})
+		for _, item := range list {
+			if m, ok := item.(map[string]interface{}); ok {
+				result.Matches[hash] = append(result.Matches[hash], movieHashMatch(m))
+			}
+		}
+	}
+	return result, nil
+}
+
+// CheckSubHash calls the XML-RPC CheckSubHash method.
+func (c *xmlRpcClient) CheckSubHash(hashes []string) (map[string]string, error) {
+	if !c.loggedIn || c.token == "" {
+		return nil, ErrNotLoggedIn
+	}
+	list := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		list[i] = hash
+	}
+	resp, err := c.call("CheckSubHash", c.token, list)
+	if err != nil {
+		return nil, err
+	}
+	data, _ := resp["data"].(map[string]interface{})
+	known := make(map[string]string, len(data))
+	for hash, raw := range data {
+		// Unknown hashes map to "0".
+		if id := xmlRpcString(raw); id != "" && id != "0" {
+			known[hash] = id
+		}
+	}
+	return known, nil
+}
+
+// checkMovieHash calls method and returns its per-hash data and unprocessed hashes.
+func (c *xmlRpcClient) checkMovieHash(method string, hashes []string) (map[string]interface{}, []string, error) {
+	if !c.loggedIn || c.token == "" {
+		return nil, nil, ErrNotLoggedIn
+	}
+	if len(hashes) > MaxCheckMovieHashes {
+		return nil, nil, fmt.Errorf("xmlrpc %s accepts at most %d hashes, got %d", method, MaxCheckMovieHashes, len(hashes))
+	}
+	list := make([]interface{}, len(hashes))
+	for i, hash := range hashes {
+		list[i] = hash
+	}
+	resp, err := c.call(method, c.token, list)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, _ := resp["data"].(map[string]interface{})
+	var notProcessed []string
+	if raw, ok := resp["not_processed"].([]interface{}); ok {
+		for _, hash := range raw {
+			notProcessed = append(notProcessed, xmlRpcString(hash))
+		}
+	}
+	return data, notProcessed, nil
+}
+
+// call invokes an XML-RPC method and checks the status of its struct response.
+func (c *xmlRpcClient) call(method string, args ...interface{}) (map[string]interface{}, error) {
+	var raw interface{}
+	if err := c.client.Call(method, args, &raw); err != nil {
+		return nil, fmt.Errorf("xmlrpc %s call failed: %w", method, err)
+	}
+	resp, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s response type: %T (%v)", method, raw, raw)
+	}
+	status := xmlRpcString(resp["status"])
+	switch {
+	case strings.HasPrefix(status, "200"):
+		return resp, nil
+	case strings.HasPrefix(status, "401"):
+		return nil, ErrNotLoggedIn
+	default:
+		return nil, fmt.Errorf("xmlrpc %s failed with status: %s", method, status)
+	}
+}
+
+func movieHashMatch(m map[string]interface{}) MovieHashMatch {
+	return MovieHashMatch{
+		MovieHash:     xmlRpcString(m["MovieHash"]),
+		MovieImdbID:   xmlRpcString(m["MovieImdbID"]),
+		MovieName:     xmlRpcString(m["MovieName"]),
+		MovieYear:     xmlRpcInt(m["MovieYear"]),
+		MovieKind:     xmlRpcString(m["MovieKind"]),
+		SeriesSeason:  xmlRpcInt(m["SeriesSeason"]),
+		SeriesEpisode: xmlRpcInt(m["SeriesEpisode"]),
+		SeenCount:     xmlRpcInt(m["SeenCount"]),
+		SubCount:      xmlRpcInt(m["SubCount"]),
+	}
+}
+
+// xmlRpcString returns v as a string; the server mixes strings, ints and doubles.
+func xmlRpcString(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return ""
+	}
+}
+
+func xmlRpcFloat(v interface{}) float64 {
+	f, _ := strconv.ParseFloat(xmlRpcString(v), 64)
+	return f
+}
+
+func xmlRpcInt(v interface{}) int {
+	return int(xmlRpcFloat(v))
+}
+
+func xmlRpcInt64(v interface{}) int64 {
+	n, _ := strconv.ParseInt(xmlRpcString(v), 10, 64)
+	return n
+}
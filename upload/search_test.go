@@ -0,0 +1,168 @@
+package upload
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	xmlrpc "github.com/kolo/xmlrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestClient returns a logged-in client whose server answers every call with response.
+func newTestClient(t *testing.T, response string, check func(body string)) *xmlRpcClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if check != nil {
+			check(string(body))
+		}
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = io.WriteString(w, `<?xml version="1.0"?><methodResponse><params><param><value>`+response+`</value></param></params></methodResponse>`)
+	}))
+	t.Cleanup(server.Close)
+	client, err := xmlrpc.NewClient(server.URL, nil)
+	require.NoError(t, err)
+	return &xmlRpcClient{client: client, token: "token", loggedIn: true}
+}
+
+func member(name, value string) string {
+	return "<member><name>" + name + "</name><value>" + value + "</value></member>"
+}
+
+func TestSearchSubtitles(t *testing.T) {
+	result := "<struct>" +
+		member("IDSubtitleFile", "<string>1951976245</string>") +
+		member("SubFileName", "<string>Heat.1995.eng.srt</string>") +
+		member("SubLanguageID", "<string>eng</string>") +
+		member("SubDownloadsCnt", "<string>1234</string>") +
+		member("SubRating", "<string>7.5</string>") +
+		member("SubHearingImpaired", "<string>1</string>") +
+		member("MovieByteSize", "<string>7356364800</string>") +
+		member("MovieYear", "<string>1995</string>") +
+		member("IDMovieImdb", "<string>113277</string>") +
+		member("MatchedBy", "<string>moviehash</string>") +
+		member("QueryNumber", "<string>0</string>") +
+		"</struct>"
+	response := "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<array><data><value>"+result+"</value></data></array>") + "</struct>"
+	c := newTestClient(t, response, func(body string) {
+		assert.Contains(t, body, "<methodName>SearchSubtitles</methodName>")
+		assert.Contains(t, body, "8e245d9679d31e12")
+		assert.Contains(t, body, "<name>imdbid</name><value><string>113277</string>")
+		assert.Contains(t, body, "<name>limit</name><value><int>10</int>")
+	})
+
+	results, err := c.SearchSubtitles([]SearchQuery{
+		{SubLanguageID: "eng", MovieHash: "8e245d9679d31e12", MovieByteSize: 7356364800},
+		{IMDbID: "tt113277"},
+	}, 10)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	r := results[0]
+	assert.Equal(t, "1951976245", r.IDSubtitleFile)
+	assert.Equal(t, "Heat.1995.eng.srt", r.SubFileName)
+	assert.Equal(t, 1234, r.SubDownloadsCnt)
+	assert.Equal(t, 7.5, r.SubRating)
+	assert.True(t, r.SubHearingImpaired)
+	assert.Equal(t, int64(7356364800), r.MovieByteSize)
+	assert.Equal(t, 1995, r.MovieYear)
+	assert.Equal(t, "moviehash", r.MatchedBy)
+}
+
+func TestSearchSubtitlesNoResults(t *testing.T) {
+	c := newTestClient(t, "<struct>"+member("status", "<string>200 OK</string>")+member("data", "<boolean>0</boolean>")+"</struct>", nil)
+	results, err := c.SearchSubtitles([]SearchQuery{{Query: "nothing"}}, 0)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestCheckMovieHash(t *testing.T) {
+	known := "<struct>" + member("MovieHash", "<string>8e245d9679d31e12</string>") +
+		member("MovieImdbID", "<string>113277</string>") + member("MovieName", "<string>Heat</string>") +
+		member("MovieYear", "<string>1995</string>") + "</struct>"
+	response := "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<struct>"+member("8e245d9679d31e12", known)+member("0000000000000000", "<array><data></data></array>")+"</struct>") +
+		member("not_processed", "<array><data><value><string>ffffffffffffffff</string></value></data></array>") + "</struct>"
+	c := newTestClient(t, response, nil)
+
+	result, err := c.CheckMovieHash([]string{"8e245d9679d31e12", "0000000000000000", "ffffffffffffffff"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]MovieHashMatch{
+		"8e245d9679d31e12": {MovieHash: "8e245d9679d31e12", MovieImdbID: "113277", MovieName: "Heat", MovieYear: 1995},
+	}, result.Matches)
+	assert.Equal(t, []string{"ffffffffffffffff"}, result.NotProcessed)
+
+	_, err = c.CheckMovieHash(make([]string, MaxCheckMovieHashes+1))
+	assert.Error(t, err)
+}
+
+func TestCheckMovieHash2(t *testing.T) {
+	match := func(kind, season string) string {
+		return "<value><struct>" + member("MovieHash", "<string>8e245d9679d31e12</string>") +
+			member("MovieImdbID", "<string>1</string>") + member("MovieKind", "<string>"+kind+"</string>") +
+			member("SeriesSeason", "<string>"+season+"</string>") + member("SeenCount", "<int>42</int>") + "</struct></value>"
+	}
+	response := "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<struct>"+member("8e245d9679d31e12", "<array><data>"+match("movie", "0")+match("episode", "2")+"</data></array>")+"</struct>") + "</struct>"
+	c := newTestClient(t, response, func(body string) {
+		assert.Contains(t, body, "<methodName>CheckMovieHash2</methodName>")
+	})
+
+	result, err := c.CheckMovieHash2([]string{"8e245d9679d31e12"})
+	require.NoError(t, err)
+	require.Len(t, result.Matches["8e245d9679d31e12"], 2)
+	assert.Equal(t, "episode", result.Matches["8e245d9679d31e12"][1].MovieKind)
+	assert.Equal(t, 2, result.Matches["8e245d9679d31e12"][1].SeriesSeason)
+	assert.Equal(t, 42, result.Matches["8e245d9679d31e12"][0].SeenCount)
+}
+
+func TestSearcherErrors(t *testing.T) {
+	_, err := (&xmlRpcClient{}).CheckMovieHash([]string{"x"})
+	assert.ErrorIs(t, err, ErrNotLoggedIn)
+
+	c := newTestClient(t, "<struct>"+member("status", "<string>407 Download limit reached</string>")+"</struct>", nil)
+	_, err = c.SearchSubtitles([]SearchQuery{{Query: "x"}}, 0)
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "407 Download limit reached"))
+}
+
+func TestCheckSubHash(t *testing.T) {
+	response := "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<struct>"+member("a2b51e055b718161a2b51e055b718161", "<string>1951976245</string>")+member("00000000000000000000000000000000", "<string>0</string>")+"</struct>") + "</struct>"
+	c := newTestClient(t, response, func(body string) {
+		assert.Contains(t, body, "<methodName>CheckSubHash</methodName>")
+	})
+
+	known, err := c.CheckSubHash([]string{"a2b51e055b718161a2b51e055b718161", "00000000000000000000000000000000"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"a2b51e055b718161a2b51e055b718161": "1951976245"}, known)
+
+	_, err = (&xmlRpcClient{}).CheckSubHash([]string{"x"})
+	assert.ErrorIs(t, err, ErrNotLoggedIn)
+}
+
+func TestUploadSkipsKnownSubHash(t *testing.T) {
+	subHash, err := CalculateMD5Hash("../testdata/dummy.srt")
+	require.NoError(t, err)
+	var methods []string
+	response := "<struct>" + member("status", "<string>200 OK</string>") +
+		member("data", "<struct>"+member(subHash, "<string>42</string>")+"</struct>") + "</struct>"
+	c := newTestClient(t, response, func(body string) {
+		start := strings.Index(body, "<methodName>") + len("<methodName>")
+		methods = append(methods, body[start:strings.Index(body, "</methodName>")])
+	})
+
+	_, err = c.Upload(UserUploadIntent{
+		SubtitleFilePath: "../testdata/dummy.srt",
+		SubtitleFileName: "dummy.srt",
+		IMDBID:           "tt0113277",
+		LanguageID:       "eng",
+	})
+	assert.ErrorIs(t, err, ErrUploadDuplicate)
+	assert.ErrorContains(t, err, "IDSubtitleFile 42")
+	assert.Equal(t, []string{"CheckSubHash"}, methods, "TryUploadSubtitles is not called")
+}
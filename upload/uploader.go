@@ -26,14 +26,20 @@ package upload
 // potentially reusing/adapting from the old xmlrpc_client.go
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/rpc"
 	"net/url"
+	"sync"
 	"time"
 
+	"github.com/angelospk/opensubtitles-go/internal/httpclient"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/mediainfo"
 	xmlrpc "github.com/kolo/xmlrpc"
 )
 
@@ -51,9 +57,14 @@ type Uploader interface {
 	Logout() error
 	// Upload performs the complete two-step subtitle upload process.
 	// It takes user intent, prepares parameters, calls TryUpload and UploadSubtitles.
-	// Returns the URL of the uploaded subtitle on success.
-	Upload(intent UserUploadIntent) (string, error)
+	// Subtitles whose MD5 hash is already known are rejected with ErrUploadDuplicate
+	// before TryUpload. Returns the URL and ID of the uploaded subtitle on success.
+	Upload(intent UserUploadIntent) (*UploadResult, error)
 	Close() error // Add Close method to the interface
+	// Searcher provides the hash lookups of the same XML-RPC session.
+	Searcher
+	// Info describes the server's languages and limits.
+	Info
 }
 
 // Errors returned by the upload package
@@ -71,22 +82,89 @@ type xmlRpcClient struct {
 	client   *xmlrpc.Client
 	token    string
 	loggedIn bool
+	logger   *slog.Logger // Nil discards logs
+	prober   mediainfo.Prober
+	timeout  time.Duration // Bounds probing as well as each call; <= 0 means none
+	encoding ContentEncoding
+	detect   bool // Options.DetectFlags
+	validate bool // Options.ValidateLanguage
+
+	mu           sync.Mutex      // Protects accepted and subLanguages
+	accepted     ContentEncoding // Last encoding UploadSubtitles accepted
+	subLanguages map[string]bool // Lowercase SubLanguageIDs of GetSubLanguages; nil until fetched
 }
 
 // Ensure xmlRpcClient implements Uploader.
 var _ Uploader = (*xmlRpcClient)(nil)
 
-// NewXmlRpcUploader creates a new XML-RPC uploader client.
+// NewXmlRpcUploader creates a new XML-RPC uploader client that does not log.
 // Renamed from NewXmlRpcClient
 func NewXmlRpcUploader() (Uploader, error) {
-	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
+	return NewXmlRpcUploaderWithLogger(nil)
+}
+
+// NewXmlRpcUploaderWithLogger creates a new XML-RPC uploader client that logs the
+// upload steps at debug level and unexpected responses as warnings to logger.
+func NewXmlRpcUploaderWithLogger(logger *slog.Logger) (Uploader, error) {
+	return NewXmlRpcUploaderWithOptions(Options{Logger: logger})
+}
+
+// DefaultTimeout bounds each XML-RPC call when Options.Timeout is not set.
+const DefaultTimeout = 30 * time.Second
+
+// Options configures NewXmlRpcUploaderWithOptions.
+type Options struct {
+	// Logger receives the upload steps at debug level and unexpected responses as
+	// warnings. Nil discards logs.
+	Logger *slog.Logger
+	// Transport sends the XML-RPC requests, e.g. through a proxy or with a custom CA
+	// bundle. Nil uses a transport that honours the HTTP(S)_PROXY environment variables.
+	Transport http.RoundTripper
+	// Timeout bounds each XML-RPC call, including reading the response
+	// (default: DefaultTimeout). A negative value disables it.
+	Timeout time.Duration
+	// Prober, if set, reads the FPS, duration and frame count of the video file
+	// before each upload; see ConsolidateMetadata. A failed probe is logged and the
+	// upload continues without them.
+	Prober mediainfo.Prober
+	// ContentEncoding selects how UploadSubtitles sends the subtitle content
+	// (default: EncodingAuto).
+	ContentEncoding ContentEncoding
+	// DetectFlags makes Upload set HearingImpaired and ForeignPartsOnly when the
+	// subtitle's content shows them; see DetectFlags.
+	DetectFlags bool
+	// ValidateLanguage makes Upload check the subtitle's language against
+	// GetSubLanguages, fetched once, before TryUploadSubtitles, returning
+	// ErrUnsupportedLanguage for languages the server does not list. A failure to
+	// fetch the list is logged and the upload continues.
+	ValidateLanguage bool
+	// Header is sent with every XML-RPC call, e.g. a request ID for tracing. The
+	// Content-Type, Content-Length and User-Agent headers are never replaced.
+	Header http.Header
+}
+
+// NewXmlRpcUploaderWithOptions creates a new XML-RPC uploader client.
+func NewXmlRpcUploaderWithOptions(opts Options) (Uploader, error) {
+	if err := opts.ContentEncoding.validate(); err != nil {
+		return nil, err
 	}
-	httpClient := &http.Client{
-		Transport: tr,
-		Timeout:   30 * time.Second,
+	transport := opts.Transport
+	if transport == nil {
+		transport = &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+		}
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	if timeout > 0 {
+		transport = timeoutTransport{next: transport, timeout: timeout}
 	}
-	client, err := xmlrpc.NewClient(xmlRpcEndpoint, httpClient.Transport)
+	if len(opts.Header) > 0 {
+		transport = headerTransport{next: transport, header: opts.Header.Clone()}
+	}
+	client, err := xmlrpc.NewClient(xmlRpcEndpoint, transport)
 	if err != nil {
 		return nil, fmt.Errorf("error creating XML-RPC client: %w", err)
 	}
@@ -94,9 +172,57 @@ func NewXmlRpcUploader() (Uploader, error) {
 	return &xmlRpcClient{
 		client:   client,
 		loggedIn: false,
+		logger:   opts.Logger,
+		prober:   opts.Prober,
+		timeout:  timeout,
+		encoding: opts.ContentEncoding,
+		detect:   opts.DetectFlags,
+		validate: opts.ValidateLanguage,
 	}, nil
 }
 
+// timeoutTransport bounds each request, including reading its response body. The
+// xmlrpc package only accepts a RoundTripper, so http.Client.Timeout cannot be used.
+type timeoutTransport struct {
+	next    http.RoundTripper
+	timeout time.Duration
+}
+
+func (t timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.next.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// headerTransport adds Options.Header to each request.
+type headerTransport struct {
+	next   http.RoundTripper
+	header http.Header
+}
+
+func (t headerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	httpclient.AddHeaders(req.Header, t.header)
+	return t.next.RoundTrip(req)
+}
+
+// cancelOnClose releases the request's timeout when the response body is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
 // Login authenticates the user via XML-RPC and stores the token.
 func (c *xmlRpcClient) Login(username, password, language, userAgent string) error {
 	var result xmlRpcLoginResponse // Use unexported struct
@@ -124,7 +250,7 @@ func (c *xmlRpcClient) Login(username, password, language, userAgent string) err
 
 	c.token = result.Token
 	c.loggedIn = true
-	fmt.Printf("XML-RPC Login successful. Token: %s...\n", c.token[:4]) // Shorten token log
+	c.log().Debug("xmlrpc: login successful")
 	return nil
 }
 
@@ -145,59 +271,112 @@ func (c *xmlRpcClient) Logout() error {
 
 	c.token = ""
 	c.loggedIn = false
-	fmt.Println("XML-RPC Logout successful.")
+	c.log().Debug("xmlrpc: logout successful")
 	return nil
 }
 
 // Upload performs the full two-step upload process.
-func (c *xmlRpcClient) Upload(intent UserUploadIntent) (string, error) {
+func (c *xmlRpcClient) Upload(intent UserUploadIntent) (*UploadResult, error) {
 	if !c.loggedIn || c.token == "" {
-		return "", ErrNotLoggedIn
+		return nil, ErrNotLoggedIn
+	}
+
+	// 1. Fill the file names, video details and, if enabled, detected flags the caller left empty
+	if err := c.consolidate(&intent); err != nil {
+		c.log().Warn("xmlrpc: failed to read video details, uploading without them", "error", err)
+	}
+	if c.detect {
+		if analysis, err := DetectFlags(&intent); err != nil {
+			c.log().Warn("xmlrpc: failed to analyze subtitle content, uploading without detected flags", "error", err)
+		} else {
+			c.log().Debug("xmlrpc: analyzed subtitle content", "hearing_impaired", analysis.HearingImpaired, "forced", analysis.Forced)
+		}
 	}
 
-	// 1. Prepare TryUpload parameters
-	log.Println("Preparing TryUpload parameters...")
+	// 2. Check the intent locally, so that bad input does not surface as server errors
+	issues := ValidateIntent(intent)
+	for _, issue := range issues {
+		if issue.Severity == SeverityWarning {
+			c.log().Warn("xmlrpc: upload intent has a problem the server may accept", "field", issue.Field, "issue", issue.Message)
+		}
+	}
+	if HasErrors(issues) {
+		return nil, &ValidationError{Issues: issues}
+	}
+
+	// 3. Prepare TryUpload parameters
+	c.log().Debug("xmlrpc: preparing TryUploadSubtitles parameters")
 	tryParams, err := PrepareTryUploadParams(intent) // From helpers.go
 	if err != nil {
-		return "", fmt.Errorf("error preparing TryUpload params: %w", err)
+		return nil, fmt.Errorf("error preparing TryUpload params: %w", err)
 	}
 	// log.Printf("[DEBUG] TryUpload Params: %+v\n", tryParams)
 
-	// 2. Call TryUploadSubtitles
-	log.Println("Calling TryUploadSubtitles...")
+	if c.validate && tryParams.SubLanguageID != "" {
+		if err := c.checkSubLanguage(tryParams.SubLanguageID); errors.Is(err, ErrUnsupportedLanguage) {
+			return nil, err
+		} else if err != nil {
+			c.log().Warn("xmlrpc: GetSubLanguages failed, uploading without checking the language", "error", err)
+		}
+	}
+
+	// CheckSubHash is much cheaper than TryUploadSubtitles, so known files are
+	// rejected early. A failed check only skips the shortcut.
+	subHash := tryParams.CDs["cd1"].SubHash
+	if known, err := c.CheckSubHash([]string{subHash}); err != nil {
+		c.log().Warn("xmlrpc: CheckSubHash failed, falling back to TryUploadSubtitles", "error", err)
+	} else if id, ok := known[subHash]; ok {
+		c.log().Debug("xmlrpc: CheckSubHash reports a duplicate", "subhash", subHash, "id_subtitle_file", id)
+		return nil, fmt.Errorf("%w (IDSubtitleFile %s)", ErrUploadDuplicate, id)
+	}
+
+	// 4. Call TryUploadSubtitles
+	c.log().Debug("xmlrpc: calling TryUploadSubtitles")
 	tryResponse, err := c.tryUploadSubtitles(tryParams) // Call internal method
 	if err != nil {
 		if errors.Is(err, ErrUploadDuplicate) {
-			log.Println("TryUploadSubtitles indicates duplicate.")
-			return "", ErrUploadDuplicate
+			c.log().Debug("xmlrpc: TryUploadSubtitles reports a duplicate")
+			return nil, ErrUploadDuplicate
 		}
-		return "", fmt.Errorf("TryUploadSubtitles failed: %w", err)
+		return nil, fmt.Errorf("TryUploadSubtitles failed: %w", err)
 	}
-	log.Printf("TryUploadSubtitles response: Status='%s', Data=%v, AlreadyInDB=%d", tryResponse.Status, tryResponse.Data, tryResponse.AlreadyInDB)
+	c.log().Debug("xmlrpc: TryUploadSubtitles response", "status", tryResponse.Status, "data", tryResponse.Data, "already_in_db", tryResponse.AlreadyInDB)
 
-	// 3. Check if TryUpload response indicates we should proceed
+	// 5. Check if TryUpload response indicates we should proceed
 	if !tryResponse.Data {
-		log.Println("TryUpload response indicates duplicate or issue (Data=false). Skipping final upload.")
-		return "", ErrUploadDuplicate // Treat non-proceed as duplicate error for simplicity
+		c.log().Debug("xmlrpc: TryUploadSubtitles returned data=false, skipping UploadSubtitles")
+		return nil, ErrUploadDuplicate // Treat non-proceed as duplicate error for simplicity
 	}
 
-	// 4. Prepare UploadSubtitles parameters
-	log.Println("Preparing UploadSubtitles parameters...")
-	uploadParams, err := PrepareUploadSubtitlesParams(tryParams, intent.SubtitleFilePath) // From helpers.go
+	// 6. Prepare and call UploadSubtitles in the configured content encoding
+	c.log().Debug("xmlrpc: calling UploadSubtitles")
+	uploadResp, err := c.uploadSubtitlesEncoded(tryParams, intent.SubtitleFilePath)
 	if err != nil {
-		return "", fmt.Errorf("error preparing UploadSubtitles params: %w", err)
+		return nil, err
 	}
-	// fmt.Printf("[DEBUG] UploadSubtitles Params: %+v\n", uploadParams) // Keep commented unless needed
+	c.log().Debug("xmlrpc: UploadSubtitles successful", "status", uploadResp.Status, "url", uploadResp.Data)
 
-	// 5. Call UploadSubtitles
-	log.Println("Calling UploadSubtitles...")
-	uploadResp, err := c.uploadSubtitles(uploadParams) // Call internal method
+	result, err := newUploadResult(tryParams, uploadResp.Data)
 	if err != nil {
-		return "", fmt.Errorf("UploadSubtitles failed: %w", err)
+		c.log().Warn("xmlrpc: failed to read the subtitle ID of the upload", "error", err)
 	}
-	log.Printf("UploadSubtitles successful! Status: %s, URL: %s", uploadResp.Status, uploadResp.Data)
+	return result, nil
+}
 
-	return uploadResp.Data, nil // Return the subtitle URL
+// consolidate runs ConsolidateMetadata with the uploader's prober, bounded by its timeout.
+func (c *xmlRpcClient) consolidate(intent *UserUploadIntent) error {
+	ctx := context.Background()
+	if c.prober != nil && c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+	return ConsolidateMetadata(ctx, intent, c.prober)
+}
+
+// log returns the logger, discarding logs if none was set.
+func (c *xmlRpcClient) log() *slog.Logger {
+	return logging.OrDiscard(c.logger)
 }
 
 // Close closes the underlying XML-RPC client connection.
@@ -254,7 +433,7 @@ func (c *xmlRpcClient) tryUploadSubtitles(params XmlRpcTryUploadParams) (*xmlRpc
 		// This case should ideally be handled, perhaps by an error from PrepareTryUploadParams
 		// or a check before calling tryUploadSubtitles.
 		// For now, log and continue, which might lead to an API error if fields are missing.
-		log.Println("[WARN] tryUploadSubtitles: 'cd1' data not found in params.CDs")
+		c.log().Warn("xmlrpc: TryUploadSubtitles parameters lack cd1")
 		// Or return an error:
 		// return nil, fmt.Errorf("tryUploadSubtitles: 'cd1' data not found in params.CDs")
 	}
@@ -328,6 +507,9 @@ func (c *xmlRpcClient) tryUploadSubtitles(params XmlRpcTryUploadParams) (*xmlRpc
 	if params.SubTranslator != "" {
 		baseInfoMap["subtranslator"] = params.SubTranslator
 	}
+	if params.AutomaticTranslation != "" {
+		baseInfoMap["automatictranslation"] = params.AutomaticTranslation
+	}
 	if params.ForeignPartsOnly != "" {
 		baseInfoMap["foreignpartsonly"] = params.ForeignPartsOnly
 	}
@@ -391,7 +573,7 @@ func (c *xmlRpcClient) uploadSubtitles(params XmlRpcUploadSubtitlesParams) (*xml
 	// 	 return nil, fmt.Errorf("missing 'cd1' data in UploadSubtitles parameters")
 	// }
 	if _, ok := params.CDs["cd1"]; !ok { // Just check existence if needed later, but not used now.
-		log.Println("[WARN] 'cd1' key missing in upload parameters, but proceedeing.")
+		c.log().Warn("xmlrpc: UploadSubtitles parameters lack cd1, proceeding")
 		// return nil, fmt.Errorf("missing 'cd1' data in UploadSubtitles parameters")
 	}
 
@@ -425,9 +607,9 @@ func (c *xmlRpcClient) uploadSubtitles(params XmlRpcUploadSubtitlesParams) (*xml
 			result.Data = data
 		} else if dataRaw, dataOK := v["data"]; dataOK && dataRaw == nil {
 			// Handle case where 'data' is present but null (might indicate failure despite 200 OK status text?)
-			log.Printf("[WARN] UploadSubtitles received 'data': <nil> with status: %s", result.Status)
+			c.log().Warn("xmlrpc: UploadSubtitles returned nil data", "status", result.Status)
 		} else {
-			log.Printf("[WARN] UploadSubtitles 'data' field missing or not a string: %T (%v)", dataRaw, dataRaw)
+			c.log().Warn("xmlrpc: UploadSubtitles data is missing or not a string", "type", fmt.Sprintf("%T", dataRaw), "data", dataRaw)
 		}
 
 		if subtitles, ok := v["subtitles"].(bool); ok {
@@ -439,18 +621,21 @@ func (c *xmlRpcClient) uploadSubtitles(params XmlRpcUploadSubtitlesParams) (*xml
 			result.Seconds = seconds
 		}
 		if result.Status != "200 OK" {
-			log.Printf("[ERROR] UploadSubtitles failed. Status: %s, Raw Response: %+v", result.Status, v)
+			c.log().Debug("xmlrpc: UploadSubtitles failed", "status", result.Status, "response", v)
+			if contentRejected(result.Status) {
+				return nil, fmt.Errorf("%w: %s", ErrContentRejected, result.Status)
+			}
 			return nil, fmt.Errorf("xmlrpc UploadSubtitles failed with status: %s", result.Status)
 		}
 		// Check if data URL is empty even if status is 200 OK
 		if result.Data == "" {
-			log.Printf("[WARN] UploadSubtitles status 200 OK but data URL is empty. Raw: %+v", v)
+			c.log().Warn("xmlrpc: UploadSubtitles succeeded without a subtitle URL", "response", v)
 			// Consider returning an error here if an empty URL always means failure
 			// return nil, fmt.Errorf("xmlrpc UploadSubtitles status 200 OK but data URL is empty")
 		}
 		return &result, nil
 	default:
-		log.Printf("[ERROR] Unexpected UploadSubtitles response type: %T, Value: %+v", rawResp, rawResp)
+		c.log().Debug("xmlrpc: unexpected UploadSubtitles response", "type", fmt.Sprintf("%T", rawResp), "response", rawResp)
 		return nil, fmt.Errorf("unexpected UploadSubtitles response type: %T (%v)", rawResp, rawResp)
 	}
 }
@@ -0,0 +1,53 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestNewXmlRpcUploaderWithOptions(t *testing.T) {
+	var hosts []string
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		hosts = append(hosts, req.URL.Host)
+		_, hasDeadline := req.Context().Deadline()
+		assert.True(t, hasDeadline, "calls are bounded by the timeout")
+		<-req.Context().Done()
+		return nil, req.Context().Err()
+	})
+	uploader, err := NewXmlRpcUploaderWithOptions(Options{Transport: transport, Timeout: 20 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	err = uploader.Login("user", "md5", "en", "")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Equal(t, []string{"api.opensubtitles.org:443"}, hosts, "the custom transport is used")
+}
+
+func TestUploaderHeader(t *testing.T) {
+	var received http.Header
+	transport := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		received = req.Header
+		return nil, errors.New("offline")
+	})
+	uploader, err := NewXmlRpcUploaderWithOptions(Options{
+		Transport: transport,
+		Header:    http.Header{"X-Request-ID": {"req-1"}, "Content-Type": {"application/json"}},
+	})
+	require.NoError(t, err)
+
+	_, err = uploader.ServerInfo()
+	assert.Error(t, err)
+	assert.Equal(t, "req-1", received.Get("X-Request-ID"))
+	assert.Equal(t, "text/xml", received.Get("Content-Type"), "reserved headers are never replaced")
+}
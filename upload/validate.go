@@ -0,0 +1,181 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/angelospk/opensubtitles-go/charset"
+	osdbhash "github.com/angelospk/opensubtitles-go/hash"
+	"github.com/angelospk/opensubtitles-go/languages"
+	"github.com/angelospk/opensubtitles-go/subfmt"
+)
+
+// ErrInvalidIntent is matched by the *ValidationError Upload returns for intents
+// with SeverityError issues.
+var ErrInvalidIntent = errors.New("invalid upload intent")
+
+// Severity ranks a ValidationIssue.
+type Severity int
+
+const (
+	// SeverityWarning marks issues the server may accept, e.g. an unrecognized
+	// subtitle format.
+	SeverityWarning Severity = iota
+	// SeverityError marks issues the upload fails with.
+	SeverityError
+)
+
+// String returns "warning" or "error".
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+	return "warning"
+}
+
+// ValidationIssue is a problem of a UserUploadIntent found by ValidateIntent.
+type ValidationIssue struct {
+	Field    string // UserUploadIntent field, e.g. "IMDBID"
+	Severity Severity
+	Message  string
+	Err      error // Underlying error, if any
+}
+
+// String returns the issue as "error: IMDBID: ...".
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s: %s: %s", i.Severity, i.Field, i.Message)
+}
+
+// ValidationError is returned by Upload when ValidateIntent reports errors. It
+// matches ErrInvalidIntent.
+type ValidationError struct {
+	Issues []ValidationIssue // All issues, warnings included
+}
+
+// Error lists the SeverityError issues.
+func (e *ValidationError) Error() string {
+	var msgs []string
+	for _, issue := range e.Issues {
+		if issue.Severity == SeverityError {
+			msgs = append(msgs, issue.Field+": "+issue.Message)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidIntent, strings.Join(msgs, "; "))
+}
+
+// Is reports whether target is ErrInvalidIntent.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidIntent
+}
+
+// Unwrap returns the underlying errors of the issues, e.g. to match
+// languages.ErrUnknownLanguage.
+func (e *ValidationError) Unwrap() []error {
+	var errs []error
+	for _, issue := range e.Issues {
+		if issue.Err != nil {
+			errs = append(errs, issue.Err)
+		}
+	}
+	return errs
+}
+
+// HasErrors reports whether issues contain a SeverityError issue.
+func HasErrors(issues []ValidationIssue) bool {
+	for _, issue := range issues {
+		if issue.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateIntent checks intent without any network call: the subtitle file exists,
+// its encoding can be decoded and it parses with at least one cue; the IMDb ID is
+// numeric; the language is in the languages catalog; the video file, if given, is
+// large enough for OSDb hashing; and the descriptive fields are within the limits of
+// Metadata.Validate. Without a video file, the language and IMDb ID are required.
+// Upload runs it before any call and fails with a *ValidationError on errors.
+func ValidateIntent(intent UserUploadIntent) []ValidationIssue {
+	var issues []ValidationIssue
+	add := func(field string, severity Severity, err error, format string, args ...interface{}) {
+		issues = append(issues, ValidationIssue{Field: field, Severity: severity, Message: fmt.Sprintf(format, args...), Err: err})
+	}
+
+	if intent.SubtitleFilePath == "" {
+		add("SubtitleFilePath", SeverityError, nil, "subtitle file path is required")
+	} else {
+		issues = append(issues, validateSubtitle(intent.SubtitleFilePath)...)
+	}
+
+	if intent.IMDBID != "" {
+		id := strings.TrimPrefix(strings.ToLower(intent.IMDBID), "tt")
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			add("IMDBID", SeverityError, nil, "IMDb ID %q is not numeric", intent.IMDBID)
+		}
+	}
+	if intent.LanguageID != "" {
+		if _, err := languages.ToISO3(intent.LanguageID); err != nil {
+			add("LanguageID", SeverityError, err, "unknown language %q", intent.LanguageID)
+		}
+	}
+
+	if intent.VideoFilePath != "" {
+		info, err := os.Stat(intent.VideoFilePath)
+		switch {
+		case err != nil:
+			add("VideoFilePath", SeverityError, err, "cannot read video file: %v", err)
+		case !info.Mode().IsRegular():
+			add("VideoFilePath", SeverityError, nil, "video file is not a regular file")
+		case info.Size() < 2*osdbhash.ChunkSize:
+			add("VideoFilePath", SeverityError, osdbhash.ErrFileTooSmall, "video file is %d bytes, OSDb hashing needs at least %d", info.Size(), 2*osdbhash.ChunkSize)
+		}
+	} else {
+		if intent.LanguageID == "" {
+			add("LanguageID", SeverityError, nil, "language ID is required without a video file")
+		}
+		if intent.IMDBID == "" {
+			add("IMDBID", SeverityError, nil, "IMDb ID is required without a video file")
+		}
+	}
+
+	if err := intent.Metadata().Validate(); err != nil {
+		add("Metadata", SeverityError, err, "%s", strings.TrimPrefix(err.Error(), ErrInvalidMetadata.Error()+": "))
+	}
+	return issues
+}
+
+// validateSubtitle checks that the subtitle file at path is readable and parses.
+func validateSubtitle(path string) []ValidationIssue {
+	issue := func(severity Severity, err error, format string, args ...interface{}) []ValidationIssue {
+		return []ValidationIssue{{Field: "SubtitleFilePath", Severity: severity, Message: fmt.Sprintf(format, args...), Err: err}}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return issue(SeverityError, err, "cannot read subtitle file: %v", err)
+	}
+	if len(data) == 0 {
+		return issue(SeverityError, nil, "subtitle file is empty")
+	}
+	decoded, enc, err := charset.ToUTF8(data)
+	if err != nil {
+		return issue(SeverityError, err, "cannot decode subtitle file: %v", err)
+	}
+	var issues []ValidationIssue
+	if n := strings.Count(string(decoded), "\uFFFD"); n > 0 {
+		issues = issue(SeverityWarning, nil, "%d characters cannot be decoded as %s", n, enc)
+	}
+	doc, err := subfmt.Parse(decoded)
+	switch {
+	case errors.Is(err, subfmt.ErrUnknownFormat):
+		// The server accepts formats subfmt does not parse, such as MicroDVD.
+		issues = append(issues, issue(SeverityWarning, err, "subtitle format not recognized")...)
+	case err != nil:
+		issues = append(issues, issue(SeverityError, err, "subtitle file does not parse: %v", err)...)
+	case len(doc.Cues) == 0:
+		issues = append(issues, issue(SeverityError, nil, "subtitle file has no cues")...)
+	}
+	return issues
+}
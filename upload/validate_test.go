@@ -0,0 +1,99 @@
+package upload
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	osdbhash "github.com/angelospk/opensubtitles-go/hash"
+	"github.com/angelospk/opensubtitles-go/languages"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fields returns "Severity Field" of each issue.
+func fields(issues []ValidationIssue) []string {
+	var out []string
+	for _, issue := range issues {
+		out = append(out, issue.Severity.String()+" "+issue.Field)
+	}
+	return out
+}
+
+func TestValidateIntent(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, data, 0o644))
+		return path
+	}
+	valid := UserUploadIntent{SubtitleFilePath: "../testdata/dummy.srt", IMDBID: "tt0113277", LanguageID: "en"}
+
+	t.Run("Valid", func(t *testing.T) {
+		assert.Empty(t, ValidateIntent(valid))
+	})
+
+	t.Run("Fields", func(t *testing.T) {
+		intent := valid
+		intent.IMDBID = "tt01132x7"
+		intent.LanguageID = "klingon"
+		intent.Comment = strings.Repeat("x", MaxCommentLength+1)
+		issues := ValidateIntent(intent)
+		assert.Equal(t, []string{"error IMDBID", "error LanguageID", "error Metadata"}, fields(issues))
+		assert.ErrorIs(t, issues[1].Err, languages.ErrUnknownLanguage)
+	})
+
+	t.Run("MissingEverything", func(t *testing.T) {
+		issues := ValidateIntent(UserUploadIntent{})
+		assert.Equal(t, []string{"error SubtitleFilePath", "error LanguageID", "error IMDBID"}, fields(issues))
+	})
+
+	t.Run("Subtitle", func(t *testing.T) {
+		tests := map[string]struct {
+			data []byte
+			want []string
+		}{
+			"Empty":         {nil, []string{"error SubtitleFilePath"}},
+			"NoCues":        {[]byte("WEBVTT\n\n"), []string{"error SubtitleFilePath"}},
+			"Broken":        {[]byte("1\n00:00:01,000 --> soon\nHello\n"), []string{"error SubtitleFilePath"}},
+			"UnknownFormat": {[]byte("{1}{25}Hello\n"), []string{"warning SubtitleFilePath"}},
+			"Greek":         {[]byte("1\n00:00:01,000 --> 00:00:02,000\n\xc3\xe5\xe9\xdc \xf3\xef\xf5\n"), nil},
+		}
+		for name, tt := range tests {
+			t.Run(name, func(t *testing.T) {
+				intent := valid
+				intent.SubtitleFilePath = write(name+".srt", tt.data)
+				assert.Equal(t, tt.want, fields(ValidateIntent(intent)))
+			})
+		}
+		intent := valid
+		intent.SubtitleFilePath = filepath.Join(dir, "missing.srt")
+		issues := ValidateIntent(intent)
+		assert.Equal(t, []string{"error SubtitleFilePath"}, fields(issues))
+		assert.ErrorIs(t, issues[0].Err, os.ErrNotExist)
+	})
+
+	t.Run("Video", func(t *testing.T) {
+		intent := UserUploadIntent{SubtitleFilePath: "../testdata/dummy.srt", VideoFilePath: write("small.mkv", make([]byte, 1024))}
+		issues := ValidateIntent(intent)
+		assert.Equal(t, []string{"error VideoFilePath"}, fields(issues), "language and IMDb ID are optional with a video")
+		assert.ErrorIs(t, issues[0].Err, osdbhash.ErrFileTooSmall)
+
+		intent.VideoFilePath = write("large.mkv", make([]byte, 2*osdbhash.ChunkSize))
+		assert.Empty(t, ValidateIntent(intent))
+	})
+}
+
+func TestUploadRejectsInvalidIntent(t *testing.T) {
+	var calls int
+	c := newTestClient(t, "<struct>"+member("status", "<string>200 OK</string>")+"</struct>", func(string) { calls++ })
+
+	_, err := c.Upload(UserUploadIntent{SubtitleFilePath: "../testdata/dummy.srt", IMDBID: "heat", LanguageID: "en"})
+	var validation *ValidationError
+	require.ErrorAs(t, err, &validation)
+	assert.ErrorIs(t, err, ErrInvalidIntent)
+	assert.Equal(t, []string{"error IMDBID"}, fields(validation.Issues))
+	assert.Contains(t, err.Error(), `IMDBID: IMDb ID "heat" is not numeric`)
+	assert.Zero(t, calls, "nothing is sent to the server")
+}
@@ -0,0 +1,35 @@
+package opensubtitles
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/angelospk/opensubtitles-go/languages"
+	"github.com/angelospk/opensubtitles-go/upload"
+)
+
+// ResolveUpload finds the REST subtitle of an XML-RPC upload among the newest
+// subtitles for result.IMDbID, by its opensubtitles.org ID, and fills
+// result.FeatureID. New uploads can take a while to appear in the REST API; until
+// then, and for results without a SubtitleID or IMDbID, the error matches ErrNotFound.
+func (c *Client) ResolveUpload(ctx context.Context, result *upload.UploadResult) (*Subtitle, error) {
+	if result.SubtitleID == 0 || result.IMDbID == 0 {
+		return nil, fmt.Errorf("%w: the upload result has no subtitle or IMDb ID", ErrNotFound)
+	}
+	orderBy, direction := OrderByUploadDate, SortDesc
+	params := SearchSubtitlesParams{IMDbID: &result.IMDbID, OrderBy: &orderBy, OrderDirection: &direction}
+	if code, err := languages.ToOSCode(result.Language); err == nil {
+		params.Languages = &code
+	}
+	resp, err := c.SearchSubtitles(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	for i, sub := range resp.Data {
+		if id := sub.Attributes.LegacySubtitleID; id != nil && *id == result.SubtitleID {
+			result.FeatureID = sub.Attributes.FeatureDetails.FeatureID
+			return &resp.Data[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: subtitle %d is not listed for IMDb ID %d yet", ErrNotFound, result.SubtitleID, result.IMDbID)
+}
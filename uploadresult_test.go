@@ -0,0 +1,34 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/angelospk/opensubtitles-go/upload"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveUpload(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/subtitles", r.URL.Path)
+		assert.Equal(t, "imdb_id=113277&languages=en&order_by=upload_date&order_direction=desc", r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"data":[
+			{"id":"1","attributes":{"legacy_subtitle_id":3580231,"feature_details":{"feature_id":10}}},
+			{"id":"2","attributes":{"legacy_subtitle_id":3580232,"feature_details":{"feature_id":11}}}]}`))
+	})
+	ctx := context.Background()
+
+	result := &upload.UploadResult{SubtitleID: 3580232, Language: "eng", IMDbID: 113277}
+	sub, err := client.ResolveUpload(ctx, result)
+	require.NoError(t, err)
+	assert.Equal(t, "2", sub.ID)
+	assert.Equal(t, 11, result.FeatureID)
+
+	_, err = client.ResolveUpload(ctx, &upload.UploadResult{SubtitleID: 3580233, Language: "eng", IMDbID: 113277})
+	assert.ErrorIs(t, err, ErrNotFound, "not listed yet")
+
+	_, err = client.ResolveUpload(ctx, &upload.UploadResult{URL: "http://www.opensubtitles.org/"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
@@ -1,8 +1,11 @@
 package opensubtitles
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
-// Methods related to utility endpoints (Guessit)
+// Methods related to utility and info endpoints (Guessit, Languages, Formats)
 
 // Guessit attempts to parse structured information (title, year, season, etc.)
 // from a filename using the OpenSubtitles guessit utility.
@@ -15,3 +18,49 @@ func (c *Client) Guessit(ctx context.Context, params GuessitParams) (*GuessitRes
 	}
 	return &response, nil
 }
+
+// GetLanguages retrieves the languages subtitles are available in.
+func (c *Client) GetLanguages(ctx context.Context) (*GetLanguagesResponse, error) {
+	var response GetLanguagesResponse
+	err := c.httpClient.Get(ctx, "/infos/languages", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GetSubtitleFormats retrieves the formats accepted by DownloadRequest.SubFormat.
+func (c *Client) GetSubtitleFormats(ctx context.Context) (*GetSubtitleFormatsResponse, error) {
+	var response GetSubtitleFormatsResponse
+	err := c.httpClient.Get(ctx, "/infos/formats", nil, &response)
+	if err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// IsValidLanguage reports whether code (compared case-insensitively) is in the list.
+func (r *GetLanguagesResponse) IsValidLanguage(code LanguageCode) bool {
+	for _, lang := range r.Data {
+		if strings.EqualFold(string(lang.LanguageCode), string(code)) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidLanguage reports whether the API supports code, e.g. before building
+// SearchSubtitlesParams.Languages. The language list is fetched on first use and
+// kept for the lifetime of the client; failed fetches are retried on the next call.
+func (c *Client) IsValidLanguage(ctx context.Context, code LanguageCode) (bool, error) {
+	c.languagesMu.Lock()
+	defer c.languagesMu.Unlock()
+	if c.languages == nil {
+		languages, err := c.GetLanguages(ctx)
+		if err != nil {
+			return false, err
+		}
+		c.languages = languages
+	}
+	return c.languages.IsValidLanguage(code), nil
+}
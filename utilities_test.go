@@ -6,6 +6,7 @@ import (
 	"net/http"
 
 	// "net/url"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -113,3 +114,61 @@ func TestGuessitError(t *testing.T) {
 // Helpers defined in features_test.go or common test file
 // func pint(i int) *int       { return &i }
 // func pstr(s string) *string { return &s }
+
+func TestGetLanguages(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		assert.Equal(t, http.MethodGet, r.Method)
+		assert.Equal(t, "/api/v1/infos/languages", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":[{"language_code":"en","language_name":"English"},{"language_code":"pt-BR","language_name":"Portuguese (BR)"}]}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	resp, err := client.GetLanguages(context.Background())
+	require.NoError(t, err)
+	require.Len(t, resp.Data, 2)
+	assert.Equal(t, Language{LanguageCode: "pt-BR", LanguageName: "Portuguese (BR)"}, resp.Data[1])
+	assert.True(t, resp.IsValidLanguage("pt-br"))
+	assert.False(t, resp.IsValidLanguage("xx"))
+
+	valid, err := client.IsValidLanguage(context.Background(), "EN")
+	require.NoError(t, err)
+	assert.True(t, valid)
+	valid, err = client.IsValidLanguage(context.Background(), "klingon")
+	require.NoError(t, err)
+	assert.False(t, valid)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls), "IsValidLanguage fetches the list once")
+}
+
+func TestIsValidLanguageError(t *testing.T) {
+	var calls int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":[{"language_code":"el","language_name":"Greek"}]}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	_, err := client.IsValidLanguage(context.Background(), "el")
+	require.ErrorIs(t, err, ErrServiceUnavailable)
+	valid, err := client.IsValidLanguage(context.Background(), "el")
+	require.NoError(t, err)
+	assert.True(t, valid, "a failed fetch is retried")
+}
+
+func TestGetSubtitleFormats(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/infos/formats", r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":{"output_formats":["srt","sub","mpl","webvtt","dfxp","txt"]}}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	resp, err := client.GetSubtitleFormats(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"srt", "sub", "mpl", "webvtt", "dfxp", "txt"}, resp.Data.OutputFormats)
+}
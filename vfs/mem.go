@@ -0,0 +1,292 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory FS, intended for tests. Native and slash-separated paths
+// are both accepted; a leading separator is ignored. The zero value is not usable,
+// create one with NewMemFS.
+type MemFS struct {
+	mu      sync.RWMutex
+	entries map[string]*memEntry // Keyed by cleaned slash path, "." is the root
+}
+
+type memEntry struct {
+	data    []byte
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+// Ensure MemFS implements FS.
+var _ FS = (*MemFS)(nil)
+
+// NewMemFS creates an empty in-memory file system.
+func NewMemFS() *MemFS {
+	return &MemFS{entries: map[string]*memEntry{
+		".": {mode: fs.ModeDir | 0o755, modTime: time.Now()},
+	}}
+}
+
+// memPath converts name to the key used in MemFS.entries.
+func memPath(name string) string {
+	p := path.Clean("/" + filepath.ToSlash(name))
+	if p == "/" {
+		return "."
+	}
+	return strings.TrimPrefix(p, "/")
+}
+
+func memParent(p string) string {
+	return path.Dir(p) // path.Dir("a") == "."
+}
+
+// Open opens the named file or directory for reading.
+func (m *MemFS) Open(name string) (fs.File, error) {
+	p := memPath(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.entries[p]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return &memFile{fsys: m, name: p, entry: entry}, nil
+}
+
+// Stat returns file info for the named file or directory.
+func (m *MemFS) Stat(name string) (fs.FileInfo, error) {
+	p := memPath(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.entries[p]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.info(p, entry), nil
+}
+
+// ReadDir lists the named directory sorted by filename.
+func (m *MemFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	p := memPath(name)
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, ok := m.entries[p]
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	if !entry.mode.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+	var list []fs.DirEntry
+	for key, child := range m.entries {
+		if key != "." && memParent(key) == p {
+			list = append(list, fs.FileInfoToDirEntry(m.info(key, child)))
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
+	return list, nil
+}
+
+// Create creates or truncates the named file. The parent directory must exist.
+func (m *MemFS) Create(name string) (File, error) {
+	p := memPath(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	parent, ok := m.entries[memParent(p)]
+	if !ok || !parent.mode.IsDir() {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: fs.ErrNotExist}
+	}
+	if existing, ok := m.entries[p]; ok && existing.mode.IsDir() {
+		return nil, &fs.PathError{Op: "create", Path: name, Err: errors.New("is a directory")}
+	}
+	entry := &memEntry{mode: 0o644, modTime: time.Now()}
+	m.entries[p] = entry
+	return &memFile{fsys: m, name: p, entry: entry, writable: true}, nil
+}
+
+// MkdirAll creates a directory and any missing parents.
+func (m *MemFS) MkdirAll(name string, perm fs.FileMode) error {
+	p := memPath(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := p; dir != "."; dir = memParent(dir) {
+		if existing, ok := m.entries[dir]; ok {
+			if !existing.mode.IsDir() {
+				return &fs.PathError{Op: "mkdir", Path: name, Err: errors.New("not a directory")}
+			}
+			continue
+		}
+		m.entries[dir] = &memEntry{mode: fs.ModeDir | perm.Perm(), modTime: time.Now()}
+	}
+	return nil
+}
+
+// Remove removes a file or an empty directory.
+func (m *MemFS) Remove(name string) error {
+	p := memPath(name)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[p]
+	if !ok || p == "." {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	if entry.mode.IsDir() {
+		for key := range m.entries {
+			if key != "." && memParent(key) == p {
+				return &fs.PathError{Op: "remove", Path: name, Err: errors.New("directory not empty")}
+			}
+		}
+	}
+	delete(m.entries, p)
+	return nil
+}
+
+// Rename moves a file, replacing the destination if it is a file.
+func (m *MemFS) Rename(oldpath, newpath string) error {
+	oldP, newP := memPath(oldpath), memPath(newpath)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	entry, ok := m.entries[oldP]
+	if !ok {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: fs.ErrNotExist}
+	}
+	if entry.mode.IsDir() {
+		return &fs.PathError{Op: "rename", Path: oldpath, Err: errors.New("renaming directories is not supported")}
+	}
+	if parent, ok := m.entries[memParent(newP)]; !ok || !parent.mode.IsDir() {
+		return &fs.PathError{Op: "rename", Path: newpath, Err: fs.ErrNotExist}
+	}
+	delete(m.entries, oldP)
+	m.entries[newP] = entry
+	return nil
+}
+
+// info builds a FileInfo snapshot. Caller must hold m.mu.
+func (m *MemFS) info(p string, entry *memEntry) fs.FileInfo {
+	return &memInfo{name: path.Base(p), size: int64(len(entry.data)), mode: entry.mode, modTime: entry.modTime}
+}
+
+// memFile is an open handle on a MemFS entry.
+type memFile struct {
+	fsys     *MemFS
+	name     string
+	entry    *memEntry
+	offset   int64
+	writable bool
+	closed   bool
+
+	dirEntries []fs.DirEntry // Listing snapshot for ReadDir on directory handles
+	dirOffset  int
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) {
+	f.fsys.mu.RLock()
+	defer f.fsys.mu.RUnlock()
+	return f.fsys.info(f.name, f.entry), nil
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	n, err := f.ReadAt(p, f.offset)
+	f.offset += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memFile) ReadAt(p []byte, off int64) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if off < 0 {
+		return 0, &fs.PathError{Op: "readat", Path: f.name, Err: fs.ErrInvalid}
+	}
+	f.fsys.mu.RLock()
+	defer f.fsys.mu.RUnlock()
+	if f.entry.mode.IsDir() {
+		return 0, &fs.PathError{Op: "read", Path: f.name, Err: errors.New("is a directory")}
+	}
+	if off >= int64(len(f.entry.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.entry.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.closed {
+		return 0, fs.ErrClosed
+	}
+	if !f.writable {
+		return 0, &fs.PathError{Op: "write", Path: f.name, Err: fs.ErrPermission}
+	}
+	f.fsys.mu.Lock()
+	defer f.fsys.mu.Unlock()
+	f.entry.data = append(f.entry.data, p...)
+	f.entry.modTime = time.Now()
+	return len(p), nil
+}
+
+// ReadDir makes directory handles satisfy fs.ReadDirFile.
+func (f *memFile) ReadDir(n int) ([]fs.DirEntry, error) {
+	if f.closed {
+		return nil, fs.ErrClosed
+	}
+	if f.dirEntries == nil {
+		entries, err := f.fsys.ReadDir(f.name)
+		if err != nil {
+			return nil, err
+		}
+		f.dirEntries = entries
+	}
+	remaining := f.dirEntries[f.dirOffset:]
+	if n <= 0 {
+		f.dirOffset = len(f.dirEntries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(remaining) {
+		n = len(remaining)
+	}
+	f.dirOffset += n
+	return remaining[:n], nil
+}
+
+func (f *memFile) Close() error {
+	if f.closed {
+		return fs.ErrClosed
+	}
+	f.closed = true
+	return nil
+}
+
+// memInfo implements fs.FileInfo.
+type memInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func (i *memInfo) Name() string       { return i.name }
+func (i *memInfo) Size() int64        { return i.size }
+func (i *memInfo) Mode() fs.FileMode  { return i.mode }
+func (i *memInfo) ModTime() time.Time { return i.modTime }
+func (i *memInfo) IsDir() bool        { return i.mode.IsDir() }
+func (i *memInfo) Sys() any           { return nil }
@@ -0,0 +1,113 @@
+package vfs
+
+import (
+	"io"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemFS(t *testing.T) {
+	t.Run("WalkDir", func(t *testing.T) {
+		m := NewMemFS()
+		require.NoError(t, m.MkdirAll("movies/Inception (2010)", 0o755))
+		require.NoError(t, WriteFile(m, "movies/Inception (2010)/Inception.mkv", []byte("video")))
+		require.NoError(t, WriteFile(m, "movies/Inception (2010)/Inception.en.srt", []byte("subs")))
+
+		var walked []string
+		err := fs.WalkDir(m, ".", func(path string, d fs.DirEntry, err error) error {
+			require.NoError(t, err)
+			walked = append(walked, path)
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, []string{
+			".",
+			"movies",
+			"movies/Inception (2010)",
+			"movies/Inception (2010)/Inception.en.srt",
+			"movies/Inception (2010)/Inception.mkv",
+		}, walked)
+	})
+
+	t.Run("NativeAndSlashPathsAreEquivalent", func(t *testing.T) {
+		m := NewMemFS()
+		require.NoError(t, m.MkdirAll("/data/subs", 0o755))
+		require.NoError(t, WriteFile(m, "/data/subs/a.srt", []byte("hello")))
+
+		data, err := fs.ReadFile(m, "data/subs/a.srt")
+		require.NoError(t, err)
+		assert.Equal(t, "hello", string(data))
+	})
+
+	t.Run("CreateRequiresParent", func(t *testing.T) {
+		m := NewMemFS()
+		_, err := m.Create("missing/a.srt")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+	})
+
+	t.Run("ReadAtAndWrite", func(t *testing.T) {
+		m := NewMemFS()
+		f, err := m.Create("a.bin")
+		require.NoError(t, err)
+		_, err = f.Write([]byte("0123456789"))
+		require.NoError(t, err)
+		require.NoError(t, f.Close())
+
+		r, err := m.Open("a.bin")
+		require.NoError(t, err)
+		buf := make([]byte, 4)
+		n, err := r.(io.ReaderAt).ReadAt(buf, 6)
+		require.NoError(t, err)
+		assert.Equal(t, "6789", string(buf[:n]))
+
+		_, err = r.(io.Writer).Write([]byte("x"))
+		assert.ErrorIs(t, err, fs.ErrPermission, "handles from Open are read-only")
+	})
+
+	t.Run("RenameAndRemove", func(t *testing.T) {
+		m := NewMemFS()
+		require.NoError(t, m.MkdirAll("dir", 0o755))
+		require.NoError(t, WriteFile(m, "dir/tmp.srt", []byte("x")))
+		require.NoError(t, m.Rename("dir/tmp.srt", "dir/final.srt"))
+
+		_, err := m.Stat("dir/tmp.srt")
+		assert.ErrorIs(t, err, fs.ErrNotExist)
+		info, err := m.Stat("dir/final.srt")
+		require.NoError(t, err)
+		assert.EqualValues(t, 1, info.Size())
+
+		assert.Error(t, m.Remove("dir"), "non-empty directory")
+		require.NoError(t, m.Remove("dir/final.srt"))
+		require.NoError(t, m.Remove("dir"))
+	})
+}
+
+func TestCreateTemp(t *testing.T) {
+	for name, fsys := range map[string]FS{"Mem": NewMemFS(), "OS": OS} {
+		t.Run(name, func(t *testing.T) {
+			dir := "subs"
+			if fsys == OS {
+				dir = t.TempDir()
+			} else {
+				require.NoError(t, fsys.MkdirAll(dir, 0o755))
+			}
+			f1, name1, err := CreateTemp(fsys, dir, ".a.srt.*.part")
+			require.NoError(t, err)
+			f2, name2, err := CreateTemp(fsys, dir, ".a.srt.*.part")
+			require.NoError(t, err)
+			assert.NotEqual(t, name1, name2)
+			assert.Regexp(t, `\.a\.srt\.\w+\.part$`, name1)
+
+			_, err = f1.Write([]byte("one"))
+			require.NoError(t, err)
+			require.NoError(t, f1.Close())
+			require.NoError(t, f2.Close())
+			data, err := fs.ReadFile(fsys, name1)
+			require.NoError(t, err)
+			assert.Equal(t, "one", string(data))
+		})
+	}
+}
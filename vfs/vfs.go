@@ -0,0 +1,108 @@
+// Package vfs defines the file-system abstraction used by the library for disk access.
+//
+// FS extends io/fs.FS with the write operations needed by downloaders, hashing and
+// state persistence, in the spirit of afero. OS is backed by the real file system
+// and accepts native paths; MemFS keeps everything in memory for tests.
+package vfs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File is an open file handle returned by FS.Open or FS.Create.
+type File interface {
+	fs.File
+	io.ReaderAt
+	io.Writer
+}
+
+// FS is a writable file system. It satisfies fs.FS, fs.StatFS and fs.ReadDirFS,
+// so it can be passed to fs.WalkDir, fs.ReadFile and friends.
+type FS interface {
+	fs.StatFS
+	fs.ReadDirFS
+	// Create creates or truncates the named file and opens it for writing.
+	Create(name string) (File, error)
+	MkdirAll(path string, perm fs.FileMode) error
+	Remove(name string) error
+	Rename(oldpath, newpath string) error
+}
+
+// OS is the FS backed by the operating system. Paths are native paths, not
+// slash-separated fs.FS paths, so absolute paths work as with the os package.
+var OS FS = osFS{}
+
+type osFS struct{}
+
+func (osFS) Open(name string) (fs.File, error) { return os.Open(name) }
+
+func (osFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (osFS) ReadDir(name string) ([]fs.DirEntry, error) { return os.ReadDir(name) }
+
+func (osFS) Create(name string) (File, error) { return os.Create(name) }
+
+func (osFS) MkdirAll(path string, perm fs.FileMode) error { return os.MkdirAll(path, perm) }
+
+func (osFS) Remove(name string) error { return os.Remove(name) }
+
+func (osFS) Rename(oldpath, newpath string) error { return os.Rename(oldpath, newpath) }
+
+// WriteFile writes data to name in fsys, creating or truncating the file.
+func WriteFile(fsys FS, name string, data []byte) error {
+	f, err := fsys.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// CreateTemp creates a new file in dir with a unique name built from pattern, whose
+// last "*" is replaced by a random string, and opens it for writing. It returns the
+// file and its name. Like Create, the file gets mode 0666 before the umask; on OS it
+// is created exclusively, so concurrent callers never share a file.
+func CreateTemp(fsys FS, dir, pattern string) (File, string, error) {
+	prefix, suffix := pattern, ""
+	if i := strings.LastIndex(pattern, "*"); i >= 0 {
+		prefix, suffix = pattern[:i], pattern[i+1:]
+	}
+	for try := 0; try < 10; try++ {
+		var random [8]byte
+		if _, err := rand.Read(random[:]); err != nil {
+			return nil, "", err
+		}
+		name := filepath.Join(dir, prefix+hex.EncodeToString(random[:])+suffix)
+		if _, ok := fsys.(osFS); ok {
+			// Unlike os.CreateTemp, which always uses 0600, so that other users, e.g. a
+			// media server, can read the files renamed into place.
+			f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0o666)
+			if errors.Is(err, fs.ErrExist) {
+				continue
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			return f, name, nil
+		}
+		if _, err := fsys.Stat(name); !errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		f, err := fsys.Create(name)
+		if err != nil {
+			return nil, "", err
+		}
+		return f, name, nil
+	}
+	return nil, "", &fs.PathError{Op: "createtemp", Path: filepath.Join(dir, pattern), Err: fs.ErrExist}
+}
@@ -0,0 +1,73 @@
+package opensubtitles
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/angelospk/opensubtitles-go/hash"
+)
+
+// VideoFileSearch is the result of SearchByVideoFile.
+type VideoFileSearch struct {
+	*SearchSubtitlesResponse
+	MovieHash string // Empty if the file is too small to hash
+	FileSize  int64
+	// ByHash reports whether the results are moviehash matches. If false, the file
+	// name was searched as described by Guess.
+	ByHash bool
+	Guess  *GuessitResponse
+}
+
+// SearchByVideoFile searches subtitles for the video file at path in the given
+// languages (all if empty). It first searches by the file's OSDb hash, returning only
+// moviehash matches. If there are none, it guesses the title, year, season and episode
+// from the file name with Guessit, falling back to ParseReleaseName when the API is
+// unavailable, and searches for those instead.
+func (c *Client) SearchByVideoFile(ctx context.Context, path string, languages []LanguageCode) (*VideoFileSearch, error) {
+	movieHash, size, err := hash.ComputeOSDbHash(path)
+	if err != nil && !errors.Is(err, hash.ErrFileTooSmall) {
+		return nil, err
+	}
+	result := &VideoFileSearch{MovieHash: movieHash, FileSize: size}
+
+	base := SearchSubtitlesParams{}
+	if langs := languagesParam(languages); langs != "" {
+		base.Languages = &langs
+	}
+	if movieHash != "" {
+		params := base
+		only := "only"
+		params.Moviehash, params.MoviehashMatch = &movieHash, &only
+		resp, err := c.SearchSubtitles(ctx, params)
+		if err != nil {
+			return nil, err
+		}
+		if len(resp.Data) > 0 {
+			result.SearchSubtitlesResponse, result.ByHash = resp, true
+			return result, nil
+		}
+	}
+
+	name := filepath.Base(path)
+	guessed := c.guessFilename(ctx, name, GuessitBatchOptions{})
+	if guessed.Err != nil {
+		return nil, fmt.Errorf("failed to guess '%s': %w", name, guessed.Err)
+	}
+	guess := guessed.Guess
+	if guess.Title == nil || *guess.Title == "" {
+		return nil, fmt.Errorf("%w: no title found in '%s'", ErrNoSubtitleFound, name)
+	}
+	params := base
+	params.Query = guess.Title
+	params.Year = guess.Year
+	params.SeasonNumber = guess.Season
+	params.EpisodeNumber = guess.Episode
+	resp, err := c.SearchSubtitles(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	result.SearchSubtitlesResponse, result.Guess = resp, guess
+	return result, nil
+}
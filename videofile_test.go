@@ -0,0 +1,110 @@
+package opensubtitles
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// copyVideo copies testdata/video.mkv to a temporary file named name.
+func copyVideo(t *testing.T, name string) string {
+	data, err := os.ReadFile("testdata/video.mkv")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, data, 0o644))
+	return path
+}
+
+func TestSearchByVideoFileHashMatch(t *testing.T) {
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/subtitles", r.URL.Path)
+		query := r.URL.Query()
+		assert.Equal(t, "a2b51e055b718161", query.Get("moviehash"))
+		assert.Equal(t, "only", query.Get("moviehash_match"))
+		assert.Equal(t, "el,en", query.Get("languages"))
+		_, _ = w.Write([]byte(`{"total_count":1,"data":[{"id":"7","attributes":{"moviehash_match":true}}]}`))
+	}
+	_, client := setupTestServer(t, handler)
+
+	result, err := client.SearchByVideoFile(context.Background(), "testdata/video.mkv", []LanguageCode{"en", "EL"})
+	require.NoError(t, err)
+	assert.True(t, result.ByHash)
+	assert.Nil(t, result.Guess)
+	assert.Equal(t, "a2b51e055b718161", result.MovieHash)
+	assert.Equal(t, int64(345108), result.FileSize)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, "7", result.Data[0].ID)
+}
+
+func TestSearchByVideoFileFallsBackToGuessit(t *testing.T) {
+	path := copyVideo(t, "Breaking.Bad.S02E02.720p.HDTV.x264-GRP.mkv")
+	var queries []string
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v1/utilities/guessit":
+			assert.Equal(t, "Breaking.Bad.S02E02.720p.HDTV.x264-GRP.mkv", query.Get("filename"))
+			_, _ = w.Write([]byte(`{"title":"Breaking Bad","season":2,"episode":2,"type":"episode"}`))
+		case "/api/v1/subtitles":
+			queries = append(queries, r.URL.RawQuery)
+			if query.Get("moviehash") != "" {
+				_, _ = w.Write([]byte(`{"total_count":0,"data":[]}`))
+				return
+			}
+			assert.Equal(t, "Breaking Bad", query.Get("query"))
+			assert.Equal(t, "2", query.Get("season_number"))
+			assert.Equal(t, "2", query.Get("episode_number"))
+			_, _ = w.Write([]byte(`{"total_count":1,"data":[{"id":"8","attributes":{}}]}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL.Path)
+		}
+	}
+	_, client := setupTestServer(t, handler)
+
+	result, err := client.SearchByVideoFile(context.Background(), path, nil)
+	require.NoError(t, err)
+	assert.Len(t, queries, 2)
+	assert.False(t, result.ByHash)
+	require.NotNil(t, result.Guess)
+	assert.Equal(t, "Breaking Bad", *result.Guess.Title)
+	require.Len(t, result.Data, 1)
+	assert.Equal(t, "8", result.Data[0].ID)
+}
+
+func TestSearchByVideoFileTooSmallToHash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Heat.1995.1080p.BluRay.mkv")
+	require.NoError(t, os.WriteFile(path, []byte("tiny"), 0o644))
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/utilities/guessit":
+			w.WriteHeader(http.StatusBadGateway)
+		case "/api/v1/subtitles":
+			query := r.URL.Query()
+			assert.Empty(t, query.Get("moviehash"))
+			assert.Equal(t, "Heat", query.Get("query"))
+			assert.Equal(t, "1995", query.Get("year"))
+			_, _ = w.Write([]byte(`{"total_count":0,"data":[]}`))
+		}
+	}
+	_, client := setupTestServer(t, handler)
+
+	result, err := client.SearchByVideoFile(context.Background(), path, nil)
+	require.NoError(t, err)
+	assert.Empty(t, result.MovieHash)
+	assert.Equal(t, "Heat", *result.Guess.Title, "parsed locally when Guessit is unavailable")
+	assert.Empty(t, result.Data)
+}
+
+func TestSearchByVideoFileMissing(t *testing.T) {
+	_, client := setupTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Error("no request expected")
+	})
+
+	_, err := client.SearchByVideoFile(context.Background(), filepath.Join(t.TempDir(), "missing.mkv"), nil)
+	assert.ErrorIs(t, err, os.ErrNotExist)
+}
@@ -0,0 +1,370 @@
+// Package watcher watches directories for new videos and downloads the best matching
+// subtitle for each, in every wanted language, as a sidecar file next to the video.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/envconfig"
+	"github.com/angelospk/opensubtitles-go/hash"
+	"github.com/angelospk/opensubtitles-go/internal/logging"
+	"github.com/angelospk/opensubtitles-go/naming"
+	"github.com/angelospk/opensubtitles-go/scanner"
+)
+
+// Defaults of Options.
+const (
+	DefaultPollInterval  = 5 * time.Second
+	DefaultSettleTime    = 10 * time.Second
+	DefaultRetryInterval = time.Minute
+)
+
+// maxRetryShift caps the doubling of Options.RetryInterval, at 64 times the interval.
+const maxRetryShift = 6
+
+// ErrNoDirs is returned by New when Options.Dirs is empty.
+var ErrNoDirs = errors.New("watcher: no directories to watch")
+
+// Client is the part of *opensubtitles.Client used by the Watcher.
+type Client interface {
+	FindBestSubtitle(ctx context.Context, video opensubtitles.VideoQuery, prefs opensubtitles.MatchPreferences) (*opensubtitles.SubtitleMatch, error)
+	DownloadToFile(ctx context.Context, req opensubtitles.DownloadRequest, destPath string, opts opensubtitles.DownloadToFileOptions) (*opensubtitles.DownloadToFileResult, error)
+}
+
+// Ensure the client can be used by the Watcher.
+var _ Client = (*opensubtitles.Client)(nil)
+
+// Options configures a Watcher.
+type Options struct {
+	// Dirs are watched recursively. Directories named "sample" or starting with "."
+	// are skipped.
+	Dirs []string
+	// Languages are the wanted subtitle languages; a sidecar is downloaded per language.
+	Languages []opensubtitles.LanguageCode
+	// Template names the sidecar in the video's directory (default:
+	// naming.DefaultTemplate). {title}, {year}, {season} and {episode} are guessed
+	// from the video's file name with opensubtitles.ParseReleaseName; {hi} and
+	// {forced} follow Preferences.
+	Template naming.Template
+	// Preferences tune the ranking; its Languages are replaced by each wanted language.
+	Preferences opensubtitles.MatchPreferences
+	// Download is passed to Client.DownloadToFile for every sidecar. Without
+	// Download.Collisions, existing sidecars are kept and not searched for.
+	Download opensubtitles.DownloadToFileOptions
+	// PollInterval is how often the directories are scanned (default: DefaultPollInterval).
+	PollInterval time.Duration
+	// SettleTime is how long the size and modification time of a new video must stay
+	// unchanged before it is processed, so that files still being copied are not
+	// hashed (default: DefaultSettleTime).
+	SettleTime time.Duration
+	// MinVideoSize skips smaller video files, e.g. samples and trailers.
+	MinVideoSize int64
+	// RetryInterval is how long to wait before searching again for the languages of a
+	// video that failed, e.g. after a network error, an exhausted download quota or
+	// when no subtitle exists yet. It doubles with every failure of the video, up to 64
+	// times the interval (default: DefaultRetryInterval).
+	RetryInterval time.Duration
+	// ProcessExisting also processes the videos present when Run starts. By default
+	// only videos that appear later are.
+	ProcessExisting bool
+	// Changes, if set, triggers an immediate scan for every value received, e.g. the
+	// paths reported by a file system notifier such as fsnotify. Polling continues.
+	Changes <-chan string
+	// OnResult, if set, is called with the outcome of every language of every video.
+	OnResult func(Result)
+	// Logger receives a debug record per video and a warning per failure. Nil
+	// disables logging.
+	Logger *slog.Logger
+}
+
+// OptionsFromEnv reads the Options that can be expressed as text from the variables
+// of l: WATCH_DIRS and LANGUAGES (comma-separated lists), WATCH_POLL_INTERVAL,
+// WATCH_SETTLE_TIME and WATCH_RETRY_INTERVAL (durations), WATCH_MIN_VIDEO_SIZE (bytes)
+// and WATCH_PROCESS_EXISTING. Unset variables keep the defaults.
+func OptionsFromEnv(l *envconfig.Loader) Options {
+	opts := Options{
+		Dirs:            l.List("WATCH_DIRS", nil),
+		PollInterval:    l.Duration("WATCH_POLL_INTERVAL", 0),
+		SettleTime:      l.Duration("WATCH_SETTLE_TIME", 0),
+		RetryInterval:   l.Duration("WATCH_RETRY_INTERVAL", 0),
+		MinVideoSize:    int64(l.Int("WATCH_MIN_VIDEO_SIZE", 0)),
+		ProcessExisting: l.Bool("WATCH_PROCESS_EXISTING", false),
+	}
+	for _, language := range l.List("LANGUAGES", nil) {
+		opts.Languages = append(opts.Languages, opensubtitles.LanguageCode(language))
+	}
+	return opts
+}
+
+// Result is the outcome of fetching one sidecar.
+type Result struct {
+	VideoPath string
+	Language  opensubtitles.LanguageCode
+	Path      string // Sidecar path
+	// Match is the subtitle downloaded; nil if the sidecar was kept or an error occurred.
+	Match   *opensubtitles.SubtitleMatch
+	Skipped bool // The existing sidecar was kept
+	Err     error
+}
+
+// Watcher downloads subtitles for videos appearing in the watched directories.
+type Watcher struct {
+	client Client
+	opts   Options
+
+	pending map[string]pendingFile // New videos waiting to settle
+	done    map[string]bool        // Videos processed or present at start
+	retries map[string]*retryState // Videos with languages that failed
+
+	now func() time.Time
+}
+
+// pendingFile is the last observed state of a video that is not settled yet.
+type pendingFile struct {
+	size    int64
+	modTime time.Time
+	since   time.Time // When size and modTime were first observed
+}
+
+// retryState tracks the languages of a video that failed.
+type retryState struct {
+	languages []opensubtitles.LanguageCode // Languages still missing
+	failures  int                          // Consecutive failed attempts
+	next      time.Time                    // When to try again
+}
+
+// New creates a Watcher. It does not touch the directories until Run.
+func New(client Client, opts Options) (*Watcher, error) {
+	if len(opts.Dirs) == 0 {
+		return nil, ErrNoDirs
+	}
+	if len(opts.Languages) == 0 {
+		return nil, errors.New("watcher: no languages given")
+	}
+	if opts.Template == "" {
+		opts.Template = naming.DefaultTemplate
+	}
+	if err := opts.Template.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = DefaultPollInterval
+	}
+	if opts.SettleTime <= 0 {
+		opts.SettleTime = DefaultSettleTime
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = DefaultRetryInterval
+	}
+	opts.Logger = logging.OrDiscard(opts.Logger)
+	return &Watcher{
+		client:  client,
+		opts:    opts,
+		pending: make(map[string]pendingFile),
+		done:    make(map[string]bool),
+		retries: make(map[string]*retryState),
+		now:     time.Now,
+	}, nil
+}
+
+// Run watches the directories until ctx ends and returns ctx.Err(). Failures of
+// single videos are reported to Options.OnResult and logged, not returned.
+func (w *Watcher) Run(ctx context.Context) error {
+	if !w.opts.ProcessExisting {
+		for _, path := range w.videos() {
+			w.done[path] = true
+		}
+	}
+	ticker := time.NewTicker(w.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		w.poll(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		case <-w.opts.Changes:
+		}
+	}
+}
+
+// poll scans the directories once, processes the videos that have settled and retries
+// the failed languages that are due.
+func (w *Watcher) poll(ctx context.Context) {
+	now := w.now()
+	seen := make(map[string]bool)
+	for _, path := range w.videos() {
+		seen[path] = true
+		if w.done[path] {
+			continue
+		}
+		if retry, ok := w.retries[path]; ok {
+			if !now.Before(retry.next) && ctx.Err() == nil {
+				w.finish(path, w.process(ctx, path, retry.languages), retry.failures, now)
+			}
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		last, ok := w.pending[path]
+		if !ok || last.size != info.Size() || !last.modTime.Equal(info.ModTime()) {
+			w.pending[path] = pendingFile{size: info.Size(), modTime: info.ModTime(), since: now}
+			continue
+		}
+		if now.Sub(last.since) < w.opts.SettleTime {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		delete(w.pending, path)
+		if info.Size() < w.opts.MinVideoSize {
+			w.done[path] = true
+			continue
+		}
+		w.finish(path, w.process(ctx, path, w.opts.Languages), 0, now)
+	}
+	// Forget deleted videos, so they are processed again if they come back.
+	for path := range w.pending {
+		if !seen[path] {
+			delete(w.pending, path)
+		}
+	}
+	for path := range w.done {
+		if !seen[path] {
+			delete(w.done, path)
+		}
+	}
+	for path := range w.retries {
+		if !seen[path] {
+			delete(w.retries, path)
+		}
+	}
+}
+
+// finish marks the video at path done if no language failed, and otherwise schedules
+// a retry of the failed languages, backing off with the number of failures.
+func (w *Watcher) finish(path string, failed []opensubtitles.LanguageCode, failures int, now time.Time) {
+	if len(failed) == 0 {
+		delete(w.retries, path)
+		w.done[path] = true
+		return
+	}
+	failures++
+	wait := w.opts.RetryInterval << min(failures-1, maxRetryShift)
+	w.retries[path] = &retryState{languages: failed, failures: failures, next: now.Add(wait)}
+	w.opts.Logger.Debug("watcher: retrying video later", "path", path, "languages", failed, "at", now.Add(wait))
+}
+
+// videos lists the video files in the watched directories.
+func (w *Watcher) videos() []string {
+	var paths []string
+	for _, dir := range w.opts.Dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if d.IsDir() {
+				name := d.Name()
+				if path != dir && (strings.HasPrefix(name, ".") || strings.EqualFold(name, "sample")) {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.Type().IsRegular() && scanner.IsVideoFile(d.Name()) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			w.opts.Logger.Warn("watcher: failed to scan directory", "dir", dir, "error", err)
+		}
+	}
+	return paths
+}
+
+// process fetches the sidecars of the video at path in languages and returns the
+// languages that failed.
+func (w *Watcher) process(ctx context.Context, path string, languages []opensubtitles.LanguageCode) []opensubtitles.LanguageCode {
+	w.opts.Logger.Debug("watcher: processing video", "path", path)
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	guess := opensubtitles.ParseReleaseName(filepath.Base(path))
+	video := opensubtitles.VideoQuery{Query: base, ReleaseName: base}
+	if guess.Title != nil {
+		video.Query = *guess.Title
+	}
+	if guess.Year != nil {
+		video.Year = *guess.Year
+	}
+	if guess.Season != nil && guess.Episode != nil {
+		video.SeasonNumber, video.EpisodeNumber = *guess.Season, *guess.Episode
+	}
+	movieHash, _, err := hash.ComputeOSDbHash(path)
+	if err != nil && !errors.Is(err, hash.ErrFileTooSmall) {
+		w.opts.Logger.Warn("watcher: failed to hash video, searching by name", "path", path, "error", err)
+	}
+	video.MovieHash = movieHash
+
+	prefs := w.opts.Preferences
+	fields := naming.Fields{
+		Basename: base,
+		Title:    video.Query,
+		Year:     video.Year,
+		Season:   video.SeasonNumber,
+		Episode:  video.EpisodeNumber,
+		HI:       prefs.HearingImpaired != nil && *prefs.HearingImpaired,
+		Forced:   prefs.ForeignPartsOnly != nil && *prefs.ForeignPartsOnly,
+	}
+	var failed []opensubtitles.LanguageCode
+	for _, lang := range languages {
+		result := Result{VideoPath: path, Language: lang}
+		fields.Lang = string(lang)
+		if result.Path, result.Err = w.opts.Template.Sidecar(path, fields); result.Err == nil {
+			result.Match, result.Skipped, result.Err = w.fetch(ctx, video, lang, &result.Path)
+		}
+		if result.Err != nil {
+			failed = append(failed, lang)
+			w.opts.Logger.Warn("watcher: failed to fetch subtitle", "path", path, "language", lang, "error", result.Err)
+		}
+		if w.opts.OnResult != nil {
+			w.opts.OnResult(result)
+		}
+	}
+	return failed
+}
+
+// fetch downloads the best subtitle of video in lang to *dest, updating it to the path
+// written. Without Options.Download.Collisions an existing *dest is kept.
+func (w *Watcher) fetch(ctx context.Context, video opensubtitles.VideoQuery, lang opensubtitles.LanguageCode, dest *string) (*opensubtitles.SubtitleMatch, bool, error) {
+	if w.opts.Download.Collisions == nil {
+		if _, err := os.Stat(*dest); err == nil {
+			return nil, true, nil
+		}
+	}
+	prefs := w.opts.Preferences
+	prefs.Languages = []opensubtitles.LanguageCode{lang}
+	match, err := w.client.FindBestSubtitle(ctx, video, prefs)
+	if err != nil {
+		return nil, false, err
+	}
+	written, err := w.client.DownloadToFile(ctx, opensubtitles.DownloadRequest{FileID: match.FileID}, *dest, w.opts.Download)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to download file %d: %w", match.FileID, err)
+	}
+	*dest = written.Path
+	if written.Skipped {
+		return nil, true, nil
+	}
+	return match, false, nil
+}
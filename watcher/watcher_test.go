@@ -0,0 +1,210 @@
+package watcher
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	opensubtitles "github.com/angelospk/opensubtitles-go"
+	"github.com/angelospk/opensubtitles-go/envconfig"
+	"github.com/angelospk/opensubtitles-go/naming"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClient finds file 100 for every video and writes the sidecars.
+type fakeClient struct {
+	queries   []opensubtitles.VideoQuery
+	languages []opensubtitles.LanguageCode
+	downloads []string
+	errs      []error // Returned by the next searches
+}
+
+func (c *fakeClient) FindBestSubtitle(ctx context.Context, video opensubtitles.VideoQuery, prefs opensubtitles.MatchPreferences) (*opensubtitles.SubtitleMatch, error) {
+	c.queries = append(c.queries, video)
+	c.languages = append(c.languages, prefs.Languages...)
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return nil, err
+	}
+	if prefs.Languages[0] == "xx" {
+		return nil, opensubtitles.ErrNoSubtitleFound
+	}
+	return &opensubtitles.SubtitleMatch{SubtitleFile: opensubtitles.SubtitleFile{FileID: 100}}, nil
+}
+
+func (c *fakeClient) DownloadToFile(ctx context.Context, req opensubtitles.DownloadRequest, destPath string, opts opensubtitles.DownloadToFileOptions) (*opensubtitles.DownloadToFileResult, error) {
+	c.downloads = append(c.downloads, destPath)
+	return &opensubtitles.DownloadToFileResult{Path: destPath}, os.WriteFile(destPath, []byte("1\n"), 0o644)
+}
+
+func TestWatcher(t *testing.T) {
+	dir := t.TempDir()
+	existing := filepath.Join(dir, "Old.Movie.2001.mkv")
+	require.NoError(t, os.WriteFile(existing, []byte("old"), 0o644))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "Sample"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Sample", "Heat.1995.sample.mkv"), []byte("s"), 0o644))
+
+	client := &fakeClient{}
+	var results []Result
+	w, err := New(client, Options{
+		Dirs:       []string{dir},
+		Languages:  []opensubtitles.LanguageCode{"en", "xx"},
+		SettleTime: time.Minute,
+		OnResult:   func(r Result) { results = append(results, r) },
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	w.now = func() time.Time { return now }
+	for _, path := range w.videos() {
+		w.done[path] = true // As Run does without ProcessExisting
+	}
+
+	video := filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.mkv")
+	require.NoError(t, os.WriteFile(video, []byte("partial"), 0o644))
+	w.poll(context.Background())
+	assert.Empty(t, client.queries, "new files wait to settle")
+
+	// Still being copied: the size changes, so the settle time starts again.
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, os.WriteFile(video, []byte("partial, now complete"), 0o644))
+	w.poll(context.Background())
+	assert.Empty(t, client.queries)
+
+	now = now.Add(2 * time.Minute)
+	w.poll(context.Background())
+	require.Len(t, client.queries, 2)
+	assert.Equal(t, "Heat", client.queries[0].Query)
+	assert.Equal(t, 1995, client.queries[0].Year)
+	assert.Equal(t, "Heat.1995.1080p.BluRay.x264-GROUP", client.queries[0].ReleaseName)
+	assert.Equal(t, []opensubtitles.LanguageCode{"en", "xx"}, client.languages)
+	assert.Equal(t, []string{filepath.Join(dir, "Heat.1995.1080p.BluRay.x264-GROUP.en.srt")}, client.downloads)
+
+	require.Len(t, results, 2)
+	assert.Equal(t, video, results[0].VideoPath)
+	assert.NotNil(t, results[0].Match)
+	assert.NoError(t, results[0].Err)
+	assert.ErrorIs(t, results[1].Err, opensubtitles.ErrNoSubtitleFound)
+
+	// Processed languages are not searched again; the failed one is retried later.
+	now = now.Add(time.Hour)
+	w.poll(context.Background())
+	assert.Len(t, client.queries, 3)
+	assert.Equal(t, opensubtitles.LanguageCode("xx"), client.languages[2])
+	assert.Len(t, client.downloads, 1)
+}
+
+func TestWatcherRetriesFailedVideos(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "Heat.1995.mkv")
+	require.NoError(t, os.WriteFile(video, []byte("video"), 0o644))
+
+	client := &fakeClient{errs: []error{errors.New("503 service unavailable"), opensubtitles.ErrQuotaExceeded}}
+	var results []Result
+	w, err := New(client, Options{
+		Dirs:          []string{dir},
+		Languages:     []opensubtitles.LanguageCode{"en"},
+		SettleTime:    time.Minute,
+		RetryInterval: 10 * time.Minute,
+		OnResult:      func(r Result) { results = append(results, r) },
+	})
+	require.NoError(t, err)
+	now := time.Now()
+	w.now = func() time.Time { return now }
+
+	w.poll(context.Background())
+	now = now.Add(2 * time.Minute)
+	w.poll(context.Background())
+	require.Len(t, client.queries, 1)
+	require.Error(t, results[0].Err)
+
+	now = now.Add(5 * time.Minute)
+	w.poll(context.Background())
+	assert.Len(t, client.queries, 1, "waits for RetryInterval")
+
+	now = now.Add(5 * time.Minute)
+	w.poll(context.Background())
+	require.Len(t, client.queries, 2)
+	assert.ErrorIs(t, results[1].Err, opensubtitles.ErrQuotaExceeded)
+
+	now = now.Add(10 * time.Minute)
+	w.poll(context.Background())
+	assert.Len(t, client.queries, 2, "the wait doubles after every failure")
+
+	now = now.Add(10 * time.Minute)
+	w.poll(context.Background())
+	require.Len(t, client.queries, 3)
+	assert.NoError(t, results[2].Err)
+	assert.Equal(t, []string{filepath.Join(dir, "Heat.1995.en.srt")}, client.downloads)
+
+	now = now.Add(24 * time.Hour)
+	w.poll(context.Background())
+	assert.Len(t, client.queries, 3, "done once every language succeeded")
+}
+
+func TestWatcherSkipsExistingSidecars(t *testing.T) {
+	dir := t.TempDir()
+	video := filepath.Join(dir, "Heat.1995.mkv")
+	require.NoError(t, os.WriteFile(video, []byte("video"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Heat (1995).en.srt"), []byte("1\n"), 0o644))
+
+	client := &fakeClient{}
+	var results []Result
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan string)
+	w, err := New(client, Options{
+		Dirs:            []string{dir},
+		Languages:       []opensubtitles.LanguageCode{"en"},
+		Template:        "{title} ({year}).{lang}.srt",
+		SettleTime:      time.Nanosecond,
+		PollInterval:    time.Hour,
+		ProcessExisting: true,
+		Changes:         changes,
+		OnResult: func(r Result) {
+			results = append(results, r)
+			cancel()
+		},
+	})
+	require.NoError(t, err)
+
+	done := make(chan error)
+	go func() { done <- w.Run(ctx) }()
+	changes <- video // The first poll only records the file; this one processes it.
+	assert.ErrorIs(t, <-done, context.Canceled)
+	require.Len(t, results, 1)
+	assert.True(t, results[0].Skipped)
+	assert.Empty(t, client.queries)
+}
+
+func TestOptionsFromEnv(t *testing.T) {
+	env := map[string]string{
+		"OPENSUBTITLES_WATCH_DIRS":           "/media/movies, /media/tv",
+		"OPENSUBTITLES_LANGUAGES":            "en,el",
+		"OPENSUBTITLES_WATCH_POLL_INTERVAL":  "1m",
+		"OPENSUBTITLES_WATCH_MIN_VIDEO_SIZE": "1000",
+	}
+	l := envconfig.NewWithLookup("", func(k string) (string, bool) { v, ok := env[k]; return v, ok })
+
+	opts := OptionsFromEnv(l)
+	require.NoError(t, l.Err())
+	assert.Equal(t, Options{
+		Dirs:         []string{"/media/movies", "/media/tv"},
+		Languages:    []opensubtitles.LanguageCode{"en", "el"},
+		PollInterval: time.Minute,
+		MinVideoSize: 1000,
+	}, opts)
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(&fakeClient{}, Options{Languages: []opensubtitles.LanguageCode{"en"}})
+	assert.ErrorIs(t, err, ErrNoDirs)
+	_, err = New(&fakeClient{}, Options{Dirs: []string{"."}})
+	assert.ErrorContains(t, err, "no languages")
+	_, err = New(&fakeClient{}, Options{Dirs: []string{"."}, Languages: []opensubtitles.LanguageCode{"en"}, Template: "{name}.srt"})
+	assert.ErrorIs(t, err, naming.ErrInvalidTemplate)
+}